@@ -0,0 +1,146 @@
+package external_api
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"gigo-core/gigo/api/external_api/core"
+
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/network"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func (s *HTTPServer) GetAchievements(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "get-achievements-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUser := r.Context().Value(CtxKeyUser)
+
+	callingUsername := network.GetRequestIP(r)
+	callingId := network.GetRequestIP(r)
+	var callingIdInt int64
+	if callingUser != nil {
+		callingUsername = callingUser.(*models.User).UserName
+		callingId = strconv.FormatInt(callingUser.(*models.User).ID, 10)
+		callingIdInt = callingUser.(*models.User).ID
+	}
+
+	// attempt to load JSON from request body
+	reqJson := s.jsonRequest(w, r, "GetAchievements", false, callingUsername, callingIdInt)
+	if reqJson == nil {
+		return
+	}
+
+	// check if this is a test
+	if val, ok := reqJson["test"]; ok && (val == true || val == "true") {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "GetAchievements", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+		return
+	}
+
+	// execute core function logic
+	res, err := core.GetAchievements(ctx, s.tiDB)
+	if err != nil {
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "GetAchievements core failed", r.URL.Path, "GetAchievements", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, http.StatusInternalServerError, responseMessage, err)
+		// exit
+		return
+	}
+
+	parentSpan.AddEvent(
+		"get-achievements",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUsername),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "GetAchievements", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+}
+
+func (s *HTTPServer) GetUserAchievements(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "get-user-achievements-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUser := r.Context().Value(CtxKeyUser)
+
+	callingUsername := network.GetRequestIP(r)
+	callingId := network.GetRequestIP(r)
+	var callingIdInt int64
+	if callingUser != nil {
+		callingUsername = callingUser.(*models.User).UserName
+		callingId = strconv.FormatInt(callingUser.(*models.User).ID, 10)
+		callingIdInt = callingUser.(*models.User).ID
+	}
+
+	// attempt to load JSON from request body
+	reqJson := s.jsonRequest(w, r, "GetUserAchievements", false, callingUsername, callingIdInt)
+	if reqJson == nil {
+		return
+	}
+
+	// attempt to load the id of the user whose achievements are being viewed
+	rawUserId, ok := s.loadValue(w, r, reqJson, "GetUserAchievements", "user_id", reflect.String, nil, true, callingUsername, callingId)
+	if !ok {
+		return
+	}
+
+	// default to the calling user
+	userId := callingIdInt
+	if rawUserId != nil {
+		var err error
+		userId, err = strconv.ParseInt(rawUserId.(string), 10, 64)
+		if err != nil {
+			s.handleError(w, fmt.Sprintf("failed to parse user id string to integer: %s", rawUserId.(string)), r.URL.Path, "GetUserAchievements", r.Method, r.Context().Value(CtxKeyRequestID),
+				network.GetRequestIP(r), callingUsername, callingId, http.StatusUnprocessableEntity, "invalid user id", err)
+			return
+		}
+	} else if callingUser == nil {
+		s.handleError(w, "calling user missing from context", r.URL.Path, "GetUserAchievements", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, http.StatusUnprocessableEntity, "user_id is required when logged out", nil)
+		return
+	}
+
+	// check if this is a test
+	if val, ok := reqJson["test"]; ok && (val == true || val == "true") {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "GetUserAchievements", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+		return
+	}
+
+	// execute core function logic
+	res, err := core.GetUserAchievements(ctx, s.tiDB, userId)
+	if err != nil {
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "GetUserAchievements core failed", r.URL.Path, "GetUserAchievements", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, http.StatusInternalServerError, responseMessage, err)
+		// exit
+		return
+	}
+
+	parentSpan.AddEvent(
+		"get-user-achievements",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUsername),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "GetUserAchievements", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+}
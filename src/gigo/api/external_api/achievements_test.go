@@ -0,0 +1,109 @@
+package external_api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestHTTPServer_GetAchievements(t *testing.T) {
+	body := bytes.NewReader([]byte(`{"test":true}`))
+	req, err := http.NewRequest("POST", "http://localhost:1818/api/achievements/get", body)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_GetAchievements failed\n    Error: %v", err)
+		return
+	}
+
+	req.AddCookie(&http.Cookie{
+		Name:  "gigoAuthToken",
+		Value: testUserAuth,
+	})
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_GetAchievements failed\n    Error: %v", err)
+		return
+	}
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		fmt.Println(string(body))
+		fmt.Println(res.StatusCode)
+		t.Error("\nTestHTTPServer_GetAchievements failed\n    Error: incorrect response code")
+		return
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Error("\nTestHTTPServer_GetAchievements failed\n    Error: ", err)
+		return
+	}
+
+	var resJson map[string]interface{}
+	err = json.Unmarshal(resBody, &resJson)
+	if err != nil {
+		t.Error("\nTestHTTPServer_GetAchievements failed\n    Error: ", err)
+		return
+	}
+
+	expectedJson := map[string]interface{}{}
+	if !reflect.DeepEqual(resJson, expectedJson) {
+		t.Error("\nTestHTTPServer_GetAchievements failed\n    Error: response JSON does not match expected JSON")
+		return
+	}
+
+	t.Log("\nTestHTTPServer_GetAchievements succeeded")
+}
+
+func TestHTTPServer_GetUserAchievements(t *testing.T) {
+	body := bytes.NewReader([]byte(`{"user_id":"1","test":true}`))
+	req, err := http.NewRequest("POST", "http://localhost:1818/api/achievements/user", body)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_GetUserAchievements failed\n    Error: %v", err)
+		return
+	}
+
+	req.AddCookie(&http.Cookie{
+		Name:  "gigoAuthToken",
+		Value: testUserAuth,
+	})
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_GetUserAchievements failed\n    Error: %v", err)
+		return
+	}
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		fmt.Println(string(body))
+		fmt.Println(res.StatusCode)
+		t.Error("\nTestHTTPServer_GetUserAchievements failed\n    Error: incorrect response code")
+		return
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Error("\nTestHTTPServer_GetUserAchievements failed\n    Error: ", err)
+		return
+	}
+
+	var resJson map[string]interface{}
+	err = json.Unmarshal(resBody, &resJson)
+	if err != nil {
+		t.Error("\nTestHTTPServer_GetUserAchievements failed\n    Error: ", err)
+		return
+	}
+
+	expectedJson := map[string]interface{}{}
+	if !reflect.DeepEqual(resJson, expectedJson) {
+		t.Error("\nTestHTTPServer_GetUserAchievements failed\n    Error: response JSON does not match expected JSON")
+		return
+	}
+
+	t.Log("\nTestHTTPServer_GetUserAchievements succeeded")
+}
@@ -38,6 +38,7 @@ import (
 
 	"github.com/buger/jsonparser"
 	"github.com/bwmarrin/snowflake"
+	config2 "github.com/gage-technologies/gigo-lib/config"
 	ti "github.com/gage-technologies/gigo-lib/db"
 	"github.com/gage-technologies/gigo-lib/db/models"
 	"github.com/gage-technologies/gigo-lib/db/spice"
@@ -114,10 +115,23 @@ var hybridRoutes = []*regexp.Regexp{
 	regexp.MustCompile("^/static/user/pfp.*$"),
 	regexp.MustCompile("^/static/posts/t.*$"),
 	regexp.MustCompile("^/api/project/attempts$"),
+	regexp.MustCompile("^/api/project/download/[0-9]+$"),
+	regexp.MustCompile("^/api/attempt/download/[0-9]+$"),
+	regexp.MustCompile("^/api/attempt/compare$"),
+	regexp.MustCompile("^/api/attempt/changes$"),
 	regexp.MustCompile("^/api/project/get$"),
+	regexp.MustCompile("^/api/project/releases$"),
+	regexp.MustCompile("^/api/project/forks$"),
+	regexp.MustCompile("^/api/project/collaborators$"),
+	regexp.MustCompile("^/api/learningPath/get$"),
+	regexp.MustCompile("^/api/learningPath/list$"),
 	regexp.MustCompile("^/api/project/closedAttempts$"),
 	regexp.MustCompile("^/api/discussion/getDiscussions$"),
 	regexp.MustCompile("^/api/user/profilePage$"),
+	regexp.MustCompile("^/api/user/streakPage$"),
+	regexp.MustCompile("^/api/nemesis/history$"),
+	regexp.MustCompile("^/api/achievements/get$"),
+	regexp.MustCompile("^/api/achievements/user$"),
 	regexp.MustCompile("^/api/user/getId$"),
 	regexp.MustCompile("^/api/project/getProjectCode$"),
 	regexp.MustCompile("^/api/discussion/getComments$"),
@@ -125,8 +139,13 @@ var hybridRoutes = []*regexp.Regexp{
 	regexp.MustCompile("^/api/project/getProjectFiles$"),
 	regexp.MustCompile("^/api/discussion/getThreads$"),
 	regexp.MustCompile("^/api/discussion/getThreadReply$"),
+	regexp.MustCompile("^/api/discussion/revisions$"),
+	regexp.MustCompile("^/api/discussion/revisionDiff$"),
+	regexp.MustCompile("^/api/discussion/unanswered$"),
+	regexp.MustCompile("^/api/discussion/tree$"),
 	regexp.MustCompile("^/api/attempt/get$"),
 	regexp.MustCompile("^/api/attempt/getProject$"),
+	regexp.MustCompile("^/api/attempt/grading$"),
 	regexp.MustCompile("^/api/search/tags$"),
 	regexp.MustCompile("^/api/chat/messages$"),
 	regexp.MustCompile("^/api/ephemeral/create$"),
@@ -183,10 +202,19 @@ var cacheEndpoints = []*EndpointCache{
 		Path:         regexp.MustCompile("^/api/discussion/getDiscussions$"),
 		Method:       "POST",
 		TTL:          5 * time.Minute,
-		KeyFields:    []string{"post_id", "skip", "limit"},
+		KeyFields:    []string{"post_id", "skip", "limit", "sort"},
 		UserKey:      true,
 		RefreshOnHit: false,
 	},
+	{
+		Path:         regexp.MustCompile("^/api/discussion/tree$"),
+		Method:       "POST",
+		TTL:          5 * time.Minute,
+		KeyFields:    []string{"discussion_id", "cursor", "sort", "comment_limit", "thread_limit", "reply_limit"},
+		UserKey:      true,
+		RefreshOnHit: false,
+		GroupField:   "discussion_id",
+	},
 	{
 		Path:         regexp.MustCompile("^/api/attempt/getProject$"),
 		Method:       "POST",
@@ -212,6 +240,9 @@ type EndpointCache struct {
 	KeyFields    []string
 	UserKey      bool
 	RefreshOnHit bool
+	// GroupField is a request field used to group the cached responses so
+	// that every response for the same value can be invalidated at once
+	GroupField string
 }
 
 type CachedResponse struct {
@@ -230,6 +261,7 @@ type HTTPServer struct {
 	sf                           *snowflake.Node
 	streakEngine                 *streak.StreakEngine
 	vscClient                    *git.VCSClient
+	giteaConfig                  config2.GiteaConfig
 	lockManager                  *lock.RedLockManager
 	storageEngine                storage.Storage
 	workspaceClient              *ws.WorkspaceClient
@@ -265,6 +297,7 @@ type HTTPServer struct {
 	curatedSecret                string
 	masterKey                    string
 	captchaSecret                string
+	registryCaches               []config.RegistryCacheConfig
 
 	// AGPL: Coder
 	WorkspaceAgentCache *wsconncache.Cache
@@ -300,10 +333,10 @@ type HTTPServer struct {
 //
 //	out            - *HTTPServer, freshly created HTTPServer object with the HTTP API initialized
 func CreateHTTPServer(cfg config.HttpServerConfig, otelServiceName string, tidb *ti.Database, meili *search.MeiliSearchEngine,
-	rdb redis.UniversalClient, sf *snowflake.Node, giteaClient *git.VCSClient, storageEngine storage.Storage,
+	rdb redis.UniversalClient, sf *snowflake.Node, giteaClient *git.VCSClient, giteaConfig config2.GiteaConfig, storageEngine storage.Storage,
 	wsClient *ws.WorkspaceClient, js *mq.JetstreamClient, wsStatusUpdater *utils2.WorkspaceStatusUpdater,
 	accessUrl *url.URL, passwordFilter *utils2.PasswordFilter, githubSecret string, forceCdn bool, cdnKey string, masterKey string, captchaSecret string,
-	whitelistedIpRanges []*net.IPNet, logger logging.Logger) (*HTTPServer, error) {
+	registryCaches []config.RegistryCacheConfig, whitelistedIpRanges []*net.IPNet, logger logging.Logger) (*HTTPServer, error) {
 
 	// create MUX router to enable complex HTTP applications
 	r := mux.NewRouter()
@@ -371,6 +404,7 @@ func CreateHTTPServer(cfg config.HttpServerConfig, otelServiceName string, tidb
 		wsStatusUpdater:              wsStatusUpdater,
 		limiter:                      limiter,
 		vscClient:                    giteaClient,
+		giteaConfig:                  giteaConfig,
 		storageEngine:                storageEngine,
 		gitWebhookSecret:             cfg.GitWebhookSecret,
 		stripeWebhookSecret:          cfg.StripeWebhookSecret,
@@ -393,6 +427,7 @@ func CreateHTTPServer(cfg config.HttpServerConfig, otelServiceName string, tidb
 		curatedSecret:                cfg.CuratedSecret,
 		masterKey:                    masterKey,
 		captchaSecret:                captchaSecret,
+		registryCaches:               registryCaches,
 	}
 
 	// TODO: refine a more conservative CORS policy
@@ -610,6 +645,16 @@ func (s *HTTPServer) jsonResponse(r *http.Request, w http.ResponseWriter, res ma
 			s.logger.Errorf("failed to save json response to redis: %v", err)
 			return
 		}
+
+		// track the cached response in its group so it can be invalidated
+		if cache.GroupField != "" {
+			err = s.addCacheGroupMember(r, cache)
+			if err != nil {
+				// we can only log since we've already written to the response
+				s.logger.Errorf("failed to add cached response to group: %v", err)
+				return
+			}
+		}
 	}
 
 	// log successful function execution
@@ -916,6 +961,56 @@ func (s *HTTPServer) blockNonCDNConnections(next http.Handler) http.Handler {
 	})
 }
 
+// cacheGroupKey formats the key of the redis set that holds the cache keys
+// of the responses of an endpoint that share a group value
+func cacheGroupKey(path string, value string) string {
+	return fmt.Sprintf("httpcache-group:%s:%s", path, value)
+}
+
+// addCacheGroupMember adds the cache key of the request to the group of the
+// value of the group field in the request body
+func (s *HTTPServer) addCacheGroupMember(r *http.Request, cache *EndpointCache) error {
+	bufferI := r.Context().Value(CtxKeyBodyBuffer)
+	if bufferI == nil {
+		return fmt.Errorf("missing request body buffer")
+	}
+
+	value, err := jsonparser.GetString(bufferI.(*bytes.Buffer).Bytes(), cache.GroupField)
+	if err != nil {
+		return fmt.Errorf("failed to parse group field %q: %v", cache.GroupField, err)
+	}
+
+	groupKey := cacheGroupKey(r.URL.Path, value)
+	pipe := s.rdb.TxPipeline()
+	pipe.SAdd(r.Context(), groupKey, r.Context().Value(CtxKeyCacheKey).(string))
+	// the group only needs to live as long as the newest response in it
+	pipe.Expire(r.Context(), groupKey, cache.TTL)
+	_, err = pipe.Exec(r.Context())
+	return err
+}
+
+// invalidateCacheGroup removes every cached response of an endpoint that was
+// cached for the group value
+func (s *HTTPServer) invalidateCacheGroup(ctx context.Context, path string, value string) error {
+	groupKey := cacheGroupKey(path, value)
+	keys, err := s.rdb.SMembers(ctx, groupKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to load cache group: %v", err)
+	}
+
+	// delete the keys one at a time since they may live on different
+	// nodes of a redis cluster
+	pipe := s.rdb.Pipeline()
+	for _, key := range append(keys, groupKey) {
+		pipe.Del(ctx, key)
+	}
+	_, err = pipe.Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete cache group: %v", err)
+	}
+	return nil
+}
+
 // Middleware helper function used to perform automatic caching of requests
 func (s *HTTPServer) autoCache(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -969,6 +1064,10 @@ func (s *HTTPServer) autoCache(next http.Handler) http.Handler {
 			// use jsonparser to pull the keys from the body
 			for _, field := range endpoint.KeyFields {
 				value, _, _, err := jsonparser.Get(body, field)
+				// optional fields that were omitted share the key of an empty value
+				if err == jsonparser.KeyPathNotFoundError {
+					value, err = nil, nil
+				}
 				if err != nil {
 					s.handleError(w, "failed to parse request body", r.URL.Path, "autoCache", r.Method, r.Context().Value(CtxKeyRequestID),
 						network.GetRequestIP(r), username, fmt.Sprintf("%d", userId), http.StatusInternalServerError, "internal server error", err)
@@ -1677,6 +1776,7 @@ func (s *HTTPServer) linkAPI() {
 	s.router.HandleFunc("/api/home/recommended", s.RecommendedProjectsHome).Methods("POST")
 	s.router.HandleFunc("/api/home/following", s.RecommendedProjectsHome).Methods("POST")
 	s.router.HandleFunc("/api/home/top", s.TopRecommendations).Methods("POST")
+	s.router.HandleFunc("/api/home/paths", s.ContinueLearningPathsHome).Methods("POST")
 	s.router.HandleFunc("/api/following/feed", s.FeedPage).Methods("POST")
 	s.router.HandleFunc("/api/active/pastWeek", s.PastWeekActive).Methods("POST")
 	s.router.HandleFunc("/api/active/challenging", s.MostChallengingActive).Methods("POST")
@@ -1684,14 +1784,19 @@ func (s *HTTPServer) linkAPI() {
 	s.router.HandleFunc("/api/project/get", s.ProjectInformation).Methods("POST")
 	s.router.HandleFunc("/api/project/attempts", s.ProjectAttempts).Methods("POST")
 	s.router.HandleFunc("/api/project/create", s.CreateProject).Methods("POST")
+	s.router.HandleFunc("/api/project/import/upload", s.UploadProjectImport).Methods("POST")
 	s.router.HandleFunc("/api/project/delete", s.DeleteProject).Methods("POST")
 	s.router.HandleFunc("/api/project/publish", s.PublishProject).Methods("POST")
 	s.router.HandleFunc("/api/recommendation/top", s.TopRecommendation).Methods("POST")
 	s.router.HandleFunc("/api/attempt/get", s.AttemptInformation).Methods("POST")
 	s.router.HandleFunc("/api/attempt/getProject", s.ProjectAttemptInformation).Methods("POST")
 	s.router.HandleFunc("/api/attempt/code", s.GetAttemptCode).Methods("POST")
+	s.router.HandleFunc("/api/attempt/compare", s.CompareAttempt).Methods("POST")
+	s.router.HandleFunc("/api/attempt/changes", s.AttemptChanges).Methods("POST")
 	s.router.HandleFunc("/api/attempt/closeAttempt", s.CloseAttempt).Methods("POST")
 	s.router.HandleFunc("/api/attempt/markSuccess", s.MarkSuccess).Methods("POST")
+	s.router.HandleFunc("/api/attempt/grade", s.SubmitAttemptGrading).Methods("POST")
+	s.router.HandleFunc("/api/attempt/grading", s.GetAttemptGrading).Methods("POST")
 	s.router.HandleFunc("/api/recommendation/attempt", s.RecommendByAttempt).Methods("POST")
 	s.router.HandleFunc("/api/recommendation/harder", s.HarderRecommendation).Methods("POST")
 	s.router.HandleFunc("/api/discussion/getDiscussions", s.GetDiscussions).Methods("POST")
@@ -1703,6 +1808,21 @@ func (s *HTTPServer) linkAPI() {
 	s.router.HandleFunc("/api/discussion/createThreadComment", s.CreateThreadComment).Methods("POST")
 	s.router.HandleFunc("/api/discussion/createThreadReply", s.CreateThreadReply).Methods("POST")
 	s.router.HandleFunc("/api/discussion/editDiscussions", s.EditDiscussions).Methods("POST")
+	s.router.HandleFunc("/api/discussion/revisions", s.GetDiscussionRevisions).Methods("POST")
+	s.router.HandleFunc("/api/discussion/revisionDiff", s.DiffDiscussionRevisions).Methods("POST")
+	s.router.HandleFunc("/api/discussion/deleteRevision", s.DeleteDiscussionRevision).Methods("POST")
+	s.router.HandleFunc("/api/discussion/acceptAnswer", s.AcceptDiscussionAnswer).Methods("POST")
+	s.router.HandleFunc("/api/discussion/unacceptAnswer", s.UnacceptDiscussionAnswer).Methods("POST")
+	s.router.HandleFunc("/api/discussion/unanswered", s.GetUnansweredDiscussions).Methods("POST")
+	s.router.HandleFunc("/api/discussion/tree", s.GetDiscussionTree).Methods("POST")
+	s.router.HandleFunc("/api/discussion/watch", s.WatchDiscussion).Methods("POST")
+	s.router.HandleFunc("/api/discussion/unwatch", s.UnwatchDiscussion).Methods("POST")
+	s.router.HandleFunc("/api/discussion/watching", s.GetDiscussionWatchStatus).Methods("POST")
+	s.router.HandleFunc("/api/discussion/delete", s.DeleteDiscussionContent).Methods("POST")
+	s.router.HandleFunc("/api/discussion/hide", s.HideDiscussionContent).Methods("POST")
+	s.router.HandleFunc("/api/discussion/restore", s.RestoreDiscussionContent).Methods("POST")
+	s.router.HandleFunc("/api/discussion/lock", s.LockDiscussion).Methods("POST")
+	s.router.HandleFunc("/api/discussion/unlock", s.UnlockDiscussion).Methods("POST")
 	s.router.HandleFunc("/api/discussion/addCoffee", s.AddDiscussionCoffee).Methods("POST")
 	s.router.HandleFunc("/api/discussion/removeCoffee", s.RemoveDiscussionCoffee).Methods("POST")
 	s.router.HandleFunc("/api/user/changeEmail", s.ChangeEmail).Methods("POST")
@@ -1718,6 +1838,8 @@ func (s *HTTPServer) linkAPI() {
 	s.router.HandleFunc("/api/project/getProjectCode", s.GetProjectCode).Methods("POST")
 	s.router.HandleFunc("/api/project/getProjectFiles", s.GetProjectFile).Methods("POST")
 	s.router.HandleFunc("/api/project/getProjectDirectories", s.GetProjectDirectories).Methods("POST")
+	s.router.HandleFunc("/api/project/download/{id:[0-9]+}", s.DownloadRepoArchive).Methods("GET")
+	s.router.HandleFunc("/api/attempt/download/{id:[0-9]+}", s.DownloadRepoArchive).Methods("GET")
 	s.router.HandleFunc("/api/project/config", s.GetConfig).Methods("POST")
 	s.router.HandleFunc("/api/project/editConfig", s.EditConfig).Methods("POST")
 	s.router.HandleFunc("/api/project/confirmEditConfig", s.ConfirmEditConfig).Methods("POST")
@@ -1762,6 +1884,12 @@ func (s *HTTPServer) linkAPI() {
 	s.router.HandleFunc("/api/workspace/config/update", s.UpdateWorkspaceConfig).Methods("POST")
 	s.router.HandleFunc("/api/workspace/config/get", s.GetUserWorkspaceSettings).Methods("POST")
 	s.router.HandleFunc("/api/workspace/config/get", s.GetWorkspaceConfig).Methods("POST")
+	s.router.HandleFunc("/api/workspace/config/revisions", s.ListWorkspaceConfigRevisions).Methods("POST")
+	s.router.HandleFunc("/api/workspace/config/diff", s.DiffWorkspaceConfigRevisions).Methods("POST")
+	s.router.HandleFunc("/api/workspace/config/rollback", s.RollbackWorkspaceConfig).Methods("POST")
+	s.router.HandleFunc("/api/workspace/config/lint", s.LintWorkspaceConfig).Methods("POST")
+	s.router.HandleFunc("/api/workspace/config/verify", s.VerifyWorkspaceTemplate).Methods("POST")
+	s.router.HandleFunc("/api/project/workspaceConfigRevision", s.SetPostWorkspaceConfigRevision).Methods("POST")
 	s.router.HandleFunc("/api/editDescription", s.EditDescription).Methods("POST")
 	s.router.HandleFunc("/api/attempt/start", s.StartAttempt).Methods("POST")
 	s.router.HandleFunc("/api/project/closedAttempts", s.GetClosedAttempts).Methods("POST")
@@ -1769,6 +1897,34 @@ func (s *HTTPServer) linkAPI() {
 	s.router.HandleFunc("/api/user/getId", s.GetUserID).Methods("POST")
 	s.router.HandleFunc("/api/user/updateAvatar", s.UpdateAvatarSettings).Methods("POST")
 	s.router.HandleFunc("/api/user/updateWorkspace", s.SetUserWorkspaceSettings).Methods("POST")
+	s.router.HandleFunc("/api/user/privacy/get", s.GetUserPrivacySettings).Methods("POST")
+	s.router.HandleFunc("/api/user/privacy/update", s.SetUserPrivacySettings).Methods("POST")
+	s.router.HandleFunc("/api/achievements/get", s.GetAchievements).Methods("POST")
+	s.router.HandleFunc("/api/achievements/user", s.GetUserAchievements).Methods("POST")
+	s.router.HandleFunc("/api/project/releases", s.GetProjectReleases).Methods("POST")
+	s.router.HandleFunc("/api/project/release/create", s.CreateProjectRelease).Methods("POST")
+	s.router.HandleFunc("/api/attempt/upgradeRelease", s.UpgradeAttemptRelease).Methods("POST")
+	s.router.HandleFunc("/api/project/fork", s.ForkProject).Methods("POST")
+	s.router.HandleFunc("/api/project/forks", s.GetProjectForks).Methods("POST")
+	s.router.HandleFunc("/api/project/collaborators", s.GetProjectCollaborators).Methods("POST")
+	s.router.HandleFunc("/api/project/collaborators/invite", s.InviteCollaborator).Methods("POST")
+	s.router.HandleFunc("/api/project/collaborators/accept", s.AcceptCollaboratorInvite).Methods("POST")
+	s.router.HandleFunc("/api/project/collaborators/remove", s.RemoveCollaborator).Methods("POST")
+	s.router.HandleFunc("/api/project/shareLinks", s.GetShareLinks).Methods("POST")
+	s.router.HandleFunc("/api/project/shareLinks/create", s.CreateShareLink).Methods("POST")
+	s.router.HandleFunc("/api/project/shareLinks/revoke", s.RevokeShareLink).Methods("POST")
+	s.router.HandleFunc("/api/project/analytics", s.GetPostAnalytics).Methods("POST")
+	s.router.HandleFunc("/api/report/content", s.CreateContentReport).Methods("POST")
+	s.router.HandleFunc("/api/moderation/queue", s.GetModerationQueue).Methods("POST")
+	s.router.HandleFunc("/api/moderation/report", s.GetContentReport).Methods("POST")
+	s.router.HandleFunc("/api/moderation/assign", s.AssignContentReport).Methods("POST")
+	s.router.HandleFunc("/api/moderation/note", s.AddContentReportNote).Methods("POST")
+	s.router.HandleFunc("/api/moderation/resolve", s.ResolveContentReport).Methods("POST")
+	s.router.HandleFunc("/api/learningPath/create", s.CreateLearningPath).Methods("POST")
+	s.router.HandleFunc("/api/learningPath/edit", s.EditLearningPath).Methods("POST")
+	s.router.HandleFunc("/api/learningPath/delete", s.DeleteLearningPath).Methods("POST")
+	s.router.HandleFunc("/api/learningPath/get", s.GetLearningPath).Methods("POST")
+	s.router.HandleFunc("/api/learningPath/list", s.ListLearningPaths).Methods("POST")
 	s.router.HandleFunc("/api/user/updateExclusiveAgreement", s.UpdateUserExclusiveAgreement).Methods("POST")
 	s.router.HandleFunc("/api/user/updateHolidayPreference", s.UpdateHolidayPreference).Methods("POST")
 	s.router.HandleFunc("/api/nemesis/declare", s.DeclareNemesis).Methods("POST")
@@ -0,0 +1,115 @@
+package external_api
+
+import (
+	"errors"
+	"fmt"
+	"gigo-core/gigo/api/external_api/core"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/network"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CompareAttempt returns the per-file unified diff between an attempt and the
+// commit of the project it was started from
+func (s *HTTPServer) CompareAttempt(w http.ResponseWriter, r *http.Request) {
+	s.compareAttempt(w, r, "CompareAttempt", true)
+}
+
+// AttemptChanges returns the summary of files changed by an attempt
+func (s *HTTPServer) AttemptChanges(w http.ResponseWriter, r *http.Request) {
+	s.compareAttempt(w, r, "AttemptChanges", false)
+}
+
+func (s *HTTPServer) compareAttempt(w http.ResponseWriter, r *http.Request, method string, includeHunks bool) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "compare-attempt-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUser := r.Context().Value(CtxKeyUser)
+
+	var finalCallingUser *models.User
+	callingUsername := network.GetRequestIP(r)
+	callingId := network.GetRequestIP(r)
+	var callingIdInt int64
+	if callingUser != nil {
+		finalCallingUser = callingUser.(*models.User)
+		callingUsername = callingUser.(*models.User).UserName
+		callingId = strconv.FormatInt(callingUser.(*models.User).ID, 10)
+		callingIdInt = callingUser.(*models.User).ID
+	}
+
+	// attempt to load JSON from request body
+	reqJson := s.jsonRequest(w, r, method, false, callingUsername, callingIdInt)
+	if reqJson == nil {
+		return
+	}
+
+	// attempt to load attempt id from body
+	attemptIdI, ok := s.loadValue(w, r, reqJson, method, "attempt_id", reflect.String, nil, false, callingUsername, callingId)
+	if attemptIdI == nil || !ok {
+		return
+	}
+
+	// parse attempt id to integer
+	attemptId, err := strconv.ParseInt(attemptIdI.(string), 10, 64)
+	if err != nil {
+		s.handleError(w, fmt.Sprintf("failed to parse attempt id string to integer: %s", attemptIdI.(string)), r.URL.Path, method, r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, http.StatusUnprocessableEntity, "invalid attempt id", err)
+		return
+	}
+
+	// attempt to load the optional attempt ref from body
+	refI, ok := s.loadValue(w, r, reqJson, method, "ref", reflect.String, nil, true, callingUsername, callingId)
+	if !ok {
+		return
+	}
+	ref := "main"
+	if refI != nil {
+		ref = refI.(string)
+	}
+
+	// check if this is a test
+	if val, ok := reqJson["test"]; ok && (val == true || val == "true") {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, method, r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+		return
+	}
+
+	// execute core function logic
+	res, err := core.CompareAttempt(ctx, s.tiDB, s.vscClient, finalCallingUser, attemptId, ref, includeHunks)
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			s.handleError(w, method+" not found", r.URL.Path, method, r.Method, r.Context().Value(CtxKeyRequestID),
+				network.GetRequestIP(r), callingUsername, callingId, http.StatusNotFound, "not found", err)
+			return
+		}
+		if errors.Is(err, core.ErrForbidden) {
+			s.handleError(w, method+" forbidden", r.URL.Path, method, r.Method, r.Context().Value(CtxKeyRequestID),
+				network.GetRequestIP(r), callingUsername, callingId, http.StatusForbidden, selectErrorResponse("this project must be purchased to view attempts", res), err)
+			return
+		}
+		// handle error internally
+		s.handleError(w, method+" core failed", r.URL.Path, method, r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, http.StatusInternalServerError, "internal server error occurred", err)
+		// exit
+		return
+	}
+
+	parentSpan.AddEvent(
+		"compare-attempt",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUsername),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, method, r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+}
@@ -0,0 +1,440 @@
+package external_api
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gigo-core/gigo/api/external_api/core"
+	"gigo-core/gigo/api/ws"
+
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/network"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// gradingProvisionTimeout bounds the time spent provisioning and
+	// initializing a grading workspace before the tests start
+	gradingProvisionTimeout = time.Minute * 15
+
+	// maxGradingCommandOutput bounds the output retained from a test command
+	maxGradingCommandOutput = 4 * 1024 * 1024
+)
+
+// gradingOutputBuffer collects the combined output of a test command while
+// discarding anything beyond maxGradingCommandOutput
+type gradingOutputBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *gradingOutputBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if remaining := maxGradingCommandOutput - b.buf.Len(); remaining > 0 {
+		if len(p) > remaining {
+			b.buf.Write(p[:remaining])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (b *gradingOutputBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// gradingShellQuote quotes a value for use in a shell command
+func gradingShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// restoreGradingTestFiles writes the challenge's test files into the grading
+// workspace and removes the test files that only exist in the attempt. The
+// files are streamed to the workspace as a tar archive over a single session.
+func restoreGradingTestFiles(sshClient *ssh.Client, job *core.AttemptGradingJob) error {
+	if len(job.TestFiles) == 0 && len(job.StaleTestFiles) == 0 {
+		return nil
+	}
+
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	for _, file := range job.TestFiles {
+		mode := int64(0644)
+		if file.Executable {
+			mode = 0755
+		}
+		err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     file.Path,
+			Mode:     mode,
+			Size:     int64(len(file.Content)),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write test file header %s: %v", file.Path, err)
+		}
+		_, err = tw.Write(file.Content)
+		if err != nil {
+			return fmt.Errorf("failed to write test file %s: %v", file.Path, err)
+		}
+	}
+	err := tw.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close test file archive: %v", err)
+	}
+
+	root := job.WorkingDirectory
+	if root == "" {
+		root = "."
+	}
+
+	command := fmt.Sprintf("cd %s", gradingShellQuote(root))
+	if len(job.StaleTestFiles) > 0 {
+		quoted := make([]string, 0, len(job.StaleTestFiles))
+		for _, p := range job.StaleTestFiles {
+			quoted = append(quoted, gradingShellQuote(p))
+		}
+		command += " && rm -f -- " + strings.Join(quoted, " ")
+	}
+	command += " && tar -xf -"
+
+	session, err := sshClient.NewSession()
+	if err != nil {
+		return fmt.Errorf("failed to open ssh session in grading workspace: %v", err)
+	}
+	defer session.Close()
+
+	var output gradingOutputBuffer
+	session.Stdin = &archive
+	session.Stdout = &output
+	session.Stderr = &output
+
+	err = session.Run(command)
+	if err != nil {
+		return fmt.Errorf("failed to restore challenge test files: %v\n    output: %s", err, output.String())
+	}
+
+	return nil
+}
+
+// executeAttemptGrading provisions the grading workspace via the remote
+// provisioner, waits for it to initialize and runs the test command through
+// the workspace agent returning the output and exit code of the command
+func (s *HTTPServer) executeAttemptGrading(ctx context.Context, job *core.AttemptGradingJob) (string, int, error) {
+	newAgent, err := s.workspaceClient.CreateWorkspace(ctx, ws.CreateWorkspaceOptions{
+		WorkspaceID: job.WorkspaceID,
+		OwnerID:     job.Owner.ID,
+		OwnerEmail:  job.Owner.Email,
+		OwnerName:   job.Owner.UserName,
+		Disk:        job.Disk,
+		CPU:         job.CPU,
+		Memory:      job.Memory,
+		Container:   job.Container,
+		AccessUrl:   s.accessUrl.String(),
+	})
+	if err != nil {
+		return "", -1, fmt.Errorf("failed to provision grading workspace: %v", err)
+	}
+
+	err = core.RegisterGradingAgent(ctx, s.tiDB, job, newAgent.ID, newAgent.Token)
+	if err != nil {
+		return "", -1, err
+	}
+
+	// wait for the agent to finish initializing the workspace
+	ticker := time.NewTicker(time.Second * 5)
+	defer ticker.Stop()
+	for {
+		state, initState, err := core.GetGradingWorkspaceState(ctx, s.tiDB, job.WorkspaceID)
+		if err != nil {
+			return "", -1, err
+		}
+		if state == models.WorkspaceFailed {
+			return "", -1, fmt.Errorf("grading workspace failed to initialize")
+		}
+		if initState == models.WorkspaceInitCompleted {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", -1, fmt.Errorf("timed out waiting for grading workspace to initialize")
+		case <-ticker.C:
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+	if err != nil {
+		return "", -1, fmt.Errorf("failed to create agent request: %v", err)
+	}
+
+	conn, release, err := s.WorkspaceAgentCache.Acquire(req, newAgent.ID)
+	if err != nil {
+		return "", -1, fmt.Errorf("failed to acquire connection to grading workspace agent: %v", err)
+	}
+	defer release()
+
+	reachableCtx, cancelReachableCtx := context.WithTimeout(ctx, time.Second*30)
+	reachable := conn.AwaitReachable(reachableCtx)
+	cancelReachableCtx()
+	if !reachable {
+		return "", -1, fmt.Errorf("grading workspace agent is not reachable")
+	}
+
+	sshClient, err := conn.SSHClient(ctx)
+	if err != nil {
+		return "", -1, fmt.Errorf("failed to open ssh connection to grading workspace: %v", err)
+	}
+	defer sshClient.Close()
+
+	// replace the attempt's copies of the tests with the challenge's before running them
+	err = restoreGradingTestFiles(sshClient, job)
+	if err != nil {
+		return "", -1, err
+	}
+
+	session, err := sshClient.NewSession()
+	if err != nil {
+		return "", -1, fmt.Errorf("failed to open ssh session in grading workspace: %v", err)
+	}
+	defer session.Close()
+
+	var output gradingOutputBuffer
+	session.Stdout = &output
+	session.Stderr = &output
+
+	command := job.Config.Command
+	if job.WorkingDirectory != "" {
+		command = fmt.Sprintf("cd %s && %s", gradingShellQuote(job.WorkingDirectory), command)
+	}
+
+	timeout := job.Config.TimeoutDuration()
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Run(command)
+	}()
+
+	select {
+	case err = <-done:
+	case <-time.After(timeout):
+		_ = session.Close()
+		return output.String(), -1, fmt.Errorf("tests did not complete within %s", timeout)
+	case <-ctx.Done():
+		_ = session.Close()
+		return output.String(), -1, fmt.Errorf("grading was cancelled")
+	}
+
+	if err != nil {
+		// a non-zero exit is a test failure rather than a grading error
+		var exitErr interface{ ExitStatus() int }
+		if errors.As(err, &exitErr) {
+			return output.String(), exitErr.ExitStatus(), nil
+		}
+		return output.String(), -1, fmt.Errorf("failed to run tests: %v", err)
+	}
+
+	return output.String(), 0, nil
+}
+
+// runAttemptGrading executes a grading job to completion, records its results
+// and destroys the grading workspace
+func (s *HTTPServer) runAttemptGrading(job *core.AttemptGradingJob) {
+	ctx, span := otel.Tracer("gigo-core").Start(context.Background(), "run-attempt-grading")
+	defer span.End()
+
+	runCtx, cancel := context.WithTimeout(ctx, gradingProvisionTimeout+job.Config.TimeoutDuration())
+	output, exitCode, runErr := s.executeAttemptGrading(runCtx, job)
+	cancel()
+	if runErr != nil {
+		s.logger.Warnf("runAttemptGrading (%d): grading of attempt %d errored: %v", job.GradingID, job.AttemptID, runErr)
+	}
+
+	// use fresh contexts so the results are recorded even if the run timed out
+	completeCtx, cancelComplete := context.WithTimeout(ctx, time.Minute)
+	err := core.CompleteAttemptGrading(completeCtx, s.tiDB, s.jetstreamClient, s.rdb, s.sf, job, output, exitCode, runErr, s.logger)
+	cancelComplete()
+	if err != nil {
+		s.logger.Errorf("runAttemptGrading (%d): failed to record grading results: %v", job.GradingID, err)
+	}
+
+	destroyCtx, cancelDestroy := context.WithTimeout(ctx, time.Minute*5)
+	defer cancelDestroy()
+
+	err = s.workspaceClient.DestroyWorkspace(destroyCtx, job.WorkspaceID)
+	if err != nil && !errors.Is(err, ws.ErrWorkspaceNotFound) {
+		// the workspace manager will remove the workspace once it expires
+		s.logger.Warnf("runAttemptGrading (%d): failed to destroy grading workspace %d: %v", job.GradingID, job.WorkspaceID, err)
+		return
+	}
+
+	err = core.ReleaseGradingWorkspace(destroyCtx, s.tiDB, job.WorkspaceID)
+	if err != nil {
+		s.logger.Warnf("runAttemptGrading (%d): failed to release grading workspace %d: %v", job.GradingID, job.WorkspaceID, err)
+	}
+}
+
+func (s *HTTPServer) SubmitAttemptGrading(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "submit-attempt-grading-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	// return if calling user was not retrieved in authentication
+	if callingUserI == nil {
+		s.handleError(w, "calling user missing from context", r.URL.Path, "SubmitAttemptGrading", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), network.GetRequestIP(r), network.GetRequestIP(r), http.StatusInternalServerError, "internal server error occurred", nil)
+		return
+	}
+
+	callingUser := callingUserI.(*models.User)
+	callingId := fmt.Sprintf("%d", callingUser.ID)
+
+	// attempt to load JSON from request body
+	reqJson := s.jsonRequest(w, r, "SubmitAttemptGrading", false, callingUser.UserName, callingUser.ID)
+	if reqJson == nil {
+		return
+	}
+
+	// attempt to load attempt id from body
+	attemptIdI, ok := s.loadValue(w, r, reqJson, "SubmitAttemptGrading", "attempt_id", reflect.String, nil, false, callingUser.UserName, callingId)
+	if attemptIdI == nil || !ok {
+		return
+	}
+
+	// parse attempt id to integer
+	attemptId, err := strconv.ParseInt(attemptIdI.(string), 10, 64)
+	if err != nil {
+		s.handleError(w, fmt.Sprintf("failed to parse attempt id string to integer: %s", attemptIdI.(string)), r.URL.Path, "SubmitAttemptGrading", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusUnprocessableEntity, "invalid attempt id", err)
+		return
+	}
+
+	// check if this is a test
+	if val, ok := reqJson["test"]; ok && (val == true || val == "true") {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "SubmitAttemptGrading", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusOK)
+		return
+	}
+
+	// execute core function logic
+	res, job, err := core.SubmitAttemptGrading(ctx, s.tiDB, s.vscClient, s.sf, callingUser, attemptId)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, core.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "SubmitAttemptGrading core failed", r.URL.Path, "SubmitAttemptGrading", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUser.UserName, callingId, status, responseMessage, err)
+		// exit
+		return
+	}
+
+	// run the grading in the background since provisioning can take minutes
+	s.wg.Go(func() {
+		s.runAttemptGrading(job)
+	})
+
+	parentSpan.AddEvent(
+		"submit-attempt-grading",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUser.UserName),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "SubmitAttemptGrading", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusOK)
+}
+
+func (s *HTTPServer) GetAttemptGrading(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "get-attempt-grading-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUser := r.Context().Value(CtxKeyUser)
+
+	callingUsername := network.GetRequestIP(r)
+	callingId := network.GetRequestIP(r)
+	var callingIdInt int64
+	if callingUser != nil {
+		callingUsername = callingUser.(*models.User).UserName
+		callingId = strconv.FormatInt(callingUser.(*models.User).ID, 10)
+		callingIdInt = callingUser.(*models.User).ID
+	}
+
+	// attempt to load JSON from request body
+	reqJson := s.jsonRequest(w, r, "GetAttemptGrading", false, callingUsername, callingIdInt)
+	if reqJson == nil {
+		return
+	}
+
+	// attempt to load attempt id from body
+	attemptIdI, ok := s.loadValue(w, r, reqJson, "GetAttemptGrading", "attempt_id", reflect.String, nil, false, callingUsername, callingId)
+	if attemptIdI == nil || !ok {
+		return
+	}
+
+	// parse attempt id to integer
+	attemptId, err := strconv.ParseInt(attemptIdI.(string), 10, 64)
+	if err != nil {
+		s.handleError(w, fmt.Sprintf("failed to parse attempt id string to integer: %s", attemptIdI.(string)), r.URL.Path, "GetAttemptGrading", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, http.StatusUnprocessableEntity, "invalid attempt id", err)
+		return
+	}
+
+	// check if this is a test
+	if val, ok := reqJson["test"]; ok && (val == true || val == "true") {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "GetAttemptGrading", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+		return
+	}
+
+	// execute core function logic
+	var caller *models.User
+	if callingUser != nil {
+		caller = callingUser.(*models.User)
+	}
+
+	res, err := core.GetAttemptGrading(ctx, s.tiDB, caller, attemptId)
+	if err != nil {
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "GetAttemptGrading core failed", r.URL.Path, "GetAttemptGrading", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, coreErrorStatus(err), responseMessage, err)
+		// exit
+		return
+	}
+
+	parentSpan.AddEvent(
+		"get-attempt-grading",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUsername),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "GetAttemptGrading", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+}
@@ -1,6 +1,7 @@
 package external_api
 
 import (
+	"errors"
 	"fmt"
 	"gigo-core/gigo/api/external_api/core"
 	"net/http"
@@ -59,13 +60,17 @@ func (s *HTTPServer) ProjectAttemptInformation(w http.ResponseWriter, r *http.Re
 	}
 
 	// execute core function logic
-	res, err := core.ProjectAttemptInformation(ctx, s.tiDB, s.vscClient, attemptId)
+	var caller *models.User
+	if callingUser != nil {
+		caller = callingUser.(*models.User)
+	}
+	res, err := core.ProjectAttemptInformation(ctx, s.tiDB, s.vscClient, caller, attemptId)
 	if err != nil {
 		// select error message dependent on if there was one returned from the function
 		responseMessage := selectErrorResponse("internal server error occurred", map[string]interface{}{"message": err})
 		// handle error internally
 		s.handleError(w, "ProjectAttemptInformation core failed", r.URL.Path, "ProjectAttemptInformation", r.Method, r.Context().Value(CtxKeyRequestID),
-			network.GetRequestIP(r), userName, callingId, http.StatusInternalServerError, responseMessage, err)
+			network.GetRequestIP(r), userName, callingId, coreErrorStatus(err), responseMessage, err)
 		// exit
 		return
 	}
@@ -128,9 +133,42 @@ func (s *HTTPServer) AttemptInformation(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// load the author of the attempt so that their privacy settings can be enforced
+	authorId, err := core.GetAttemptAuthorID(ctx, s.tiDB, attemptId)
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			s.handleError(w, "attempt not found", r.URL.Path, "AttemptInformation", r.Method, r.Context().Value(CtxKeyRequestID),
+				network.GetRequestIP(r), userName, callingId, http.StatusNotFound, "attempt not found", err)
+			return
+		}
+		s.handleError(w, "GetAttemptAuthorID core failed", r.URL.Path, "AttemptInformation", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), userName, callingId, http.StatusInternalServerError, "internal server error occurred", err)
+		return
+	}
+
+	// ensure the caller is permitted to view the attempts of the author
+	var callingUserModel *models.User
+	if callingUser != nil {
+		callingUserModel = callingUser.(*models.User)
+	}
+	access, ok := s.resolvePrivacyAccess(ctx, w, r, "AttemptInformation", callingUserModel, authorId, userName, callingId)
+	if !ok {
+		return
+	}
+	if !access.Attempts {
+		s.handleError(w, "attempt hidden by author privacy settings", r.URL.Path, "AttemptInformation", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), userName, callingId, http.StatusForbidden, "this attempt is private", nil)
+		return
+	}
+
 	// execute core function logic
-	res, err := core.AttemptInformation(ctx, s.tiDB, s.vscClient, attemptId)
+	res, err := core.AttemptInformation(ctx, s.tiDB, s.vscClient, callingUserModel, access, attemptId)
 	if err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			s.handleError(w, "attempt not found", r.URL.Path, "AttemptInformation", r.Method, r.Context().Value(CtxKeyRequestID),
+				network.GetRequestIP(r), userName, callingId, http.StatusNotFound, "attempt not found", err)
+			return
+		}
 		// select error message dependent on if there was one returned from the function
 		responseMessage := selectErrorResponse("internal server error occurred", map[string]interface{}{"message": err})
 		// handle error internally
@@ -201,13 +239,13 @@ func (s *HTTPServer) GetAttemptCode(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// execute core function logic
-	res, err := core.GetAttemptCode(ctx, s.vscClient, callingUser.(*models.User), repo.(string), ref.(string), filepath.(string))
+	res, err := core.GetAttemptCode(ctx, s.tiDB, s.vscClient, callingUser.(*models.User), repo.(string), ref.(string), filepath.(string))
 	if err != nil {
 		// select error message dependent on if there was one returned from the function
-		responseMessage := selectErrorResponse("internal server error occurred", map[string]interface{}{"message": err})
+		responseMessage := selectErrorResponse("internal server error occurred", res)
 		// handle error internally
 		s.handleError(w, "GetAttemptCode core failed", r.URL.Path, "GetAttemptCode", r.Method, r.Context().Value(CtxKeyRequestID),
-			network.GetRequestIP(r), callingUser.(*models.User).UserName, callingId, http.StatusInternalServerError, responseMessage, err)
+			network.GetRequestIP(r), callingUser.(*models.User).UserName, callingId, coreErrorStatus(err), responseMessage, err)
 		// exit
 		return
 	}
@@ -283,7 +321,7 @@ func (s *HTTPServer) EditDescription(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// execute core function logic
-	res, err := core.EditDescription(ctx, attemptId, s.meili, project.(bool), description.(string), s.tiDB)
+	res, err := core.EditDescription(ctx, attemptId, project.(bool), description.(string), s.tiDB)
 	if err != nil {
 		// select error message dependent on if there was one returned from the function
 		responseMessage := selectErrorResponse("internal server error occurred", map[string]interface{}{"message": err})
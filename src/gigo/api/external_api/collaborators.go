@@ -0,0 +1,310 @@
+package external_api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"gigo-core/gigo/api/external_api/core"
+
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/network"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func (s *HTTPServer) InviteCollaborator(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "invite-collaborator-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	// return if calling user was not retrieved in authentication
+	if callingUserI == nil {
+		s.handleError(w, "calling user missing from context", r.URL.Path, "InviteCollaborator", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), network.GetRequestIP(r), network.GetRequestIP(r), http.StatusInternalServerError, "internal server error occurred", nil)
+		return
+	}
+
+	callingUser := callingUserI.(*models.User)
+	callingId := fmt.Sprintf("%d", callingUser.ID)
+
+	// parse and validate request body
+	var req core.InviteCollaboratorRequest
+	if !s.validateRequest(w, r, callingUser, r.Body, &req) {
+		return
+	}
+
+	// check if this is a test
+	if req.Test {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "InviteCollaborator", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusOK)
+		return
+	}
+
+	// validation guarantees that the ids are numeric
+	projectId, _ := strconv.ParseInt(req.ProjectID, 10, 64)
+	userId, _ := strconv.ParseInt(req.UserID, 10, 64)
+
+	// execute core function logic
+	res, err := core.InviteCollaborator(ctx, s.tiDB, s.jetstreamClient, s.sf, callingUser, projectId, userId, req.Role, s.logger)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, core.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "InviteCollaborator core failed", r.URL.Path, "InviteCollaborator", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUser.UserName, callingId, status, responseMessage, err)
+		// exit
+		return
+	}
+
+	parentSpan.AddEvent(
+		"invite-collaborator",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUser.UserName),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "InviteCollaborator", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusOK)
+}
+
+func (s *HTTPServer) AcceptCollaboratorInvite(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "accept-collaborator-invite-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	// return if calling user was not retrieved in authentication
+	if callingUserI == nil {
+		s.handleError(w, "calling user missing from context", r.URL.Path, "AcceptCollaboratorInvite", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), network.GetRequestIP(r), network.GetRequestIP(r), http.StatusInternalServerError, "internal server error occurred", nil)
+		return
+	}
+
+	callingUser := callingUserI.(*models.User)
+	callingId := fmt.Sprintf("%d", callingUser.ID)
+
+	// attempt to load JSON from request body
+	reqJson := s.jsonRequest(w, r, "AcceptCollaboratorInvite", false, callingUser.UserName, callingUser.ID)
+	if reqJson == nil {
+		return
+	}
+
+	// attempt to load project id from body
+	projectIdI, ok := s.loadValue(w, r, reqJson, "AcceptCollaboratorInvite", "project_id", reflect.String, nil, false, callingUser.UserName, callingId)
+	if projectIdI == nil || !ok {
+		return
+	}
+
+	// parse project id to integer
+	projectId, err := strconv.ParseInt(projectIdI.(string), 10, 64)
+	if err != nil {
+		s.handleError(w, fmt.Sprintf("failed to parse project id string to integer: %s", projectIdI.(string)), r.URL.Path, "AcceptCollaboratorInvite", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusUnprocessableEntity, "invalid project id", err)
+		return
+	}
+
+	// check if this is a test
+	if val, ok := reqJson["test"]; ok && (val == true || val == "true") {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "AcceptCollaboratorInvite", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusOK)
+		return
+	}
+
+	// execute core function logic
+	res, err := core.AcceptCollaboratorInvite(ctx, s.tiDB, s.vscClient, callingUser, projectId)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, core.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "AcceptCollaboratorInvite core failed", r.URL.Path, "AcceptCollaboratorInvite", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUser.UserName, callingId, status, responseMessage, err)
+		// exit
+		return
+	}
+
+	parentSpan.AddEvent(
+		"accept-collaborator-invite",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUser.UserName),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "AcceptCollaboratorInvite", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusOK)
+}
+
+func (s *HTTPServer) RemoveCollaborator(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "remove-collaborator-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	// return if calling user was not retrieved in authentication
+	if callingUserI == nil {
+		s.handleError(w, "calling user missing from context", r.URL.Path, "RemoveCollaborator", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), network.GetRequestIP(r), network.GetRequestIP(r), http.StatusInternalServerError, "internal server error occurred", nil)
+		return
+	}
+
+	callingUser := callingUserI.(*models.User)
+	callingId := fmt.Sprintf("%d", callingUser.ID)
+
+	// attempt to load JSON from request body
+	reqJson := s.jsonRequest(w, r, "RemoveCollaborator", false, callingUser.UserName, callingUser.ID)
+	if reqJson == nil {
+		return
+	}
+
+	// attempt to load project id from body
+	projectIdI, ok := s.loadValue(w, r, reqJson, "RemoveCollaborator", "project_id", reflect.String, nil, false, callingUser.UserName, callingId)
+	if projectIdI == nil || !ok {
+		return
+	}
+
+	// parse project id to integer
+	projectId, err := strconv.ParseInt(projectIdI.(string), 10, 64)
+	if err != nil {
+		s.handleError(w, fmt.Sprintf("failed to parse project id string to integer: %s", projectIdI.(string)), r.URL.Path, "RemoveCollaborator", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusUnprocessableEntity, "invalid project id", err)
+		return
+	}
+
+	// attempt to load user id from body
+	userIdI, ok := s.loadValue(w, r, reqJson, "RemoveCollaborator", "user_id", reflect.String, nil, false, callingUser.UserName, callingId)
+	if userIdI == nil || !ok {
+		return
+	}
+
+	// parse user id to integer
+	userId, err := strconv.ParseInt(userIdI.(string), 10, 64)
+	if err != nil {
+		s.handleError(w, fmt.Sprintf("failed to parse user id string to integer: %s", userIdI.(string)), r.URL.Path, "RemoveCollaborator", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusUnprocessableEntity, "invalid user id", err)
+		return
+	}
+
+	// check if this is a test
+	if val, ok := reqJson["test"]; ok && (val == true || val == "true") {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "RemoveCollaborator", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusOK)
+		return
+	}
+
+	// execute core function logic
+	res, err := core.RemoveCollaborator(ctx, s.tiDB, s.vscClient, callingUser, projectId, userId)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, core.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "RemoveCollaborator core failed", r.URL.Path, "RemoveCollaborator", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUser.UserName, callingId, status, responseMessage, err)
+		// exit
+		return
+	}
+
+	parentSpan.AddEvent(
+		"remove-collaborator",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUser.UserName),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "RemoveCollaborator", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusOK)
+}
+
+func (s *HTTPServer) GetProjectCollaborators(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "get-project-collaborators-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	callingUsername := network.GetRequestIP(r)
+	callingId := network.GetRequestIP(r)
+	var callingIdInt int64
+	var callingUser *models.User
+	if callingUserI != nil {
+		callingUser = callingUserI.(*models.User)
+		callingUsername = callingUser.UserName
+		callingId = strconv.FormatInt(callingUser.ID, 10)
+		callingIdInt = callingUser.ID
+	}
+
+	// attempt to load JSON from request body
+	reqJson := s.jsonRequest(w, r, "GetProjectCollaborators", false, callingUsername, callingIdInt)
+	if reqJson == nil {
+		return
+	}
+
+	// attempt to load project id from body
+	projectIdI, ok := s.loadValue(w, r, reqJson, "GetProjectCollaborators", "project_id", reflect.String, nil, false, callingUsername, callingId)
+	if projectIdI == nil || !ok {
+		return
+	}
+
+	// parse project id to integer
+	projectId, err := strconv.ParseInt(projectIdI.(string), 10, 64)
+	if err != nil {
+		s.handleError(w, fmt.Sprintf("failed to parse project id string to integer: %s", projectIdI.(string)), r.URL.Path, "GetProjectCollaborators", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, http.StatusUnprocessableEntity, "invalid project id", err)
+		return
+	}
+
+	// check if this is a test
+	if val, ok := reqJson["test"]; ok && (val == true || val == "true") {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "GetProjectCollaborators", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+		return
+	}
+
+	// execute core function logic
+	res, err := core.GetProjectCollaborators(ctx, s.tiDB, callingUser, projectId)
+	if err != nil {
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "GetProjectCollaborators core failed", r.URL.Path, "GetProjectCollaborators", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, http.StatusInternalServerError, responseMessage, err)
+		// exit
+		return
+	}
+
+	parentSpan.AddEvent(
+		"get-project-collaborators",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUsername),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "GetProjectCollaborators", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+}
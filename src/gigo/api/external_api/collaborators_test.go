@@ -0,0 +1,207 @@
+package external_api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestHTTPServer_InviteCollaborator(t *testing.T) {
+	body := bytes.NewReader([]byte(`{"project_id":"1688617436791701504","user_id":"1688570643273318400","role":0,"test":true}`))
+	req, err := http.NewRequest("POST", "http://localhost:1818/api/project/collaborators/invite", body)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_InviteCollaborator failed\n    Error: %v", err)
+		return
+	}
+
+	req.AddCookie(&http.Cookie{
+		Name:  "gigoAuthToken",
+		Value: testUserAuth,
+	})
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_InviteCollaborator failed\n    Error: %v", err)
+		return
+	}
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		fmt.Println(string(body))
+		fmt.Println(res.StatusCode)
+		t.Error("\nTestHTTPServer_InviteCollaborator failed\n    Error: incorrect response code")
+		return
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Error("\nTestHTTPServer_InviteCollaborator failed\n    Error: ", err)
+		return
+	}
+
+	var resJson map[string]interface{}
+	err = json.Unmarshal(resBody, &resJson)
+	if err != nil {
+		t.Error("\nTestHTTPServer_InviteCollaborator failed\n    Error: ", err)
+		return
+	}
+
+	expectedJson := map[string]interface{}{}
+	if !reflect.DeepEqual(resJson, expectedJson) {
+		t.Error("\nTestHTTPServer_InviteCollaborator failed\n    Error: response JSON does not match expected JSON")
+		return
+	}
+
+	t.Log("\nTestHTTPServer_InviteCollaborator succeeded")
+}
+
+func TestHTTPServer_AcceptCollaboratorInvite(t *testing.T) {
+	body := bytes.NewReader([]byte(`{"project_id":"1688617436791701504","test":true}`))
+	req, err := http.NewRequest("POST", "http://localhost:1818/api/project/collaborators/accept", body)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_AcceptCollaboratorInvite failed\n    Error: %v", err)
+		return
+	}
+
+	req.AddCookie(&http.Cookie{
+		Name:  "gigoAuthToken",
+		Value: testUserAuth,
+	})
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_AcceptCollaboratorInvite failed\n    Error: %v", err)
+		return
+	}
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		fmt.Println(string(body))
+		fmt.Println(res.StatusCode)
+		t.Error("\nTestHTTPServer_AcceptCollaboratorInvite failed\n    Error: incorrect response code")
+		return
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Error("\nTestHTTPServer_AcceptCollaboratorInvite failed\n    Error: ", err)
+		return
+	}
+
+	var resJson map[string]interface{}
+	err = json.Unmarshal(resBody, &resJson)
+	if err != nil {
+		t.Error("\nTestHTTPServer_AcceptCollaboratorInvite failed\n    Error: ", err)
+		return
+	}
+
+	expectedJson := map[string]interface{}{}
+	if !reflect.DeepEqual(resJson, expectedJson) {
+		t.Error("\nTestHTTPServer_AcceptCollaboratorInvite failed\n    Error: response JSON does not match expected JSON")
+		return
+	}
+
+	t.Log("\nTestHTTPServer_AcceptCollaboratorInvite succeeded")
+}
+
+func TestHTTPServer_RemoveCollaborator(t *testing.T) {
+	body := bytes.NewReader([]byte(`{"project_id":"1688617436791701504","user_id":"1688570643273318400","test":true}`))
+	req, err := http.NewRequest("POST", "http://localhost:1818/api/project/collaborators/remove", body)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_RemoveCollaborator failed\n    Error: %v", err)
+		return
+	}
+
+	req.AddCookie(&http.Cookie{
+		Name:  "gigoAuthToken",
+		Value: testUserAuth,
+	})
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_RemoveCollaborator failed\n    Error: %v", err)
+		return
+	}
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		fmt.Println(string(body))
+		fmt.Println(res.StatusCode)
+		t.Error("\nTestHTTPServer_RemoveCollaborator failed\n    Error: incorrect response code")
+		return
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Error("\nTestHTTPServer_RemoveCollaborator failed\n    Error: ", err)
+		return
+	}
+
+	var resJson map[string]interface{}
+	err = json.Unmarshal(resBody, &resJson)
+	if err != nil {
+		t.Error("\nTestHTTPServer_RemoveCollaborator failed\n    Error: ", err)
+		return
+	}
+
+	expectedJson := map[string]interface{}{}
+	if !reflect.DeepEqual(resJson, expectedJson) {
+		t.Error("\nTestHTTPServer_RemoveCollaborator failed\n    Error: response JSON does not match expected JSON")
+		return
+	}
+
+	t.Log("\nTestHTTPServer_RemoveCollaborator succeeded")
+}
+
+func TestHTTPServer_GetProjectCollaborators(t *testing.T) {
+	body := bytes.NewReader([]byte(`{"project_id":"1688617436791701504","test":true}`))
+	req, err := http.NewRequest("POST", "http://localhost:1818/api/project/collaborators", body)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_GetProjectCollaborators failed\n    Error: %v", err)
+		return
+	}
+
+	req.AddCookie(&http.Cookie{
+		Name:  "gigoAuthToken",
+		Value: testUserAuth,
+	})
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_GetProjectCollaborators failed\n    Error: %v", err)
+		return
+	}
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		fmt.Println(string(body))
+		fmt.Println(res.StatusCode)
+		t.Error("\nTestHTTPServer_GetProjectCollaborators failed\n    Error: incorrect response code")
+		return
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Error("\nTestHTTPServer_GetProjectCollaborators failed\n    Error: ", err)
+		return
+	}
+
+	var resJson map[string]interface{}
+	err = json.Unmarshal(resBody, &resJson)
+	if err != nil {
+		t.Error("\nTestHTTPServer_GetProjectCollaborators failed\n    Error: ", err)
+		return
+	}
+
+	expectedJson := map[string]interface{}{}
+	if !reflect.DeepEqual(resJson, expectedJson) {
+		t.Error("\nTestHTTPServer_GetProjectCollaborators failed\n    Error: response JSON does not match expected JSON")
+		return
+	}
+
+	t.Log("\nTestHTTPServer_GetProjectCollaborators succeeded")
+}
@@ -0,0 +1,292 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/logging"
+	"github.com/gage-technologies/gigo-lib/mq"
+	"github.com/kisielk/sqlstruct"
+	"go.opentelemetry.io/otel"
+)
+
+// AchievementEventStreak is the event emitted when a user's streak is extended
+const AchievementEventStreak = "streak"
+
+type AchievementRarity int
+
+const (
+	AchievementCommon AchievementRarity = iota
+	AchievementUncommon
+	AchievementRare
+	AchievementEpic
+	AchievementLegendary
+)
+
+func (r AchievementRarity) String() string {
+	switch r {
+	case AchievementCommon:
+		return "common"
+	case AchievementUncommon:
+		return "uncommon"
+	case AchievementRare:
+		return "rare"
+	case AchievementEpic:
+		return "epic"
+	case AchievementLegendary:
+		return "legendary"
+	}
+	return "unknown"
+}
+
+// Metrics that an achievement rule can be measured against. The rules
+// themselves live in the achievement table so that new badges can be added
+// without code changes as long as they use one of these metrics.
+const (
+	// AchievementMetricCount counts the number of times the rule's event
+	// (an AddXP source) has been recorded for the user
+	AchievementMetricCount = "count"
+	// AchievementMetricCurrentStreak is the user's active streak length
+	AchievementMetricCurrentStreak = "current_streak"
+	// AchievementMetricLongestStreak is the longest streak the user has held
+	AchievementMetricLongestStreak = "longest_streak"
+	// AchievementMetricXP is the total xp of the user
+	AchievementMetricXP = "xp"
+)
+
+type Achievement struct {
+	ID          int64             `json:"_id" sql:"_id"`
+	Name        string            `json:"name" sql:"name"`
+	Title       string            `json:"title" sql:"title"`
+	Description string            `json:"description" sql:"description"`
+	Rarity      AchievementRarity `json:"rarity" sql:"rarity"`
+	Event       string            `json:"event" sql:"event"`
+	Metric      string            `json:"metric" sql:"metric"`
+	Threshold   int64             `json:"threshold" sql:"threshold"`
+	Enabled     bool              `json:"enabled" sql:"enabled"`
+	CreatedAt   time.Time         `json:"created_at" sql:"created_at"`
+}
+
+type UserAchievement struct {
+	Achievement
+	AwardedAt time.Time `json:"awarded_at" sql:"awarded_at"`
+}
+
+type AchievementFrontend struct {
+	ID           string     `json:"_id"`
+	Name         string     `json:"name"`
+	Title        string     `json:"title"`
+	Description  string     `json:"description"`
+	Rarity       int        `json:"rarity"`
+	RarityString string     `json:"rarity_string"`
+	AwardedAt    *time.Time `json:"awarded_at"`
+}
+
+func (a *Achievement) ToFrontend() *AchievementFrontend {
+	return &AchievementFrontend{
+		ID:           fmt.Sprintf("%d", a.ID),
+		Name:         a.Name,
+		Title:        a.Title,
+		Description:  a.Description,
+		Rarity:       int(a.Rarity),
+		RarityString: a.Rarity.String(),
+	}
+}
+
+func (a *UserAchievement) ToFrontend() *AchievementFrontend {
+	f := a.Achievement.ToFrontend()
+	awardedAt := a.AwardedAt
+	f.AwardedAt = &awardedAt
+	return f
+}
+
+// achievementMetric loads the current value of the metric for a user
+func achievementMetric(ctx context.Context, tidb *ti.Database, userID int64, metric string, event string) (int64, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "achievement-metric-core")
+	defer span.End()
+	callerName := "achievementMetric"
+
+	var query string
+	var params []interface{}
+	switch metric {
+	case AchievementMetricCount:
+		query = "select count(*) from xp_reasons where user_id = ? and reason = ?"
+		params = []interface{}{userID, event}
+	case AchievementMetricCurrentStreak:
+		query = "select ifnull(max(current_streak), 0) from user_stats where user_id = ? and date = (select max(date) from user_stats where user_id = ?)"
+		params = []interface{}{userID, userID}
+	case AchievementMetricLongestStreak:
+		query = "select ifnull(max(longest_streak), 0) from user_stats where user_id = ?"
+		params = []interface{}{userID}
+	case AchievementMetricXP:
+		query = "select xp from users where _id = ?"
+		params = []interface{}{userID}
+	default:
+		return 0, fmt.Errorf("unknown achievement metric: %s", metric)
+	}
+
+	var value int64
+	err := tidb.QueryRowContext(ctx, &span, &callerName, query, params...).Scan(&value)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to query achievement metric %s: %v", metric, err)
+	}
+
+	return value, nil
+}
+
+// EvaluateAchievements evaluates all of the enabled achievement rules that are
+// triggered by the passed event for the user. Any rule whose threshold has been
+// reached is awarded and the user is notified. The newly awarded achievements
+// are returned.
+func EvaluateAchievements(ctx context.Context, tidb *ti.Database, js *mq.JetstreamClient, sf *snowflake.Node, userID int64, event string, logger logging.Logger) ([]*AchievementFrontend, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "evaluate-achievements-core")
+	defer span.End()
+	callerName := "EvaluateAchievements"
+
+	// load the rules for this event that the user has not yet earned
+	res, err := tidb.QueryContext(ctx, &span, &callerName,
+		"select a.* from achievement a left join user_achievement ua on ua.achievement_id = a._id and ua.user_id = ? "+
+			"where a.event = ? and a.enabled = true and ua.user_id is null",
+		userID, event,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query achievements: %v", err)
+	}
+
+	rules := make([]*Achievement, 0)
+	for res.Next() {
+		var rule Achievement
+		err = sqlstruct.Scan(&rule, res)
+		if err != nil {
+			_ = res.Close()
+			return nil, fmt.Errorf("failed to scan achievement: %v", err)
+		}
+		rules = append(rules, &rule)
+	}
+	_ = res.Close()
+
+	awarded := make([]*AchievementFrontend, 0)
+
+	// exit early if there is nothing to evaluate
+	if len(rules) == 0 {
+		return awarded, nil
+	}
+
+	// cache metrics since multiple rules commonly share a metric
+	metrics := make(map[string]int64)
+
+	for _, rule := range rules {
+		value, ok := metrics[rule.Metric]
+		if !ok {
+			value, err = achievementMetric(ctx, tidb, userID, rule.Metric, event)
+			if err != nil {
+				// skip rules that cannot be measured so that a single bad rule
+				// does not block the rest of the achievements
+				logger.Errorf("failed to evaluate achievement %s for user %d: %v", rule.Name, userID, err)
+				continue
+			}
+			metrics[rule.Metric] = value
+		}
+
+		if value < rule.Threshold {
+			continue
+		}
+
+		now := time.Now()
+
+		// insert ignore guards against a concurrent evaluation awarding twice
+		insertRes, err := tidb.ExecContext(ctx, &span, &callerName,
+			"insert ignore into user_achievement(user_id, achievement_id, awarded_at) values (?, ?, ?)",
+			userID, rule.ID, now,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert user achievement: %v", err)
+		}
+
+		rows, err := insertRes.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("failed to check user achievement insertion: %v", err)
+		}
+		if rows == 0 {
+			continue
+		}
+
+		userAchievement := &UserAchievement{Achievement: *rule, AwardedAt: now}
+		awarded = append(awarded, userAchievement.ToFrontend())
+
+		// notify the user of their new achievement
+		_, err = CreateNotification(ctx, tidb, js, sf, userID,
+			fmt.Sprintf("You earned the %s badge: %s", rule.Title, rule.Description),
+			AchievementNotification, nil,
+		)
+		if err != nil {
+			logger.Errorf("failed to notify user %d of achievement %s: %v", userID, rule.Name, err)
+		}
+	}
+
+	return awarded, nil
+}
+
+// GetUserAchievements retrieves the achievements that have been awarded to a user
+// ordered by the time they were awarded
+func GetUserAchievements(ctx context.Context, tidb *ti.Database, userID int64) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "get-user-achievements-core")
+	defer span.End()
+	callerName := "GetUserAchievements"
+
+	res, err := tidb.QueryContext(ctx, &span, &callerName,
+		"select a.*, ua.awarded_at as awarded_at from user_achievement ua join achievement a on a._id = ua.achievement_id "+
+			"where ua.user_id = ? order by ua.awarded_at desc",
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user achievements: %v", err)
+	}
+
+	defer res.Close()
+
+	achievements := make([]*AchievementFrontend, 0)
+	for res.Next() {
+		var achievement UserAchievement
+		err = sqlstruct.Scan(&achievement, res)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user achievement: %v", err)
+		}
+		achievements = append(achievements, achievement.ToFrontend())
+	}
+
+	return map[string]interface{}{"achievements": achievements}, nil
+}
+
+// GetAchievements retrieves the catalog of all enabled achievements
+func GetAchievements(ctx context.Context, tidb *ti.Database) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "get-achievements-core")
+	defer span.End()
+	callerName := "GetAchievements"
+
+	res, err := tidb.QueryContext(ctx, &span, &callerName, "select * from achievement where enabled = true order by rarity, threshold")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query achievements: %v", err)
+	}
+
+	defer res.Close()
+
+	achievements := make([]*AchievementFrontend, 0)
+	for res.Next() {
+		var achievement Achievement
+		err = sqlstruct.Scan(&achievement, res)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan achievement: %v", err)
+		}
+		achievements = append(achievements, achievement.ToFrontend())
+	}
+
+	return map[string]interface{}{"achievements": achievements}, nil
+}
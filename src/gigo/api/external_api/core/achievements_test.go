@@ -0,0 +1,148 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gigo-core/gigo/migrations"
+
+	"github.com/bwmarrin/snowflake"
+	config2 "github.com/gage-technologies/gigo-lib/config"
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/logging"
+	"github.com/gage-technologies/gigo-lib/mq"
+)
+
+func TestEvaluateAchievements(t *testing.T) {
+	testTiDB, err := ti.CreateDatabase("gigo-dev-tidb", "4000", "mysql", "gigo-dev",
+		"gigo-dev",
+		"gigo_test_db")
+	if err != nil {
+		t.Fatal("Initialize test database failed:", err)
+	}
+
+	err = migrations.Run(testTiDB)
+	if err != nil {
+		t.Fatal("Migrate test database failed:", err)
+	}
+
+	logger, err := logging.CreateBasicLogger(logging.NewDefaultBasicLoggerOptions("/tmp/gigo-core-test.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	js, err := mq.NewJetstreamClient(config2.JetstreamConfig{
+		Host:        "mq://gigo-dev-nats:4222",
+		Username:    "gigo-dev",
+		Password:    "gigo-dev",
+		MaxPubQueue: 256,
+	}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer js.Close()
+
+	sf, err := snowflake.NewNode(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userID := int64(1)
+
+	defer func() {
+		_, _ = testTiDB.DB.Exec("DELETE FROM xp_reasons WHERE user_id = ?", userID)
+		_, _ = testTiDB.DB.Exec("DELETE FROM user_achievement WHERE user_id = ?", userID)
+		_, _ = testTiDB.DB.Exec("DELETE FROM notification WHERE user_id = ?", userID)
+	}()
+
+	// nothing should be awarded before the event has been recorded
+	awarded, err := EvaluateAchievements(context.Background(), testTiDB, js, sf, userID, "successful", logger)
+	if err != nil {
+		t.Fatalf("EvaluateAchievements() error = %v", err)
+	}
+	if len(awarded) != 0 {
+		t.Fatalf("EvaluateAchievements() awarded %d achievements, want 0", len(awarded))
+	}
+
+	now := time.Now()
+	reason := models.CreateXPReason(sf.Generate().Int64(), userID, &now, "successful", 250)
+	stmt := reason.ToSQLNative()
+	_, err = testTiDB.DB.Exec(stmt[0].Statement, stmt[0].Values...)
+	if err != nil {
+		t.Fatalf("failed to insert xp reason: %v", err)
+	}
+
+	// the first success achievement should now be awarded
+	awarded, err = EvaluateAchievements(context.Background(), testTiDB, js, sf, userID, "successful", logger)
+	if err != nil {
+		t.Fatalf("EvaluateAchievements() error = %v", err)
+	}
+	if len(awarded) != 1 || awarded[0].Name != "first_success" {
+		t.Fatalf("EvaluateAchievements() = %+v, want first_success", awarded)
+	}
+	if awarded[0].AwardedAt == nil {
+		t.Errorf("EvaluateAchievements() awarded_at is nil")
+	}
+
+	// achievements are only ever awarded once
+	awarded, err = EvaluateAchievements(context.Background(), testTiDB, js, sf, userID, "successful", logger)
+	if err != nil {
+		t.Fatalf("EvaluateAchievements() error = %v", err)
+	}
+	if len(awarded) != 0 {
+		t.Fatalf("EvaluateAchievements() awarded %d achievements, want 0", len(awarded))
+	}
+
+	var notifications int
+	err = testTiDB.DB.QueryRow("select count(*) from notification where user_id = ? and notification_type = ?", userID, AchievementNotification).Scan(&notifications)
+	if err != nil {
+		t.Fatalf("failed to count notifications: %v", err)
+	}
+	if notifications != 1 {
+		t.Errorf("expected 1 achievement notification, got %d", notifications)
+	}
+
+	res, err := GetUserAchievements(context.Background(), testTiDB, userID)
+	if err != nil {
+		t.Fatalf("GetUserAchievements() error = %v", err)
+	}
+	achievements := res["achievements"].([]*AchievementFrontend)
+	if len(achievements) != 1 || achievements[0].RarityString != "common" {
+		t.Errorf("GetUserAchievements() = %+v, want a single common achievement", achievements)
+	}
+}
+
+func TestGetAchievements(t *testing.T) {
+	testTiDB, err := ti.CreateDatabase("gigo-dev-tidb", "4000", "mysql", "gigo-dev",
+		"gigo-dev",
+		"gigo_test_db")
+	if err != nil {
+		t.Fatal("Initialize test database failed:", err)
+	}
+
+	err = migrations.Run(testTiDB)
+	if err != nil {
+		t.Fatal("Migrate test database failed:", err)
+	}
+
+	res, err := GetAchievements(context.Background(), testTiDB)
+	if err != nil {
+		t.Fatalf("GetAchievements() error = %v", err)
+	}
+
+	achievements := res["achievements"].([]*AchievementFrontend)
+	if len(achievements) == 0 {
+		t.Fatalf("GetAchievements() returned no achievements")
+	}
+
+	// achievements are ordered by rarity
+	for i := 1; i < len(achievements); i++ {
+		if achievements[i].Rarity < achievements[i-1].Rarity {
+			t.Errorf("GetAchievements() achievements are not ordered by rarity")
+			break
+		}
+	}
+}
@@ -0,0 +1,474 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/git"
+	"github.com/gage-technologies/gitea-go/gitea"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	// number of unchanged lines included around each change
+	diffContextLines = 3
+	// maximum number of files included in a comparison
+	diffMaxFiles = 300
+	// files larger than this are reported as changed without hunks
+	diffMaxFileSize = 1024 * 1024
+	// maximum number of attempt commits searched for the fork point of
+	// attempts that were started before fork commits were recorded
+	diffMaxForkPointDepth = 20
+)
+
+// DiffFileStatus is the kind of change made to a file
+type DiffFileStatus string
+
+const (
+	DiffFileAdded    DiffFileStatus = "added"
+	DiffFileModified DiffFileStatus = "modified"
+	DiffFileDeleted  DiffFileStatus = "deleted"
+)
+
+// DiffLineType is the kind of a line within a hunk
+type DiffLineType string
+
+const (
+	DiffLineContext DiffLineType = "context"
+	DiffLineAdd     DiffLineType = "add"
+	DiffLineDelete  DiffLineType = "delete"
+)
+
+// DiffLine is a single line of a hunk. Line numbers are 1-based and zero when
+// the line does not exist on that side of the diff.
+type DiffLine struct {
+	Type    DiffLineType `json:"type"`
+	Content string       `json:"content"`
+	OldLine int          `json:"old_line"`
+	NewLine int          `json:"new_line"`
+}
+
+// DiffHunk is a contiguous block of changes in unified diff form
+type DiffHunk struct {
+	Header   string     `json:"header"`
+	OldStart int        `json:"old_start"`
+	OldLines int        `json:"old_lines"`
+	NewStart int        `json:"new_start"`
+	NewLines int        `json:"new_lines"`
+	Lines    []DiffLine `json:"lines"`
+}
+
+// FileDiff is the comparison of a single file between the project and attempt
+type FileDiff struct {
+	Path      string         `json:"path"`
+	Status    DiffFileStatus `json:"status"`
+	Additions int            `json:"additions"`
+	Deletions int            `json:"deletions"`
+	Binary    bool           `json:"binary"`
+	TooLarge  bool           `json:"too_large"`
+	Hunks     []DiffHunk     `json:"hunks,omitempty"`
+}
+
+// isBinaryContent follows the git heuristic of treating content with a nul
+// byte or invalid utf-8 as binary
+func isBinaryContent(data []byte) bool {
+	check := data
+	if len(check) > 8000 {
+		check = check[:8000]
+	}
+	for _, b := range check {
+		if b == 0 {
+			return true
+		}
+	}
+	return !utf8.Valid(data)
+}
+
+// splitDiffLines splits content into lines ignoring the final newline
+func splitDiffLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines performs a line based diff by mapping each unique line to a rune
+// so that the character diff of diffmatchpatch operates on whole lines. The
+// mapping is done here rather than with DiffLinesToRunes since the version in
+// use mangles line indexes.
+func diffLines(oldLines []string, newLines []string) []diffmatchpatch.Diff {
+	index := make(map[string]rune)
+	lookup := make([]string, 0)
+
+	encode := func(lines []string) []rune {
+		runes := make([]rune, len(lines))
+		for i, l := range lines {
+			r, ok := index[l]
+			if !ok {
+				// skip the surrogate range since it cannot be encoded in strings
+				r = rune(len(lookup) + 1)
+				if r >= 0xD800 {
+					r += 0x800
+				}
+				index[l] = r
+				lookup = append(lookup, l)
+			}
+			runes[i] = r
+		}
+		return runes
+	}
+
+	oldRunes := encode(oldLines)
+	newRunes := encode(newLines)
+
+	dmp := diffmatchpatch.New()
+	dmp.DiffTimeout = 5 * time.Second
+	diffs := dmp.DiffMainRunes(oldRunes, newRunes, false)
+
+	// decode the runes back into newline separated lines
+	for i := range diffs {
+		var text strings.Builder
+		for _, r := range diffs[i].Text {
+			idx := int(r) - 1
+			if r >= 0xD800+0x800 {
+				idx -= 0x800
+			}
+			text.WriteString(lookup[idx])
+			text.WriteString("\n")
+		}
+		diffs[i].Text = text.String()
+	}
+
+	return diffs
+}
+
+// computeFileDiff builds the unified diff hunks between the old and new content
+// of a file and counts the added and removed lines
+func computeFileDiff(oldContent string, newContent string) ([]DiffHunk, int, int) {
+	// flatten the diff into individual lines
+	lines := make([]DiffLine, 0)
+	oldLine, newLine := 1, 1
+	for _, d := range diffLines(splitDiffLines(oldContent), splitDiffLines(newContent)) {
+		for _, l := range splitDiffLines(d.Text) {
+			switch d.Type {
+			case diffmatchpatch.DiffEqual:
+				lines = append(lines, DiffLine{Type: DiffLineContext, Content: l, OldLine: oldLine, NewLine: newLine})
+				oldLine++
+				newLine++
+			case diffmatchpatch.DiffDelete:
+				lines = append(lines, DiffLine{Type: DiffLineDelete, Content: l, OldLine: oldLine})
+				oldLine++
+			case diffmatchpatch.DiffInsert:
+				lines = append(lines, DiffLine{Type: DiffLineAdd, Content: l, NewLine: newLine})
+				newLine++
+			}
+		}
+	}
+
+	additions, deletions := 0, 0
+	hunks := make([]DiffHunk, 0)
+
+	// group changes that are within twice the context of each other into hunks
+	i := 0
+	for i < len(lines) {
+		if lines[i].Type == DiffLineContext {
+			i++
+			continue
+		}
+
+		start := i - diffContextLines
+		if start < 0 {
+			start = 0
+		}
+
+		// extend the hunk until the gap of unchanged lines exceeds the context on both sides
+		end := i
+		for end < len(lines) {
+			if lines[end].Type != DiffLineContext {
+				end++
+				continue
+			}
+			gap := end
+			for gap < len(lines) && lines[gap].Type == DiffLineContext {
+				gap++
+			}
+			if gap == len(lines) || gap-end > diffContextLines*2 {
+				break
+			}
+			end = gap
+		}
+
+		stop := end + diffContextLines
+		if stop > len(lines) {
+			stop = len(lines)
+		}
+
+		hunk := DiffHunk{Lines: lines[start:stop]}
+		for _, l := range hunk.Lines {
+			switch l.Type {
+			case DiffLineContext:
+				hunk.OldLines++
+				hunk.NewLines++
+			case DiffLineDelete:
+				hunk.OldLines++
+				deletions++
+			case DiffLineAdd:
+				hunk.NewLines++
+				additions++
+			}
+			if hunk.OldStart == 0 && l.OldLine > 0 {
+				hunk.OldStart = l.OldLine
+			}
+			if hunk.NewStart == 0 && l.NewLine > 0 {
+				hunk.NewStart = l.NewLine
+			}
+		}
+
+		// empty sides of a hunk start at the line preceding it like unified diffs
+		if hunk.OldLines == 0 {
+			hunk.OldStart = lines[start].NewLine - 1
+			if hunk.OldStart < 0 {
+				hunk.OldStart = 0
+			}
+		}
+		if hunk.NewLines == 0 {
+			hunk.NewStart = lines[start].OldLine - 1
+			if hunk.NewStart < 0 {
+				hunk.NewStart = 0
+			}
+		}
+
+		hunk.Header = fmt.Sprintf("@@ -%d,%d +%d,%d @@", hunk.OldStart, hunk.OldLines, hunk.NewStart, hunk.NewLines)
+		hunks = append(hunks, hunk)
+		i = stop
+	}
+
+	return hunks, additions, deletions
+}
+
+// loadDiffBlob retrieves the content of a blob for comparison
+func loadDiffBlob(vcsClient *git.VCSClient, owner string, repo string, sha string) ([]byte, error) {
+	blob, gitRes, err := vcsClient.GiteaClient.GetBlob(owner, repo, sha)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve blob %s: %v\n    res: %s", sha, err, JsonifyGiteaResponse(gitRes))
+	}
+
+	content, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(blob.Content, "\n", ""))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode blob %s: %v", sha, err)
+	}
+
+	return content, nil
+}
+
+// resolveAttemptForkCommit returns the project commit that a new attempt was
+// forked from. Attempts forked from another attempt share the fork commit of
+// their parent, attempts pinned to a release start at the release commit and
+// all other attempts start at the head of the fork.
+func resolveAttemptForkCommit(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClient, owner string, repoName string,
+	branch string, parentAttempt *int64, releaseCommit sql.NullString) (sql.NullString, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "resolve-attempt-fork-commit-core")
+	defer span.End()
+	callerName := "resolveAttemptForkCommit"
+
+	var forkCommit sql.NullString
+	if parentAttempt != nil {
+		err := tidb.QueryRowContext(ctx, &span, &callerName,
+			"select fork_commit from attempt where _id = ? limit 1", *parentAttempt,
+		).Scan(&forkCommit)
+		if err != nil {
+			return forkCommit, fmt.Errorf("failed to query parent attempt fork commit: %v", err)
+		}
+		return forkCommit, nil
+	}
+
+	if releaseCommit.Valid && releaseCommit.String != "" {
+		return releaseCommit, nil
+	}
+
+	commit, gitRes, err := vcsClient.GiteaClient.GetSingleCommit(owner, repoName, branch)
+	if err != nil {
+		return forkCommit, fmt.Errorf("failed to retrieve head of %s/%s: %v\n    res: %s", owner, repoName, err, JsonifyGiteaResponse(gitRes))
+	}
+
+	return sql.NullString{String: commit.SHA, Valid: true}, nil
+}
+
+// attemptForkPoint returns the newest commit in the history of an attempt
+// that also exists in the project it was forked from. Commits made within the
+// attempt only exist in the attempt repository so the first shared commit is
+// the point the attempt diverged from the project.
+func attemptForkPoint(vcsClient *git.VCSClient, sourceOwner string, sourceRepo string, attemptOwner string,
+	attemptRepo string, head string) (string, error) {
+	checked := 0
+	for page := 1; ; page++ {
+		commits, gitRes, err := vcsClient.GiteaClient.ListRepoCommits(attemptOwner, attemptRepo, gitea.ListCommitOptions{
+			ListOptions: gitea.ListOptions{Page: page, PageSize: 50},
+			SHA:         head,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to list commits of %s/%s: %v\n    res: %s", attemptOwner, attemptRepo, err, JsonifyGiteaResponse(gitRes))
+		}
+		if len(commits) == 0 {
+			break
+		}
+
+		for _, commit := range commits {
+			_, gitRes, err := vcsClient.GiteaClient.GetSingleCommit(sourceOwner, sourceRepo, commit.SHA)
+			if err == nil {
+				return commit.SHA, nil
+			}
+			if gitRes == nil || gitRes.StatusCode != http.StatusNotFound {
+				return "", fmt.Errorf("failed to look up commit %s in %s/%s: %v\n    res: %s", commit.SHA, sourceOwner, sourceRepo, err, JsonifyGiteaResponse(gitRes))
+			}
+
+			checked++
+			if checked >= diffMaxForkPointDepth {
+				return "", fmt.Errorf("no common commit within %d commits of %s/%s@%s", diffMaxForkPointDepth, attemptOwner, attemptRepo, head)
+			}
+		}
+	}
+
+	return "", fmt.Errorf("%s/%s@%s does not share any history with %s/%s", attemptOwner, attemptRepo, head, sourceOwner, sourceRepo)
+}
+
+// CompareAttempt compares an attempt at the passed ref to the commit of the
+// project it was started from. The base is the release the attempt is pinned
+// to or the commit the attempt was forked from. Attempts started before fork
+// commits were recorded search a few commits of their history for the fork
+// point which is only done for signed in users since it costs a request to
+// the git server for every commit. When includeHunks is false only the paths and statuses of the changed
+// files are returned and no file content is loaded.
+func CompareAttempt(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClient, callingUser *models.User,
+	attemptId int64, ref string, includeHunks bool) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "compare-attempt-core")
+	defer span.End()
+
+	source, err := authorizeAttemptSource(ctx, tidb, callingUser, attemptId)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceOwner := fmt.Sprintf("%d", source.PostAuthorID)
+	sourceRepo := fmt.Sprintf("%d", source.PostID)
+	attemptOwner := fmt.Sprintf("%d", source.AuthorID)
+	attemptRepo := fmt.Sprintf("%d", attemptId)
+
+	headCommit, err := resolveArchiveCommit(vcsClient, attemptOwner, attemptRepo, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var baseCommit string
+	switch {
+	case source.ReleaseCommit.Valid && source.ReleaseCommit.String != "":
+		baseCommit, err = resolveArchiveCommit(vcsClient, sourceOwner, sourceRepo, source.ReleaseCommit.String)
+	case source.ForkCommit.Valid && source.ForkCommit.String != "":
+		baseCommit, err = resolveArchiveCommit(vcsClient, sourceOwner, sourceRepo, source.ForkCommit.String)
+	default:
+		if callingUser == nil {
+			return map[string]interface{}{"message": "You must be logged in to compare this attempt."},
+				fmt.Errorf("anonymous comparison of attempt %d without a fork commit: %w", attemptId, ErrForbidden)
+		}
+		baseCommit, err = attemptForkPoint(vcsClient, sourceOwner, sourceRepo, attemptOwner, attemptRepo, headCommit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	baseFiles, err := repoTree(vcsClient, sourceOwner, sourceRepo, baseCommit)
+	if err != nil {
+		return nil, err
+	}
+
+	headFiles, err := repoTree(vcsClient, attemptOwner, attemptRepo, headCommit)
+	if err != nil {
+		return nil, err
+	}
+
+	// collect the paths that differ between the two trees
+	changed := make([]string, 0)
+	for path, sha := range headFiles {
+		if baseSha, ok := baseFiles[path]; !ok || baseSha != sha {
+			changed = append(changed, path)
+		}
+	}
+	for path := range baseFiles {
+		if _, ok := headFiles[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+
+	truncated := false
+	if len(changed) > diffMaxFiles {
+		changed = changed[:diffMaxFiles]
+		truncated = true
+	}
+
+	files := make([]*FileDiff, 0, len(changed))
+	totalAdditions, totalDeletions := 0, 0
+	for _, path := range changed {
+		baseSha, inBase := baseFiles[path]
+		headSha, inHead := headFiles[path]
+
+		file := &FileDiff{Path: path, Status: DiffFileModified}
+		if !inBase {
+			file.Status = DiffFileAdded
+		} else if !inHead {
+			file.Status = DiffFileDeleted
+		}
+
+		// the summary only reports which files changed so the content is not loaded
+		if !includeHunks {
+			files = append(files, file)
+			continue
+		}
+
+		var oldContent, newContent []byte
+		if inBase {
+			oldContent, err = loadDiffBlob(vcsClient, sourceOwner, sourceRepo, baseSha)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if inHead {
+			newContent, err = loadDiffBlob(vcsClient, attemptOwner, attemptRepo, headSha)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		switch {
+		case isBinaryContent(oldContent) || isBinaryContent(newContent):
+			file.Binary = true
+		case len(oldContent) > diffMaxFileSize || len(newContent) > diffMaxFileSize:
+			file.TooLarge = true
+		default:
+			file.Hunks, file.Additions, file.Deletions = computeFileDiff(string(oldContent), string(newContent))
+		}
+
+		totalAdditions += file.Additions
+		totalDeletions += file.Deletions
+		files = append(files, file)
+	}
+
+	return map[string]interface{}{
+		"base_commit": baseCommit,
+		"head_commit": headCommit,
+		"files":       files,
+		"additions":   totalAdditions,
+		"deletions":   totalDeletions,
+		"truncated":   truncated,
+	}, nil
+}
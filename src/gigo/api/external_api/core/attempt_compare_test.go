@@ -0,0 +1,66 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+func TestComputeFileDiff(t *testing.T) {
+	oldContent := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\n"
+	newContent := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\no\n"
+
+	hunks, additions, deletions := computeFileDiff(oldContent, newContent)
+	if additions != 2 || deletions != 1 {
+		t.Fatalf("computeFileDiff() counts = +%d -%d, want +2 -1", additions, deletions)
+	}
+
+	if len(hunks) != 2 {
+		t.Fatalf("computeFileDiff() returned %d hunks, want 2: %+v", len(hunks), hunks)
+	}
+
+	if hunks[0].Header != "@@ -1,5 +1,5 @@" {
+		t.Errorf("first hunk header = %q, want @@ -1,5 +1,5 @@", hunks[0].Header)
+	}
+
+	if hunks[1].Header != "@@ -12,3 +12,4 @@" {
+		t.Errorf("second hunk header = %q, want @@ -12,3 +12,4 @@", hunks[1].Header)
+	}
+
+	last := hunks[1].Lines[len(hunks[1].Lines)-1]
+	if last.Type != DiffLineAdd || last.Content != "o" || last.NewLine != 15 || last.OldLine != 0 {
+		t.Errorf("last line = %+v, want addition of o at line 15", last)
+	}
+}
+
+func TestComputeFileDiffAddedFile(t *testing.T) {
+	hunks, additions, deletions := computeFileDiff("", "one\ntwo\n")
+	if additions != 2 || deletions != 0 || len(hunks) != 1 {
+		t.Fatalf("computeFileDiff() = %+v +%d -%d, want a single hunk with 2 additions", hunks, additions, deletions)
+	}
+	if hunks[0].Header != "@@ -0,0 +1,2 @@" {
+		t.Errorf("hunk header = %q, want @@ -0,0 +1,2 @@", hunks[0].Header)
+	}
+}
+
+func TestIsBinaryContent(t *testing.T) {
+	if isBinaryContent([]byte("héllo wörld")) {
+		t.Errorf("isBinaryContent() flagged utf-8 text as binary")
+	}
+	if !isBinaryContent([]byte{0x89, 'P', 'N', 'G', 0x00}) {
+		t.Errorf("isBinaryContent() did not flag binary content")
+	}
+}
+
+func TestResolveAttemptForkCommit(t *testing.T) {
+	// attempts pinned to a release are forked from the release commit without
+	// looking up the head of the fork
+	release := sql.NullString{String: "0123456789abcdef0123456789abcdef01234567", Valid: true}
+	got, err := resolveAttemptForkCommit(context.Background(), nil, nil, "69", "420", "main", nil, release)
+	if err != nil {
+		t.Fatalf("resolveAttemptForkCommit() error = %v", err)
+	}
+	if got != release {
+		t.Errorf("resolveAttemptForkCommit() = %+v, want %+v", got, release)
+	}
+}
@@ -0,0 +1,929 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/git"
+	"github.com/gage-technologies/gigo-lib/logging"
+	"github.com/gage-technologies/gigo-lib/mq"
+	"github.com/gage-technologies/gigo-lib/workspace_config"
+	"github.com/gage-technologies/gitea-go/gitea"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"gopkg.in/yaml.v3"
+)
+
+type AttemptGradingStatus int
+
+const (
+	AttemptGradingQueued AttemptGradingStatus = iota
+	AttemptGradingRunning
+	AttemptGradingPassed
+	AttemptGradingFailed
+	AttemptGradingErrored
+)
+
+func (s AttemptGradingStatus) String() string {
+	switch s {
+	case AttemptGradingQueued:
+		return "Queued"
+	case AttemptGradingRunning:
+		return "Running"
+	case AttemptGradingPassed:
+		return "Passed"
+	case AttemptGradingFailed:
+		return "Failed"
+	case AttemptGradingErrored:
+		return "Errored"
+	}
+	return "Unknown"
+}
+
+const (
+	// GradingFormatExitCode treats the whole test command as a single test
+	GradingFormatExitCode = "exit-code"
+	// GradingFormatGo parses the event stream produced by `go test -json`
+	GradingFormatGo = "go"
+	// GradingFormatTAP parses output in the Test Anything Protocol
+	GradingFormatTAP = "tap"
+
+	defaultGradingTimeout = 5 * time.Minute
+	maxGradingTimeout     = 30 * time.Minute
+
+	// maxGradingOutput bounds the output stored for a single test result
+	maxGradingOutput = 16 * 1024
+
+	// maxGradingTestFiles and maxGradingTestBytes bound the test files that
+	// are restored from the challenge into a grading workspace
+	maxGradingTestFiles = 500
+	maxGradingTestBytes = 8 * 1024 * 1024
+)
+
+// GradingConfig is the `tests` section of a challenge's .gigo/workspace.yaml
+//
+//	tests:
+//	  command: go test -json ./...
+//	  format: go
+//	  timeout: 300
+type GradingConfig struct {
+	Command string `yaml:"command" json:"command"`
+	Format  string `yaml:"format" json:"format"`
+	// Timeout is the number of seconds the test command may run for
+	Timeout int `yaml:"timeout" json:"timeout"`
+	// Files are the patterns of the test files that are restored from the
+	// challenge before the tests run. Patterns without a slash match the file
+	// name, patterns ending in a slash match every file in that directory.
+	Files []string `yaml:"files" json:"files"`
+}
+
+// defaultGradingTestFiles matches the test files of the common test runners
+// when a challenge does not declare its own patterns
+var defaultGradingTestFiles = []string{"*_test.*", "*.test.*", "*.spec.*", "test_*", "tests/", "test/", "__tests__/"}
+
+// TestFilePatterns returns the patterns of the files that are restored from
+// the challenge before grading
+func (c *GradingConfig) TestFilePatterns() []string {
+	if len(c.Files) == 0 {
+		return defaultGradingTestFiles
+	}
+	return c.Files
+}
+
+// matchGradingTestFile reports whether the path of a repository file matches
+// any of the passed test file patterns
+func matchGradingTestFile(patterns []string, filePath string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimPrefix(strings.TrimSpace(pattern), "/")
+		if pattern == "" {
+			continue
+		}
+
+		if strings.HasSuffix(pattern, "/") {
+			if strings.HasPrefix(filePath, pattern) || strings.Contains(filePath, "/"+pattern) {
+				return true
+			}
+			continue
+		}
+
+		name := path.Base(filePath)
+		if strings.Contains(pattern, "/") {
+			name = filePath
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// TimeoutDuration returns the bounded execution timeout of the test command
+func (c *GradingConfig) TimeoutDuration() time.Duration {
+	if c.Timeout <= 0 {
+		return defaultGradingTimeout
+	}
+	timeout := time.Duration(c.Timeout) * time.Second
+	if timeout > maxGradingTimeout {
+		return maxGradingTimeout
+	}
+	return timeout
+}
+
+// ParseGradingConfig loads the grading configuration from the bytes of a
+// workspace config. A nil config is returned when no tests are declared.
+func ParseGradingConfig(configBytes []byte) (*GradingConfig, error) {
+	var cfg struct {
+		Tests *GradingConfig `yaml:"tests"`
+	}
+	err := yaml.Unmarshal(configBytes, &cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse workspace config: %v", err)
+	}
+
+	if cfg.Tests == nil || strings.TrimSpace(cfg.Tests.Command) == "" {
+		return nil, nil
+	}
+
+	switch cfg.Tests.Format {
+	case "":
+		cfg.Tests.Format = GradingFormatExitCode
+	case GradingFormatExitCode, GradingFormatGo, GradingFormatTAP:
+	default:
+		return nil, fmt.Errorf("unsupported test format: %s", cfg.Tests.Format)
+	}
+
+	return cfg.Tests, nil
+}
+
+type AttemptTestResult struct {
+	Name       string `json:"name"`
+	Passed     bool   `json:"passed"`
+	DurationMs *int64 `json:"duration_ms"`
+	Output     string `json:"output"`
+}
+
+type AttemptGradingFrontend struct {
+	ID           string               `json:"_id"`
+	AttemptID    string               `json:"attempt_id"`
+	Commit       string               `json:"commit"`
+	Status       AttemptGradingStatus `json:"status"`
+	StatusString string               `json:"status_string"`
+	Passed       int                  `json:"passed"`
+	Failed       int                  `json:"failed"`
+	ExitCode     *int                 `json:"exit_code"`
+	Error        *string              `json:"error"`
+	CreatedAt    time.Time            `json:"created_at"`
+	FinishedAt   *time.Time           `json:"finished_at"`
+	Results      []*AttemptTestResult `json:"results"`
+}
+
+// AttemptGradingJob describes a grading run that has been registered and is
+// waiting for its workspace to be provisioned and its tests to be executed
+type AttemptGradingJob struct {
+	GradingID        int64
+	AttemptID        int64
+	PostID           int64
+	WorkspaceID      int64
+	Owner            *models.User
+	Tier             models.TierType
+	Container        string
+	CPU              int
+	Memory           int
+	Disk             int
+	WorkingDirectory string
+	Config           GradingConfig
+	// TestFiles are the test files of the challenge at the commit the attempt
+	// is pinned to and are written over the attempt's copies before grading
+	TestFiles []*RepoFile
+	// StaleTestFiles are test files of the attempt that do not exist in the
+	// challenge and are removed before grading
+	StaleTestFiles []string
+}
+
+// challengeRepository locates the repository of the challenge with the passed id
+func challengeRepository(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClient, postId int64) (*gitea.Repository, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "challenge-repository-core")
+	defer span.End()
+	callerName := "challengeRepository"
+
+	var repoId int64
+	err := tidb.QueryRowContext(ctx, &span, &callerName, "select repo_id from post where _id = ? limit 1", postId).Scan(&repoId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to query post repo: %v", err)
+	}
+
+	repository, gitRes, err := vcsClient.GiteaClient.GetRepoByID(repoId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate repo %d: %v\n    response: %s", repoId, err, JsonifyGiteaResponse(gitRes))
+	}
+
+	return repository, nil
+}
+
+// loadChallengeTestFiles loads the test files of the challenge at the passed
+// ref along with the test files of the attempt that the challenge does not
+// have. Grading restores the former and removes the latter so that an attempt
+// is always graded against the tests of the challenge it was started from.
+func loadChallengeTestFiles(vcsClient *git.VCSClient, challenge *gitea.Repository, challengeRef string,
+	attempt *gitea.Repository, attemptRef string, patterns []string) ([]*RepoFile, []string, error) {
+	challengeEntries, err := repoTreeEntries(vcsClient, challenge.Owner.UserName, challenge.Name, challengeRef)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	files := make([]*RepoFile, 0)
+	challengePaths := make(map[string]bool)
+	var size int64
+	for _, entry := range challengeEntries {
+		if !matchGradingTestFile(patterns, entry.Path) {
+			continue
+		}
+
+		size += entry.Size
+		if len(files) >= maxGradingTestFiles || size > maxGradingTestBytes {
+			return nil, nil, fmt.Errorf("the test files of %s/%s@%s exceed the grading limits", challenge.Owner.UserName, challenge.Name, challengeRef)
+		}
+
+		content, err := loadDiffBlob(vcsClient, challenge.Owner.UserName, challenge.Name, entry.SHA)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		challengePaths[entry.Path] = true
+		files = append(files, &RepoFile{
+			Path:       entry.Path,
+			Executable: entry.Mode == "100755",
+			Content:    content,
+		})
+	}
+
+	attemptEntries, err := repoTreeEntries(vcsClient, attempt.Owner.UserName, attempt.Name, attemptRef)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stale := make([]string, 0)
+	for _, entry := range attemptEntries {
+		if matchGradingTestFile(patterns, entry.Path) && !challengePaths[entry.Path] {
+			stale = append(stale, entry.Path)
+		}
+	}
+
+	return files, stale, nil
+}
+
+// loadChallengeConfig retrieves the workspace config of the challenge that an
+// attempt was started from. The config is always read from the challenge
+// repository so that attempts cannot alter the tests they are graded against.
+func loadChallengeConfig(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClient, postId int64, ref string) ([]byte, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "load-challenge-config-core")
+	defer span.End()
+	repository, err := challengeRepository(ctx, tidb, vcsClient, postId)
+	if err != nil {
+		return nil, err
+	}
+
+	configBytes, gitRes, err := vcsClient.GiteaClient.GetFile(repository.Owner.UserName, repository.Name, ref, ".gigo/workspace.yaml")
+	if err != nil {
+		if gitRes != nil && gitRes.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to retrieve challenge config: %v\n    response: %s", err, JsonifyGiteaResponse(gitRes))
+	}
+
+	return configBytes, nil
+}
+
+// challengeGradingConfig returns the grading config declared by the challenge
+// that the attempt is pinned to or nil if the challenge declares no tests
+func challengeGradingConfig(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClient, postId int64,
+	releaseCommit sql.NullString) (*GradingConfig, []byte, error) {
+	ref := "main"
+	if releaseCommit.Valid {
+		ref = releaseCommit.String
+	}
+
+	configBytes, err := loadChallengeConfig(ctx, tidb, vcsClient, postId, ref)
+	if err != nil || configBytes == nil {
+		return nil, nil, err
+	}
+
+	cfg, err := ParseGradingConfig(configBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cfg, configBytes, nil
+}
+
+// SubmitAttemptGrading registers a grading run for an attempt owned by the
+// calling user. The returned job must be executed by the caller, which
+// provisions an isolated workspace for the run and reports the outcome via
+// CompleteAttemptGrading.
+func SubmitAttemptGrading(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClient, sf *snowflake.Node,
+	callingUser *models.User, attemptId int64) (map[string]interface{}, *AttemptGradingJob, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "submit-attempt-grading-core")
+	defer span.End()
+	callerName := "SubmitAttemptGrading"
+
+	var authorId, postId, repoId int64
+	var tier models.TierType
+	var releaseCommit sql.NullString
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		"select author_id, post_id, repo_id, tier, release_commit from attempt where _id = ? limit 1", attemptId,
+	).Scan(&authorId, &postId, &repoId, &tier, &releaseCommit)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return map[string]interface{}{"message": "attempt not found"}, nil, ErrNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to query attempt: %v", err)
+	}
+
+	// only the owner of an attempt may submit it for grading
+	if authorId != callingUser.ID {
+		return map[string]interface{}{"message": "attempt not found"}, nil, ErrNotFound
+	}
+
+	// ensure that only one grading runs for an attempt at a time
+	var inProgress bool
+	err = tidb.QueryRowContext(ctx, &span, &callerName,
+		"select exists(select 1 from attempt_grading where attempt_id = ? and status in (?, ?))",
+		attemptId, AttemptGradingQueued, AttemptGradingRunning,
+	).Scan(&inProgress)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query active gradings: %v", err)
+	}
+	if inProgress {
+		return map[string]interface{}{"message": "This attempt is already being graded."}, nil,
+			fmt.Errorf("attempt %d is already being graded", attemptId)
+	}
+
+	gradingCfg, configBytes, err := challengeGradingConfig(ctx, tidb, vcsClient, postId, releaseCommit)
+	if err != nil {
+		if err == ErrNotFound {
+			return map[string]interface{}{"message": "attempt not found"}, nil, err
+		}
+		return nil, nil, err
+	}
+	if gradingCfg == nil {
+		return map[string]interface{}{"message": "This challenge does not declare any tests."}, nil,
+			fmt.Errorf("post %d does not declare tests", postId)
+	}
+
+	var wsConfig workspace_config.GigoWorkspaceConfig
+	err = yaml.Unmarshal(configBytes, &wsConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse challenge config: %v", err)
+	}
+
+	// resolve the commit that is being graded so the result is reproducible
+	repository, gitRes, err := vcsClient.GiteaClient.GetRepoByID(repoId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to locate repo %d: %v\n    response: %s", repoId, err, JsonifyGiteaResponse(gitRes))
+	}
+
+	commit, gitRes, err := vcsClient.GiteaClient.GetSingleCommit(repository.Owner.UserName, repository.Name, "main")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve attempt commit: %v\n    response: %s", err, JsonifyGiteaResponse(gitRes))
+	}
+
+	// grading workspaces are always bound to the free tier allocations
+	if wsConfig.Resources.CPU > 2 || wsConfig.Resources.CPU <= 0 {
+		wsConfig.Resources.CPU = 2
+	}
+	if wsConfig.Resources.Mem > 3 || wsConfig.Resources.Mem <= 0 {
+		wsConfig.Resources.Mem = 3
+	}
+	if wsConfig.Resources.Disk > 15 || wsConfig.Resources.Disk <= 0 {
+		wsConfig.Resources.Disk = 15
+	}
+
+	wsSettings := models.DefaultWorkspaceSettings
+	if callingUser.WorkspaceSettings != nil {
+		wsSettings = *callingUser.WorkspaceSettings
+	}
+
+	// load the tests from the challenge at the commit the attempt is pinned to
+	// since the copies in the attempt are editable by the attempter
+	challenge, err := challengeRepository(ctx, tidb, vcsClient, postId)
+	if err != nil {
+		return nil, nil, err
+	}
+	challengeRef := "main"
+	if releaseCommit.Valid {
+		challengeRef = releaseCommit.String
+	}
+	testFiles, staleTestFiles, err := loadChallengeTestFiles(vcsClient, challenge, challengeRef, repository, commit.SHA, gradingCfg.TestFilePatterns())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	gradingId := sf.Generate().Int64()
+
+	// the grading workspace uses the attempt as its code source so that the
+	// agent initializes it like any other attempt workspace; the grading run
+	// references it through its workspace id. It expires shortly after the
+	// tests time out so abandoned runs are cleaned up.
+	workspace, err := models.CreateWorkspace(
+		sf.Generate().Int64(), repoId, attemptId, models.CodeSourceAttempt, time.Now(), callingUser.ID, -1,
+		time.Now().Add(gradingCfg.TimeoutDuration()+time.Minute*20), commit.SHA, &wsSettings, nil, []models.WorkspacePort{},
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create workspace model: %v", err)
+	}
+	workspace.InitState = -1
+
+	wsInsertionStatements, err := workspace.ToSQLNative()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to format workspace for insertion: %v", err)
+	}
+
+	tx, err := tidb.BeginTx(ctx, &span, &callerName, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create tx for grading insertion: %v", err)
+	}
+
+	defer tx.Rollback()
+
+	for _, statement := range wsInsertionStatements {
+		_, err = tx.ExecContext(ctx, &callerName, statement.Statement, statement.Values...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to insert grading workspace: %v", err)
+		}
+	}
+
+	createdAt := time.Now()
+	_, err = tx.ExecContext(ctx, &callerName,
+		"insert into attempt_grading(_id, attempt_id, post_id, author_id, workspace_id, commit, command, status, created_at) values (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		gradingId, attemptId, postId, callingUser.ID, workspace.ID, commit.SHA, gradingCfg.Command, AttemptGradingQueued, createdAt,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to insert grading: %v", err)
+	}
+
+	err = tx.Commit(&callerName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to commit tx: %v", err)
+	}
+
+	job := &AttemptGradingJob{
+		GradingID:        gradingId,
+		AttemptID:        attemptId,
+		PostID:           postId,
+		WorkspaceID:      workspace.ID,
+		Owner:            callingUser,
+		Tier:             tier,
+		Container:        wsConfig.BaseContainer,
+		CPU:              wsConfig.Resources.CPU,
+		Memory:           wsConfig.Resources.Mem,
+		Disk:             wsConfig.Resources.Disk,
+		WorkingDirectory: wsConfig.WorkingDirectory,
+		Config:           *gradingCfg,
+		TestFiles:        testFiles,
+		StaleTestFiles:   staleTestFiles,
+	}
+
+	return map[string]interface{}{
+		"message": "Attempt submitted for grading.",
+		"grading": &AttemptGradingFrontend{
+			ID:           fmt.Sprintf("%d", gradingId),
+			AttemptID:    fmt.Sprintf("%d", attemptId),
+			Commit:       commit.SHA,
+			Status:       AttemptGradingQueued,
+			StatusString: AttemptGradingQueued.String(),
+			CreatedAt:    createdAt,
+			Results:      make([]*AttemptTestResult, 0),
+		},
+	}, job, nil
+}
+
+// RegisterGradingAgent records the agent of a provisioned grading workspace
+// and marks the grading as running
+func RegisterGradingAgent(ctx context.Context, tidb *ti.Database, job *AttemptGradingJob, agentId int64, token uuid.UUID) error {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "register-grading-agent-core")
+	defer span.End()
+	callerName := "RegisterGradingAgent"
+
+	agent := models.CreateWorkspaceAgent(agentId, job.WorkspaceID, "", job.Owner.ID, token)
+
+	tx, err := tidb.BeginTx(ctx, &span, &callerName, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create tx for agent insertion: %v", err)
+	}
+
+	defer tx.Rollback()
+
+	for _, statement := range agent.ToSQLNative() {
+		_, err = tx.ExecContext(ctx, &callerName, statement.Statement, statement.Values...)
+		if err != nil {
+			return fmt.Errorf("failed to insert grading agent: %v", err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, &callerName,
+		"update attempt_grading set status = ? where _id = ?", AttemptGradingRunning, job.GradingID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update grading status: %v", err)
+	}
+
+	return tx.Commit(&callerName)
+}
+
+// GetGradingWorkspaceState returns the current state of a grading workspace
+func GetGradingWorkspaceState(ctx context.Context, tidb *ti.Database, wsId int64) (models.WorkspaceState, models.WorkspaceInitState, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "get-grading-workspace-state-core")
+	defer span.End()
+	callerName := "GetGradingWorkspaceState"
+
+	var state models.WorkspaceState
+	var initState models.WorkspaceInitState
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		"select state, init_state from workspaces where _id = ? limit 1", wsId,
+	).Scan(&state, &initState)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to query grading workspace: %v", err)
+	}
+
+	return state, initState, nil
+}
+
+// GetGradingWorkspaceAgent returns the id of the agent of a grading workspace
+func GetGradingWorkspaceAgent(ctx context.Context, tidb *ti.Database, wsId int64) (int64, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "get-grading-workspace-agent-core")
+	defer span.End()
+	callerName := "GetGradingWorkspaceAgent"
+
+	var agentId int64
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		"select _id from workspace_agent where workspace_id = ? order by created_at desc limit 1", wsId,
+	).Scan(&agentId)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query grading workspace agent: %v", err)
+	}
+
+	return agentId, nil
+}
+
+// ReleaseGradingWorkspace marks a grading workspace as deleted once it has
+// been destroyed by the provisioner
+func ReleaseGradingWorkspace(ctx context.Context, tidb *ti.Database, wsId int64) error {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "release-grading-workspace-core")
+	defer span.End()
+	callerName := "ReleaseGradingWorkspace"
+
+	_, err := tidb.ExecContext(ctx, &span, &callerName,
+		"update workspaces set state = ?, last_state_update = ? where _id = ?", models.WorkspaceDeleted, time.Now(), wsId,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark grading workspace deleted: %v", err)
+	}
+
+	_, err = tidb.ExecContext(ctx, &span, &callerName, "delete from workspace_agent where workspace_id = ?", wsId)
+	if err != nil {
+		return fmt.Errorf("failed to delete grading workspace agent: %v", err)
+	}
+
+	return nil
+}
+
+// truncateGradingOutput keeps the tail of the output since failures are
+// usually reported at the end of a test run
+func truncateGradingOutput(output string) string {
+	if len(output) <= maxGradingOutput {
+		return output
+	}
+	return "...\n" + output[len(output)-maxGradingOutput:]
+}
+
+// parseGoTestResults parses the event stream of `go test -json`
+func parseGoTestResults(output string) []*AttemptTestResult {
+	type testEvent struct {
+		Action  string  `json:"Action"`
+		Package string  `json:"Package"`
+		Test    string  `json:"Test"`
+		Elapsed float64 `json:"Elapsed"`
+		Output  string  `json:"Output"`
+	}
+
+	results := make([]*AttemptTestResult, 0)
+	outputs := make(map[string]*strings.Builder)
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var event testEvent
+		if json.Unmarshal(scanner.Bytes(), &event) != nil || event.Test == "" {
+			continue
+		}
+
+		name := event.Test
+		if event.Package != "" {
+			name = event.Package + "/" + event.Test
+		}
+
+		switch event.Action {
+		case "output":
+			if _, ok := outputs[name]; !ok {
+				outputs[name] = &strings.Builder{}
+			}
+			outputs[name].WriteString(event.Output)
+		case "pass", "fail", "skip":
+			duration := int64(event.Elapsed * 1000)
+			result := &AttemptTestResult{
+				Name:       name,
+				Passed:     event.Action != "fail",
+				DurationMs: &duration,
+			}
+			if b, ok := outputs[name]; ok {
+				result.Output = truncateGradingOutput(b.String())
+			}
+			results = append(results, result)
+		}
+	}
+
+	return results
+}
+
+var tapResultRegex = regexp.MustCompile(`^(not )?ok\b\s*(\d+)?\s*(?:-\s*)?(.*)$`)
+
+// parseTAPResults parses test results reported in the Test Anything Protocol.
+// Diagnostic lines following a test are attached to it as its output.
+func parseTAPResults(output string) []*AttemptTestResult {
+	results := make([]*AttemptTestResult, 0)
+	var current *AttemptTestResult
+	var diagnostics strings.Builder
+
+	flush := func() {
+		if current != nil {
+			current.Output = truncateGradingOutput(diagnostics.String())
+			results = append(results, current)
+		}
+		diagnostics.Reset()
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		match := tapResultRegex.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			if current != nil {
+				diagnostics.WriteString(line + "\n")
+			}
+			continue
+		}
+
+		flush()
+
+		name := match[3]
+		directive := ""
+		if idx := strings.Index(name, "#"); idx >= 0 {
+			directive = strings.ToUpper(strings.TrimSpace(name[idx+1:]))
+			name = strings.TrimSpace(name[:idx])
+		}
+		if name == "" {
+			name = fmt.Sprintf("test %s", match[2])
+		}
+
+		// skipped and todo tests do not count against the attempt
+		current = &AttemptTestResult{
+			Name:   name,
+			Passed: match[1] == "" || strings.HasPrefix(directive, "SKIP") || strings.HasPrefix(directive, "TODO"),
+		}
+	}
+	flush()
+
+	return results
+}
+
+// parseGradingResults converts the output of a test command into individual
+// test results. Commands that produce no parsable results are reported as a
+// single test derived from the exit code.
+func parseGradingResults(format string, command string, output string, exitCode int) []*AttemptTestResult {
+	var results []*AttemptTestResult
+	switch format {
+	case GradingFormatGo:
+		results = parseGoTestResults(output)
+	case GradingFormatTAP:
+		results = parseTAPResults(output)
+	}
+
+	if len(results) == 0 {
+		results = []*AttemptTestResult{{
+			Name:   command,
+			Passed: exitCode == 0,
+			Output: truncateGradingOutput(output),
+		}}
+	}
+
+	return results
+}
+
+// CompleteAttemptGrading stores the outcome of a grading run. A run passes
+// when the test command exits cleanly and no individual test failed, in which
+// case the attempt is marked as successful and XP is awarded the first time.
+func CompleteAttemptGrading(ctx context.Context, tidb *ti.Database, js *mq.JetstreamClient, rdb redis.UniversalClient,
+	sf *snowflake.Node, job *AttemptGradingJob, output string, exitCode int, runErr error, logger logging.Logger) error {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "complete-attempt-grading-core")
+	defer span.End()
+	callerName := "CompleteAttemptGrading"
+
+	// runs that could not execute the tests are recorded without results
+	if runErr != nil {
+		_, err := tidb.ExecContext(ctx, &span, &callerName,
+			"update attempt_grading set status = ?, error = ?, finished_at = ? where _id = ?",
+			AttemptGradingErrored, runErr.Error(), time.Now(), job.GradingID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to record grading error: %v", err)
+		}
+		return nil
+	}
+
+	results := parseGradingResults(job.Config.Format, job.Config.Command, output, exitCode)
+
+	passed, failed := 0, 0
+	for _, r := range results {
+		if r.Passed {
+			passed++
+		} else {
+			failed++
+		}
+	}
+
+	status := AttemptGradingFailed
+	if exitCode == 0 && failed == 0 {
+		status = AttemptGradingPassed
+	}
+
+	tx, err := tidb.BeginTx(ctx, &span, &callerName, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create tx for grading results: %v", err)
+	}
+
+	defer tx.Rollback()
+
+	for i, r := range results {
+		_, err = tx.ExecContext(ctx, &callerName,
+			"insert into attempt_grading_result(grading_id, idx, name, passed, duration_ms, output) values (?, ?, ?, ?, ?, ?)",
+			job.GradingID, i, truncateString(r.Name, 512), r.Passed, r.DurationMs, r.Output,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert grading result: %v", err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, &callerName,
+		"update attempt_grading set status = ?, passed = ?, failed = ?, exit_code = ?, finished_at = ? where _id = ?",
+		status, passed, failed, exitCode, time.Now(), job.GradingID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update grading: %v", err)
+	}
+
+	// only the first passing run marks the attempt successful and awards xp
+	newlySuccessful := false
+	if status == AttemptGradingPassed {
+		updateRes, err := tx.ExecContext(ctx, &callerName,
+			"update attempt set success = true, success_at = ? where _id = ? and success = false", time.Now(), job.AttemptID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to mark attempt successful: %v", err)
+		}
+		rows, err := updateRes.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to retrieve updated attempt count: %v", err)
+		}
+		newlySuccessful = rows > 0
+	}
+
+	err = tx.Commit(&callerName)
+	if err != nil {
+		return fmt.Errorf("failed to commit grading results: %v", err)
+	}
+
+	if newlySuccessful {
+		_, err = AddXP(ctx, tidb, js, rdb, sf, job.Owner.ID, "successful", &job.Tier, nil, logger, job.Owner)
+		if err != nil {
+			return fmt.Errorf("failed to add xp to user: %v", err)
+		}
+
+		_, err = CheckLearningPathCompletion(ctx, tidb, js, rdb, sf, job.Owner.ID, job.PostID, logger, job.Owner)
+		if err != nil {
+			return fmt.Errorf("failed to check learning path completion: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// truncateString bounds a string to the passed number of bytes
+func truncateString(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+// getLatestAttemptGrading loads the most recent grading of an attempt along
+// with its test results or nil if the attempt has never been graded
+func getLatestAttemptGrading(ctx context.Context, tidb *ti.Database, attemptId int64) (*AttemptGradingFrontend, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "get-latest-attempt-grading-core")
+	defer span.End()
+	callerName := "getLatestAttemptGrading"
+
+	var gradingId int64
+	var exitCode sql.NullInt64
+	var gradingErr sql.NullString
+	var finishedAt sql.NullTime
+	grading := &AttemptGradingFrontend{
+		AttemptID: fmt.Sprintf("%d", attemptId),
+		Results:   make([]*AttemptTestResult, 0),
+	}
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		"select _id, commit, status, passed, failed, exit_code, error, created_at, finished_at from attempt_grading "+
+			"where attempt_id = ? order by created_at desc limit 1",
+		attemptId,
+	).Scan(&gradingId, &grading.Commit, &grading.Status, &grading.Passed, &grading.Failed, &exitCode, &gradingErr,
+		&grading.CreatedAt, &finishedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query attempt grading: %v", err)
+	}
+
+	grading.ID = fmt.Sprintf("%d", gradingId)
+	grading.StatusString = grading.Status.String()
+	if exitCode.Valid {
+		code := int(exitCode.Int64)
+		grading.ExitCode = &code
+	}
+	if gradingErr.Valid {
+		grading.Error = &gradingErr.String
+	}
+	if finishedAt.Valid {
+		grading.FinishedAt = &finishedAt.Time
+	}
+
+	res, err := tidb.QueryContext(ctx, &span, &callerName,
+		"select name, passed, duration_ms, output from attempt_grading_result where grading_id = ? order by idx",
+		gradingId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query grading results: %v", err)
+	}
+
+	defer res.Close()
+
+	for res.Next() {
+		var durationMs sql.NullInt64
+		var output sql.NullString
+		result := &AttemptTestResult{}
+		err = res.Scan(&result.Name, &result.Passed, &durationMs, &output)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan grading result: %v", err)
+		}
+		if durationMs.Valid {
+			result.DurationMs = &durationMs.Int64
+		}
+		result.Output = output.String
+		grading.Results = append(grading.Results, result)
+	}
+
+	return grading, nil
+}
+
+// GetAttemptGrading retrieves the latest grading of an attempt. The test output
+// exposes the source of the attempt so the grading is only returned to callers
+// that can view the attempt source. ErrNotFound is returned for all others.
+func GetAttemptGrading(ctx context.Context, tidb *ti.Database, callingUser *models.User, attemptId int64) (map[string]interface{}, error) {
+	_, err := authorizeAttemptSource(ctx, tidb, callingUser, attemptId)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrForbidden) {
+			return map[string]interface{}{"message": "attempt not found"}, ErrNotFound
+		}
+		return nil, err
+	}
+
+	grading, err := getLatestAttemptGrading(ctx, tidb, attemptId)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{"grading": grading}, nil
+}
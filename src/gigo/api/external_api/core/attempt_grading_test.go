@@ -0,0 +1,101 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseGradingConfig(t *testing.T) {
+	cfg, err := ParseGradingConfig([]byte("version: 0.1\nbase_container: golang:1.20\n"))
+	if err != nil {
+		t.Fatalf("ParseGradingConfig() error = %v", err)
+	}
+	if cfg != nil {
+		t.Errorf("ParseGradingConfig() = %+v, want nil for a config without tests", cfg)
+	}
+
+	cfg, err = ParseGradingConfig([]byte("version: 0.1\ntests:\n  command: go test -json ./...\n  format: go\n  timeout: 7200\n"))
+	if err != nil {
+		t.Fatalf("ParseGradingConfig() error = %v", err)
+	}
+	if cfg == nil || cfg.Command != "go test -json ./..." || cfg.Format != GradingFormatGo {
+		t.Fatalf("ParseGradingConfig() = %+v, want the go test command", cfg)
+	}
+	if cfg.TimeoutDuration() != maxGradingTimeout {
+		t.Errorf("TimeoutDuration() = %v, want %v", cfg.TimeoutDuration(), maxGradingTimeout)
+	}
+
+	cfg, err = ParseGradingConfig([]byte("tests:\n  command: ./run-tests.sh\n"))
+	if err != nil {
+		t.Fatalf("ParseGradingConfig() error = %v", err)
+	}
+	if cfg.Format != GradingFormatExitCode || cfg.TimeoutDuration() != 5*time.Minute {
+		t.Errorf("ParseGradingConfig() = %+v, want exit code format with the default timeout", cfg)
+	}
+
+	_, err = ParseGradingConfig([]byte("tests:\n  command: ./run-tests.sh\n  format: junit\n"))
+	if err == nil {
+		t.Errorf("ParseGradingConfig() expected error for an unsupported format")
+	}
+}
+
+func TestParseGradingResults(t *testing.T) {
+	goOutput := `{"Action":"run","Package":"example","Test":"TestAdd"}
+{"Action":"output","Package":"example","Test":"TestAdd","Output":"=== RUN   TestAdd\n"}
+{"Action":"pass","Package":"example","Test":"TestAdd","Elapsed":0.01}
+{"Action":"run","Package":"example","Test":"TestSub"}
+{"Action":"output","Package":"example","Test":"TestSub","Output":"    sub_test.go:8: got 1, want 2\n"}
+{"Action":"fail","Package":"example","Test":"TestSub","Elapsed":0.02}
+{"Action":"fail","Package":"example","Elapsed":0.03}`
+
+	results := parseGradingResults(GradingFormatGo, "go test -json ./...", goOutput, 1)
+	if len(results) != 2 {
+		t.Fatalf("parseGradingResults() returned %d results, want 2", len(results))
+	}
+	if results[0].Name != "example/TestAdd" || !results[0].Passed || *results[0].DurationMs != 10 {
+		t.Errorf("parseGradingResults() first result = %+v", results[0])
+	}
+	if results[1].Passed || results[1].Output != "    sub_test.go:8: got 1, want 2\n" {
+		t.Errorf("parseGradingResults() second result = %+v", results[1])
+	}
+
+	tapOutput := "TAP version 13\n1..3\nok 1 - adds numbers\nnot ok 2 - subtracts numbers\n  # expected 2 got 1\nnot ok 3 - divides numbers # TODO not implemented\n"
+	results = parseGradingResults(GradingFormatTAP, "npm test", tapOutput, 1)
+	if len(results) != 3 {
+		t.Fatalf("parseGradingResults() returned %d results, want 3", len(results))
+	}
+	if !results[0].Passed || results[1].Passed || !results[2].Passed {
+		t.Errorf("parseGradingResults() = %+v, %+v, %+v", results[0], results[1], results[2])
+	}
+	if results[1].Output != "  # expected 2 got 1\n" || results[2].Name != "divides numbers" {
+		t.Errorf("parseGradingResults() = %+v, %+v", results[1], results[2])
+	}
+
+	// output that cannot be parsed falls back to the exit code of the command
+	results = parseGradingResults(GradingFormatGo, "go test -json ./...", "# example\n./main.go:3:1: syntax error", 2)
+	if len(results) != 1 || results[0].Passed || results[0].Name != "go test -json ./..." {
+		t.Errorf("parseGradingResults() = %+v, want a single failed result", results)
+	}
+}
+
+func TestMatchGradingTestFile(t *testing.T) {
+	defaults := (&GradingConfig{}).TestFilePatterns()
+	for _, p := range []string{"math_test.go", "pkg/math_test.go", "src/add.test.js", "test_add.py", "tests/fixtures/input.txt", "web/__tests__/app.jsx"} {
+		if !matchGradingTestFile(defaults, p) {
+			t.Errorf("matchGradingTestFile(defaults, %q) = false, want true", p)
+		}
+	}
+	for _, p := range []string{"math.go", "testing.md", "latest/main.py"} {
+		if matchGradingTestFile(defaults, p) {
+			t.Errorf("matchGradingTestFile(defaults, %q) = true, want false", p)
+		}
+	}
+
+	declared := (&GradingConfig{Files: []string{"spec/*.rb", "/checks/"}}).TestFilePatterns()
+	if !matchGradingTestFile(declared, "spec/add.rb") || !matchGradingTestFile(declared, "checks/run.sh") {
+		t.Errorf("matchGradingTestFile() did not match the declared patterns")
+	}
+	if matchGradingTestFile(declared, "lib/spec/add.rb") || matchGradingTestFile(declared, "math_test.go") {
+		t.Errorf("matchGradingTestFile() matched a file outside the declared patterns")
+	}
+}
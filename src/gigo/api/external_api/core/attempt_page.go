@@ -2,8 +2,11 @@ package core
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"io"
+	"strconv"
 
 	"go.opentelemetry.io/otel"
 
@@ -12,12 +15,24 @@ import (
 	ti "github.com/gage-technologies/gigo-lib/db"
 	"github.com/gage-technologies/gigo-lib/db/models"
 	"github.com/gage-technologies/gigo-lib/git"
-	"github.com/gage-technologies/gigo-lib/search"
 )
 
-func ProjectAttemptInformation(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClient, attemptId int64) (map[string]interface{}, error) {
+// ProjectAttemptInformation loads the readme of the project an attempt was made
+// on. ErrNotFound is returned for attempts hidden from the caller by the
+// privacy settings of the author.
+func ProjectAttemptInformation(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClient, callingUser *models.User, attemptId int64) (map[string]interface{}, error) {
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "project-attempt-information-core")
 	callerName := "ProjectAttemptInformation"
+
+	// respect the attempt privacy of the author
+	attemptAuthorId, err := GetAttemptAuthorID(ctx, tidb, attemptId)
+	if err != nil {
+		return nil, err
+	}
+	err = checkAttemptPrivacy(ctx, tidb, callingUser, attemptAuthorId)
+	if err != nil {
+		return nil, err
+	}
 	// query for all active projects for specified user
 	res, err := tidb.QueryContext(ctx, &span, &callerName, "select p.author_id as author_id, p._id as _id, p.challenge_cost as challenge_cost from post p join attempt a on a.post_id = p._id where a._id = ? limit 1", attemptId)
 	if err != nil {
@@ -59,18 +74,48 @@ func ProjectAttemptInformation(ctx context.Context, tidb *ti.Database, vcsClient
 	}, nil
 }
 
-func AttemptInformation(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClient, attemptId int64) (map[string]interface{}, error) {
+// AttemptInformation loads an attempt and its readme. The access is the
+// resolved privacy access of the caller to the author of the attempt and is
+// used to strip the author's stats when they are hidden from the caller.
+// ErrNotFound is returned for attempts that are missing or hidden from the caller.
+func AttemptInformation(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClient, callingUser *models.User, access *PrivacyAccess, attemptId int64) (map[string]interface{}, error) {
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "attempt-information-core")
 	callerName := "AttemptInformation"
+
+	// hidden attempts and attempts of hidden projects are only served to their
+	// authors and the moderators
+	var attemptAuthorId, postAuthorId int64
+	var attemptHidden, postHidden bool
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		"select a.author_id, a.hidden, p.author_id, p.hidden from attempt a join post p on p._id = a.post_id where a._id = ? limit 1", attemptId,
+	).Scan(&attemptAuthorId, &attemptHidden, &postAuthorId, &postHidden)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to query attempt visibility: %v", err)
+	}
+	err = checkHiddenContentAccess(ctx, tidb, callingUser, attemptHidden, attemptAuthorId)
+	if err != nil {
+		return nil, err
+	}
+	err = checkHiddenContentAccess(ctx, tidb, callingUser, postHidden, postAuthorId, attemptAuthorId)
+	if err != nil {
+		return nil, err
+	}
+
 	// query for all active projects for specified user
 	res, err := tidb.QueryContext(ctx, &span, &callerName, "select a._id as _id, post_title, description, author, author_id, a.created_at as created_at, updated_at, repo_id, author_tier, a.coffee as coffee, post_id, closed, success, closed_date, a.tier as tier, parent_attempt, a.workspace_settings, r._id as reward_id, color_palette, render_in_front, name, a.post_type as post_type from attempt a left join users u on a.author_id = u._id left join rewards r on r._id = u.avatar_reward where a._id = ? limit 1", attemptId)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query attempt information: %v", err)
 	}
 
-	// check if post was found with given id
+	// check if attempt was found with given id
 	if res == nil || !res.Next() {
-		return nil, fmt.Errorf("no post found with given id: %v", err)
+		if res != nil {
+			_ = res.Close()
+		}
+		return nil, ErrNotFound
 	}
 
 	// attempt to decode res into post model
@@ -100,17 +145,49 @@ func AttemptInformation(ctx context.Context, tidb *ti.Database, vcsClient *git.V
 	// format post to frontend
 	fp := attempt.ToFrontend()
 
+	// strip the author's tier if their stats are hidden from the caller
+	if access != nil && !access.Stats {
+		fp.AuthorTier = 0
+	}
+
+	// load the latest test results of the attempt
+	grading, err := getLatestAttemptGrading(ctx, tidb, attemptId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load attempt grading: %v", err)
+	}
+
 	return map[string]interface{}{
 		"post":        fp,
 		"description": string(readMeBytes),
+		"grading":     grading,
 	}, nil
 }
 
-func GetAttemptCode(ctx context.Context, vcsClient *git.VCSClient, callingUser *models.User, repo string, ref string, filePath string) (map[string]interface{}, error) {
+// GetAttemptCode lists the contents of an attempt repository at the ref. The
+// repo is the id of the attempt and the contents are only served if the
+// caller is permitted to view the source of the attempt.
+func GetAttemptCode(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClient, callingUser *models.User, repo string, ref string, filePath string) (map[string]interface{}, error) {
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "get-attempt-code-core")
 	defer span.End()
 
-	ownerId := fmt.Sprintf("%d", callingUser.ID)
+	// attempt repositories are named after the id of the attempt
+	attemptId, err := strconv.ParseInt(repo, 10, 64)
+	if err != nil {
+		return map[string]interface{}{"message": "attempt not found"}, ErrNotFound
+	}
+
+	source, err := authorizeAttemptSource(ctx, tidb, callingUser, attemptId)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return map[string]interface{}{"message": "attempt not found"}, err
+		}
+		if errors.Is(err, ErrForbidden) {
+			return map[string]interface{}{"message": "you must purchase the project to view this attempt"}, err
+		}
+		return nil, err
+	}
+
+	ownerId := fmt.Sprintf("%d", source.AuthorID)
 
 	project, _, err := vcsClient.GiteaClient.ListContents(ownerId, repo, ref, filePath)
 	if err != nil {
@@ -120,7 +197,7 @@ func GetAttemptCode(ctx context.Context, vcsClient *git.VCSClient, callingUser *
 	return map[string]interface{}{"message": project}, nil
 }
 
-func EditDescription(ctx context.Context, id int64, meili *search.MeiliSearchEngine, project bool, newDescription string, tidb *ti.Database) (map[string]interface{}, error) {
+func EditDescription(ctx context.Context, id int64, project bool, newDescription string, tidb *ti.Database) (map[string]interface{}, error) {
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "edit-description-http")
 	callerName := "EditDescription"
 
@@ -139,10 +216,10 @@ func EditDescription(ctx context.Context, id int64, meili *search.MeiliSearchEng
 		if err != nil {
 			return nil, fmt.Errorf("failed to edit post description: %v", err)
 		}
-		// update post description in meilisearch
-		err = meili.UpdateDocuments("posts", map[string]interface{}{"_id": id, "description": newDescription})
+		// queue the post to be synced to meilisearch once committed
+		err = queueSearchSync(ctx, tx, callerName, "posts", id)
 		if err != nil {
-			return nil, fmt.Errorf("failed to update post description in meilisearch: %v", err)
+			return nil, err
 		}
 	} else {
 		// update attempt description if the user is not original owner
@@ -90,6 +90,15 @@ func Login(ctx context.Context, tidb *ti.Database, js *mq.JetstreamClient, rdb r
 		}, "", nil
 	}
 
+	// reject the login if the account is suspended
+	suspendedRes, err := checkUserSuspension(ctx, tidb, user.ID)
+	if err != nil {
+		return map[string]interface{}{"message": "Incorrect email or password."}, "", err
+	}
+	if suspendedRes != nil {
+		return suspendedRes, "", nil
+	}
+
 	// generate token for user
 	token := ""
 
@@ -233,6 +242,15 @@ func LoginWithGoogle(ctx context.Context, tidb *ti.Database, js *mq.JetstreamCli
 		}, "", nil
 	}
 
+	// reject the login if the account is suspended
+	suspendedRes, err := checkUserSuspension(ctx, tidb, user.ID)
+	if err != nil {
+		return map[string]interface{}{"message": "Incorrect email or password."}, "", err
+	}
+	if suspendedRes != nil {
+		return suspendedRes, "", nil
+	}
+
 	// generate token for user
 	token := ""
 
@@ -424,6 +442,15 @@ func ConfirmGithubLogin(ctx context.Context, tidb *ti.Database, rdb redis.Univer
 		}, "", nil
 	}
 
+	// reject the login if the account is suspended
+	suspendedRes, err := checkUserSuspension(ctx, tidb, callingUser.ID)
+	if err != nil {
+		return map[string]interface{}{"message": "Incorrect email or password."}, "", err
+	}
+	if suspendedRes != nil {
+		return suspendedRes, "", nil
+	}
+
 	// generate token for user
 	token := ""
 
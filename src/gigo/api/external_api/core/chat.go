@@ -691,6 +691,7 @@ from chat_messages cm
 where 
     cm.chat_id = ? 
   	and cm.created_at < ? 
+  	and cm.hidden = false 
 order by cm.created_at %s 
 limit ?
 `
@@ -0,0 +1,401 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/git"
+	"github.com/gage-technologies/gigo-lib/logging"
+	"github.com/gage-technologies/gigo-lib/mq"
+	"github.com/gage-technologies/gitea-go/gitea"
+	"go.opentelemetry.io/otel"
+)
+
+// CollaboratorRole is the permission level of a collaborator on a post. Roles
+// are ordered so that a higher role includes the permissions of lower roles.
+type CollaboratorRole int
+
+const (
+	// CollaboratorEditor can push to the repository, create workspaces on the
+	// post code source and edit the workspace config
+	CollaboratorEditor CollaboratorRole = iota
+	// CollaboratorMaintainer has the permissions of an editor and can also
+	// invite and remove editors
+	CollaboratorMaintainer
+	// CollaboratorOwner is the author of the post and is never stored in the
+	// collaborator table
+	CollaboratorOwner
+)
+
+func (r CollaboratorRole) String() string {
+	switch r {
+	case CollaboratorEditor:
+		return "editor"
+	case CollaboratorMaintainer:
+		return "maintainer"
+	case CollaboratorOwner:
+		return "owner"
+	}
+	return "unknown"
+}
+
+type InviteCollaboratorRequest struct {
+	ProjectID string           `json:"project_id" validate:"required,number"`
+	UserID    string           `json:"user_id" validate:"required,number"`
+	Role      CollaboratorRole `json:"role" validate:"gte=0,lte=1"`
+	Test      bool             `json:"test"`
+}
+
+type PostCollaboratorFrontend struct {
+	UserID     string    `json:"user_id"`
+	UserName   string    `json:"user_name"`
+	Role       int       `json:"role"`
+	RoleString string    `json:"role_string"`
+	Accepted   bool      `json:"accepted"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// GetPostRole returns the role of the user on the post or nil if the user is
+// neither the author nor an accepted collaborator. ErrNotFound is returned if
+// the post does not exist.
+func GetPostRole(ctx context.Context, tidb *ti.Database, postId int64, userId int64) (*CollaboratorRole, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "get-post-role-core")
+	defer span.End()
+	callerName := "GetPostRole"
+
+	var authorId int64
+	var role sql.NullInt64
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		"select p.author_id, pc.role from post p left join post_collaborator pc on pc.post_id = p._id and pc.user_id = ? and pc.accepted = true "+
+			"where p._id = ? limit 1",
+		userId, postId,
+	).Scan(&authorId, &role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to query post role: %v", err)
+	}
+
+	if authorId == userId {
+		owner := CollaboratorOwner
+		return &owner, nil
+	}
+
+	if !role.Valid {
+		return nil, nil
+	}
+
+	r := CollaboratorRole(role.Int64)
+	return &r, nil
+}
+
+// HasPostPermission checks whether the user holds at least the passed role on
+// the post. The author of the post always has permission.
+func HasPostPermission(ctx context.Context, tidb *ti.Database, postId int64, userId int64, minRole CollaboratorRole) (bool, error) {
+	role, err := GetPostRole(ctx, tidb, postId, userId)
+	if err != nil {
+		if err == ErrNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return role != nil && *role >= minRole, nil
+}
+
+// hasRepoCollaboratorPermission checks whether the user is permitted to edit
+// the repository of a post as a collaborator. Repositories that do not belong
+// to a post, such as attempt repositories, have no collaborators.
+func hasRepoCollaboratorPermission(ctx context.Context, tidb *ti.Database, repoId int64, userId int64) (bool, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "has-repo-collaborator-permission-core")
+	defer span.End()
+	callerName := "hasRepoCollaboratorPermission"
+
+	var postId int64
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		"select _id from post where repo_id = ? limit 1", repoId,
+	).Scan(&postId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to query post for repo %d: %v", repoId, err)
+	}
+
+	return HasPostPermission(ctx, tidb, postId, userId, CollaboratorEditor)
+}
+
+// InviteCollaborator invites a user to collaborate on a post with the passed
+// role. Inviting an existing collaborator changes their role. Only the author
+// can invite maintainers while maintainers can invite editors.
+func InviteCollaborator(ctx context.Context, tidb *ti.Database, js *mq.JetstreamClient, sf *snowflake.Node, callingUser *models.User,
+	postId int64, userId int64, role CollaboratorRole, logger logging.Logger) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "invite-collaborator-core")
+	defer span.End()
+	callerName := "InviteCollaborator"
+
+	if role != CollaboratorEditor && role != CollaboratorMaintainer {
+		return map[string]interface{}{"message": "invalid role"}, fmt.Errorf("invalid collaborator role: %d", role)
+	}
+
+	callerRole, err := GetPostRole(ctx, tidb, postId, callingUser.ID)
+	if err != nil {
+		if err == ErrNotFound {
+			return map[string]interface{}{"message": "project not found"}, err
+		}
+		return nil, err
+	}
+
+	if callerRole == nil || *callerRole < CollaboratorMaintainer || (*callerRole < CollaboratorOwner && role >= CollaboratorMaintainer) {
+		return map[string]interface{}{"message": "you do not have permission to invite collaborators with this role"},
+			fmt.Errorf("user %d cannot invite %s collaborators to post %d", callingUser.ID, role, postId)
+	}
+
+	if userId == callingUser.ID {
+		return map[string]interface{}{"message": "you cannot invite yourself"}, fmt.Errorf("user %d attempted to invite themselves", userId)
+	}
+
+	// load the invitee ensuring that they exist and are not the author
+	var userName string
+	err = tidb.QueryRowContext(ctx, &span, &callerName, "select user_name from users where _id = ? limit 1", userId).Scan(&userName)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return map[string]interface{}{"message": "user not found"}, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to query user: %v", err)
+	}
+
+	existingRole, err := GetPostRole(ctx, tidb, postId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if existingRole != nil && *existingRole == CollaboratorOwner {
+		return map[string]interface{}{"message": "the author cannot be invited"}, fmt.Errorf("user %d is the author of post %d", userId, postId)
+	}
+
+	// maintainers cannot change the role of other maintainers including pending invites
+	var pendingRole CollaboratorRole
+	err = tidb.QueryRowContext(ctx, &span, &callerName,
+		"select role from post_collaborator where post_id = ? and user_id = ? limit 1", postId, userId,
+	).Scan(&pendingRole)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to query existing collaborator: %v", err)
+	}
+
+	if err == nil && pendingRole >= CollaboratorMaintainer && *callerRole < CollaboratorOwner {
+		return map[string]interface{}{"message": "you do not have permission to change the role of this collaborator"},
+			fmt.Errorf("user %d cannot change the role of maintainer %d on post %d", callingUser.ID, userId, postId)
+	}
+
+	insertRes, err := tidb.ExecContext(ctx, &span, &callerName,
+		"insert into post_collaborator(post_id, user_id, role, accepted, invited_by, created_at) values (?, ?, ?, false, ?, ?) "+
+			"on duplicate key update role = values(role)",
+		postId, userId, role, callingUser.ID, time.Now(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert collaborator: %v", err)
+	}
+
+	// only notify the user the first time that they are invited
+	rows, err := insertRes.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check collaborator insertion: %v", err)
+	}
+
+	if rows == 1 {
+		var postTitle string
+		err = tidb.QueryRowContext(ctx, &span, &callerName, "select title from post where _id = ? limit 1", postId).Scan(&postTitle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query post title: %v", err)
+		}
+
+		_, err = CreateNotification(ctx, tidb, js, sf, userId,
+			fmt.Sprintf("%s invited you to collaborate on %s as %s", callingUser.UserName, postTitle, role),
+			CollaboratorInviteNotification, &callingUser.ID,
+		)
+		if err != nil {
+			logger.Errorf("failed to notify user %d of collaborator invite on post %d: %v", userId, postId, err)
+		}
+	}
+
+	return map[string]interface{}{"message": "Collaborator invited successfully."}, nil
+}
+
+// AcceptCollaboratorInvite accepts a pending invite for the calling user and
+// grants them push access to the post repository
+func AcceptCollaboratorInvite(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClient, callingUser *models.User, postId int64) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "accept-collaborator-invite-core")
+	defer span.End()
+	callerName := "AcceptCollaboratorInvite"
+
+	var authorId int64
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		"select p.author_id from post_collaborator pc join post p on p._id = pc.post_id where pc.post_id = ? and pc.user_id = ? and pc.accepted = false limit 1",
+		postId, callingUser.ID,
+	).Scan(&authorId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return map[string]interface{}{"message": "invite not found"}, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to query collaborator invite: %v", err)
+	}
+
+	// grant push access to the repository before accepting so that a failure
+	// leaves the invite pending and can be retried
+	writeAccess := gitea.AccessModeWrite
+	gitRes, err := vcsClient.GiteaClient.AddCollaborator(fmt.Sprintf("%d", authorId), fmt.Sprintf("%d", postId), fmt.Sprintf("%d", callingUser.ID), gitea.AddCollaboratorOption{
+		Permission: &writeAccess,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to grant write access to repository: %v\n    res: %s", err, JsonifyGiteaResponse(gitRes))
+	}
+
+	_, err = tidb.ExecContext(ctx, &span, &callerName,
+		"update post_collaborator set accepted = true where post_id = ? and user_id = ?", postId, callingUser.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to accept collaborator invite: %v", err)
+	}
+
+	return map[string]interface{}{"message": "Invite accepted."}, nil
+}
+
+// RemoveCollaborator removes a collaborator from a post and revokes their
+// access to the post repository. Collaborators can always remove themselves.
+func RemoveCollaborator(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClient, callingUser *models.User, postId int64, userId int64) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "remove-collaborator-core")
+	defer span.End()
+	callerName := "RemoveCollaborator"
+
+	var authorId int64
+	var role CollaboratorRole
+	var accepted bool
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		"select p.author_id, pc.role, pc.accepted from post_collaborator pc join post p on p._id = pc.post_id where pc.post_id = ? and pc.user_id = ? limit 1",
+		postId, userId,
+	).Scan(&authorId, &role, &accepted)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return map[string]interface{}{"message": "collaborator not found"}, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to query collaborator: %v", err)
+	}
+
+	if userId != callingUser.ID {
+		callerRole, err := GetPostRole(ctx, tidb, postId, callingUser.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		// maintainers can only remove editors
+		if callerRole == nil || *callerRole < CollaboratorMaintainer || (*callerRole < CollaboratorOwner && role >= CollaboratorMaintainer) {
+			return map[string]interface{}{"message": "you do not have permission to remove this collaborator"},
+				fmt.Errorf("user %d cannot remove collaborator %d from post %d", callingUser.ID, userId, postId)
+		}
+	}
+
+	if accepted {
+		gitRes, err := vcsClient.GiteaClient.DeleteCollaborator(fmt.Sprintf("%d", authorId), fmt.Sprintf("%d", postId), fmt.Sprintf("%d", userId))
+		if err != nil {
+			return nil, fmt.Errorf("failed to revoke access to repository: %v\n    res: %s", err, JsonifyGiteaResponse(gitRes))
+		}
+	}
+
+	_, err = tidb.ExecContext(ctx, &span, &callerName,
+		"delete from post_collaborator where post_id = ? and user_id = ?", postId, userId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete collaborator: %v", err)
+	}
+
+	return map[string]interface{}{"message": "Collaborator removed."}, nil
+}
+
+// GetPostCollaborators retrieves the collaborators of a post. Pending invites
+// are only included when includePending is set.
+func GetPostCollaborators(ctx context.Context, tidb *ti.Database, postId int64, includePending bool) ([]*PostCollaboratorFrontend, error) {
+	collaborators, err := getCollaborators(ctx, tidb, []int64{postId}, includePending)
+	if err != nil {
+		return nil, err
+	}
+	return collaborators[postId], nil
+}
+
+// getCollaborators loads the collaborators for a set of posts keyed by post id
+func getCollaborators(ctx context.Context, tidb *ti.Database, postIds []int64, includePending bool) (map[int64][]*PostCollaboratorFrontend, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "get-collaborators-core")
+	defer span.End()
+	callerName := "getCollaborators"
+
+	collaborators := make(map[int64][]*PostCollaboratorFrontend)
+	for _, id := range postIds {
+		collaborators[id] = make([]*PostCollaboratorFrontend, 0)
+	}
+
+	if len(postIds) == 0 {
+		return collaborators, nil
+	}
+
+	params := make([]interface{}, 0, len(postIds))
+	for _, id := range postIds {
+		params = append(params, id)
+	}
+
+	query := "select pc.post_id, pc.user_id, u.user_name, pc.role, pc.accepted, pc.created_at from post_collaborator pc " +
+		"join users u on u._id = pc.user_id where pc.post_id in (?" + strings.Repeat(", ?", len(postIds)-1) + ")"
+	if !includePending {
+		query += " and pc.accepted = true"
+	}
+	query += " order by pc.role desc, pc.created_at"
+
+	res, err := tidb.QueryContext(ctx, &span, &callerName, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query collaborators: %v", err)
+	}
+
+	defer res.Close()
+
+	for res.Next() {
+		var postId int64
+		var userId int64
+		var role CollaboratorRole
+		c := &PostCollaboratorFrontend{}
+		err = res.Scan(&postId, &userId, &c.UserName, &role, &c.Accepted, &c.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan collaborator: %v", err)
+		}
+		c.UserID = fmt.Sprintf("%d", userId)
+		c.Role = int(role)
+		c.RoleString = role.String()
+		collaborators[postId] = append(collaborators[postId], c)
+	}
+
+	return collaborators, nil
+}
+
+// GetProjectCollaborators retrieves the collaborators of a post. The author and
+// maintainers can also see pending invites.
+func GetProjectCollaborators(ctx context.Context, tidb *ti.Database, callingUser *models.User, postId int64) (map[string]interface{}, error) {
+	includePending := false
+	if callingUser != nil {
+		ok, err := HasPostPermission(ctx, tidb, postId, callingUser.ID, CollaboratorMaintainer)
+		if err != nil {
+			return nil, err
+		}
+		includePending = ok
+	}
+
+	collaborators, err := GetPostCollaborators(ctx, tidb, postId, includePending)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{"collaborators": collaborators}, nil
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gigo-core/gigo/migrations"
+
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/db/models"
+)
+
+func TestHasPostPermission(t *testing.T) {
+	testTiDB, err := ti.CreateDatabase("gigo-dev-tidb", "4000", "mysql", "gigo-dev",
+		"gigo-dev",
+		"gigo_test_db")
+	if err != nil {
+		t.Fatal("Initialize test database failed:", err)
+	}
+
+	err = migrations.Run(testTiDB)
+	if err != nil {
+		t.Fatal("Migrate test database failed:", err)
+	}
+
+	post, err := models.CreatePost(69, "title", "content", "author", 420, time.Now(), time.Now(), 69, 5, []int64{}, nil,
+		42069, 2, 6969, 6900, 4206969, []models.ProgrammingLanguage{models.Go}, models.PublicVisibility, []int64{},
+		nil, nil, 0, 0, nil, false, false, nil)
+	if err != nil {
+		t.Fatalf("failed to create post: %v", err)
+	}
+
+	stmts, err := post.ToSQLNative()
+	if err != nil {
+		t.Fatalf("failed to format post: %v", err)
+	}
+
+	for _, s := range stmts {
+		_, err = testTiDB.DB.Exec(s.Statement, s.Values...)
+		if err != nil {
+			t.Fatalf("failed to insert post: %v", err)
+		}
+	}
+
+	defer func() {
+		_, _ = testTiDB.DB.Exec("DELETE FROM post WHERE _id = ?", post.ID)
+		_, _ = testTiDB.DB.Exec("DELETE FROM post_collaborator WHERE post_id = ?", post.ID)
+	}()
+
+	// 1 is an accepted editor, 2 is an accepted maintainer and 3 has a pending maintainer invite
+	_, err = testTiDB.DB.Exec(
+		"insert into post_collaborator(post_id, user_id, role, accepted, invited_by, created_at) values (?, ?, ?, ?, ?, ?), (?, ?, ?, ?, ?, ?), (?, ?, ?, ?, ?, ?)",
+		post.ID, 1, CollaboratorEditor, true, post.AuthorID, time.Now(),
+		post.ID, 2, CollaboratorMaintainer, true, post.AuthorID, time.Now(),
+		post.ID, 3, CollaboratorMaintainer, false, post.AuthorID, time.Now(),
+	)
+	if err != nil {
+		t.Fatalf("failed to insert collaborators: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		userId  int64
+		minRole CollaboratorRole
+		want    bool
+	}{
+		{"author is owner", post.AuthorID, CollaboratorOwner, true},
+		{"editor can edit", 1, CollaboratorEditor, true},
+		{"editor cannot maintain", 1, CollaboratorMaintainer, false},
+		{"maintainer can maintain", 2, CollaboratorMaintainer, true},
+		{"maintainer is not owner", 2, CollaboratorOwner, false},
+		{"pending invite has no access", 3, CollaboratorEditor, false},
+		{"stranger has no access", 4, CollaboratorEditor, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := HasPostPermission(context.Background(), testTiDB, post.ID, tt.userId, tt.minRole)
+			if err != nil {
+				t.Fatalf("HasPostPermission() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("HasPostPermission() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	ok, err := HasPostPermission(context.Background(), testTiDB, post.ID+1, post.AuthorID, CollaboratorEditor)
+	if err != nil || ok {
+		t.Errorf("HasPostPermission() = %v, %v, want false for a missing post", ok, err)
+	}
+
+	collaborators, err := GetPostCollaborators(context.Background(), testTiDB, post.ID, false)
+	if err != nil {
+		t.Fatalf("GetPostCollaborators() error = %v", err)
+	}
+	for _, c := range collaborators {
+		if !c.Accepted {
+			t.Errorf("GetPostCollaborators() returned pending invite %+v", c)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/logging"
+	"github.com/gage-technologies/gigo-lib/mq"
+	"github.com/go-redis/redis/v8"
+	"github.com/kisielk/sqlstruct"
+	"go.opentelemetry.io/otel"
+)
+
+type AcceptDiscussionAnswerRequest struct {
+	CommentID string `json:"comment_id" validate:"required,number"`
+	Test      bool   `json:"test"`
+}
+
+type UnacceptDiscussionAnswerRequest struct {
+	DiscussionID string `json:"discussion_id" validate:"required,number"`
+	Test         bool   `json:"test"`
+}
+
+type UnansweredDiscussionsRequest struct {
+	PostID string `json:"post_id" validate:"required,number"`
+	Skip   int    `json:"skip" validate:"gte=0"`
+	Limit  int    `json:"limit" validate:"gt=0,lte=50"`
+	Test   bool   `json:"test"`
+}
+
+// discussionAnswerTarget holds the ids needed to check who can manage the
+// accepted answer of a discussion
+type discussionAnswerTarget struct {
+	discussionId       int64
+	discussionAuthorId int64
+	postId             int64
+	postAuthorId       int64
+}
+
+// loadDiscussionAnswerTarget retrieves the authors of a discussion and the
+// post it belongs to
+func loadDiscussionAnswerTarget(ctx context.Context, tidb *ti.Database, discussionId int64) (*discussionAnswerTarget, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "load-discussion-answer-target-core")
+	defer span.End()
+	callerName := "loadDiscussionAnswerTarget"
+
+	target := discussionAnswerTarget{discussionId: discussionId}
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		"select d.author_id, d.post_id, p.author_id from discussion d join post p on p._id = d.post_id "+
+			"where d._id = ? and d.hidden = false order by d.revision desc limit 1",
+		discussionId,
+	).Scan(&target.discussionAuthorId, &target.postId, &target.postAuthorId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to query discussion: %v", err)
+	}
+
+	return &target, nil
+}
+
+// canManageDiscussionAnswer returns true if the user asked the question or
+// created the post that the question was asked on
+func (t *discussionAnswerTarget) canManageDiscussionAnswer(user *models.User) bool {
+	return user.ID == t.discussionAuthorId || user.ID == t.postAuthorId
+}
+
+// recordDiscussionAnswerAward records that the accepted answer of a discussion
+// earned xp. False is returned if an answer of the discussion was already
+// awarded so that xp is only granted once per question.
+func recordDiscussionAnswerAward(ctx context.Context, tidb *ti.Database, discussionId int64, commentId int64, userId int64) (bool, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "record-discussion-answer-award-core")
+	defer span.End()
+	callerName := "recordDiscussionAnswerAward"
+
+	res, err := tidb.ExecContext(ctx, &span, &callerName,
+		"insert ignore into discussion_answer_award(discussion_id, comment_id, user_id, awarded_at) values (?, ?, ?, ?)",
+		discussionId, commentId, userId, time.Now(),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to record accepted answer xp: %v", err)
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check accepted answer xp: %v", err)
+	}
+
+	return inserted > 0, nil
+}
+
+// AcceptDiscussionAnswer marks a comment as the accepted answer of its
+// discussion replacing any previously accepted answer. The author of the
+// first accepted answer of a discussion is granted xp unless they accepted
+// their own answer.
+func AcceptDiscussionAnswer(ctx context.Context, tidb *ti.Database, js *mq.JetstreamClient, rdb redis.UniversalClient, sf *snowflake.Node,
+	callingUser *models.User, logger logging.Logger, commentId int64) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "accept-discussion-answer-core")
+	defer span.End()
+	callerName := "AcceptDiscussionAnswer"
+
+	var discussionId, answerAuthorId int64
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		"select discussion_id, author_id from comment where _id = ? and hidden = false and deleted = false order by revision desc limit 1", commentId,
+	).Scan(&discussionId, &answerAuthorId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return map[string]interface{}{"message": "comment not found"}, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to query comment: %v", err)
+	}
+
+	target, err := loadDiscussionAnswerTarget(ctx, tidb, discussionId)
+	if err != nil {
+		if err == ErrNotFound {
+			return map[string]interface{}{"message": "discussion not found"}, err
+		}
+		return nil, err
+	}
+
+	if !target.canManageDiscussionAnswer(callingUser) {
+		return map[string]interface{}{"message": "only the author of the discussion or the challenge can accept an answer"},
+			fmt.Errorf("user %d cannot accept answers on discussion %d: %w", callingUser.ID, discussionId, ErrForbidden)
+	}
+
+	_, err = tidb.ExecContext(ctx, &span, &callerName,
+		"insert into discussion_answer(discussion_id, post_id, comment_id, accepted_by, accepted_at) values (?, ?, ?, ?, ?) "+
+			"on duplicate key update comment_id = values(comment_id), accepted_by = values(accepted_by), accepted_at = values(accepted_at)",
+		discussionId, target.postId, commentId, callingUser.ID, time.Now(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to accept answer: %v", err)
+	}
+
+	// users cannot earn xp by answering and accepting their own questions
+	if answerAuthorId == callingUser.ID {
+		return map[string]interface{}{"message": "Answer accepted"}, nil
+	}
+
+	awarded, err := recordDiscussionAnswerAward(ctx, tidb, discussionId, commentId, answerAuthorId)
+	if err != nil {
+		return nil, err
+	}
+	if !awarded {
+		return map[string]interface{}{"message": "Answer accepted"}, nil
+	}
+
+	xpRes, err := AddXP(ctx, tidb, js, rdb, sf, answerAuthorId, "accepted_answer", nil, nil, logger, callingUser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add accepted answer xp: %v", err)
+	}
+
+	return map[string]interface{}{"message": "Answer accepted", "xp": xpRes}, nil
+}
+
+// UnacceptDiscussionAnswer removes the accepted answer of a discussion. Xp
+// that was granted for the answer is kept.
+func UnacceptDiscussionAnswer(ctx context.Context, tidb *ti.Database, callingUser *models.User, discussionId int64) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "unaccept-discussion-answer-core")
+	defer span.End()
+	callerName := "UnacceptDiscussionAnswer"
+
+	target, err := loadDiscussionAnswerTarget(ctx, tidb, discussionId)
+	if err != nil {
+		if err == ErrNotFound {
+			return map[string]interface{}{"message": "discussion not found"}, err
+		}
+		return nil, err
+	}
+
+	if !target.canManageDiscussionAnswer(callingUser) {
+		return map[string]interface{}{"message": "only the author of the discussion or the challenge can remove the accepted answer"},
+			fmt.Errorf("user %d cannot remove answers on discussion %d: %w", callingUser.ID, discussionId, ErrForbidden)
+	}
+
+	_, err = tidb.ExecContext(ctx, &span, &callerName, "delete from discussion_answer where discussion_id = ?", discussionId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove accepted answer: %v", err)
+	}
+
+	return map[string]interface{}{"message": "Accepted answer removed"}, nil
+}
+
+// discussionAcceptedAnswers maps the ids of discussions to the ids of their
+// accepted answers
+func discussionAcceptedAnswers(ctx context.Context, tidb *ti.Database, discussionIds []int64) (map[string]string, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "discussion-accepted-answers-core")
+	defer span.End()
+	callerName := "discussionAcceptedAnswers"
+
+	answers := make(map[string]string)
+	if len(discussionIds) == 0 {
+		return answers, nil
+	}
+
+	params := make([]interface{}, 0, len(discussionIds))
+	for _, id := range discussionIds {
+		params = append(params, id)
+	}
+
+	rows, err := tidb.QueryContext(ctx, &span, &callerName,
+		"select discussion_id, comment_id from discussion_answer where discussion_id in (?"+strings.Repeat(", ?", len(discussionIds)-1)+")",
+		params...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query accepted answers: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var discussionId, commentId int64
+		err = rows.Scan(&discussionId, &commentId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan accepted answer: %v", err)
+		}
+		answers[strconv.FormatInt(discussionId, 10)] = strconv.FormatInt(commentId, 10)
+	}
+
+	return answers, nil
+}
+
+// GetUnansweredDiscussions lists the discussions on a post that do not have an
+// accepted answer with the newest discussion first
+func GetUnansweredDiscussions(ctx context.Context, tidb *ti.Database, postId int64, skip int, limit int) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "get-unanswered-discussions-core")
+	defer span.End()
+	callerName := "GetUnansweredDiscussions"
+
+	res, err := tidb.QueryContext(ctx, &span, &callerName,
+		"select d.*, r._id as reward_id, color_palette, name, render_in_front, user_status from discussion d "+
+			"inner join (select _id, max(revision) as revision from discussion where post_id = ? group by _id) t on d._id = t._id and d.revision = t.revision "+
+			"left join discussion_answer a on a.discussion_id = d._id left join users u on d.author_id = u._id left join rewards r on r._id = u.avatar_reward "+
+			"where d.hidden = false and d.deleted = false and a.discussion_id is null order by d.created_at desc limit ? offset ?",
+		postId, limit, skip,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unanswered discussions: %v", err)
+	}
+	defer res.Close()
+
+	discussions := make([]*models.DiscussionBackgroundFrontend, 0)
+	bodyHTML := make(map[string]string)
+	for res.Next() {
+		var discussion struct {
+			models.DiscussionBackground
+			BodyHTML sql.NullString `sql:"body_html"`
+		}
+
+		err = sqlstruct.Scan(&discussion, res)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode unanswered discussion: %v", err)
+		}
+
+		discussions = append(discussions, discussion.ToFrontend())
+		bodyHTML[strconv.FormatInt(discussion.ID, 10)] = discussionBodyHTML(discussion.BodyHTML, discussion.Body)
+	}
+
+	return map[string]interface{}{"discussions": discussions, "body_html": bodyHTML}, nil
+}
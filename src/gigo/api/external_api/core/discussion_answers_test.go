@@ -0,0 +1,70 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/db/models"
+)
+
+func TestCanManageDiscussionAnswer(t *testing.T) {
+	target := discussionAnswerTarget{discussionId: 1, discussionAuthorId: 69, postId: 2, postAuthorId: 70}
+
+	tests := []struct {
+		name   string
+		userId int64
+		want   bool
+	}{
+		{"discussion author", 69, true},
+		{"post author", 70, true},
+		{"other user", 71, false},
+	}
+
+	for _, tt := range tests {
+		if got := target.canManageDiscussionAnswer(&models.User{ID: tt.userId}); got != tt.want {
+			t.Errorf("\nTestCanManageDiscussionAnswer failed\n    Case: %s\n    Error: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRecordDiscussionAnswerAward(t *testing.T) {
+	testTiDB, err := ti.CreateDatabase("gigo-dev-tidb", "4000", "mysql", "gigo-dev",
+		"gigo-dev",
+		"gigo_test_db")
+	if err != nil {
+		t.Fatal("Initialize test database failed:", err)
+	}
+
+	defer func() {
+		_, _ = testTiDB.DB.Exec("delete from discussion_answer_award where discussion_id in (42069, 42070)")
+	}()
+
+	awarded, err := recordDiscussionAnswerAward(context.Background(), testTiDB, 42069, 1, 69)
+	if err != nil {
+		t.Errorf("\nTestRecordDiscussionAnswerAward failed\n    Error: %v\n", err)
+		return
+	}
+	if !awarded {
+		t.Error("\nTestRecordDiscussionAnswerAward failed\n    Error: first accepted answer was not awarded")
+	}
+
+	// moving the accepted answer to another comment does not grant xp again
+	awarded, err = recordDiscussionAnswerAward(context.Background(), testTiDB, 42069, 2, 70)
+	if err != nil {
+		t.Errorf("\nTestRecordDiscussionAnswerAward failed\n    Error: %v\n", err)
+		return
+	}
+	if awarded {
+		t.Error("\nTestRecordDiscussionAnswerAward failed\n    Error: second accepted answer of a discussion was awarded")
+	}
+
+	awarded, err = recordDiscussionAnswerAward(context.Background(), testTiDB, 42070, 2, 70)
+	if err != nil {
+		t.Errorf("\nTestRecordDiscussionAnswerAward failed\n    Error: %v\n", err)
+		return
+	}
+	if !awarded {
+		t.Error("\nTestRecordDiscussionAnswerAward failed\n    Error: answer of another discussion was not awarded")
+	}
+}
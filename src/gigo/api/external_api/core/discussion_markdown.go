@@ -0,0 +1,315 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/text"
+	"go.opentelemetry.io/otel"
+)
+
+// maxDiscussionMentions caps the number of users that a single body can
+// notify so that a post cannot be used to spam the whole community
+const maxDiscussionMentions = 20
+
+// maxDiscussionPostReferences caps the number of posts that a single body can
+// link so that the lookup of the referenced posts stays bounded
+const maxDiscussionPostReferences = 20
+
+var (
+	mentionRegex       = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9_-]{1,39})`)
+	postReferenceRegex = regexp.MustCompile(`(?:^|[^\w&#])#(\d{1,19})\b`)
+
+	discussionMarkdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+	// discussionHTMLPolicy strips everything that is not safe to render from
+	// user generated content while keeping the classes used to style links
+	// created for mentions and post references
+	discussionHTMLPolicy = func() *bluemonday.Policy {
+		p := bluemonday.UGCPolicy()
+		p.AllowAttrs("class").Matching(regexp.MustCompile(`^(mention|post-reference)$`)).OnElements("a")
+		return p
+	}()
+)
+
+// discussionMarkdownMatch is a mention or post reference found in the text of
+// a markdown body
+type discussionMarkdownMatch struct {
+	start  int
+	stop   int
+	target string
+	class  string
+}
+
+// renderedDiscussionMarkdown is the sanitized html of a discussion body along
+// with the users that were mentioned in it
+type renderedDiscussionMarkdown struct {
+	HTML     string
+	Mentions []int64
+}
+
+// discussionTextRuns collects the runs of adjacent text nodes in a markdown
+// document that are eligible for linking. The parser splits text on the
+// characters that may start inline elements so neighbouring text nodes are
+// grouped to allow names like foo_bar to be matched. Text inside of code
+// and existing links is never linked.
+func discussionTextRuns(doc ast.Node) [][]*ast.Text {
+	runs := make([][]*ast.Text, 0)
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch n.Kind() {
+		case ast.KindCodeSpan, ast.KindCodeBlock, ast.KindFencedCodeBlock, ast.KindLink, ast.KindAutoLink, ast.KindImage:
+			return ast.WalkSkipChildren, nil
+		case ast.KindText:
+		default:
+			return ast.WalkContinue, nil
+		}
+
+		// skip text that was already added as part of a previous run
+		if prev, ok := n.PreviousSibling().(*ast.Text); ok && discussionTextContiguous(prev, n.(*ast.Text)) {
+			return ast.WalkContinue, nil
+		}
+
+		run := []*ast.Text{n.(*ast.Text)}
+		for next, ok := n.NextSibling().(*ast.Text); ok && discussionTextContiguous(run[len(run)-1], next); next, ok = next.NextSibling().(*ast.Text) {
+			run = append(run, next)
+		}
+		runs = append(runs, run)
+
+		return ast.WalkContinue, nil
+	})
+	return runs
+}
+
+// discussionTextContiguous returns true if b directly follows a in the source
+func discussionTextContiguous(a *ast.Text, b *ast.Text) bool {
+	return !a.SoftLineBreak() && !a.HardLineBreak() && !a.IsRaw() && !b.IsRaw() && a.Segment.Stop == b.Segment.Start
+}
+
+// discussionMarkdownCandidates returns the usernames and post ids referenced
+// in the text of a markdown document. Only the first maxDiscussionMentions
+// usernames and maxDiscussionPostReferences post ids are returned.
+func discussionMarkdownCandidates(source []byte, runs [][]*ast.Text) ([]string, []int64) {
+	usernames := make([]string, 0)
+	postIds := make([]int64, 0)
+	seenUsers := make(map[string]bool)
+	seenPosts := make(map[int64]bool)
+
+	for _, run := range runs {
+		value := source[run[0].Segment.Start:run[len(run)-1].Segment.Stop]
+
+		for _, m := range mentionRegex.FindAllSubmatch(value, -1) {
+			name := strings.ToLower(string(m[1]))
+			if !seenUsers[name] && len(usernames) < maxDiscussionMentions {
+				seenUsers[name] = true
+				usernames = append(usernames, string(m[1]))
+			}
+		}
+
+		for _, m := range postReferenceRegex.FindAllSubmatch(value, -1) {
+			id, err := strconv.ParseInt(string(m[1]), 10, 64)
+			if err != nil || seenPosts[id] || len(postIds) >= maxDiscussionPostReferences {
+				continue
+			}
+			seenPosts[id] = true
+			postIds = append(postIds, id)
+		}
+	}
+
+	return usernames, postIds
+}
+
+// linkDiscussionTextRun replaces the mentions and post references in a run of
+// text nodes with links. Only users and posts that exist are linked.
+func linkDiscussionTextRun(source []byte, run []*ast.Text, users map[string]int64, posts map[int64]bool) {
+	start := run[0].Segment.Start
+	stop := run[len(run)-1].Segment.Stop
+	value := source[start:stop]
+
+	matches := make([]discussionMarkdownMatch, 0)
+	for _, m := range mentionRegex.FindAllSubmatchIndex(value, -1) {
+		id, ok := users[strings.ToLower(string(value[m[2]:m[3]]))]
+		if !ok {
+			continue
+		}
+		// include the @ in the link text
+		matches = append(matches, discussionMarkdownMatch{start: m[2] - 1, stop: m[3], target: fmt.Sprintf("/user/%d", id), class: "mention"})
+	}
+	for _, m := range postReferenceRegex.FindAllSubmatchIndex(value, -1) {
+		id, err := strconv.ParseInt(string(value[m[2]:m[3]]), 10, 64)
+		if err != nil || !posts[id] {
+			continue
+		}
+		matches = append(matches, discussionMarkdownMatch{start: m[2] - 1, stop: m[3], target: fmt.Sprintf("/challenge/%d", id), class: "post-reference"})
+	}
+
+	if len(matches) == 0 {
+		return
+	}
+
+	// order the matches by position so the text can be rebuilt in one pass
+	for i := 1; i < len(matches); i++ {
+		for j := i; j > 0 && matches[j].start < matches[j-1].start; j-- {
+			matches[j], matches[j-1] = matches[j-1], matches[j]
+		}
+	}
+
+	parent := run[0].Parent()
+	last := run[len(run)-1]
+	cursor := 0
+	for _, match := range matches {
+		if match.start < cursor {
+			continue
+		}
+
+		if match.start > cursor {
+			parent.InsertBefore(parent, run[0], ast.NewTextSegment(text.NewSegment(start+cursor, start+match.start)))
+		}
+
+		link := ast.NewLink()
+		link.Destination = []byte(match.target)
+		link.SetAttributeString("class", []byte(match.class))
+		link.AppendChild(link, ast.NewTextSegment(text.NewSegment(start+match.start, start+match.stop)))
+		parent.InsertBefore(parent, run[0], link)
+
+		cursor = match.stop
+	}
+
+	// the trailing text keeps the line break of the run it replaces
+	tail := ast.NewTextSegment(text.NewSegment(start+cursor, stop))
+	tail.SetSoftLineBreak(last.SoftLineBreak())
+	tail.SetHardLineBreak(last.HardLineBreak())
+	parent.InsertBefore(parent, run[0], tail)
+
+	for _, node := range run {
+		parent.RemoveChild(parent, node)
+	}
+}
+
+// renderDiscussionBody renders a markdown body to sanitized html linking the
+// mentions of the passed users and references to the passed posts. The ids
+// of the mentioned users are returned in the order they first appear.
+func renderDiscussionBody(body string, users map[string]int64, posts map[int64]bool) (*renderedDiscussionMarkdown, error) {
+	source := []byte(body)
+	doc := discussionMarkdown.Parser().Parse(text.NewReader(source))
+
+	runs := discussionTextRuns(doc)
+	for _, run := range runs {
+		linkDiscussionTextRun(source, run, users, posts)
+	}
+
+	var buf bytes.Buffer
+	err := discussionMarkdown.Renderer().Render(&buf, source, doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render markdown: %v", err)
+	}
+
+	usernames, _ := discussionMarkdownCandidates(source, runs)
+	mentions := make([]int64, 0)
+	for _, name := range usernames {
+		if id, ok := users[strings.ToLower(name)]; ok {
+			mentions = append(mentions, id)
+		}
+	}
+
+	return &renderedDiscussionMarkdown{
+		HTML:     discussionHTMLPolicy.Sanitize(buf.String()),
+		Mentions: mentions,
+	}, nil
+}
+
+// renderDiscussionMarkdown renders the markdown body of a discussion, comment,
+// thread comment or thread reply to sanitized html. Mentions of existing users
+// link to their profile and references to published posts link to the post.
+func renderDiscussionMarkdown(ctx context.Context, tidb *ti.Database, body string) (*renderedDiscussionMarkdown, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "render-discussion-markdown-core")
+	defer span.End()
+	callerName := "renderDiscussionMarkdown"
+
+	source := []byte(body)
+	usernames, postIds := discussionMarkdownCandidates(source, discussionTextRuns(discussionMarkdown.Parser().Parse(text.NewReader(source))))
+
+	users := make(map[string]int64)
+	if len(usernames) > 0 {
+		params := make([]interface{}, 0, len(usernames))
+		for _, name := range usernames {
+			params = append(params, name)
+		}
+
+		res, err := tidb.QueryContext(ctx, &span, &callerName,
+			"select _id, user_name from users where user_name in (?"+strings.Repeat(", ?", len(usernames)-1)+")",
+			params...,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query mentioned users: %v", err)
+		}
+
+		for res.Next() {
+			var id int64
+			var name string
+			err = res.Scan(&id, &name)
+			if err != nil {
+				_ = res.Close()
+				return nil, fmt.Errorf("failed to scan mentioned user: %v", err)
+			}
+			users[strings.ToLower(name)] = id
+		}
+		_ = res.Close()
+	}
+
+	posts := make(map[int64]bool)
+	if len(postIds) > 0 {
+		params := make([]interface{}, 0, len(postIds))
+		for _, id := range postIds {
+			params = append(params, id)
+		}
+
+		res, err := tidb.QueryContext(ctx, &span, &callerName,
+			"select _id from post where _id in (?"+strings.Repeat(", ?", len(postIds)-1)+") and published = true and deleted = false and hidden = false",
+			params...,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query referenced posts: %v", err)
+		}
+
+		for res.Next() {
+			var id int64
+			err = res.Scan(&id)
+			if err != nil {
+				_ = res.Close()
+				return nil, fmt.Errorf("failed to scan referenced post: %v", err)
+			}
+			posts[id] = true
+		}
+		_ = res.Close()
+	}
+
+	return renderDiscussionBody(body, users, posts)
+}
+
+// discussionBodyHTML returns the stored html of a body or renders it without
+// links for content created before bodies were rendered on write
+func discussionBodyHTML(stored sql.NullString, body string) string {
+	if stored.Valid {
+		return stored.String
+	}
+
+	rendered, err := renderDiscussionBody(body, nil, nil)
+	if err != nil {
+		return discussionHTMLPolicy.Sanitize(body)
+	}
+	return rendered.HTML
+}
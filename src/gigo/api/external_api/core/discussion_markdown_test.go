@@ -0,0 +1,77 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark/text"
+)
+
+func TestRenderDiscussionBody(t *testing.T) {
+	users := map[string]int64{"alice": 1, "bob_smith": 2}
+	posts := map[int64]bool{42: true}
+
+	body := "Hey @Alice and @bob_smith, see #42 and #43.\n\n" +
+		"`@alice` is code and so is\n\n```\n@bob_smith #42\n```\n\n" +
+		"[@alice](https://example.com) mail alice@example.com or @nobody\n\n" +
+		"<script>alert('x')</script><img src=x onerror=alert(1)>"
+
+	rendered, err := renderDiscussionBody(body, users, posts)
+	if err != nil {
+		t.Fatalf("\nTestRenderDiscussionBody failed\n    Error: %v", err)
+	}
+
+	for _, want := range []string{
+		`<a href="/user/1" class="mention" rel="nofollow">@Alice</a>`,
+		`<a href="/user/2" class="mention" rel="nofollow">@bob_smith</a>`,
+		`<a href="/challenge/42" class="post-reference" rel="nofollow">#42</a>`,
+		"#43.",
+		"<code>@alice</code>",
+		"@bob_smith #42\n</code>",
+		`<a href="https://example.com" rel="nofollow">@alice</a>`,
+		"@nobody",
+	} {
+		if !strings.Contains(rendered.HTML, want) {
+			t.Errorf("\nTestRenderDiscussionBody failed\n    Error: missing %q in %s", want, rendered.HTML)
+		}
+	}
+
+	for _, unwanted := range []string{"<script", "onerror", "/user/1\" class=\"mention\" rel=\"nofollow\">@alice"} {
+		if strings.Contains(rendered.HTML, unwanted) {
+			t.Errorf("\nTestRenderDiscussionBody failed\n    Error: found %q in %s", unwanted, rendered.HTML)
+		}
+	}
+
+	if !reflect.DeepEqual(rendered.Mentions, []int64{1, 2}) {
+		t.Errorf("\nTestRenderDiscussionBody failed\n    Error: unexpected mentions %v", rendered.Mentions)
+	}
+}
+
+func TestDiscussionMarkdownCandidates(t *testing.T) {
+	source := []byte("@foo_bar @Foo_Bar #7 #7 x#8 &#9; `@code` @baz")
+	usernames, postIds := discussionMarkdownCandidates(source, discussionTextRuns(discussionMarkdown.Parser().Parse(text.NewReader(source))))
+
+	if !reflect.DeepEqual(usernames, []string{"foo_bar", "baz"}) {
+		t.Errorf("\nTestDiscussionMarkdownCandidates failed\n    Error: unexpected usernames %v", usernames)
+	}
+	if !reflect.DeepEqual(postIds, []int64{7}) {
+		t.Errorf("\nTestDiscussionMarkdownCandidates failed\n    Error: unexpected post ids %v", postIds)
+	}
+
+	// the lookups are bounded no matter how many candidates a body holds
+	var many strings.Builder
+	for i := 0; i < maxDiscussionMentions+5; i++ {
+		fmt.Fprintf(&many, "@user%d #%d ", i, i+1)
+	}
+	source = []byte(many.String())
+	usernames, postIds = discussionMarkdownCandidates(source, discussionTextRuns(discussionMarkdown.Parser().Parse(text.NewReader(source))))
+
+	if len(usernames) != maxDiscussionMentions || usernames[0] != "user0" {
+		t.Errorf("\nTestDiscussionMarkdownCandidates failed\n    Error: unexpected capped usernames %v", usernames)
+	}
+	if len(postIds) != maxDiscussionPostReferences || postIds[0] != 1 {
+		t.Errorf("\nTestDiscussionMarkdownCandidates failed\n    Error: unexpected capped post ids %v", postIds)
+	}
+}
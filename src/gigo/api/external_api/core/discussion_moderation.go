@@ -0,0 +1,287 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"go.opentelemetry.io/otel"
+)
+
+// deletedDiscussionBody replaces the body of deleted discussion content so
+// that the replies below it keep their place in the discussion
+const deletedDiscussionBody = "[deleted]"
+
+type DeleteDiscussionContentRequest struct {
+	DiscussionType string `json:"discussion_type" validate:"required,oneof=discussion comment thread_comment thread_reply"`
+	ID             string `json:"id" validate:"required,number"`
+	Test           bool   `json:"test"`
+}
+
+type ModerateDiscussionContentRequest struct {
+	DiscussionType string `json:"discussion_type" validate:"required,oneof=discussion comment thread_comment thread_reply"`
+	ID             string `json:"id" validate:"required,number"`
+	Test           bool   `json:"test"`
+}
+
+type LockDiscussionRequest struct {
+	DiscussionID string `json:"discussion_id" validate:"required,number"`
+	Test         bool   `json:"test"`
+}
+
+// redactDeletedDiscussion replaces the body of deleted content with the
+// deleted placeholder
+func redactDeletedDiscussion(deleted bool, body *string, bodyHTML *sql.NullString) {
+	if !deleted {
+		return
+	}
+	*body = deletedDiscussionBody
+	*bodyHTML = sql.NullString{String: "<p>" + deletedDiscussionBody + "</p>", Valid: true}
+}
+
+// checkDiscussionLocked returns an ErrForbidden response if the discussion
+// has been locked by a moderator
+func checkDiscussionLocked(ctx context.Context, tx *ti.Tx, callerName string, discussionId int64) (map[string]interface{}, error) {
+	var locked bool
+	err := tx.QueryRow(&callerName,
+		"select exists(select 1 from discussion_lock where discussion_id = ?)", discussionId,
+	).Scan(&locked)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query discussion lock: %v", err)
+	}
+
+	if locked {
+		return map[string]interface{}{"message": "this discussion has been locked"},
+			fmt.Errorf("discussion %d is locked: %w", discussionId, ErrForbidden)
+	}
+
+	return nil, nil
+}
+
+// checkDiscussionEditable returns an ErrForbidden response if the content has
+// been deleted by its author or hidden by a moderator. Edits insert a new
+// revision so they would otherwise bring the content back.
+func checkDiscussionEditable(ctx context.Context, tidb *ti.Database, discussionType string, id int64) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "check-discussion-editable-core")
+	defer span.End()
+	callerName := "checkDiscussionEditable"
+
+	table, err := discussionRevisionTable(discussionType)
+	if err != nil {
+		return map[string]interface{}{"message": "invalid discussion type"}, err
+	}
+
+	var deleted, hidden bool
+	err = tidb.QueryRowContext(ctx, &span, &callerName,
+		fmt.Sprintf("select deleted, hidden from %s where _id = ? order by revision desc limit 1", table), id,
+	).Scan(&deleted, &hidden)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to query %s: %v", table, err)
+	}
+
+	if deleted {
+		return map[string]interface{}{"message": "deleted content cannot be edited"},
+			fmt.Errorf("%s %d has been deleted: %w", table, id, ErrForbidden)
+	}
+
+	if hidden {
+		return map[string]interface{}{"message": "hidden content cannot be edited"},
+			fmt.Errorf("%s %d has been hidden: %w", table, id, ErrForbidden)
+	}
+
+	return nil, nil
+}
+
+// DeleteDiscussionContent deletes a discussion, comment, thread comment or
+// thread reply on behalf of its author. The content is kept so that the
+// replies below it stay in place but its body is replaced with a placeholder
+// and it is removed from search.
+func DeleteDiscussionContent(ctx context.Context, tidb *ti.Database, callingUser *models.User, discussionType string, id int64) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "delete-discussion-content-core")
+	defer span.End()
+	callerName := "DeleteDiscussionContent"
+
+	contentType, ok := discussionRevisionTypes[discussionType]
+	if !ok {
+		return map[string]interface{}{"message": "invalid discussion type"}, fmt.Errorf("invalid discussion type %q", discussionType)
+	}
+	source := reportContentSources[contentType]
+
+	var authorId int64
+	var deleted bool
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		fmt.Sprintf("select author_id, deleted from %s where _id = ? and hidden = false order by revision desc limit 1", source.table), id,
+	).Scan(&authorId, &deleted)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return map[string]interface{}{"message": fmt.Sprintf("%s not found", discussionType)}, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to query %s: %v", source.table, err)
+	}
+
+	// only the author and the moderators can delete the content
+	forbidden, err := checkDiscussionEditor(ctx, tidb, callingUser, authorId)
+	if forbidden != nil || err != nil {
+		return forbidden, err
+	}
+
+	if deleted {
+		return map[string]interface{}{"message": "Content has been deleted"}, nil
+	}
+
+	tx, err := tidb.BeginTx(ctx, &span, &callerName, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create delete tx: %v", err)
+	}
+	defer tx.Rollback()
+
+	// mark every revision so that edits cannot bring the content back
+	_, err = tx.ExecContext(ctx, &callerName,
+		fmt.Sprintf("update %s set deleted = true, deleted_at = ?, deleted_by = ? where _id = ?", source.table),
+		time.Now(), callingUser.ID, id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete %s: %v", source.table, err)
+	}
+
+	// a deleted comment can no longer be the answer to a discussion
+	if contentType == ReportContentComment {
+		_, err = tx.ExecContext(ctx, &callerName, "delete from discussion_answer where comment_id = ?", id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to remove deleted answer: %v", err)
+		}
+	}
+
+	// queue the content to be removed from search once committed
+	if source.index != "" {
+		err = queueSearchSync(ctx, tx, callerName, source.index, id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit(&callerName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit delete tx: %v", err)
+	}
+
+	return map[string]interface{}{"message": "Content has been deleted"}, nil
+}
+
+// setDiscussionContentHidden hides or restores a discussion, comment, thread
+// comment or thread reply for a moderator
+func setDiscussionContentHidden(ctx context.Context, tidb *ti.Database, callingUser *models.User, discussionType string, id int64, hidden bool) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "set-discussion-content-hidden-core")
+	defer span.End()
+	callerName := "setDiscussionContentHidden"
+
+	forbidden, err := requireModerator(ctx, tidb, callingUser)
+	if forbidden != nil || err != nil {
+		return forbidden, err
+	}
+
+	contentType, ok := discussionRevisionTypes[discussionType]
+	if !ok {
+		return map[string]interface{}{"message": "invalid discussion type"}, fmt.Errorf("invalid discussion type %q", discussionType)
+	}
+
+	var exists bool
+	err = tidb.QueryRowContext(ctx, &span, &callerName,
+		fmt.Sprintf("select exists(select 1 from %s where _id = ?)", reportContentSources[contentType].table), id,
+	).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %v", discussionType, err)
+	}
+	if !exists {
+		return map[string]interface{}{"message": fmt.Sprintf("%s not found", discussionType)}, ErrNotFound
+	}
+
+	err = SetContentHidden(ctx, tidb, contentType, id, hidden)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{"hidden": hidden}, nil
+}
+
+// HideDiscussionContent hides a discussion, comment, thread comment or thread
+// reply from everyone but the moderators
+func HideDiscussionContent(ctx context.Context, tidb *ti.Database, callingUser *models.User, discussionType string, id int64) (map[string]interface{}, error) {
+	res, err := setDiscussionContentHidden(ctx, tidb, callingUser, discussionType, id, true)
+	if err != nil {
+		return res, err
+	}
+	res["message"] = "Content has been hidden"
+	return res, nil
+}
+
+// RestoreDiscussionContent makes hidden discussion content visible again.
+// Content deleted by its author stays deleted.
+func RestoreDiscussionContent(ctx context.Context, tidb *ti.Database, callingUser *models.User, discussionType string, id int64) (map[string]interface{}, error) {
+	res, err := setDiscussionContentHidden(ctx, tidb, callingUser, discussionType, id, false)
+	if err != nil {
+		return res, err
+	}
+	res["message"] = "Content has been restored"
+	return res, nil
+}
+
+// setDiscussionLocked locks or unlocks a discussion for a moderator
+func setDiscussionLocked(ctx context.Context, tidb *ti.Database, callingUser *models.User, discussionId int64, locked bool) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "set-discussion-locked-core")
+	defer span.End()
+	callerName := "setDiscussionLocked"
+
+	forbidden, err := requireModerator(ctx, tidb, callingUser)
+	if forbidden != nil || err != nil {
+		return forbidden, err
+	}
+
+	var exists bool
+	err = tidb.QueryRowContext(ctx, &span, &callerName,
+		"select exists(select 1 from discussion where _id = ?)", discussionId,
+	).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query discussion: %v", err)
+	}
+	if !exists {
+		return map[string]interface{}{"message": "discussion not found"}, ErrNotFound
+	}
+
+	if locked {
+		_, err = tidb.ExecContext(ctx, &span, &callerName,
+			"insert ignore into discussion_lock(discussion_id, locked_by, locked_at) values (?, ?, ?)",
+			discussionId, callingUser.ID, time.Now(),
+		)
+	} else {
+		_, err = tidb.ExecContext(ctx, &span, &callerName, "delete from discussion_lock where discussion_id = ?", discussionId)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update discussion lock: %v", err)
+	}
+
+	return map[string]interface{}{"locked": locked}, nil
+}
+
+// LockDiscussion stops a discussion from accepting new comments and replies
+func LockDiscussion(ctx context.Context, tidb *ti.Database, callingUser *models.User, discussionId int64) (map[string]interface{}, error) {
+	res, err := setDiscussionLocked(ctx, tidb, callingUser, discussionId, true)
+	if err != nil {
+		return res, err
+	}
+	res["message"] = "Discussion has been locked"
+	return res, nil
+}
+
+// UnlockDiscussion lets a locked discussion accept new comments and replies again
+func UnlockDiscussion(ctx context.Context, tidb *ti.Database, callingUser *models.User, discussionId int64) (map[string]interface{}, error) {
+	res, err := setDiscussionLocked(ctx, tidb, callingUser, discussionId, false)
+	if err != nil {
+		return res, err
+	}
+	res["message"] = "Discussion has been unlocked"
+	return res, nil
+}
@@ -0,0 +1,24 @@
+package core
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestRedactDeletedDiscussion(t *testing.T) {
+	body := "my answer"
+	bodyHTML := sql.NullString{String: "<p>my answer</p>", Valid: true}
+
+	redactDeletedDiscussion(false, &body, &bodyHTML)
+	if body != "my answer" || bodyHTML.String != "<p>my answer</p>" {
+		t.Errorf("\nTestRedactDeletedDiscussion failed\n    Error: content that was not deleted was redacted")
+	}
+
+	redactDeletedDiscussion(true, &body, &bodyHTML)
+	if body != deletedDiscussionBody {
+		t.Errorf("\nTestRedactDeletedDiscussion failed\n    Error: got body %q, want %q", body, deletedDiscussionBody)
+	}
+	if !bodyHTML.Valid || bodyHTML.String != "<p>[deleted]</p>" {
+		t.Errorf("\nTestRedactDeletedDiscussion failed\n    Error: got html %q", bodyHTML.String)
+	}
+}
@@ -0,0 +1,322 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"go.opentelemetry.io/otel"
+)
+
+// DiscussionSort selects the order of discussions, comments, thread comments
+// and thread replies
+type DiscussionSort string
+
+const (
+	// DiscussionSortNew keeps the default order of the content
+	DiscussionSortNew DiscussionSort = "new"
+	// DiscussionSortTop orders the content by the most coffee
+	DiscussionSortTop DiscussionSort = "top"
+	// DiscussionSortHot orders the content by coffee decayed by age
+	DiscussionSortHot DiscussionSort = "hot"
+	// DiscussionSortActive orders the content by the newest reply
+	DiscussionSortActive DiscussionSort = "active"
+)
+
+const (
+	// hotScoreEpoch is subtracted from the creation time of content so that
+	// the time component of the hot score stays small
+	hotScoreEpoch = 1672531200
+	// hotScoreDecay is the number of seconds of age that cost the same as a
+	// ten fold increase in coffee
+	hotScoreDecay = 45000
+)
+
+// discussionHotScore calculates the hot score of content. Newer content ranks
+// higher so the score of an item never has to be updated as it ages; it only
+// changes when the coffee of the item changes. The formula must match the one
+// used to backfill scores in the discussion_rank migration.
+func discussionHotScore(coffee int64, createdAt time.Time) float64 {
+	if coffee < 1 {
+		coffee = 1
+	}
+	return math.Log10(float64(coffee)) + float64(createdAt.Unix()-hotScoreEpoch)/hotScoreDecay
+}
+
+// discussionSortKey is a column of the discussion_rank table, joined as k,
+// that a sort mode orders by
+type discussionSortKey struct {
+	column string
+	desc   bool
+}
+
+// discussionSortKeys are the columns that each sort mode orders by. The new
+// sort keeps the default order so it has no columns.
+var discussionSortKeys = map[DiscussionSort][]discussionSortKey{
+	DiscussionSortNew:    nil,
+	DiscussionSortTop:    {{column: "k.coffee", desc: true}, {column: "k.created_at", desc: true}},
+	DiscussionSortHot:    {{column: "k.hot_score", desc: true}},
+	DiscussionSortActive: {{column: "k.last_activity_at", desc: true}},
+}
+
+// discussionSortKeysFor returns the columns that a sort mode orders by
+func discussionSortKeysFor(sort string) ([]discussionSortKey, error) {
+	if sort == "" {
+		sort = string(DiscussionSortNew)
+	}
+	keys, ok := discussionSortKeys[DiscussionSort(sort)]
+	if !ok {
+		return nil, fmt.Errorf("invalid discussion sort %q", sort)
+	}
+	return keys, nil
+}
+
+// discussionSortOrder returns the order by columns for a sort mode
+func discussionSortOrder(sort string) (string, error) {
+	keys, err := discussionSortKeysFor(sort)
+	if err != nil {
+		return "", err
+	}
+
+	columns := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if key.desc {
+			columns = append(columns, key.column+" desc")
+		} else {
+			columns = append(columns, key.column)
+		}
+	}
+	return strings.Join(columns, ", "), nil
+}
+
+// ValidDiscussionSort returns true if the sort mode is supported
+func ValidDiscussionSort(sort string) bool {
+	_, err := discussionSortOrder(sort)
+	return err == nil
+}
+
+// discussionSortClause builds the order by clause for a sort mode placing the
+// passed leading columns before the columns of the sort mode
+func discussionSortClause(sort string, leading ...string) (string, error) {
+	order, err := discussionSortOrder(sort)
+	if err != nil {
+		return "", err
+	}
+
+	columns := append(leading, order)
+	clause := ""
+	for _, column := range columns {
+		if column == "" {
+			continue
+		}
+		if clause == "" {
+			clause = " order by " + column
+		} else {
+			clause += ", " + column
+		}
+	}
+	return clause, nil
+}
+
+// insertDiscussionRank creates the ranking row for new content and records
+// the activity on every item above it
+func insertDiscussionRank(ctx context.Context, tx *ti.Tx, callerName string, id int64, contentType models.CommunicationType,
+	parentId int64, createdAt time.Time) error {
+	_, err := tx.ExecContext(ctx, &callerName,
+		"insert into discussion_rank(_id, content_type, parent_id, coffee, hot_score, created_at, last_activity_at) values (?, ?, ?, 0, ?, ?, ?)",
+		id, contentType, parentId, discussionHotScore(0, createdAt), createdAt, createdAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert discussion rank: %v", err)
+	}
+
+	// discussions belong to posts which are not ranked
+	if contentType == models.DiscussionLevel {
+		return nil
+	}
+
+	// walk up the tree until the discussion is reached
+	for level := contentType; level > models.DiscussionLevel; level-- {
+		_, err = tx.ExecContext(ctx, &callerName,
+			"update discussion_rank set last_activity_at = greatest(last_activity_at, ?) where _id = ?", createdAt, parentId,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update discussion activity: %v", err)
+		}
+
+		if level == models.CommentLevel {
+			break
+		}
+
+		err = tx.QueryRow(&callerName, "select parent_id from discussion_rank where _id = ?", parentId).Scan(&parentId)
+		if err != nil {
+			// content created before ranking was added may not have a row
+			if err == sql.ErrNoRows {
+				return nil
+			}
+			return fmt.Errorf("failed to query discussion parent: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// updateDiscussionRankCoffee applies a change in coffee to the ranking of content
+func updateDiscussionRankCoffee(ctx context.Context, tidb *ti.Database, id int64, delta int) error {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "update-discussion-rank-coffee-core")
+	defer span.End()
+	callerName := "updateDiscussionRankCoffee"
+
+	tx, err := tidb.BeginTx(ctx, &span, &callerName, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start discussion rank tx: %v", err)
+	}
+	defer tx.Rollback()
+
+	var coffee int64
+	var createdAt time.Time
+	err = tx.QueryRow(&callerName,
+		"select coffee, created_at from discussion_rank where _id = ? for update", id,
+	).Scan(&coffee, &createdAt)
+	if err != nil {
+		// content created before ranking was added may not have a row
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return fmt.Errorf("failed to query discussion rank: %v", err)
+	}
+
+	coffee += int64(delta)
+	if coffee < 0 {
+		coffee = 0
+	}
+
+	_, err = tx.ExecContext(ctx, &callerName,
+		"update discussion_rank set coffee = ?, hot_score = ? where _id = ?",
+		coffee, discussionHotScore(coffee, createdAt), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update discussion rank: %v", err)
+	}
+
+	err = tx.Commit(&callerName)
+	if err != nil {
+		return fmt.Errorf("failed to commit discussion rank tx: %v", err)
+	}
+
+	return nil
+}
+
+// discussionRankLevel describes where the content of a level of a discussion
+// is stored and what is ordered before the sort mode on the level
+type discussionRankLevel struct {
+	table  string
+	join   string
+	pinned string
+}
+
+var discussionRankLevels = map[models.CommunicationType]discussionRankLevel{
+	models.DiscussionLevel: {table: "discussion"},
+	models.CommentLevel: {
+		table: "comment",
+		// accepted answers are pinned to the top of the comments
+		join:   " left join discussion_answer a on a.comment_id = k._id",
+		pinned: "a.comment_id is null",
+	},
+	models.ThreadLevel:      {table: "thread_comment"},
+	models.ThreadReplyLevel: {table: "thread_reply"},
+}
+
+// discussionRankPageQuery builds the query for a page of the ids of the
+// visible children of the parents in the order of the sort mode
+func discussionRankPageQuery(contentType models.CommunicationType, parents int, sort string) (string, error) {
+	level, ok := discussionRankLevels[contentType]
+	if !ok {
+		return "", fmt.Errorf("invalid discussion level %d", contentType)
+	}
+
+	leading := make([]string, 0, 1)
+	if level.pinned != "" {
+		leading = append(leading, level.pinned)
+	}
+
+	order, err := discussionSortClause(sort, leading...)
+	if err != nil {
+		return "", err
+	}
+
+	// the id is always last so that pages are stable
+	if order == "" {
+		order = " order by k._id"
+	} else {
+		order += ", k._id"
+	}
+
+	return fmt.Sprintf(
+		"select k._id from discussion_rank k%s where k.parent_id in (?%s) and k.content_type = ? "+
+			"and not exists (select 1 from %s h where h._id = k._id and h.hidden = true)%s limit ? offset ?",
+		level.join, strings.Repeat(", ?", parents-1), level.table, order,
+	), nil
+}
+
+// discussionRankPage returns the ids of a page of the visible children of the
+// parents in the order of the sort mode. Pages are read from discussion_rank
+// so that the sort is served by the indexes on the parent of the items and
+// only the content on the page has to be loaded.
+func discussionRankPage(ctx context.Context, tidb *ti.Database, contentType models.CommunicationType, parentIds []int64,
+	sort string, skip int, limit int) ([]int64, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "discussion-rank-page-core")
+	defer span.End()
+	callerName := "discussionRankPage"
+
+	ids := make([]int64, 0)
+	if len(parentIds) == 0 {
+		return ids, nil
+	}
+
+	query, err := discussionRankPageQuery(contentType, len(parentIds), sort)
+	if err != nil {
+		return nil, err
+	}
+
+	params := make([]interface{}, 0, len(parentIds)+3)
+	for _, id := range parentIds {
+		params = append(params, id)
+	}
+	params = append(params, contentType, limit, skip)
+
+	rows, err := tidb.QueryContext(ctx, &span, &callerName, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query discussion rank page: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan discussion rank page: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// discussionPageContentClause builds the placeholders and parameters that
+// select the latest revision of the content on a page and keep the order of
+// the page. The clause is used as "where _id in (%s)" and "field(c._id, %s)".
+func discussionPageContentClause(ids []int64) (string, []interface{}) {
+	params := make([]interface{}, 0, len(ids)*2)
+	for i := 0; i < 2; i++ {
+		for _, id := range ids {
+			params = append(params, id)
+		}
+	}
+	return "?" + strings.Repeat(", ?", len(ids)-1), params
+}
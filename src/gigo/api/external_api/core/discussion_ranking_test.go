@@ -0,0 +1,84 @@
+package core
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gage-technologies/gigo-lib/db/models"
+)
+
+func TestDiscussionHotScore(t *testing.T) {
+	created := time.Unix(hotScoreEpoch, 0)
+
+	if score := discussionHotScore(0, created); score != 0 {
+		t.Errorf("\nTestDiscussionHotScore failed\n    Error: unexpected score for new content %v", score)
+	}
+
+	// ten times the coffee is worth the same as being newer by the decay period
+	older := discussionHotScore(10, created)
+	newer := discussionHotScore(1, created.Add(hotScoreDecay*time.Second))
+	if older != newer {
+		t.Errorf("\nTestDiscussionHotScore failed\n    Error: expected equal scores got %v and %v", older, newer)
+	}
+
+	if discussionHotScore(5, created) <= discussionHotScore(4, created) {
+		t.Error("\nTestDiscussionHotScore failed\n    Error: more coffee did not increase the score")
+	}
+}
+
+func TestDiscussionSortClause(t *testing.T) {
+	tests := []struct {
+		sort    string
+		leading []string
+		want    string
+	}{
+		{"", nil, ""},
+		{"new", []string{"a.comment_id is null"}, " order by a.comment_id is null"},
+		{"top", nil, " order by k.coffee desc, k.created_at desc"},
+		{"hot", []string{"a.comment_id is null"}, " order by a.comment_id is null, k.hot_score desc"},
+		{"active", nil, " order by k.last_activity_at desc"},
+	}
+
+	for _, tt := range tests {
+		got, err := discussionSortClause(tt.sort, tt.leading...)
+		if err != nil || got != tt.want {
+			t.Errorf("\nTestDiscussionSortClause failed\n    Case: %s\n    Error: got %q %v", tt.sort, got, err)
+		}
+	}
+
+	if _, err := discussionSortClause("controversial"); err == nil {
+		t.Error("\nTestDiscussionSortClause failed\n    Error: unknown sort was accepted")
+	}
+}
+
+func TestDiscussionRankPageQuery(t *testing.T) {
+	query, err := discussionRankPageQuery(models.CommentLevel, 2, "top")
+	if err != nil {
+		t.Fatalf("\nTestDiscussionRankPageQuery failed\n    Error: %v", err)
+	}
+
+	want := "select k._id from discussion_rank k left join discussion_answer a on a.comment_id = k._id where k.parent_id in (?, ?) and k.content_type = ? " +
+		"and not exists (select 1 from comment h where h._id = k._id and h.hidden = true) order by a.comment_id is null, k.coffee desc, k.created_at desc, k._id limit ? offset ?"
+	if query != want {
+		t.Errorf("\nTestDiscussionRankPageQuery failed\n    Error: unexpected query %q", query)
+	}
+
+	// the new sort keeps the order of creation
+	query, err = discussionRankPageQuery(models.ThreadReplyLevel, 1, "new")
+	if err != nil || !strings.HasSuffix(query, "from thread_reply h where h._id = k._id and h.hidden = true) order by k._id limit ? offset ?") {
+		t.Errorf("\nTestDiscussionRankPageQuery failed\n    Error: unexpected query %q %v", query, err)
+	}
+
+	if _, err = discussionRankPageQuery(models.CommunicationType(9), 1, "new"); err == nil {
+		t.Error("\nTestDiscussionRankPageQuery failed\n    Error: unknown level was accepted")
+	}
+}
+
+func TestDiscussionPageContentClause(t *testing.T) {
+	placeholders, params := discussionPageContentClause([]int64{3, 1, 2})
+	if placeholders != "?, ?, ?" || !reflect.DeepEqual(params, []interface{}{int64(3), int64(1), int64(2), int64(3), int64(1), int64(2)}) {
+		t.Errorf("\nTestDiscussionPageContentClause failed\n    Error: unexpected clause %q %v", placeholders, params)
+	}
+}
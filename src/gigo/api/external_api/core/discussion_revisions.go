@@ -0,0 +1,319 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"go.opentelemetry.io/otel"
+)
+
+type DiscussionRevisionsRequest struct {
+	DiscussionType string `json:"discussion_type" validate:"required,oneof=discussion comment thread_comment thread_reply"`
+	ID             string `json:"id" validate:"required,number"`
+	Test           bool   `json:"test"`
+}
+
+type DiffDiscussionRevisionsRequest struct {
+	DiscussionType string `json:"discussion_type" validate:"required,oneof=discussion comment thread_comment thread_reply"`
+	ID             string `json:"id" validate:"required,number"`
+	FromRevision   int    `json:"from_revision" validate:"gte=0"`
+	ToRevision     int    `json:"to_revision" validate:"gte=0"`
+	Test           bool   `json:"test"`
+}
+
+type DeleteDiscussionRevisionRequest struct {
+	DiscussionType string `json:"discussion_type" validate:"required,oneof=discussion comment thread_comment thread_reply"`
+	ID             string `json:"id" validate:"required,number"`
+	Revision       int    `json:"revision" validate:"gte=0"`
+	Test           bool   `json:"test"`
+}
+
+// DiscussionRevisionFrontend is a single revision of a discussion, comment,
+// thread comment or thread reply. The content of deleted revisions is only
+// included for moderators.
+type DiscussionRevisionFrontend struct {
+	Revision     int       `json:"revision"`
+	Title        *string   `json:"title,omitempty"`
+	Body         *string   `json:"body,omitempty"`
+	BodyHTML     *string   `json:"body_html,omitempty"`
+	EditedAt     time.Time `json:"edited_at"`
+	EditedBy     string    `json:"edited_by"`
+	EditedByName *string   `json:"edited_by_name"`
+	Deleted      bool      `json:"deleted"`
+}
+
+// discussionRevisionTypes maps the discussion types used by the api to the
+// content types used for moderation
+var discussionRevisionTypes = map[string]ReportContentType{
+	"discussion":     ReportContentDiscussion,
+	"comment":        ReportContentComment,
+	"thread_comment": ReportContentThreadComment,
+	"thread_reply":   ReportContentThreadReply,
+}
+
+// discussionRevisionTable returns the table that holds the revisions of a
+// discussion type
+func discussionRevisionTable(discussionType string) (string, error) {
+	contentType, ok := discussionRevisionTypes[discussionType]
+	if !ok {
+		return "", fmt.Errorf("invalid discussion type %q", discussionType)
+	}
+	return reportContentSources[contentType].table, nil
+}
+
+// discussionRevisionQuery builds the select for the revisions of a piece of
+// discussion content. Only discussions have a title. Revisions created before
+// edits were recorded fall back to the author and creation time. Every
+// revision of content deleted by its author is reported as deleted.
+func discussionRevisionQuery(table string) string {
+	title := "null"
+	if table == "discussion" {
+		title = "d.title"
+	}
+	return fmt.Sprintf(
+		"select d.revision, %s, d.body, d.body_html, coalesce(d.edited_at, d.created_at), coalesce(d.edited_by, d.author_id), u.user_name, d.revision_deleted or d.deleted "+
+			"from %s d left join users u on u._id = coalesce(d.edited_by, d.author_id) where d._id = ?",
+		title, table,
+	)
+}
+
+// scanDiscussionRevision decodes a row selected by discussionRevisionQuery
+func scanDiscussionRevision(rows *sql.Rows) (*DiscussionRevisionFrontend, error) {
+	var revision DiscussionRevisionFrontend
+	var title, bodyHTML, editedByName sql.NullString
+	var body string
+	var editedBy int64
+	err := rows.Scan(&revision.Revision, &title, &body, &bodyHTML, &revision.EditedAt, &editedBy, &editedByName, &revision.Deleted)
+	if err != nil {
+		return nil, err
+	}
+
+	if title.Valid {
+		revision.Title = &title.String
+	}
+	html := discussionBodyHTML(bodyHTML, body)
+	revision.Body = &body
+	revision.BodyHTML = &html
+	revision.EditedBy = fmt.Sprintf("%d", editedBy)
+	if editedByName.Valid {
+		revision.EditedByName = &editedByName.String
+	}
+
+	return &revision, nil
+}
+
+// markDiscussionEdited records content with more than one revision as edited.
+// Edits made before editors were recorded have no edit time.
+func markDiscussionEdited(edited map[string]*time.Time, id int64, revision int, editedAt sql.NullTime) {
+	if revision == 0 {
+		return
+	}
+
+	var at *time.Time
+	if editedAt.Valid {
+		at = &editedAt.Time
+	}
+	edited[fmt.Sprintf("%d", id)] = at
+}
+
+// checkDiscussionEditor returns an ErrForbidden response if the user is not
+// the author of the content and not a moderator
+func checkDiscussionEditor(ctx context.Context, tidb *ti.Database, callingUser *models.User, authorId int64) (map[string]interface{}, error) {
+	if callingUser.ID == authorId {
+		return nil, nil
+	}
+
+	moderator, err := IsModerator(ctx, tidb, callingUser)
+	if err != nil {
+		return nil, err
+	}
+	if !moderator {
+		return map[string]interface{}{"message": "you do not have permission to edit this content"},
+			fmt.Errorf("user %d cannot edit content of user %d: %w", callingUser.ID, authorId, ErrForbidden)
+	}
+
+	return nil, nil
+}
+
+// GetDiscussionRevisions lists the revisions of a discussion, comment, thread
+// comment or thread reply with the newest revision first. Deleted revisions
+// are listed without their content unless the caller is a moderator.
+func GetDiscussionRevisions(ctx context.Context, tidb *ti.Database, callingUser *models.User, discussionType string, id int64) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "get-discussion-revisions-core")
+	defer span.End()
+	callerName := "GetDiscussionRevisions"
+
+	table, err := discussionRevisionTable(discussionType)
+	if err != nil {
+		return map[string]interface{}{"message": "invalid discussion type"}, err
+	}
+
+	moderator, err := IsModerator(ctx, tidb, callingUser)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tidb.QueryContext(ctx, &span, &callerName,
+		discussionRevisionQuery(table)+" and d.hidden = false order by d.revision desc", id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s revisions: %v", table, err)
+	}
+	defer rows.Close()
+
+	revisions := make([]*DiscussionRevisionFrontend, 0)
+	for rows.Next() {
+		revision, err := scanDiscussionRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s revision: %v", table, err)
+		}
+
+		if revision.Deleted && !moderator {
+			revision.Title = nil
+			revision.Body = nil
+			revision.BodyHTML = nil
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	if len(revisions) == 0 {
+		return map[string]interface{}{"message": fmt.Sprintf("%s not found", discussionType)}, ErrNotFound
+	}
+
+	return map[string]interface{}{"revisions": revisions}, nil
+}
+
+// loadDiscussionRevision retrieves a single revision of a piece of discussion content
+func loadDiscussionRevision(ctx context.Context, tidb *ti.Database, table string, id int64, revision int) (*DiscussionRevisionFrontend, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "load-discussion-revision-core")
+	defer span.End()
+	callerName := "loadDiscussionRevision"
+
+	rows, err := tidb.QueryContext(ctx, &span, &callerName,
+		discussionRevisionQuery(table)+" and d.revision = ? and d.hidden = false limit 1", id, revision,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s revision: %v", table, err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, ErrNotFound
+	}
+
+	res, err := scanDiscussionRevision(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s revision: %v", table, err)
+	}
+
+	return res, nil
+}
+
+// DiffDiscussionRevisions returns the line diff between the bodies of two
+// revisions of a discussion, comment, thread comment or thread reply. Only
+// moderators can diff deleted revisions.
+func DiffDiscussionRevisions(ctx context.Context, tidb *ti.Database, callingUser *models.User, discussionType string, id int64, fromRevision int, toRevision int) (map[string]interface{}, error) {
+	table, err := discussionRevisionTable(discussionType)
+	if err != nil {
+		return map[string]interface{}{"message": "invalid discussion type"}, err
+	}
+
+	moderator, err := IsModerator(ctx, tidb, callingUser)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]*DiscussionRevisionFrontend, 0, 2)
+	for _, r := range []int{fromRevision, toRevision} {
+		revision, err := loadDiscussionRevision(ctx, tidb, table, id, r)
+		if err != nil {
+			if err == ErrNotFound {
+				return map[string]interface{}{"message": fmt.Sprintf("revision %d not found", r)}, err
+			}
+			return nil, err
+		}
+
+		if revision.Deleted && !moderator {
+			return map[string]interface{}{"message": fmt.Sprintf("revision %d has been deleted", r)},
+				fmt.Errorf("revision %d of %s %d is deleted: %w", r, table, id, ErrForbidden)
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	from, to := revisions[0], revisions[1]
+	hunks, additions, deletions := computeFileDiff(*from.Body, *to.Body)
+
+	res := map[string]interface{}{
+		"from_revision": fromRevision,
+		"to_revision":   toRevision,
+		"hunks":         hunks,
+		"additions":     additions,
+		"deletions":     deletions,
+	}
+
+	// discussions also report title changes
+	if from.Title != nil && to.Title != nil && *from.Title != *to.Title {
+		res["title_from"] = *from.Title
+		res["title_to"] = *to.Title
+	}
+
+	return res, nil
+}
+
+// DeleteDiscussionRevision deletes a previous revision of a discussion,
+// comment, thread comment or thread reply. The revision is kept for the
+// moderators but its content is no longer shown to anyone else. The current
+// revision cannot be deleted since it is the content being displayed; it
+// must be edited instead.
+func DeleteDiscussionRevision(ctx context.Context, tidb *ti.Database, callingUser *models.User, discussionType string, id int64, revision int) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "delete-discussion-revision-core")
+	defer span.End()
+	callerName := "DeleteDiscussionRevision"
+
+	table, err := discussionRevisionTable(discussionType)
+	if err != nil {
+		return map[string]interface{}{"message": "invalid discussion type"}, err
+	}
+
+	var authorId int64
+	var latest int
+	err = tidb.QueryRowContext(ctx, &span, &callerName,
+		fmt.Sprintf("select author_id, revision from %s where _id = ? order by revision desc limit 1", table), id,
+	).Scan(&authorId, &latest)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return map[string]interface{}{"message": fmt.Sprintf("%s not found", discussionType)}, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to query %s: %v", table, err)
+	}
+
+	// only the author and the moderators can remove revisions
+	forbidden, err := checkDiscussionEditor(ctx, tidb, callingUser, authorId)
+	if forbidden != nil || err != nil {
+		return forbidden, err
+	}
+
+	if revision == latest {
+		return map[string]interface{}{"message": "the current revision cannot be deleted"},
+			fmt.Errorf("cannot delete current revision of %s %d", table, id)
+	}
+
+	res, err := tidb.ExecContext(ctx, &span, &callerName,
+		fmt.Sprintf("update %s set revision_deleted = true where _id = ? and revision = ?", table), id, revision,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete %s revision: %v", table, err)
+	}
+
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return map[string]interface{}{"message": fmt.Sprintf("revision %d not found", revision)}, ErrNotFound
+	}
+
+	return map[string]interface{}{"message": "Revision deleted"}, nil
+}
@@ -0,0 +1,26 @@
+package core
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestMarkDiscussionEdited(t *testing.T) {
+	edited := make(map[string]*time.Time)
+	at := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	markDiscussionEdited(edited, 1, 0, sql.NullTime{})
+	markDiscussionEdited(edited, 2, 1, sql.NullTime{})
+	markDiscussionEdited(edited, 3, 2, sql.NullTime{Time: at, Valid: true})
+
+	if _, ok := edited["1"]; ok {
+		t.Error("\nTestMarkDiscussionEdited failed\n    Error: first revision was marked as edited")
+	}
+	if v, ok := edited["2"]; !ok || v != nil {
+		t.Errorf("\nTestMarkDiscussionEdited failed\n    Error: unexpected marker for legacy edit %v", v)
+	}
+	if v, ok := edited["3"]; !ok || v == nil || !v.Equal(at) {
+		t.Errorf("\nTestMarkDiscussionEdited failed\n    Error: unexpected marker for edit %v", v)
+	}
+}
@@ -0,0 +1,318 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/gob"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/logging"
+	"github.com/gage-technologies/gigo-lib/mq"
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel"
+)
+
+// Jetstream configuration for the discussion work queue. The streams defined
+// in gigo-lib are shared with the other services so the stream is owned and
+// initialized by gigo-core.
+const (
+	StreamDiscussion = "Discussion"
+
+	SubjectDiscussionReply = "DISCUSSION.Reply"
+
+	RetentionPolicyDiscussion = nats.WorkQueuePolicy
+)
+
+type WatchDiscussionRequest struct {
+	DiscussionID string `json:"discussion_id" validate:"required,number"`
+	Test         bool   `json:"test"`
+}
+
+type UnwatchDiscussionRequest struct {
+	DiscussionID string `json:"discussion_id" validate:"required,number"`
+	Test         bool   `json:"test"`
+}
+
+type DiscussionWatchStatusRequest struct {
+	DiscussionID string `json:"discussion_id" validate:"required,number"`
+	Test         bool   `json:"test"`
+}
+
+// DiscussionReplyMsg is published when a discussion, comment, thread comment
+// or thread reply is created so that a follower can notify the mentioned users
+// and the subscribers of the discussion outside of the request
+type DiscussionReplyMsg struct {
+	DiscussionID int64
+	ContentID    int64
+	ContentType  models.CommunicationType
+	AuthorID     int64
+	AuthorName   string
+	// Mentions holds the users that were mentioned in the content. They are
+	// notified of the mention instead of the reply.
+	Mentions []int64
+}
+
+// InitDiscussionStream creates the discussion stream if it does not exist
+func InitDiscussionStream(js *mq.JetstreamClient) error {
+	_, err := js.StreamInfo(StreamDiscussion)
+	if err == nil {
+		return nil
+	}
+	if err != nats.ErrStreamNotFound {
+		return fmt.Errorf("failed to retrieve discussion stream: %v", err)
+	}
+
+	_, err = js.AddStream(&nats.StreamConfig{
+		Name:      StreamDiscussion,
+		Subjects:  []string{SubjectDiscussionReply},
+		Retention: RetentionPolicyDiscussion,
+	})
+	if err != nil && !strings.Contains(err.Error(), "stream name already in use") {
+		return fmt.Errorf("failed to create discussion stream: %v", err)
+	}
+
+	return nil
+}
+
+// discussionIdForContent resolves the discussion that new content at the
+// passed level belongs to using the id of its parent. Zero is returned if the
+// parent does not exist.
+func discussionIdForContent(ctx context.Context, tx *ti.Tx, callerName string, contentType models.CommunicationType, parentId int64) (int64, error) {
+	var parentType string
+	switch contentType {
+	case models.DiscussionLevel, models.CommentLevel:
+		return parentId, nil
+	case models.ThreadLevel:
+		parentType = "comment"
+	case models.ThreadReplyLevel:
+		parentType = "thread_comment"
+	default:
+		return 0, fmt.Errorf("invalid discussion level %d", contentType)
+	}
+
+	var discussionId int64
+	err := tx.QueryRow(&callerName, discussionRootQueries[parentType], parentId).Scan(&discussionId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to query parent discussion: %v", err)
+	}
+
+	return discussionId, nil
+}
+
+// subscribeToDiscussion subscribes the author of new content to the
+// discussion it was posted in. Users that unwatched the discussion stay
+// unsubscribed.
+func subscribeToDiscussion(ctx context.Context, tx *ti.Tx, callerName string, discussionId int64, userId int64) error {
+	_, err := tx.ExecContext(ctx, &callerName,
+		"insert ignore into discussion_subscription(discussion_id, user_id, watching, created_at) values (?, ?, true, ?)",
+		discussionId, userId, time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to discussion: %v", err)
+	}
+	return nil
+}
+
+// publishDiscussionReply queues the notification of the users mentioned in
+// new content and the subscribers of its discussion. Failures are logged since
+// the content has already been saved.
+func publishDiscussionReply(js *mq.JetstreamClient, logger logging.Logger, msg DiscussionReplyMsg) {
+	// skip content that is not attached to a discussion
+	if msg.DiscussionID == 0 {
+		return
+	}
+
+	buf := bytes.NewBuffer(nil)
+	encoder := gob.NewEncoder(buf)
+	err := encoder.Encode(msg)
+	if err != nil {
+		logger.Errorf("failed to encode discussion reply %d: %v", msg.ContentID, err)
+		return
+	}
+
+	_, err = js.PublishAsync(SubjectDiscussionReply, buf.Bytes())
+	if err != nil {
+		logger.Errorf("failed to publish discussion reply %d: %v", msg.ContentID, err)
+	}
+}
+
+// discussionReplyMessage formats the notification sent to the subscribers of
+// a discussion for new content
+func discussionReplyMessage(authorName string, contentType models.CommunicationType, title string) string {
+	switch contentType {
+	case models.CommentLevel:
+		return fmt.Sprintf("%s commented on %q", authorName, title)
+	case models.ThreadLevel:
+		return fmt.Sprintf("%s replied to a comment on %q", authorName, title)
+	default:
+		return fmt.Sprintf("%s replied to a thread on %q", authorName, title)
+	}
+}
+
+// discussionMentionKind names the content that a user was mentioned in
+func discussionMentionKind(contentType models.CommunicationType) string {
+	switch contentType {
+	case models.DiscussionLevel:
+		return "discussion"
+	case models.CommentLevel, models.ThreadLevel:
+		return "comment"
+	default:
+		return "reply"
+	}
+}
+
+// NotifyDiscussionSubscribers notifies the users mentioned in new discussion
+// content and the users watching the discussion of a new comment, thread
+// comment or thread reply. The author of the content is skipped and mentioned
+// users only receive the mention. Failures to notify a single user are logged
+// so that one user cannot block the rest of the subscribers.
+func NotifyDiscussionSubscribers(ctx context.Context, tidb *ti.Database, js *mq.JetstreamClient, sf *snowflake.Node,
+	logger logging.Logger, msg DiscussionReplyMsg) error {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "notify-discussion-subscribers-core")
+	defer span.End()
+	callerName := "NotifyDiscussionSubscribers"
+
+	var title string
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		"select title from discussion where _id = ? and hidden = false and deleted = false order by revision desc limit 1", msg.DiscussionID,
+	).Scan(&title)
+	if err != nil {
+		// hidden and deleted discussions do not notify anyone
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return fmt.Errorf("failed to query discussion: %v", err)
+	}
+
+	mentioned := make(map[int64]bool)
+	for _, userId := range msg.Mentions {
+		// users are not told that they mentioned themselves
+		if userId == msg.AuthorID || mentioned[userId] {
+			continue
+		}
+		mentioned[userId] = true
+
+		_, err = CreateNotification(ctx, tidb, js, sf, userId,
+			fmt.Sprintf("%s mentioned you in a %s", msg.AuthorName, discussionMentionKind(msg.ContentType)),
+			MentionNotification, &msg.AuthorID,
+		)
+		if err != nil {
+			logger.Errorf("failed to notify user %d of mention in %d by %d: %v", userId, msg.ContentID, msg.AuthorID, err)
+		}
+	}
+
+	// the only subscriber of a new discussion is its author
+	if msg.ContentType == models.DiscussionLevel {
+		return nil
+	}
+
+	rows, err := tidb.QueryContext(ctx, &span, &callerName,
+		"select user_id from discussion_subscription where discussion_id = ? and watching = true and user_id != ?",
+		msg.DiscussionID, msg.AuthorID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to query discussion subscribers: %v", err)
+	}
+
+	subscribers := make([]int64, 0)
+	for rows.Next() {
+		var userId int64
+		err = rows.Scan(&userId)
+		if err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("failed to scan discussion subscriber: %v", err)
+		}
+		subscribers = append(subscribers, userId)
+	}
+	_ = rows.Close()
+
+	message := discussionReplyMessage(msg.AuthorName, msg.ContentType, title)
+	for _, userId := range subscribers {
+		if mentioned[userId] {
+			continue
+		}
+
+		_, err = CreateNotification(ctx, tidb, js, sf, userId, message, DiscussionReplyNotification, &msg.AuthorID)
+		if err != nil {
+			logger.Errorf("failed to notify user %d of reply %d on discussion %d: %v", userId, msg.ContentID, msg.DiscussionID, err)
+		}
+	}
+
+	return nil
+}
+
+// setDiscussionWatching subscribes or unsubscribes a user from a discussion
+func setDiscussionWatching(ctx context.Context, tidb *ti.Database, callingUser *models.User, discussionId int64, watching bool) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "set-discussion-watching-core")
+	defer span.End()
+	callerName := "setDiscussionWatching"
+
+	var exists bool
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		"select exists(select 1 from discussion where _id = ? and hidden = false)", discussionId,
+	).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query discussion: %v", err)
+	}
+	if !exists {
+		return map[string]interface{}{"message": "discussion not found"}, ErrNotFound
+	}
+
+	_, err = tidb.ExecContext(ctx, &span, &callerName,
+		"insert into discussion_subscription(discussion_id, user_id, watching, created_at) values (?, ?, ?, ?) "+
+			"on duplicate key update watching = values(watching)",
+		discussionId, callingUser.ID, watching, time.Now(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update discussion subscription: %v", err)
+	}
+
+	return map[string]interface{}{"watching": watching}, nil
+}
+
+// WatchDiscussion subscribes the user to the new comments and replies of a discussion
+func WatchDiscussion(ctx context.Context, tidb *ti.Database, callingUser *models.User, discussionId int64) (map[string]interface{}, error) {
+	res, err := setDiscussionWatching(ctx, tidb, callingUser, discussionId, true)
+	if err != nil {
+		return res, err
+	}
+	res["message"] = "Watching discussion"
+	return res, nil
+}
+
+// UnwatchDiscussion stops notifying the user of the new comments and replies
+// of a discussion. The user stays unsubscribed if they reply again.
+func UnwatchDiscussion(ctx context.Context, tidb *ti.Database, callingUser *models.User, discussionId int64) (map[string]interface{}, error) {
+	res, err := setDiscussionWatching(ctx, tidb, callingUser, discussionId, false)
+	if err != nil {
+		return res, err
+	}
+	res["message"] = "Stopped watching discussion"
+	return res, nil
+}
+
+// GetDiscussionWatchStatus returns whether the user is watching a discussion
+func GetDiscussionWatchStatus(ctx context.Context, tidb *ti.Database, callingUser *models.User, discussionId int64) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "get-discussion-watch-status-core")
+	defer span.End()
+	callerName := "GetDiscussionWatchStatus"
+
+	var watching bool
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		"select watching from discussion_subscription where discussion_id = ? and user_id = ?", discussionId, callingUser.ID,
+	).Scan(&watching)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to query discussion subscription: %v", err)
+	}
+
+	return map[string]interface{}{"watching": watching}, nil
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"testing"
+
+	config2 "github.com/gage-technologies/gigo-lib/config"
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/logging"
+	"github.com/gage-technologies/gigo-lib/mq"
+)
+
+// testDiscussionJetstream creates the jetstream client used to publish the
+// notifications of new discussion content
+func testDiscussionJetstream(t *testing.T) (*mq.JetstreamClient, logging.Logger) {
+	logger, err := logging.CreateBasicLogger(logging.NewDefaultBasicLoggerOptions("/tmp/gigo-core-test.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	js, err := mq.NewJetstreamClient(config2.JetstreamConfig{
+		Host:        "mq://gigo-dev-nats:4222",
+		Username:    "gigo-dev",
+		Password:    "gigo-dev",
+		MaxPubQueue: 256,
+	}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = InitDiscussionStream(js)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return js, logger
+}
+
+func TestDiscussionReplyMessage(t *testing.T) {
+	tests := []struct {
+		contentType models.CommunicationType
+		want        string
+	}{
+		{models.CommentLevel, `test commented on "How do I start?"`},
+		{models.ThreadLevel, `test replied to a comment on "How do I start?"`},
+		{models.ThreadReplyLevel, `test replied to a thread on "How do I start?"`},
+	}
+
+	for _, tt := range tests {
+		got := discussionReplyMessage("test", tt.contentType, "How do I start?")
+		if got != tt.want {
+			t.Errorf("\nTestDiscussionReplyMessage failed\n    Case: %d\n    Error: got %q, want %q", tt.contentType, got, tt.want)
+		}
+	}
+}
+
+func TestDiscussionMentionKind(t *testing.T) {
+	tests := []struct {
+		contentType models.CommunicationType
+		want        string
+	}{
+		{models.DiscussionLevel, "discussion"},
+		{models.CommentLevel, "comment"},
+		{models.ThreadLevel, "comment"},
+		{models.ThreadReplyLevel, "reply"},
+	}
+
+	for _, tt := range tests {
+		if got := discussionMentionKind(tt.contentType); got != tt.want {
+			t.Errorf("\nTestDiscussionMentionKind failed\n    Case: %d\n    Error: got %q, want %q", tt.contentType, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,684 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gigo-core/gigo/api/external_api/core/query_models"
+
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/kisielk/sqlstruct"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// default number of items loaded for each level of a discussion tree
+	defaultTreeCommentLimit = 10
+	defaultTreeThreadLimit  = 3
+	defaultTreeReplyLimit   = 3
+)
+
+// discussionRootQueries select the discussion that a comment, thread comment
+// or thread reply belongs to
+var discussionRootQueries = map[string]string{
+	"comment":        "select discussion_id from comment where _id = ? limit 1",
+	"thread_comment": "select c.discussion_id from thread_comment t join comment c on c._id = t.comment_id where t._id = ? limit 1",
+	"thread_reply": "select c.discussion_id from thread_reply r join thread_comment t on t._id = r.thread_comment_id " +
+		"join comment c on c._id = t.comment_id where r._id = ? limit 1",
+}
+
+// discussionTreeLevel describes how the children of a level of a discussion
+// tree are stored
+type discussionTreeLevel struct {
+	table        string
+	parentColumn string
+	// pinned orders the level before the sort mode
+	pinned string
+}
+
+var discussionTreeLevels = map[models.CommunicationType]discussionTreeLevel{
+	models.CommentLevel:     {table: "comment", parentColumn: "discussion_id", pinned: "a.comment_id is null"},
+	models.ThreadLevel:      {table: "thread_comment", parentColumn: "comment_id"},
+	models.ThreadReplyLevel: {table: "thread_reply", parentColumn: "thread_comment_id"},
+}
+
+type DiscussionTreeRequest struct {
+	DiscussionID string `json:"discussion_id" validate:"required,number"`
+	Cursor       string `json:"cursor"`
+	Sort         string `json:"sort" validate:"omitempty,oneof=new top hot active"`
+	CommentLimit int    `json:"comment_limit" validate:"gte=0,lte=50"`
+	ThreadLimit  int    `json:"thread_limit" validate:"gte=0,lte=20"`
+	ReplyLimit   int    `json:"reply_limit" validate:"gte=0,lte=20"`
+	Test         bool   `json:"test"`
+}
+
+// DiscussionTreeThread is a thread comment with the first page of its replies
+type DiscussionTreeThread struct {
+	Thread        *query_models.ThreadCommentBackgroundFrontend `json:"thread"`
+	Replies       []*query_models.ThreadReplyBackgroundFrontend `json:"replies"`
+	RepliesCursor *string                                       `json:"replies_cursor"`
+}
+
+// DiscussionTreeComment is a comment with the first page of its threads
+type DiscussionTreeComment struct {
+	Comment       *models.CommentBackgroundFrontend `json:"comment"`
+	Accepted      bool                              `json:"accepted"`
+	Threads       []*DiscussionTreeThread           `json:"threads"`
+	ThreadsCursor *string                           `json:"threads_cursor"`
+}
+
+// DiscussionTreeKey holds the values that an item of a discussion tree is
+// ordered by. It is scanned along with every item so that the cursor of the
+// next page can continue after the last item of the current one.
+type DiscussionTreeKey struct {
+	TreePinned     bool      `sql:"tree_pinned" json:"tree_pinned"`
+	TreeCoffee     int64     `sql:"tree_coffee" json:"tree_coffee"`
+	TreeCreatedAt  time.Time `sql:"tree_created_at" json:"tree_created_at"`
+	TreeHotScore   float64   `sql:"tree_hot_score" json:"tree_hot_score"`
+	TreeActivityAt time.Time `sql:"tree_activity_at" json:"tree_activity_at"`
+}
+
+// discussionTreeKeyValues return the value of a ranking column in a tree key
+var discussionTreeKeyValues = map[string]func(k DiscussionTreeKey) interface{}{
+	"k.coffee":           func(k DiscussionTreeKey) interface{} { return k.TreeCoffee },
+	"k.created_at":       func(k DiscussionTreeKey) interface{} { return k.TreeCreatedAt },
+	"k.hot_score":        func(k DiscussionTreeKey) interface{} { return k.TreeHotScore },
+	"k.last_activity_at": func(k DiscussionTreeKey) interface{} { return k.TreeActivityAt },
+}
+
+// discussionTreeCursor marks where the next page of a level of a discussion
+// tree starts. Pages continue after the sort key and id of the last item
+// rather than at an offset so that content created, hidden or re-ranked
+// between requests does not shift items across pages.
+type discussionTreeCursor struct {
+	Level    models.CommunicationType `json:"level"`
+	ParentID int64                    `json:"parent_id"`
+	Sort     string                   `json:"sort"`
+	Key      DiscussionTreeKey        `json:"key"`
+	ID       int64                    `json:"id"`
+}
+
+// encodeDiscussionTreeCursor formats a cursor as an opaque url safe string
+func encodeDiscussionTreeCursor(c discussionTreeCursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeDiscussionTreeCursor parses a cursor created by encodeDiscussionTreeCursor
+func decodeDiscussionTreeCursor(cursor string) (*discussionTreeCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor encoding: %v", err)
+	}
+
+	var c discussionTreeCursor
+	err = json.Unmarshal(raw, &c)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor %q: %v", raw, err)
+	}
+
+	if _, ok := discussionTreeLevels[c.Level]; !ok {
+		return nil, fmt.Errorf("invalid cursor level %d", c.Level)
+	}
+	if !ValidDiscussionSort(c.Sort) {
+		return nil, fmt.Errorf("invalid cursor sort %q", c.Sort)
+	}
+
+	return &c, nil
+}
+
+// discussionTreeColumn is a column that a level of a discussion tree is
+// ordered by along with its value in a cursor
+type discussionTreeColumn struct {
+	column string
+	desc   bool
+	value  func(c *discussionTreeCursor) interface{}
+}
+
+// discussionTreeColumns returns the columns that a level is ordered by. The
+// pinned column comes first, then the columns of the sort mode and finally
+// the id so that every item has a unique position.
+func discussionTreeColumns(level discussionTreeLevel, sort string) ([]discussionTreeColumn, error) {
+	keys, err := discussionSortKeysFor(sort)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]discussionTreeColumn, 0, len(keys)+2)
+	if level.pinned != "" {
+		columns = append(columns, discussionTreeColumn{
+			column: level.pinned,
+			value:  func(c *discussionTreeCursor) interface{} { return c.Key.TreePinned },
+		})
+	}
+	for _, key := range keys {
+		value := discussionTreeKeyValues[key.column]
+		columns = append(columns, discussionTreeColumn{
+			column: key.column,
+			desc:   key.desc,
+			value:  func(c *discussionTreeCursor) interface{} { return value(c.Key) },
+		})
+	}
+	columns = append(columns, discussionTreeColumn{
+		column: "c._id",
+		value:  func(c *discussionTreeCursor) interface{} { return c.ID },
+	})
+
+	return columns, nil
+}
+
+// discussionTreeAfter builds the condition that selects the items ordered
+// after the cursor. Each column is compared only when all of the columns
+// before it are equal to the values of the cursor.
+func discussionTreeAfter(columns []discussionTreeColumn, after *discussionTreeCursor) (string, []interface{}) {
+	clauses := make([]string, 0, len(columns))
+	params := make([]interface{}, 0)
+	for i, column := range columns {
+		parts := make([]string, 0, i+1)
+		for _, prev := range columns[:i] {
+			parts = append(parts, fmt.Sprintf("(%s) = ?", prev.column))
+			params = append(params, prev.value(after))
+		}
+
+		op := ">"
+		if column.desc {
+			op = "<"
+		}
+		parts = append(parts, fmt.Sprintf("(%s) %s ?", column.column, op))
+		params = append(params, column.value(after))
+
+		clauses = append(clauses, "("+strings.Join(parts, " and ")+")")
+	}
+
+	return " and (" + strings.Join(clauses, " or ") + ")", params
+}
+
+// discussionTreeQuery builds the query for a page of the children of many
+// parents at once. Each parent is limited separately by ranking its children
+// and selecting one extra child to learn whether another page exists. When a
+// cursor is passed only the children ordered after it are ranked.
+func discussionTreeQuery(level discussionTreeLevel, parents int, sort string, after *discussionTreeCursor) (string, []interface{}, error) {
+	columns, err := discussionTreeColumns(level, sort)
+	if err != nil {
+		return "", nil, err
+	}
+
+	order := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if column.desc {
+			order = append(order, column.column+" desc")
+		} else {
+			order = append(order, column.column)
+		}
+	}
+
+	where, params := "", []interface{}(nil)
+	if after != nil {
+		where, params = discussionTreeAfter(columns, after)
+	}
+
+	accepted, join, pinned := "", "", "false"
+	if level.table == "comment" {
+		accepted = ", a.comment_id is not null as accepted"
+		join = " left join discussion_answer a on a.comment_id = c._id"
+	}
+	if level.pinned != "" {
+		pinned = level.pinned
+	}
+
+	return fmt.Sprintf(
+		"select * from (select c.*, r._id as reward_id, color_palette, name, render_in_front, user_status%s, %s as tree_pinned, "+
+			"k.coffee as tree_coffee, k.created_at as tree_created_at, k.hot_score as tree_hot_score, k.last_activity_at as tree_activity_at, "+
+			"row_number() over (partition by c.%s order by %s) as tree_rank from %s c "+
+			"inner join (select _id, max(revision) as revision from %s where %s in (?%s) group by _id) t on c._id = t._id and c.revision = t.revision%s "+
+			"join discussion_rank k on k._id = c._id left join users u on c.author_id = u._id left join rewards r on r._id = u.avatar_reward "+
+			"where c.hidden = false%s) x where tree_rank <= ? order by %s, tree_rank",
+		accepted, pinned, level.parentColumn, strings.Join(order, ", "), level.table, level.table, level.parentColumn,
+		strings.Repeat(", ?", parents-1), join, where, level.parentColumn,
+	), params, nil
+}
+
+// discussionTreeBuilder assembles a discussion tree one level at a time so
+// that every level is loaded with a single query
+type discussionTreeBuilder struct {
+	ctx          context.Context
+	span         *trace.Span
+	callerName   string
+	tidb         *ti.Database
+	sort         string
+	commentLimit int
+	threadLimit  int
+	replyLimit   int
+	bodyHTML     map[string]string
+	edited       map[string]*time.Time
+	ids          []int64
+}
+
+// queryLevel executes the query for a page of the children of the parents
+func (b *discussionTreeBuilder) queryLevel(contentType models.CommunicationType, parentIds []int64, after *discussionTreeCursor, limit int) (*sql.Rows, error) {
+	query, afterParams, err := discussionTreeQuery(discussionTreeLevels[contentType], len(parentIds), b.sort, after)
+	if err != nil {
+		return nil, err
+	}
+
+	params := make([]interface{}, 0, len(parentIds)+len(afterParams)+1)
+	for _, id := range parentIds {
+		params = append(params, id)
+	}
+	params = append(params, afterParams...)
+	params = append(params, limit+1)
+
+	rows, err := b.tidb.QueryContext(b.ctx, b.span, &b.callerName, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s tree: %v", discussionTreeLevels[contentType].table, err)
+	}
+	return rows, nil
+}
+
+// record stores the rendered body and edit time of an item in the tree
+func (b *discussionTreeBuilder) record(id int64, revision int, body string, bodyHTML sql.NullString, editedAt sql.NullTime) {
+	b.bodyHTML[strconv.FormatInt(id, 10)] = discussionBodyHTML(bodyHTML, body)
+	markDiscussionEdited(b.edited, id, revision, editedAt)
+	b.ids = append(b.ids, id)
+}
+
+// nextCursor returns the cursor for the page after the current one if there
+// is one. The cursor continues after the last item of the current page.
+func (b *discussionTreeBuilder) nextCursor(level models.CommunicationType, parentId int64, last discussionTreeCursor, limit int, count int) *string {
+	if count <= limit {
+		return nil
+	}
+	last.Level = level
+	last.ParentID = parentId
+	last.Sort = b.sort
+	cursor := encodeDiscussionTreeCursor(last)
+	return &cursor
+}
+
+// loadReplies loads a page of the replies of each of the threads
+func (b *discussionTreeBuilder) loadReplies(threadIds []int64, after *discussionTreeCursor) (map[int64][]*query_models.ThreadReplyBackgroundFrontend, map[int64]*string, error) {
+	replies := make(map[int64][]*query_models.ThreadReplyBackgroundFrontend)
+	cursors := make(map[int64]*string)
+	if len(threadIds) == 0 {
+		return replies, cursors, nil
+	}
+
+	rows, err := b.queryLevel(models.ThreadReplyLevel, threadIds, after, b.replyLimit)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[int64]int)
+	last := make(map[int64]discussionTreeCursor)
+	for rows.Next() {
+		var reply struct {
+			query_models.ThreadReplyBackground
+			DiscussionTreeKey
+			BodyHTML sql.NullString `sql:"body_html"`
+			EditedAt sql.NullTime   `sql:"edited_at"`
+			Deleted  bool           `sql:"deleted"`
+		}
+		err = sqlstruct.Scan(&reply, rows)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan thread reply: %v", err)
+		}
+		redactDeletedDiscussion(reply.Deleted, &reply.Body, &reply.BodyHTML)
+
+		counts[reply.ThreadCommentId]++
+		if counts[reply.ThreadCommentId] > b.replyLimit {
+			continue
+		}
+
+		replies[reply.ThreadCommentId] = append(replies[reply.ThreadCommentId], reply.ToFrontend())
+		last[reply.ThreadCommentId] = discussionTreeCursor{Key: reply.DiscussionTreeKey, ID: reply.ID}
+		b.record(reply.ID, reply.Revision, reply.Body, reply.BodyHTML, reply.EditedAt)
+	}
+
+	for threadId, count := range counts {
+		cursors[threadId] = b.nextCursor(models.ThreadReplyLevel, threadId, last[threadId], b.replyLimit, count)
+	}
+
+	return replies, cursors, nil
+}
+
+// loadThreads loads a page of the threads of each of the comments along with
+// the first page of the replies of every thread
+func (b *discussionTreeBuilder) loadThreads(commentIds []int64, after *discussionTreeCursor) (map[int64][]*DiscussionTreeThread, map[int64]*string, error) {
+	threads := make(map[int64][]*DiscussionTreeThread)
+	cursors := make(map[int64]*string)
+	if len(commentIds) == 0 {
+		return threads, cursors, nil
+	}
+
+	rows, err := b.queryLevel(models.ThreadLevel, commentIds, after, b.threadLimit)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[int64]int)
+	last := make(map[int64]discussionTreeCursor)
+	threadIds := make([]int64, 0)
+	for rows.Next() {
+		var thread struct {
+			query_models.ThreadCommentBackground
+			DiscussionTreeKey
+			BodyHTML sql.NullString `sql:"body_html"`
+			EditedAt sql.NullTime   `sql:"edited_at"`
+			Deleted  bool           `sql:"deleted"`
+		}
+		err = sqlstruct.Scan(&thread, rows)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan thread comment: %v", err)
+		}
+		redactDeletedDiscussion(thread.Deleted, &thread.Body, &thread.BodyHTML)
+
+		counts[thread.CommentId]++
+		if counts[thread.CommentId] > b.threadLimit {
+			continue
+		}
+
+		threads[thread.CommentId] = append(threads[thread.CommentId], &DiscussionTreeThread{
+			Thread:  thread.ToFrontend(),
+			Replies: make([]*query_models.ThreadReplyBackgroundFrontend, 0),
+		})
+		threadIds = append(threadIds, thread.ID)
+		last[thread.CommentId] = discussionTreeCursor{Key: thread.DiscussionTreeKey, ID: thread.ID}
+		b.record(thread.ID, thread.Revision, thread.Body, thread.BodyHTML, thread.EditedAt)
+	}
+	_ = rows.Close()
+
+	for commentId, count := range counts {
+		cursors[commentId] = b.nextCursor(models.ThreadLevel, commentId, last[commentId], b.threadLimit, count)
+	}
+
+	replies, replyCursors, err := b.loadReplies(threadIds, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, page := range threads {
+		for _, thread := range page {
+			id, _ := strconv.ParseInt(thread.Thread.ID, 10, 64)
+			if r, ok := replies[id]; ok {
+				thread.Replies = r
+			}
+			thread.RepliesCursor = replyCursors[id]
+		}
+	}
+
+	return threads, cursors, nil
+}
+
+// loadComments loads a page of the comments of a discussion along with the
+// first page of the threads of every comment
+func (b *discussionTreeBuilder) loadComments(discussionId int64, after *discussionTreeCursor) ([]*DiscussionTreeComment, *string, error) {
+	rows, err := b.queryLevel(models.CommentLevel, []int64{discussionId}, after, b.commentLimit)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	comments := make([]*DiscussionTreeComment, 0)
+	commentIds := make([]int64, 0)
+	count := 0
+	var last discussionTreeCursor
+	for rows.Next() {
+		var comment struct {
+			models.CommentBackground
+			DiscussionTreeKey
+			BodyHTML sql.NullString `sql:"body_html"`
+			EditedAt sql.NullTime   `sql:"edited_at"`
+			Deleted  bool           `sql:"deleted"`
+			Accepted bool           `sql:"accepted"`
+		}
+		err = sqlstruct.Scan(&comment, rows)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan comment: %v", err)
+		}
+		redactDeletedDiscussion(comment.Deleted, &comment.Body, &comment.BodyHTML)
+
+		count++
+		if count > b.commentLimit {
+			continue
+		}
+
+		comments = append(comments, &DiscussionTreeComment{
+			Comment:  comment.ToFrontend(),
+			Accepted: comment.Accepted,
+			Threads:  make([]*DiscussionTreeThread, 0),
+		})
+		commentIds = append(commentIds, comment.ID)
+		last = discussionTreeCursor{Key: comment.DiscussionTreeKey, ID: comment.ID}
+		b.record(comment.ID, comment.Revision, comment.Body, comment.BodyHTML, comment.EditedAt)
+	}
+	_ = rows.Close()
+
+	threads, threadCursors, err := b.loadThreads(commentIds, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i, comment := range comments {
+		if t, ok := threads[commentIds[i]]; ok {
+			comment.Threads = t
+		}
+		comment.ThreadsCursor = threadCursors[commentIds[i]]
+	}
+
+	return comments, b.nextCursor(models.CommentLevel, discussionId, last, b.commentLimit, count), nil
+}
+
+// upVoted returns the ids of the items in the tree that the user has up voted
+func (b *discussionTreeBuilder) upVoted(callingUser *models.User) ([]string, error) {
+	voted := make([]string, 0)
+	if callingUser == nil || len(b.ids) == 0 {
+		return voted, nil
+	}
+
+	params := make([]interface{}, 0, len(b.ids)+1)
+	params = append(params, callingUser.ID)
+	for _, id := range b.ids {
+		params = append(params, id)
+	}
+
+	rows, err := b.tidb.QueryContext(b.ctx, b.span, &b.callerName,
+		"select discussion_id from up_vote where user_id = ? and discussion_id in (?"+strings.Repeat(", ?", len(b.ids)-1)+")",
+		params...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query up votes: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan up vote: %v", err)
+		}
+		voted = append(voted, strconv.FormatInt(id, 10))
+	}
+
+	return voted, nil
+}
+
+// DiscussionRootID returns the id of the discussion that a discussion,
+// comment, thread comment or thread reply belongs to
+func DiscussionRootID(ctx context.Context, tidb *ti.Database, discussionType string, id int64) (int64, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "discussion-root-id-core")
+	defer span.End()
+	callerName := "DiscussionRootID"
+
+	if discussionType == "discussion" {
+		return id, nil
+	}
+
+	query, ok := discussionRootQueries[discussionType]
+	if !ok {
+		return 0, fmt.Errorf("invalid discussion type %q", discussionType)
+	}
+
+	var discussionId int64
+	err := tidb.QueryRowContext(ctx, &span, &callerName, query, id).Scan(&discussionId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrNotFound
+		}
+		return 0, fmt.Errorf("failed to query discussion of %s: %v", discussionType, err)
+	}
+
+	return discussionId, nil
+}
+
+// GetDiscussionTree loads a discussion with a page of its comments, a page of
+// the threads of each comment and a page of the replies of each thread. Every
+// level that has more items returns a cursor. Passing a cursor loads the next
+// page of that level along with the levels below it instead of the discussion.
+func GetDiscussionTree(ctx context.Context, tidb *ti.Database, callingUser *models.User, discussionId int64, cursor string,
+	sort string, commentLimit int, threadLimit int, replyLimit int) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "get-discussion-tree-core")
+	defer span.End()
+	callerName := "GetDiscussionTree"
+
+	if !ValidDiscussionSort(sort) {
+		return map[string]interface{}{"message": "invalid sort mode"}, fmt.Errorf("invalid discussion sort %q", sort)
+	}
+
+	if commentLimit <= 0 {
+		commentLimit = defaultTreeCommentLimit
+	}
+	if threadLimit <= 0 {
+		threadLimit = defaultTreeThreadLimit
+	}
+	if replyLimit <= 0 {
+		replyLimit = defaultTreeReplyLimit
+	}
+
+	b := &discussionTreeBuilder{
+		ctx:          ctx,
+		span:         &span,
+		callerName:   callerName,
+		tidb:         tidb,
+		sort:         sort,
+		commentLimit: commentLimit,
+		threadLimit:  threadLimit,
+		replyLimit:   replyLimit,
+		bodyHTML:     make(map[string]string),
+		edited:       make(map[string]*time.Time),
+		ids:          make([]int64, 0),
+	}
+
+	var res map[string]interface{}
+	if cursor != "" {
+		c, err := decodeDiscussionTreeCursor(cursor)
+		if err != nil {
+			return map[string]interface{}{"message": "invalid cursor"}, err
+		}
+
+		// cursors continue the pages of the sort mode that created them
+		b.sort = c.Sort
+
+		// cursors can only continue a level of the requested discussion so
+		// that cached pages are invalidated along with the discussion
+		parentDiscussion := c.ParentID
+		if c.Level != models.CommentLevel {
+			parentType := "comment"
+			if c.Level == models.ThreadReplyLevel {
+				parentType = "thread_comment"
+			}
+			parentDiscussion, err = DiscussionRootID(ctx, tidb, parentType, c.ParentID)
+			if err != nil && err != ErrNotFound {
+				return nil, err
+			}
+		}
+		if parentDiscussion != discussionId {
+			return map[string]interface{}{"message": "invalid cursor"}, fmt.Errorf("cursor parent %d is not part of discussion %d", c.ParentID, discussionId)
+		}
+
+		switch c.Level {
+		case models.CommentLevel:
+			comments, next, err := b.loadComments(c.ParentID, c)
+			if err != nil {
+				return nil, err
+			}
+			res = map[string]interface{}{"comments": comments, "comments_cursor": next}
+		case models.ThreadLevel:
+			threads, cursors, err := b.loadThreads([]int64{c.ParentID}, c)
+			if err != nil {
+				return nil, err
+			}
+			page := threads[c.ParentID]
+			if page == nil {
+				page = make([]*DiscussionTreeThread, 0)
+			}
+			res = map[string]interface{}{"threads": page, "threads_cursor": cursors[c.ParentID]}
+		case models.ThreadReplyLevel:
+			replies, cursors, err := b.loadReplies([]int64{c.ParentID}, c)
+			if err != nil {
+				return nil, err
+			}
+			page := replies[c.ParentID]
+			if page == nil {
+				page = make([]*query_models.ThreadReplyBackgroundFrontend, 0)
+			}
+			res = map[string]interface{}{"replies": page, "replies_cursor": cursors[c.ParentID]}
+		}
+	} else {
+		rows, err := tidb.QueryContext(ctx, &span, &callerName,
+			"select d.*, r._id as reward_id, color_palette, name, render_in_front, user_status, l.discussion_id is not null as locked from discussion d "+
+				"left join discussion_lock l on l.discussion_id = d._id left join users u on d.author_id = u._id left join rewards r on r._id = u.avatar_reward "+
+				"where d._id = ? and d.hidden = false order by d.revision desc limit 1",
+			discussionId,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query discussion: %v", err)
+		}
+
+		if !rows.Next() {
+			_ = rows.Close()
+			return map[string]interface{}{"message": "discussion not found"}, ErrNotFound
+		}
+
+		var discussion struct {
+			models.DiscussionBackground
+			BodyHTML sql.NullString `sql:"body_html"`
+			EditedAt sql.NullTime   `sql:"edited_at"`
+			Deleted  bool           `sql:"deleted"`
+			Locked   bool           `sql:"locked"`
+		}
+		err = sqlstruct.Scan(&discussion, rows)
+		_ = rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan discussion: %v", err)
+		}
+		redactDeletedDiscussion(discussion.Deleted, &discussion.Body, &discussion.BodyHTML)
+		b.record(discussion.ID, discussion.Revision, discussion.Body, discussion.BodyHTML, discussion.EditedAt)
+
+		comments, next, err := b.loadComments(discussionId, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		res = map[string]interface{}{"discussion": discussion.ToFrontend(), "locked": discussion.Locked, "comments": comments, "comments_cursor": next}
+	}
+
+	voted, err := b.upVoted(callingUser)
+	if err != nil {
+		return nil, err
+	}
+
+	res["body_html"] = b.bodyHTML
+	res["edited"] = b.edited
+	res["up_voted"] = voted
+	return res, nil
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gage-technologies/gigo-lib/db/models"
+)
+
+func TestDiscussionTreeCursor(t *testing.T) {
+	cursor := discussionTreeCursor{
+		Level:    models.ThreadLevel,
+		ParentID: 1688617436791701504,
+		Sort:     "top",
+		Key: DiscussionTreeKey{
+			TreeCoffee:     12,
+			TreeCreatedAt:  time.Date(2023, 7, 7, 12, 30, 0, 0, time.UTC),
+			TreeHotScore:   1.0791812460476249,
+			TreeActivityAt: time.Date(2023, 7, 8, 9, 0, 0, 0, time.UTC),
+		},
+		ID: 1688617436791701505,
+	}
+
+	got, err := decodeDiscussionTreeCursor(encodeDiscussionTreeCursor(cursor))
+	if err != nil {
+		t.Fatalf("\nTestDiscussionTreeCursor failed\n    Error: %v", err)
+	}
+	if got.Level != cursor.Level || got.ParentID != cursor.ParentID || got.Sort != cursor.Sort || got.ID != cursor.ID ||
+		got.Key.TreeCoffee != cursor.Key.TreeCoffee || got.Key.TreeHotScore != cursor.Key.TreeHotScore ||
+		!got.Key.TreeCreatedAt.Equal(cursor.Key.TreeCreatedAt) || !got.Key.TreeActivityAt.Equal(cursor.Key.TreeActivityAt) {
+		t.Errorf("\nTestDiscussionTreeCursor failed\n    Error: got %+v, want %+v", *got, cursor)
+	}
+
+	for _, invalid := range []string{
+		"not a cursor",
+		encodeDiscussionTreeCursor(discussionTreeCursor{Level: models.DiscussionLevel}),
+		encodeDiscussionTreeCursor(discussionTreeCursor{Level: models.CommentLevel, Sort: "bogus"}),
+		"MTox",
+	} {
+		if _, err := decodeDiscussionTreeCursor(invalid); err == nil {
+			t.Errorf("\nTestDiscussionTreeCursor failed\n    Case: %s\n    Error: invalid cursor was accepted", invalid)
+		}
+	}
+}
+
+func TestDiscussionTreeQuery(t *testing.T) {
+	query, params, err := discussionTreeQuery(discussionTreeLevels[models.CommentLevel], 1, "top", nil)
+	if err != nil {
+		t.Fatalf("\nTestDiscussionTreeQuery failed\n    Error: %v", err)
+	}
+
+	// accepted answers stay pinned ahead of the sort mode and the id keeps pages stable
+	if !strings.Contains(query, "partition by c.discussion_id order by a.comment_id is null, k.coffee desc, k.created_at desc, c._id") {
+		t.Errorf("\nTestDiscussionTreeQuery failed\n    Error: unexpected order in %s", query)
+	}
+	if !strings.Contains(query, "where discussion_id in (?) group by _id") {
+		t.Errorf("\nTestDiscussionTreeQuery failed\n    Error: unexpected parents in %s", query)
+	}
+	if len(params) != 0 || !strings.Contains(query, "where c.hidden = false) x where tree_rank <= ?") {
+		t.Errorf("\nTestDiscussionTreeQuery failed\n    Error: first page should not filter by a cursor in %s %v", query, params)
+	}
+
+	query, _, err = discussionTreeQuery(discussionTreeLevels[models.ThreadReplyLevel], 3, "new", nil)
+	if err != nil {
+		t.Fatalf("\nTestDiscussionTreeQuery failed\n    Error: %v", err)
+	}
+	if !strings.Contains(query, "partition by c.thread_comment_id order by c._id") || !strings.Contains(query, "in (?, ?, ?)") {
+		t.Errorf("\nTestDiscussionTreeQuery failed\n    Error: unexpected query %s", query)
+	}
+	if strings.Contains(query, "discussion_answer") {
+		t.Errorf("\nTestDiscussionTreeQuery failed\n    Error: replies should not join answers in %s", query)
+	}
+
+	if _, _, err = discussionTreeQuery(discussionTreeLevels[models.ThreadLevel], 1, "bogus", nil); err == nil {
+		t.Error("\nTestDiscussionTreeQuery failed\n    Error: invalid sort was accepted")
+	}
+}
+
+func TestDiscussionTreeAfter(t *testing.T) {
+	createdAt := time.Date(2023, 7, 7, 12, 30, 0, 0, time.UTC)
+	after := &discussionTreeCursor{
+		Level:    models.CommentLevel,
+		ParentID: 1,
+		Sort:     "top",
+		Key:      DiscussionTreeKey{TreePinned: true, TreeCoffee: 12, TreeCreatedAt: createdAt},
+		ID:       69,
+	}
+
+	query, params, err := discussionTreeQuery(discussionTreeLevels[models.CommentLevel], 1, "top", after)
+	if err != nil {
+		t.Fatalf("\nTestDiscussionTreeAfter failed\n    Error: %v", err)
+	}
+
+	// continuation pages start after the sort key and id of the last item
+	want := "where c.hidden = false and (((a.comment_id is null) > ?) or ((a.comment_id is null) = ? and (k.coffee) < ?) or " +
+		"((a.comment_id is null) = ? and (k.coffee) = ? and (k.created_at) < ?) or " +
+		"((a.comment_id is null) = ? and (k.coffee) = ? and (k.created_at) = ? and (c._id) > ?))) x where tree_rank <= ?"
+	if !strings.Contains(query, want) {
+		t.Errorf("\nTestDiscussionTreeAfter failed\n    Error: unexpected cursor condition in %s", query)
+	}
+
+	wantParams := []interface{}{true, true, int64(12), true, int64(12), createdAt, true, int64(12), createdAt, int64(69)}
+	if !reflect.DeepEqual(params, wantParams) {
+		t.Errorf("\nTestDiscussionTreeAfter failed\n    Error: got params %v, want %v", params, wantParams)
+	}
+
+	query, params, err = discussionTreeQuery(discussionTreeLevels[models.ThreadReplyLevel], 1, "new", &discussionTreeCursor{ID: 70})
+	if err != nil {
+		t.Fatalf("\nTestDiscussionTreeAfter failed\n    Error: %v", err)
+	}
+	if !strings.Contains(query, "where c.hidden = false and (((c._id) > ?))") || !reflect.DeepEqual(params, []interface{}{int64(70)}) {
+		t.Errorf("\nTestDiscussionTreeAfter failed\n    Error: unexpected cursor condition in %s %v", query, params)
+	}
+}
@@ -47,7 +47,6 @@ import (
 	"github.com/gage-technologies/gigo-lib/git"
 	"github.com/gage-technologies/gigo-lib/logging"
 	"github.com/gage-technologies/gigo-lib/mq"
-	"github.com/gage-technologies/gigo-lib/search"
 	"github.com/gage-technologies/gigo-lib/session"
 	"github.com/gage-technologies/gigo-lib/storage"
 	"github.com/gage-technologies/gigo-lib/utils"
@@ -57,7 +56,7 @@ import (
 	"go.opentelemetry.io/otel"
 )
 
-func CreateEphemeral(ctx context.Context, tidb *ti.Database, storageEngine storage.Storage, meili *search.MeiliSearchEngine, sf *snowflake.Node,
+func CreateEphemeral(ctx context.Context, tidb *ti.Database, storageEngine storage.Storage, sf *snowflake.Node,
 	domain string, vscClient *git.VCSClient, masterKey string, jetstreamClient *mq.JetstreamClient,
 	wsStatusUpdater *utils2.WorkspaceStatusUpdater, rdb redis.UniversalClient, challengeID int64, ip int64, workspacePath string, accessUrl string,
 	hostname string, useTLS bool, ipString string, logger logging.Logger) (map[string]interface{}, error) {
@@ -76,7 +75,7 @@ func CreateEphemeral(ctx context.Context, tidb *ti.Database, storageEngine stora
 		return map[string]interface{}{"message": "ephemeral system has been used on this network before"}, nil
 	}
 
-	callingUser, err := CreateNewEUser(ctx, tidb, meili, sf, domain, vscClient, masterKey)
+	callingUser, err := CreateNewEUser(ctx, tidb, sf, domain, vscClient, masterKey)
 	if err != nil {
 		logger.Errorf("failed to create new ephemeral user, ip: %v err: %v", fmt.Sprintf("%v", ip), err)
 		return nil, err
@@ -163,7 +162,7 @@ func CreateEphemeral(ctx context.Context, tidb *ti.Database, storageEngine stora
 	}, nil
 }
 
-func CreateAccountFromEphemeral(ctx context.Context, tidb *ti.Database, meili *search.MeiliSearchEngine,
+func CreateAccountFromEphemeral(ctx context.Context, tidb *ti.Database,
 	streakEngine *streak.StreakEngine, domain string, userName string, password string, email string, phone string, bio string,
 	firstName string, lastName string, vcsClient *git.VCSClient, starterUserInfo models.UserStart, timezone string, thumbnailPath string,
 	storageEngine storage.Storage, avatarSettings models.AvatarSettings, filter *utils3.PasswordFilter, forcePass bool, initialRecUrl string,
@@ -381,10 +380,11 @@ func CreateAccountFromEphemeral(ctx context.Context, tidb *ti.Database, meili *s
 		return nil, fmt.Errorf("failed to write thumbnail to final location: %v", err)
 	}
 
-	// attempt to insert user into search engine
-	err = meili.AddDocuments("users", newUser.ToSearch())
+	// queue the new user for the search engine
+	err = queueSearchSync(ctx, tx, callerName, "users", newUser.ID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to insert new user into search engine: %v", err)
+		_ = tx.Rollback()
+		return nil, fmt.Errorf("failed to queue new user for search engine: %v", err)
 	}
 
 	// format user to frontend
@@ -471,7 +471,7 @@ func CreateAccountFromEphemeral(ctx context.Context, tidb *ti.Database, meili *s
 
 }
 
-func CreateAccountFromEphemeralGoogle(ctx context.Context, tidb *ti.Database, meili *search.MeiliSearchEngine, snowflakeNode *snowflake.Node, streakEngine *streak.StreakEngine,
+func CreateAccountFromEphemeralGoogle(ctx context.Context, tidb *ti.Database, snowflakeNode *snowflake.Node, streakEngine *streak.StreakEngine,
 	domain string, externalAuth string, password string, vcsClient *git.VCSClient, starterUserInfo models.UserStart, timezone string, avatarSettings models.AvatarSettings, thumbnailPath string,
 	storageEngine storage.Storage, mgKey string, mgDomain string, initialRecUrl string, referralUser *string, eUser *models.User, logger logging.Logger) (map[string]interface{}, error) {
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "create-new-google-user-core")
@@ -635,10 +635,11 @@ func CreateAccountFromEphemeralGoogle(ctx context.Context, tidb *ti.Database, me
 		return nil, fmt.Errorf("failed to write thumbnail to final location: %v", err)
 	}
 
-	// attempt to insert user into search engine
-	err = meili.AddDocuments("users", newUser.ToSearch())
+	// queue the new user for the search engine
+	err = queueSearchSync(ctx, tx, callerName, "users", newUser.ID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to insert new user into search engine: %v", err)
+		_ = tx.Rollback()
+		return nil, fmt.Errorf("failed to queue new user for search engine: %v", err)
 	}
 
 	// format user to frontend
@@ -728,7 +729,7 @@ func CreateAccountFromEphemeralGoogle(ctx context.Context, tidb *ti.Database, me
 	return map[string]interface{}{"message": "Google User Added.", "user": user}, nil
 }
 
-func CreateAccountFromEphemeralGithub(ctx context.Context, tidb *ti.Database, meili *search.MeiliSearchEngine, snowflakeNode *snowflake.Node, streakEngine *streak.StreakEngine,
+func CreateAccountFromEphemeralGithub(ctx context.Context, tidb *ti.Database, snowflakeNode *snowflake.Node, streakEngine *streak.StreakEngine,
 	domain string, externalAuth string, password string, vcsClient *git.VCSClient, starterUserInfo models.UserStart,
 	timezone string, avatarSetting models.AvatarSettings, githubSecret string, thumbnailPath string,
 	storageEngine storage.Storage, mgKey string, mgDomain string, initialRecUrl string, referralUser *string,
@@ -955,10 +956,11 @@ func CreateAccountFromEphemeralGithub(ctx context.Context, tidb *ti.Database, me
 		return nil, fmt.Errorf("failed to write thumbnail to final location: %v", err)
 	}
 
-	// attempt to insert user into search engine
-	err = meili.AddDocuments("users", newUser.ToSearch())
+	// queue the new user for the search engine
+	err = queueSearchSync(ctx, tx, callerName, "users", newUser.ID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to insert new user into search engine: %v", err)
+		_ = tx.Rollback()
+		return nil, fmt.Errorf("failed to queue new user for search engine: %v", err)
 	}
 
 	if referralUser != nil {
@@ -40,6 +40,7 @@ where
 	f.follower = ?
 	and p.deleted = false
 	and p.published = true
+	and p.hidden = false
 order by p.updated_at desc
 limit ?
 offset ?
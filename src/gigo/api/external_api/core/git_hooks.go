@@ -2,70 +2,266 @@ package core
 
 import (
 	"context"
-	"github.com/bwmarrin/snowflake"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gage-technologies/gigo-lib/config"
 	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/db/models"
 	"github.com/gage-technologies/gigo-lib/git"
 	"github.com/gage-technologies/gigo-lib/types"
+	"github.com/go-redis/redis/v8"
 	"go.opentelemetry.io/otel"
 )
 
-// TODO: needs testing
+const workspaceConfigRepoPath = ".gigo/workspace.yaml"
+
+// workspaceConfigPushChange describes what a push did to the workspace config
+type workspaceConfigPushChange int
+
+const (
+	workspaceConfigUnchanged workspaceConfigPushChange = iota
+	workspaceConfigChanged
+	workspaceConfigRemoved
+)
+
+// giteaCompareTimeout bounds the compare request made for each push
+const giteaCompareTimeout = time.Second * 30
+
+// giteaCompare is the subset of the response of the gitea compare endpoint
+// that is needed to find the files changed by a push
+type giteaCompare struct {
+	TotalCommits int `json:"total_commits"`
+	Commits      []struct {
+		Files []struct {
+			Filename string `json:"filename"`
+			Status   string `json:"status"`
+		} `json:"files"`
+	} `json:"commits"`
+}
+
+// touches returns whether any commit of the comparison changed the file
+func (c *giteaCompare) touches(path string) bool {
+	for _, commit := range c.Commits {
+		for _, file := range commit.Files {
+			if file.Filename == path {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// compareGiteaCommits retrieves the commits between two refs of a repository.
+// The gitea sdk does not expose the compare endpoint so the request is made
+// directly with the credentials of the gitea client.
+func compareGiteaCommits(ctx context.Context, giteaConfig config.GiteaConfig, owner string, repo string,
+	base string, head string) (*giteaCompare, error) {
+	ctx, cancel := context.WithTimeout(ctx, giteaCompareTimeout)
+	defer cancel()
+
+	compareUrl := fmt.Sprintf("%s/api/v1/repos/%s/%s/compare/%s...%s", strings.TrimSuffix(giteaConfig.HostUrl, "/"),
+		url.PathEscape(owner), url.PathEscape(repo), url.PathEscape(base), url.PathEscape(head))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, compareUrl, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create compare request: %v", err)
+	}
+	req.SetBasicAuth(giteaConfig.Username, giteaConfig.Password)
+	req.Header.Set("Accept", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare %s...%s: %v", base, head, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		buf, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return nil, fmt.Errorf("failed to compare %s...%s: status %d\n    res: %s", base, head, res.StatusCode, string(buf))
+	}
+
+	var compare giteaCompare
+	err = json.NewDecoder(res.Body).Decode(&compare)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode compare of %s...%s: %v", base, head, err)
+	}
+
+	return &compare, nil
+}
+
+// workspaceConfigChangeFromPush determines what a push did to the workspace
+// config. The commits of the push are compared through gitea since the webhook
+// payload only carries a truncated list of commits. The content of the config
+// at the head of the push is returned when it was changed.
+func workspaceConfigChangeFromPush(ctx context.Context, vcsClient *git.VCSClient, giteaConfig config.GiteaConfig,
+	req *types.GiteaWebhookPush) (workspaceConfigPushChange, []byte, error) {
+	owner := req.Repository.Owner.Username
+	repo := req.Repository.Name
+
+	// a push that creates the branch has nothing to compare against so only
+	// the presence of the config at the head matters
+	created := strings.Trim(req.Before, "0") == ""
+	if !created {
+		compare, err := compareGiteaCommits(ctx, giteaConfig, owner, repo, req.Before, req.After)
+		if err != nil {
+			return workspaceConfigUnchanged, nil, err
+		}
+		if !compare.touches(workspaceConfigRepoPath) {
+			return workspaceConfigUnchanged, nil, nil
+		}
+	}
+
+	// the final state of the config is read from the head instead of replaying
+	// the commits so that a config removed and re-added is handled correctly
+	configBytes, gitRes, err := vcsClient.GiteaClient.GetFile(owner, repo, req.After, workspaceConfigRepoPath)
+	if err != nil {
+		if gitRes != nil && gitRes.StatusCode == http.StatusNotFound {
+			if created {
+				return workspaceConfigUnchanged, nil, nil
+			}
+			return workspaceConfigRemoved, nil, nil
+		}
+		return workspaceConfigUnchanged, nil, fmt.Errorf("failed to retrieve workspace config for push %s: %v", req.After, err)
+	}
+
+	return workspaceConfigChanged, configBytes, nil
+}
 
-func GiteaWebhookPush(ctx context.Context, db *ti.Database, vcsClient *git.VCSClient, sf *snowflake.Node,
-	req *types.GiteaWebhookPush) error {
+// GiteaWebhookPush handles a push to a repository. The post or attempt that
+// owns the repository is marked as updated and re-indexed. When the push
+// changes the workspace config the new config is validated and any running
+// workspaces for the repository are flagged so that they can be restarted.
+func GiteaWebhookPush(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClient, giteaConfig config.GiteaConfig,
+	rdb redis.UniversalClient, req *types.GiteaWebhookPush) (map[string]interface{}, error) {
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "gitea-webhook-push")
 	defer span.End()
-	return nil
+	callerName := "GiteaWebhookPush"
+
+	// only pushes to the default branch change the state of a project
+	branch := req.Repository.DefaultBranch
+	if branch == "" {
+		branch = "main"
+	}
+	if req.Ref != "refs/heads/"+branch {
+		return map[string]interface{}{"message": "skipped"}, nil
+	}
+
+	repoId := req.Repository.ID
+	now := time.Now()
+
+	_, err := tidb.ExecContext(ctx, &span, &callerName,
+		"update post set updated_at = ? where repo_id = ? and deleted = false", now, repoId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update post for push: %v", err)
+	}
+
+	_, err = tidb.ExecContext(ctx, &span, &callerName,
+		"update attempt set updated_at = ? where repo_id = ?", now, repoId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update attempt for push: %v", err)
+	}
+
+	// refresh the search documents of the posts backed by this repo
+	err = refreshPushedPosts(ctx, tidb, repoId)
+	if err != nil {
+		return nil, err
+	}
+
+	change, configBytes, err := workspaceConfigChangeFromPush(ctx, vcsClient, giteaConfig, req)
+	if err != nil {
+		return nil, err
+	}
+	if change == workspaceConfigUnchanged {
+		return map[string]interface{}{"message": "success"}, nil
+	}
+
+	var lint *WorkspaceConfigLintResult
+	if change == workspaceConfigRemoved {
+		lint = &WorkspaceConfigLintResult{
+			Errors:   []WorkspaceConfigIssue{{Path: "", Message: "workspace config was removed", Line: 1, Column: 1}},
+			Warnings: make([]WorkspaceConfigIssue, 0),
+		}
+	} else {
+		// repos are owned by the account named after the user id so the owner's
+		// tier is used to check the resources
+		var owner *models.User
+		ownerId, err := strconv.ParseInt(req.Repository.Owner.Username, 10, 64)
+		if err == nil {
+			var userStatus models.UserStatus
+			err = tidb.QueryRowContext(ctx, &span, &callerName,
+				"select user_status from users where _id = ? limit 1", ownerId,
+			).Scan(&userStatus)
+			if err != nil && err != sql.ErrNoRows {
+				return nil, fmt.Errorf("failed to query repo owner: %v", err)
+			}
+			owner = &models.User{ID: ownerId, UserStatus: userStatus}
+		}
+
+		lint = lintWorkspaceConfig(ctx, rdb, owner, string(configBytes))
+	}
+
+	// a broken config would fail to start so the running workspaces are left
+	// alone until the config is fixed
+	if !lint.Valid {
+		return map[string]interface{}{"message": "workspace config is invalid", "lint": lint}, nil
+	}
+
+	res, err := tidb.ExecContext(ctx, &span, &callerName,
+		"update workspaces set config_update_pending = true where repo_id = ? and state in (?, ?)",
+		repoId, models.WorkspaceStarting, models.WorkspaceActive,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to flag workspaces for config update: %v", err)
+	}
+
+	flagged, _ := res.RowsAffected()
+
+	return map[string]interface{}{"message": "success", "lint": lint, "flagged_workspaces": flagged}, nil
 }
 
-// func GiteaWebhookPush(db *ti.Database, vcsClient *git.VCSClient, coderClient *coder.CoderClient, sf *snowflake.Node,
-// 	req *types.GiteaWebhookPush) error {
-// 	// skip if the workspace file wasn't modified in anyway
-// 	modified := false
-// 	for _, commit := range req.Commits {
-// 		for _, file := range commit.Modified {
-// 			if file == ".gigo/workspace.yaml" {
-// 				modified = true
-// 				break
-// 			}
-// 		}
-// 		if modified {
-// 			break
-// 		}
-//
-// 		for _, file := range commit.Added {
-// 			if file == ".gigo/workspace.yaml" {
-// 				modified = true
-// 				break
-// 			}
-// 		}
-// 		if modified {
-// 			break
-// 		}
-//
-// 		for _, file := range commit.Removed {
-// 			if file == ".gigo/workspace.yaml" {
-// 				modified = true
-// 				break
-// 			}
-// 		}
-// 		if modified {
-// 			break
-// 		}
-// 	}
-//
-// 	// exit quietly if nothing has been modified
-// 	if !modified {
-// 		return nil
-// 	}
-//
-// 	// generate the template update
-// 	err := generateWorkspaceTemplate(
-// 		db, sf, vcsClient, coderClient, req.Repository.Owner.Username, req.Repository.Name, req.After,
-// 	)
-// 	if err != nil {
-// 		return fmt.Errorf("failed to generate workspace template: %v", err)
-// 	}
-//
-// 	return nil
-// }
+// refreshPushedPosts queues the visible posts that are backed by a repo to be
+// re-indexed
+func refreshPushedPosts(ctx context.Context, tidb *ti.Database, repoId int64) error {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "refresh-pushed-posts-core")
+	defer span.End()
+	callerName := "refreshPushedPosts"
+
+	rows, err := tidb.QueryContext(ctx, &span, &callerName,
+		"select _id from post where repo_id = ? and deleted = false and hidden = false", repoId,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to query posts for push: %v", err)
+	}
+	defer rows.Close()
+
+	postIds := make([]int64, 0)
+	for rows.Next() {
+		var postId int64
+		err = rows.Scan(&postId)
+		if err != nil {
+			return fmt.Errorf("failed to scan post for push: %v", err)
+		}
+		postIds = append(postIds, postId)
+	}
+
+	if len(postIds) == 0 {
+		return nil
+	}
+
+	err = queueSearchSyncNow(ctx, tidb, "posts", postIds...)
+	if err != nil {
+		return fmt.Errorf("failed to queue posts for search engine refresh: %v", err)
+	}
+
+	return nil
+}
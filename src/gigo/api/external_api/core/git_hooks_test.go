@@ -0,0 +1,64 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	config2 "github.com/gage-technologies/gigo-lib/config"
+)
+
+func TestCompareGiteaCommits(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    bool
+	}{
+		{"unrelated", `{"total_commits":1,"commits":[{"files":[{"filename":"main.go","status":"modified"}]}]}`, false},
+		{"modified", `{"total_commits":1,"commits":[{"files":[{"filename":"main.go","status":"modified"},{"filename":".gigo/workspace.yaml","status":"modified"}]}]}`, true},
+		{"removed", `{"total_commits":2,"commits":[{"files":[{"filename":"main.go","status":"added"}]},{"files":[{"filename":".gigo/workspace.yaml","status":"removed"}]}]}`, true},
+	}
+
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			username, password, ok := r.BasicAuth()
+			if !ok || username != "gigo" || password != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Path != "/api/v1/repos/69/test/compare/aaa...bbb" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = fmt.Fprint(w, tt.payload)
+		}))
+
+		compare, err := compareGiteaCommits(context.Background(), config2.GiteaConfig{
+			HostUrl:  server.URL + "/",
+			Username: "gigo",
+			Password: "secret",
+		}, "69", "test", "aaa", "bbb")
+		server.Close()
+		if err != nil {
+			t.Errorf("\nTestCompareGiteaCommits failed\n    Case: %s\n    Error: %v", tt.name, err)
+			continue
+		}
+
+		if got := compare.touches(workspaceConfigRepoPath); got != tt.want {
+			t.Errorf("\nTestCompareGiteaCommits failed\n    Case: %s\n    Error: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// failed comparisons are surfaced instead of being treated as unchanged
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, err := compareGiteaCommits(context.Background(), config2.GiteaConfig{HostUrl: server.URL}, "69", "test", "aaa", "bbb")
+	if err == nil {
+		t.Error("\nTestCompareGiteaCommits failed\n    Error: expected an error for a failed comparison")
+	}
+}
@@ -66,6 +66,7 @@ where
 	and rp.accepted = false
 	and p.published = true
 	and p.deleted = false
+	and p.hidden = false
 order by score desc 
 limit 32
 offset ?`
@@ -97,6 +98,7 @@ from post p
 	left join rewards r on u.avatar_reward = r._id
 where 
 	p.published = 1
+	and p.hidden = false
 order by p.attempts desc 
 limit 32
 offset ?`
@@ -128,6 +130,7 @@ from post p
          left join rewards r on u.avatar_reward = r._id
 where
         p._id in (1688570643030736896, 1688617436791701504, 1688638972722413568, 1688656093628071936, 1688914007987060736, 1688940677359992832, 1688982281277931520, 1689003147793530880, 1689029578237935616, 1689326500572037120, 1689350506096361472)
+    and p.hidden = false
 order by p.attempts desc`
 
 func ActiveProjectsHome(ctx context.Context, callingUser *models.User, tidb *ti.Database) (map[string]interface{}, error) {
@@ -0,0 +1,867 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/logging"
+	"github.com/gage-technologies/gigo-lib/mq"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel"
+)
+
+// LearningPathStepStatus is the state of a single step of a learning path for a user
+type LearningPathStepStatus string
+
+const (
+	// LearningPathStepLocked steps have prerequisites that the user has not completed
+	LearningPathStepLocked LearningPathStepStatus = "locked"
+	// LearningPathStepAvailable steps can be started by the user
+	LearningPathStepAvailable LearningPathStepStatus = "available"
+	// LearningPathStepInProgress steps have been attempted but not completed
+	LearningPathStepInProgress LearningPathStepStatus = "in_progress"
+	// LearningPathStepCompleted steps have a successful attempt
+	LearningPathStepCompleted LearningPathStepStatus = "completed"
+)
+
+// maxLearningPathSteps bounds the number of posts that can be added to a path
+const maxLearningPathSteps = 50
+
+type LearningPathStepRequest struct {
+	PostID string `json:"post_id" validate:"required,number"`
+	// Prerequisites are the ids of earlier posts in the path that must be
+	// completed before this step is unlocked
+	Prerequisites []string `json:"prerequisites" validate:"dive,number"`
+}
+
+type CreateLearningPathRequest struct {
+	Title       string                    `json:"title" validate:"required,lte=120"`
+	Description string                    `json:"description" validate:"lte=5000"`
+	Published   bool                      `json:"published"`
+	Steps       []LearningPathStepRequest `json:"steps" validate:"required,min=1,max=50,dive"`
+	Test        bool                      `json:"test"`
+}
+
+type EditLearningPathRequest struct {
+	PathID      string                    `json:"path_id" validate:"required,number"`
+	Title       string                    `json:"title" validate:"required,lte=120"`
+	Description string                    `json:"description" validate:"lte=5000"`
+	Published   bool                      `json:"published"`
+	Steps       []LearningPathStepRequest `json:"steps" validate:"required,min=1,max=50,dive"`
+	Test        bool                      `json:"test"`
+}
+
+type LearningPathRequest struct {
+	PathID string `json:"path_id" validate:"required,number"`
+	Test   bool   `json:"test"`
+}
+
+type ListLearningPathsRequest struct {
+	AuthorID    string `json:"author_id" validate:"omitempty,number"`
+	CuratedOnly bool   `json:"curated_only"`
+	Skip        int    `json:"skip" validate:"gte=0"`
+	Limit       int    `json:"limit" validate:"gt=0,lte=50"`
+	Test        bool   `json:"test"`
+}
+
+type LearningPathFrontend struct {
+	ID          string    `json:"_id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	AuthorID    string    `json:"author_id"`
+	Author      string    `json:"author"`
+	Curated     bool      `json:"curated"`
+	Published   bool      `json:"published"`
+	StepCount   int       `json:"step_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type LearningPathStepFrontend struct {
+	PostID        string                 `json:"post_id"`
+	Position      int                    `json:"position"`
+	Title         string                 `json:"title"`
+	Description   string                 `json:"description"`
+	Author        string                 `json:"author"`
+	AuthorID      string                 `json:"author_id"`
+	Tier          models.TierType        `json:"tier"`
+	PostType      models.ChallengeType   `json:"post_type"`
+	Attempts      int                    `json:"attempts"`
+	Completions   int                    `json:"completions"`
+	Prerequisites []string               `json:"prerequisites"`
+	Status        LearningPathStepStatus `json:"status"`
+}
+
+type LearningPathProgressFrontend struct {
+	CompletedSteps int        `json:"completed_steps"`
+	TotalSteps     int        `json:"total_steps"`
+	Percent        float64    `json:"percent"`
+	NextPostID     *string    `json:"next_post_id"`
+	CompletedAt    *time.Time `json:"completed_at"`
+}
+
+// learningPathStep is a validated step of a learning path
+type learningPathStep struct {
+	PostID        int64
+	Prerequisites []int64
+}
+
+// parseLearningPathSteps converts the steps of a request into their numeric form
+func parseLearningPathSteps(steps []LearningPathStepRequest) ([]learningPathStep, error) {
+	parsed := make([]learningPathStep, 0, len(steps))
+	for _, step := range steps {
+		postId, err := strconv.ParseInt(step.PostID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid post id %q", step.PostID)
+		}
+
+		prerequisites := make([]int64, 0, len(step.Prerequisites))
+		for _, p := range step.Prerequisites {
+			prerequisiteId, err := strconv.ParseInt(p, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid prerequisite id %q", p)
+			}
+			prerequisites = append(prerequisites, prerequisiteId)
+		}
+
+		parsed = append(parsed, learningPathStep{PostID: postId, Prerequisites: prerequisites})
+	}
+	return parsed, nil
+}
+
+// validateLearningPathSteps ensures that every post appears once and that
+// prerequisites only reference earlier steps of the path so that the path can
+// always be completed in order
+func validateLearningPathSteps(steps []learningPathStep) error {
+	if len(steps) == 0 {
+		return fmt.Errorf("a learning path must contain at least one post")
+	}
+	if len(steps) > maxLearningPathSteps {
+		return fmt.Errorf("a learning path cannot contain more than %d posts", maxLearningPathSteps)
+	}
+
+	seen := make(map[int64]bool, len(steps))
+	for _, step := range steps {
+		if seen[step.PostID] {
+			return fmt.Errorf("post %d appears more than once in the path", step.PostID)
+		}
+
+		for _, prerequisite := range step.Prerequisites {
+			if prerequisite == step.PostID {
+				return fmt.Errorf("post %d cannot be a prerequisite of itself", step.PostID)
+			}
+			if !seen[prerequisite] {
+				return fmt.Errorf("prerequisite %d of post %d must appear earlier in the path", prerequisite, step.PostID)
+			}
+		}
+
+		seen[step.PostID] = true
+	}
+
+	return nil
+}
+
+// computeLearningPathProgress determines the status of each step of a path
+// from the posts the user has attempted and completed. The next step is the
+// first step in order that is neither completed nor locked.
+func computeLearningPathProgress(steps []learningPathStep, attempted map[int64]bool, completed map[int64]bool) ([]LearningPathStepStatus, int, *int64) {
+	statuses := make([]LearningPathStepStatus, len(steps))
+	completedCount := 0
+	var next *int64
+
+	for i, step := range steps {
+		switch {
+		case completed[step.PostID]:
+			statuses[i] = LearningPathStepCompleted
+			completedCount++
+			continue
+		case attempted[step.PostID]:
+			statuses[i] = LearningPathStepInProgress
+		default:
+			statuses[i] = LearningPathStepAvailable
+		}
+
+		for _, prerequisite := range step.Prerequisites {
+			if !completed[prerequisite] {
+				statuses[i] = LearningPathStepLocked
+				break
+			}
+		}
+
+		if next == nil && statuses[i] != LearningPathStepLocked {
+			postId := step.PostID
+			next = &postId
+		}
+	}
+
+	return statuses, completedCount, next
+}
+
+// canManageLearningPath checks whether the user can edit the path. The gigo
+// admin account curates paths and can manage every path.
+func canManageLearningPath(callingUser *models.User, authorId int64) bool {
+	return callingUser != nil && (callingUser.UserName == "gigo" || callingUser.ID == authorId)
+}
+
+// checkLearningPathPosts ensures that every post of the path can be added by
+// the user. Curators can add any public post while authors can only build
+// paths out of posts they maintain.
+func checkLearningPathPosts(ctx context.Context, tidb *ti.Database, callingUser *models.User, steps []learningPathStep) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "check-learning-path-posts-core")
+	defer span.End()
+	callerName := "checkLearningPathPosts"
+
+	params := make([]interface{}, 0, len(steps))
+	for _, step := range steps {
+		params = append(params, step.PostID)
+	}
+
+	var count int
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		"select count(*) from post where _id in (?"+strings.Repeat(", ?", len(params)-1)+") "+
+			"and published = true and deleted = false and hidden = false",
+		params...,
+	).Scan(&count)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query learning path posts: %v", err)
+	}
+	if count != len(steps) {
+		return map[string]interface{}{"message": "learning paths can only contain published posts"}, nil
+	}
+
+	if callingUser.UserName == "gigo" {
+		return nil, nil
+	}
+
+	for _, step := range steps {
+		ok, err := HasPostPermission(ctx, tidb, step.PostID, callingUser.ID, CollaboratorMaintainer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check post permission: %v", err)
+		}
+		if !ok {
+			return map[string]interface{}{"message": "you can only add your own posts to a learning path"},
+				fmt.Errorf("user %d cannot add post %d to a learning path: %w", callingUser.ID, step.PostID, ErrForbidden)
+		}
+	}
+
+	return nil, nil
+}
+
+// insertLearningPathSteps writes the steps and prerequisites of a path
+func insertLearningPathSteps(ctx context.Context, tx *ti.Tx, callerName string, pathId int64, steps []learningPathStep) error {
+	for i, step := range steps {
+		_, err := tx.ExecContext(ctx, &callerName,
+			"insert into learning_path_step(path_id, post_id, position) values (?, ?, ?)",
+			pathId, step.PostID, i,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert learning path step: %v", err)
+		}
+
+		for _, prerequisite := range step.Prerequisites {
+			_, err = tx.ExecContext(ctx, &callerName,
+				"insert into learning_path_prerequisite(path_id, post_id, prerequisite_post_id) values (?, ?, ?)",
+				pathId, step.PostID, prerequisite,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to insert learning path prerequisite: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// CreateLearningPath creates a new learning path owned by the calling user
+func CreateLearningPath(ctx context.Context, tidb *ti.Database, sf *snowflake.Node, callingUser *models.User, title string,
+	description string, published bool, stepsReq []LearningPathStepRequest) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "create-learning-path-core")
+	defer span.End()
+	callerName := "CreateLearningPath"
+
+	steps, err := parseLearningPathSteps(stepsReq)
+	if err != nil {
+		return map[string]interface{}{"message": err.Error()}, nil
+	}
+	if err := validateLearningPathSteps(steps); err != nil {
+		return map[string]interface{}{"message": err.Error()}, nil
+	}
+
+	res, err := checkLearningPathPosts(ctx, tidb, callingUser, steps)
+	if res != nil || err != nil {
+		return res, err
+	}
+
+	tx, err := tidb.BeginTx(ctx, &span, &callerName, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create learning path tx: %v", err)
+	}
+	defer tx.Rollback()
+
+	pathId := sf.Generate().Int64()
+	now := time.Now()
+	_, err = tx.ExecContext(ctx, &callerName,
+		"insert into learning_path(_id, title, description, author_id, curated, published, created_at, updated_at) values (?, ?, ?, ?, ?, ?, ?, ?)",
+		pathId, title, description, callingUser.ID, callingUser.UserName == "gigo", published, now, now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert learning path: %v", err)
+	}
+
+	err = insertLearningPathSteps(ctx, tx, callerName, pathId, steps)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(&callerName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit learning path: %v", err)
+	}
+
+	return map[string]interface{}{"message": "Learning path created.", "path_id": fmt.Sprintf("%d", pathId)}, nil
+}
+
+// loadLearningPathAuthor retrieves the author of a path
+func loadLearningPathAuthor(ctx context.Context, tidb *ti.Database, pathId int64) (int64, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "load-learning-path-author-core")
+	defer span.End()
+	callerName := "loadLearningPathAuthor"
+
+	var authorId int64
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		"select author_id from learning_path where _id = ? limit 1", pathId,
+	).Scan(&authorId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrNotFound
+		}
+		return 0, fmt.Errorf("failed to query learning path: %v", err)
+	}
+
+	return authorId, nil
+}
+
+// EditLearningPath replaces the details and steps of a learning path. User
+// progress is derived from attempts so it carries over to the new steps.
+func EditLearningPath(ctx context.Context, tidb *ti.Database, callingUser *models.User, pathId int64, title string,
+	description string, published bool, stepsReq []LearningPathStepRequest) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "edit-learning-path-core")
+	defer span.End()
+	callerName := "EditLearningPath"
+
+	authorId, err := loadLearningPathAuthor(ctx, tidb, pathId)
+	if err != nil {
+		if err == ErrNotFound {
+			return map[string]interface{}{"message": "learning path not found"}, err
+		}
+		return nil, err
+	}
+	if !canManageLearningPath(callingUser, authorId) {
+		return map[string]interface{}{"message": "you do not have permission to edit this learning path"},
+			fmt.Errorf("user %d cannot edit learning path %d: %w", callingUser.ID, pathId, ErrForbidden)
+	}
+
+	steps, err := parseLearningPathSteps(stepsReq)
+	if err != nil {
+		return map[string]interface{}{"message": err.Error()}, nil
+	}
+	if err := validateLearningPathSteps(steps); err != nil {
+		return map[string]interface{}{"message": err.Error()}, nil
+	}
+
+	res, err := checkLearningPathPosts(ctx, tidb, callingUser, steps)
+	if res != nil || err != nil {
+		return res, err
+	}
+
+	tx, err := tidb.BeginTx(ctx, &span, &callerName, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create learning path tx: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, &callerName,
+		"update learning_path set title = ?, description = ?, published = ?, updated_at = ? where _id = ?",
+		title, description, published, time.Now(), pathId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update learning path: %v", err)
+	}
+
+	_, err = tx.ExecContext(ctx, &callerName, "delete from learning_path_step where path_id = ?", pathId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete learning path steps: %v", err)
+	}
+
+	_, err = tx.ExecContext(ctx, &callerName, "delete from learning_path_prerequisite where path_id = ?", pathId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete learning path prerequisites: %v", err)
+	}
+
+	err = insertLearningPathSteps(ctx, tx, callerName, pathId, steps)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(&callerName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit learning path: %v", err)
+	}
+
+	return map[string]interface{}{"message": "Learning path updated."}, nil
+}
+
+// DeleteLearningPath removes a learning path along with its steps and completions
+func DeleteLearningPath(ctx context.Context, tidb *ti.Database, callingUser *models.User, pathId int64) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "delete-learning-path-core")
+	defer span.End()
+	callerName := "DeleteLearningPath"
+
+	authorId, err := loadLearningPathAuthor(ctx, tidb, pathId)
+	if err != nil {
+		if err == ErrNotFound {
+			return map[string]interface{}{"message": "learning path not found"}, err
+		}
+		return nil, err
+	}
+	if !canManageLearningPath(callingUser, authorId) {
+		return map[string]interface{}{"message": "you do not have permission to delete this learning path"},
+			fmt.Errorf("user %d cannot delete learning path %d: %w", callingUser.ID, pathId, ErrForbidden)
+	}
+
+	tx, err := tidb.BeginTx(ctx, &span, &callerName, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create learning path tx: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"learning_path_prerequisite", "learning_path_step", "learning_path_completion"} {
+		_, err = tx.ExecContext(ctx, &callerName, fmt.Sprintf("delete from %s where path_id = ?", table), pathId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete from %s: %v", table, err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, &callerName, "delete from learning_path where _id = ?", pathId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete learning path: %v", err)
+	}
+
+	err = tx.Commit(&callerName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit learning path deletion: %v", err)
+	}
+
+	return map[string]interface{}{"message": "Learning path deleted."}, nil
+}
+
+// loadLearningPathSteps retrieves the ordered steps of a path along with the
+// post details shown for each step
+func loadLearningPathSteps(ctx context.Context, tidb *ti.Database, pathId int64) ([]learningPathStep, []*LearningPathStepFrontend, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "load-learning-path-steps-core")
+	defer span.End()
+	callerName := "loadLearningPathSteps"
+
+	rows, err := tidb.QueryContext(ctx, &span, &callerName,
+		"select s.post_id, s.position, p.title, p.description, p.author, p.author_id, p.tier, p.post_type, p.attempts, p.completions "+
+			"from learning_path_step s join post p on p._id = s.post_id "+
+			"where s.path_id = ? and p.deleted = false and p.hidden = false order by s.position asc",
+		pathId,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query learning path steps: %v", err)
+	}
+	defer rows.Close()
+
+	steps := make([]learningPathStep, 0)
+	frontends := make([]*LearningPathStepFrontend, 0)
+	index := make(map[int64]int)
+	for rows.Next() {
+		var step LearningPathStepFrontend
+		var postId, authorId int64
+		err = rows.Scan(&postId, &step.Position, &step.Title, &step.Description, &step.Author, &authorId, &step.Tier,
+			&step.PostType, &step.Attempts, &step.Completions)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan learning path step: %v", err)
+		}
+		step.PostID = fmt.Sprintf("%d", postId)
+		step.AuthorID = fmt.Sprintf("%d", authorId)
+		step.Prerequisites = make([]string, 0)
+		index[postId] = len(steps)
+		steps = append(steps, learningPathStep{PostID: postId})
+		frontends = append(frontends, &step)
+	}
+	_ = rows.Close()
+
+	prereqRows, err := tidb.QueryContext(ctx, &span, &callerName,
+		"select post_id, prerequisite_post_id from learning_path_prerequisite where path_id = ?", pathId,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query learning path prerequisites: %v", err)
+	}
+	defer prereqRows.Close()
+
+	for prereqRows.Next() {
+		var postId, prerequisiteId int64
+		err = prereqRows.Scan(&postId, &prerequisiteId)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan learning path prerequisite: %v", err)
+		}
+
+		// skip prerequisites of posts that are no longer visible
+		i, ok := index[postId]
+		if !ok {
+			continue
+		}
+		if _, ok := index[prerequisiteId]; !ok {
+			continue
+		}
+		steps[i].Prerequisites = append(steps[i].Prerequisites, prerequisiteId)
+		frontends[i].Prerequisites = append(frontends[i].Prerequisites, fmt.Sprintf("%d", prerequisiteId))
+	}
+
+	return steps, frontends, nil
+}
+
+// loadLearningPathProgress retrieves the posts of the path that the user has
+// attempted and completed
+func loadLearningPathProgress(ctx context.Context, tidb *ti.Database, userId int64, steps []learningPathStep) (map[int64]bool, map[int64]bool, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "load-learning-path-progress-core")
+	defer span.End()
+	callerName := "loadLearningPathProgress"
+
+	attempted := make(map[int64]bool)
+	completed := make(map[int64]bool)
+	if len(steps) == 0 {
+		return attempted, completed, nil
+	}
+
+	params := []interface{}{userId}
+	for _, step := range steps {
+		params = append(params, step.PostID)
+	}
+
+	rows, err := tidb.QueryContext(ctx, &span, &callerName,
+		"select post_id, max(success) from attempt where author_id = ? and post_id in (?"+strings.Repeat(", ?", len(steps)-1)+") group by post_id",
+		params...,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query learning path attempts: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postId int64
+		var success bool
+		err = rows.Scan(&postId, &success)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to scan learning path attempt: %v", err)
+		}
+		attempted[postId] = true
+		if success {
+			completed[postId] = true
+		}
+	}
+
+	return attempted, completed, nil
+}
+
+// scanLearningPath decodes a learning path row selected with learningPathColumns
+func scanLearningPath(row interface{ Scan(...interface{}) error }) (*LearningPathFrontend, error) {
+	var path LearningPathFrontend
+	var id, authorId int64
+	var description sql.NullString
+	err := row.Scan(&id, &path.Title, &description, &authorId, &path.Author, &path.Curated, &path.Published,
+		&path.StepCount, &path.CreatedAt, &path.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	path.ID = fmt.Sprintf("%d", id)
+	path.AuthorID = fmt.Sprintf("%d", authorId)
+	path.Description = description.String
+	return &path, nil
+}
+
+const learningPathColumns = "lp._id, lp.title, lp.description, lp.author_id, u.user_name, lp.curated, lp.published, " +
+	"(select count(*) from learning_path_step s where s.path_id = lp._id), lp.created_at, lp.updated_at"
+
+// GetLearningPath retrieves a learning path with its steps. Logged in users
+// also receive their progress through the path.
+func GetLearningPath(ctx context.Context, tidb *ti.Database, callingUser *models.User, pathId int64) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "get-learning-path-core")
+	defer span.End()
+	callerName := "GetLearningPath"
+
+	path, err := scanLearningPath(tidb.QueryRowContext(ctx, &span, &callerName,
+		"select "+learningPathColumns+" from learning_path lp join users u on u._id = lp.author_id where lp._id = ? limit 1", pathId,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return map[string]interface{}{"message": "learning path not found"}, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to query learning path: %v", err)
+	}
+
+	// unpublished paths are only visible to the users that can manage them
+	authorId, _ := strconv.ParseInt(path.AuthorID, 10, 64)
+	if !path.Published && !canManageLearningPath(callingUser, authorId) {
+		return map[string]interface{}{"message": "learning path not found"}, ErrNotFound
+	}
+
+	steps, stepFrontends, err := loadLearningPathSteps(ctx, tidb, pathId)
+	if err != nil {
+		return nil, err
+	}
+	path.StepCount = len(steps)
+
+	if callingUser == nil {
+		return map[string]interface{}{"path": path, "steps": stepFrontends}, nil
+	}
+
+	attempted, completed, err := loadLearningPathProgress(ctx, tidb, callingUser.ID, steps)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses, completedCount, next := computeLearningPathProgress(steps, attempted, completed)
+	for i := range stepFrontends {
+		stepFrontends[i].Status = statuses[i]
+	}
+
+	progress := LearningPathProgressFrontend{
+		CompletedSteps: completedCount,
+		TotalSteps:     len(steps),
+	}
+	if len(steps) > 0 {
+		progress.Percent = float64(completedCount) / float64(len(steps)) * 100
+	}
+	if next != nil {
+		n := fmt.Sprintf("%d", *next)
+		progress.NextPostID = &n
+	}
+
+	var completedAt sql.NullTime
+	err = tidb.QueryRowContext(ctx, &span, &callerName,
+		"select completed_at from learning_path_completion where path_id = ? and user_id = ?", pathId, callingUser.ID,
+	).Scan(&completedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to query learning path completion: %v", err)
+	}
+	if completedAt.Valid {
+		progress.CompletedAt = &completedAt.Time
+	}
+
+	return map[string]interface{}{"path": path, "steps": stepFrontends, "progress": progress}, nil
+}
+
+// ListLearningPaths lists published learning paths with curated paths first
+func ListLearningPaths(ctx context.Context, tidb *ti.Database, authorId *int64, curatedOnly bool, skip int, limit int) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "list-learning-paths-core")
+	defer span.End()
+	callerName := "ListLearningPaths"
+
+	query := "select " + learningPathColumns + " from learning_path lp join users u on u._id = lp.author_id where lp.published = true"
+	params := make([]interface{}, 0)
+	if authorId != nil {
+		query += " and lp.author_id = ?"
+		params = append(params, *authorId)
+	}
+	if curatedOnly {
+		query += " and lp.curated = true"
+	}
+	query += " order by lp.curated desc, lp.updated_at desc limit ? offset ?"
+	params = append(params, limit, skip)
+
+	rows, err := tidb.QueryContext(ctx, &span, &callerName, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query learning paths: %v", err)
+	}
+	defer rows.Close()
+
+	paths := make([]*LearningPathFrontend, 0)
+	for rows.Next() {
+		path, err := scanLearningPath(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan learning path: %v", err)
+		}
+		paths = append(paths, path)
+	}
+
+	return map[string]interface{}{"paths": paths}, nil
+}
+
+// ContinueLearningPathsHome returns the learning paths that the user has
+// started but not completed along with the next step of each path. Paths are
+// ordered by the most recent activity of the user on the path.
+func ContinueLearningPathsHome(ctx context.Context, tidb *ti.Database, callingUser *models.User) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "continue-learning-paths-home-core")
+	defer span.End()
+	callerName := "ContinueLearningPathsHome"
+
+	rows, err := tidb.QueryContext(ctx, &span, &callerName,
+		"select "+learningPathColumns+" from learning_path lp join users u on u._id = lp.author_id "+
+			"join (select s.path_id, max(a.updated_at) as last_activity from learning_path_step s join attempt a on a.post_id = s.post_id "+
+			"where a.author_id = ? group by s.path_id) act on act.path_id = lp._id "+
+			"where lp.published = true and not exists (select 1 from learning_path_completion c where c.path_id = lp._id and c.user_id = ?) "+
+			"order by act.last_activity desc limit 5",
+		callingUser.ID, callingUser.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query started learning paths: %v", err)
+	}
+
+	paths := make([]*LearningPathFrontend, 0)
+	for rows.Next() {
+		path, err := scanLearningPath(rows)
+		if err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("failed to scan learning path: %v", err)
+		}
+		paths = append(paths, path)
+	}
+	_ = rows.Close()
+
+	type continuePath struct {
+		Path     *LearningPathFrontend        `json:"path"`
+		NextStep *LearningPathStepFrontend    `json:"next_step"`
+		Progress LearningPathProgressFrontend `json:"progress"`
+	}
+
+	results := make([]continuePath, 0, len(paths))
+	for _, path := range paths {
+		pathId, _ := strconv.ParseInt(path.ID, 10, 64)
+		steps, stepFrontends, err := loadLearningPathSteps(ctx, tidb, pathId)
+		if err != nil {
+			return nil, err
+		}
+
+		attempted, completed, err := loadLearningPathProgress(ctx, tidb, callingUser.ID, steps)
+		if err != nil {
+			return nil, err
+		}
+
+		statuses, completedCount, next := computeLearningPathProgress(steps, attempted, completed)
+		if next == nil {
+			continue
+		}
+
+		result := continuePath{
+			Path: path,
+			Progress: LearningPathProgressFrontend{
+				CompletedSteps: completedCount,
+				TotalSteps:     len(steps),
+				Percent:        float64(completedCount) / float64(len(steps)) * 100,
+			},
+		}
+		for i, step := range steps {
+			if step.PostID == *next {
+				stepFrontends[i].Status = statuses[i]
+				result.NextStep = stepFrontends[i]
+				result.Progress.NextPostID = &stepFrontends[i].PostID
+				break
+			}
+		}
+		path.StepCount = len(steps)
+		results = append(results, result)
+	}
+
+	return map[string]interface{}{"paths": results}, nil
+}
+
+// CheckLearningPathCompletion records the completion of every published path
+// containing the post that the user has now completed. Completion xp is granted
+// once per user and path, and only for curated paths that the user did not
+// author and that contain none of the user's own challenges. It is called after
+// an attempt is first marked successful.
+func CheckLearningPathCompletion(ctx context.Context, tidb *ti.Database, js *mq.JetstreamClient, rdb redis.UniversalClient,
+	sf *snowflake.Node, userId int64, postId int64, logger logging.Logger, callingUser *models.User) ([]int64, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "check-learning-path-completion-core")
+	defer span.End()
+	callerName := "CheckLearningPathCompletion"
+
+	// xp is only granted for curated paths that were not authored by the user
+	// and that do not contain any of the user's own challenges
+	rows, err := tidb.QueryContext(ctx, &span, &callerName,
+		"select s.path_id, lp.curated and lp.author_id != ? and sum(p.author_id = ?) = 0 from learning_path_step s "+
+			"join learning_path lp on lp._id = s.path_id join post p on p._id = s.post_id "+
+			"where lp.published = true "+
+			"and s.path_id in (select path_id from learning_path_step where post_id = ?) "+
+			"and not exists (select 1 from learning_path_completion c where c.path_id = s.path_id and c.user_id = ?) "+
+			"group by s.path_id, lp.curated, lp.author_id "+
+			"having sum(not exists (select 1 from attempt a where a.post_id = s.post_id and a.author_id = ? and a.success = true)) = 0",
+		userId, userId, postId, userId, userId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query completed learning paths: %v", err)
+	}
+
+	pathIds := make([]int64, 0)
+	xpEligible := make(map[int64]bool)
+	for rows.Next() {
+		var pathId int64
+		var eligible bool
+		err = rows.Scan(&pathId, &eligible)
+		if err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("failed to scan completed learning path: %v", err)
+		}
+		pathIds = append(pathIds, pathId)
+		xpEligible[pathId] = eligible
+	}
+	_ = rows.Close()
+
+	completed := make([]int64, 0, len(pathIds))
+	for _, pathId := range pathIds {
+		res, err := tidb.ExecContext(ctx, &span, &callerName,
+			"insert ignore into learning_path_completion(path_id, user_id, completed_at) values (?, ?, ?)",
+			pathId, userId, time.Now(),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert learning path completion: %v", err)
+		}
+
+		inserted, err := res.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("failed to check learning path completion: %v", err)
+		}
+		if inserted == 0 {
+			continue
+		}
+		completed = append(completed, pathId)
+
+		if !xpEligible[pathId] {
+			continue
+		}
+
+		// the award is recorded separately from the completion so that xp is
+		// granted once per user and path even for concurrent successes
+		res, err = tidb.ExecContext(ctx, &span, &callerName,
+			"insert ignore into learning_path_xp(path_id, user_id, awarded_at) values (?, ?, ?)",
+			pathId, userId, time.Now(),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert learning path xp award: %v", err)
+		}
+
+		inserted, err = res.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("failed to check learning path xp award: %v", err)
+		}
+		if inserted == 0 {
+			continue
+		}
+
+		_, err = AddXP(ctx, tidb, js, rdb, sf, userId, "learning_path", nil, nil, logger, callingUser)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add learning path xp: %v", err)
+		}
+	}
+
+	return completed, nil
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestValidateLearningPathSteps(t *testing.T) {
+	tests := []struct {
+		name    string
+		steps   []learningPathStep
+		wantErr bool
+	}{
+		{"empty", []learningPathStep{}, true},
+		{"single", []learningPathStep{{PostID: 1}}, false},
+		{"ordered prerequisites", []learningPathStep{{PostID: 1}, {PostID: 2, Prerequisites: []int64{1}}, {PostID: 3, Prerequisites: []int64{1, 2}}}, false},
+		{"duplicate post", []learningPathStep{{PostID: 1}, {PostID: 1}}, true},
+		{"self prerequisite", []learningPathStep{{PostID: 1, Prerequisites: []int64{1}}}, true},
+		{"later prerequisite", []learningPathStep{{PostID: 1, Prerequisites: []int64{2}}, {PostID: 2}}, true},
+		{"unknown prerequisite", []learningPathStep{{PostID: 1}, {PostID: 2, Prerequisites: []int64{3}}}, true},
+	}
+
+	for _, tt := range tests {
+		err := validateLearningPathSteps(tt.steps)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("\nTestValidateLearningPathSteps failed\n    Case: %s\n    Error: %v", tt.name, err)
+		}
+	}
+}
+
+func TestComputeLearningPathProgress(t *testing.T) {
+	steps := []learningPathStep{
+		{PostID: 1},
+		{PostID: 2, Prerequisites: []int64{1}},
+		{PostID: 3},
+		{PostID: 4, Prerequisites: []int64{2, 3}},
+	}
+
+	// nothing started
+	statuses, completed, next := computeLearningPathProgress(steps, map[int64]bool{}, map[int64]bool{})
+	want := []LearningPathStepStatus{LearningPathStepAvailable, LearningPathStepLocked, LearningPathStepAvailable, LearningPathStepLocked}
+	for i := range want {
+		if statuses[i] != want[i] {
+			t.Errorf("\nTestComputeLearningPathProgress failed\n    Error: step %d status %s, want %s", i, statuses[i], want[i])
+		}
+	}
+	if completed != 0 || next == nil || *next != 1 {
+		t.Errorf("\nTestComputeLearningPathProgress failed\n    Error: unexpected progress %d %v", completed, next)
+	}
+
+	// first step done, second in progress
+	attempted := map[int64]bool{1: true, 2: true}
+	done := map[int64]bool{1: true}
+	statuses, completed, next = computeLearningPathProgress(steps, attempted, done)
+	want = []LearningPathStepStatus{LearningPathStepCompleted, LearningPathStepInProgress, LearningPathStepAvailable, LearningPathStepLocked}
+	for i := range want {
+		if statuses[i] != want[i] {
+			t.Errorf("\nTestComputeLearningPathProgress failed\n    Error: step %d status %s, want %s", i, statuses[i], want[i])
+		}
+	}
+	if completed != 1 || next == nil || *next != 2 {
+		t.Errorf("\nTestComputeLearningPathProgress failed\n    Error: unexpected progress %d %v", completed, next)
+	}
+
+	// everything done
+	done = map[int64]bool{1: true, 2: true, 3: true, 4: true}
+	_, completed, next = computeLearningPathProgress(steps, done, done)
+	if completed != 4 || next != nil {
+		t.Errorf("\nTestComputeLearningPathProgress failed\n    Error: unexpected progress %d %v", completed, next)
+	}
+}
@@ -0,0 +1,788 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/mq"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel"
+)
+
+// ReportContentType is the kind of content that a report was made against
+type ReportContentType int
+
+const (
+	ReportContentPost ReportContentType = iota
+	ReportContentAttempt
+	ReportContentDiscussion
+	ReportContentComment
+	ReportContentThreadComment
+	ReportContentThreadReply
+	ReportContentChatMessage
+	ReportContentUser
+)
+
+func (t ReportContentType) String() string {
+	switch t {
+	case ReportContentPost:
+		return "post"
+	case ReportContentAttempt:
+		return "attempt"
+	case ReportContentDiscussion:
+		return "discussion"
+	case ReportContentComment:
+		return "comment"
+	case ReportContentThreadComment:
+		return "thread_comment"
+	case ReportContentThreadReply:
+		return "thread_reply"
+	case ReportContentChatMessage:
+		return "chat_message"
+	case ReportContentUser:
+		return "user"
+	}
+	return "unknown"
+}
+
+// reportContentSource describes where reported content is stored
+type reportContentSource struct {
+	// table holding the content
+	table string
+	// column holding the id of the user that created the content
+	ownerColumn string
+	// search index that the content is discoverable through if any
+	index string
+}
+
+var reportContentSources = map[ReportContentType]reportContentSource{
+	ReportContentPost:          {"post", "author_id", "posts"},
+	ReportContentAttempt:       {"attempt", "author_id", ""},
+	ReportContentDiscussion:    {"discussion", "author_id", "discussion"},
+	ReportContentComment:       {"comment", "author_id", "comment"},
+	ReportContentThreadComment: {"thread_comment", "author_id", "thread_comment"},
+	ReportContentThreadReply:   {"thread_reply", "author_id", ""},
+	ReportContentChatMessage:   {"chat_messages", "author_id", ""},
+	ReportContentUser:          {"users", "_id", ""},
+}
+
+// ReportReason is the category selected by the reporter
+type ReportReason int
+
+const (
+	ReportReasonSpam ReportReason = iota
+	ReportReasonHarassment
+	ReportReasonHateSpeech
+	ReportReasonInappropriate
+	ReportReasonPlagiarism
+	ReportReasonOther
+)
+
+func (r ReportReason) String() string {
+	switch r {
+	case ReportReasonSpam:
+		return "spam"
+	case ReportReasonHarassment:
+		return "harassment"
+	case ReportReasonHateSpeech:
+		return "hate_speech"
+	case ReportReasonInappropriate:
+		return "inappropriate"
+	case ReportReasonPlagiarism:
+		return "plagiarism"
+	case ReportReasonOther:
+		return "other"
+	}
+	return "unknown"
+}
+
+// ReportStatus is the position of a report in the moderation queue
+type ReportStatus int
+
+const (
+	ReportStatusOpen ReportStatus = iota
+	ReportStatusInReview
+	ReportStatusResolved
+	ReportStatusDismissed
+)
+
+func (s ReportStatus) String() string {
+	switch s {
+	case ReportStatusOpen:
+		return "open"
+	case ReportStatusInReview:
+		return "in_review"
+	case ReportStatusResolved:
+		return "resolved"
+	case ReportStatusDismissed:
+		return "dismissed"
+	}
+	return "unknown"
+}
+
+// ReportAction is the action taken by a moderator to resolve a report
+type ReportAction int
+
+const (
+	// ReportActionDismiss closes the report without acting on the content
+	ReportActionDismiss ReportAction = iota
+	// ReportActionHideContent hides the content from feeds, search and the project page
+	ReportActionHideContent
+	// ReportActionWarnUser notifies the owner of the content that it violated the guidelines
+	ReportActionWarnUser
+	// ReportActionSuspendUser prevents the owner of the content from logging in for a number of days
+	ReportActionSuspendUser
+)
+
+func (a ReportAction) String() string {
+	switch a {
+	case ReportActionDismiss:
+		return "dismiss"
+	case ReportActionHideContent:
+		return "hide_content"
+	case ReportActionWarnUser:
+		return "warn_user"
+	case ReportActionSuspendUser:
+		return "suspend_user"
+	}
+	return "unknown"
+}
+
+type CreateContentReportRequest struct {
+	ContentType ReportContentType `json:"content_type" validate:"gte=0,lte=7"`
+	ContentID   string            `json:"content_id" validate:"required,number"`
+	Reason      ReportReason      `json:"reason" validate:"gte=0,lte=5"`
+	Details     string            `json:"details" validate:"lte=2000"`
+	Test        bool              `json:"test"`
+}
+
+type GetModerationQueueRequest struct {
+	Status       *ReportStatus `json:"status" validate:"omitempty,gte=0,lte=3"`
+	AssignedToMe bool          `json:"assigned_to_me"`
+	Skip         int           `json:"skip" validate:"gte=0"`
+	Limit        int           `json:"limit" validate:"gt=0,lte=100"`
+	Test         bool          `json:"test"`
+}
+
+type ContentReportRequest struct {
+	ReportID string `json:"report_id" validate:"required,number"`
+	Test     bool   `json:"test"`
+}
+
+type AssignContentReportRequest struct {
+	ReportID string `json:"report_id" validate:"required,number"`
+	// AssigneeID is the moderator to assign or empty to unassign the report
+	AssigneeID string `json:"assignee_id" validate:"omitempty,number"`
+	Test       bool   `json:"test"`
+}
+
+type AddContentReportNoteRequest struct {
+	ReportID string `json:"report_id" validate:"required,number"`
+	Note     string `json:"note" validate:"required,lte=5000"`
+	Test     bool   `json:"test"`
+}
+
+type ResolveContentReportRequest struct {
+	ReportID    string       `json:"report_id" validate:"required,number"`
+	Action      ReportAction `json:"action" validate:"gte=0,lte=3"`
+	Note        string       `json:"note" validate:"lte=5000"`
+	SuspendDays int          `json:"suspend_days" validate:"omitempty,gte=1,lte=365"`
+	Test        bool         `json:"test"`
+}
+
+type ContentReportFrontend struct {
+	ID                string            `json:"_id"`
+	ReporterID        string            `json:"reporter_id"`
+	ContentType       ReportContentType `json:"content_type"`
+	ContentTypeString string            `json:"content_type_string"`
+	ContentID         string            `json:"content_id"`
+	ContentOwnerID    string            `json:"content_owner_id"`
+	Reason            ReportReason      `json:"reason"`
+	ReasonString      string            `json:"reason_string"`
+	Details           string            `json:"details"`
+	Status            ReportStatus      `json:"status"`
+	StatusString      string            `json:"status_string"`
+	AssigneeID        *string           `json:"assignee_id"`
+	Resolution        *ReportAction     `json:"resolution"`
+	ReportCount       int64             `json:"report_count"`
+	CreatedAt         time.Time         `json:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at"`
+	ResolvedAt        *time.Time        `json:"resolved_at"`
+}
+
+type ContentReportNoteFrontend struct {
+	ID        string    `json:"_id"`
+	AuthorID  string    `json:"author_id"`
+	Author    string    `json:"author"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// contentReportColumns selects a report along with the number of reports
+// made against the same content
+const contentReportColumns = "r._id, r.reporter_id, r.content_type, r.content_id, r.content_owner_id, r.reason, r.details, r.status, " +
+	"r.assignee_id, r.resolution, r.created_at, r.updated_at, r.resolved_at, " +
+	"(select count(*) from content_report c where c.content_type = r.content_type and c.content_id = r.content_id)"
+
+func scanContentReport(row interface{ Scan(...interface{}) error }) (*ContentReportFrontend, error) {
+	var report ContentReportFrontend
+	var id, reporterId, contentId, ownerId int64
+	var details sql.NullString
+	var assigneeId, resolution sql.NullInt64
+	var resolvedAt sql.NullTime
+	err := row.Scan(&id, &reporterId, &report.ContentType, &contentId, &ownerId, &report.Reason, &details, &report.Status,
+		&assigneeId, &resolution, &report.CreatedAt, &report.UpdatedAt, &resolvedAt, &report.ReportCount)
+	if err != nil {
+		return nil, err
+	}
+
+	report.ID = fmt.Sprintf("%d", id)
+	report.ReporterID = fmt.Sprintf("%d", reporterId)
+	report.ContentID = fmt.Sprintf("%d", contentId)
+	report.ContentOwnerID = fmt.Sprintf("%d", ownerId)
+	report.ContentTypeString = report.ContentType.String()
+	report.ReasonString = report.Reason.String()
+	report.StatusString = report.Status.String()
+	report.Details = details.String
+	if assigneeId.Valid {
+		a := fmt.Sprintf("%d", assigneeId.Int64)
+		report.AssigneeID = &a
+	}
+	if resolution.Valid {
+		r := ReportAction(resolution.Int64)
+		report.Resolution = &r
+	}
+	if resolvedAt.Valid {
+		report.ResolvedAt = &resolvedAt.Time
+	}
+
+	return &report, nil
+}
+
+// IsModerator checks whether the user can work the moderation queue. The gigo
+// admin account is always a moderator.
+func IsModerator(ctx context.Context, tidb *ti.Database, user *models.User) (bool, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "is-moderator-core")
+	defer span.End()
+	callerName := "IsModerator"
+
+	if user == nil {
+		return false, nil
+	}
+	if user.UserName == "gigo" {
+		return true, nil
+	}
+
+	var exists bool
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		"select exists(select 1 from moderator where user_id = ?)", user.ID,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to query moderator: %v", err)
+	}
+
+	return exists, nil
+}
+
+// requireModerator returns an ErrForbidden response if the user is not a moderator
+func requireModerator(ctx context.Context, tidb *ti.Database, user *models.User) (map[string]interface{}, error) {
+	ok, err := IsModerator(ctx, tidb, user)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return map[string]interface{}{"message": "you do not have permission to moderate content"},
+			fmt.Errorf("user %d is not a moderator: %w", user.ID, ErrForbidden)
+	}
+	return nil, nil
+}
+
+// checkHiddenContentAccess returns ErrNotFound when content hidden by a
+// moderator is requested by anyone other than its owners or a moderator
+func checkHiddenContentAccess(ctx context.Context, tidb *ti.Database, callingUser *models.User, hidden bool, ownerIds ...int64) error {
+	if !hidden {
+		return nil
+	}
+
+	if callingUser != nil {
+		for _, id := range ownerIds {
+			if id == callingUser.ID {
+				return nil
+			}
+		}
+	}
+
+	ok, err := IsModerator(ctx, tidb, callingUser)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// CreateContentReport reports a piece of content or a user to the moderators.
+// Reporting the same content again updates the existing report and reopens it
+// if it was already closed.
+func CreateContentReport(ctx context.Context, tidb *ti.Database, sf *snowflake.Node, callingUser *models.User,
+	contentType ReportContentType, contentId int64, reason ReportReason, details string) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "create-content-report-core")
+	defer span.End()
+	callerName := "CreateContentReport"
+
+	source, ok := reportContentSources[contentType]
+	if !ok {
+		return map[string]interface{}{"message": "invalid content type"}, nil
+	}
+
+	// load the owner of the content ensuring that it exists
+	var ownerId int64
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		fmt.Sprintf("select %s from %s where _id = ? limit 1", source.ownerColumn, source.table), contentId,
+	).Scan(&ownerId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return map[string]interface{}{"message": "content not found"}, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to query reported content: %v", err)
+	}
+
+	if ownerId == callingUser.ID {
+		return map[string]interface{}{"message": "you cannot report your own content"}, nil
+	}
+
+	now := time.Now()
+	_, err = tidb.ExecContext(ctx, &span, &callerName,
+		"insert into content_report(_id, reporter_id, content_type, content_id, content_owner_id, reason, details, status, created_at, updated_at) "+
+			"values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) "+
+			"on duplicate key update reason = values(reason), details = values(details), updated_at = values(updated_at), "+
+			"status = if(status >= ?, ?, status), resolution = if(status = ?, null, resolution), resolved_at = if(status = ?, null, resolved_at)",
+		sf.Generate().Int64(), callingUser.ID, contentType, contentId, ownerId, reason, details, ReportStatusOpen, now, now,
+		ReportStatusResolved, ReportStatusOpen, ReportStatusOpen, ReportStatusOpen,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert content report: %v", err)
+	}
+
+	return map[string]interface{}{"message": "Thank you for your report. Our moderators will review it shortly."}, nil
+}
+
+// GetModerationQueue lists reports for moderators with the oldest reports first
+func GetModerationQueue(ctx context.Context, tidb *ti.Database, callingUser *models.User, status *ReportStatus,
+	assignedToMe bool, skip int, limit int) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "get-moderation-queue-core")
+	defer span.End()
+	callerName := "GetModerationQueue"
+
+	if res, err := requireModerator(ctx, tidb, callingUser); res != nil || err != nil {
+		return res, err
+	}
+
+	query := "select " + contentReportColumns + " from content_report r where 1 = 1"
+	params := make([]interface{}, 0)
+
+	// default to the reports that still need to be worked
+	if status != nil {
+		query += " and r.status = ?"
+		params = append(params, *status)
+	} else {
+		query += " and r.status in (?, ?)"
+		params = append(params, ReportStatusOpen, ReportStatusInReview)
+	}
+
+	if assignedToMe {
+		query += " and r.assignee_id = ?"
+		params = append(params, callingUser.ID)
+	}
+
+	query += " order by r.created_at asc limit ? offset ?"
+	params = append(params, limit, skip)
+
+	rows, err := tidb.QueryContext(ctx, &span, &callerName, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query moderation queue: %v", err)
+	}
+	defer rows.Close()
+
+	reports := make([]*ContentReportFrontend, 0)
+	for rows.Next() {
+		report, err := scanContentReport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan content report: %v", err)
+		}
+		reports = append(reports, report)
+	}
+
+	return map[string]interface{}{"reports": reports}, nil
+}
+
+// loadContentReport retrieves a report by its id
+func loadContentReport(ctx context.Context, tidb *ti.Database, reportId int64) (*ContentReportFrontend, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "load-content-report-core")
+	defer span.End()
+	callerName := "loadContentReport"
+
+	report, err := scanContentReport(tidb.QueryRowContext(ctx, &span, &callerName,
+		"select "+contentReportColumns+" from content_report r where r._id = ? limit 1", reportId,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to query content report: %v", err)
+	}
+
+	return report, nil
+}
+
+// GetContentReport retrieves a report along with the notes left by moderators
+func GetContentReport(ctx context.Context, tidb *ti.Database, callingUser *models.User, reportId int64) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "get-content-report-core")
+	defer span.End()
+	callerName := "GetContentReport"
+
+	if res, err := requireModerator(ctx, tidb, callingUser); res != nil || err != nil {
+		return res, err
+	}
+
+	report, err := loadContentReport(ctx, tidb, reportId)
+	if err != nil {
+		if err == ErrNotFound {
+			return map[string]interface{}{"message": "report not found"}, err
+		}
+		return nil, err
+	}
+
+	rows, err := tidb.QueryContext(ctx, &span, &callerName,
+		"select n._id, n.author_id, u.user_name, n.note, n.created_at from content_report_note n join users u on u._id = n.author_id "+
+			"where n.report_id = ? order by n.created_at asc",
+		reportId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query content report notes: %v", err)
+	}
+	defer rows.Close()
+
+	notes := make([]*ContentReportNoteFrontend, 0)
+	for rows.Next() {
+		var note ContentReportNoteFrontend
+		var id, authorId int64
+		err = rows.Scan(&id, &authorId, &note.Author, &note.Note, &note.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan content report note: %v", err)
+		}
+		note.ID = fmt.Sprintf("%d", id)
+		note.AuthorID = fmt.Sprintf("%d", authorId)
+		notes = append(notes, &note)
+	}
+
+	return map[string]interface{}{"report": report, "notes": notes}, nil
+}
+
+// AssignContentReport assigns a report to a moderator moving it into review.
+// Passing a nil assignee returns the report to the open queue.
+func AssignContentReport(ctx context.Context, tidb *ti.Database, callingUser *models.User, reportId int64, assigneeId *int64) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "assign-content-report-core")
+	defer span.End()
+	callerName := "AssignContentReport"
+
+	if res, err := requireModerator(ctx, tidb, callingUser); res != nil || err != nil {
+		return res, err
+	}
+
+	status := ReportStatusOpen
+	if assigneeId != nil {
+		var assignee models.User
+		err := tidb.QueryRowContext(ctx, &span, &callerName,
+			"select _id, user_name from users where _id = ? limit 1", *assigneeId,
+		).Scan(&assignee.ID, &assignee.UserName)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return map[string]interface{}{"message": "assignee not found"}, ErrNotFound
+			}
+			return nil, fmt.Errorf("failed to query assignee: %v", err)
+		}
+
+		ok, err := IsModerator(ctx, tidb, &assignee)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return map[string]interface{}{"message": "reports can only be assigned to moderators"}, nil
+		}
+		status = ReportStatusInReview
+	}
+
+	res, err := tidb.ExecContext(ctx, &span, &callerName,
+		"update content_report set assignee_id = ?, status = ?, updated_at = ? where _id = ? and status in (?, ?)",
+		assigneeId, status, time.Now(), reportId, ReportStatusOpen, ReportStatusInReview,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to assign content report: %v", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check content report assignment: %v", err)
+	}
+	if rows == 0 {
+		if _, err := loadContentReport(ctx, tidb, reportId); err != nil {
+			if err == ErrNotFound {
+				return map[string]interface{}{"message": "report not found"}, err
+			}
+			return nil, err
+		}
+		return map[string]interface{}{"message": "this report has already been closed"}, nil
+	}
+
+	return map[string]interface{}{"message": "Report assigned."}, nil
+}
+
+// addContentReportNote inserts a moderator note on a report
+func addContentReportNote(ctx context.Context, tidb *ti.Database, sf *snowflake.Node, authorId int64, reportId int64, note string) error {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "add-content-report-note-core")
+	defer span.End()
+	callerName := "addContentReportNote"
+
+	_, err := tidb.ExecContext(ctx, &span, &callerName,
+		"insert into content_report_note(_id, report_id, author_id, note, created_at) values (?, ?, ?, ?, ?)",
+		sf.Generate().Int64(), reportId, authorId, note, time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert content report note: %v", err)
+	}
+
+	return nil
+}
+
+// AddContentReportNote leaves a note on a report for other moderators
+func AddContentReportNote(ctx context.Context, tidb *ti.Database, sf *snowflake.Node, callingUser *models.User, reportId int64, note string) (map[string]interface{}, error) {
+	if res, err := requireModerator(ctx, tidb, callingUser); res != nil || err != nil {
+		return res, err
+	}
+
+	if _, err := loadContentReport(ctx, tidb, reportId); err != nil {
+		if err == ErrNotFound {
+			return map[string]interface{}{"message": "report not found"}, err
+		}
+		return nil, err
+	}
+
+	err := addContentReportNote(ctx, tidb, sf, callingUser.ID, reportId, note)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{"message": "Note added."}, nil
+}
+
+// SetContentHidden hides or restores a piece of content. The content is
+// queued for the search engine so that hidden content is removed from its
+// search index and restored content is indexed again.
+func SetContentHidden(ctx context.Context, tidb *ti.Database, contentType ReportContentType, contentId int64, hidden bool) error {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "set-content-hidden-core")
+	defer span.End()
+	callerName := "SetContentHidden"
+
+	source, ok := reportContentSources[contentType]
+	if !ok || contentType == ReportContentUser {
+		return fmt.Errorf("content type %s cannot be hidden", contentType)
+	}
+
+	tx, err := tidb.BeginTx(ctx, &span, &callerName, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create hidden state tx: %v", err)
+	}
+	defer tx.Rollback()
+
+	// update every revision of the content
+	_, err = tx.ExecContext(ctx, &callerName,
+		fmt.Sprintf("update %s set hidden = ? where _id = ?", source.table), hidden, contentId,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update hidden state of %s %d: %v", contentType, contentId, err)
+	}
+
+	if source.index != "" {
+		err = queueSearchSync(ctx, tx, callerName, source.index, contentId)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit(&callerName)
+	if err != nil {
+		return fmt.Errorf("failed to commit hidden state tx: %v", err)
+	}
+
+	return nil
+}
+
+// suspendUser prevents the user from logging in until the passed time and
+// ends their current session
+func suspendUser(ctx context.Context, tidb *ti.Database, rdb redis.UniversalClient, userId int64, until time.Time) error {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "suspend-user-core")
+	defer span.End()
+	callerName := "suspendUser"
+
+	_, err := tidb.ExecContext(ctx, &span, &callerName,
+		"update users set suspended_until = greatest(coalesce(suspended_until, ?), ?) where _id = ?", until, until, userId,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to suspend user: %v", err)
+	}
+
+	// end the active session so the suspension takes effect immediately
+	sessionKey := fmt.Sprintf("gigo-user-sess-%d", userId)
+	sessionBytes, err := rdb.Get(ctx, sessionKey).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil
+		}
+		return fmt.Errorf("failed to retrieve session from redis: %v", err)
+	}
+
+	var session models.UserSession
+	err = json.Unmarshal(sessionBytes, &session)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal session from redis: %v", err)
+	}
+
+	_, err = tidb.ExecContext(ctx, &span, &callerName, "delete from user_session_key where _id = ?", session.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete user session key: %v", err)
+	}
+
+	err = rdb.Del(ctx, sessionKey).Err()
+	if err != nil {
+		return fmt.Errorf("failed to delete user session from redis: %v", err)
+	}
+
+	return nil
+}
+
+// checkUserSuspension returns a failed login response if the user is
+// currently suspended
+func checkUserSuspension(ctx context.Context, tidb *ti.Database, userId int64) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "check-user-suspension-core")
+	defer span.End()
+	callerName := "checkUserSuspension"
+
+	var suspendedUntil sql.NullTime
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		"select suspended_until from users where _id = ? limit 1", userId,
+	).Scan(&suspendedUntil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user suspension: %v", err)
+	}
+
+	if !suspendedUntil.Valid || !suspendedUntil.Time.After(time.Now()) {
+		return nil, nil
+	}
+
+	return map[string]interface{}{
+		"auth":        false,
+		"user_status": "",
+		"token":       "",
+		"message":     fmt.Sprintf("Your account has been suspended until %s.", suspendedUntil.Time.UTC().Format("January 2, 2006 15:04 MST")),
+	}, nil
+}
+
+// ResolveContentReport closes a report by taking the passed action against the
+// reported content or its owner. All other open reports against the same
+// content are closed with the same resolution.
+func ResolveContentReport(ctx context.Context, tidb *ti.Database, js *mq.JetstreamClient,
+	rdb redis.UniversalClient, sf *snowflake.Node, callingUser *models.User, reportId int64, action ReportAction,
+	note string, suspendDays int) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "resolve-content-report-core")
+	defer span.End()
+	callerName := "ResolveContentReport"
+
+	if res, err := requireModerator(ctx, tidb, callingUser); res != nil || err != nil {
+		return res, err
+	}
+
+	report, err := loadContentReport(ctx, tidb, reportId)
+	if err != nil {
+		if err == ErrNotFound {
+			return map[string]interface{}{"message": "report not found"}, err
+		}
+		return nil, err
+	}
+
+	if report.Status == ReportStatusResolved || report.Status == ReportStatusDismissed {
+		return map[string]interface{}{"message": "this report has already been closed"}, nil
+	}
+
+	var contentId, ownerId int64
+	fmt.Sscanf(report.ContentID, "%d", &contentId)
+	fmt.Sscanf(report.ContentOwnerID, "%d", &ownerId)
+
+	status := ReportStatusResolved
+	switch action {
+	case ReportActionDismiss:
+		status = ReportStatusDismissed
+	case ReportActionHideContent:
+		if report.ContentType == ReportContentUser {
+			return map[string]interface{}{"message": "users cannot be hidden, suspend the user instead"}, nil
+		}
+		err = SetContentHidden(ctx, tidb, report.ContentType, contentId, true)
+		if err != nil {
+			return nil, err
+		}
+	case ReportActionWarnUser:
+		_, err = CreateNotification(ctx, tidb, js, sf, ownerId,
+			fmt.Sprintf("Your %s was reported for %s and reviewed by a moderator. Please follow the community guidelines "+
+				"to avoid further action on your account.", report.ContentType, report.Reason),
+			ModerationWarningNotification, nil,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to warn user: %v", err)
+		}
+	case ReportActionSuspendUser:
+		if suspendDays <= 0 {
+			return map[string]interface{}{"message": "suspension length must be at least one day"}, nil
+		}
+		err = suspendUser(ctx, tidb, rdb, ownerId, time.Now().AddDate(0, 0, suspendDays))
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return map[string]interface{}{"message": "invalid action"}, nil
+	}
+
+	now := time.Now()
+	_, err = tidb.ExecContext(ctx, &span, &callerName,
+		"update content_report set status = ?, resolution = ?, resolved_at = ?, updated_at = ?, assignee_id = coalesce(assignee_id, ?) "+
+			"where content_type = ? and content_id = ? and status in (?, ?)",
+		status, action, now, now, callingUser.ID, report.ContentType, contentId, ReportStatusOpen, ReportStatusInReview,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve content reports: %v", err)
+	}
+
+	if note != "" {
+		err = addContentReportNote(ctx, tidb, sf, callingUser.ID, reportId, note)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res := map[string]interface{}{"message": fmt.Sprintf("Report closed with action %s.", action)}
+
+	// hidden discussion content is returned so that the api can drop the
+	// cached trees of its discussion
+	if _, ok := discussionRevisionTypes[report.ContentType.String()]; ok && action == ReportActionHideContent {
+		res["discussion_type"] = report.ContentType.String()
+		res["content_id"] = report.ContentID
+	}
+
+	return res, nil
+}
@@ -0,0 +1,63 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gage-technologies/gigo-lib/db/models"
+)
+
+func TestReportContentSources(t *testing.T) {
+	// every reportable content type must resolve to a table and an owner
+	for contentType := ReportContentPost; contentType <= ReportContentUser; contentType++ {
+		source, ok := reportContentSources[contentType]
+		if !ok {
+			t.Errorf("\nTestReportContentSources failed\n    Error: missing source for %s", contentType)
+			continue
+		}
+		if source.table == "" || source.ownerColumn == "" {
+			t.Errorf("\nTestReportContentSources failed\n    Error: incomplete source for %s: %+v", contentType, source)
+		}
+		if contentType.String() == "unknown" {
+			t.Errorf("\nTestReportContentSources failed\n    Error: content type %d has no name", contentType)
+		}
+	}
+
+	// users are identified by their own id
+	if reportContentSources[ReportContentUser].ownerColumn != "_id" {
+		t.Errorf("\nTestReportContentSources failed\n    Error: users must be owned by themselves")
+	}
+}
+
+func TestReportEnumStrings(t *testing.T) {
+	for reason := ReportReasonSpam; reason <= ReportReasonOther; reason++ {
+		if reason.String() == "unknown" {
+			t.Errorf("\nTestReportEnumStrings failed\n    Error: reason %d has no name", reason)
+		}
+	}
+	for status := ReportStatusOpen; status <= ReportStatusDismissed; status++ {
+		if status.String() == "unknown" {
+			t.Errorf("\nTestReportEnumStrings failed\n    Error: status %d has no name", status)
+		}
+	}
+	for action := ReportActionDismiss; action <= ReportActionSuspendUser; action++ {
+		if action.String() == "unknown" {
+			t.Errorf("\nTestReportEnumStrings failed\n    Error: action %d has no name", action)
+		}
+	}
+}
+
+func TestCheckHiddenContentAccess(t *testing.T) {
+	owner := &models.User{ID: 7, UserName: "owner"}
+
+	// visible content and the owners of hidden content never need a moderator lookup
+	if err := checkHiddenContentAccess(context.Background(), nil, nil, false, 7); err != nil {
+		t.Errorf("checkHiddenContentAccess() error = %v for visible content", err)
+	}
+	if err := checkHiddenContentAccess(context.Background(), nil, owner, true, 3, 7); err != nil {
+		t.Errorf("checkHiddenContentAccess() error = %v for an owner", err)
+	}
+	if err := checkHiddenContentAccess(context.Background(), nil, nil, true, 7); err != ErrNotFound {
+		t.Errorf("checkHiddenContentAccess() error = %v for an anonymous caller, want %v", err, ErrNotFound)
+	}
+}
@@ -16,6 +16,24 @@ import (
 	"time"
 )
 
+// Notification types that are specific to gigo-core. These extend the
+// notification types defined in gigo-lib so they must start after the last
+// type defined there.
+const (
+	// AchievementNotification informs a user that they have been awarded a new achievement
+	AchievementNotification models.NotificationType = models.StreakInfo + 1 + iota
+	// CollaboratorInviteNotification informs a user that they have been invited to collaborate on a project
+	CollaboratorInviteNotification
+	// ModerationWarningNotification warns a user that content they created violated the community guidelines
+	ModerationWarningNotification
+	// WorkspaceConfigUpdateNotification informs the authors of projects using a shared workspace config that it changed
+	WorkspaceConfigUpdateNotification
+	// MentionNotification informs a user that they were mentioned in a discussion, comment or reply
+	MentionNotification
+	// DiscussionReplyNotification informs a user that a discussion they are watching has a new comment or reply
+	DiscussionReplyNotification
+)
+
 func CreateNotification(ctx context.Context, tidb *ti.Database, js *mq.JetstreamClient, sf *snowflake.Node, userId int64, message string, notificationType models.NotificationType, interactingUserId *int64) (*models.NotificationFrontend, error) {
 
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "create-notification-core")
@@ -12,7 +12,7 @@ func PopularPageFeed(ctx context.Context, skip int, limit int, tidb *ti.Database
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "popular-page-feed-core")
 	callerName := "PopularPageFeed"
 
-	res, err := tidb.QueryContext(ctx, &span, &callerName, "select * from post order by coffee desc, attempts desc limit ? offset ?", limit, skip)
+	res, err := tidb.QueryContext(ctx, &span, &callerName, "select * from post where hidden = false order by coffee desc, attempts desc limit ? offset ?", limit, skip)
 	if err != nil {
 		return map[string]interface{}{"feed": "There was an issue querying for feed"}, err
 	}
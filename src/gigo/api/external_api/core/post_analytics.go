@@ -0,0 +1,371 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	// default number of days included in the analytics of a post
+	analyticsDefaultDays = 30
+	// workspaces that are still initializing after this long are counted as dropped off
+	analyticsInitAbandonAfter = time.Hour
+	// layout of the day keys returned in analytics series
+	analyticsDayLayout = "2006-01-02"
+)
+
+type PostAnalyticsRequest struct {
+	ProjectID string `json:"project_id" validate:"required,number"`
+	Days      int    `json:"days" validate:"omitempty,gte=1,lte=365"`
+	Test      bool   `json:"test"`
+}
+
+// PostAnalyticsDay is the activity on a post during a single day
+type PostAnalyticsDay struct {
+	Day                    string `json:"day"`
+	Views                  int64  `json:"views"`
+	SearchViews            int64  `json:"search_views"`
+	ShareLinkViews         int64  `json:"share_link_views"`
+	Attempts               int64  `json:"attempts"`
+	SearchAttempts         int64  `json:"search_attempts"`
+	RecommendationAttempts int64  `json:"recommendation_attempts"`
+	ShareLinkAttempts      int64  `json:"share_link_attempts"`
+	Workspaces             int64  `json:"workspaces"`
+	WorkspacesReady        int64  `json:"workspaces_ready"`
+	Successes              int64  `json:"successes"`
+}
+
+// PostAnalyticsFunnelStep is a single stage of the funnel from viewing a post
+// to succeeding at it. Conversion is relative to the previous step.
+type PostAnalyticsFunnelStep struct {
+	Step       string  `json:"step"`
+	Count      int64   `json:"count"`
+	Conversion float64 `json:"conversion"`
+}
+
+// PostAnalyticsInitDropOff is the number of workspaces that stopped at an init step
+type PostAnalyticsInitDropOff struct {
+	InitState  models.WorkspaceInitState `json:"init_state"`
+	StepName   string                    `json:"step_name"`
+	Workspaces int64                     `json:"workspaces"`
+}
+
+// analyticsDay returns the start of the UTC day containing t
+func analyticsDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// fillAnalyticsSeries returns one entry for each day from start to end
+// inclusive using the rolled up days where they exist
+func fillAnalyticsSeries(rollups map[string]*PostAnalyticsDay, start time.Time, end time.Time) []*PostAnalyticsDay {
+	series := make([]*PostAnalyticsDay, 0)
+	for day := analyticsDay(start); !day.After(end); day = day.AddDate(0, 0, 1) {
+		key := day.Format(analyticsDayLayout)
+		if r, ok := rollups[key]; ok {
+			series = append(series, r)
+			continue
+		}
+		series = append(series, &PostAnalyticsDay{Day: key})
+	}
+	return series
+}
+
+// buildAnalyticsFunnel totals the series into the view, attempt, workspace and
+// success funnel
+func buildAnalyticsFunnel(series []*PostAnalyticsDay) []*PostAnalyticsFunnelStep {
+	var views, attempts, workspaces, successes int64
+	for _, d := range series {
+		views += d.Views
+		attempts += d.Attempts
+		workspaces += d.Workspaces
+		successes += d.Successes
+	}
+
+	steps := []*PostAnalyticsFunnelStep{
+		{Step: "views", Count: views},
+		{Step: "attempts", Count: attempts},
+		{Step: "workspaces", Count: workspaces},
+		{Step: "successes", Count: successes},
+	}
+	for i := 1; i < len(steps); i++ {
+		if steps[i-1].Count > 0 {
+			steps[i].Conversion = float64(steps[i].Count) / float64(steps[i-1].Count)
+		}
+	}
+	steps[0].Conversion = 1
+
+	return steps
+}
+
+// medianDuration returns the median of the passed durations or nil if there are none
+func medianDuration(durations []int64) *int64 {
+	if len(durations) == 0 {
+		return nil
+	}
+	sorted := append([]int64(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	mid := len(sorted) / 2
+	median := sorted[mid]
+	if len(sorted)%2 == 0 {
+		median = (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return &median
+}
+
+// RollupPostAnalytics recomputes the daily analytics of every post for the UTC
+// day containing the passed time. Rollups are idempotent so the current day
+// can be rolled up repeatedly as activity comes in.
+func RollupPostAnalytics(ctx context.Context, tidb *ti.Database, day time.Time) error {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "rollup-post-analytics-core")
+	defer span.End()
+	callerName := "RollupPostAnalytics"
+
+	start := analyticsDay(day)
+	end := start.AddDate(0, 0, 1)
+
+	// attempts are attributed to a share link first, then to an accepted
+	// recommendation and finally to a search that selected the post before
+	// the attempt was started
+	statements := []struct {
+		name   string
+		query  string
+		params []interface{}
+	}{
+		{
+			"views",
+			"insert into post_analytics_daily(post_id, day, views) " +
+				"select post_id, ?, count(*) from implicit_rec where implicit_action = ? and created_at >= ? and created_at < ? group by post_id " +
+				"on duplicate key update views = values(views)",
+			[]interface{}{start, models.ImplicitTypeClicked, start, end},
+		},
+		{
+			"search views",
+			"insert into post_analytics_daily(post_id, day, search_views) " +
+				"select selected_post_id, ?, count(*) from search_rec where selected_post_id is not null and created_at >= ? and created_at < ? group by selected_post_id " +
+				"on duplicate key update search_views = values(search_views)",
+			[]interface{}{start, start, end},
+		},
+		{
+			"attempts",
+			"insert into post_analytics_daily(post_id, day, attempts, share_link_attempts, recommendation_attempts, search_attempts) " +
+				"select a.post_id, ?, count(*), " +
+				"sum(a.share_link_id is not null), " +
+				"sum(a.share_link_id is null and exists(select 1 from recommended_post r where r.user_id = a.author_id and r.post_id = a.post_id and r.accepted = true)), " +
+				"sum(a.share_link_id is null and not exists(select 1 from recommended_post r where r.user_id = a.author_id and r.post_id = a.post_id and r.accepted = true) " +
+				"and exists(select 1 from search_rec s where s.user_id = a.author_id and s.selected_post_id = a.post_id and s.created_at <= a.created_at)) " +
+				"from attempt a where a.created_at >= ? and a.created_at < ? group by a.post_id " +
+				"on duplicate key update attempts = values(attempts), share_link_attempts = values(share_link_attempts), " +
+				"recommendation_attempts = values(recommendation_attempts), search_attempts = values(search_attempts)",
+			[]interface{}{start, start, end},
+		},
+		{
+			"workspaces",
+			"insert into post_analytics_daily(post_id, day, workspaces, workspaces_ready) " +
+				"select a.post_id, ?, count(*), sum(w.init_state = ?) from workspaces w join attempt a on a._id = w.code_source_id " +
+				"where w.code_source_type = ? and w.created_at >= ? and w.created_at < ? " +
+				"and not exists (select 1 from attempt_grading g where g.workspace_id = w._id) group by a.post_id " +
+				"on duplicate key update workspaces = values(workspaces), workspaces_ready = values(workspaces_ready)",
+			[]interface{}{start, models.WorkspaceInitCompleted, models.CodeSourceAttempt, start, end},
+		},
+		{
+			"successes",
+			"insert into post_analytics_daily(post_id, day, successes) " +
+				"select post_id, ?, count(*) from attempt where success_at >= ? and success_at < ? group by post_id " +
+				"on duplicate key update successes = values(successes)",
+			[]interface{}{start, start, end},
+		},
+	}
+
+	tx, err := tidb.BeginTx(ctx, &span, &callerName, nil)
+	if err != nil {
+		return fmt.Errorf("failed to open tx for analytics rollup: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, s := range statements {
+		_, err = tx.ExecContext(ctx, &callerName, s.query, s.params...)
+		if err != nil {
+			return fmt.Errorf("failed to roll up %s for %s: %v", s.name, start.Format(analyticsDayLayout), err)
+		}
+	}
+
+	// replace the init drop off of the day since steps can move as workspaces progress
+	_, err = tx.ExecContext(ctx, &callerName, "delete from post_analytics_init_daily where day = ?", start)
+	if err != nil {
+		return fmt.Errorf("failed to clear init drop off for %s: %v", start.Format(analyticsDayLayout), err)
+	}
+
+	_, err = tx.ExecContext(ctx, &callerName,
+		"insert into post_analytics_init_daily(post_id, day, init_state, workspaces) "+
+			"select a.post_id, ?, w.init_state, count(*) from workspaces w join attempt a on a._id = w.code_source_id "+
+			"where w.code_source_type = ? and w.created_at >= ? and w.created_at < ? and w.init_state != ? and (w.state = ? or w.created_at < ?) "+
+			"and not exists (select 1 from attempt_grading g where g.workspace_id = w._id) "+
+			"group by a.post_id, w.init_state",
+		start, models.CodeSourceAttempt, start, end, models.WorkspaceInitCompleted, models.WorkspaceFailed, time.Now().Add(-analyticsInitAbandonAfter),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to roll up init drop off for %s: %v", start.Format(analyticsDayLayout), err)
+	}
+
+	err = tx.Commit(&callerName)
+	if err != nil {
+		return fmt.Errorf("failed to commit analytics rollup: %v", err)
+	}
+
+	return nil
+}
+
+// recordShareLinkView counts a view through a share link in the rollup of the
+// current day. Share link views are only stored as totals on the link so they
+// are counted as they happen rather than by the rollup job.
+func recordShareLinkView(ctx context.Context, tidb *ti.Database, postId int64) error {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "record-share-link-view-core")
+	defer span.End()
+	callerName := "recordShareLinkView"
+
+	_, err := tidb.ExecContext(ctx, &span, &callerName,
+		"insert into post_analytics_daily(post_id, day, share_link_views) values (?, ?, 1) "+
+			"on duplicate key update share_link_views = share_link_views + 1",
+		postId, analyticsDay(time.Now()),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record share link view: %v", err)
+	}
+
+	return nil
+}
+
+// GetPostAnalytics retrieves the daily series, funnel, median time to success,
+// workspace init drop off and traffic sources of a post over the last days.
+// Only the author and maintainers of the post can view its analytics.
+func GetPostAnalytics(ctx context.Context, tidb *ti.Database, callingUser *models.User, postId int64, days int) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "get-post-analytics-core")
+	defer span.End()
+	callerName := "GetPostAnalytics"
+
+	ok, err := HasPostPermission(ctx, tidb, postId, callingUser.ID, CollaboratorMaintainer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check post permission: %v", err)
+	}
+	if !ok {
+		return map[string]interface{}{"message": "you do not have permission to view analytics for this project"},
+			fmt.Errorf("user %d cannot view analytics for post %d: %w", callingUser.ID, postId, ErrForbidden)
+	}
+
+	if days <= 0 {
+		days = analyticsDefaultDays
+	}
+	end := analyticsDay(time.Now())
+	start := end.AddDate(0, 0, -(days - 1))
+
+	// load the daily rollups
+	rows, err := tidb.QueryContext(ctx, &span, &callerName,
+		"select day, views, search_views, share_link_views, attempts, search_attempts, recommendation_attempts, share_link_attempts, "+
+			"workspaces, workspaces_ready, successes from post_analytics_daily where post_id = ? and day >= ? and day <= ?",
+		postId, start, end,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query analytics rollups: %v", err)
+	}
+	defer rows.Close()
+
+	rollups := make(map[string]*PostAnalyticsDay)
+	for rows.Next() {
+		var day time.Time
+		var d PostAnalyticsDay
+		err = rows.Scan(&day, &d.Views, &d.SearchViews, &d.ShareLinkViews, &d.Attempts, &d.SearchAttempts,
+			&d.RecommendationAttempts, &d.ShareLinkAttempts, &d.Workspaces, &d.WorkspacesReady, &d.Successes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan analytics rollup: %v", err)
+		}
+		d.Day = day.Format(analyticsDayLayout)
+		rollups[d.Day] = &d
+	}
+	series := fillAnalyticsSeries(rollups, start, end)
+
+	// load the init step that failed workspaces stopped at
+	initRows, err := tidb.QueryContext(ctx, &span, &callerName,
+		"select init_state, sum(workspaces) from post_analytics_init_daily where post_id = ? and day >= ? and day <= ? group by init_state order by init_state",
+		postId, start, end,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query init drop off: %v", err)
+	}
+	defer initRows.Close()
+
+	dropOff := make([]*PostAnalyticsInitDropOff, 0)
+	for initRows.Next() {
+		var d PostAnalyticsInitDropOff
+		err = initRows.Scan(&d.InitState, &d.Workspaces)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan init drop off: %v", err)
+		}
+		d.StepName = d.InitState.String()
+		dropOff = append(dropOff, &d)
+	}
+
+	// load the time to success of the attempts that succeeded in the window.
+	// attempts that succeeded before success times were recorded are excluded.
+	durationRows, err := tidb.QueryContext(ctx, &span, &callerName,
+		"select timestampdiff(second, created_at, success_at) from attempt where post_id = ? and success_at >= ? and success_at < ?",
+		postId, start, end.AddDate(0, 0, 1),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query time to success: %v", err)
+	}
+	defer durationRows.Close()
+
+	durations := make([]int64, 0)
+	for durationRows.Next() {
+		var d int64
+		err = durationRows.Scan(&d)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan time to success: %v", err)
+		}
+		durations = append(durations, d)
+	}
+
+	// total the traffic sources. views that did not come from search or a
+	// share link are attributed to other sources such as the feeds
+	var views, searchViews, shareLinkViews, attempts, searchAttempts, recommendationAttempts, shareLinkAttempts int64
+	for _, d := range series {
+		views += d.Views
+		searchViews += d.SearchViews
+		shareLinkViews += d.ShareLinkViews
+		attempts += d.Attempts
+		searchAttempts += d.SearchAttempts
+		recommendationAttempts += d.RecommendationAttempts
+		shareLinkAttempts += d.ShareLinkAttempts
+	}
+	otherViews := views - searchViews - shareLinkViews
+	if otherViews < 0 {
+		otherViews = 0
+	}
+
+	return map[string]interface{}{
+		"series":                         series,
+		"funnel":                         buildAnalyticsFunnel(series),
+		"median_time_to_success_seconds": medianDuration(durations),
+		"init_drop_off":                  dropOff,
+		"traffic_sources": map[string]interface{}{
+			"views": map[string]int64{
+				"search":     searchViews,
+				"share_link": shareLinkViews,
+				"other":      otherViews,
+			},
+			"attempts": map[string]int64{
+				"search":         searchAttempts,
+				"recommendation": recommendationAttempts,
+				"share_link":     shareLinkAttempts,
+				"direct":         attempts - searchAttempts - recommendationAttempts - shareLinkAttempts,
+			},
+		},
+	}, nil
+}
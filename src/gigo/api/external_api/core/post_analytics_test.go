@@ -0,0 +1,63 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFillAnalyticsSeries(t *testing.T) {
+	start := time.Date(2023, 10, 30, 15, 0, 0, 0, time.UTC)
+	end := time.Date(2023, 11, 2, 0, 0, 0, 0, time.UTC)
+
+	series := fillAnalyticsSeries(map[string]*PostAnalyticsDay{
+		"2023-10-31": {Day: "2023-10-31", Views: 4},
+	}, start, end)
+
+	want := []string{"2023-10-30", "2023-10-31", "2023-11-01", "2023-11-02"}
+	if len(series) != len(want) {
+		t.Fatalf("fillAnalyticsSeries() returned %d days, want %d", len(series), len(want))
+	}
+	for i, d := range series {
+		if d.Day != want[i] {
+			t.Errorf("fillAnalyticsSeries()[%d].Day = %s, want %s", i, d.Day, want[i])
+		}
+	}
+	if series[1].Views != 4 || series[0].Views != 0 {
+		t.Errorf("fillAnalyticsSeries() did not use the rolled up days: %+v", series)
+	}
+}
+
+func TestBuildAnalyticsFunnel(t *testing.T) {
+	funnel := buildAnalyticsFunnel([]*PostAnalyticsDay{
+		{Views: 60, Attempts: 10, Workspaces: 8, Successes: 2},
+		{Views: 40, Attempts: 10, Workspaces: 12, Successes: 3},
+	})
+
+	want := []struct {
+		count      int64
+		conversion float64
+	}{{100, 1}, {20, 0.2}, {20, 1}, {5, 0.25}}
+	for i, w := range want {
+		if funnel[i].Count != w.count || funnel[i].Conversion != w.conversion {
+			t.Errorf("buildAnalyticsFunnel()[%s] = %d %v, want %d %v", funnel[i].Step, funnel[i].Count, funnel[i].Conversion, w.count, w.conversion)
+		}
+	}
+
+	// empty steps do not divide by zero
+	funnel = buildAnalyticsFunnel(nil)
+	if funnel[1].Conversion != 0 {
+		t.Errorf("buildAnalyticsFunnel(nil) conversion = %v, want 0", funnel[1].Conversion)
+	}
+}
+
+func TestMedianDuration(t *testing.T) {
+	if medianDuration(nil) != nil {
+		t.Error("medianDuration(nil) should be nil")
+	}
+	if got := medianDuration([]int64{30, 10, 20}); *got != 20 {
+		t.Errorf("medianDuration() = %d, want 20", *got)
+	}
+	if got := medianDuration([]int64{40, 10, 20, 30}); *got != 25 {
+		t.Errorf("medianDuration() = %d, want 25", *got)
+	}
+}
@@ -0,0 +1,265 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"go.opentelemetry.io/otel"
+)
+
+type PrivacyLevel int
+
+const (
+	PrivacyPublic PrivacyLevel = iota
+	PrivacyFriends
+	PrivacyPrivate
+)
+
+func (l PrivacyLevel) String() string {
+	switch l {
+	case PrivacyPublic:
+		return "public"
+	case PrivacyFriends:
+		return "friends"
+	case PrivacyPrivate:
+		return "private"
+	}
+	return "unknown"
+}
+
+// Valid returns true if the privacy level is one of the supported levels
+func (l PrivacyLevel) Valid() bool {
+	return l >= PrivacyPublic && l <= PrivacyPrivate
+}
+
+// PrivacySettings controls which sections of a user's profile are visible
+// to other users. Settings are stored as json on the users table in the same
+// fashion as the workspace settings.
+type PrivacySettings struct {
+	// Streaks controls the visibility of the daily activity and streak data
+	Streaks PrivacyLevel `json:"streaks" validate:"gte=0,lte=2"`
+	// Stats controls the visibility of the xp, level and rank of the user
+	Stats PrivacyLevel `json:"stats" validate:"gte=0,lte=2"`
+	// Nemesis controls the visibility of the nemesis war history
+	Nemesis PrivacyLevel `json:"nemesis" validate:"gte=0,lte=2"`
+	// Attempts controls the visibility of the attempts made by the user
+	Attempts PrivacyLevel `json:"attempts" validate:"gte=0,lte=2"`
+	// HideFromSearch removes the user from the results of SearchUsers
+	HideFromSearch bool `json:"hide_from_search"`
+}
+
+type SetUserPrivacySettingsRequest struct {
+	PrivacySettings PrivacySettings `json:"privacy_settings" validate:"required"`
+	Test            bool            `json:"test"`
+}
+
+var DefaultPrivacySettings = PrivacySettings{
+	Streaks:        PrivacyPublic,
+	Stats:          PrivacyPublic,
+	Nemesis:        PrivacyPublic,
+	Attempts:       PrivacyPublic,
+	HideFromSearch: false,
+}
+
+// PrivacyAccess is the resolved visibility of each profile section for a
+// specific viewer of a user's profile
+type PrivacyAccess struct {
+	Streaks  bool `json:"streaks"`
+	Stats    bool `json:"stats"`
+	Nemesis  bool `json:"nemesis"`
+	Attempts bool `json:"attempts"`
+}
+
+// FullPrivacyAccess is the access granted to a user viewing their own profile
+var FullPrivacyAccess = PrivacyAccess{
+	Streaks:  true,
+	Stats:    true,
+	Nemesis:  true,
+	Attempts: true,
+}
+
+// HiddenSections returns the names of the sections the viewer cannot see so
+// that the frontend can render a placeholder in their place
+func (a *PrivacyAccess) HiddenSections() []string {
+	hidden := make([]string, 0)
+	if !a.Streaks {
+		hidden = append(hidden, "streaks")
+	}
+	if !a.Stats {
+		hidden = append(hidden, "stats")
+	}
+	if !a.Nemesis {
+		hidden = append(hidden, "nemesis")
+	}
+	if !a.Attempts {
+		hidden = append(hidden, "attempts")
+	}
+	return hidden
+}
+
+// loadPrivacySettings loads the privacy settings for the passed user falling
+// back on the default settings if the user has never saved any
+func loadPrivacySettings(ctx context.Context, tidb *ti.Database, userId int64) (*PrivacySettings, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "load-privacy-settings-core")
+	defer span.End()
+	callerName := "loadPrivacySettings"
+
+	var raw []byte
+	err := tidb.QueryRowContext(ctx, &span, &callerName, "select privacy_settings from users where _id = ? limit 1", userId).Scan(&raw)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to query privacy settings: %v", err)
+	}
+
+	settings := DefaultPrivacySettings
+	if len(raw) > 0 {
+		err = json.Unmarshal(raw, &settings)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal privacy settings: %v", err)
+		}
+	}
+
+	return &settings, nil
+}
+
+// ResolvePrivacyAccess determines which sections of the profile owned by ownerId
+// are visible to the calling user. The calling user may be nil for anonymous
+// viewers on hybrid routes.
+func ResolvePrivacyAccess(ctx context.Context, tidb *ti.Database, callingUser *models.User, ownerId int64) (*PrivacyAccess, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "resolve-privacy-access-core")
+	defer span.End()
+	callerName := "ResolvePrivacyAccess"
+
+	// users can always see their own profile
+	if callingUser != nil && callingUser.ID == ownerId {
+		access := FullPrivacyAccess
+		return &access, nil
+	}
+
+	settings, err := loadPrivacySettings(ctx, tidb, ownerId)
+	if err != nil {
+		return nil, err
+	}
+
+	// only check for a friendship if one of the sections requires it
+	friends := false
+	if callingUser != nil && (settings.Streaks == PrivacyFriends || settings.Stats == PrivacyFriends ||
+		settings.Nemesis == PrivacyFriends || settings.Attempts == PrivacyFriends) {
+		var count int64
+		err = tidb.QueryRowContext(ctx, &span, &callerName,
+			"select count(*) from friends where user_id = ? and friend = ?", ownerId, callingUser.ID,
+		).Scan(&count)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query friendship: %v", err)
+		}
+		friends = count > 0
+	}
+
+	visible := func(level PrivacyLevel) bool {
+		switch level {
+		case PrivacyPublic:
+			return true
+		case PrivacyFriends:
+			return friends
+		}
+		return false
+	}
+
+	return &PrivacyAccess{
+		Streaks:  visible(settings.Streaks),
+		Stats:    visible(settings.Stats),
+		Nemesis:  visible(settings.Nemesis),
+		Attempts: visible(settings.Attempts),
+	}, nil
+}
+
+// GetAttemptAuthorID retrieves the id of the author of an attempt so that the
+// privacy settings of the author can be enforced before the attempt is loaded
+func GetAttemptAuthorID(ctx context.Context, tidb *ti.Database, attemptId int64) (int64, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "get-attempt-author-id-core")
+	defer span.End()
+	callerName := "GetAttemptAuthorID"
+
+	var authorId int64
+	err := tidb.QueryRowContext(ctx, &span, &callerName, "select author_id from attempt where _id = ? limit 1", attemptId).Scan(&authorId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrNotFound
+		}
+		return 0, fmt.Errorf("failed to query attempt author: %v", err)
+	}
+
+	return authorId, nil
+}
+
+func SetUserPrivacySettings(ctx context.Context, callingUser *models.User, tidb *ti.Database, privacySettings *PrivacySettings) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "set-user-privacy-settings-core")
+	defer span.End()
+	callerName := "SetUserPrivacySettings"
+
+	// validate the privacy levels
+	for _, level := range []PrivacyLevel{privacySettings.Streaks, privacySettings.Stats, privacySettings.Nemesis, privacySettings.Attempts} {
+		if !level.Valid() {
+			return map[string]interface{}{"message": "invalid privacy level"}, fmt.Errorf("invalid privacy level: %d", level)
+		}
+	}
+
+	setting, err := json.Marshal(privacySettings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal privacy settings: %v", err)
+	}
+
+	_, err = tidb.ExecContext(ctx, &span, &callerName, "update users set privacy_settings = ? where _id = ?", setting, callingUser.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update privacy settings: %v", err)
+	}
+
+	return map[string]interface{}{"message": "privacy settings edited successfully"}, nil
+}
+
+func GetUserPrivacySettings(ctx context.Context, callingUser *models.User, tidb *ti.Database) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "get-user-privacy-settings-core")
+	defer span.End()
+
+	settings, err := loadPrivacySettings(ctx, tidb, callingUser.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load privacy settings: %v", err)
+	}
+
+	return map[string]interface{}{"privacy": settings}, nil
+}
+
+// checkAttemptPrivacy returns ErrNotFound if the attempts of the author are
+// hidden from the calling user by the author's privacy settings
+func checkAttemptPrivacy(ctx context.Context, tidb *ti.Database, callingUser *models.User, authorId int64) error {
+	access, err := ResolvePrivacyAccess(ctx, tidb, callingUser, authorId)
+	if err != nil {
+		return fmt.Errorf("failed to resolve privacy access: %v", err)
+	}
+	if !access.Attempts {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// attemptPrivacyFilter builds a sql condition that excludes the attempts hidden
+// from the calling user by the privacy settings of their authors. The query
+// must alias the attempt table as a and the users table of the author as u.
+func attemptPrivacyFilter(callingUser *models.User) (string, []interface{}) {
+	level := "ifnull(cast(json_unquote(json_extract(u.privacy_settings, '$.attempts')) as signed), 0)"
+
+	// anonymous viewers can only see public attempts
+	if callingUser == nil {
+		return level + " = ?", []interface{}{PrivacyPublic}
+	}
+
+	return "(a.author_id = ? or " + level + " = ? or (" + level + " = ? and exists " +
+			"(select 1 from friends f where f.user_id = a.author_id and f.friend = ?)))",
+		[]interface{}{callingUser.ID, PrivacyPublic, PrivacyFriends, callingUser.ID}
+}
@@ -0,0 +1,159 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gigo-core/gigo/migrations"
+
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/db/models"
+)
+
+func TestResolvePrivacyAccess(t *testing.T) {
+	testTiDB, err := ti.CreateDatabase("gigo-dev-tidb", "4000", "mysql", "gigo-dev",
+		"gigo-dev",
+		"gigo_test_db")
+	if err != nil {
+		t.Fatal("Initialize test database failed:", err)
+	}
+
+	err = migrations.Run(testTiDB)
+	if err != nil {
+		t.Fatal("Migrate test database failed:", err)
+	}
+
+	var ava models.AvatarSettings
+
+	owner, err := models.CreateUser(1, "testuser1", "", "", "", models.UserStatusBasic, "", nil, nil, "", "", 0, "None", models.UserStart{}, "America/Chicago", ava, 0)
+	if err != nil {
+		t.Fatalf("\nTestResolvePrivacyAccess failed\n    Error: %v\n", err)
+	}
+
+	friend, err := models.CreateUser(2, "testuser2", "", "", "", models.UserStatusBasic, "", nil, nil, "", "", 0, "None", models.UserStart{}, "America/Chicago", ava, 0)
+	if err != nil {
+		t.Fatalf("\nTestResolvePrivacyAccess failed\n    Error: %v\n", err)
+	}
+
+	stranger, err := models.CreateUser(3, "testuser3", "", "", "", models.UserStatusBasic, "", nil, nil, "", "", 0, "None", models.UserStart{}, "America/Chicago", ava, 0)
+	if err != nil {
+		t.Fatalf("\nTestResolvePrivacyAccess failed\n    Error: %v\n", err)
+	}
+
+	for _, u := range []*models.User{owner, friend, stranger} {
+		stmts, err := u.ToSQLNative()
+		if err != nil {
+			t.Fatalf("Failed to convert user to SQL: %v", err)
+		}
+
+		for _, stmt := range stmts {
+			_, err = testTiDB.DB.Exec(stmt.Statement, stmt.Values...)
+			if err != nil {
+				t.Fatalf("Failed to insert user: %v", err)
+			}
+		}
+	}
+
+	_, err = testTiDB.DB.Exec("insert into friends(_id, user_id, user_name, friend, friend_name, date) values (?, ?, ?, ?, ?, ?), (?, ?, ?, ?, ?, ?)",
+		1, owner.ID, owner.UserName, friend.ID, friend.UserName, time.Now(),
+		2, friend.ID, friend.UserName, owner.ID, owner.UserName, time.Now(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to insert friends: %v", err)
+	}
+
+	defer func() {
+		_, _ = testTiDB.DB.Exec("DELETE FROM users WHERE _id in (1, 2, 3)")
+		_, _ = testTiDB.DB.Exec("DELETE FROM friends WHERE _id in (1, 2)")
+	}()
+
+	// default settings should expose everything to everyone
+	access, err := ResolvePrivacyAccess(context.Background(), testTiDB, nil, owner.ID)
+	if err != nil {
+		t.Fatalf("ResolvePrivacyAccess() error = %v", err)
+	}
+	if *access != FullPrivacyAccess {
+		t.Errorf("ResolvePrivacyAccess() = %+v, want %+v", *access, FullPrivacyAccess)
+	}
+
+	_, err = SetUserPrivacySettings(context.Background(), owner, testTiDB, &PrivacySettings{
+		Streaks:        PrivacyPublic,
+		Stats:          PrivacyFriends,
+		Nemesis:        PrivacyPrivate,
+		Attempts:       PrivacyFriends,
+		HideFromSearch: true,
+	})
+	if err != nil {
+		t.Fatalf("SetUserPrivacySettings() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		viewer *models.User
+		want   PrivacyAccess
+	}{
+		{
+			name:   "owner",
+			viewer: owner,
+			want:   FullPrivacyAccess,
+		},
+		{
+			name:   "friend",
+			viewer: friend,
+			want:   PrivacyAccess{Streaks: true, Stats: true, Nemesis: false, Attempts: true},
+		},
+		{
+			name:   "stranger",
+			viewer: stranger,
+			want:   PrivacyAccess{Streaks: true, Stats: false, Nemesis: false, Attempts: false},
+		},
+		{
+			name:   "anonymous",
+			viewer: nil,
+			want:   PrivacyAccess{Streaks: true, Stats: false, Nemesis: false, Attempts: false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolvePrivacyAccess(context.Background(), testTiDB, tt.viewer, owner.ID)
+			if err != nil {
+				t.Fatalf("ResolvePrivacyAccess() error = %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("ResolvePrivacyAccess() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+
+	res, err := GetUserPrivacySettings(context.Background(), owner, testTiDB)
+	if err != nil {
+		t.Fatalf("GetUserPrivacySettings() error = %v", err)
+	}
+	if !res["privacy"].(*PrivacySettings).HideFromSearch {
+		t.Errorf("GetUserPrivacySettings() hide_from_search = false, want true")
+	}
+
+	_, err = ResolvePrivacyAccess(context.Background(), testTiDB, nil, 4)
+	if err != ErrNotFound {
+		t.Errorf("ResolvePrivacyAccess() error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestSetUserPrivacySettings_InvalidLevel(t *testing.T) {
+	testTiDB, err := ti.CreateDatabase("gigo-dev-tidb", "4000", "mysql", "gigo-dev",
+		"gigo-dev",
+		"gigo_test_db")
+	if err != nil {
+		t.Fatal("Initialize test database failed:", err)
+	}
+
+	res, err := SetUserPrivacySettings(context.Background(), &models.User{ID: 1}, testTiDB, &PrivacySettings{Stats: 7})
+	if err == nil {
+		t.Fatalf("SetUserPrivacySettings() expected error for invalid level")
+	}
+	if res["message"] != "invalid privacy level" {
+		t.Errorf("SetUserPrivacySettings() message = %v, want %v", res["message"], "invalid privacy level")
+	}
+}
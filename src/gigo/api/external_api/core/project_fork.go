@@ -0,0 +1,452 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/git"
+	"github.com/gage-technologies/gigo-lib/storage"
+	utils2 "github.com/gage-technologies/gigo-lib/utils"
+	"go.opentelemetry.io/otel"
+)
+
+// maxForkFiles caps the number of files that can be copied into a fork since
+// every file is read from the source repository individually
+const maxForkFiles = 500
+
+type ForkProjectRequest struct {
+	ProjectID   string  `json:"project_id" validate:"required,number"`
+	AttemptID   *string `json:"attempt_id" validate:"omitempty,number"`
+	Commit      string  `json:"commit" validate:"lte=64"`
+	Title       *string `json:"title" validate:"omitempty,gte=1,lte=255"`
+	Description *string `json:"description" validate:"omitempty,lte=10000"`
+	Test        bool    `json:"test"`
+}
+
+type ProjectForkSource struct {
+	PostID    string  `json:"post_id"`
+	Title     string  `json:"title"`
+	Author    string  `json:"author"`
+	AuthorID  string  `json:"author_id"`
+	AttemptID *string `json:"attempt_id"`
+	Commit    string  `json:"commit"`
+}
+
+// postThumbnailPath returns the storage path of the thumbnail for a post
+func postThumbnailPath(postId int64) (string, error) {
+	idHash, err := utils2.HashData([]byte(fmt.Sprintf("%d", postId)))
+	if err != nil {
+		return "", fmt.Errorf("failed to hash post id: %v", err)
+	}
+	return fmt.Sprintf("post/%s/%s/%s/thumbnail.jpg", idHash[:3], idHash[3:6], idHash), nil
+}
+
+// ForkProject creates a new draft project from a snapshot of an existing
+// project or one of the calling user's own attempts at the passed commit.
+// The new project inherits the configuration of the source project and keeps
+// a link back to the source so that attribution is preserved.
+func ForkProject(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClient,
+	storageEngine storage.Storage, sf *snowflake.Node, callingUser *models.User, userSession *models.UserSession,
+	postId int64, attemptId *int64, commit string, title *string, description *string) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "fork-project-core")
+	defer span.End()
+	callerName := "ForkProject"
+
+	// load the source post
+	res, err := tidb.QueryContext(ctx, &span, &callerName,
+		"select * from post where _id = ? and deleted = false limit 1", postId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query post: %v", err)
+	}
+
+	if !res.Next() {
+		_ = res.Close()
+		return map[string]interface{}{"message": "project not found"}, ErrNotFound
+	}
+
+	source, err := models.PostFromSQLNative(tidb, res)
+	_ = res.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load post: %v", err)
+	}
+
+	// projects hidden by a moderator cannot be forked by anyone but their
+	// author and the moderators
+	var sourceHidden bool
+	err = tidb.QueryRowContext(ctx, &span, &callerName,
+		"select hidden from post where _id = ? limit 1", postId,
+	).Scan(&sourceHidden)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query post visibility: %v", err)
+	}
+	err = checkHiddenContentAccess(ctx, tidb, callingUser, sourceHidden, source.AuthorID)
+	if err != nil {
+		if err == ErrNotFound {
+			return map[string]interface{}{"message": "project not found"}, err
+		}
+		return nil, err
+	}
+
+	// ensure the caller is permitted to copy the source project
+	if source.AuthorID != callingUser.ID {
+		if !source.Published || source.Visibility == models.PrivateVisibility {
+			return map[string]interface{}{"message": "project not found"}, ErrNotFound
+		}
+
+		// the fork is owned by the caller so forking paid or restricted
+		// content would hand it out for free
+		if source.Visibility != models.PublicVisibility {
+			return map[string]interface{}{"message": "Only public projects can be forked."},
+				fmt.Errorf("user %d cannot fork post %d with visibility %d", callingUser.ID, postId, source.Visibility)
+		}
+	}
+
+	// the fork keeps the visibility of the source
+	if source.Visibility == models.PrivateVisibility && callingUser.UserStatus != models.UserStatusPremium {
+		return map[string]interface{}{"message": "Private projects can only be forked by Premium users."},
+			fmt.Errorf("user %d cannot fork private post %d without premium", callingUser.ID, postId)
+	}
+
+	// default to copying the project repository
+	repoOwner := fmt.Sprintf("%d", source.AuthorID)
+	repoName := fmt.Sprintf("%d", source.ID)
+
+	// users can only remix their own attempts
+	if attemptId != nil {
+		var attemptAuthor int64
+		err = tidb.QueryRowContext(ctx, &span, &callerName,
+			"select author_id from attempt where _id = ? and post_id = ? limit 1", *attemptId, postId,
+		).Scan(&attemptAuthor)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return map[string]interface{}{"message": "attempt not found"}, ErrNotFound
+			}
+			return nil, fmt.Errorf("failed to query attempt: %v", err)
+		}
+
+		if attemptAuthor != callingUser.ID {
+			return map[string]interface{}{"message": "You can only fork your own attempts."},
+				fmt.Errorf("user %d cannot fork attempt %d", callingUser.ID, *attemptId)
+		}
+
+		repoOwner = fmt.Sprintf("%d", attemptAuthor)
+		repoName = fmt.Sprintf("%d", *attemptId)
+	}
+
+	// default to the head of the main branch
+	if commit == "" {
+		commit = "main"
+	}
+
+	// resolve the ref into a concrete commit so the fork records exactly what was copied
+	gitCommit, gitRes, err := vcsClient.GiteaClient.GetSingleCommit(repoOwner, repoName, commit)
+	if err != nil {
+		if gitRes != nil && gitRes.StatusCode == 404 {
+			return map[string]interface{}{"message": "commit not found"}, fmt.Errorf("commit %s not found in %s/%s", commit, repoOwner, repoName)
+		}
+		return nil, fmt.Errorf("failed to retrieve commit %s: %v\n    res: %s", commit, err, JsonifyGiteaResponse(gitRes))
+	}
+	sha := gitCommit.CommitMeta.SHA
+
+	entries, err := repoTreeEntries(vcsClient, repoOwner, repoName, sha)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) > maxForkFiles {
+		return map[string]interface{}{"message": fmt.Sprintf("Projects with more than %d files cannot be forked.", maxForkFiles)},
+			fmt.Errorf("%s/%s@%s has %d files", repoOwner, repoName, sha, len(entries))
+	}
+
+	// load the snapshot of the source repository
+	files := make([]*RepoFile, 0, len(entries))
+	for _, entry := range entries {
+		blob, gitRes, err := vcsClient.GiteaClient.GetBlob(repoOwner, repoName, entry.SHA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve blob %s: %v\n    res: %s", entry.Path, err, JsonifyGiteaResponse(gitRes))
+		}
+
+		content, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(blob.Content, "\n", ""))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode blob %s: %v", entry.Path, err)
+		}
+
+		files = append(files, &RepoFile{
+			Path:       entry.Path,
+			Executable: entry.Mode == "100755",
+			Content:    content,
+		})
+	}
+
+	// retrieve the service password from the session so the snapshot is pushed as the caller
+	servicePassword, err := userSession.GetServiceKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service key: %v", err)
+	}
+
+	// create a new id for the post
+	id := sf.Generate().Int64()
+
+	// create repo for the fork
+	repo, err := vcsClient.CreateRepo(
+		fmt.Sprintf("%d", callingUser.ID),
+		fmt.Sprintf("%d", id),
+		"",
+		true,
+		"",
+		"",
+		"",
+		"main",
+	)
+	if err != nil {
+		return map[string]interface{}{"message": "Unable to create repo"}, err
+	}
+
+	// create boolean to track failure
+	failed := true
+
+	// defer function to cleanup repo on failure
+	defer func() {
+		// skip cleanup if we succeeded
+		if !failed {
+			return
+		}
+
+		_ = vcsClient.DeleteRepo(fmt.Sprintf("%d", callingUser.ID), fmt.Sprintf("%d", id))
+	}()
+
+	// copy the snapshot of the source repository into the new repository in a single commit
+	err = pushRepoFiles(ctx, vcsClient, fmt.Sprintf("%d", callingUser.ID), fmt.Sprintf("%d", id), servicePassword,
+		files, nil, fmt.Sprintf("[GIGO-FORK] %s/%s@%s", repoOwner, repoName, sha))
+	if err != nil {
+		return nil, err
+	}
+
+	// default to the source title and description
+	forkTitle := source.Title
+	if title != nil {
+		forkTitle = *title
+	}
+	forkDescription := source.Description
+	if description != nil {
+		forkDescription = *description
+	}
+
+	// create the fork as a draft so the author can make changes before publishing
+	post, err := models.CreatePost(
+		id,
+		forkTitle,
+		forkDescription,
+		callingUser.UserName,
+		callingUser.ID,
+		time.Now(),
+		time.Now(),
+		repo.ID,
+		source.Tier,
+		[]int64{},
+		nil,
+		uint64(0),
+		source.PostType,
+		0,
+		0,
+		0,
+		source.Languages,
+		source.Visibility,
+		source.Tags,
+		nil,
+		nil,
+		source.WorkspaceConfig,
+		source.WorkspaceConfigRevision,
+		source.WorkspaceSettings,
+		false,
+		false,
+		nil,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new post struct: %v", err)
+	}
+
+	// format the post into sql insert statements
+	statements, err := post.ToSQLNative()
+	if err != nil {
+		return nil, fmt.Errorf("failed to format post into insert statements: %v", err)
+	}
+
+	// open tx for post insertion
+	tx, err := tidb.BeginTx(ctx, &span, &callerName, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create insert tx: %v", err)
+	}
+
+	defer tx.Rollback()
+
+	// iterate over insert statements performing insertion into sql
+	for _, statement := range statements {
+		_, err = tx.ExecContext(ctx, &callerName, statement.Statement, statement.Values...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to perform insertion statement for post: %v\n    statement: %s\n    params: %v", err, statement.Statement, statement.Values)
+		}
+	}
+
+	// link the fork to its source and keep the price of exclusive projects
+	_, err = tx.ExecContext(ctx, &callerName,
+		"update post set forked_from_post = ?, forked_from_attempt = ?, forked_from_commit = ?, challenge_cost = ? where _id = ?",
+		source.ID, attemptId, sha, source.ChallengeCost, id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to link fork to source: %v", err)
+	}
+
+	// increment the usage of the inherited tags
+	for _, tag := range source.Tags {
+		_, err = tx.ExecContext(ctx, &callerName, "update tag set usage_count = usage_count + 1 where _id =?", tag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to increment tag usage count: %v", err)
+		}
+	}
+
+	// copy the thumbnail of the source project
+	sourceThumbnail, err := postThumbnailPath(source.ID)
+	if err != nil {
+		return nil, err
+	}
+	forkThumbnail, err := postThumbnailPath(id)
+	if err != nil {
+		return nil, err
+	}
+	// a source without a thumbnail leaves the fork without one
+	exists, _, err := storageEngine.Exists(sourceThumbnail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check source thumbnail: %v", err)
+	}
+	if exists {
+		err = storageEngine.CopyFile(sourceThumbnail, forkThumbnail)
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy thumbnail: %v", err)
+		}
+	}
+
+	// queue the post to be made discoverable once committed
+	err = queueSearchSync(ctx, tx, callerName, "posts", id)
+	if err != nil {
+		return nil, err
+	}
+
+	// format post to frontend object
+	fp, err := post.ToFrontend()
+	if err != nil {
+		return nil, fmt.Errorf("failed to format post to frontend object: %v", err)
+	}
+
+	// commit insert tx
+	err = tx.Commit(&callerName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit fork insertion: %v", err)
+	}
+
+	// set failed as false
+	failed = false
+
+	return map[string]interface{}{"message": "Project forked successfully.", "project": fp}, nil
+}
+
+// getForkSource loads the attribution for a project that was forked from
+// another project or nil if the project is an original
+func getForkSource(ctx context.Context, tidb *ti.Database, postId int64) (*ProjectForkSource, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "get-fork-source-core")
+	defer span.End()
+	callerName := "getForkSource"
+
+	var sourcePostId sql.NullInt64
+	var sourceAttemptId sql.NullInt64
+	var sourceCommit sql.NullString
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		"select forked_from_post, forked_from_attempt, forked_from_commit from post where _id = ? limit 1", postId,
+	).Scan(&sourcePostId, &sourceAttemptId, &sourceCommit)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query fork source: %v", err)
+	}
+
+	if !sourcePostId.Valid {
+		return nil, nil
+	}
+
+	source := &ProjectForkSource{
+		PostID: fmt.Sprintf("%d", sourcePostId.Int64),
+		Commit: sourceCommit.String,
+	}
+
+	// the source may have since been deleted in which case we only keep the link
+	var authorId int64
+	err = tidb.QueryRowContext(ctx, &span, &callerName,
+		"select title, author, author_id from post where _id = ? limit 1", sourcePostId.Int64,
+	).Scan(&source.Title, &source.Author, &authorId)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to query fork source post: %v", err)
+	}
+	if authorId > 0 {
+		source.AuthorID = fmt.Sprintf("%d", authorId)
+	}
+
+	if sourceAttemptId.Valid {
+		attemptId := fmt.Sprintf("%d", sourceAttemptId.Int64)
+		source.AttemptID = &attemptId
+	}
+
+	return source, nil
+}
+
+// GetProjectForks retrieves the published forks of a project with the most
+// recent fork first along with the total number of forks
+func GetProjectForks(ctx context.Context, tidb *ti.Database, postId int64, skip int, limit int) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "get-project-forks-core")
+	defer span.End()
+	callerName := "GetProjectForks"
+
+	var count int64
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		"select count(*) from post where forked_from_post = ? and published = true and deleted = false and visibility = ?",
+		postId, models.PublicVisibility,
+	).Scan(&count)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count forks: %v", err)
+	}
+
+	res, err := tidb.QueryContext(ctx, &span, &callerName,
+		"select * from post where forked_from_post = ? and published = true and deleted = false and visibility = ? order by created_at desc limit ? offset ?",
+		postId, models.PublicVisibility, limit, skip,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query forks: %v", err)
+	}
+
+	defer res.Close()
+
+	forks := make([]*models.PostFrontend, 0)
+	for res.Next() {
+		post, err := models.PostFromSQLNative(tidb, res)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load fork: %v", err)
+		}
+
+		fp, err := post.ToFrontend()
+		if err != nil {
+			return nil, fmt.Errorf("failed to format fork to frontend: %v", err)
+		}
+
+		forks = append(forks, fp)
+	}
+
+	return map[string]interface{}{"forks": forks, "count": count}, nil
+}
@@ -0,0 +1,92 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gigo-core/gigo/migrations"
+
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/db/models"
+)
+
+func TestGetProjectForks(t *testing.T) {
+	testTiDB, err := ti.CreateDatabase("gigo-dev-tidb", "4000", "mysql", "gigo-dev",
+		"gigo-dev",
+		"gigo_test_db")
+	if err != nil {
+		t.Fatal("Initialize test database failed:", err)
+	}
+
+	err = migrations.Run(testTiDB)
+	if err != nil {
+		t.Fatal("Migrate test database failed:", err)
+	}
+
+	ids := []int64{69, 70, 71}
+	for _, id := range ids {
+		post, err := models.CreatePost(id, "title", "content", "author", 420, time.Now(), time.Now(), id, 5, []int64{}, nil,
+			42069, 2, 6969, 6900, 4206969, []models.ProgrammingLanguage{models.Go}, models.PublicVisibility, []int64{},
+			nil, nil, 0, 0, nil, false, false, nil)
+		if err != nil {
+			t.Fatalf("failed to create post: %v", err)
+		}
+
+		stmts, err := post.ToSQLNative()
+		if err != nil {
+			t.Fatalf("failed to format post: %v", err)
+		}
+
+		for _, s := range stmts {
+			_, err = testTiDB.DB.Exec(s.Statement, s.Values...)
+			if err != nil {
+				t.Fatalf("failed to insert post: %v", err)
+			}
+		}
+	}
+
+	defer func() {
+		_, _ = testTiDB.DB.Exec("DELETE FROM post WHERE _id in (69, 70, 71)")
+	}()
+
+	// 70 is a published fork of 69 while 71 is still a draft
+	_, err = testTiDB.DB.Exec("update post set forked_from_post = 69, forked_from_commit = 'abc123' where _id in (70, 71)")
+	if err != nil {
+		t.Fatalf("failed to link forks: %v", err)
+	}
+	_, err = testTiDB.DB.Exec("update post set published = true where _id in (69, 70)")
+	if err != nil {
+		t.Fatalf("failed to publish posts: %v", err)
+	}
+
+	res, err := GetProjectForks(context.Background(), testTiDB, 69, 0, 10)
+	if err != nil {
+		t.Fatalf("GetProjectForks() error = %v", err)
+	}
+
+	if res["count"].(int64) != 1 {
+		t.Errorf("GetProjectForks() count = %v, want 1", res["count"])
+	}
+
+	forks := res["forks"].([]*models.PostFrontend)
+	if len(forks) != 1 || forks[0].ID != "70" {
+		t.Errorf("GetProjectForks() = %+v, want only post 70", forks)
+	}
+
+	source, err := getForkSource(context.Background(), testTiDB, 70)
+	if err != nil {
+		t.Fatalf("getForkSource() error = %v", err)
+	}
+	if source == nil || source.PostID != "69" || source.Commit != "abc123" || source.AttemptID != nil {
+		t.Errorf("getForkSource() = %+v, want post 69 at abc123", source)
+	}
+
+	source, err = getForkSource(context.Background(), testTiDB, 69)
+	if err != nil {
+		t.Fatalf("getForkSource() error = %v", err)
+	}
+	if source != nil {
+		t.Errorf("getForkSource() = %+v, want nil for an original project", source)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -14,7 +15,6 @@ import (
 	"time"
 
 	"github.com/go-redis/redis/v8"
-	"github.com/google/uuid"
 	"go.opentelemetry.io/otel"
 	"go.uber.org/zap"
 
@@ -26,7 +26,6 @@ import (
 	"github.com/gage-technologies/gigo-lib/git"
 	"github.com/gage-technologies/gigo-lib/logging"
 	"github.com/gage-technologies/gigo-lib/mq"
-	"github.com/gage-technologies/gigo-lib/search"
 	"github.com/gage-technologies/gigo-lib/storage"
 	utils2 "github.com/gage-technologies/gigo-lib/utils"
 	"github.com/gage-technologies/gigo-lib/workspace_config"
@@ -38,13 +37,14 @@ import (
 
 // TODO: needs testing
 
-func CreateProject(ctx context.Context, tidb *ti.Database, meili *search.MeiliSearchEngine, vcsClient *git.VCSClient,
+func CreateProject(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClient,
 	storageEngine storage.Storage, rdb redis.UniversalClient, js *mq.JetstreamClient, callingUser *models.User, name string, description string, sf *snowflake.Node,
 	languages []models.ProgrammingLanguage, challengeType models.ChallengeType, tier models.TierType, tags []*models.Tag,
 	thumbnailPath string, workspaceConfigId int64, workspaceConfigRevision int, workspaceConfigContent string,
 	workspaceConfigTitle string, workspaceConfigDescription string, workspaceConfigTags []*models.Tag,
 	workspaceConfigLangs []models.ProgrammingLanguage, visibility models.PostVisibility, createWorkspaceConfig bool,
-	workspaceSettings *models.WorkspaceSettings, evaluation *string, projectCost *int64, logger logging.Logger, exclusiveDescription *string) (map[string]interface{}, error) {
+	workspaceSettings *models.WorkspaceSettings, evaluation *string, projectCost *int64, logger logging.Logger, exclusiveDescription *string,
+	importSource *ProjectImportSource, userSession *models.UserSession) (map[string]interface{}, error) {
 
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "create-project-core")
 	callerName := "CreateProject"
@@ -76,52 +76,9 @@ func CreateProject(ctx context.Context, tidb *ti.Database, meili *search.MeiliSe
 
 	if len(workspaceConfigContent) > 0 {
 		// validate that the config is in the right format
-		var wsCfg workspace_config.GigoWorkspaceConfig
-		err := yaml.Unmarshal([]byte(workspaceConfigContent), &wsCfg)
-		if err != nil {
-			return map[string]interface{}{"message": "config is not the right format"}, err
-		}
-
-		if wsCfg.Version != 0.1 {
-			return map[string]interface{}{"message": "version must be 0.1"}, nil
-		}
-
-		if wsCfg.BaseContainer == "" {
-			return map[string]interface{}{"message": "must have a base container"}, nil
-		}
-
-		if wsCfg.WorkingDirectory == "" {
-			return map[string]interface{}{"message": "must have a working directory"}, nil
-		}
-
-		// make sure cpu cores are set
-		if wsCfg.Resources.CPU <= 0 {
-			return map[string]interface{}{"message": "must provide cpu cores"}, nil
-		}
-
-		// make sure memory is set
-		if wsCfg.Resources.Mem <= 0 {
-			return map[string]interface{}{"message": "must provide memory"}, nil
-		}
-
-		// make sure disk is set
-		if wsCfg.Resources.Disk <= 0 {
-			return map[string]interface{}{"message": "must provide disk"}, nil
-		}
-
-		// make sure no more than 6 cores are used
-		if wsCfg.Resources.CPU > 6 {
-			return map[string]interface{}{"message": "cannot use more than 6 CPU cores"}, nil
-		}
-
-		// make sure no more than 8gb of memory is used
-		if wsCfg.Resources.Mem > 8 {
-			return map[string]interface{}{"message": "cannot use more than 8 GB of RAM"}, nil
-		}
-
-		// make sure no more than 100gb of storage is used
-		if wsCfg.Resources.Disk > 100 {
-			return map[string]interface{}{"message": "cannot use more than 100 GB of disk space"}, nil
+		res, err := validateWorkspaceConfig(ctx, rdb, callingUser, workspaceConfigContent)
+		if res != nil || err != nil {
+			return res, err
 		}
 	}
 
@@ -142,6 +99,58 @@ func CreateProject(ctx context.Context, tidb *ti.Database, meili *search.MeiliSe
 		}
 	}
 
+	// load and validate the imported content before anything is created so
+	// that a bad import never leaves a partial project behind
+	var importFiles []*RepoFile
+	var importServicePassword string
+	if importSource != nil {
+		if userSession == nil {
+			return nil, fmt.Errorf("user session required to import project")
+		}
+
+		importServicePassword, err = userSession.GetServiceKey()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get service key: %v", err)
+		}
+
+		importFiles, err = LoadProjectImport(ctx, storageEngine, importSource)
+		if err != nil {
+			var importErr *ProjectImportError
+			if errors.As(err, &importErr) {
+				return map[string]interface{}{"message": importErr.Message}, nil
+			}
+			return nil, fmt.Errorf("failed to load project import: %v", err)
+		}
+
+		// an explicitly selected config takes precedence over the imported one
+		// and a default config is generated when neither exists
+		importedConfig := findImportFile(importFiles, workspaceConfigPath)
+		if len(workspaceConfigContent) == 0 {
+			if importedConfig != nil {
+				workspaceConfigContent = string(importedConfig.Content)
+				res, err := validateWorkspaceConfig(ctx, rdb, callingUser, workspaceConfigContent)
+				if res != nil || err != nil {
+					if res != nil {
+						res["message"] = fmt.Sprintf("imported %s is invalid: %v", workspaceConfigPath, res["message"])
+					}
+					return res, err
+				}
+			} else {
+				workspaceConfigContent = generateImportWorkspaceConfig(importFiles)
+			}
+		}
+
+		// write the final config into the imported content
+		if importedConfig != nil {
+			importedConfig.Content = []byte(workspaceConfigContent)
+		} else {
+			importFiles = append(importFiles, &RepoFile{
+				Path:    workspaceConfigPath,
+				Content: []byte(workspaceConfigContent),
+			})
+		}
+	}
+
 	// create repo for the project
 	repo, err := vcsClient.CreateRepo(
 		fmt.Sprintf("%d", callingUser.ID),
@@ -164,9 +173,6 @@ func CreateProject(ctx context.Context, tidb *ti.Database, meili *search.MeiliSe
 	tagIds := make([]int64, len(tags))
 	newTags := make([]interface{}, 0)
 
-	// create variable to track the id of a config template that is created
-	var configTemplateId *int64
-
 	// defer function to cleanup repo on failure
 	defer func() {
 		// skip cleanup if we succeeded
@@ -175,45 +181,51 @@ func CreateProject(ctx context.Context, tidb *ti.Database, meili *search.MeiliSe
 		}
 
 		_ = vcsClient.DeleteRepo(fmt.Sprintf("%d", callingUser.ID), fmt.Sprintf("%d", id))
-		_ = meili.DeleteDocuments("posts", id)
-		for _, tag := range newTags {
-			_ = meili.DeleteDocuments("tags", tag.(*models.TagSearch).ID)
-		}
-		if configTemplateId != nil {
-			_ = meili.DeleteDocuments("workspace_configs", *configTemplateId)
-		}
 	}()
 
-	// encode workspace config content to base64
-	workspaceConfigContentBase64 := base64.StdEncoding.EncodeToString([]byte(workspaceConfigContent))
+	if importSource != nil {
+		// push the imported content along with the workspace config
+		importName := importSource.GitURL
+		if importName == "" {
+			importName = "archive"
+		}
+		err = pushRepoFiles(ctx, vcsClient, fmt.Sprintf("%d", callingUser.ID), fmt.Sprintf("%d", id),
+			importServicePassword, importFiles, nil, fmt.Sprintf("[GIGO-INIT] import from %s", importName))
+		if err != nil {
+			return nil, fmt.Errorf("failed to push project import: %v", err)
+		}
+	} else {
+		// encode workspace config content to base64
+		workspaceConfigContentBase64 := base64.StdEncoding.EncodeToString([]byte(workspaceConfigContent))
 
-	// add workspace config to repository
-	_, gitRes, err := vcsClient.GiteaClient.CreateFile(
-		fmt.Sprintf("%d", callingUser.ID),
-		fmt.Sprintf("%d", id),
-		".gigo/workspace.yaml",
-		gitea.CreateFileOptions{
-			Content: workspaceConfigContentBase64,
-			FileOptions: gitea.FileOptions{
-				Message:    "[GIGO-INIT] workspace config",
-				BranchName: "main",
-				Author: gitea.Identity{
-					Name:  "Gigo",
-					Email: "gigo@gigo.dev",
-				},
-				Committer: gitea.Identity{
-					Name:  "Gigo",
-					Email: "gigo@gigo.dev",
+		// add workspace config to repository
+		_, gitRes, err := vcsClient.GiteaClient.CreateFile(
+			fmt.Sprintf("%d", callingUser.ID),
+			fmt.Sprintf("%d", id),
+			".gigo/workspace.yaml",
+			gitea.CreateFileOptions{
+				Content: workspaceConfigContentBase64,
+				FileOptions: gitea.FileOptions{
+					Message:    "[GIGO-INIT] workspace config",
+					BranchName: "main",
+					Author: gitea.Identity{
+						Name:  "Gigo",
+						Email: "gigo@gigo.dev",
+					},
+					Committer: gitea.Identity{
+						Name:  "Gigo",
+						Email: "gigo@gigo.dev",
+					},
 				},
 			},
-		},
-	)
-	if err != nil {
-		var buf []byte
-		if gitRes != nil {
-			buf, _ = io.ReadAll(gitRes.Body)
+		)
+		if err != nil {
+			var buf []byte
+			if gitRes != nil {
+				buf, _ = io.ReadAll(gitRes.Body)
+			}
+			return nil, fmt.Errorf("failed to create workspace config in repo: %v\n    res: %v", err, string(buf))
 		}
-		return nil, fmt.Errorf("failed to create workspace config in repo: %v\n    res: %v", err, string(buf))
 	}
 
 	if evaluation != nil {
@@ -355,17 +367,14 @@ func CreateProject(ctx context.Context, tidb *ti.Database, meili *search.MeiliSe
 			}
 		}
 
-		// perform search engine insertion
-		err = meili.AddDocuments("workspace_configs", wsCfg)
+		// queue the workspace config to be made discoverable once committed
+		err = queueSearchSync(ctx, tx, callerName, "workspace_configs", wsCfg.ID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to insert workspace config to search engine: %v", err)
+			return nil, err
 		}
 
 		// update workspace config id
 		workspaceConfigId = wsCfg.ID
-
-		// update workspace config template id so that we can cleanup if we fail
-		configTemplateId = &wsCfg.ID
 	}
 
 	if visibility == models.PrivateVisibility && callingUser.UserStatus != models.UserStatusPremium {
@@ -433,17 +442,15 @@ func CreateProject(ctx context.Context, tidb *ti.Database, meili *search.MeiliSe
 		return nil, fmt.Errorf("failed to write thumbnail to final location: %v", err)
 	}
 
-	// attempt to insert the prost into the search engine to make it discoverable
-	err = meili.AddDocuments("posts", post)
+	// queue the post and the new tags to be made discoverable once committed
+	err = queueSearchSync(ctx, tx, callerName, "posts", id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to add post to search engine: %v", err)
+		return nil, err
 	}
-
-	// conditionally attempt to insert the tags into the search engine to make it discoverable
-	if len(newTags) > 0 {
-		err = meili.AddDocuments("tags", newTags...)
+	for _, tag := range newTags {
+		err = queueSearchSync(ctx, tx, callerName, "tags", tag.(*models.TagSearch).ID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to add new tags to search engine: %v", err)
+			return nil, err
 		}
 	}
 
@@ -494,13 +501,22 @@ func CreateProject(ctx context.Context, tidb *ti.Database, meili *search.MeiliSe
 
 }
 
-func DeleteProject(ctx context.Context, tidb *ti.Database, callingUser *models.User, meili *search.MeiliSearchEngine, projectID int64, logger logging.Logger) (map[string]interface{}, error) {
+func DeleteProject(ctx context.Context, tidb *ti.Database, callingUser *models.User, projectID int64, logger logging.Logger) (map[string]interface{}, error) {
 
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "delete-project")
 	callerName := "DeleteProject"
 
 	logger.Infof("attempting to delete project with id: %d from user: %v", projectID, callingUser.UserName)
-	res, err := tidb.ExecContext(ctx, &span, &callerName, "update post set deleted = true, published = false where _id = ? and author_id = ?", projectID, callingUser.ID)
+
+	// open tx so that the post is removed from the search engine with the delete
+	tx, err := tidb.BeginTx(ctx, &span, &callerName, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tx for project deletion: %v", err)
+	}
+
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, &callerName, "update post set deleted = true, published = false where _id = ? and author_id = ?", projectID, callingUser.ID)
 	if err != nil {
 		logger.Errorf("failed to delete project: %v by updating database row: %v", projectID, err)
 		return nil, fmt.Errorf("failed to delete project by updating database row: %v", err)
@@ -517,18 +533,24 @@ func DeleteProject(ctx context.Context, tidb *ti.Database, callingUser *models.U
 		return nil, fmt.Errorf("failed to delete project by updating database row, no rows affected")
 	}
 
-	err = meili.DeleteDocuments("posts", projectID)
+	err = queueSearchSync(ctx, tx, callerName, "posts", projectID)
 	if err != nil {
 		logger.Errorf("failed to delete project: %v by updating search engine: %v", projectID, err)
 		return nil, fmt.Errorf("failed to delete project by updating search engine: %v", err)
 	}
 
+	err = tx.Commit(&callerName)
+	if err != nil {
+		logger.Errorf("failed to delete project: %v by committing tx: %v", projectID, err)
+		return nil, fmt.Errorf("failed to commit project deletion: %v", err)
+	}
+
 	logger.Infof("deleted project: %v from user: %v", projectID, callingUser.UserName)
 	return map[string]interface{}{"message": "Project has been deleted.", "project": projectID}, nil
 }
 
 func StartAttempt(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClient, js *mq.JetstreamClient, rdb redis.UniversalClient, callingUser *models.User, userSession *models.UserSession,
-	sf *snowflake.Node, postId int64, parentAttempt *int64, logger logging.Logger) (map[string]interface{}, error) {
+	sf *snowflake.Node, postId int64, parentAttempt *int64, shareToken string, logger logging.Logger) (map[string]interface{}, error) {
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "start-attempt-core")
 	callerName := "StartAttempt"
 
@@ -552,28 +574,72 @@ func StartAttempt(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClie
 	var postAuthorId int64
 	var postVisibility models.PostVisibility
 	var postType models.ChallengeType
+	var postHidden bool
 
 	// retrieve post
 	err = tidb.QueryRowContext(ctx, &span, &callerName,
-		"select _id, title, description, author_id, visibility, post_type from post where _id = ? limit 1", postId,
-	).Scan(&postId, &postTitle, &postDesc, &postAuthorId, &postVisibility, &postType)
+		"select _id, title, description, author_id, visibility, post_type, hidden from post where _id = ? and deleted = false limit 1", postId,
+	).Scan(&postId, &postTitle, &postDesc, &postAuthorId, &postVisibility, &postType, &postHidden)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("This Challenge could not be found.")
+		}
 		return nil, fmt.Errorf("failed to query for post: %v\n    query: %s\n    params: %v", err,
 			"select repo_id from post where _id = ?", []interface{}{postId})
 	}
 
+	// challenges hidden by a moderator cannot be started by anyone but their
+	// author and the moderators
+	err = checkHiddenContentAccess(ctx, tidb, callingUser, postHidden, postAuthorId)
+	if err != nil {
+		if err == ErrNotFound {
+			return nil, fmt.Errorf("This Challenge could not be found.")
+		}
+		return nil, err
+	}
+
+	// load the share link that the attempt is being started through. Share
+	// links are handed out by the author so they grant access to exclusive
+	// and premium challenges.
+	var shareLink *ShareLinkFrontend
+	if shareToken != "" {
+		shareLink, err = loadShareLink(ctx, tidb, postId, shareToken)
+		if err != nil {
+			if err == ErrNotFound {
+				return nil, fmt.Errorf("This share link is not valid for this Challenge.")
+			}
+			return nil, err
+		}
+		if shareLink.Status != ShareLinkActive {
+			return nil, fmt.Errorf("This share link is no longer valid. Ask the author of the challenge for a new link.")
+		}
+	}
+
 	// ensure that post is not Exclusive
-	if postVisibility == models.ExclusiveVisibility {
+	if postVisibility == models.ExclusiveVisibility && shareLink == nil {
 		return nil, fmt.Errorf("You can't start this attempt yet. This Challenge is an Exclusive Challenge " +
 			"and must be purchased.")
 	}
 
 	// ensure that the user is premium if this is a Premium challenge
-	if postVisibility == models.PremiumVisibility && callingUser.UserStatus != models.UserStatusPremium {
+	if postVisibility == models.PremiumVisibility && callingUser.UserStatus != models.UserStatusPremium && shareLink == nil {
 		return nil, fmt.Errorf("You can't start this attempt yet. This Challenge is a Premium Challenge and " +
 			"is only accessible to Premium users. Go tou the Account Settings page to upgrade your account.")
 	}
 
+	// private challenges can only be started by the author, the collaborators
+	// of the challenge or through a share link handed out by the author
+	if postVisibility == models.PrivateVisibility && shareLink == nil && postAuthorId != callingUser.ID {
+		isCollaborator, err := HasPostPermission(ctx, tidb, postId, callingUser.ID, CollaboratorEditor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check post permission: %v", err)
+		}
+		if !isCollaborator {
+			return nil, fmt.Errorf("You can't start this attempt. This Challenge is private and can only be " +
+				"started through a share link from its author.")
+		}
+	}
+
 	// create source repo path
 	repoOwner := fmt.Sprintf("%d", postAuthorId)
 	repoName := fmt.Sprintf("%d", postId)
@@ -581,10 +647,11 @@ func StartAttempt(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClie
 	// conditionally load parent attempt data for repo
 	if parentAttempt != nil {
 		var attemptOwner int64
+		var attemptHidden bool
 		err = tidb.QueryRowContext(ctx, &span, &callerName,
-			"select author_id from attempt where _id = ? limit 1",
+			"select author_id, hidden from attempt where _id = ? limit 1",
 			*parentAttempt,
-		).Scan(&attemptOwner)
+		).Scan(&attemptOwner, &attemptHidden)
 		if err != nil {
 			if err == sql.ErrNoRows {
 				return map[string]interface{}{
@@ -593,10 +660,27 @@ func StartAttempt(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClie
 			}
 			return nil, fmt.Errorf("failed to query for parent attempt: %v", err)
 		}
+
+		// hidden attempts cannot be used as the starting point of new attempts
+		err = checkHiddenContentAccess(ctx, tidb, callingUser, attemptHidden, attemptOwner)
+		if err != nil {
+			if err == ErrNotFound {
+				return map[string]interface{}{
+					"message": "We couldn't find that Attempt. We're sorry! We'll get hustlin' and bustlin' on fixing that!",
+				}, fmt.Errorf("parent attempt %d is hidden", *parentAttempt)
+			}
+			return nil, err
+		}
 		repoOwner = fmt.Sprintf("%d", attemptOwner)
 		repoName = fmt.Sprintf("%d", *parentAttempt)
 	}
 
+	// pin the attempt to the release it is started from
+	releaseId, releaseVersion, releaseCommit, err := resolveAttemptRelease(ctx, tidb, postId, parentAttempt)
+	if err != nil {
+		return nil, err
+	}
+
 	// create a new attempt
 	attempt, err := models.CreateAttempt(sf.Generate().Int64(), postTitle, postDesc, callingUser.UserName,
 		callingUser.ID, time.Now(), time.Now(), -1, callingUser.Tier, nil, 0, postId, 0, parentAttempt, postType)
@@ -610,17 +694,29 @@ func StartAttempt(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClie
 		return nil, fmt.Errorf("failed to get service key: %v", err)
 	}
 
-	// grant read access to challenge repository for calling user so that they can fork it
-	readAccess := gitea.AccessModeRead
-	_, err = vcsClient.GiteaClient.AddCollaborator(repoOwner, repoName, fmt.Sprintf("%d", callingUser.ID), gitea.AddCollaboratorOption{
-		Permission: &readAccess,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to grant read access to repository: %v", err)
+	// collaborators already have access to the challenge repository and must
+	// not have it replaced by the temporary read access below
+	hasRepoAccess := false
+	if parentAttempt == nil {
+		hasRepoAccess, err = HasPostPermission(ctx, tidb, postId, callingUser.ID, CollaboratorEditor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check post permission: %v", err)
+		}
 	}
 
-	// defer removal of read access
-	defer vcsClient.GiteaClient.DeleteCollaborator(repoOwner, repoName, fmt.Sprintf("%d", callingUser.ID))
+	if !hasRepoAccess {
+		// grant read access to challenge repository for calling user so that they can fork it
+		readAccess := gitea.AccessModeRead
+		_, err = vcsClient.GiteaClient.AddCollaborator(repoOwner, repoName, fmt.Sprintf("%d", callingUser.ID), gitea.AddCollaboratorOption{
+			Permission: &readAccess,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to grant read access to repository: %v", err)
+		}
+
+		// defer removal of read access
+		defer vcsClient.GiteaClient.DeleteCollaborator(repoOwner, repoName, fmt.Sprintf("%d", callingUser.ID))
+	}
 
 	// login to git client to create a token
 	userGitClient, err := vcsClient.LoginAsUser(fmt.Sprintf("%d", callingUser.ID), servicePassword)
@@ -639,9 +735,28 @@ func StartAttempt(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClie
 	}
 
 	// revoke read access to challenge repository for calling user since we have forked it
-	_, err = vcsClient.GiteaClient.DeleteCollaborator(repoOwner, repoName, fmt.Sprintf("%d", callingUser.ID))
+	if !hasRepoAccess {
+		_, err = vcsClient.GiteaClient.DeleteCollaborator(repoOwner, repoName, fmt.Sprintf("%d", callingUser.ID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to revoke read access to repository: %v", err)
+		}
+	}
+
+	// the fork is copied from the head of the challenge so it is moved back to
+	// the release the attempt is pinned to. Attempts of attempts keep the work
+	// of the parent attempt.
+	if parentAttempt == nil && releaseCommit.Valid && releaseCommit.String != "" {
+		err = resetForkToRelease(ctx, vcsClient, fmt.Sprintf("%d", callingUser.ID), newRepoId, servicePassword, releaseCommit.String)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// record the project commit the attempt starts from for comparisons
+	forkCommit, err := resolveAttemptForkCommit(ctx, tidb, vcsClient, fmt.Sprintf("%d", callingUser.ID), newRepoId, repo.DefaultBranch,
+		parentAttempt, releaseCommit)
 	if err != nil {
-		return nil, fmt.Errorf("failed to revoke read access to repository: %v", err)
+		return nil, err
 	}
 
 	// update attempt with new repo id
@@ -670,6 +785,23 @@ func StartAttempt(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClie
 		}
 	}
 
+	// record the release that the attempt is pinned to and the commit it was forked from
+	_, err = tx.ExecContext(ctx, &callerName,
+		"update attempt set release_id = ?, release_version = ?, release_commit = ?, fork_commit = ? where _id = ?",
+		releaseId, releaseVersion, releaseCommit, forkCommit, attempt.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pin attempt release: %v", err)
+	}
+
+	// record the attempt on the share link that it was started through
+	if shareLink != nil {
+		err = useShareLink(ctx, tx, callerName, shareLink, attempt.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// increment tag column usage_count in database
 	_, err = tx.ExecContext(ctx, &callerName, "update post set attempts = attempts + 1 where _id = ?", postId)
 	if err != nil {
@@ -730,16 +862,30 @@ func StartEAttempt(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSCli
 	var postAuthorId int64
 	var postVisibility models.PostVisibility
 	var postType models.ChallengeType
+	var postHidden bool
 
 	// retrieve post
 	err = tidb.QueryRowContext(ctx, &span, &callerName,
-		"select _id, title, description, author_id, visibility, post_type from post where _id = ? limit 1", postId,
-	).Scan(&postId, &postTitle, &postDesc, &postAuthorId, &postVisibility, &postType)
+		"select _id, title, description, author_id, visibility, post_type, hidden from post where _id = ? and deleted = false limit 1", postId,
+	).Scan(&postId, &postTitle, &postDesc, &postAuthorId, &postVisibility, &postType, &postHidden)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("This Challenge could not be found.")
+		}
 		return nil, fmt.Errorf("failed to query for post: %v\n    query: %s\n    params: %v", err,
 			"select repo_id from post where _id = ?", []interface{}{postId})
 	}
 
+	// challenges hidden by a moderator cannot be started by anyone but their
+	// author and the moderators
+	err = checkHiddenContentAccess(ctx, tidb, callingUser, postHidden, postAuthorId)
+	if err != nil {
+		if err == ErrNotFound {
+			return nil, fmt.Errorf("This Challenge could not be found.")
+		}
+		return nil, err
+	}
+
 	// ensure that post is not Exclusive
 	if postVisibility == models.ExclusiveVisibility {
 		return nil, fmt.Errorf("You can't start this attempt yet. This Challenge is an Exclusive Challenge " +
@@ -752,6 +898,12 @@ func StartEAttempt(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSCli
 			"is only accessible to Premium users. Go tou the Account Settings page to upgrade your account.")
 	}
 
+	// ephemeral users cannot be authors or collaborators of private challenges
+	if postVisibility == models.PrivateVisibility {
+		return nil, fmt.Errorf("You can't start this attempt. This Challenge is private and can only be " +
+			"started through a share link from its author.")
+	}
+
 	// create source repo path
 	repoOwner := fmt.Sprintf("%d", postAuthorId)
 	repoName := fmt.Sprintf("%d", postId)
@@ -759,10 +911,11 @@ func StartEAttempt(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSCli
 	// conditionally load parent attempt data for repo
 	if parentAttempt != nil {
 		var attemptOwner int64
+		var attemptHidden bool
 		err = tidb.QueryRowContext(ctx, &span, &callerName,
-			"select author_id from attempt where _id = ? limit 1",
+			"select author_id, hidden from attempt where _id = ? limit 1",
 			*parentAttempt,
-		).Scan(&attemptOwner)
+		).Scan(&attemptOwner, &attemptHidden)
 		if err != nil {
 			if err == sql.ErrNoRows {
 				return map[string]interface{}{
@@ -771,10 +924,27 @@ func StartEAttempt(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSCli
 			}
 			return nil, fmt.Errorf("failed to query for parent attempt: %v", err)
 		}
+
+		// hidden attempts cannot be used as the starting point of new attempts
+		err = checkHiddenContentAccess(ctx, tidb, callingUser, attemptHidden, attemptOwner)
+		if err != nil {
+			if err == ErrNotFound {
+				return map[string]interface{}{
+					"message": "We couldn't find that Attempt. We're sorry! We'll get hustlin' and bustlin' on fixing that!",
+				}, fmt.Errorf("parent attempt %d is hidden", *parentAttempt)
+			}
+			return nil, err
+		}
 		repoOwner = fmt.Sprintf("%d", attemptOwner)
 		repoName = fmt.Sprintf("%d", *parentAttempt)
 	}
 
+	// pin the attempt to the release it is started from
+	releaseId, releaseVersion, releaseCommit, err := resolveAttemptRelease(ctx, tidb, postId, parentAttempt)
+	if err != nil {
+		return nil, err
+	}
+
 	// create a new attempt
 	attempt, err := models.CreateAttempt(sf.Generate().Int64(), postTitle, postDesc, callingUser.UserName,
 		callingUser.ID, time.Now(), time.Now(), -1, callingUser.Tier, nil, 0, postId, 0, parentAttempt, postType)
@@ -788,17 +958,29 @@ func StartEAttempt(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSCli
 		return nil, fmt.Errorf("failed to get service key: %v", err)
 	}
 
-	// grant read access to challenge repository for calling user so that they can fork it
-	readAccess := gitea.AccessModeRead
-	_, err = vcsClient.GiteaClient.AddCollaborator(repoOwner, repoName, fmt.Sprintf("%d", callingUser.ID), gitea.AddCollaboratorOption{
-		Permission: &readAccess,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to grant read access to repository: %v -- repoOwner: %v, repoName: %v, collaborator: %v", err, repoOwner, repoName, callingUser.ID)
+	// collaborators already have access to the challenge repository and must
+	// not have it replaced by the temporary read access below
+	hasRepoAccess := false
+	if parentAttempt == nil {
+		hasRepoAccess, err = HasPostPermission(ctx, tidb, postId, callingUser.ID, CollaboratorEditor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check post permission: %v", err)
+		}
 	}
 
-	// defer removal of read access
-	defer vcsClient.GiteaClient.DeleteCollaborator(repoOwner, repoName, fmt.Sprintf("%d", callingUser.ID))
+	if !hasRepoAccess {
+		// grant read access to challenge repository for calling user so that they can fork it
+		readAccess := gitea.AccessModeRead
+		_, err = vcsClient.GiteaClient.AddCollaborator(repoOwner, repoName, fmt.Sprintf("%d", callingUser.ID), gitea.AddCollaboratorOption{
+			Permission: &readAccess,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to grant read access to repository: %v -- repoOwner: %v, repoName: %v, collaborator: %v", err, repoOwner, repoName, callingUser.ID)
+		}
+
+		// defer removal of read access
+		defer vcsClient.GiteaClient.DeleteCollaborator(repoOwner, repoName, fmt.Sprintf("%d", callingUser.ID))
+	}
 
 	// login to git client to create a token
 	userGitClient, err := vcsClient.LoginAsUser(fmt.Sprintf("%d", callingUser.ID), servicePassword)
@@ -817,9 +999,28 @@ func StartEAttempt(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSCli
 	}
 
 	// revoke read access to challenge repository for calling user since we have forked it
-	_, err = vcsClient.GiteaClient.DeleteCollaborator(repoOwner, repoName, fmt.Sprintf("%d", callingUser.ID))
+	if !hasRepoAccess {
+		_, err = vcsClient.GiteaClient.DeleteCollaborator(repoOwner, repoName, fmt.Sprintf("%d", callingUser.ID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to revoke read access to repository: %v", err)
+		}
+	}
+
+	// the fork is copied from the head of the challenge so it is moved back to
+	// the release the attempt is pinned to. Attempts of attempts keep the work
+	// of the parent attempt.
+	if parentAttempt == nil && releaseCommit.Valid && releaseCommit.String != "" {
+		err = resetForkToRelease(ctx, vcsClient, fmt.Sprintf("%d", callingUser.ID), newRepoId, servicePassword, releaseCommit.String)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// record the project commit the attempt starts from for comparisons
+	forkCommit, err := resolveAttemptForkCommit(ctx, tidb, vcsClient, fmt.Sprintf("%d", callingUser.ID), newRepoId, repo.DefaultBranch,
+		parentAttempt, releaseCommit)
 	if err != nil {
-		return nil, fmt.Errorf("failed to revoke read access to repository: %v", err)
+		return nil, err
 	}
 
 	// update attempt with new repo id
@@ -848,6 +1049,15 @@ func StartEAttempt(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSCli
 		}
 	}
 
+	// record the release that the attempt is pinned to and the commit it was forked from
+	_, err = tx.ExecContext(ctx, &callerName,
+		"update attempt set release_id = ?, release_version = ?, release_commit = ?, fork_commit = ? where _id = ?",
+		releaseId, releaseVersion, releaseCommit, forkCommit, attempt.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pin attempt release: %v", err)
+	}
+
 	// increment tag column usage_count in database
 	_, err = tx.ExecContext(ctx, &callerName, "update post set attempts = attempts + 1 where _id = ?", postId)
 	if err != nil {
@@ -869,7 +1079,7 @@ func StartEAttempt(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSCli
 	return map[string]interface{}{"message": "Attempt created successfully.", "attempt": attempt.ToFrontend()}, nil
 }
 
-func PublishProject(ctx context.Context, tidb *ti.Database, meili *search.MeiliSearchEngine, postId int64) (map[string]interface{}, error) {
+func PublishProject(ctx context.Context, tidb *ti.Database, postId int64) (map[string]interface{}, error) {
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "publish-project-core")
 	callerName := "PublishProject"
 
@@ -887,10 +1097,10 @@ func PublishProject(ctx context.Context, tidb *ti.Database, meili *search.MeiliS
 		return nil, fmt.Errorf("failed to update post: %v", err)
 	}
 
-	// update post in meilisearch
-	err = meili.UpdateDocuments("posts", map[string]interface{}{"_id": postId, "published": true})
+	// queue the published post to be synced to meilisearch
+	err = queueSearchSync(ctx, tx, callerName, "posts", postId)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update post in meilisearch: %v", err)
+		return nil, err
 	}
 
 	// commit tx
@@ -902,61 +1112,22 @@ func PublishProject(ctx context.Context, tidb *ti.Database, meili *search.MeiliS
 	return map[string]interface{}{"message": "Post published successfully.", "post": fmt.Sprintf("%d", postId)}, nil
 }
 
-func EditConfig(ctx context.Context, vcsClient *git.VCSClient, callingUser *models.User, repoId int64, content string, commit string) (map[string]interface{}, error) {
+func EditConfig(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClient, rdb redis.UniversalClient, callingUser *models.User, repoId int64, content string, commit string) (map[string]interface{}, error) {
 	_, span := otel.Tracer("gigo-core").Start(ctx, "edit-config-core")
 	defer span.End()
 
 	// validate that the config is in the right format
+	res, err := validateWorkspaceConfig(ctx, rdb, callingUser, content)
+	if res != nil || err != nil {
+		return res, err
+	}
+
 	var wsCfg workspace_config.GigoWorkspaceConfig
-	err := yaml.Unmarshal([]byte(content), &wsCfg)
+	err = yaml.Unmarshal([]byte(content), &wsCfg)
 	if err != nil {
 		return map[string]interface{}{"message": "config is not the right format"}, err
 	}
 
-	if wsCfg.Version != 0.1 {
-		return map[string]interface{}{"message": "version must be 0.1"}, fmt.Errorf("version must be 0.1")
-	}
-
-	if wsCfg.BaseContainer == "" {
-		return map[string]interface{}{"message": "must have a base container"}, fmt.Errorf("must have a base container")
-	}
-
-	if wsCfg.WorkingDirectory == "" {
-		return map[string]interface{}{"message": "must have a working directory"}, fmt.Errorf("must have a working directory")
-	}
-
-	// make sure cpu cores are set
-	if wsCfg.Resources.CPU <= 0 {
-		return map[string]interface{}{"message": "must provide cpu cores"}, fmt.Errorf("must provide cpu cores")
-	}
-
-	// make sure memory is set
-	if wsCfg.Resources.Mem <= 0 {
-		return map[string]interface{}{"message": "must provide memory"}, fmt.Errorf("must provide memory")
-	}
-
-	// make sure disk is set
-	if wsCfg.Resources.Disk <= 0 {
-		return map[string]interface{}{"message": "must provide disk"}, fmt.Errorf("must provide disk")
-	}
-
-	// make sure no more than 6 cores are used
-	if wsCfg.Resources.CPU > 6 {
-		return map[string]interface{}{"message": "cannot use more than 6 CPU cores"}, fmt.Errorf("cannot use more than 6 CPU cores")
-	}
-
-	// make sure no more than 8gb of memory is used
-	if wsCfg.Resources.Mem > 8 {
-		return map[string]interface{}{"message": "cannot use more than 8 GB of RAM"}, fmt.Errorf("cannot use more than 8 GB of RAM")
-	}
-
-	// make sure no more than 100gb of storage is used
-	if wsCfg.Resources.Disk > 100 {
-		return map[string]interface{}{"message": "cannot use more than 100 GB of disk space"}, fmt.Errorf("cannot use more than 100 GB of disk space")
-	}
-
-	// TODO make sure docker container is valid
-
 	// get repository name from repo id
 	repo, _, err := vcsClient.GiteaClient.GetRepoByID(repoId)
 	if err != nil {
@@ -970,12 +1141,20 @@ func EditConfig(ctx context.Context, vcsClient *git.VCSClient, callingUser *mode
 	}
 
 	if repoOwnerId != callingUser.ID {
-		return map[string]interface{}{"message": "you do not have permission to edit this repo"}, fmt.Errorf("you do not have permission to edit this repo")
+		// collaborators on the post that owns this repo are also permitted to edit the config
+		hasPermission, err := hasRepoCollaboratorPermission(ctx, tidb, repoId, callingUser.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if !hasPermission {
+			return map[string]interface{}{"message": "you do not have permission to edit this repo"}, fmt.Errorf("you do not have permission to edit this repo")
+		}
 	}
 
 	// retrieve file from existing repo head
 	fileMeta, _, err := vcsClient.GiteaClient.GetContents(
-		fmt.Sprintf("%d", callingUser.ID),
+		repo.Owner.UserName,
 		repo.Name,
 		"main",
 		".gigo/workspace.yaml",
@@ -984,7 +1163,7 @@ func EditConfig(ctx context.Context, vcsClient *git.VCSClient, callingUser *mode
 		return nil, fmt.Errorf("failed to retrieve file from repo %d: %v", repoId, err)
 	}
 
-	res, err := GetConfig(ctx, vcsClient, callingUser, repoId, commit)
+	res, err = GetConfig(ctx, tidb, vcsClient, callingUser, repoId, commit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve existing config %d: %v", repoId, err)
 	}
@@ -1003,7 +1182,7 @@ func EditConfig(ctx context.Context, vcsClient *git.VCSClient, callingUser *mode
 	workspaceConfigContentBase64 := base64.StdEncoding.EncodeToString([]byte(content))
 
 	_, gitRes, err := vcsClient.GiteaClient.UpdateFile(
-		fmt.Sprintf("%d", callingUser.ID),
+		repo.Owner.UserName,
 		repo.Name,
 		".gigo/workspace.yaml",
 		gitea.UpdateFileOptions{
@@ -1036,7 +1215,7 @@ func ConfirmEditConfig(ctx context.Context, tidb *ti.Database, js *mq.JetstreamC
 
 	callerName := "ConfirmEditConfig"
 
-	queryRes, err := tidb.QueryContext(ctx, &span, &callerName, "select _id, code_source_type, state from workspaces where code_source_id = ? and owner_id = ?", projectID, callingUser.ID)
+	queryRes, err := tidb.QueryContext(ctx, &span, &callerName, "select _id, code_source_type, state from workspaces where code_source_id = ? and owner_id = ? and not exists (select 1 from attempt_grading g where g.workspace_id = workspaces._id)", projectID, callingUser.ID)
 	if err != nil {
 		logger.Errorf("failed to retrieve workspace: %v, err: %v", projectID, zap.Error(err))
 		return map[string]interface{}{"message": "failed to retrieve workspace"}, fmt.Errorf("failed to retrieve workspace: %v", err)
@@ -1098,7 +1277,7 @@ func ConfirmEditConfig(ctx context.Context, tidb *ti.Database, js *mq.JetstreamC
 	return map[string]interface{}{"message": "config edit confirmed successfully"}, nil
 }
 
-func GetConfig(ctx context.Context, vcsClient *git.VCSClient, callingUser *models.User, repo int64, commit string) (map[string]interface{}, error) {
+func GetConfig(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClient, callingUser *models.User, repo int64, commit string) (map[string]interface{}, error) {
 
 	_, span := otel.Tracer("gigo-core").Start(ctx, "get-config-core")
 	defer span.End()
@@ -1109,9 +1288,21 @@ func GetConfig(ctx context.Context, vcsClient *git.VCSClient, callingUser *model
 		fmt.Errorf("failed to locate repo %d: %v", repo, err)
 	}
 
+	// only the owner of the repo and collaborators on the post that owns it can read the config
+	if repository.Owner.UserName != fmt.Sprintf("%d", callingUser.ID) {
+		hasPermission, err := hasRepoCollaboratorPermission(ctx, tidb, repo, callingUser.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if !hasPermission {
+			return map[string]interface{}{"message": "you do not have permission to view this repo"}, fmt.Errorf("you do not have permission to view this repo")
+		}
+	}
+
 	// retrieve the gigo workspace config from the passed branch
 	configBytes, gitRes, err := vcsClient.GiteaClient.GetFile(
-		fmt.Sprintf("%d", callingUser.ID),
+		repository.Owner.UserName,
 		repository.Name,
 		commit,
 		".gigo/workspace.yaml",
@@ -1221,7 +1412,7 @@ func CloseAttempt(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClie
 	return map[string]interface{}{"message": "Attempt Closed Successfully"}, nil
 }
 
-func MarkSuccess(ctx context.Context, tidb *ti.Database, js *mq.JetstreamClient, rdb redis.UniversalClient, sf *snowflake.Node, attemptId int64, logger logging.Logger, callingUser *models.User) (map[string]interface{}, error) {
+func MarkSuccess(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClient, js *mq.JetstreamClient, rdb redis.UniversalClient, sf *snowflake.Node, attemptId int64, logger logging.Logger, callingUser *models.User) (map[string]interface{}, error) {
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "mark-success-core")
 	callerName := "MarkSuccess"
 
@@ -1245,68 +1436,133 @@ func MarkSuccess(ctx context.Context, tidb *ti.Database, js *mq.JetstreamClient,
 	// close
 	_ = res.Close()
 
+	// only the owner of the attempt can mark it as a success
+	if attempt.AuthorID != callingUser.ID {
+		return map[string]interface{}{"message": "attempt not found"}, ErrNotFound
+	}
+
 	// make sure the attempt is closed before marking it as success
 	if attempt.Closed != true {
 		return map[string]interface{}{"message": "attempt not closed"}, fmt.Errorf("attempt not closed: %v", err)
 	}
 
+	// challenges that declare tests can only be completed by passing them
+	var releaseCommit sql.NullString
+	err = tidb.QueryRowContext(ctx, &span, &callerName, "select release_commit from attempt where _id = ?", attemptId).Scan(&releaseCommit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attempt release: %v", err)
+	}
+
+	gradingCfg, _, err := challengeGradingConfig(ctx, tidb, vcsClient, attempt.PostID, releaseCommit)
+	if err != nil && err != ErrNotFound {
+		return nil, fmt.Errorf("failed to load challenge grading config: %v", err)
+	}
+	if gradingCfg != nil {
+		return map[string]interface{}{"message": "This challenge is graded automatically. Submit your attempt for grading instead."},
+			fmt.Errorf("attempt %d must be graded", attemptId)
+	}
+
 	// mark the selected attempt as a successful attempt to the challenge
-	_, err = tidb.ExecContext(ctx, &span, &callerName, "update attempt set success = true where _id = ?", attemptId)
+	updateRes, err := tidb.ExecContext(ctx, &span, &callerName, "update attempt set success = true, success_at = ? where _id = ? and success = false", time.Now(), attemptId)
 	if err != nil {
 		return map[string]interface{}{"message": "failed to close attempt"}, fmt.Errorf("failed to close attempt: %v", err)
 	}
 
+	// xp is only awarded the first time the attempt is marked as a success
+	rows, err := updateRes.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve updated attempt count: %v", err)
+	}
+	if rows == 0 {
+		return map[string]interface{}{"message": "Attempt Marked as a Success"}, nil
+	}
+
 	// add xp to user for logging in
 	xpRes, err := AddXP(ctx, tidb, js, rdb, sf, attempt.AuthorID, "successful", &attempt.Tier, nil, logger, callingUser)
 	if err != nil {
 		return map[string]interface{}{"message": "Attempt Marked as a Success"}, fmt.Errorf("failed to add xp to user: %v", err)
 	}
 
-	return map[string]interface{}{"message": "Attempt Marked as a Success", "xp": xpRes}, nil
+	// complete any learning paths that this attempt finished
+	completedPaths, err := CheckLearningPathCompletion(ctx, tidb, js, rdb, sf, attempt.AuthorID, attempt.PostID, logger, callingUser)
+	if err != nil {
+		return map[string]interface{}{"message": "Attempt Marked as a Success", "xp": xpRes}, fmt.Errorf("failed to check learning path completion: %v", err)
+	}
+
+	return map[string]interface{}{"message": "Attempt Marked as a Success", "xp": xpRes, "completed_paths": completedPaths}, nil
 }
 
-func ShareLink(ctx context.Context, tidb *ti.Database, postId int64, callingUser *models.User) (map[string]interface{}, error) {
+// ShareLink returns the token of the most recent active share link of the post
+// creating a default link if the post has none
+func ShareLink(ctx context.Context, tidb *ti.Database, sf *snowflake.Node, postId int64, callingUser *models.User) (map[string]interface{}, error) {
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "post-share-link")
 	defer span.End()
 	callerName := "postShareLink"
 
-	var shareHash sql.NullString
-	err := tidb.QueryRowContext(ctx, &span, &callerName, "select bin_to_uuid(share_hash) from post where _id = ? and author_id = ?", postId, callingUser.ID).Scan(&shareHash)
+	ok, err := HasPostPermission(ctx, tidb, postId, callingUser.ID, CollaboratorMaintainer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check post permission: %v", err)
+	}
+	if !ok {
+		return map[string]interface{}{"message": "you do not have permission to manage share links for this project"},
+			fmt.Errorf("user %d cannot manage share links for post %d: %w", callingUser.ID, postId, ErrForbidden)
+	}
+
+	rows, err := tidb.QueryContext(ctx, &span, &callerName,
+		"select "+shareLinkColumns+" from post_share_link where post_id = ? and revoked = false order by created_at desc", postId,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check for share hash for post: %v", err)
+		return nil, fmt.Errorf("failed to query share links: %v", err)
 	}
+	defer rows.Close()
 
-	if !shareHash.Valid {
-		newShareHash := uuid.New()
-		_, err = tidb.ExecContext(ctx, &span, &callerName, "update post set share_hash = uuid_to_bin(?) where _id = ? and author_id = ?", newShareHash, postId, callingUser.ID)
+	for rows.Next() {
+		link, err := scanShareLink(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to update share hash for post: %v", err)
+			return nil, fmt.Errorf("failed to scan share link: %v", err)
 		}
-		return map[string]interface{}{"message": newShareHash.String()}, nil
+		if link.Status == ShareLinkActive {
+			return map[string]interface{}{"message": link.Token}, nil
+		}
+	}
+
+	res, err := CreateShareLink(ctx, tidb, sf, callingUser, postId, "Default", nil, nil)
+	if err != nil {
+		return nil, err
 	}
 
-	return map[string]interface{}{"message": shareHash.String}, nil
+	return map[string]interface{}{"message": res["link"].(*ShareLinkFrontend).Token}, nil
 }
 
+// VerifyLink checks that a share link can still be used to access the post and
+// records a view on the link when it can
 func VerifyLink(ctx context.Context, tidb *ti.Database, postId int64, shareLink string) (map[string]interface{}, error) {
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "verify-share-link")
 	defer span.End()
 	callerName := "verifyShareLink"
 
-	// Check if shareLink is a valid UUID
-	_, err := uuid.Parse(shareLink)
+	link, err := loadShareLink(ctx, tidb, postId, shareLink)
 	if err != nil {
-		return map[string]interface{}{"message": "invalid share link"}, nil
+		if err == ErrNotFound {
+			return map[string]interface{}{"message": "invalid share link"}, nil
+		}
+		return nil, fmt.Errorf("failed to verify share link: %v", err)
+	}
+
+	if link.Status != ShareLinkActive {
+		return map[string]interface{}{"message": "invalid share link", "reason": link.Status}, nil
 	}
 
-	var verified bool
-	err = tidb.QueryRowContext(ctx, &span, &callerName, "select exists(select 1 from post where _id = ? and share_hash = uuid_to_bin(?))", postId, shareLink).Scan(&verified)
+	_, err = tidb.ExecContext(ctx, &span, &callerName,
+		"update post_share_link set views = views + 1, last_used_at = ? where _id = ?", time.Now(), link.ID,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to verify share link: %v", err)
+		return nil, fmt.Errorf("failed to record share link view: %v", err)
 	}
 
-	if !verified {
-		return map[string]interface{}{"message": "invalid share link"}, nil
+	err = recordShareLinkView(ctx, tidb, postId)
+	if err != nil {
+		return nil, err
 	}
 
 	return map[string]interface{}{"message": "valid share link"}, nil
@@ -4,14 +4,12 @@ import (
 	"context"
 	"fmt"
 	"github.com/bwmarrin/snowflake"
-	"github.com/gage-technologies/gigo-lib/config"
 	config2 "github.com/gage-technologies/gigo-lib/config"
 	ti "github.com/gage-technologies/gigo-lib/db"
 	"github.com/gage-technologies/gigo-lib/db/models"
 	"github.com/gage-technologies/gigo-lib/git"
 	"github.com/gage-technologies/gigo-lib/logging"
 	"github.com/gage-technologies/gigo-lib/mq"
-	"github.com/gage-technologies/gigo-lib/search"
 	"github.com/go-redis/redis/v8"
 	"testing"
 	"time"
@@ -44,30 +42,10 @@ func TestPublishProject(t *testing.T) {
 		}
 	}
 
-	// Create a MeiliSearch client
-	cfg := config.MeiliConfig{
-		Host:  "http://gigo-dev-meili:7700",
-		Token: "gigo-dev",
-		Indices: map[string]config.MeiliIndexConfig{
-			"posts": {
-				Name:                 "posts",
-				PrimaryKey:           "_id",
-				SearchableAttributes: []string{"title", "description", "languages", "tags"},
-				FilterableAttributes: []string{"languages", "tags"},
-				SortableAttributes:   []string{},
-			},
-		},
-	}
-
-	meili, err := search.CreateMeiliSearchEngine(cfg)
-	if err != nil {
-		t.Fatalf("\n%s failed\n    Error: %v", t.Name(), err)
-	}
-
 	// Test the PublishProject function
 	postID := int64(1)
 
-	response, err := PublishProject(context.Background(), testTiDB, meili, postID)
+	response, err := PublishProject(context.Background(), testTiDB, postID)
 	if err != nil {
 		t.Errorf("PublishProject() error = %v", err)
 		return
@@ -81,9 +59,19 @@ func TestPublishProject(t *testing.T) {
 		t.Errorf("PublishProject() unexpected post ID: %v", response["post"])
 	}
 
+	// the published post must be queued for the search engine
+	var queued bool
+	err = testTiDB.DB.QueryRow("select exists(select 1 from search_outbox where search_index = 'posts' and document_id = 1)").Scan(&queued)
+	if err != nil {
+		t.Errorf("PublishProject() failed to query search outbox: %v", err)
+	} else if !queued {
+		t.Errorf("PublishProject() post was not queued for the search engine")
+	}
+
 	// Deferred removal of inserted data
 	defer func() {
 		_, _ = testTiDB.DB.Exec("DELETE FROM post WHERE _id = 1")
+		_, _ = testTiDB.DB.Exec("DELETE FROM search_outbox WHERE search_index = 'posts' AND document_id = 1")
 	}()
 }
 
@@ -93,6 +81,13 @@ func TestEditConfig(t *testing.T) {
 		ID: 1,
 	}
 
+	testTiDB, err := ti.CreateDatabase("gigo-dev-tidb", "4000", "mysql", "gigo-dev",
+		"gigo-dev",
+		"gigo_test_db")
+	if err != nil {
+		t.Fatal("Initialize test database failed:", err)
+	}
+
 	vcsClient, err := git.CreateVCSClient("http://gigo-dev-git:3000", "gigo-dev", "gigo-dev", true)
 	if err != nil {
 		t.Fatal(fmt.Sprintf("failed to create vsc client, %v", err))
@@ -111,7 +106,7 @@ resources:
   disk: 1
 `
 
-	response, err := EditConfig(context.Background(), vcsClient, callingUser, repoId, content, "commit message")
+	response, err := EditConfig(context.Background(), testTiDB, vcsClient, redis.NewClient(&redis.Options{}), callingUser, repoId, content, "commit message")
 	if err != nil {
 		t.Errorf("EditConfig() error = %v", err)
 		return
@@ -134,6 +129,13 @@ func TestGetConfig(t *testing.T) {
 		ID: 1,
 	}
 
+	testTiDB, err := ti.CreateDatabase("gigo-dev-tidb", "4000", "mysql", "gigo-dev",
+		"gigo-dev",
+		"gigo_test_db")
+	if err != nil {
+		t.Fatal("Initialize test database failed:", err)
+	}
+
 	// Create a VCS client
 	vcsClient, err := git.CreateVCSClient("http://gigo-dev-git:3000", "gigo-dev", "gigo-dev", true)
 	if err != nil {
@@ -146,7 +148,7 @@ func TestGetConfig(t *testing.T) {
 	// Set the commit to test. You can use "main" or the specific commit hash.
 	commit := "main"
 
-	response, err := GetConfig(context.Background(), vcsClient, callingUser, repoId, commit)
+	response, err := GetConfig(context.Background(), testTiDB, vcsClient, callingUser, repoId, commit)
 	if err != nil {
 		t.Errorf("GetConfig() error = %v", err)
 		return
@@ -309,7 +311,12 @@ func TestMarkSuccess(t *testing.T) {
 	// create local client
 	rdb = redis.NewClient(&redis.Options{})
 
-	response, err := MarkSuccess(context.Background(), tidb, js, rdb, sf, attempt.ID, logger, callingUser)
+	vcsClient, err := git.CreateVCSClient("http://gigo-dev-git:3000", "gigo-dev", "gigo-dev", true)
+	if err != nil {
+		t.Fatal(fmt.Sprintf("failed to create vsc client, %v", err))
+	}
+
+	response, err := MarkSuccess(context.Background(), tidb, vcsClient, js, rdb, sf, attempt.ID, logger, callingUser)
 	if err != nil {
 		t.Errorf("MarkSuccess() error = %v", err)
 		return
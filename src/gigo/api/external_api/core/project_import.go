@@ -0,0 +1,568 @@
+package core
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	nethttp "net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gage-technologies/gigo-lib/storage"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	gitclient "github.com/go-git/go-git/v5/plumbing/transport/client"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	// maximum number of files that can be imported into a project
+	importMaxFiles = 5000
+	// maximum size of a single imported file
+	importMaxFileSize = 25 * 1024 * 1024
+	// maximum size of all imported files combined
+	importMaxTotalSize = 250 * 1024 * 1024
+	// maximum number of bytes transferred while cloning a remote repository
+	importMaxCloneSize = importMaxTotalSize
+	// maximum time permitted to clone a remote repository
+	importCloneTimeout = 2 * time.Minute
+	// path of the workspace config within a project repository
+	workspaceConfigPath = ".gigo/workspace.yaml"
+)
+
+// ProjectImportSource describes where the initial content of a project
+// should be imported from. Exactly one of GitURL or ArchivePath must be set.
+type ProjectImportSource struct {
+	// GitURL is the http(s) url of a public git repository
+	GitURL string
+	// GitRef is an optional branch to clone from GitURL
+	GitRef string
+	// ArchivePath is the storage path of an uploaded zip or tar archive
+	ArchivePath string
+}
+
+// ProjectImportError is returned when the import source is invalid. The
+// message is safe to return to the caller.
+type ProjectImportError struct {
+	Message string
+}
+
+func (e *ProjectImportError) Error() string {
+	return e.Message
+}
+
+func importErrorf(format string, args ...interface{}) error {
+	return &ProjectImportError{Message: fmt.Sprintf(format, args...)}
+}
+
+// projectImportCollector accumulates files from an import source while
+// enforcing the import limits and path rules
+type projectImportCollector struct {
+	files     map[string]*RepoFile
+	totalSize int64
+}
+
+func newProjectImportCollector() *projectImportCollector {
+	return &projectImportCollector{files: make(map[string]*RepoFile)}
+}
+
+// add validates and stores a single file from the import source
+func (c *projectImportCollector) add(name string, size int64, executable bool, r io.Reader) error {
+	// normalize the path and reject anything that escapes the repository root
+	clean := path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	if strings.HasPrefix(clean, "/") || clean == ".." || strings.HasPrefix(clean, "../") {
+		return importErrorf("archive contains an invalid path: %s", name)
+	}
+
+	// skip git metadata since the repository history is not imported
+	if clean == ".git" || strings.HasPrefix(clean, ".git/") || strings.Contains(clean, "/.git/") {
+		return nil
+	}
+
+	if len(c.files) >= importMaxFiles {
+		return importErrorf("imports cannot contain more than %d files", importMaxFiles)
+	}
+
+	if size > importMaxFileSize {
+		return importErrorf("%s is larger than the %d MB file limit", clean, importMaxFileSize/1024/1024)
+	}
+
+	// read the content with a hard limit in case the declared size was wrong
+	content, err := io.ReadAll(io.LimitReader(r, importMaxFileSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to read import file %s: %v", clean, err)
+	}
+	if len(content) > importMaxFileSize {
+		return importErrorf("%s is larger than the %d MB file limit", clean, importMaxFileSize/1024/1024)
+	}
+
+	c.totalSize += int64(len(content))
+	if c.totalSize > importMaxTotalSize {
+		return importErrorf("imports cannot be larger than %d MB", importMaxTotalSize/1024/1024)
+	}
+
+	c.files[clean] = &RepoFile{
+		Path:       clean,
+		Executable: executable,
+		Content:    content,
+	}
+
+	return nil
+}
+
+// result returns the collected files sorted by path with any single
+// top-level directory shared by every file stripped from the paths
+func (c *projectImportCollector) result() ([]*RepoFile, error) {
+	if len(c.files) == 0 {
+		return nil, importErrorf("import does not contain any files")
+	}
+
+	// archives downloaded from git hosts wrap the repository in a single
+	// directory so we strip it to place the content at the repository root
+	prefix := ""
+	for p := range c.files {
+		idx := strings.Index(p, "/")
+		if idx == -1 {
+			prefix = ""
+			break
+		}
+		if prefix == "" {
+			prefix = p[:idx+1]
+		}
+		if !strings.HasPrefix(p, prefix) {
+			prefix = ""
+			break
+		}
+	}
+
+	files := make([]*RepoFile, 0, len(c.files))
+	for _, f := range c.files {
+		f.Path = strings.TrimPrefix(f.Path, prefix)
+		files = append(files, f)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+
+	return files, nil
+}
+
+// readImportArchive loads the files from a zip, tar or gzipped tar archive
+func readImportArchive(buf []byte) ([]*RepoFile, error) {
+	collector := newProjectImportCollector()
+
+	switch {
+	case bytes.HasPrefix(buf, []byte("PK\x03\x04")) || bytes.HasPrefix(buf, []byte("PK\x05\x06")):
+		zr, err := zip.NewReader(bytes.NewReader(buf), int64(len(buf)))
+		if err != nil {
+			return nil, importErrorf("archive is not a valid zip file")
+		}
+
+		for _, f := range zr.File {
+			// only regular files are imported
+			if !f.Mode().IsRegular() {
+				continue
+			}
+
+			rc, err := f.Open()
+			if err != nil {
+				return nil, importErrorf("archive is not a valid zip file")
+			}
+			err = collector.add(f.Name, int64(f.UncompressedSize64), f.Mode()&0111 != 0, rc)
+			_ = rc.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+	default:
+		var r io.Reader = bytes.NewReader(buf)
+
+		// transparently decompress gzipped tarballs
+		if bytes.HasPrefix(buf, []byte{0x1f, 0x8b}) {
+			gz, err := gzip.NewReader(r)
+			if err != nil {
+				return nil, importErrorf("archive is not a valid gzip file")
+			}
+			defer gz.Close()
+			r = gz
+		}
+
+		tr := tar.NewReader(r)
+		for {
+			header, err := tr.Next()
+			if err != nil {
+				if err == io.EOF {
+					break
+				}
+				return nil, importErrorf("archive must be a zip, tar or tar.gz file")
+			}
+
+			// only regular files are imported
+			if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeRegA {
+				continue
+			}
+
+			err = collector.add(header.Name, header.Size, header.Mode&0111 != 0, tr)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return collector.result()
+}
+
+// importSharedAddressSpace is the carrier-grade nat range (RFC 6598) which is
+// not covered by net.IP.IsPrivate but is not publicly routable either
+var importSharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// isPublicImportIP reports whether an address may be reached by an import
+func isPublicImportIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || importSharedAddressSpace.Contains(ip))
+}
+
+// validateImportGitURL ensures that the git url is a public http(s) url so
+// that imports cannot be used to reach internal services
+func validateImportGitURL(rawUrl string) error {
+	u, err := url.Parse(rawUrl)
+	if err != nil || u.Host == "" {
+		return importErrorf("git url is not valid")
+	}
+
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return importErrorf("git url must use http or https")
+	}
+
+	if u.User != nil {
+		return importErrorf("git url cannot contain credentials")
+	}
+
+	ips, err := net.LookupIP(u.Hostname())
+	if err != nil || len(ips) == 0 {
+		return importErrorf("unable to resolve git host %s", u.Hostname())
+	}
+
+	for _, ip := range ips {
+		if !isPublicImportIP(ip) {
+			return importErrorf("git url must point to a public host")
+		}
+	}
+
+	return nil
+}
+
+// importCloneBudget tracks the bytes transferred by a single import clone
+type importCloneBudget struct {
+	remaining int64
+	exceeded  int32
+}
+
+// consume records n transferred bytes and reports whether the budget is exhausted
+func (b *importCloneBudget) consume(n int) bool {
+	if atomic.AddInt64(&b.remaining, -int64(n)) < 0 {
+		atomic.StoreInt32(&b.exceeded, 1)
+		return true
+	}
+	return false
+}
+
+type importCloneBudgetKey struct{}
+
+var errImportCloneTooLarge = errors.New("import clone exceeded the size limit")
+
+// importCloneBody aborts the transfer of a clone once its budget is exhausted
+type importCloneBody struct {
+	io.ReadCloser
+	budget *importCloneBudget
+}
+
+func (b *importCloneBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if b.budget.consume(n) {
+		return n, errImportCloneTooLarge
+	}
+	return n, err
+}
+
+// importGitTransport performs the requests of an import clone. Every request
+// must carry the budget of its clone in the request context.
+type importGitTransport struct {
+	base nethttp.RoundTripper
+}
+
+func (t *importGitTransport) RoundTrip(req *nethttp.Request) (*nethttp.Response, error) {
+	budget, _ := req.Context().Value(importCloneBudgetKey{}).(*importCloneBudget)
+	if budget == nil {
+		return nil, fmt.Errorf("import clone budget missing from request context")
+	}
+
+	// restore the real scheme of the remote
+	req = req.Clone(req.Context())
+	req.URL.Scheme = strings.TrimPrefix(req.URL.Scheme, importGitSchemePrefix)
+
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	res.Body = &importCloneBody{ReadCloser: res.Body, budget: budget}
+	return res, nil
+}
+
+// dialImportGitHost resolves the host of an import once and connects to the
+// resolved address only if it is public. Dialing the checked address prevents
+// the host from being rebound to an internal address after validation.
+func dialImportGitHost(ctx context.Context, network string, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no addresses found for %s", host)
+	}
+	for _, ip := range ips {
+		if !isPublicImportIP(ip.IP) {
+			return nil, fmt.Errorf("import host %s resolves to a non-public address", host)
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	return dialer.DialContext(ctx, network, net.JoinHostPort(ips[0].IP.String(), port))
+}
+
+// importGitSchemePrefix prefixes the scheme of import urls so that go-git
+// routes them through the import transport instead of the default http client
+// that is used to reach the internal git server
+const importGitSchemePrefix = "gigo-import-"
+
+var installImportGitProtocol sync.Once
+
+// newImportGitClient creates the http client used for import clones. Proxies
+// and redirects are disabled so that every connection goes through the dialer.
+func newImportGitClient() *nethttp.Client {
+	return &nethttp.Client{
+		Transport: &importGitTransport{
+			base: &nethttp.Transport{
+				Proxy:                 nil,
+				DialContext:           dialImportGitHost,
+				ForceAttemptHTTP2:     true,
+				TLSHandshakeTimeout:   10 * time.Second,
+				ResponseHeaderTimeout: 30 * time.Second,
+				MaxIdleConns:          10,
+				IdleConnTimeout:       90 * time.Second,
+			},
+		},
+		CheckRedirect: func(req *nethttp.Request, via []*nethttp.Request) error {
+			return fmt.Errorf("redirects are not followed for imports")
+		},
+	}
+}
+
+// cloneImportRepo performs a shallow clone of a public git repository and
+// loads the files of the head commit
+func cloneImportRepo(ctx context.Context, gitUrl string, ref string) ([]*RepoFile, error) {
+	err := validateImportGitURL(gitUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	installImportGitProtocol.Do(func() {
+		importClient := http.NewClient(newImportGitClient())
+		gitclient.InstallProtocol(importGitSchemePrefix+"https", importClient)
+		gitclient.InstallProtocol(importGitSchemePrefix+"http", importClient)
+	})
+
+	// the total size of the transfer is bounded since the clone is held in memory
+	budget := &importCloneBudget{remaining: importMaxCloneSize}
+	cloneCtx, cancel := context.WithTimeout(context.WithValue(ctx, importCloneBudgetKey{}, budget), importCloneTimeout)
+	defer cancel()
+
+	opts := &gogit.CloneOptions{
+		URL:          importGitSchemePrefix + gitUrl,
+		Depth:        1,
+		SingleBranch: true,
+		NoCheckout:   true,
+		Tags:         gogit.NoTags,
+	}
+	if ref != "" {
+		opts.ReferenceName = plumbing.NewBranchReferenceName(ref)
+	}
+
+	// clone into memory since we only need the content of the head commit
+	repo, err := gogit.CloneContext(cloneCtx, memory.NewStorage(), nil, opts)
+	if err != nil {
+		if atomic.LoadInt32(&budget.exceeded) == 1 {
+			return nil, importErrorf("repository is larger than the %d MB import limit", importMaxCloneSize/1024/1024)
+		}
+		return nil, importErrorf("unable to clone repository, make sure it is public and the branch exists")
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil, importErrorf("repository does not have any commits")
+	}
+
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load head commit of cloned repo: %v", err)
+	}
+
+	fileIter, err := commit.Files()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files of cloned repo: %v", err)
+	}
+
+	collector := newProjectImportCollector()
+	err = fileIter.ForEach(func(f *object.File) error {
+		// skip symlinks and submodules
+		if f.Mode != filemode.Regular && f.Mode != filemode.Executable && f.Mode != filemode.Deprecated {
+			return nil
+		}
+
+		if f.Size > importMaxFileSize {
+			return importErrorf("%s is larger than the %d MB file limit", f.Name, importMaxFileSize/1024/1024)
+		}
+
+		r, err := f.Reader()
+		if err != nil {
+			return fmt.Errorf("failed to open cloned file %s: %v", f.Name, err)
+		}
+		defer r.Close()
+
+		return collector.add(f.Name, f.Size, f.Mode == filemode.Executable, r)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return collector.result()
+}
+
+// LoadProjectImport loads and validates the files from the import source.
+// Errors of type *ProjectImportError describe an invalid source.
+func LoadProjectImport(ctx context.Context, storageEngine storage.Storage, source *ProjectImportSource) ([]*RepoFile, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "load-project-import-core")
+	defer span.End()
+
+	if (source.GitURL == "") == (source.ArchivePath == "") {
+		return nil, importErrorf("import must provide either a git url or an archive")
+	}
+
+	if source.GitURL != "" {
+		return cloneImportRepo(ctx, source.GitURL, source.GitRef)
+	}
+
+	archive, err := storageEngine.GetFile(source.ArchivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve import archive: %v", err)
+	}
+	if archive == nil {
+		return nil, importErrorf("import archive not found")
+	}
+	defer archive.Close()
+
+	// read the archive with a limit to protect against oversized uploads
+	buf, err := io.ReadAll(io.LimitReader(archive, importMaxTotalSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read import archive: %v", err)
+	}
+	if len(buf) > importMaxTotalSize {
+		return nil, importErrorf("imports cannot be larger than %d MB", importMaxTotalSize/1024/1024)
+	}
+
+	return readImportArchive(buf)
+}
+
+// findImportFile returns the import file with the passed path or nil
+func findImportFile(files []*RepoFile, p string) *RepoFile {
+	for _, f := range files {
+		if f.Path == p {
+			return f
+		}
+	}
+	return nil
+}
+
+// importLanguageDefaults holds the workspace defaults used when generating
+// a workspace config for an imported project of a given language
+type importLanguageDefaults struct {
+	marker    string
+	container string
+	extension string
+	install   string
+}
+
+var importLanguages = []importLanguageDefaults{
+	{marker: "go.mod", container: "gigodev/gimg:golang-ubuntu", extension: "golang.go", install: "go mod download"},
+	{marker: "requirements.txt", container: "gigodev/gimg:python-ubuntu", extension: "ms-python.python", install: "pip install -r requirements.txt"},
+	{marker: "package.json", container: "gigodev/gimg:node-ubuntu", extension: "dbaeumer.vscode-eslint", install: "npm install"},
+	{marker: "Cargo.toml", container: "gigodev/gimg:rust-ubuntu", extension: "rust-lang.rust-analyzer", install: "cargo fetch"},
+}
+
+// generateImportWorkspaceConfig creates a default workspace config for an
+// imported project based on the dependency files at the repository root
+func generateImportWorkspaceConfig(files []*RepoFile) string {
+	container := "gigodev/gimg:base-ubuntu"
+	extensions := ""
+	execs := ""
+
+	for _, lang := range importLanguages {
+		if findImportFile(files, lang.marker) == nil {
+			continue
+		}
+
+		// the first detected language selects the base container
+		if extensions == "" {
+			container = lang.container
+		}
+
+		extensions += fmt.Sprintf("    - %s\n", lang.extension)
+		execs += fmt.Sprintf("  - name: %q\n    init: true\n    command: %q\n", "Install dependencies ("+lang.marker+")", lang.install)
+	}
+
+	if extensions == "" {
+		extensions = "    []\n"
+	}
+	if execs == "" {
+		execs = "  []\n"
+	}
+
+	return fmt.Sprintf(
+		"version: 0.1\n"+
+			"resources:\n"+
+			"  cpu: 2\n"+
+			"  mem: 4\n"+
+			"  disk: 15\n"+
+			"base_container: %s\n"+
+			"working_directory: /home/gigo/codebase\n"+
+			"environment: {}\n"+
+			"containers: {}\n"+
+			"vscode:\n"+
+			"  enabled: true\n"+
+			"  extensions:\n%s"+
+			"port_forward: []\n"+
+			"exec:\n%s",
+		container, extensions, execs,
+	)
+}
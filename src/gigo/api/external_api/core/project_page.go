@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"database/sql"
 	"encoding/base64"
 	"fmt"
 	"io"
@@ -47,8 +48,8 @@ func ProjectInformation(ctx context.Context, tidb *ti.Database, vcsClient *git.V
 
 	// query for all active projects for specified user
 	res, err := tidb.QueryContext(ctx, &span, &callerName,
-		"select p._id as _id, title, description, author, p.deleted as deleted, author_id, p.created_at as created_at, updated_at, repo_id, p.tier as tier, top_reply, p.coffee as coffee, post_type, views, completions, attempts, published, stripe_price_id,challenge_cost, workspace_config, p.workspace_settings, leads, embedded, r._id as reward_id, name, color_palette, render_in_front, exclusive_description from post p join users u on p.author_id = u._id left join rewards r on r._id = u.avatar_reward where p._id = ? and ((visibility = ? and author_id = ?) or visibility = ?) limit 1",
-		projectId, models.PrivateVisibility, callerId, models.PublicVisibility,
+		"select p._id as _id, title, description, author, p.deleted as deleted, author_id, p.created_at as created_at, updated_at, repo_id, p.tier as tier, top_reply, p.coffee as coffee, post_type, views, completions, attempts, published, stripe_price_id,challenge_cost, workspace_config, p.workspace_settings, leads, embedded, r._id as reward_id, name, color_palette, render_in_front, exclusive_description from post p join users u on p.author_id = u._id left join rewards r on r._id = u.avatar_reward where p._id = ? and ((visibility = ? and author_id = ?) or visibility = ?) and (p.hidden = false or author_id = ?) limit 1",
+		projectId, models.PrivateVisibility, callerId, models.PublicVisibility, callerId,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query post: %v", err)
@@ -223,12 +224,65 @@ func ProjectInformation(ctx context.Context, tidb *ti.Database, vcsClient *git.V
 		fp.HasAccess = nil
 	}
 
+	// load the version history of the project
+	releasesRes, err := GetProjectReleases(ctx, tidb, callingUser, post.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load project releases: %v", err)
+	}
+	releases := releasesRes["releases"].([]*ProjectReleaseFrontend)
+
+	// determine the release that the calling user's attempt is pinned to so
+	// that they can be offered an upgrade when a newer release exists
+	var attemptReleaseVersion *int
+	upgradeAvailable := false
+	if attemptFrontend != nil {
+		var version sql.NullInt64
+		err = tidb.QueryRowContext(ctx, &span, &callerName,
+			"select release_version from attempt where _id = ? limit 1", attemptFrontend.ID,
+		).Scan(&version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query attempt release: %v", err)
+		}
+		if version.Valid {
+			v := int(version.Int64)
+			attemptReleaseVersion = &v
+		}
+		upgradeAvailable = len(releases) > 0 && (attemptReleaseVersion == nil || *attemptReleaseVersion < releases[0].Version)
+	}
+
+	// load the attribution for forked projects and the number of times this project has been forked
+	forkedFrom, err := getForkSource(ctx, tidb, post.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load fork source: %v", err)
+	}
+
+	var forkCount int64
+	err = tidb.QueryRowContext(ctx, &span, &callerName,
+		"select count(*) from post where forked_from_post = ? and published = true and deleted = false and visibility = ?",
+		post.ID, models.PublicVisibility,
+	).Scan(&forkCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count forks: %v", err)
+	}
+
+	// load the accepted collaborators so they can be displayed as co-authors
+	collaborators, err := GetPostCollaborators(ctx, tidb, post.ID, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load collaborators: %v", err)
+	}
+
 	return map[string]interface{}{
-		"post":        fp,
-		"description": string(readMeBytes),
-		"evaluation":  string(evaluationBytes),
-		"attempt":     attemptFrontend,
-		"tutorials":   tutorials,
+		"post":                    fp,
+		"description":             string(readMeBytes),
+		"evaluation":              string(evaluationBytes),
+		"attempt":                 attemptFrontend,
+		"tutorials":               tutorials,
+		"releases":                releases,
+		"attempt_release_version": attemptReleaseVersion,
+		"upgrade_available":       upgradeAvailable,
+		"forked_from":             forkedFrom,
+		"fork_count":              forkCount,
+		"collaborators":           collaborators,
 	}, nil
 }
 
@@ -238,6 +292,7 @@ func ProjectInformation(ctx context.Context, tidb *ti.Database, vcsClient *git.V
 // Args:
 //
 //		tidb       - *ti.Database, tidb
+//		callingUser - *models.User, the viewer whose access to private attempts is checked (nil for anonymous)
 //		projectId  - int64, id of selected project
 //	 limit	   - *int, optional integer to limit the number of returned attempts
 //
@@ -245,16 +300,20 @@ func ProjectInformation(ctx context.Context, tidb *ti.Database, vcsClient *git.V
 //
 //	out        - []*models.Attempt, an array of attempts for specified project sorted by most recent creation
 //			   - error
-func ProjectAttempts(ctx context.Context, tidb *ti.Database, projectId int64, skip int, limit int) (map[string]interface{}, error) {
+func ProjectAttempts(ctx context.Context, tidb *ti.Database, callingUser *models.User, projectId int64, skip int, limit int) (map[string]interface{}, error) {
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "project-attempts-core")
 	callerName := "ProjectAttempts"
 
+	// exclude the attempts hidden from the caller by the privacy of their authors
+	privacyFilter, params := attemptPrivacyFilter(callingUser)
+	params = append([]interface{}{projectId}, params...)
+	params = append(params, limit, skip)
+
 	// query for all active projects for specified user
-	res, err := tidb.QueryContext(ctx, &span, &callerName, "select a._id as _id, post_title, description, author, author_id, a.created_at as created_at, updated_at, repo_id, author_tier, a.coffee as coffee, post_id, closed, success, closed_date, a.tier as tier, parent_attempt, a.workspace_settings as workspace_settings, r._id as reward_id, name, color_palette, render_in_front from attempt a join users u on a.author_id = u._id left join rewards r on u.avatar_reward = r._id where post_id = ? order by created_at desc limit ? offset ?", projectId, limit, skip)
+	query := "select a._id as _id, post_title, description, author, author_id, a.created_at as created_at, updated_at, repo_id, author_tier, a.coffee as coffee, post_id, closed, success, closed_date, a.tier as tier, parent_attempt, a.workspace_settings as workspace_settings, r._id as reward_id, name, color_palette, render_in_front from attempt a join users u on a.author_id = u._id left join rewards r on u.avatar_reward = r._id where post_id = ? and a.hidden = false and " + privacyFilter + " order by created_at desc limit ? offset ?"
+	res, err := tidb.QueryContext(ctx, &span, &callerName, query, params...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query post: %v\n    query: %s\n    values: %v", err,
-			"select * from attempt where post_id = ? order by created_at desc limit ? offset ?",
-			[]interface{}{projectId, limit, skip})
+		return nil, fmt.Errorf("failed to query post: %v\n    query: %s\n    values: %v", err, query, params)
 	}
 
 	// ensure the closure of the rows
@@ -430,10 +489,10 @@ func GetClosedAttempts(ctx context.Context, tidb *ti.Database, projectId int64,
 	callerName := "GetClosedAttempts"
 
 	// query for all active projects for specified user
-	res, err := tidb.QueryContext(ctx, &span, &callerName, "select a._id as _id, post_title, description, author, author_id, a.created_at as created_at, updated_at, repo_id, author_tier, a.coffee as coffee, post_id, closed, success, closed_date, a.tier as tier, parent_attempt, a.workspace_settings as workspace_settings, r._id as reward_id, name, color_palette, render_in_front from attempt a join users u on a.author_id = u._id left join rewards r on u.avatar_reward = r._id where post_id = ? and closed = true order by created_at desc limit ? offset ?", projectId, limit, skip)
+	res, err := tidb.QueryContext(ctx, &span, &callerName, "select a._id as _id, post_title, description, author, author_id, a.created_at as created_at, updated_at, repo_id, author_tier, a.coffee as coffee, post_id, closed, success, closed_date, a.tier as tier, parent_attempt, a.workspace_settings as workspace_settings, r._id as reward_id, name, color_palette, render_in_front from attempt a join users u on a.author_id = u._id left join rewards r on u.avatar_reward = r._id where post_id = ? and closed = true and a.hidden = false order by created_at desc limit ? offset ?", projectId, limit, skip)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query attempt: %v\n    query: %s\n    values: %v", err,
-			"select * from attempt where post_id = ? and closed = true order by created_at desc limit ? offset ?",
+			"select * from attempt where post_id = ? and closed = true and hidden = false order by created_at desc limit ? offset ?",
 			[]interface{}{projectId, limit, skip})
 	}
 
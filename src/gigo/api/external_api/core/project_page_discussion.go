@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"strconv"
 	"time"
@@ -13,16 +14,33 @@ import (
 	"github.com/bwmarrin/snowflake"
 	ti "github.com/gage-technologies/gigo-lib/db"
 	"github.com/gage-technologies/gigo-lib/db/models"
-	"github.com/gage-technologies/gigo-lib/search"
+	"github.com/gage-technologies/gigo-lib/logging"
+	"github.com/gage-technologies/gigo-lib/mq"
 	"github.com/kisielk/sqlstruct"
 )
 
-func GetDiscussions(ctx context.Context, tidb *ti.Database, callingUser *models.User, postId int64, skip int, limit int) (map[string]interface{}, error) {
+func GetDiscussions(ctx context.Context, tidb *ti.Database, callingUser *models.User, postId int64, skip int, limit int, sort string) (map[string]interface{}, error) {
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "get-discussions-core")
 	callerName := "GetDiscussions"
 
-	// query attempt and projects with the user id as author id and sort by date last edited
-	res, err := tidb.QueryContext(ctx, &span, &callerName, "select d.*, r._id as reward_id, color_palette, name, render_in_front, user_status from discussion d inner join (select _id, max(revision) as revision from discussion where post_id = ? group by _id) t on d._id = t._id and d.revision = t.revision left join users u on d.author_id = u._id left join rewards r on r._id = u.avatar_reward limit ? offset ?", postId, limit, skip)
+	// reject unknown sort modes
+	if !ValidDiscussionSort(sort) {
+		return map[string]interface{}{"message": "invalid sort mode"}, fmt.Errorf("invalid discussion sort %q", sort)
+	}
+
+	// load the page of discussions from the ranking in the order of the sort mode
+	ids, err := discussionRankPage(ctx, tidb, models.DiscussionLevel, []int64{postId}, sort, skip, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for discussions. GetDiscussions Core.    Error: %v", err)
+	}
+	if len(ids) == 0 {
+		return map[string]interface{}{"discussions": make([]*models.DiscussionBackgroundFrontend, 0), "lead_ids": nil, "up_voted": make([]string, 0),
+			"body_html": make(map[string]string), "edited": make(map[string]*time.Time), "locked_ids": make([]string, 0)}, nil
+	}
+
+	// load the latest revision of the discussions on the page
+	placeholders, params := discussionPageContentClause(ids)
+	res, err := tidb.QueryContext(ctx, &span, &callerName, "select d.*, r._id as reward_id, color_palette, name, render_in_front, user_status, l.discussion_id is not null as locked from discussion d inner join (select _id, max(revision) as revision from discussion where _id in ("+placeholders+") group by _id) t on d._id = t._id and d.revision = t.revision left join discussion_lock l on l.discussion_id = d._id left join users u on d.author_id = u._id left join rewards r on r._id = u.avatar_reward where d.hidden = false order by field(d._id, "+placeholders+")", params...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query for discussions. GetDiscussions Core.    Error: %v", err)
 	}
@@ -30,6 +48,12 @@ func GetDiscussions(ctx context.Context, tidb *ti.Database, callingUser *models.
 	// slice to hold results
 	discussions := make([]*models.DiscussionBackgroundFrontend, 0)
 
+	// map of ids to the rendered html of their bodies
+	bodyHTML := make(map[string]string)
+
+	// map of the ids of edited content to the time of the last edit
+	edited := make(map[string]*time.Time)
+
 	// slice to hold discussions that user has up voted
 	voted := make([]string, 0)
 
@@ -63,28 +87,49 @@ func GetDiscussions(ctx context.Context, tidb *ti.Database, callingUser *models.
 	// create slice to hold comment lead ids
 	var leadIds []string
 
+	// create slice to hold the ids of locked discussions
+	lockedIds := make([]string, 0)
+
 	for res.Next() {
-		var discussion models.DiscussionBackground
+		var discussion struct {
+			models.DiscussionBackground
+			BodyHTML sql.NullString `sql:"body_html"`
+			EditedAt sql.NullTime   `sql:"edited_at"`
+			Deleted  bool           `sql:"deleted"`
+			Locked   bool           `sql:"locked"`
+		}
 
 		err = sqlstruct.Scan(&discussion, res)
 		if err != nil {
 			return nil, fmt.Errorf("failed to decode query for project discussion. GetDiscussions Core.    Error: %v", err)
 		}
+		redactDeletedDiscussion(discussion.Deleted, &discussion.Body, &discussion.BodyHTML)
 
 		discussions = append(discussions, discussion.ToFrontend())
+		bodyHTML[strconv.FormatInt(discussion.ID, 10)] = discussionBodyHTML(discussion.BodyHTML, discussion.Body)
+		markDiscussionEdited(edited, discussion.ID, discussion.Revision, discussion.EditedAt)
 
 		if discussion.Leads {
 			leadIds = append(leadIds, strconv.FormatInt(discussion.ID, 10))
 		}
+
+		if discussion.Locked {
+			lockedIds = append(lockedIds, strconv.FormatInt(discussion.ID, 10))
+		}
 	}
 
-	return map[string]interface{}{"discussions": discussions, "lead_ids": leadIds, "up_voted": voted}, nil
+	return map[string]interface{}{"discussions": discussions, "lead_ids": leadIds, "up_voted": voted, "body_html": bodyHTML, "edited": edited, "locked_ids": lockedIds}, nil
 }
 
-func GetDiscussionComments(ctx context.Context, tidb *ti.Database, callingUser *models.User, discussionId []int64, skip int, limit int) (map[string]interface{}, error) {
+func GetDiscussionComments(ctx context.Context, tidb *ti.Database, callingUser *models.User, discussionId []int64, skip int, limit int, sort string) (map[string]interface{}, error) {
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "get-discussion-comments-core")
 	callerName := "GetDiscussionComments"
 
+	// reject unknown sort modes
+	if !ValidDiscussionSort(sort) {
+		return map[string]interface{}{"message": "invalid sort mode"}, fmt.Errorf("invalid discussion sort %q", sort)
+	}
+
 	// save length of id array to variable
 	idLength := len(discussionId)
 
@@ -93,31 +138,22 @@ func GetDiscussionComments(ctx context.Context, tidb *ti.Database, callingUser *
 		return map[string]interface{}{"message": "No comments found"}, nil
 	}
 
-	// build first chunk of query variable
-	query := "select c.*, r._id as reward_id, color_palette, name, render_in_front, user_status from comment c inner join (select _id, max(revision) as revision from comment where discussion_id "
-
-	// build next query chunk depending on number of discussion ids passed
-	if idLength <= 1 {
-		query += "= " + strconv.FormatInt(discussionId[0], 10)
-	} else {
-		query += " in ("
-		// iterate over discussion ids appending to query
-		for i := range discussionId {
-			query += strconv.FormatInt(discussionId[i], 10)
-			if (i + 1) < idLength {
-				query += ", "
-			} else {
-				query += ")"
-				break
-			}
-		}
+	// load the page from the ranking in the order of the sort mode
+	ids, err := discussionRankPage(ctx, tidb, models.CommentLevel, discussionId, sort, skip, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for any discussion comments. GetDiscussionComments Core.    Error: %v", err)
+	}
+	if len(ids) == 0 {
+		return map[string]interface{}{"comments": make([]*models.CommentBackgroundFrontend, 0), "lead_ids": nil, "up_voted": make([]string, 0),
+			"body_html": make(map[string]string), "edited": make(map[string]*time.Time), "accepted_ids": make([]string, 0)}, nil
 	}
 
-	// append final portion of query
-	query += " group by _id) t on c._id = t._id and c.revision = t.revision left join users u on c.author_id = u._id left join rewards r on r._id = u.avatar_reward limit ? offset ?"
-
-	// query for comments with given discussion id and highest revision
-	res, err := tidb.QueryContext(ctx, &span, &callerName, query, limit, skip)
+	// load the latest revision of the content on the page
+	placeholders, params := discussionPageContentClause(ids)
+	query := "select c.*, r._id as reward_id, color_palette, name, render_in_front, user_status, a.comment_id is not null as accepted from comment c inner join (select _id, max(revision) as revision from comment where _id in (" + placeholders + ") group by _id) t " +
+		"on c._id = t._id and c.revision = t.revision left join discussion_answer a on a.comment_id = c._id left join users u on c.author_id = u._id left join rewards r on r._id = u.avatar_reward " +
+		"where c.hidden = false order by field(c._id, " + placeholders + ")"
+	res, err := tidb.QueryContext(ctx, &span, &callerName, query, params...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query for any discussion comments. GetDiscussionComments Core.    Error: %v", err)
 	}
@@ -152,34 +188,61 @@ func GetDiscussionComments(ctx context.Context, tidb *ti.Database, callingUser *
 	// slice to hold results
 	comments := make([]*models.CommentBackgroundFrontend, 0)
 
+	// map of ids to the rendered html of their bodies
+	bodyHTML := make(map[string]string)
+
+	// map of the ids of edited content to the time of the last edit
+	edited := make(map[string]*time.Time)
+
 	// create slice to hold comment lead ids
 	var leadIds []string
 
+	// create slice to hold the ids of accepted answers
+	acceptedIds := make([]string, 0)
+
 	defer res.Close()
 
 	for res.Next() {
-		var comment models.CommentBackground
+		var comment struct {
+			models.CommentBackground
+			BodyHTML sql.NullString `sql:"body_html"`
+			EditedAt sql.NullTime   `sql:"edited_at"`
+			Deleted  bool           `sql:"deleted"`
+			Accepted bool           `sql:"accepted"`
+		}
 
 		err = sqlstruct.Scan(&comment, res)
 		if err != nil {
 			return nil, fmt.Errorf("failed to decode query for resulsts. GetDiscussionComments Core.    Error: %v", err)
 		}
+		redactDeletedDiscussion(comment.Deleted, &comment.Body, &comment.BodyHTML)
 
 		comments = append(comments, comment.ToFrontend())
+		bodyHTML[strconv.FormatInt(comment.ID, 10)] = discussionBodyHTML(comment.BodyHTML, comment.Body)
+		markDiscussionEdited(edited, comment.ID, comment.Revision, comment.EditedAt)
+
+		if comment.Accepted {
+			acceptedIds = append(acceptedIds, strconv.FormatInt(comment.ID, 10))
+		}
 
 		if comment.Leads {
 			leadIds = append(leadIds, strconv.FormatInt(comment.ID, 10))
 		}
 	}
 
-	return map[string]interface{}{"comments": comments, "lead_ids": leadIds, "up_voted": voted}, nil
+	return map[string]interface{}{"comments": comments, "lead_ids": leadIds, "up_voted": voted, "body_html": bodyHTML, "edited": edited, "accepted_ids": acceptedIds}, nil
 
 }
 
-func GetCommentThreads(ctx context.Context, tidb *ti.Database, callingUser *models.User, commentId []int64, skip int, limit int) (map[string]interface{}, error) {
+func GetCommentThreads(ctx context.Context, tidb *ti.Database, callingUser *models.User, commentId []int64, skip int, limit int, sort string) (map[string]interface{}, error) {
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "get-comment-threads-core")
 	callerName := "GetCommentThreads"
 
+	// reject unknown sort modes
+	if !ValidDiscussionSort(sort) {
+		return map[string]interface{}{"message": "invalid sort mode"}, fmt.Errorf("invalid discussion sort %q", sort)
+	}
+
 	// save length of id array to variable
 	idLength := len(commentId)
 
@@ -188,31 +251,22 @@ func GetCommentThreads(ctx context.Context, tidb *ti.Database, callingUser *mode
 		return map[string]interface{}{"message": "No threads found"}, nil
 	}
 
-	// build first chunk of query variable
-	query := "select c.*, r._id as reward_id, color_palette, name, render_in_front, user_status from thread_comment c inner join (select _id, max(revision) as revision from thread_comment where comment_id "
-
-	// build next query chunk depending on number of comment ids passed
-	if idLength <= 1 {
-		query += "= " + strconv.FormatInt(commentId[0], 10)
-	} else {
-		query += " in ("
-		// iterate over comment ids appending to query
-		for i := range commentId {
-			query += strconv.FormatInt(commentId[i], 10)
-			if (i + 1) < idLength {
-				query += ", "
-			} else {
-				query += ")"
-				break
-			}
-		}
+	// load the page from the ranking in the order of the sort mode
+	ids, err := discussionRankPage(ctx, tidb, models.ThreadLevel, commentId, sort, skip, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for comment threads. GetCommentThreads Core.    Error: %v", err)
+	}
+	if len(ids) == 0 {
+		return map[string]interface{}{"threads": make([]*query_models.ThreadCommentBackgroundFrontend, 0), "lead_ids": nil, "up_voted": make([]string, 0),
+			"body_html": make(map[string]string), "edited": make(map[string]*time.Time)}, nil
 	}
 
-	// append final portion of query
-	query += " group by _id) t on c._id = t._id and c.revision = t.revision left join users u on c.author_id = u._id left join rewards r on r._id = u.avatar_reward limit ? offset ?"
-
-	// query thread_comment with comment id and highest revision
-	res, err := tidb.QueryContext(ctx, &span, &callerName, query, limit, skip)
+	// load the latest revision of the content on the page
+	placeholders, params := discussionPageContentClause(ids)
+	query := "select c.*, r._id as reward_id, color_palette, name, render_in_front, user_status from thread_comment c inner join (select _id, max(revision) as revision from thread_comment where _id in (" + placeholders + ") group by _id) t " +
+		"on c._id = t._id and c.revision = t.revision left join users u on c.author_id = u._id left join rewards r on r._id = u.avatar_reward " +
+		"where c.hidden = false order by field(c._id, " + placeholders + ")"
+	res, err := tidb.QueryContext(ctx, &span, &callerName, query, params...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query for comment threads. GetCommentThreads Core.    Error: %v", err)
 	}
@@ -244,33 +298,52 @@ func GetCommentThreads(ctx context.Context, tidb *ti.Database, callingUser *mode
 
 	threads := make([]*query_models.ThreadCommentBackgroundFrontend, 0)
 
+	// map of ids to the rendered html of their bodies
+	bodyHTML := make(map[string]string)
+
+	// map of the ids of edited content to the time of the last edit
+	edited := make(map[string]*time.Time)
+
 	// create slice to hold thread lead ids
 	var leadIds []string
 
 	defer res.Close()
 
 	for res.Next() {
-		var thread query_models.ThreadCommentBackground
+		var thread struct {
+			query_models.ThreadCommentBackground
+			BodyHTML sql.NullString `sql:"body_html"`
+			EditedAt sql.NullTime   `sql:"edited_at"`
+			Deleted  bool           `sql:"deleted"`
+		}
 
 		err = sqlstruct.Scan(&thread, res)
 		if err != nil {
 			return nil, fmt.Errorf("failed to decode query for res. GetCommentThreads Core.    Error: %v", err)
 		}
+		redactDeletedDiscussion(thread.Deleted, &thread.Body, &thread.BodyHTML)
 
 		threads = append(threads, thread.ToFrontend())
+		bodyHTML[strconv.FormatInt(thread.ID, 10)] = discussionBodyHTML(thread.BodyHTML, thread.Body)
+		markDiscussionEdited(edited, thread.ID, thread.Revision, thread.EditedAt)
 
 		if thread.Leads {
 			leadIds = append(leadIds, strconv.FormatInt(thread.ID, 10))
 		}
 	}
 
-	return map[string]interface{}{"threads": threads, "lead_ids": leadIds, "up_voted": voted}, nil
+	return map[string]interface{}{"threads": threads, "lead_ids": leadIds, "up_voted": voted, "body_html": bodyHTML, "edited": edited}, nil
 }
 
-func GetThreadReply(ctx context.Context, tidb *ti.Database, callingUser *models.User, threadId []int64, skip int, limit int) (map[string]interface{}, error) {
+func GetThreadReply(ctx context.Context, tidb *ti.Database, callingUser *models.User, threadId []int64, skip int, limit int, sort string) (map[string]interface{}, error) {
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "get-thread-reply-core")
 	callerName := "GetThreadReply"
 
+	// reject unknown sort modes
+	if !ValidDiscussionSort(sort) {
+		return map[string]interface{}{"message": "invalid sort mode"}, fmt.Errorf("invalid discussion sort %q", sort)
+	}
+
 	// save length of id array to variable
 	idLength := len(threadId)
 
@@ -279,31 +352,22 @@ func GetThreadReply(ctx context.Context, tidb *ti.Database, callingUser *models.
 		return map[string]interface{}{"message": "No threads replies found"}, nil
 	}
 
-	// build first chunk of query variable
-	query := "select c.*, r._id as reward_id, color_palette, name, render_in_front, user_status from thread_reply c inner join (select _id, max(revision) as revision from thread_reply where thread_comment_id "
-
-	// build next query chunk depending on number of discussion ids passed
-	if idLength <= 1 {
-		query += "= " + strconv.FormatInt(threadId[0], 10)
-	} else {
-		query += " in ("
-		// iterate over discussion ids appending to query
-		for i := range threadId {
-			query += strconv.FormatInt(threadId[i], 10)
-			if (i + 1) < idLength {
-				query += ", "
-			} else {
-				query += ")"
-				break
-			}
-		}
+	// load the page from the ranking in the order of the sort mode
+	ids, err := discussionRankPage(ctx, tidb, models.ThreadReplyLevel, threadId, sort, skip, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for thread reply. GetThreadReply Core.    Error: %v", err)
+	}
+	if len(ids) == 0 {
+		return map[string]interface{}{"thread_reply": make([]*query_models.ThreadReplyBackgroundFrontend, 0), "up_voted": make([]string, 0),
+			"body_html": make(map[string]string), "edited": make(map[string]*time.Time)}, nil
 	}
 
-	// append final portion of query
-	query += " group by _id) t on c._id = t._id and c.revision = t.revision left join users u on c.author_id = u._id left join rewards r on r._id = u.avatar_reward limit ? offset ?"
-
-	// query attempt and projects with the user id as author id and sort by date last edited
-	res, err := tidb.QueryContext(ctx, &span, &callerName, query, limit, skip)
+	// load the latest revision of the content on the page
+	placeholders, params := discussionPageContentClause(ids)
+	query := "select c.*, r._id as reward_id, color_palette, name, render_in_front, user_status from thread_reply c inner join (select _id, max(revision) as revision from thread_reply where _id in (" + placeholders + ") group by _id) t " +
+		"on c._id = t._id and c.revision = t.revision left join users u on c.author_id = u._id left join rewards r on r._id = u.avatar_reward " +
+		"where c.hidden = false order by field(c._id, " + placeholders + ")"
+	res, err := tidb.QueryContext(ctx, &span, &callerName, query, params...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query for thread reply: %v\n   query: %s\n    params: %v",
 			err, query, []interface{}{limit, skip})
@@ -337,23 +401,37 @@ func GetThreadReply(ctx context.Context, tidb *ti.Database, callingUser *models.
 
 	threadReplies := make([]*query_models.ThreadReplyBackgroundFrontend, 0)
 
+	// map of ids to the rendered html of their bodies
+	bodyHTML := make(map[string]string)
+
+	// map of the ids of edited content to the time of the last edit
+	edited := make(map[string]*time.Time)
+
 	defer res.Close()
 
 	for res.Next() {
-		var threadReply query_models.ThreadReplyBackground
+		var threadReply struct {
+			query_models.ThreadReplyBackground
+			BodyHTML sql.NullString `sql:"body_html"`
+			EditedAt sql.NullTime   `sql:"edited_at"`
+			Deleted  bool           `sql:"deleted"`
+		}
 
 		err = sqlstruct.Scan(&threadReply, res)
 		if err != nil {
 			return nil, fmt.Errorf("failed to decode query for resulsts. GetThreadReply Core.    Error: %v", err)
 		}
+		redactDeletedDiscussion(threadReply.Deleted, &threadReply.Body, &threadReply.BodyHTML)
 
 		threadReplies = append(threadReplies, threadReply.ToFrontend())
+		bodyHTML[strconv.FormatInt(threadReply.ID, 10)] = discussionBodyHTML(threadReply.BodyHTML, threadReply.Body)
+		markDiscussionEdited(edited, threadReply.ID, threadReply.Revision, threadReply.EditedAt)
 	}
 
-	return map[string]interface{}{"thread_reply": threadReplies, "up_voted": voted}, nil
+	return map[string]interface{}{"thread_reply": threadReplies, "up_voted": voted, "body_html": bodyHTML, "edited": edited}, nil
 }
 
-func CreateDiscussion(ctx context.Context, tidb *ti.Database, meili *search.MeiliSearchEngine, callingUser *models.User, sf *snowflake.Node, postId int64, title string, body string, tags []*models.Tag) (map[string]interface{}, error) {
+func CreateDiscussion(ctx context.Context, tidb *ti.Database, js *mq.JetstreamClient, callingUser *models.User, sf *snowflake.Node, logger logging.Logger, postId int64, title string, body string, tags []*models.Tag) (map[string]interface{}, error) {
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "create-discussion-core")
 	callerName := "CreateDiscussion"
 
@@ -370,25 +448,15 @@ func CreateDiscussion(ctx context.Context, tidb *ti.Database, meili *search.Meil
 		return map[string]interface{}{"message": "You must provide content for your discussion"}, fmt.Errorf("provided body was empty. CreateDiscussions Core")
 	}
 
-	// create boolean to track failure
-	failed := true
+	// render the markdown body so the html can be stored next to the source
+	rendered, err := renderDiscussionMarkdown(ctx, tidb, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s body: %v", "discussion", err)
+	}
 
 	// create slice to hold tag ids
 	tagIds := make([]int64, len(tags))
-	newTags := make([]interface{}, 0)
-
-	// defer function to cleanup repo on failure
-	defer func() {
-		// skip cleanup if we succeeded
-		if !failed {
-			return
-		}
-
-		_ = meili.DeleteDocuments("discussion", id)
-		for _, tag := range newTags {
-			_ = meili.DeleteDocuments("tags", tag.(*models.TagSearch).ID)
-		}
-	}()
+	newTags := make([]int64, 0)
 
 	// create transaction for discussion insertion
 	tx, err := tidb.BeginTx(ctx, &span, &callerName, nil)
@@ -415,7 +483,7 @@ func CreateDiscussion(ctx context.Context, tidb *ti.Database, meili *search.Meil
 			}
 
 			// add tag to new tags for search engine insertion
-			newTags = append(newTags, tag.ToSearch())
+			newTags = append(newTags, tag.ID)
 		} else {
 			// increment tag column usage_count in database
 			_, err = tx.ExecContext(ctx, &callerName, "update tag set usage_count = usage_count + 1 where id =?", tag.ID)
@@ -448,18 +516,32 @@ func CreateDiscussion(ctx context.Context, tidb *ti.Database, meili *search.Meil
 		}
 	}
 
-	// attempt to insert the discussion into the search engine to make it discoverable
-	err = meili.AddDocuments("discussion", discussion)
+	// store the rendered html for the first revision
+	_, err = tx.ExecContext(ctx, &callerName, "update discussion set body_html = ? where _id = ? and revision = ?", rendered.HTML, id, discussion.Revision)
 	if err != nil {
-		return nil, fmt.Errorf("failed to add discussion to search engine: %v", err)
+		return nil, fmt.Errorf("failed to store rendered discussion body: %v", err)
 	}
 
-	// conditionally attempt to insert the tags into the search engine to make it discoverable
-	if len(newTags) > 0 {
-		err = meili.AddDocuments("tags", newTags...)
-		if err != nil {
-			return nil, fmt.Errorf("failed to add new discussion tags to search engine: %v", err)
-		}
+	// rank the new discussion so that it can be sorted
+	err = insertDiscussionRank(ctx, tx, callerName, id, models.DiscussionLevel, postId, discussion.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// subscribe the author so they learn about replies to their discussion
+	err = subscribeToDiscussion(ctx, tx, callerName, id, callingUser.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// queue the discussion and the new tags to be made discoverable once committed
+	err = queueSearchSync(ctx, tx, callerName, "discussion", id)
+	if err != nil {
+		return nil, err
+	}
+	err = queueSearchSync(ctx, tx, callerName, "tags", newTags...)
+	if err != nil {
+		return nil, err
 	}
 
 	// format discussion to frontend object
@@ -471,13 +553,20 @@ func CreateDiscussion(ctx context.Context, tidb *ti.Database, meili *search.Meil
 		return nil, fmt.Errorf("failed to commit transaction for discussion: %v", err)
 	}
 
-	// set failed as false
-	failed = false
-
-	return map[string]interface{}{"message": "Discussion has been posted", "discussion": discussionFrontend}, nil
+	// notify the mentioned users in the background
+	publishDiscussionReply(js, logger, DiscussionReplyMsg{
+		DiscussionID: id,
+		ContentID:    id,
+		ContentType:  models.DiscussionLevel,
+		AuthorID:     callingUser.ID,
+		AuthorName:   callingUser.UserName,
+		Mentions:     rendered.Mentions,
+	})
+
+	return map[string]interface{}{"message": "Discussion has been posted", "discussion": discussionFrontend, "body_html": rendered.HTML}, nil
 }
 
-func CreateComment(ctx context.Context, tidb *ti.Database, meili *search.MeiliSearchEngine, callingUser *models.User, sf *snowflake.Node, discussionId int64, body string) (map[string]interface{}, error) {
+func CreateComment(ctx context.Context, tidb *ti.Database, js *mq.JetstreamClient, callingUser *models.User, sf *snowflake.Node, logger logging.Logger, discussionId int64, body string) (map[string]interface{}, error) {
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "create-comment-core")
 	callerName := "CreateComment"
 
@@ -489,17 +578,11 @@ func CreateComment(ctx context.Context, tidb *ti.Database, meili *search.MeiliSe
 		return map[string]interface{}{"message": "You must provide content for your comment"}, fmt.Errorf("provided body was empty. CreateComment Core")
 	}
 
-	// create boolean to track failure
-	failed := true
-
-	// defer function to clean up repo on failure
-	defer func() {
-		// skip cleanup if we succeeded
-		if !failed {
-			return
-		}
-		_ = meili.DeleteDocuments("comment", id)
-	}()
+	// render the markdown body so the html can be stored next to the source
+	rendered, err := renderDiscussionMarkdown(ctx, tidb, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s body: %v", "comment", err)
+	}
 
 	// create transaction for comment insertion
 	tx, err := tidb.BeginTx(ctx, &span, &callerName, nil)
@@ -530,6 +613,29 @@ func CreateComment(ctx context.Context, tidb *ti.Database, meili *search.MeiliSe
 		}
 	}
 
+	// store the rendered html for the first revision
+	_, err = tx.ExecContext(ctx, &callerName, "update comment set body_html = ? where _id = ? and revision = ?", rendered.HTML, id, comment.Revision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store rendered comment body: %v", err)
+	}
+
+	// rank the new comment so that it can be sorted
+	err = insertDiscussionRank(ctx, tx, callerName, id, models.CommentLevel, discussionId, comment.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// locked discussions do not accept new comments
+	if locked, err := checkDiscussionLocked(ctx, tx, callerName, discussionId); locked != nil || err != nil {
+		return locked, err
+	}
+
+	// subscribe the author to the discussion so they learn about later replies
+	err = subscribeToDiscussion(ctx, tx, callerName, discussionId, callingUser.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	// set leads on parent discussion as true
 	_, err = tidb.ExecContext(ctx, &span, &callerName, "update discussion set leads = true where _id = ?", discussionId)
 	if err != nil {
@@ -537,10 +643,10 @@ func CreateComment(ctx context.Context, tidb *ti.Database, meili *search.MeiliSe
 		return nil, fmt.Errorf("failed to update parent discussion: %v", err)
 	}
 
-	// attempt to insert the comment into the search engine to make it discoverable
-	err = meili.AddDocuments("comment", comment)
+	// queue the comment to be made discoverable once committed
+	err = queueSearchSync(ctx, tx, callerName, "comment", id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to add comment to search engine: %v", err)
+		return nil, err
 	}
 
 	// format discussion to frontend object
@@ -552,13 +658,20 @@ func CreateComment(ctx context.Context, tidb *ti.Database, meili *search.MeiliSe
 		return nil, fmt.Errorf("failed to commit transaction for comment: %v", err)
 	}
 
-	// set failed as false
-	failed = false
-
-	return map[string]interface{}{"message": "Comment has been posted", "comment": commentFrontend}, nil
+	// notify the mentioned users and the subscribers of the discussion in the background
+	publishDiscussionReply(js, logger, DiscussionReplyMsg{
+		DiscussionID: discussionId,
+		ContentID:    id,
+		ContentType:  models.CommentLevel,
+		AuthorID:     callingUser.ID,
+		AuthorName:   callingUser.UserName,
+		Mentions:     rendered.Mentions,
+	})
+
+	return map[string]interface{}{"message": "Comment has been posted", "comment": commentFrontend, "body_html": rendered.HTML}, nil
 }
 
-func CreateThreadComment(ctx context.Context, tidb *ti.Database, meili *search.MeiliSearchEngine, callingUser *models.User, sf *snowflake.Node, commentId int64, body string) (map[string]interface{}, error) {
+func CreateThreadComment(ctx context.Context, tidb *ti.Database, js *mq.JetstreamClient, callingUser *models.User, sf *snowflake.Node, logger logging.Logger, commentId int64, body string) (map[string]interface{}, error) {
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "create-thread-comment-core")
 	callerName := "CreateThreadComment"
 
@@ -570,17 +683,11 @@ func CreateThreadComment(ctx context.Context, tidb *ti.Database, meili *search.M
 		return map[string]interface{}{"message": "You must provide content for your comment"}, fmt.Errorf("provided body was empty. CreateThreadComment Core")
 	}
 
-	// create boolean to track failure
-	failed := true
-
-	// defer function to clean up repo on failure
-	defer func() {
-		// skip cleanup if we succeeded
-		if !failed {
-			return
-		}
-		_ = meili.DeleteDocuments("thread_comment", id)
-	}()
+	// render the markdown body so the html can be stored next to the source
+	rendered, err := renderDiscussionMarkdown(ctx, tidb, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s body: %v", "comment", err)
+	}
 
 	// create transaction for comment insertion
 	tx, err := tidb.BeginTx(ctx, &span, &callerName, nil)
@@ -611,6 +718,35 @@ func CreateThreadComment(ctx context.Context, tidb *ti.Database, meili *search.M
 		}
 	}
 
+	// store the rendered html for the first revision
+	_, err = tx.ExecContext(ctx, &callerName, "update thread_comment set body_html = ? where _id = ? and revision = ?", rendered.HTML, id, threadComment.Revision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store rendered thread_comment body: %v", err)
+	}
+
+	// rank the new thread comment so that it can be sorted
+	err = insertDiscussionRank(ctx, tx, callerName, id, models.ThreadLevel, commentId, threadComment.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// subscribe the author to the discussion so they learn about later replies
+	discussionId, err := discussionIdForContent(ctx, tx, callerName, models.ThreadLevel, commentId)
+	if err != nil {
+		return nil, err
+	}
+	if discussionId != 0 {
+		// locked discussions do not accept new replies
+		if locked, err := checkDiscussionLocked(ctx, tx, callerName, discussionId); locked != nil || err != nil {
+			return locked, err
+		}
+
+		err = subscribeToDiscussion(ctx, tx, callerName, discussionId, callingUser.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// set leads on parent comment as true
 	_, err = tidb.ExecContext(ctx, &span, &callerName, "update comment set leads = true where _id = ?", commentId)
 	if err != nil {
@@ -618,10 +754,10 @@ func CreateThreadComment(ctx context.Context, tidb *ti.Database, meili *search.M
 		return nil, fmt.Errorf("failed to update parent comment: %v", err)
 	}
 
-	// attempt to insert the comment into the search engine to make it discoverable
-	err = meili.AddDocuments("thread_comment", threadComment)
+	// queue the comment to be made discoverable once committed
+	err = queueSearchSync(ctx, tx, callerName, "thread_comment", id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to add thread_comment to search engine: %v", err)
+		return nil, err
 	}
 
 	// format discussion to frontend object
@@ -633,13 +769,20 @@ func CreateThreadComment(ctx context.Context, tidb *ti.Database, meili *search.M
 		return nil, fmt.Errorf("failed to commit transaction for comment: %v", err)
 	}
 
-	// set failed as false
-	failed = false
-
-	return map[string]interface{}{"message": "Comment has been posted", "thread_comment": threadFrontend}, nil
+	// notify the mentioned users and the subscribers of the discussion in the background
+	publishDiscussionReply(js, logger, DiscussionReplyMsg{
+		DiscussionID: discussionId,
+		ContentID:    id,
+		ContentType:  models.ThreadLevel,
+		AuthorID:     callingUser.ID,
+		AuthorName:   callingUser.UserName,
+		Mentions:     rendered.Mentions,
+	})
+
+	return map[string]interface{}{"message": "Comment has been posted", "thread_comment": threadFrontend, "body_html": rendered.HTML}, nil
 }
 
-func CreateThreadReply(ctx context.Context, tidb *ti.Database, callingUser *models.User, sf *snowflake.Node, threadId int64, body string) (map[string]interface{}, error) {
+func CreateThreadReply(ctx context.Context, tidb *ti.Database, js *mq.JetstreamClient, callingUser *models.User, sf *snowflake.Node, logger logging.Logger, threadId int64, body string) (map[string]interface{}, error) {
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "create-thread-reply-core")
 	callerName := "CreateThreadReply"
 
@@ -651,6 +794,12 @@ func CreateThreadReply(ctx context.Context, tidb *ti.Database, callingUser *mode
 		return map[string]interface{}{"message": "You must provide content for your comment"}, fmt.Errorf("provided body was empty. CreateThreadReply Core")
 	}
 
+	// render the markdown body so the html can be stored next to the source
+	rendered, err := renderDiscussionMarkdown(ctx, tidb, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s body: %v", "reply", err)
+	}
+
 	// create transaction for thread reply insertion
 	tx, err := tidb.BeginTx(ctx, &span, &callerName, nil)
 	if err != nil {
@@ -680,6 +829,35 @@ func CreateThreadReply(ctx context.Context, tidb *ti.Database, callingUser *mode
 		}
 	}
 
+	// store the rendered html for the first revision
+	_, err = tx.ExecContext(ctx, &callerName, "update thread_reply set body_html = ? where _id = ? and revision = ?", rendered.HTML, id, threadReply.Revision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store rendered thread_reply body: %v", err)
+	}
+
+	// rank the new thread reply so that it can be sorted
+	err = insertDiscussionRank(ctx, tx, callerName, id, models.ThreadReplyLevel, threadId, threadReply.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// subscribe the author to the discussion so they learn about later replies
+	discussionId, err := discussionIdForContent(ctx, tx, callerName, models.ThreadReplyLevel, threadId)
+	if err != nil {
+		return nil, err
+	}
+	if discussionId != 0 {
+		// locked discussions do not accept new replies
+		if locked, err := checkDiscussionLocked(ctx, tx, callerName, discussionId); locked != nil || err != nil {
+			return locked, err
+		}
+
+		err = subscribeToDiscussion(ctx, tx, callerName, discussionId, callingUser.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// format discussion to frontend object
 	threadReplyFrontend := threadReply.ToFrontend()
 
@@ -695,10 +873,20 @@ func CreateThreadReply(ctx context.Context, tidb *ti.Database, callingUser *mode
 		return nil, fmt.Errorf("failed to update parent thread: %v", err)
 	}
 
-	return map[string]interface{}{"message": "Reply has been posted", "thread_reply": threadReplyFrontend}, nil
+	// notify the mentioned users and the subscribers of the discussion in the background
+	publishDiscussionReply(js, logger, DiscussionReplyMsg{
+		DiscussionID: discussionId,
+		ContentID:    id,
+		ContentType:  models.ThreadReplyLevel,
+		AuthorID:     callingUser.ID,
+		AuthorName:   callingUser.UserName,
+		Mentions:     rendered.Mentions,
+	})
+
+	return map[string]interface{}{"message": "Reply has been posted", "thread_reply": threadReplyFrontend, "body_html": rendered.HTML}, nil
 }
 
-func EditDiscussions(ctx context.Context, tidb *ti.Database, callingUser *models.User, meili *search.MeiliSearchEngine, sf *snowflake.Node, discussionType string, id int64, title *string, body string, tags []*models.Tag) (map[string]interface{}, error) {
+func EditDiscussions(ctx context.Context, tidb *ti.Database, callingUser *models.User, sf *snowflake.Node, discussionType string, id int64, title *string, body string, tags []*models.Tag) (map[string]interface{}, error) {
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "edit-discussions-core")
 	callerName := "EditDiscussions"
 
@@ -712,6 +900,17 @@ func EditDiscussions(ctx context.Context, tidb *ti.Database, callingUser *models
 		return map[string]interface{}{"message": "Title cannot be empty for discussion"}, fmt.Errorf("provided title was empty. EditDiscussions Core")
 	}
 
+	// deleted and hidden content cannot be brought back by a new revision
+	if forbidden, err := checkDiscussionEditable(ctx, tidb, discussionType, id); forbidden != nil || err != nil {
+		return forbidden, err
+	}
+
+	// render the new body so the html can be stored with the new revision
+	rendered, err := renderDiscussionMarkdown(ctx, tidb, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render edited body: %v", err)
+	}
+
 	// switch to handle different CommunicationTypes
 	switch discussionType {
 	case "discussion":
@@ -738,9 +937,14 @@ func EditDiscussions(ctx context.Context, tidb *ti.Database, callingUser *models
 			return nil, fmt.Errorf("could not find discussion with provided id: %v", err)
 		}
 
+		// only the author and the moderators can edit the content
+		if forbidden, err := checkDiscussionEditor(ctx, tidb, callingUser, oldDiscussion.AuthorID); forbidden != nil || err != nil {
+			return forbidden, err
+		}
+
 		// create slice to hold tag ids
 		tagIds := make([]int64, len(tags))
-		newTags := make([]interface{}, 0)
+		newTags := make([]int64, 0)
 
 		// create transaction for discussion insertion
 		tx, err := tidb.BeginTx(ctx, &span, &callerName, nil)
@@ -767,7 +971,7 @@ func EditDiscussions(ctx context.Context, tidb *ti.Database, callingUser *models
 				}
 
 				// add tag to new tags for search engine insertion
-				newTags = append(newTags, tag.ToSearch())
+				newTags = append(newTags, tag.ID)
 			} else {
 				// increment tag column usage_count in database
 				_, err = tx.ExecContext(ctx, &callerName, "update tag set usage_count = usage_count + 1 where id =?", tag.ID)
@@ -788,7 +992,7 @@ func EditDiscussions(ctx context.Context, tidb *ti.Database, callingUser *models
 		}
 
 		// create a new discussion
-		discussion, err := models.CreateDiscussion(oldDiscussion.ID, body, callingUser.UserName, callingUser.ID, oldDiscussion.CreatedAt, time.Now(), callingUser.Tier, oldDiscussion.Awards, oldDiscussion.Coffee, oldDiscussion.PostId, *title, tagIds, oldDiscussion.Leads, newRevision, 0)
+		discussion, err := models.CreateDiscussion(oldDiscussion.ID, body, oldDiscussion.Author, oldDiscussion.AuthorID, oldDiscussion.CreatedAt, time.Now(), oldDiscussion.AuthorTier, oldDiscussion.Awards, oldDiscussion.Coffee, oldDiscussion.PostId, *title, tagIds, oldDiscussion.Leads, newRevision, 0)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create new revision of discussion struct: %v", err)
 		}
@@ -807,19 +1011,20 @@ func EditDiscussions(ctx context.Context, tidb *ti.Database, callingUser *models
 			}
 		}
 
-		// Note: I don't defer meili clean up, because it will keep the information of the previous revision in the case of a failure
-		// attempt to insert the discussion into the search engine to make it discoverable
-		err = meili.AddDocuments("discussion", discussion)
+		// store the rendered html and the editor of the new revision
+		_, err = tx.ExecContext(ctx, &callerName, "update discussion set body_html = ?, edited_at = ?, edited_by = ? where _id = ? and revision = ?", rendered.HTML, time.Now(), callingUser.ID, id, newRevision)
 		if err != nil {
-			return nil, fmt.Errorf("failed to add discussion to search engine: %v", err)
+			return nil, fmt.Errorf("failed to store rendered discussion body: %v", err)
 		}
 
-		// conditionally attempt to insert the tags into the search engine to make it discoverable
-		if len(newTags) > 0 {
-			err = meili.AddDocuments("tags", newTags...)
-			if err != nil {
-				return nil, fmt.Errorf("failed to add new discussion tags to search engine: %v", err)
-			}
+		// queue the new revision and the new tags to be synced to the search engine once committed
+		err = queueSearchSync(ctx, tx, callerName, "discussion", id)
+		if err != nil {
+			return nil, err
+		}
+		err = queueSearchSync(ctx, tx, callerName, "tags", newTags...)
+		if err != nil {
+			return nil, err
 		}
 
 		// format discussion to frontend object
@@ -831,7 +1036,7 @@ func EditDiscussions(ctx context.Context, tidb *ti.Database, callingUser *models
 			return nil, fmt.Errorf("failed to commit transaction for discussion: %v", err)
 		}
 
-		return map[string]interface{}{"message": "Discussion has been successfully edited", "new_discussion": discussionFrontend}, nil
+		return map[string]interface{}{"message": "Discussion has been successfully edited", "new_discussion": discussionFrontend, "body_html": rendered.HTML}, nil
 
 	case "comment":
 		// model to hold query results
@@ -855,6 +1060,11 @@ func EditDiscussions(ctx context.Context, tidb *ti.Database, callingUser *models
 			return nil, fmt.Errorf("could not find comment with provided id: %v", err)
 		}
 
+		// only the author and the moderators can edit the content
+		if forbidden, err := checkDiscussionEditor(ctx, tidb, callingUser, oldComment.AuthorID); forbidden != nil || err != nil {
+			return forbidden, err
+		}
+
 		defer res.Close()
 
 		// create transaction for comment insertion
@@ -870,7 +1080,7 @@ func EditDiscussions(ctx context.Context, tidb *ti.Database, callingUser *models
 		newRevision := oldComment.Revision + 1
 
 		// create a new comment
-		comment, err := models.CreateComment(oldComment.ID, body, callingUser.UserName, callingUser.ID, time.Now(), callingUser.Tier, oldComment.Awards, oldComment.Coffee, oldComment.DiscussionId, oldComment.Leads, newRevision, 1)
+		comment, err := models.CreateComment(oldComment.ID, body, oldComment.Author, oldComment.AuthorID, oldComment.CreatedAt, oldComment.AuthorTier, oldComment.Awards, oldComment.Coffee, oldComment.DiscussionId, oldComment.Leads, newRevision, 1)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create new comment struct: %v", err)
 		}
@@ -889,10 +1099,16 @@ func EditDiscussions(ctx context.Context, tidb *ti.Database, callingUser *models
 			}
 		}
 
-		// attempt to insert the comment into the search engine to make it discoverable
-		err = meili.AddDocuments("comment", comment)
+		// store the rendered html and the editor of the new revision
+		_, err = tx.ExecContext(ctx, &callerName, "update comment set body_html = ?, edited_at = ?, edited_by = ? where _id = ? and revision = ?", rendered.HTML, time.Now(), callingUser.ID, id, newRevision)
 		if err != nil {
-			return nil, fmt.Errorf("failed to add comment to search engine: %v", err)
+			return nil, fmt.Errorf("failed to store rendered comment body: %v", err)
+		}
+
+		// queue the new revision to be synced to the search engine once committed
+		err = queueSearchSync(ctx, tx, callerName, "comment", id)
+		if err != nil {
+			return nil, err
 		}
 
 		// format discussion to frontend object
@@ -904,7 +1120,7 @@ func EditDiscussions(ctx context.Context, tidb *ti.Database, callingUser *models
 			return nil, fmt.Errorf("failed to commit transaction for comment: %v", err)
 		}
 
-		return map[string]interface{}{"message": "Comment has been successfully edited", "new_comment": commentFrontend}, nil
+		return map[string]interface{}{"message": "Comment has been successfully edited", "new_comment": commentFrontend, "body_html": rendered.HTML}, nil
 	case "thread_comment":
 		// model to hold query results
 		var oldThread *models.ThreadComment
@@ -927,6 +1143,11 @@ func EditDiscussions(ctx context.Context, tidb *ti.Database, callingUser *models
 			return nil, fmt.Errorf("could not find thread with provided id: %v", err)
 		}
 
+		// only the author and the moderators can edit the content
+		if forbidden, err := checkDiscussionEditor(ctx, tidb, callingUser, oldThread.AuthorID); forbidden != nil || err != nil {
+			return forbidden, err
+		}
+
 		defer res.Close()
 
 		// create transaction for thread comment insertion
@@ -942,7 +1163,7 @@ func EditDiscussions(ctx context.Context, tidb *ti.Database, callingUser *models
 		newRevision := oldThread.Revision + 1
 
 		// create a new comment
-		threadComment, err := models.CreateThreadComment(oldThread.ID, body, callingUser.UserName, callingUser.ID, time.Now(), callingUser.Tier, oldThread.Coffee, oldThread.CommentId, oldThread.Leads, newRevision, 2)
+		threadComment, err := models.CreateThreadComment(oldThread.ID, body, oldThread.Author, oldThread.AuthorID, oldThread.CreatedAt, oldThread.AuthorTier, oldThread.Coffee, oldThread.CommentId, oldThread.Leads, newRevision, 2)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create new thread_comment struct: %v", err)
 		}
@@ -961,10 +1182,16 @@ func EditDiscussions(ctx context.Context, tidb *ti.Database, callingUser *models
 			}
 		}
 
-		// attempt to insert the comment into the search engine to make it discoverable
-		err = meili.AddDocuments("thread_comment", threadComment)
+		// store the rendered html and the editor of the new revision
+		_, err = tx.ExecContext(ctx, &callerName, "update thread_comment set body_html = ?, edited_at = ?, edited_by = ? where _id = ? and revision = ?", rendered.HTML, time.Now(), callingUser.ID, id, newRevision)
 		if err != nil {
-			return nil, fmt.Errorf("failed to add thread_comment to search engine: %v", err)
+			return nil, fmt.Errorf("failed to store rendered thread_comment body: %v", err)
+		}
+
+		// queue the new revision to be synced to the search engine once committed
+		err = queueSearchSync(ctx, tx, callerName, "thread_comment", id)
+		if err != nil {
+			return nil, err
 		}
 
 		// format discussion to frontend object
@@ -976,7 +1203,7 @@ func EditDiscussions(ctx context.Context, tidb *ti.Database, callingUser *models
 			return nil, fmt.Errorf("failed to commit transaction for comment: %v", err)
 		}
 
-		return map[string]interface{}{"message": "Comment has been successfully edited", "new_thread_comment": threadFrontend}, nil
+		return map[string]interface{}{"message": "Comment has been successfully edited", "new_thread_comment": threadFrontend, "body_html": rendered.HTML}, nil
 	case "thread_reply":
 		// model to hold query results
 		var oldThreadReply *models.ThreadReply
@@ -999,6 +1226,11 @@ func EditDiscussions(ctx context.Context, tidb *ti.Database, callingUser *models
 			return nil, fmt.Errorf("could not find thread reply with provided id: %v", err)
 		}
 
+		// only the author and the moderators can edit the content
+		if forbidden, err := checkDiscussionEditor(ctx, tidb, callingUser, oldThreadReply.AuthorID); forbidden != nil || err != nil {
+			return forbidden, err
+		}
+
 		defer res.Close()
 
 		// create transaction for new thread reply revision insertion
@@ -1014,7 +1246,7 @@ func EditDiscussions(ctx context.Context, tidb *ti.Database, callingUser *models
 		newRevision := oldThreadReply.Revision + 1
 
 		// create a new thread reply
-		threadReply, err := models.CreateThreadReply(oldThreadReply.ID, body, callingUser.UserName, callingUser.ID, time.Now(), callingUser.Tier, oldThreadReply.Coffee, oldThreadReply.ThreadCommentId, newRevision, 3)
+		threadReply, err := models.CreateThreadReply(oldThreadReply.ID, body, oldThreadReply.Author, oldThreadReply.AuthorID, oldThreadReply.CreatedAt, oldThreadReply.AuthorTier, oldThreadReply.Coffee, oldThreadReply.ThreadCommentId, newRevision, 3)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create new thread_reply struct: %v", err)
 		}
@@ -1033,6 +1265,12 @@ func EditDiscussions(ctx context.Context, tidb *ti.Database, callingUser *models
 			}
 		}
 
+		// store the rendered html and the editor of the new revision
+		_, err = tx.ExecContext(ctx, &callerName, "update thread_reply set body_html = ?, edited_at = ?, edited_by = ? where _id = ? and revision = ?", rendered.HTML, time.Now(), callingUser.ID, id, newRevision)
+		if err != nil {
+			return nil, fmt.Errorf("failed to store rendered thread_reply body: %v", err)
+		}
+
 		// format discussion to frontend object
 		threadReplyFrontend := threadReply.ToFrontend()
 
@@ -1042,7 +1280,7 @@ func EditDiscussions(ctx context.Context, tidb *ti.Database, callingUser *models
 			return nil, fmt.Errorf("failed to commit transaction for comment: %v", err)
 		}
 
-		return map[string]interface{}{"message": "Reply has been successfully edited", "new_thread_reply": threadReplyFrontend}, nil
+		return map[string]interface{}{"message": "Reply has been successfully edited", "new_thread_reply": threadReplyFrontend, "body_html": rendered.HTML}, nil
 	default:
 		return nil, fmt.Errorf("invalid CommunicationType pass. EditDiscussions Core")
 	}
@@ -1108,6 +1346,12 @@ func AddDiscussionCoffee(ctx context.Context, tidb *ti.Database, callingUser *mo
 		return nil, fmt.Errorf("failed to add coffee to discussion: %v", err)
 	}
 
+	// update the ranking with the new coffee
+	err = updateDiscussionRankCoffee(ctx, tidb, id, 1)
+	if err != nil {
+		return nil, err
+	}
+
 	return map[string]interface{}{"message": "Coffee added to discussion"}, nil
 }
 
@@ -1241,6 +1485,12 @@ func RemoveDiscussionCoffee(ctx context.Context, tidb *ti.Database, callingUser
 		return nil, fmt.Errorf("failed to remove coffee from discussion: %v", err)
 	}
 
+	// update the ranking with the new coffee
+	err = updateDiscussionRankCoffee(ctx, tidb, id, -1)
+	if err != nil {
+		return nil, err
+	}
+
 	// perform delete query
 	_, err = tidb.ExecContext(ctx, &span, &callerName, deleteQuery, id, callingUser.ID)
 	if err != nil {
@@ -3,6 +3,7 @@ package core
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"testing"
@@ -160,7 +161,7 @@ func TestGetDiscussions(t *testing.T) {
 		}
 	}()
 
-	res, err := GetDiscussions(context.Background(), testTiDB, user, 420, 0, 10)
+	res, err := GetDiscussions(context.Background(), testTiDB, user, 420, 0, 10, "new")
 	if err != nil {
 		t.Errorf("\nTestGetDiscussions failed\n    Error: %v", err)
 		return
@@ -268,7 +269,7 @@ func TestGetDiscussionComments(t *testing.T) {
 
 	idArray := []int64{420, 69}
 
-	res, err := GetDiscussionComments(context.Background(), testTiDB, user, idArray, 0, 10)
+	res, err := GetDiscussionComments(context.Background(), testTiDB, user, idArray, 0, 10, "new")
 	if err != nil {
 		t.Errorf("\nTestGetDiscussionComments failed\n    Error: %v", err)
 		return
@@ -396,7 +397,7 @@ func TestGetCommentThreads(t *testing.T) {
 
 	idArray := []int64{420, 69}
 
-	res, err := GetCommentThreads(context.Background(), testTiDB, user, idArray, 0, 10)
+	res, err := GetCommentThreads(context.Background(), testTiDB, user, idArray, 0, 10, "new")
 	if err != nil {
 		t.Errorf("\nTestGetCommentThreads failed\n    Error: %v", err)
 		return
@@ -506,7 +507,7 @@ func TestGetGetThreadReply(t *testing.T) {
 
 	idArray := []int64{420, 69}
 
-	res, err := GetThreadReply(context.Background(), testTiDB, user, idArray, 0, 10)
+	res, err := GetThreadReply(context.Background(), testTiDB, user, idArray, 0, 10, "new")
 	if err != nil {
 		t.Errorf("\nTestGetGetThreadReply failed\n    Error: %v", err)
 		return
@@ -584,7 +585,9 @@ func TestCreateDiscussion(t *testing.T) {
 		return
 	}
 
-	discussion, err := CreateDiscussion(context.Background(), testTiDB, meili, user, testSnowflake, 69, "test-title", "test123", nil)
+	js, logger := testDiscussionJetstream(t)
+
+	discussion, err := CreateDiscussion(context.Background(), testTiDB, js, user, testSnowflake, logger, 69, "test-title", "test123", nil)
 	if err != nil {
 		t.Errorf("\nTestCreateDiscussion failed\n    Error: %v\n", err)
 		return
@@ -663,7 +666,9 @@ func TestCreateComment(t *testing.T) {
 		return
 	}
 
-	comment, err := CreateComment(context.Background(), testTiDB, meili, user, testSnowflake, 69, "test123")
+	js, logger := testDiscussionJetstream(t)
+
+	comment, err := CreateComment(context.Background(), testTiDB, js, user, testSnowflake, logger, 69, "test123")
 	if err != nil {
 		t.Errorf("\nTestCreateComment failed\n    Error: %v\n", err)
 		return
@@ -744,7 +749,9 @@ func TestCreateThreadComment(t *testing.T) {
 		return
 	}
 
-	threadComment, err := CreateThreadComment(context.Background(), testTiDB, meili, user, testSnowflake, 69, "test123")
+	js, logger := testDiscussionJetstream(t)
+
+	threadComment, err := CreateThreadComment(context.Background(), testTiDB, js, user, testSnowflake, logger, 69, "test123")
 	if err != nil {
 		t.Errorf("\nTestCreateThreadComment failed\n    Error: %v\n", err)
 		return
@@ -825,7 +832,9 @@ func TestCreateThreadReply(t *testing.T) {
 		return
 	}
 
-	thread, err := CreateThreadComment(context.Background(), testTiDB, meili, user, testSnowflake, 69, "test123")
+	js, logger := testDiscussionJetstream(t)
+
+	thread, err := CreateThreadComment(context.Background(), testTiDB, js, user, testSnowflake, logger, 69, "test123")
 	if err != nil {
 		t.Errorf("\nTestCreateThreadReply failed\n    Error: %v\n", err)
 		return
@@ -837,7 +846,7 @@ func TestCreateThreadReply(t *testing.T) {
 		return
 	}
 
-	threadReply, err := CreateThreadReply(context.Background(), testTiDB, user, testSnowflake, id, "test123")
+	threadReply, err := CreateThreadReply(context.Background(), testTiDB, js, user, testSnowflake, logger, id, "test123")
 	if err != nil {
 		t.Errorf("\nTestCreateThreadReply failed\n    Error: %v\n", err)
 		return
@@ -929,19 +938,21 @@ func TestEditDiscussions(t *testing.T) {
 		meili.DeleteDocuments("discussion", 69)
 	}()
 
-	discussion, err := CreateDiscussion(context.Background(), testTiDB, meili, user, testSnowflake, 69, "title", "body", nil)
+	js, logger := testDiscussionJetstream(t)
+
+	discussion, err := CreateDiscussion(context.Background(), testTiDB, js, user, testSnowflake, logger, 69, "title", "body", nil)
 	if err != nil {
 		t.Errorf("\nTestEditDiscussions failed\n    Error: %v\n", err)
 		return
 	}
 
-	comment, err := CreateComment(context.Background(), testTiDB, meili, user, testSnowflake, 69, "body")
+	comment, err := CreateComment(context.Background(), testTiDB, js, user, testSnowflake, logger, 69, "body")
 	if err != nil {
 		t.Errorf("\nTestEditDiscussions failed\n    Error: %v\n", err)
 		return
 	}
 
-	thread, err := CreateThreadComment(context.Background(), testTiDB, meili, user, testSnowflake, 69, "body")
+	thread, err := CreateThreadComment(context.Background(), testTiDB, js, user, testSnowflake, logger, 69, "body")
 	if err != nil {
 		t.Errorf("\nTestEditDiscussions failed\n    Error: %v\n", err)
 		return
@@ -956,7 +967,7 @@ func TestEditDiscussions(t *testing.T) {
 	discId, err := strconv.ParseInt(discussion["discussion"].(*models.DiscussionFrontend).ID, 10, 64)
 	// newTitle := "edited title"
 
-	editDiscussion, err := EditDiscussions(context.Background(), testTiDB, user, meili, testSnowflake, "discussion", discId, nil, "edited body", nil)
+	editDiscussion, err := EditDiscussions(context.Background(), testTiDB, user, testSnowflake, "discussion", discId, nil, "edited body", nil)
 	if err != nil {
 		t.Errorf("\nTestEditDiscussions failed\n    Error: %v\n", err)
 		return
@@ -974,7 +985,7 @@ func TestEditDiscussions(t *testing.T) {
 
 	commId, err := strconv.ParseInt(comment["comment"].(*models.CommentFrontend).ID, 10, 64)
 
-	editComment, err := EditDiscussions(context.Background(), testTiDB, user, meili, testSnowflake, "comment", commId, nil, "edited body", nil)
+	editComment, err := EditDiscussions(context.Background(), testTiDB, user, testSnowflake, "comment", commId, nil, "edited body", nil)
 	if err != nil {
 		t.Errorf("\nTestEditDiscussions failed\n    Error: %v\n", err)
 		return
@@ -986,7 +997,7 @@ func TestEditDiscussions(t *testing.T) {
 
 	thrId, err := strconv.ParseInt(thread["thread_comment"].(*models.ThreadCommentFrontend).ID, 10, 64)
 
-	editThread, err := EditDiscussions(context.Background(), testTiDB, user, meili, testSnowflake, "thread_comment", thrId, nil, "edited body", nil)
+	editThread, err := EditDiscussions(context.Background(), testTiDB, user, testSnowflake, "thread_comment", thrId, nil, "edited body", nil)
 	if err != nil {
 		t.Errorf("\nTestEditDiscussions failed\n    Error: %v\n", err)
 		return
@@ -995,6 +1006,28 @@ func TestEditDiscussions(t *testing.T) {
 	if editThread["new_thread_comment"].(*models.ThreadCommentFrontend).Body != "edited body" {
 		t.Errorf("\nTestEditDiscussions failed\n    Error: body not updated")
 	}
+
+	// hidden content cannot be edited since the new revision would not be hidden
+	err = SetContentHidden(context.Background(), testTiDB, ReportContentComment, commId, true)
+	if err != nil {
+		t.Errorf("\nTestEditDiscussions failed\n    Error: %v\n", err)
+		return
+	}
+
+	_, err = EditDiscussions(context.Background(), testTiDB, user, testSnowflake, "comment", commId, nil, "hidden body", nil)
+	if !errors.Is(err, ErrForbidden) {
+		t.Errorf("\nTestEditDiscussions failed\n    Error: expected hidden comment edit to be forbidden, got %v\n", err)
+	}
+
+	var hiddenBody string
+	err = testTiDB.DB.QueryRow("select body from comment where _id = ? order by revision desc limit 1", commId).Scan(&hiddenBody)
+	if err != nil {
+		t.Errorf("\nTestEditDiscussions failed\n    Error: %v\n", err)
+		return
+	}
+	if hiddenBody != "edited body" {
+		t.Errorf("\nTestEditDiscussions failed\n    Error: hidden comment was edited to %q", hiddenBody)
+	}
 }
 
 func TestAddDiscussionCoffee(t *testing.T) {
@@ -1042,13 +1075,15 @@ func TestAddDiscussionCoffee(t *testing.T) {
 		meili.DeleteDocuments("discussion", 69)
 	}()
 
-	discussion, err := CreateDiscussion(context.Background(), testTiDB, meili, user, testSnowflake, 69, "title", "body", nil)
+	js, logger := testDiscussionJetstream(t)
+
+	discussion, err := CreateDiscussion(context.Background(), testTiDB, js, user, testSnowflake, logger, 69, "title", "body", nil)
 	if err != nil {
 		t.Errorf("\nTestAddDiscussionCoffee failed\n    Error: %v\n", err)
 		return
 	}
 
-	comment, err := CreateComment(context.Background(), testTiDB, meili, user, testSnowflake, 69, "body")
+	comment, err := CreateComment(context.Background(), testTiDB, js, user, testSnowflake, logger, 69, "body")
 	if err != nil {
 		t.Errorf("\nTestAddDiscussionCoffee failed\n    Error: %v\n", err)
 		return
@@ -1184,13 +1219,15 @@ func TestRemoveDiscussionCoffee(t *testing.T) {
 		meili.DeleteDocuments("discussion", 69)
 	}()
 
-	discussion, err := CreateDiscussion(context.Background(), testTiDB, meili, user, testSnowflake, 69, "title", "body", nil)
+	js, logger := testDiscussionJetstream(t)
+
+	discussion, err := CreateDiscussion(context.Background(), testTiDB, js, user, testSnowflake, logger, 69, "title", "body", nil)
 	if err != nil {
 		t.Errorf("\nTestRemoveDiscussionCoffee failed\n    Error: %v\n", err)
 		return
 	}
 
-	comment, err := CreateComment(context.Background(), testTiDB, meili, user, testSnowflake, 69, "body")
+	comment, err := CreateComment(context.Background(), testTiDB, js, user, testSnowflake, logger, 69, "body")
 	if err != nil {
 		t.Errorf("\nTestRemoveDiscussionCoffee failed\n    Error: %v\n", err)
 		return
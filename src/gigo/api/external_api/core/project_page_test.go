@@ -157,7 +157,7 @@ func TestProjectAttempts(t *testing.T) {
 	skip := 0
 	limit := 10
 
-	attemptsInfo, err := ProjectAttempts(context.Background(), testTiDB, post.ID, skip, limit)
+	attemptsInfo, err := ProjectAttempts(context.Background(), testTiDB, nil, post.ID, skip, limit)
 	if err != nil {
 		t.Error("\nTestProjectAttempts failed\n    Error: ", err)
 		return
@@ -186,6 +186,35 @@ func TestProjectAttempts(t *testing.T) {
 		}
 	}
 
+	// hide the attempts of the author from everyone else
+	_, err = testTiDB.DB.Exec("update users set privacy_settings = ? where _id = ?", `{"attempts": 2}`, user.ID)
+	if err != nil {
+		t.Errorf("\nTestProjectAttempts failed\n    Error: %v\n", err)
+		return
+	}
+
+	attemptsInfo, err = ProjectAttempts(context.Background(), testTiDB, nil, post.ID, skip, limit)
+	if err != nil {
+		t.Errorf("\nTestProjectAttempts failed\n    Error: %v\n", err)
+		return
+	}
+
+	if len(attemptsInfo["attempts"].([]*query_models.AttemptUserBackgroundFrontend)) != 0 {
+		t.Error("\nTestProjectAttempts failed\n    Error: private attempts returned to an anonymous caller")
+		return
+	}
+
+	attemptsInfo, err = ProjectAttempts(context.Background(), testTiDB, user, post.ID, skip, limit)
+	if err != nil {
+		t.Errorf("\nTestProjectAttempts failed\n    Error: %v\n", err)
+		return
+	}
+
+	if len(attemptsInfo["attempts"].([]*query_models.AttemptUserBackgroundFrontend)) != 2 {
+		t.Error("\nTestProjectAttempts failed\n    Error: private attempts hidden from their author")
+		return
+	}
+
 	t.Log("\nTestProjectAttempts succeeded")
 }
 
@@ -0,0 +1,675 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/git"
+	"github.com/gage-technologies/gitea-go/gitea"
+	"github.com/go-git/go-billy/v5/memfs"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/kisielk/sqlstruct"
+	"go.opentelemetry.io/otel"
+)
+
+type ProjectRelease struct {
+	ID        int64     `json:"_id" sql:"_id"`
+	PostID    int64     `json:"post_id" sql:"post_id"`
+	Version   int       `json:"version" sql:"version"`
+	Commit    string    `json:"commit" sql:"commit"`
+	Title     string    `json:"title" sql:"title"`
+	Notes     string    `json:"notes" sql:"notes"`
+	AuthorID  int64     `json:"author_id" sql:"author_id"`
+	CreatedAt time.Time `json:"created_at" sql:"created_at"`
+}
+
+type ProjectReleaseFrontend struct {
+	ID        string    `json:"_id"`
+	PostID    string    `json:"post_id"`
+	Version   int       `json:"version"`
+	Tag       string    `json:"tag"`
+	Commit    string    `json:"commit"`
+	Title     string    `json:"title"`
+	Notes     string    `json:"notes"`
+	AuthorID  string    `json:"author_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CreateProjectReleaseRequest struct {
+	ProjectID string `json:"project_id" validate:"required,number"`
+	Commit    string `json:"commit" validate:"lte=64"`
+	Title     string `json:"title" validate:"required,lte=255"`
+	Notes     string `json:"notes" validate:"lte=20000"`
+	Test      bool   `json:"test"`
+}
+
+// ReleaseTag returns the name of the git tag that marks the release
+func ReleaseTag(version int) string {
+	return fmt.Sprintf("v%d", version)
+}
+
+func (r *ProjectRelease) ToFrontend() *ProjectReleaseFrontend {
+	return &ProjectReleaseFrontend{
+		ID:        fmt.Sprintf("%d", r.ID),
+		PostID:    fmt.Sprintf("%d", r.PostID),
+		Version:   r.Version,
+		Tag:       ReleaseTag(r.Version),
+		Commit:    r.Commit,
+		Title:     r.Title,
+		Notes:     r.Notes,
+		AuthorID:  fmt.Sprintf("%d", r.AuthorID),
+		CreatedAt: r.CreatedAt,
+	}
+}
+
+// getLatestRelease loads the most recent release of a project or nil if the
+// project has never been released
+func getLatestRelease(ctx context.Context, tidb *ti.Database, postId int64) (*ProjectRelease, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "get-latest-release-core")
+	defer span.End()
+	callerName := "getLatestRelease"
+
+	res, err := tidb.QueryContext(ctx, &span, &callerName,
+		"select * from post_release where post_id = ? order by version desc limit 1", postId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query latest release: %v", err)
+	}
+
+	defer res.Close()
+
+	if !res.Next() {
+		return nil, nil
+	}
+
+	var release ProjectRelease
+	err = sqlstruct.Scan(&release, res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan release: %v", err)
+	}
+
+	return &release, nil
+}
+
+// resolveAttemptRelease determines the release that a new attempt is pinned to.
+// Attempts forked from another attempt inherit the release of their parent while
+// all other attempts are pinned to the latest release of the project.
+func resolveAttemptRelease(ctx context.Context, tidb *ti.Database, postId int64, parentAttempt *int64) (sql.NullInt64, sql.NullInt64, sql.NullString, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "resolve-attempt-release-core")
+	defer span.End()
+	callerName := "resolveAttemptRelease"
+
+	var releaseId sql.NullInt64
+	var releaseVersion sql.NullInt64
+	var releaseCommit sql.NullString
+
+	if parentAttempt != nil {
+		err := tidb.QueryRowContext(ctx, &span, &callerName,
+			"select release_id, release_version, release_commit from attempt where _id = ? limit 1",
+			*parentAttempt,
+		).Scan(&releaseId, &releaseVersion, &releaseCommit)
+		if err != nil {
+			return releaseId, releaseVersion, releaseCommit, fmt.Errorf("failed to query parent attempt release: %v", err)
+		}
+		return releaseId, releaseVersion, releaseCommit, nil
+	}
+
+	latest, err := getLatestRelease(ctx, tidb, postId)
+	if err != nil {
+		return releaseId, releaseVersion, releaseCommit, err
+	}
+
+	if latest != nil {
+		releaseId = sql.NullInt64{Int64: latest.ID, Valid: true}
+		releaseVersion = sql.NullInt64{Int64: int64(latest.Version), Valid: true}
+		releaseCommit = sql.NullString{String: latest.Commit, Valid: true}
+	}
+
+	return releaseId, releaseVersion, releaseCommit, nil
+}
+
+// resetForkToRelease moves the main branch of a freshly forked attempt repo
+// back to the release commit that the attempt is pinned to. Forks are copied
+// from the head of the challenge so without the reset an attempt would get the
+// commits made after the release.
+func resetForkToRelease(ctx context.Context, vcsClient *git.VCSClient, owner string, repoName string,
+	servicePassword string, commit string) error {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "reset-fork-to-release-core")
+	defer span.End()
+
+	auth := &http.BasicAuth{
+		Username: owner,
+		Password: servicePassword,
+	}
+
+	// the objects of the release are already in the fork so only the refs are needed locally
+	repo, err := gogit.CloneContext(ctx, memory.NewStorage(), nil, &gogit.CloneOptions{
+		URL:        fmt.Sprintf("%s/%s/%s.git", strings.TrimSuffix(vcsClient.HostUrl, "/"), owner, repoName),
+		Auth:       auth,
+		NoCheckout: true,
+		Tags:       gogit.NoTags,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to clone attempt repo: %v", err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("failed to load head of attempt repo: %v", err)
+	}
+
+	releaseHash := plumbing.NewHash(commit)
+	if head.Hash() == releaseHash {
+		return nil
+	}
+
+	_, err = repo.CommitObject(releaseHash)
+	if err != nil {
+		return fmt.Errorf("failed to find release commit %s in attempt repo: %v", commit, err)
+	}
+
+	releaseRef := plumbing.NewBranchReferenceName("gigo-release")
+	err = repo.Storer.SetReference(plumbing.NewHashReference(releaseRef, releaseHash))
+	if err != nil {
+		return fmt.Errorf("failed to create release ref: %v", err)
+	}
+
+	err = repo.PushContext(ctx, &gogit.PushOptions{
+		RemoteName: "origin",
+		Auth:       auth,
+		RefSpecs:   []config.RefSpec{config.RefSpec(fmt.Sprintf("+%s:refs/heads/main", releaseRef))},
+		Force:      true,
+	})
+	if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("failed to reset attempt repo to release: %v", err)
+	}
+
+	return nil
+}
+
+// CreateProjectRelease tags a commit of the project repository as the next
+// version of the project. New attempts are pinned to the latest release and
+// existing attempts can opt in to upgrading via UpgradeAttemptRelease.
+func CreateProjectRelease(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClient, sf *snowflake.Node,
+	callingUser *models.User, postId int64, commit string, title string, notes string) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "create-project-release-core")
+	defer span.End()
+	callerName := "CreateProjectRelease"
+
+	// load the post author to ensure that only the owner can release
+	var authorId int64
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		"select author_id from post where _id = ? and deleted = false limit 1", postId,
+	).Scan(&authorId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return map[string]interface{}{"message": "project not found"}, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to query post: %v", err)
+	}
+
+	if authorId != callingUser.ID {
+		return map[string]interface{}{"message": "you do not have permission to release this project"},
+			fmt.Errorf("user %d is not the author of post %d", callingUser.ID, postId)
+	}
+
+	// default to the head of the main branch
+	if commit == "" {
+		commit = "main"
+	}
+
+	repoOwner := fmt.Sprintf("%d", authorId)
+	repoName := fmt.Sprintf("%d", postId)
+
+	// resolve the ref into a concrete commit so the release can never move
+	gitCommit, gitRes, err := vcsClient.GiteaClient.GetSingleCommit(repoOwner, repoName, commit)
+	if err != nil {
+		if gitRes != nil && gitRes.StatusCode == 404 {
+			return map[string]interface{}{"message": "commit not found"}, fmt.Errorf("commit %s not found in %s/%s", commit, repoOwner, repoName)
+		}
+		return nil, fmt.Errorf("failed to retrieve commit %s: %v\n    res: %s", commit, err, JsonifyGiteaResponse(gitRes))
+	}
+	sha := gitCommit.CommitMeta.SHA
+
+	latest, err := getLatestRelease(ctx, tidb, postId)
+	if err != nil {
+		return nil, err
+	}
+
+	version := 1
+	if latest != nil {
+		if latest.Commit == sha {
+			return map[string]interface{}{"message": "this commit has already been released"},
+				fmt.Errorf("commit %s is already released as %s", sha, ReleaseTag(latest.Version))
+		}
+		version = latest.Version + 1
+	}
+
+	release := &ProjectRelease{
+		ID:        sf.Generate().Int64(),
+		PostID:    postId,
+		Version:   version,
+		Commit:    sha,
+		Title:     title,
+		Notes:     notes,
+		AuthorID:  callingUser.ID,
+		CreatedAt: time.Now(),
+	}
+
+	// the unique key on (post_id, version) protects against concurrent releases
+	_, err = tidb.ExecContext(ctx, &span, &callerName,
+		"insert into post_release(_id, post_id, version, commit, title, notes, author_id, created_at) values (?, ?, ?, ?, ?, ?, ?, ?)",
+		release.ID, release.PostID, release.Version, release.Commit, release.Title, release.Notes, release.AuthorID, release.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert release: %v", err)
+	}
+
+	// create the release in the repository which also creates the version tag.
+	// the release row is inserted first so that a concurrent release fails on
+	// the unique key before a tag is created and is removed if tagging fails
+	_, gitRes, err = vcsClient.GiteaClient.CreateRelease(repoOwner, repoName, gitea.CreateReleaseOption{
+		TagName: ReleaseTag(version),
+		Target:  sha,
+		Title:   title,
+		Note:    notes,
+	})
+	if err != nil {
+		_, delErr := tidb.ExecContext(ctx, &span, &callerName, "delete from post_release where _id = ?", release.ID)
+		if delErr != nil {
+			return nil, fmt.Errorf("failed to remove release %d after failing to create release %s for %s/%s: %v\n    create err: %v\n    res: %s",
+				release.ID, ReleaseTag(version), repoOwner, repoName, delErr, err, JsonifyGiteaResponse(gitRes))
+		}
+		return nil, fmt.Errorf("failed to create release %s for %s/%s: %v\n    res: %s",
+			ReleaseTag(version), repoOwner, repoName, err, JsonifyGiteaResponse(gitRes))
+	}
+
+	return map[string]interface{}{"message": "Release created successfully.", "release": release.ToFrontend()}, nil
+}
+
+// GetProjectReleases retrieves the version history of a project with the
+// newest release first. Releases of unpublished or private projects are only
+// visible to the author.
+func GetProjectReleases(ctx context.Context, tidb *ti.Database, callingUser *models.User, postId int64) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "get-project-releases-core")
+	defer span.End()
+	callerName := "GetProjectReleases"
+
+	var callerId int64
+	if callingUser != nil {
+		callerId = callingUser.ID
+	}
+
+	// ensure the project is visible to the caller
+	var authorId int64
+	var published bool
+	var visibility models.PostVisibility
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		"select author_id, published, visibility from post where _id = ? and deleted = false limit 1", postId,
+	).Scan(&authorId, &published, &visibility)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return map[string]interface{}{"message": "project not found"}, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to query post: %v", err)
+	}
+
+	if authorId != callerId && (!published || visibility == models.PrivateVisibility) {
+		return map[string]interface{}{"message": "project not found"}, ErrNotFound
+	}
+
+	res, err := tidb.QueryContext(ctx, &span, &callerName,
+		"select * from post_release where post_id = ? order by version desc", postId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query releases: %v", err)
+	}
+
+	defer res.Close()
+
+	releases := make([]*ProjectReleaseFrontend, 0)
+	for res.Next() {
+		var release ProjectRelease
+		err = sqlstruct.Scan(&release, res)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan release: %v", err)
+		}
+		releases = append(releases, release.ToFrontend())
+	}
+
+	return map[string]interface{}{"releases": releases}, nil
+}
+
+// repoTreeEntries loads the blobs of a repository at the passed ref
+func repoTreeEntries(vcsClient *git.VCSClient, owner string, repo string, ref string) ([]gitea.GitEntry, error) {
+	tree, gitRes, err := vcsClient.GiteaClient.GetTrees(owner, repo, ref, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve tree %s/%s@%s: %v\n    res: %s", owner, repo, ref, err, JsonifyGiteaResponse(gitRes))
+	}
+
+	if tree.Truncated {
+		return nil, fmt.Errorf("tree %s/%s@%s is too large to load", owner, repo, ref)
+	}
+
+	entries := make([]gitea.GitEntry, 0, len(tree.Entries))
+	for _, entry := range tree.Entries {
+		if entry.Type != "blob" {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// repoTree loads the blob shas of every file in a repository at the passed ref
+func repoTree(vcsClient *git.VCSClient, owner string, repo string, ref string) (map[string]string, error) {
+	entries, err := repoTreeEntries(vcsClient, owner, repo, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]string)
+	for _, entry := range entries {
+		files[entry.Path] = entry.SHA
+	}
+
+	return files, nil
+}
+
+// RepoFile is a file that is committed to a repository by pushRepoFiles
+type RepoFile struct {
+	Path       string
+	Executable bool
+	Content    []byte
+}
+
+// pushRepoFiles commits the files to the main branch of a repository as the
+// owner of the repository in a single commit. The removed paths are deleted
+// from the repository in the same commit.
+func pushRepoFiles(ctx context.Context, vcsClient *git.VCSClient, owner string, repoName string,
+	servicePassword string, files []*RepoFile, removed []string, message string) error {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "push-repo-files-core")
+	defer span.End()
+
+	// the repository and the files are held in memory so both are bounded
+	var size int64
+	for _, f := range files {
+		size += int64(len(f.Content))
+	}
+	if size > importMaxTotalSize {
+		return fmt.Errorf("files for %s/%s exceed the %d MB limit", owner, repoName, importMaxTotalSize/1024/1024)
+	}
+
+	repository, gitRes, err := vcsClient.GiteaClient.GetRepo(owner, repoName)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve repo: %v\n    res: %s", err, JsonifyGiteaResponse(gitRes))
+	}
+	// gitea reports the size of the repository in kilobytes
+	if int64(repository.Size)*1024 > importMaxCloneSize {
+		return fmt.Errorf("repo %s/%s is too large to update in memory", owner, repoName)
+	}
+
+	auth := &http.BasicAuth{
+		Username: owner,
+		Password: servicePassword,
+	}
+
+	// clone the repository so the files are committed on top of its main branch
+	fs := memfs.New()
+	repo, err := gogit.CloneContext(ctx, memory.NewStorage(), fs, &gogit.CloneOptions{
+		URL:           fmt.Sprintf("%s/%s/%s.git", strings.TrimSuffix(vcsClient.HostUrl, "/"), owner, repoName),
+		Auth:          auth,
+		ReferenceName: plumbing.NewBranchReferenceName("main"),
+		SingleBranch:  true,
+		Depth:         1,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to clone repo: %v", err)
+	}
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to open repo worktree: %v", err)
+	}
+
+	for _, f := range files {
+		// the file mode is carried into the index when the file is staged
+		perm := os.FileMode(0644)
+		if f.Executable {
+			perm = 0755
+		}
+
+		err = fs.MkdirAll(path.Dir(f.Path), 0755)
+		if err != nil {
+			return fmt.Errorf("failed to create directory for %s: %v", f.Path, err)
+		}
+
+		file, err := fs.OpenFile(f.Path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %v", f.Path, err)
+		}
+		_, err = file.Write(f.Content)
+		_ = file.Close()
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %v", f.Path, err)
+		}
+
+		_, err = wt.Add(f.Path)
+		if err != nil {
+			return fmt.Errorf("failed to stage %s: %v", f.Path, err)
+		}
+	}
+
+	for _, p := range removed {
+		_, err = wt.Remove(p)
+		if err != nil {
+			return fmt.Errorf("failed to remove %s: %v", p, err)
+		}
+	}
+
+	sig := &object.Signature{
+		Name:  "Gigo",
+		Email: "gigo@gigo.dev",
+		When:  time.Now(),
+	}
+	_, err = wt.Commit(message, &gogit.CommitOptions{
+		Author:    sig,
+		Committer: sig,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to commit files: %v", err)
+	}
+
+	err = repo.PushContext(ctx, &gogit.PushOptions{
+		RemoteName: "origin",
+		Auth:       auth,
+	})
+	if err != nil && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("failed to push files: %v", err)
+	}
+
+	return nil
+}
+
+// UpgradeAttemptRelease upgrades an attempt to the latest release of its project.
+// Only files that were changed by the author between the two releases are
+// applied, and any of those files that the attempter has modified themselves
+// are left untouched and returned as conflicts so no work is overwritten. The
+// upgrade is pushed to the attempt as a single commit.
+func UpgradeAttemptRelease(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClient, callingUser *models.User,
+	userSession *models.UserSession, attemptId int64) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "upgrade-attempt-release-core")
+	defer span.End()
+	callerName := "UpgradeAttemptRelease"
+
+	var authorId int64
+	var postId int64
+	var postAuthorId int64
+	var releaseCommit sql.NullString
+	var releaseVersion sql.NullInt64
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		"select a.author_id, a.post_id, p.author_id, a.release_commit, a.release_version from attempt a join post p on p._id = a.post_id where a._id = ? limit 1",
+		attemptId,
+	).Scan(&authorId, &postId, &postAuthorId, &releaseCommit, &releaseVersion)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return map[string]interface{}{"message": "attempt not found"}, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to query attempt: %v", err)
+	}
+
+	if authorId != callingUser.ID {
+		return map[string]interface{}{"message": "you do not have permission to upgrade this attempt"},
+			fmt.Errorf("user %d is not the author of attempt %d", callingUser.ID, attemptId)
+	}
+
+	latest, err := getLatestRelease(ctx, tidb, postId)
+	if err != nil {
+		return nil, err
+	}
+
+	if latest == nil {
+		return map[string]interface{}{"message": "This project has no releases."}, nil
+	}
+
+	if releaseVersion.Valid && int(releaseVersion.Int64) >= latest.Version {
+		return map[string]interface{}{"message": "Attempt is already on the latest release.", "release": latest.ToFrontend()}, nil
+	}
+
+	sourceOwner := fmt.Sprintf("%d", postAuthorId)
+	sourceRepo := fmt.Sprintf("%d", postId)
+	attemptOwner := fmt.Sprintf("%d", authorId)
+	attemptRepo := fmt.Sprintf("%d", attemptId)
+
+	newEntries, err := repoTreeEntries(vcsClient, sourceOwner, sourceRepo, latest.Commit)
+	if err != nil {
+		return nil, err
+	}
+
+	newFiles := make(map[string]string)
+	newModes := make(map[string]string)
+	for _, entry := range newEntries {
+		newFiles[entry.Path] = entry.SHA
+		newModes[entry.Path] = entry.Mode
+	}
+
+	// attempts started before the project was released have no base to diff
+	// against so every file of the attempt that differs from the release is
+	// reported as a conflict and only files missing from the attempt are added
+	oldFiles := make(map[string]string)
+	if releaseCommit.Valid && releaseCommit.String != "" {
+		oldFiles, err = repoTree(vcsClient, sourceOwner, sourceRepo, releaseCommit.String)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	attemptFiles, err := repoTree(vcsClient, attemptOwner, attemptRepo, "main")
+	if err != nil {
+		return nil, err
+	}
+
+	updated := make([]string, 0)
+	conflicts := make([]string, 0)
+	files := make([]*RepoFile, 0)
+	removed := make([]string, 0)
+
+	// collect files that were added or modified by the release
+	for path, newSha := range newFiles {
+		oldSha, inOld := oldFiles[path]
+		attemptSha, inAttempt := attemptFiles[path]
+
+		// skip files that the author did not change or the attempt already matches
+		if (inOld && oldSha == newSha) || (inAttempt && attemptSha == newSha) {
+			continue
+		}
+
+		// skip files that the attempter has changed since the release they started from
+		if inAttempt && (!inOld || attemptSha != oldSha) {
+			conflicts = append(conflicts, path)
+			continue
+		}
+
+		blob, gitRes, err := vcsClient.GiteaClient.GetBlob(sourceOwner, sourceRepo, newSha)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve blob %s: %v\n    res: %s", path, err, JsonifyGiteaResponse(gitRes))
+		}
+
+		content, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(blob.Content, "\n", ""))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode blob %s: %v", path, err)
+		}
+
+		files = append(files, &RepoFile{
+			Path:       path,
+			Executable: newModes[path] == "100755",
+			Content:    content,
+		})
+		updated = append(updated, path)
+	}
+
+	// collect files that were deleted by the release as long as the attempter did not change them
+	for path, oldSha := range oldFiles {
+		if _, ok := newFiles[path]; ok {
+			continue
+		}
+
+		attemptSha, inAttempt := attemptFiles[path]
+		if !inAttempt {
+			continue
+		}
+
+		if attemptSha != oldSha {
+			conflicts = append(conflicts, path)
+			continue
+		}
+
+		removed = append(removed, path)
+		updated = append(updated, path)
+	}
+
+	// apply the whole upgrade to the attempt repo in a single commit
+	if len(updated) > 0 {
+		servicePassword, err := userSession.GetServiceKey()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get service key: %v", err)
+		}
+
+		err = pushRepoFiles(ctx, vcsClient, attemptOwner, attemptRepo, servicePassword, files, removed,
+			fmt.Sprintf("[GIGO-RELEASE] upgrade to %s", ReleaseTag(latest.Version)))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tidb.ExecContext(ctx, &span, &callerName,
+		"update attempt set release_id = ?, release_version = ?, release_commit = ?, updated_at = ? where _id = ?",
+		latest.ID, latest.Version, latest.Commit, time.Now(), attemptId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update attempt release: %v", err)
+	}
+
+	return map[string]interface{}{
+		"message":   fmt.Sprintf("Attempt upgraded to %s.", ReleaseTag(latest.Version)),
+		"release":   latest.ToFrontend(),
+		"updated":   updated,
+		"conflicts": conflicts,
+	}, nil
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gigo-core/gigo/migrations"
+
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/db/models"
+)
+
+func TestGetProjectReleases(t *testing.T) {
+	testTiDB, err := ti.CreateDatabase("gigo-dev-tidb", "4000", "mysql", "gigo-dev",
+		"gigo-dev",
+		"gigo_test_db")
+	if err != nil {
+		t.Fatal("Initialize test database failed:", err)
+	}
+
+	err = migrations.Run(testTiDB)
+	if err != nil {
+		t.Fatal("Migrate test database failed:", err)
+	}
+
+	post, err := models.CreatePost(69, "title", "content", "author", 420, time.Now(), time.Now(), 69, 5, []int64{}, nil,
+		42069, 2, 6969, 6900, 4206969, []models.ProgrammingLanguage{models.Go}, models.PublicVisibility, []int64{},
+		nil, nil, 0, 0, nil, false, false, nil)
+	if err != nil {
+		t.Fatalf("failed to create post: %v", err)
+	}
+
+	stmts, err := post.ToSQLNative()
+	if err != nil {
+		t.Fatalf("failed to format post: %v", err)
+	}
+
+	for _, s := range stmts {
+		_, err = testTiDB.DB.Exec(s.Statement, s.Values...)
+		if err != nil {
+			t.Fatalf("failed to insert post: %v", err)
+		}
+	}
+
+	defer func() {
+		_, _ = testTiDB.DB.Exec("DELETE FROM post WHERE _id = ?", post.ID)
+		_, _ = testTiDB.DB.Exec("DELETE FROM post_release WHERE post_id = ?", post.ID)
+	}()
+
+	_, err = testTiDB.DB.Exec(
+		"insert into post_release(_id, post_id, version, commit, title, notes, author_id, created_at) values (?, ?, ?, ?, ?, ?, ?, ?), (?, ?, ?, ?, ?, ?, ?, ?)",
+		1, post.ID, 1, "aaaaaaa", "Initial release", "", post.AuthorID, time.Now(),
+		2, post.ID, 2, "bbbbbbb", "Bug fixes", "Fixed the tests", post.AuthorID, time.Now(),
+	)
+	if err != nil {
+		t.Fatalf("failed to insert releases: %v", err)
+	}
+
+	author := &models.User{ID: post.AuthorID}
+	stranger := &models.User{ID: post.AuthorID + 1}
+
+	// unpublished projects only expose their history to the author
+	_, err = GetProjectReleases(context.Background(), testTiDB, stranger, post.ID)
+	if err != ErrNotFound {
+		t.Fatalf("GetProjectReleases() error = %v, want %v", err, ErrNotFound)
+	}
+
+	res, err := GetProjectReleases(context.Background(), testTiDB, author, post.ID)
+	if err != nil {
+		t.Fatalf("GetProjectReleases() error = %v", err)
+	}
+
+	releases := res["releases"].([]*ProjectReleaseFrontend)
+	if len(releases) != 2 {
+		t.Fatalf("GetProjectReleases() returned %d releases, want 2", len(releases))
+	}
+	if releases[0].Version != 2 || releases[0].Tag != "v2" {
+		t.Errorf("GetProjectReleases() latest = %+v, want v2", releases[0])
+	}
+
+	_, err = testTiDB.DB.Exec("update post set published = true where _id = ?", post.ID)
+	if err != nil {
+		t.Fatalf("failed to publish post: %v", err)
+	}
+
+	res, err = GetProjectReleases(context.Background(), testTiDB, nil, post.ID)
+	if err != nil {
+		t.Fatalf("GetProjectReleases() error = %v", err)
+	}
+	if len(res["releases"].([]*ProjectReleaseFrontend)) != 2 {
+		t.Errorf("GetProjectReleases() returned %d releases, want 2", len(res["releases"].([]*ProjectReleaseFrontend)))
+	}
+
+	// new attempts are pinned to the latest release
+	releaseId, releaseVersion, releaseCommit, err := resolveAttemptRelease(context.Background(), testTiDB, post.ID, nil)
+	if err != nil {
+		t.Fatalf("resolveAttemptRelease() error = %v", err)
+	}
+	if releaseId.Int64 != 2 || releaseVersion.Int64 != 2 || releaseCommit.String != "bbbbbbb" {
+		t.Errorf("resolveAttemptRelease() = %v, %v, %v, want the v2 release", releaseId, releaseVersion, releaseCommit)
+	}
+}
@@ -0,0 +1,487 @@
+package core
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/git"
+	"github.com/gage-technologies/gigo-lib/storage"
+	"github.com/gage-technologies/gitea-go/gitea"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	// projects with at least this many views or attempts have their archives cached
+	archiveCacheMinViews    = 100
+	archiveCacheMinAttempts = 10
+	// archives larger than this are streamed without being cached. Cached
+	// archives are buffered in memory while they are streamed so the cap
+	// bounds the memory used by every download.
+	archiveCacheMaxSize = 8 * 1024 * 1024
+)
+
+var archiveRefValidator = regexp.MustCompile(`^[A-Za-z0-9._/-]{1,255}$`)
+
+// ArchiveFormat is a supported format for repository downloads
+type ArchiveFormat string
+
+const (
+	ArchiveFormatZip   ArchiveFormat = "zip"
+	ArchiveFormatTarGz ArchiveFormat = "tar.gz"
+)
+
+// ParseArchiveFormat parses the archive format defaulting to zip
+func ParseArchiveFormat(s string) (ArchiveFormat, bool) {
+	switch strings.ToLower(s) {
+	case "", "zip":
+		return ArchiveFormatZip, true
+	case "tar.gz", "tgz":
+		return ArchiveFormatTarGz, true
+	}
+	return "", false
+}
+
+// ContentType returns the mime type of the archive format
+func (f ArchiveFormat) ContentType() string {
+	if f == ArchiveFormatTarGz {
+		return "application/gzip"
+	}
+	return "application/zip"
+}
+
+func (f ArchiveFormat) giteaType() gitea.ArchiveType {
+	if f == ArchiveFormatTarGz {
+		return gitea.TarGZArchive
+	}
+	return gitea.ZipArchive
+}
+
+// RepoArchive is a resolved download of a project or attempt repository at a
+// specific commit
+type RepoArchive struct {
+	Owner    string
+	Repo     string
+	Commit   string
+	Format   ArchiveFormat
+	FileName string
+	// Cache marks the archive as popular enough to be stored in the storage engine
+	Cache bool
+}
+
+// cachePath returns the storage path of the cached archive. Archives are keyed
+// by commit so a cached archive never goes stale.
+func (a *RepoArchive) cachePath() string {
+	return fmt.Sprintf("archives/%s/%s/%s.%s", a.Owner, a.Repo, a.Commit, a.Format)
+}
+
+// hasPurchasedPost checks whether the user has purchased the exclusive post
+func hasPurchasedPost(ctx context.Context, tidb *ti.Database, userId int64, postId int64) (bool, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "has-purchased-post-core")
+	defer span.End()
+	callerName := "hasPurchasedPost"
+
+	var count int64
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		"select count(*) from exclusive_content_purchases where user_id = ? and post = ?", userId, postId,
+	).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to query purchases: %v", err)
+	}
+
+	return count > 0, nil
+}
+
+// resolveArchiveCommit resolves the ref of the repository to a commit
+func resolveArchiveCommit(vcsClient *git.VCSClient, owner string, repo string, ref string) (string, error) {
+	if ref == "" {
+		ref = "main"
+	}
+
+	if !archiveRefValidator.MatchString(ref) || strings.Contains(ref, "..") {
+		return "", ErrNotFound
+	}
+
+	commit, gitRes, err := vcsClient.GiteaClient.GetSingleCommit(owner, repo, ref)
+	if err != nil {
+		if gitRes != nil && (gitRes.StatusCode == 404 || gitRes.StatusCode == 422) {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("failed to resolve ref %q: %v\n    res: %s", ref, err, JsonifyGiteaResponse(gitRes))
+	}
+
+	return commit.SHA, nil
+}
+
+// ResolveProjectArchive performs the same visibility and purchase checks as the
+// project page and resolves the download of the project repository at the ref.
+// ErrNotFound is returned when the project or ref is not visible to the caller
+// and ErrForbidden when the project must be purchased first.
+func ResolveProjectArchive(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClient, callingUser *models.User,
+	postId int64, ref string, format ArchiveFormat) (*RepoArchive, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "resolve-project-archive-core")
+	defer span.End()
+	callerName := "ResolveProjectArchive"
+
+	var callerId int64
+	if callingUser != nil {
+		callerId = callingUser.ID
+	}
+
+	var authorId, views, attempts int64
+	var published, hidden bool
+	var visibility models.PostVisibility
+	var challengeCost sql.NullString
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		"select author_id, published, visibility, challenge_cost, views, attempts, hidden from post where _id = ? and deleted = false limit 1",
+		postId,
+	).Scan(&authorId, &published, &visibility, &challengeCost, &views, &attempts, &hidden)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to query post: %v", err)
+	}
+
+	// authors and collaborators can always download the project
+	canEdit := callerId == authorId
+	if !canEdit && callingUser != nil {
+		canEdit, err = HasPostPermission(ctx, tidb, postId, callerId, CollaboratorEditor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check post permission: %v", err)
+		}
+	}
+
+	if !canEdit {
+		if visibility != models.PublicVisibility || !published {
+			return nil, ErrNotFound
+		}
+
+		// projects hidden by a moderator are only served to the moderators
+		err = checkHiddenContentAccess(ctx, tidb, callingUser, hidden)
+		if err != nil {
+			return nil, err
+		}
+
+		// exclusive content must be purchased before the source can be downloaded
+		if challengeCost.Valid {
+			if callingUser == nil {
+				return nil, ErrForbidden
+			}
+			purchased, err := hasPurchasedPost(ctx, tidb, callerId, postId)
+			if err != nil {
+				return nil, err
+			}
+			if !purchased {
+				return nil, ErrForbidden
+			}
+		}
+	}
+
+	archive := &RepoArchive{
+		Owner:  fmt.Sprintf("%d", authorId),
+		Repo:   fmt.Sprintf("%d", postId),
+		Format: format,
+		Cache:  views >= archiveCacheMinViews || attempts >= archiveCacheMinAttempts,
+	}
+
+	archive.Commit, err = resolveArchiveCommit(vcsClient, archive.Owner, archive.Repo, ref)
+	if err != nil {
+		return nil, err
+	}
+	archive.FileName = fmt.Sprintf("project-%d-%s.%s", postId, archive.Commit[:7], format)
+
+	return archive, nil
+}
+
+// attemptSource identifies an attempt repository and the project it was started from
+type attemptSource struct {
+	AuthorID      int64
+	PostID        int64
+	PostAuthorID  int64
+	ReleaseCommit sql.NullString
+	ForkCommit    sql.NullString
+}
+
+// authorizeAttemptSource loads the attempt and ensures that the calling user can
+// view its source. Attempts contain the source of their project so the project
+// must be downloadable by the caller as in ResolveProjectArchive unless the
+// caller wrote the attempt. The attempt privacy of the author is respected and
+// attempts of exclusive projects require the project to be purchased.
+func authorizeAttemptSource(ctx context.Context, tidb *ti.Database, callingUser *models.User, attemptId int64) (*attemptSource, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "authorize-attempt-source-core")
+	defer span.End()
+	callerName := "authorizeAttemptSource"
+
+	var source attemptSource
+	var challengeCost sql.NullString
+	var attemptHidden, postHidden, published bool
+	var visibility models.PostVisibility
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		"select a.author_id, a.post_id, p.author_id, p.challenge_cost, a.release_commit, a.fork_commit, a.hidden, p.hidden, p.published, p.visibility "+
+			"from attempt a join post p on p._id = a.post_id where a._id = ? and p.deleted = false limit 1",
+		attemptId,
+	).Scan(&source.AuthorID, &source.PostID, &source.PostAuthorID, &challengeCost, &source.ReleaseCommit, &source.ForkCommit, &attemptHidden, &postHidden,
+		&published, &visibility)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to query attempt: %v", err)
+	}
+
+	// hidden attempts and attempts of hidden projects are only served to their
+	// authors and the moderators
+	err = checkHiddenContentAccess(ctx, tidb, callingUser, attemptHidden, source.AuthorID)
+	if err != nil {
+		return nil, err
+	}
+	err = checkHiddenContentAccess(ctx, tidb, callingUser, postHidden, source.PostAuthorID, source.AuthorID)
+	if err != nil {
+		return nil, err
+	}
+
+	// authors can always view their own attempts
+	if callingUser != nil && callingUser.ID == source.AuthorID {
+		return &source, nil
+	}
+
+	// the project source is only served for published public projects unless
+	// the caller is the author or a collaborator of the project
+	if visibility != models.PublicVisibility || !published {
+		canEdit := callingUser != nil && callingUser.ID == source.PostAuthorID
+		if !canEdit && callingUser != nil {
+			canEdit, err = HasPostPermission(ctx, tidb, source.PostID, callingUser.ID, CollaboratorEditor)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check post permission: %v", err)
+			}
+		}
+		if !canEdit {
+			return nil, ErrNotFound
+		}
+	}
+
+	// respect the attempt privacy of the author
+	err = checkAttemptPrivacy(ctx, tidb, callingUser, source.AuthorID)
+	if err != nil {
+		return nil, err
+	}
+
+	// attempts of exclusive content contain the purchased source
+	if challengeCost.Valid && (callingUser == nil || callingUser.ID != source.PostAuthorID) {
+		if callingUser == nil {
+			return nil, ErrForbidden
+		}
+		purchased, err := hasPurchasedPost(ctx, tidb, callingUser.ID, source.PostID)
+		if err != nil {
+			return nil, err
+		}
+		if !purchased {
+			return nil, ErrForbidden
+		}
+	}
+
+	return &source, nil
+}
+
+// ResolveAttemptArchive resolves the download of an attempt repository at the
+// ref respecting the attempt privacy of the author and the purchase status of
+// the source project.
+func ResolveAttemptArchive(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClient, callingUser *models.User,
+	attemptId int64, ref string, format ArchiveFormat) (*RepoArchive, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "resolve-attempt-archive-core")
+	defer span.End()
+
+	source, err := authorizeAttemptSource(ctx, tidb, callingUser, attemptId)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := &RepoArchive{
+		Owner:  fmt.Sprintf("%d", source.AuthorID),
+		Repo:   fmt.Sprintf("%d", attemptId),
+		Format: format,
+	}
+
+	archive.Commit, err = resolveArchiveCommit(vcsClient, archive.Owner, archive.Repo, ref)
+	if err != nil {
+		return nil, err
+	}
+	archive.FileName = fmt.Sprintf("attempt-%d-%s.%s", attemptId, archive.Commit[:7], format)
+
+	return archive, nil
+}
+
+// cappedBuffer buffers writes until the cap is exceeded after which the
+// buffered content is discarded
+type cappedBuffer struct {
+	buf      bytes.Buffer
+	cap      int
+	overflow bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if !b.overflow {
+		if b.buf.Len()+len(p) > b.cap {
+			b.overflow = true
+			b.buf = bytes.Buffer{}
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// WriteRepoArchive streams the resolved archive to the writer. Cached archives
+// are served from the storage engine, otherwise the archive is retrieved from
+// the Gitea archive api falling back to building it from the repository tree.
+func WriteRepoArchive(ctx context.Context, vcsClient *git.VCSClient, storageEngine storage.Storage, archive *RepoArchive, w io.Writer) error {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "write-repo-archive-core")
+	defer span.End()
+
+	if archive.Cache {
+		cached, err := storageEngine.GetFile(archive.cachePath())
+		if err != nil {
+			return fmt.Errorf("failed to retrieve cached archive: %v", err)
+		}
+		if cached != nil {
+			defer cached.Close()
+			_, err = io.Copy(w, cached)
+			if err != nil {
+				return fmt.Errorf("failed to write cached archive: %v", err)
+			}
+			return nil
+		}
+	}
+
+	// tee the archive into a buffer so that it can be cached once complete
+	var cacheBuf *cappedBuffer
+	out := w
+	if archive.Cache {
+		cacheBuf = &cappedBuffer{cap: archiveCacheMaxSize}
+		out = io.MultiWriter(w, cacheBuf)
+	}
+
+	reader, _, err := vcsClient.GiteaClient.GetArchiveReader(archive.Owner, archive.Repo, archive.Commit, archive.Format.giteaType())
+	if err == nil {
+		defer reader.Close()
+		_, err = io.Copy(out, reader)
+		if err != nil {
+			return fmt.Errorf("failed to write archive: %v", err)
+		}
+	} else {
+		err = buildRepoArchive(vcsClient, archive, out)
+		if err != nil {
+			return fmt.Errorf("failed to build archive from tree: %v", err)
+		}
+	}
+
+	if cacheBuf != nil && !cacheBuf.overflow {
+		err = storageEngine.CreateFile(archive.cachePath(), cacheBuf.buf.Bytes())
+		if err != nil {
+			return fmt.Errorf("failed to cache archive: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// buildRepoArchive builds the archive by walking the tree of the commit. This is
+// used when the Gitea archive api is unavailable.
+func buildRepoArchive(vcsClient *git.VCSClient, archive *RepoArchive, w io.Writer) error {
+	tree, gitRes, err := vcsClient.GiteaClient.GetTrees(archive.Owner, archive.Repo, archive.Commit, true)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve tree: %v\n    res: %s", err, JsonifyGiteaResponse(gitRes))
+	}
+	if tree.Truncated {
+		return fmt.Errorf("repository tree is too large to archive")
+	}
+
+	// mirror the layout of the Gitea archives which nest the content in a directory named after the repo
+	prefix := archive.Repo + "/"
+	modTime := time.Now()
+
+	var addFile func(name string, mode int64, content []byte) error
+	var closeArchive func() error
+
+	if archive.Format == ArchiveFormatTarGz {
+		gz := gzip.NewWriter(w)
+		tw := tar.NewWriter(gz)
+		addFile = func(name string, mode int64, content []byte) error {
+			err := tw.WriteHeader(&tar.Header{
+				Name:     prefix + name,
+				Mode:     mode,
+				Size:     int64(len(content)),
+				ModTime:  modTime,
+				Typeflag: tar.TypeReg,
+			})
+			if err != nil {
+				return err
+			}
+			_, err = tw.Write(content)
+			return err
+		}
+		closeArchive = func() error {
+			if err := tw.Close(); err != nil {
+				return err
+			}
+			return gz.Close()
+		}
+	} else {
+		zw := zip.NewWriter(w)
+		addFile = func(name string, mode int64, content []byte) error {
+			header := &zip.FileHeader{Name: prefix + name, Method: zip.Deflate, Modified: modTime}
+			header.SetMode(0644)
+			if mode == 0755 {
+				header.SetMode(0755)
+			}
+			fw, err := zw.CreateHeader(header)
+			if err != nil {
+				return err
+			}
+			_, err = fw.Write(content)
+			return err
+		}
+		closeArchive = zw.Close
+	}
+
+	for _, entry := range tree.Entries {
+		// symlinks and submodules are not included in the fallback archive
+		if entry.Type != "blob" || (entry.Mode != "100644" && entry.Mode != "100755") {
+			continue
+		}
+
+		blob, gitRes, err := vcsClient.GiteaClient.GetBlob(archive.Owner, archive.Repo, entry.SHA)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve blob %s: %v\n    res: %s", entry.Path, err, JsonifyGiteaResponse(gitRes))
+		}
+
+		content, err := base64.StdEncoding.DecodeString(blob.Content)
+		if err != nil {
+			return fmt.Errorf("failed to decode blob %s: %v", entry.Path, err)
+		}
+
+		mode := int64(0644)
+		if entry.Mode == "100755" {
+			mode = 0755
+		}
+
+		err = addFile(entry.Path, mode, content)
+		if err != nil {
+			return fmt.Errorf("failed to add %s to archive: %v", entry.Path, err)
+		}
+	}
+
+	return closeArchive()
+}
@@ -0,0 +1,50 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestParseArchiveFormat(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ArchiveFormat
+		ok   bool
+	}{
+		{"", ArchiveFormatZip, true},
+		{"ZIP", ArchiveFormatZip, true},
+		{"tar.gz", ArchiveFormatTarGz, true},
+		{"tgz", ArchiveFormatTarGz, true},
+		{"rar", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseArchiveFormat(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseArchiveFormat(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCappedBuffer(t *testing.T) {
+	b := &cappedBuffer{cap: 8}
+	_, _ = b.Write([]byte("1234"))
+	_, _ = b.Write([]byte("5678"))
+	if b.overflow || b.buf.String() != "12345678" {
+		t.Fatalf("cappedBuffer = %q overflow %v, want full buffer", b.buf.String(), b.overflow)
+	}
+
+	n, err := b.Write([]byte("9"))
+	if n != 1 || err != nil {
+		t.Fatalf("cappedBuffer.Write() = %d, %v, want writes to keep succeeding", n, err)
+	}
+	if !b.overflow || b.buf.Len() != 0 {
+		t.Errorf("cappedBuffer should discard content after overflowing")
+	}
+}
+
+func TestArchiveCachePath(t *testing.T) {
+	a := &RepoArchive{Owner: "1", Repo: "2", Commit: "abc", Format: ArchiveFormatTarGz}
+	if got := a.cachePath(); got != "archives/1/2/abc.tar.gz" {
+		t.Errorf("cachePath() = %q", got)
+	}
+}
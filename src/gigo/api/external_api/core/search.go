@@ -403,6 +403,24 @@ func SearchPosts(
 		posts = append(posts, fp)
 	}
 
+	// load the co-authors of the returned posts keyed by post id
+	coAuthorIds := make([]int64, 0, len(posts))
+	for _, post := range posts {
+		id, err := strconv.ParseInt(post.ID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse post ID: %v", err)
+		}
+		coAuthorIds = append(coAuthorIds, id)
+	}
+	collaborators, err := getCollaborators(ctx, tidb, coAuthorIds, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load co-authors: %v", err)
+	}
+	coAuthors := make(map[string][]*PostCollaboratorFrontend)
+	for postId, c := range collaborators {
+		coAuthors[strconv.FormatInt(postId, 10)] = c
+	}
+
 	logger.Debugf("search_rec_id: %v inside of Search", searchRecModelID)
 
 	if searchRecModelID == nil {
@@ -427,7 +445,7 @@ func SearchPosts(
 		}
 
 		logger.Debugf("search_rec_id: %v created", searchRc.ID)
-		return map[string]interface{}{"challenges": posts, "search_rec_id": fmt.Sprintf("%v", searchRc.ID)}, nil
+		return map[string]interface{}{"challenges": posts, "search_rec_id": fmt.Sprintf("%v", searchRc.ID), "co_authors": coAuthors}, nil
 
 	}
 
@@ -449,12 +467,12 @@ func SearchPosts(
 			logger.Info("insert search rec post failed because the ids are duplicates")
 		}
 
-		return map[string]interface{}{"challenges": posts, "search_rec_id": fmt.Sprintf("%v", *searchRecModelID)}, nil
+		return map[string]interface{}{"challenges": posts, "search_rec_id": fmt.Sprintf("%v", *searchRecModelID), "co_authors": coAuthors}, nil
 	}
 
 	logger.Debugf("search_rec_id: %v inside of Search, executing with no search rec", *searchRecModelID)
 
-	return map[string]interface{}{"challenges": posts, "search_rec_id": nil}, nil
+	return map[string]interface{}{"challenges": posts, "search_rec_id": nil, "co_authors": coAuthors}, nil
 }
 
 func SearchUsers(ctx context.Context, tidb *ti.Database, meili *search.MeiliSearchEngine, query string, skip int, limit int) (map[string]interface{}, error) {
@@ -521,7 +539,8 @@ func SearchUsers(ctx context.Context, tidb *ti.Database, meili *search.MeiliSear
 	}
 
 	// format query for multi-user query
-	query = "select u._id as _id, user_name, user_rank, render_in_front, color_palette, name, level, tier, user_status from users u left join rewards r on r._id = u.avatar_reward where u._id in (" + strings.Join(paramSlots, ",") + ")"
+	// users that have opted out of search via their privacy settings are excluded
+	query = "select u._id as _id, user_name, user_rank, render_in_front, color_palette, name, level, tier, user_status from users u left join rewards r on r._id = u.avatar_reward where u._id in (" + strings.Join(paramSlots, ",") + ") and ifnull(json_unquote(json_extract(u.privacy_settings, '$.hide_from_search')), 'false') != 'true'"
 
 	// query database for users
 	res, err := tidb.QueryContext(ctx, &span, &callerName, query, userIds...)
@@ -633,7 +652,7 @@ func SearchTags(ctx context.Context, meili *search.MeiliSearchEngine, query stri
 	return map[string]interface{}{"tags": tags}, nil
 }
 
-func SearchDiscussions(ctx context.Context, meili *search.MeiliSearchEngine, query string, skip int, limit int, postId *int64) (map[string]interface{}, error) {
+func SearchDiscussions(ctx context.Context, tidb *ti.Database, meili *search.MeiliSearchEngine, query string, skip int, limit int, postId *int64) (map[string]interface{}, error) {
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "search-discussions-core")
 	defer span.End()
 
@@ -683,6 +702,9 @@ func SearchDiscussions(ctx context.Context, meili *search.MeiliSearchEngine, que
 	// create slice to hold discussions
 	discussions := make([]*models.DiscussionFrontend, 0)
 
+	// create slice to hold the ids of the discussions
+	discussionIds := make([]int64, 0)
+
 	// iterate results cursor scanning results into discussion structs and appending the id to the discussions slice
 	for {
 		// attempt to load next value into the first position of the cursor
@@ -707,9 +729,16 @@ func SearchDiscussions(ctx context.Context, meili *search.MeiliSearchEngine, que
 
 		// append discussion to outer slice
 		discussions = append(discussions, disc.ToFrontend())
+		discussionIds = append(discussionIds, disc.ID)
+	}
+
+	// retrieve the accepted answers of the discussions
+	acceptedAnswers, err := discussionAcceptedAnswers(ctx, tidb, discussionIds)
+	if err != nil {
+		return nil, err
 	}
 
-	return map[string]interface{}{"discussions": discussions}, nil
+	return map[string]interface{}{"discussions": discussions, "accepted_answers": acceptedAnswers}, nil
 }
 
 func SearchComments(ctx context.Context, meili *search.MeiliSearchEngine, query string, skip int, limit int, discussionId *int64) (map[string]interface{}, error) {
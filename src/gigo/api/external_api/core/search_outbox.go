@@ -0,0 +1,512 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	"github.com/gage-technologies/gigo-lib/config"
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/logging"
+	"github.com/meilisearch/meilisearch-go"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	// how long a drained batch is claimed before other followers can retry it
+	searchOutboxLease = time.Minute
+	// upper bound of the delay between retries of a failing document
+	searchOutboxMaxBackoff = time.Minute * 10
+	// how long a write to the search engine is awaited before it is retried
+	searchTaskTimeout = time.Second * 30
+	// how often the status of a write to the search engine is checked
+	searchTaskInterval = time.Millisecond * 50
+)
+
+// searchIndexSource describes how the documents of a search index are loaded
+// from the database
+type searchIndexSource struct {
+	table string
+	// revisioned content only indexes its latest revision
+	revisioned bool
+	// searchable filters out the rows that must not be discoverable
+	searchable string
+	// document decodes a row into the id and the search document
+	document func(tidb *ti.Database, rows *sql.Rows) (int64, interface{}, error)
+}
+
+var searchIndexSources = map[string]searchIndexSource{
+	"users": {
+		table: "users",
+		document: func(tidb *ti.Database, rows *sql.Rows) (int64, interface{}, error) {
+			user, err := models.UserFromSQLNative(tidb, rows)
+			if err != nil {
+				return 0, nil, err
+			}
+			return user.ID, user.ToSearch(), nil
+		},
+	},
+	"tags": {
+		table: "tag",
+		document: func(tidb *ti.Database, rows *sql.Rows) (int64, interface{}, error) {
+			tag, err := models.TagFromSQLNative(rows)
+			if err != nil {
+				return 0, nil, err
+			}
+			return tag.ID, tag.ToSearch(), nil
+		},
+	},
+	"posts": {
+		table:      "post",
+		searchable: "d.deleted = false and d.hidden = false",
+		document: func(tidb *ti.Database, rows *sql.Rows) (int64, interface{}, error) {
+			post, err := models.PostFromSQLNative(tidb, rows)
+			if err != nil {
+				return 0, nil, err
+			}
+			return post.ID, post, nil
+		},
+	},
+	"discussion": {
+		table:      "discussion",
+		revisioned: true,
+		searchable: "d.hidden = false and d.deleted = false",
+		document: func(tidb *ti.Database, rows *sql.Rows) (int64, interface{}, error) {
+			discussion, err := models.DiscussionFromSQLNative(tidb, rows)
+			if err != nil {
+				return 0, nil, err
+			}
+			return discussion.ID, discussion, nil
+		},
+	},
+	"comment": {
+		table:      "comment",
+		revisioned: true,
+		searchable: "d.hidden = false and d.deleted = false",
+		document: func(tidb *ti.Database, rows *sql.Rows) (int64, interface{}, error) {
+			comment, err := models.CommentFromSQLNative(tidb, rows)
+			if err != nil {
+				return 0, nil, err
+			}
+			return comment.ID, comment, nil
+		},
+	},
+	"thread_comment": {
+		table:      "thread_comment",
+		revisioned: true,
+		searchable: "d.hidden = false and d.deleted = false",
+		document: func(tidb *ti.Database, rows *sql.Rows) (int64, interface{}, error) {
+			threadComment, err := models.ThreadCommentFromSQLNative(rows)
+			if err != nil {
+				return 0, nil, err
+			}
+			return threadComment.ID, threadComment, nil
+		},
+	},
+	"workspace_configs": {
+		table:      "workspace_config",
+		revisioned: true,
+		document: func(tidb *ti.Database, rows *sql.Rows) (int64, interface{}, error) {
+			workspaceConfig, err := models.WorkspaceConfigFromSQLNative(tidb, rows)
+			if err != nil {
+				return 0, nil, err
+			}
+			return workspaceConfig.ID, workspaceConfig, nil
+		},
+	},
+}
+
+// SearchIndexes lists the search indexes that are synced from the database
+var SearchIndexes = []string{"users", "tags", "posts", "discussion", "comment", "thread_comment", "workspace_configs"}
+
+// query builds the select for the searchable documents matching the filter.
+// The filter is applied before the latest revision of revisioned content is
+// selected so that it can use the primary key.
+func (s searchIndexSource) query(filter string) string {
+	where := ""
+	if s.searchable != "" {
+		where = " where " + s.searchable
+	}
+
+	if !s.revisioned {
+		if where == "" {
+			where = " where " + filter
+		} else {
+			where += " and " + filter
+		}
+		return fmt.Sprintf("select d.* from %s d%s", s.table, where)
+	}
+
+	return fmt.Sprintf(
+		"select d.* from %s d inner join (select _id, max(revision) as revision from %s d where %s group by _id) t "+
+			"on d._id = t._id and d.revision = t.revision%s",
+		s.table, s.table, filter, where,
+	)
+}
+
+// queueSearchSync records that documents of a search index changed so that
+// the follower syncs them to the search engine. It must be called with the
+// transaction that made the change so that committed changes are never lost.
+func queueSearchSync(ctx context.Context, tx *ti.Tx, callerName string, index string, ids ...int64) error {
+	if _, ok := searchIndexSources[index]; !ok {
+		return fmt.Errorf("unknown search index %q", index)
+	}
+
+	now := time.Now()
+	for _, id := range ids {
+		_, err := tx.ExecContext(ctx, &callerName,
+			"insert into search_outbox(search_index, document_id, version, attempts, available_at, created_at) values (?, ?, 1, 0, ?, ?) "+
+				"on duplicate key update version = version + 1, attempts = 0, last_error = null, claim_id = null, available_at = values(available_at)",
+			index, id, now, now,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to queue %s document %d for search sync: %v", index, id, err)
+		}
+	}
+
+	return nil
+}
+
+// queueSearchSyncNow records changes to searchable documents that were made
+// outside of a transaction
+func queueSearchSyncNow(ctx context.Context, tidb *ti.Database, index string, ids ...int64) error {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "queue-search-sync-core")
+	defer span.End()
+	callerName := "queueSearchSyncNow"
+
+	tx, err := tidb.BeginTx(ctx, &span, &callerName, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create search sync tx: %v", err)
+	}
+	defer tx.Rollback()
+
+	err = queueSearchSync(ctx, tx, callerName, index, ids...)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(&callerName)
+	if err != nil {
+		return fmt.Errorf("failed to commit search sync tx: %v", err)
+	}
+
+	return nil
+}
+
+// NewSearchSyncClient creates the client used to sync documents to the search
+// engine. The writes of the gigo-lib search engine only enqueue a task so the
+// sync talks to the engine directly to wait for every write to be applied.
+func NewSearchSyncClient(cfg config.MeiliConfig) *meilisearch.Client {
+	return meilisearch.NewClient(meilisearch.ClientConfig{
+		Host:    cfg.Host,
+		APIKey:  cfg.Token,
+		Timeout: time.Second * 3,
+	})
+}
+
+// waitSearchTask waits for a write to the search engine to be applied and
+// returns an error if the task failed
+func waitSearchTask(ctx context.Context, meili *meilisearch.Client, index string, task *meilisearch.TaskInfo) error {
+	ctx, cancel := context.WithTimeout(ctx, searchTaskTimeout)
+	defer cancel()
+
+	res, err := meili.WaitForTask(task.TaskUID, meilisearch.WaitParams{Context: ctx, Interval: searchTaskInterval})
+	if err != nil {
+		return fmt.Errorf("failed to wait for task %d on index %q: %v", task.TaskUID, index, err)
+	}
+
+	if res.Status != meilisearch.TaskStatusSucceeded {
+		return fmt.Errorf("task %d on index %q %s: %s %s", task.TaskUID, index, res.Status, res.Error.Code, res.Error.Message)
+	}
+
+	return nil
+}
+
+// syncSearchDocuments brings the documents of a search index in line with the
+// database. Documents that are no longer searchable are removed from the index.
+// The writes are awaited so that an error is returned unless the index holds
+// the synced documents. The number of documents that were indexed is returned.
+func syncSearchDocuments(ctx context.Context, tidb *ti.Database, meili *meilisearch.Client, index string, ids []int64) (int, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "sync-search-documents-core")
+	defer span.End()
+	callerName := "syncSearchDocuments"
+
+	source, ok := searchIndexSources[index]
+	if !ok {
+		return 0, fmt.Errorf("unknown search index %q", index)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	params := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		params = append(params, id)
+	}
+
+	rows, err := tidb.QueryContext(ctx, &span, &callerName,
+		source.query("d._id in (?"+strings.Repeat(", ?", len(ids)-1)+")"), params...,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query %s documents: %v", index, err)
+	}
+
+	documents := make([]interface{}, 0, len(ids))
+	found := make(map[int64]bool)
+	for rows.Next() {
+		id, document, err := source.document(tidb, rows)
+		if err != nil {
+			_ = rows.Close()
+			return 0, fmt.Errorf("failed to decode %s document: %v", index, err)
+		}
+		documents = append(documents, document)
+		found[id] = true
+	}
+	_ = rows.Close()
+
+	if len(documents) > 0 {
+		task, err := meili.Index(index).AddDocuments(documents)
+		if err != nil {
+			return 0, fmt.Errorf("failed to add documents to index %q: %v", index, err)
+		}
+		err = waitSearchTask(ctx, meili, index, task)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	removed := make([]string, 0)
+	for _, id := range ids {
+		if !found[id] {
+			removed = append(removed, strconv.FormatInt(id, 10))
+		}
+	}
+
+	if len(removed) > 0 {
+		task, err := meili.Index(index).DeleteDocuments(removed)
+		if err != nil {
+			return 0, fmt.Errorf("failed to delete documents from index %q: %v", index, err)
+		}
+		err = waitSearchTask(ctx, meili, index, task)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(documents), nil
+}
+
+// searchOutboxBackoff returns the delay before a document that failed to sync
+// is retried
+func searchOutboxBackoff(attempts int) time.Duration {
+	if attempts > 10 {
+		return searchOutboxMaxBackoff
+	}
+	backoff := time.Second << attempts
+	if backoff > searchOutboxMaxBackoff {
+		return searchOutboxMaxBackoff
+	}
+	return backoff
+}
+
+// searchOutboxEntry is a claimed row of the search outbox
+type searchOutboxEntry struct {
+	documentId int64
+	version    int64
+	attempts   int
+}
+
+// DrainSearchOutbox claims a batch of the queued search documents and syncs
+// them to the search engine. A document leaves the outbox only once the search
+// engine has applied it. When a batch fails its documents are synced one at a
+// time so that a single bad document does not hold back the rest, and the
+// documents that still fail are retried with a backoff. Documents that changed
+// again while they were being synced stay queued. The number of claimed
+// documents is returned.
+func DrainSearchOutbox(ctx context.Context, tidb *ti.Database, meili *meilisearch.Client, sf *snowflake.Node, logger logging.Logger, limit int) (int, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "drain-search-outbox-core")
+	defer span.End()
+	callerName := "DrainSearchOutbox"
+
+	// claim the batch so that the other followers skip it until the lease ends
+	claimId := sf.Generate().Int64()
+	now := time.Now()
+	leaseEnd := now.Add(searchOutboxLease)
+	res, err := tidb.ExecContext(ctx, &span, &callerName,
+		"update search_outbox set claim_id = ?, available_at = ? where available_at <= ? order by available_at limit ?",
+		claimId, leaseEnd, now, limit,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim search outbox: %v", err)
+	}
+	if claimed, _ := res.RowsAffected(); claimed == 0 {
+		return 0, nil
+	}
+
+	rows, err := tidb.QueryContext(ctx, &span, &callerName,
+		"select search_index, document_id, version, attempts from search_outbox where claim_id = ?", claimId,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query claimed search outbox: %v", err)
+	}
+
+	batches := make(map[string][]searchOutboxEntry)
+	count := 0
+	for rows.Next() {
+		var index string
+		var entry searchOutboxEntry
+		err = rows.Scan(&index, &entry.documentId, &entry.version, &entry.attempts)
+		if err != nil {
+			_ = rows.Close()
+			return 0, fmt.Errorf("failed to scan search outbox: %v", err)
+		}
+		batches[index] = append(batches[index], entry)
+		count++
+	}
+	_ = rows.Close()
+
+	for index, entries := range batches {
+		ids := make([]int64, 0, len(entries))
+		for _, entry := range entries {
+			ids = append(ids, entry.documentId)
+		}
+
+		_, syncErr := syncSearchDocuments(ctx, tidb, meili, index, ids)
+		if syncErr != nil {
+			logger.Errorf("failed to sync %d %s documents to search engine, retrying them one at a time: %v", len(entries), index, syncErr)
+		}
+
+		for _, entry := range entries {
+			// retry the documents of a failed batch one at a time while the
+			// claim is held so that the others are not blocked by a bad one
+			entryErr := syncErr
+			if syncErr != nil && len(entries) > 1 && time.Now().Before(leaseEnd) {
+				_, entryErr = syncSearchDocuments(ctx, tidb, meili, index, []int64{entry.documentId})
+				if entryErr != nil {
+					logger.Errorf("failed to sync %s document %d to search engine: %v", index, entry.documentId, entryErr)
+				}
+			}
+
+			// the version guards against removing a change that was queued
+			// while this batch was being synced
+			if entryErr == nil {
+				_, err = tidb.ExecContext(ctx, &span, &callerName,
+					"delete from search_outbox where search_index = ? and document_id = ? and version = ?",
+					index, entry.documentId, entry.version,
+				)
+			} else {
+				_, err = tidb.ExecContext(ctx, &span, &callerName,
+					"update search_outbox set attempts = attempts + 1, last_error = ?, claim_id = null, available_at = ? "+
+						"where search_index = ? and document_id = ? and version = ?",
+					entryErr.Error(), time.Now().Add(searchOutboxBackoff(entry.attempts)), index, entry.documentId, entry.version,
+				)
+			}
+			if err != nil {
+				return count, fmt.Errorf("failed to update search outbox: %v", err)
+			}
+		}
+	}
+
+	return count, nil
+}
+
+// ReindexSearchIndex syncs every document of a search index in batches of
+// ascending id. The last indexed id is checkpointed after every batch so that
+// an interrupted reindex resumes where it stopped. A completed reindex starts
+// over as does any reindex when restart is set. The progress function is
+// called after every batch with the total number of documents indexed.
+func ReindexSearchIndex(ctx context.Context, tidb *ti.Database, meili *meilisearch.Client, index string, batchSize int,
+	restart bool, progress func(lastId int64, indexed int64)) (int64, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "reindex-search-index-core")
+	defer span.End()
+	callerName := "ReindexSearchIndex"
+
+	source, ok := searchIndexSources[index]
+	if !ok {
+		return 0, fmt.Errorf("unknown search index %q", index)
+	}
+
+	var lastId, indexed int64
+	var completedAt sql.NullTime
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		"select last_id, indexed, completed_at from search_reindex_checkpoint where search_index = ?", index,
+	).Scan(&lastId, &indexed, &completedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, fmt.Errorf("failed to query %s reindex checkpoint: %v", index, err)
+	}
+
+	if err == sql.ErrNoRows || restart || completedAt.Valid {
+		lastId, indexed = 0, 0
+		now := time.Now()
+		_, err = tidb.ExecContext(ctx, &span, &callerName,
+			"insert into search_reindex_checkpoint(search_index, last_id, indexed, started_at, updated_at) values (?, 0, 0, ?, ?) "+
+				"on duplicate key update last_id = 0, indexed = 0, started_at = values(started_at), updated_at = values(updated_at), completed_at = null",
+			index, now, now,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("failed to reset %s reindex checkpoint: %v", index, err)
+		}
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return indexed, err
+		}
+
+		rows, err := tidb.QueryContext(ctx, &span, &callerName,
+			fmt.Sprintf("select distinct _id from %s where _id > ? order by _id limit ?", source.table), lastId, batchSize,
+		)
+		if err != nil {
+			return indexed, fmt.Errorf("failed to query %s ids: %v", index, err)
+		}
+
+		ids := make([]int64, 0, batchSize)
+		for rows.Next() {
+			var id int64
+			err = rows.Scan(&id)
+			if err != nil {
+				_ = rows.Close()
+				return indexed, fmt.Errorf("failed to scan %s id: %v", index, err)
+			}
+			ids = append(ids, id)
+		}
+		_ = rows.Close()
+
+		if len(ids) == 0 {
+			break
+		}
+
+		count, err := syncSearchDocuments(ctx, tidb, meili, index, ids)
+		if err != nil {
+			return indexed, err
+		}
+
+		lastId = ids[len(ids)-1]
+		indexed += int64(count)
+		_, err = tidb.ExecContext(ctx, &span, &callerName,
+			"update search_reindex_checkpoint set last_id = ?, indexed = ?, updated_at = ? where search_index = ?",
+			lastId, indexed, time.Now(), index,
+		)
+		if err != nil {
+			return indexed, fmt.Errorf("failed to checkpoint %s reindex: %v", index, err)
+		}
+
+		if progress != nil {
+			progress(lastId, indexed)
+		}
+	}
+
+	_, err = tidb.ExecContext(ctx, &span, &callerName,
+		"update search_reindex_checkpoint set completed_at = ?, updated_at = ? where search_index = ?", time.Now(), time.Now(), index,
+	)
+	if err != nil {
+		return indexed, fmt.Errorf("failed to complete %s reindex checkpoint: %v", index, err)
+	}
+
+	return indexed, nil
+}
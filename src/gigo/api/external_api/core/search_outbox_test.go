@@ -0,0 +1,92 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gage-technologies/gigo-lib/config"
+	"github.com/meilisearch/meilisearch-go"
+)
+
+func TestSearchIndexSourceQuery(t *testing.T) {
+	got := searchIndexSources["tags"].query("d._id in (?)")
+	want := "select d.* from tag d where d._id in (?)"
+	if got != want {
+		t.Errorf("\nTestSearchIndexSourceQuery failed\n    Error: got %q, want %q", got, want)
+	}
+
+	got = searchIndexSources["posts"].query("d._id in (?)")
+	want = "select d.* from post d where d.deleted = false and d.hidden = false and d._id in (?)"
+	if got != want {
+		t.Errorf("\nTestSearchIndexSourceQuery failed\n    Error: got %q, want %q", got, want)
+	}
+
+	// the latest revision must be selected before the searchable filter is
+	// applied so that hidden or deleted content is never indexed from an older revision
+	got = searchIndexSources["comment"].query("d._id in (?)")
+	want = "select d.* from comment d inner join (select _id, max(revision) as revision from comment d where d._id in (?) group by _id) t " +
+		"on d._id = t._id and d.revision = t.revision where d.hidden = false and d.deleted = false"
+	if got != want {
+		t.Errorf("\nTestSearchIndexSourceQuery failed\n    Error: got %q, want %q", got, want)
+	}
+
+	for _, index := range SearchIndexes {
+		if _, ok := searchIndexSources[index]; !ok {
+			t.Errorf("\nTestSearchIndexSourceQuery failed\n    Error: missing source for index %q", index)
+		}
+	}
+}
+
+func TestSearchOutboxBackoff(t *testing.T) {
+	if got := searchOutboxBackoff(0); got != time.Second {
+		t.Errorf("\nTestSearchOutboxBackoff failed\n    Error: got %v for the first attempt, want %v", got, time.Second)
+	}
+	if got := searchOutboxBackoff(3); got != time.Second*8 {
+		t.Errorf("\nTestSearchOutboxBackoff failed\n    Error: got %v for the fourth attempt, want %v", got, time.Second*8)
+	}
+	if got := searchOutboxBackoff(64); got != searchOutboxMaxBackoff {
+		t.Errorf("\nTestSearchOutboxBackoff failed\n    Error: got %v for a failing document, want %v", got, searchOutboxMaxBackoff)
+	}
+}
+
+func TestWaitSearchTask(t *testing.T) {
+	// the first task is processed on the second poll and the second task fails
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tasks/1":
+			polls++
+			status := meilisearch.TaskStatusProcessing
+			if polls > 1 {
+				status = meilisearch.TaskStatusSucceeded
+			}
+			_, _ = fmt.Fprintf(w, `{"uid":1,"indexUid":"tags","status":%q,"type":"documentAdditionOrUpdate"}`, status)
+		case "/tasks/2":
+			_, _ = fmt.Fprint(w, `{"uid":2,"indexUid":"tags","status":"failed","type":"documentAdditionOrUpdate",`+
+				`"error":{"message":"invalid document","code":"invalid_document_id","type":"invalid_request"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	meili := NewSearchSyncClient(config.MeiliConfig{Host: server.URL})
+
+	err := waitSearchTask(context.Background(), meili, "tags", &meilisearch.TaskInfo{TaskUID: 1})
+	if err != nil {
+		t.Errorf("\nTestWaitSearchTask failed\n    Error: %v", err)
+	}
+	if polls != 2 {
+		t.Errorf("\nTestWaitSearchTask failed\n    Error: got %d polls, want 2", polls)
+	}
+
+	// failed tasks are surfaced so that the documents stay queued
+	err = waitSearchTask(context.Background(), meili, "tags", &meilisearch.TaskInfo{TaskUID: 2})
+	if err == nil {
+		t.Error("\nTestWaitSearchTask failed\n    Error: failed task was accepted")
+	}
+}
@@ -343,6 +343,13 @@ func TestSearchTags(t *testing.T) {
 }
 
 func TestSearchDiscussions(t *testing.T) {
+	testTiDB, err := ti.CreateDatabase("gigo-dev-tidb", "4000", "mysql", "gigo-dev",
+		"gigo-dev",
+		"gigo_test_db")
+	if err != nil {
+		t.Fatal("Initialize test database failed:", err)
+	}
+
 	cfg := config.MeiliConfig{
 		Host:  "http://gigo-dev-meili:7700",
 		Token: "gigo-dev",
@@ -384,7 +391,8 @@ func TestSearchDiscussions(t *testing.T) {
 			mockMeili: meili,
 			wantErr:   false,
 			wantResult: map[string]interface{}{
-				"discussions": []*models.DiscussionFrontend{}, // Expected discussion list
+				"discussions":      []*models.DiscussionFrontend{}, // Expected discussion list
+				"accepted_answers": map[string]string{},
 			},
 		},
 		// Add more test cases if needed
@@ -392,7 +400,7 @@ func TestSearchDiscussions(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotResult, err := SearchDiscussions(context.Background(), tt.mockMeili, tt.query, tt.skip, tt.limit, tt.postId)
+			gotResult, err := SearchDiscussions(context.Background(), testTiDB, tt.mockMeili, tt.query, tt.skip, tt.limit, tt.postId)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("SearchDiscussions() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -0,0 +1,269 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+)
+
+// ShareLinkStatus describes whether a share link can still be used
+type ShareLinkStatus string
+
+const (
+	ShareLinkActive    ShareLinkStatus = "active"
+	ShareLinkRevoked   ShareLinkStatus = "revoked"
+	ShareLinkExpired   ShareLinkStatus = "expired"
+	ShareLinkExhausted ShareLinkStatus = "exhausted"
+)
+
+type CreateShareLinkRequest struct {
+	ProjectID string     `json:"project_id" validate:"required,number"`
+	Name      string     `json:"name" validate:"required,lte=255"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	MaxUses   *int       `json:"max_uses" validate:"omitempty,gt=0"`
+	Test      bool       `json:"test"`
+}
+
+type ShareLinkFrontend struct {
+	ID         string          `json:"_id"`
+	PostID     string          `json:"post_id"`
+	Name       string          `json:"name"`
+	Token      string          `json:"token"`
+	ExpiresAt  *time.Time      `json:"expires_at"`
+	MaxUses    *int64          `json:"max_uses"`
+	Views      int64           `json:"views"`
+	Attempts   int64           `json:"attempts"`
+	Status     ShareLinkStatus `json:"status"`
+	CreatedAt  time.Time       `json:"created_at"`
+	RevokedAt  *time.Time      `json:"revoked_at"`
+	LastUsedAt *time.Time      `json:"last_used_at"`
+}
+
+// shareLinkStatus determines the status of a share link at the passed time.
+// The use limit of a link counts the attempts started through it.
+func shareLinkStatus(revoked bool, expiresAt *time.Time, maxUses *int64, attempts int64, now time.Time) ShareLinkStatus {
+	switch {
+	case revoked:
+		return ShareLinkRevoked
+	case expiresAt != nil && !now.Before(*expiresAt):
+		return ShareLinkExpired
+	case maxUses != nil && attempts >= *maxUses:
+		return ShareLinkExhausted
+	}
+	return ShareLinkActive
+}
+
+// scanShareLink scans a share link row selected with shareLinkColumns
+func scanShareLink(row interface{ Scan(...interface{}) error }) (*ShareLinkFrontend, error) {
+	var link ShareLinkFrontend
+	var id, postId int64
+	var revoked bool
+	var expiresAt, revokedAt, lastUsedAt sql.NullTime
+	var maxUses sql.NullInt64
+	err := row.Scan(&id, &postId, &link.Name, &link.Token, &expiresAt, &maxUses, &link.Views, &link.Attempts,
+		&revoked, &link.CreatedAt, &revokedAt, &lastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	link.ID = fmt.Sprintf("%d", id)
+	link.PostID = fmt.Sprintf("%d", postId)
+	if expiresAt.Valid {
+		link.ExpiresAt = &expiresAt.Time
+	}
+	if maxUses.Valid {
+		link.MaxUses = &maxUses.Int64
+	}
+	if revokedAt.Valid {
+		link.RevokedAt = &revokedAt.Time
+	}
+	if lastUsedAt.Valid {
+		link.LastUsedAt = &lastUsedAt.Time
+	}
+	link.Status = shareLinkStatus(revoked, link.ExpiresAt, link.MaxUses, link.Attempts, time.Now())
+
+	return &link, nil
+}
+
+const shareLinkColumns = "_id, post_id, name, bin_to_uuid(token), expires_at, max_uses, views, attempts, revoked, created_at, revoked_at, last_used_at"
+
+// loadShareLink retrieves the share link of a post by its token. ErrNotFound is
+// returned if the token is malformed or does not belong to the post.
+func loadShareLink(ctx context.Context, tidb *ti.Database, postId int64, token string) (*ShareLinkFrontend, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "load-share-link-core")
+	defer span.End()
+	callerName := "loadShareLink"
+
+	if _, err := uuid.Parse(token); err != nil {
+		return nil, ErrNotFound
+	}
+
+	link, err := scanShareLink(tidb.QueryRowContext(ctx, &span, &callerName,
+		"select "+shareLinkColumns+" from post_share_link where post_id = ? and token = uuid_to_bin(?) limit 1",
+		postId, token,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to query share link: %v", err)
+	}
+
+	return link, nil
+}
+
+// CreateShareLink creates a new named share link for a post. Only the author
+// and maintainers of the post can create share links.
+func CreateShareLink(ctx context.Context, tidb *ti.Database, sf *snowflake.Node, callingUser *models.User, postId int64,
+	name string, expiresAt *time.Time, maxUses *int) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "create-share-link-core")
+	defer span.End()
+	callerName := "CreateShareLink"
+
+	ok, err := HasPostPermission(ctx, tidb, postId, callingUser.ID, CollaboratorMaintainer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check post permission: %v", err)
+	}
+	if !ok {
+		return map[string]interface{}{"message": "you do not have permission to manage share links for this project"},
+			fmt.Errorf("user %d cannot manage share links for post %d: %w", callingUser.ID, postId, ErrForbidden)
+	}
+
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return map[string]interface{}{"message": "expiration must be in the future"}, nil
+	}
+
+	id := sf.Generate().Int64()
+	token := uuid.New()
+	_, err = tidb.ExecContext(ctx, &span, &callerName,
+		"insert into post_share_link(_id, post_id, author_id, name, token, expires_at, max_uses, created_at) values (?, ?, ?, ?, uuid_to_bin(?), ?, ?, ?)",
+		id, postId, callingUser.ID, name, token.String(), expiresAt, maxUses, time.Now(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert share link: %v", err)
+	}
+
+	link, err := loadShareLink(ctx, tidb, postId, token.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{"message": "Share link created.", "link": link}, nil
+}
+
+// GetShareLinks retrieves all of the share links of a post along with the
+// views and attempts that came through each of them
+func GetShareLinks(ctx context.Context, tidb *ti.Database, callingUser *models.User, postId int64) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "get-share-links-core")
+	defer span.End()
+	callerName := "GetShareLinks"
+
+	ok, err := HasPostPermission(ctx, tidb, postId, callingUser.ID, CollaboratorMaintainer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check post permission: %v", err)
+	}
+	if !ok {
+		return map[string]interface{}{"message": "you do not have permission to manage share links for this project"},
+			fmt.Errorf("user %d cannot manage share links for post %d: %w", callingUser.ID, postId, ErrForbidden)
+	}
+
+	rows, err := tidb.QueryContext(ctx, &span, &callerName,
+		"select "+shareLinkColumns+" from post_share_link where post_id = ? order by created_at desc", postId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query share links: %v", err)
+	}
+	defer rows.Close()
+
+	links := make([]*ShareLinkFrontend, 0)
+	var totalViews, totalAttempts int64
+	for rows.Next() {
+		link, err := scanShareLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan share link: %v", err)
+		}
+		totalViews += link.Views
+		totalAttempts += link.Attempts
+		links = append(links, link)
+	}
+
+	return map[string]interface{}{
+		"links":          links,
+		"total_views":    totalViews,
+		"total_attempts": totalAttempts,
+	}, nil
+}
+
+// RevokeShareLink permanently disables a share link. Attempts that were already
+// started through the link are not affected.
+func RevokeShareLink(ctx context.Context, tidb *ti.Database, callingUser *models.User, linkId int64) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "revoke-share-link-core")
+	defer span.End()
+	callerName := "RevokeShareLink"
+
+	var postId int64
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		"select post_id from post_share_link where _id = ? limit 1", linkId,
+	).Scan(&postId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return map[string]interface{}{"message": "share link not found"}, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to query share link: %v", err)
+	}
+
+	ok, err := HasPostPermission(ctx, tidb, postId, callingUser.ID, CollaboratorMaintainer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check post permission: %v", err)
+	}
+	if !ok {
+		return map[string]interface{}{"message": "you do not have permission to manage share links for this project"},
+			fmt.Errorf("user %d cannot manage share links for post %d: %w", callingUser.ID, postId, ErrForbidden)
+	}
+
+	_, err = tidb.ExecContext(ctx, &span, &callerName,
+		"update post_share_link set revoked = true, revoked_at = ? where _id = ? and revoked = false", time.Now(), linkId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke share link: %v", err)
+	}
+
+	return map[string]interface{}{"message": "Share link revoked."}, nil
+}
+
+// useShareLink records an attempt started through a share link within the tx
+// that inserts the attempt. The conditional update ensures that concurrent
+// attempts cannot exceed the use limit of the link.
+func useShareLink(ctx context.Context, tx *ti.Tx, callerName string, link *ShareLinkFrontend, attemptId int64) error {
+	now := time.Now()
+	res, err := tx.ExecContext(ctx, &callerName,
+		"update post_share_link set attempts = attempts + 1, last_used_at = ? "+
+			"where _id = ? and revoked = false and (expires_at is null or expires_at > ?) and (max_uses is null or attempts < max_uses)",
+		now, link.ID, now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record share link attempt: %v", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check share link attempt: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("This share link is no longer valid. Ask the author of the challenge for a new link.")
+	}
+
+	_, err = tx.ExecContext(ctx, &callerName, "update attempt set share_link_id = ? where _id = ?", link.ID, attemptId)
+	if err != nil {
+		return fmt.Errorf("failed to record attempt share link: %v", err)
+	}
+
+	return nil
+}
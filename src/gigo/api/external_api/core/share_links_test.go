@@ -0,0 +1,37 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestShareLinkStatus(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	limit := int64(3)
+
+	tests := []struct {
+		name      string
+		revoked   bool
+		expiresAt *time.Time
+		maxUses   *int64
+		attempts  int64
+		want      ShareLinkStatus
+	}{
+		{"no limits", false, nil, nil, 100, ShareLinkActive},
+		{"before expiry under limit", false, &future, &limit, 2, ShareLinkActive},
+		{"revoked wins", true, &past, &limit, 3, ShareLinkRevoked},
+		{"expired", false, &past, nil, 0, ShareLinkExpired},
+		{"expires exactly now", false, &now, nil, 0, ShareLinkExpired},
+		{"use limit reached", false, &future, &limit, 3, ShareLinkExhausted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shareLinkStatus(tt.revoked, tt.expiresAt, tt.maxUses, tt.attempts, now); got != tt.want {
+				t.Errorf("shareLinkStatus() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	ti "github.com/gage-technologies/gigo-lib/db"
 	"github.com/gage-technologies/gigo-lib/db/models"
@@ -206,12 +207,25 @@ func UpdateStreak(ctx context.Context, db *ti.Database, userID int64, streakNum
 	return nil
 }
 
-func GetUserStreaks(ctx context.Context, db *ti.Database, callingUser *models.User, logger logging.Logger) (map[string]interface{}, error) {
+func GetUserStreaks(ctx context.Context, db *ti.Database, callingUser *models.User, userID int64, logger logging.Logger) (map[string]interface{}, error) {
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "get-user-streaks-core")
 	callerName := "GetUserStreaks"
 
-	userID := callingUser.ID
-	locale, err := time.LoadLocation(callingUser.Timezone)
+	// load the timezone of the user whose streaks are being retrieved
+	var timezone string
+	if callingUser != nil && callingUser.ID == userID {
+		timezone = callingUser.Timezone
+	} else {
+		err := db.QueryRowContext(ctx, &span, &callerName, "select timezone from users where _id = ? limit 1", userID).Scan(&timezone)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return map[string]interface{}{"message": "user not found"}, fmt.Errorf("user not found: %v", userID)
+			}
+			return nil, fmt.Errorf("failed to query timezone for user: %v, err: %v", userID, err)
+		}
+	}
+
+	locale, err := time.LoadLocation(timezone)
 	if err != nil {
 		return nil, fmt.Errorf("failed to set today's date for query: %v, err: %v", userID, err)
 	}
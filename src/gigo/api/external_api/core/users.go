@@ -32,7 +32,6 @@ import (
 	ti "github.com/gage-technologies/gigo-lib/db"
 	"github.com/gage-technologies/gigo-lib/db/models"
 	"github.com/gage-technologies/gigo-lib/git"
-	"github.com/gage-technologies/gigo-lib/search"
 	"github.com/gage-technologies/gigo-lib/session"
 	"github.com/gage-technologies/gigo-lib/storage"
 	"github.com/gage-technologies/gigo-lib/utils"
@@ -51,7 +50,7 @@ type DateCount struct {
 	PostDate string
 }
 
-func CreateNewUser(ctx context.Context, tidb *ti.Database, meili *search.MeiliSearchEngine, streakEngine *streak.StreakEngine,
+func CreateNewUser(ctx context.Context, tidb *ti.Database, streakEngine *streak.StreakEngine,
 	snowflakeNode *snowflake.Node, domain string, userName string, password string, email string, phone string, bio string,
 	firstName string, lastName string, vcsClient *git.VCSClient, starterUserInfo models.UserStart, timezone string, thumbnailPath string,
 	storageEngine storage.Storage, avatarSettings models.AvatarSettings, filter *utils3.PasswordFilter, forcePass bool, initialRecUrl string,
@@ -194,8 +193,6 @@ func CreateNewUser(ctx context.Context, tidb *ti.Database, meili *search.MeiliSe
 
 		// cleaned git user
 		_ = vcsClient.DeleteUser(gitUser.UserName)
-		// clean user from search
-		_ = meili.DeleteDocuments("users", newUser.ID)
 	}()
 
 	// retrieve the insert command for the life cycle
@@ -244,10 +241,11 @@ func CreateNewUser(ctx context.Context, tidb *ti.Database, meili *search.MeiliSe
 		return nil, fmt.Errorf("failed to write thumbnail to final location: %v", err)
 	}
 
-	// attempt to insert user into search engine
-	err = meili.AddDocuments("users", newUser.ToSearch())
+	// queue the new user for the search engine
+	err = queueSearchSync(ctx, tx, callerName, "users", newUser.ID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to insert new user into search engine: %v", err)
+		_ = tx.Rollback()
+		return nil, fmt.Errorf("failed to queue new user for search engine: %v", err)
 	}
 
 	// format user to frontend
@@ -334,7 +332,7 @@ func CreateNewUser(ctx context.Context, tidb *ti.Database, meili *search.MeiliSe
 }
 
 // creates a new ephemeral user and inserts it into the database
-func CreateNewEUser(ctx context.Context, tidb *ti.Database, meili *search.MeiliSearchEngine,
+func CreateNewEUser(ctx context.Context, tidb *ti.Database,
 	snowflakeNode *snowflake.Node, domain string, vcsClient *git.VCSClient, masterKey string) (*models.User, error) {
 
 	id := snowflakeNode.Generate().Int64()
@@ -466,8 +464,6 @@ func CreateNewEUser(ctx context.Context, tidb *ti.Database, meili *search.MeiliS
 
 		// cleaned git user
 		_ = vcsClient.DeleteUser(gitUser.UserName)
-		// clean user from search
-		_ = meili.DeleteDocuments("users", newUser.ID)
 	}()
 
 	// retrieve the insert command for the life cycle
@@ -963,7 +959,7 @@ type UserUsage struct {
 	Date           string  `json:"date"`
 }
 
-func UserProfilePage(ctx context.Context, callingUser *models.User, tidb *ti.Database, userId *int64) (map[string]interface{}, error) {
+func UserProfilePage(ctx context.Context, callingUser *models.User, tidb *ti.Database, userId *int64, access *PrivacyAccess) (map[string]interface{}, error) {
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "user-profile-page-core")
 	callerName := "UserProfilePage"
 
@@ -1013,26 +1009,34 @@ func UserProfilePage(ctx context.Context, callingUser *models.User, tidb *ti.Dat
 
 	monthStart := fmt.Sprintf("%v", currentYear) + "-" + finalMonth + "-01"
 
-	// query attempt and projects with the user id as author id and sort by date last edited
-	// res, err := tidb.DB.Query("select date_format(updated_at, '%Y-%m-%d') as date from attempt where author_id = ? and updated_at > ? union select date_format(updated_at, '%Y-%m-%d') as date from post where author_id = ? and updated_at > ? order by date asc", userId, monthStart, userId, monthStart)
-	res, err := tidb.QueryContext(ctx, &span, &callerName, "select SUM(TIMESTAMPDIFF(SECOND, start_time, end_time)) as date_difference, date from user_daily_usage where user_id = ? and date > ? group by date order by date asc", userId, monthStart)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query for any attempts. Active Project Home core.    Error: %v", err)
+	// default to the most restrictive access if none was resolved by the caller
+	if access == nil {
+		access = &PrivacyAccess{}
 	}
 
 	data := make([]UserUsage, 0)
 
-	defer res.Close()
-
-	for res.Next() {
-		var dataObject UserUsage
-
-		err = res.Scan(&dataObject.DateDifference, &dataObject.Date)
+	// only load the activity if the streaks are visible to the caller
+	if access.Streaks {
+		// query attempt and projects with the user id as author id and sort by date last edited
+		// res, err := tidb.DB.Query("select date_format(updated_at, '%Y-%m-%d') as date from attempt where author_id = ? and updated_at > ? union select date_format(updated_at, '%Y-%m-%d') as date from post where author_id = ? and updated_at > ? order by date asc", userId, monthStart, userId, monthStart)
+		res, err := tidb.QueryContext(ctx, &span, &callerName, "select SUM(TIMESTAMPDIFF(SECOND, start_time, end_time)) as date_difference, date from user_daily_usage where user_id = ? and date > ? group by date order by date asc", userId, monthStart)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan date count from cursor: %v", err)
+			return nil, fmt.Errorf("failed to query for any attempts. Active Project Home core.    Error: %v", err)
 		}
 
-		data = append(data, dataObject)
+		defer res.Close()
+
+		for res.Next() {
+			var dataObject UserUsage
+
+			err = res.Scan(&dataObject.DateDifference, &dataObject.Date)
+			if err != nil {
+				return nil, fmt.Errorf("failed to scan date count from cursor: %v", err)
+			}
+
+			data = append(data, dataObject)
+		}
 	}
 
 	// query for important user information for their profile page
@@ -1065,7 +1069,22 @@ func UserProfilePage(ctx context.Context, callingUser *models.User, tidb *ti.Dat
 		return nil, fmt.Errorf("failed to format struct for frontend: %v", err)
 	}
 
-	return map[string]interface{}{"activity": data, "user": finalUser, "following": following}, nil
+	// strip the stats from the user if they are hidden from the caller
+	if !access.Stats {
+		finalUser.Level = 0
+		finalUser.Rank = 0
+		finalUser.Coffee = 0
+		finalUser.Tier = 0
+	}
+
+	// load the badges the user has earned
+	achievements, err := GetUserAchievements(ctx, tidb, *userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user achievements: %v", err)
+	}
+
+	return map[string]interface{}{"activity": data, "user": finalUser, "following": following, "hidden": access.HiddenSections(),
+		"achievements": achievements["achievements"]}, nil
 }
 
 func ChangeEmail(ctx context.Context, callingUser *models.User, tidb *ti.Database, newEmail string) (map[string]interface{}, error) {
@@ -1359,7 +1378,7 @@ func ChangeUserPicture(ctx context.Context, callingUser *models.User, tidb *ti.D
 	return map[string]interface{}{"message": "Profile picture updated successfully"}, nil
 }
 
-func DeleteUserAccount(ctx context.Context, db *ti.Database, meili *search.MeiliSearchEngine, vcsClient *git.VCSClient,
+func DeleteUserAccount(ctx context.Context, db *ti.Database, vcsClient *git.VCSClient,
 	callingUser *models.User) (map[string]interface{}, error) {
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "delete-user-account-core")
 	callerName := "DeleteUserAccount"
@@ -1423,9 +1442,9 @@ func DeleteUserAccount(ctx context.Context, db *ti.Database, meili *search.Meili
 			}
 		}
 
-		err = meili.DeleteDocuments("posts", post.ID)
+		err = queueSearchSync(ctx, tx, callerName, "posts", post.ID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to delete user from meili: Error: %v", err)
+			return nil, fmt.Errorf("failed to queue post for search engine removal: %v", err)
 		}
 	}
 
@@ -1490,9 +1509,9 @@ func DeleteUserAccount(ctx context.Context, db *ti.Database, meili *search.Meili
 	// if err != nil {
 	//	return nil, fmt.Errorf("failed to delete user from vcs: Error: %v", err)
 	// }
-	err = meili.DeleteDocuments("users", callingUser.ID)
+	err = queueSearchSync(ctx, tx, callerName, "users", callingUser.ID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to delete user from meili: Error: %v", err)
+		return nil, fmt.Errorf("failed to queue user for search engine removal: %v", err)
 	}
 
 	// commit transaction
@@ -1504,7 +1523,7 @@ func DeleteUserAccount(ctx context.Context, db *ti.Database, meili *search.Meili
 	return map[string]interface{}{"message": "Account has been deleted."}, nil
 }
 
-func CreateNewGoogleUser(ctx context.Context, tidb *ti.Database, meili *search.MeiliSearchEngine, snowflakeNode *snowflake.Node, streakEngine *streak.StreakEngine,
+func CreateNewGoogleUser(ctx context.Context, tidb *ti.Database, snowflakeNode *snowflake.Node, streakEngine *streak.StreakEngine,
 	domain string, externalAuth string, password string, vcsClient *git.VCSClient, starterUserInfo models.UserStart, timezone string, avatarSettings models.AvatarSettings, thumbnailPath string,
 	storageEngine storage.Storage, mgKey string, mgDomain string, initialRecUrl string, referralUser *string, logger logging.Logger) (map[string]interface{}, error) {
 
@@ -1621,8 +1640,6 @@ func CreateNewGoogleUser(ctx context.Context, tidb *ti.Database, meili *search.M
 
 		// cleaned git user
 		_ = vcsClient.DeleteUser(gitUser.UserName)
-		// clean user from search
-		_ = meili.DeleteDocuments("users", newUser.ID)
 	}()
 
 	// retrieve the insert command for the life cycle
@@ -1671,10 +1688,11 @@ func CreateNewGoogleUser(ctx context.Context, tidb *ti.Database, meili *search.M
 		return nil, fmt.Errorf("failed to write thumbnail to final location: %v", err)
 	}
 
-	// attempt to insert user into search engine
-	err = meili.AddDocuments("users", newUser.ToSearch())
+	// queue the new user for the search engine
+	err = queueSearchSync(ctx, tx, callerName, "users", newUser.ID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to insert new user into search engine: %v", err)
+		_ = tx.Rollback()
+		return nil, fmt.Errorf("failed to queue new user for search engine: %v", err)
 	}
 
 	// format user to frontend
@@ -1849,7 +1867,7 @@ func GetGithubId(githubCode string, githubSecret string) ([]byte, error) {
 	return userResBody, nil
 }
 
-func CreateNewGithubUser(ctx context.Context, tidb *ti.Database, meili *search.MeiliSearchEngine, snowflakeNode *snowflake.Node, streakEngine *streak.StreakEngine,
+func CreateNewGithubUser(ctx context.Context, tidb *ti.Database, snowflakeNode *snowflake.Node, streakEngine *streak.StreakEngine,
 	domain string, externalAuth string, password string, vcsClient *git.VCSClient, starterUserInfo models.UserStart,
 	timezone string, avatarSetting models.AvatarSettings, githubSecret string, thumbnailPath string,
 	storageEngine storage.Storage, mgKey string, mgDomain string, initialRecUrl string, referralUser *string, logger logging.Logger) (map[string]interface{}, error) {
@@ -2018,8 +2036,6 @@ func CreateNewGithubUser(ctx context.Context, tidb *ti.Database, meili *search.M
 
 		// cleaned git user
 		_ = vcsClient.DeleteUser(gitUser.UserName)
-		// clean user from search
-		_ = meili.DeleteDocuments("users", newUser.ID)
 	}()
 
 	// retrieve the insert command for the life cycle
@@ -2074,10 +2090,11 @@ func CreateNewGithubUser(ctx context.Context, tidb *ti.Database, meili *search.M
 		return nil, fmt.Errorf("failed to write thumbnail to final location: %v", err)
 	}
 
-	// attempt to insert user into search engine
-	err = meili.AddDocuments("users", newUser.ToSearch())
+	// queue the new user for the search engine
+	err = queueSearchSync(ctx, tx, callerName, "users", newUser.ID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to insert new user into search engine: %v", err)
+		_ = tx.Rollback()
+		return nil, fmt.Errorf("failed to queue new user for search engine: %v", err)
 	}
 
 	if referralUser != nil {
@@ -4,11 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"github.com/gage-technologies/gigo-lib/config"
 	ti "github.com/gage-technologies/gigo-lib/db"
 	"github.com/gage-technologies/gigo-lib/db/models"
 	"github.com/gage-technologies/gigo-lib/git"
-	"github.com/gage-technologies/gigo-lib/search"
 	"github.com/gage-technologies/gigo-lib/utils"
 	"reflect"
 	"testing"
@@ -420,25 +418,6 @@ func TestDeleteUserAccount(t *testing.T) {
 		t.Fatal("Initialize test database failed:", err)
 	}
 
-	cfg := config.MeiliConfig{
-		Host:  "http://gigo-dev-meili:7700",
-		Token: "gigo-dev",
-		Indices: map[string]config.MeiliIndexConfig{
-			"users": {
-				Name:                 "users",
-				PrimaryKey:           "_id",
-				SearchableAttributes: []string{"name", "email"},
-				FilterableAttributes: []string{"user_status"},
-				SortableAttributes:   []string{"created_at"},
-			},
-		},
-	}
-
-	meili, err := search.CreateMeiliSearchEngine(cfg)
-	if err != nil {
-		t.Fatalf("\n%s failed\n    Error: %v", t.Name(), err)
-	}
-
 	vcsClient, err := git.CreateVCSClient("http://gigo-dev-git:3000", "gigo-dev", "gigo-dev", true)
 	if err != nil {
 		t.Fatal(fmt.Sprintf("failed to create vsc client, %v", err))
@@ -652,7 +631,7 @@ func TestDeleteUserAccount(t *testing.T) {
 	}
 
 	// Call the DeleteUserAccount function
-	response, err := DeleteUserAccount(context.Background(), testTiDB, meili, vcsClient, user)
+	response, err := DeleteUserAccount(context.Background(), testTiDB, vcsClient, user)
 	if err != nil {
 		t.Errorf("DeleteUserAccount() error = %v", err)
 		return
@@ -1,80 +1,435 @@
 package core
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"github.com/gruntwork-io/terratest/modules/terraform"
-	"github.com/stretchr/testify/assert"
-	"io"
-	"os"
-	"testing"
+	"path"
+	"strconv"
+	"strings"
+
+	"gigo-core/gigo/config"
+
+	"go.opentelemetry.io/otel"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// port used by the editor inside of every workspace
+	workspaceEditorPort = 13337
+
+	// home directory of the workspace user; container data is conventionally
+	// kept under ~/.gigo/containers
+	workspaceHomeDirectory = "/home/gigo"
 )
 
-func TestTemplate(t *testing.T, dir string) (map[string]interface{}, error) {
+// compose options that would give a container access to the host namespaces
+var composeHostOptions = []string{"network_mode", "pid", "ipc", "userns_mode"}
 
-	// Construct the terraform options with default retryable errors to handle the most common
-	// retryable errors in terraform testing.
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
-		// Set the path to the Terraform code that will be tested.
-		TerraformDir: dir,
-		// find more terraform options here https://pkg.go.dev/github.com/gruntwork-io/terratest/modules/terraform@v0.41.3#Options
-	})
+type VerifyWorkspaceTemplateRequest struct {
+	Content string `json:"content" validate:"required"`
+	Test    bool   `json:"test"`
+}
 
-	// Clean up resources with "terraform destroy" at the end of the test.
-	defer terraform.Destroy(t, terraformOptions)
+// TemplateImage is a container image referenced by a workspace template. The
+// resolved reference is the image that will be pulled once the registry cache
+// rewrite rules have been applied.
+type TemplateImage struct {
+	Path       string `json:"path"`
+	Reference  string `json:"reference"`
+	Registry   string `json:"registry"`
+	Repository string `json:"repository"`
+	Tag        string `json:"tag"`
+	Digest     string `json:"digest"`
+	Resolved   string `json:"resolved"`
+	Cached     bool   `json:"cached"`
+}
+
+// TemplateVerificationReport is the outcome of verifying a workspace template
+// and its container compose definition
+type TemplateVerificationReport struct {
+	Valid    bool                   `json:"valid"`
+	Errors   []WorkspaceConfigIssue `json:"errors"`
+	Warnings []WorkspaceConfigIssue `json:"warnings"`
+	Images   []TemplateImage        `json:"images"`
+}
+
+// templatePortBinding is a host port published by a compose service
+type templatePortBinding struct {
+	port     int
+	protocol string
+	path     string
+	node     *yaml.Node
+}
+
+type templateVerifier struct {
+	workspaceConfigLinter
+	registryCaches   []config.RegistryCacheConfig
+	workingDirectory string
+	images           []TemplateImage
+	bindings         []templatePortBinding
+}
+
+// parseImageReference splits a container image reference into its registry,
+// repository, tag and digest using the same defaults as docker
+func parseImageReference(reference string) (TemplateImage, error) {
+	image := TemplateImage{Reference: reference}
+	if !containerImageRegex.MatchString(reference) {
+		return image, fmt.Errorf("%q is not a valid container image reference", reference)
+	}
+
+	remainder := reference
+	if idx := strings.Index(remainder, "@"); idx >= 0 {
+		image.Digest = remainder[idx+1:]
+		remainder = remainder[:idx]
+	}
+
+	// the tag follows the last colon after the final path separator so that a
+	// registry port is not mistaken for a tag
+	if idx := strings.LastIndex(remainder, ":"); idx > strings.LastIndex(remainder, "/") {
+		image.Tag = remainder[idx+1:]
+		remainder = remainder[:idx]
+	}
+
+	// the first component is only a registry when it looks like a host
+	parts := strings.SplitN(remainder, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		image.Registry = parts[0]
+		image.Repository = parts[1]
+	} else {
+		image.Registry = "docker.io"
+		image.Repository = remainder
+		if !strings.Contains(remainder, "/") {
+			image.Repository = "library/" + remainder
+		}
+	}
 
-	// Run "terraform validate". Fail the test if there are any errors.
-	_, err := terraform.InitAndValidateE(t, terraformOptions)
+	if image.Tag == "" && image.Digest == "" {
+		image.Tag = "latest"
+	}
+
+	return image, nil
+}
+
+// parseComposePortBinding returns the host ports published by a short form
+// compose port mapping. Mappings that only expose a container port publish
+// nothing on the host.
+func parseComposePortBinding(mapping string) ([]int, string, error) {
+	protocol := "tcp"
+	if idx := strings.LastIndex(mapping, "/"); idx >= 0 {
+		protocol = mapping[idx+1:]
+		mapping = mapping[:idx]
+	}
+
+	// strip a host ip which may be an ipv6 address in brackets
+	if strings.HasPrefix(mapping, "[") {
+		idx := strings.Index(mapping, "]:")
+		if idx < 0 {
+			return nil, protocol, fmt.Errorf("invalid host ip")
+		}
+		mapping = mapping[idx+2:]
+	}
+
+	parts := strings.Split(mapping, ":")
+	var published string
+	switch len(parts) {
+	case 1:
+		return nil, protocol, nil
+	case 2:
+		published = parts[0]
+	case 3:
+		published = parts[1]
+	default:
+		return nil, protocol, fmt.Errorf("too many port segments")
+	}
+
+	start, end := published, published
+	if idx := strings.Index(published, "-"); idx >= 0 {
+		start, end = published[:idx], published[idx+1:]
+	}
+
+	first, err := strconv.Atoi(start)
 	if err != nil {
-		return nil, fmt.Errorf("failed to validate terraform file: %v", err)
+		return nil, protocol, fmt.Errorf("invalid host port %q", published)
+	}
+	last, err := strconv.Atoi(end)
+	if err != nil {
+		return nil, protocol, fmt.Errorf("invalid host port %q", published)
+	}
+	if first < 1 || last > 65535 || first > last {
+		return nil, protocol, fmt.Errorf("host port %q must be between 1 and 65535", published)
 	}
 
-	// Run "terraform init" and "terraform apply". Fail the test if there are any errors.
-	_, err = terraform.InitAndApplyE(t, terraformOptions)
+	ports := make([]int, 0, last-first+1)
+	for p := first; p <= last; p++ {
+		ports = append(ports, p)
+	}
+	return ports, protocol, nil
+}
+
+// VerifyTemplate verifies a workspace template and its container compose
+// definition without contacting any external service
+func VerifyTemplate(content string, registryCaches []config.RegistryCacheConfig) *TemplateVerificationReport {
+	// the linter performs the structural checks; the tier does not matter for a
+	// template so resources are checked against the premium limits
+	lint, _ := lintWorkspaceConfigContent(content, true)
+
+	v := &templateVerifier{
+		workspaceConfigLinter: workspaceConfigLinter{premium: true, result: lint},
+		registryCaches:        registryCaches,
+		images:                make([]TemplateImage, 0),
+		bindings:              make([]templatePortBinding, 0),
+	}
+
+	report := &TemplateVerificationReport{Images: v.images}
+
+	var doc yaml.Node
+	if yaml.Unmarshal([]byte(content), &doc) == nil && len(doc.Content) > 0 && doc.Content[0].Kind == yaml.MappingNode {
+		root := doc.Content[0]
+
+		_, workingDirectory := yamlMappingEntry(root, "working_directory")
+		if !yamlIsNull(workingDirectory) {
+			v.workingDirectory = path.Clean(workingDirectory.Value)
+		}
+
+		_, baseContainer := yamlMappingEntry(root, "base_container")
+		if !yamlIsNull(baseContainer) && baseContainer.Kind == yaml.ScalarNode {
+			// the base container is provisioned directly and is not rewritten
+			v.verifyImage("base_container", baseContainer, false)
+		}
+
+		v.verifyServices(root)
+		v.verifyPortConflicts(root)
+		report.Images = v.images
+	}
+
+	report.Errors = v.result.Errors
+	report.Warnings = v.result.Warnings
+	report.Valid = len(report.Errors) == 0
+	return report
+}
+
+func (v *templateVerifier) verifyImage(path string, node *yaml.Node, rewrite bool) {
+	image, err := parseImageReference(node.Value)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize and apply terraform file: %v", err)
+		// the linter has already reported the malformed reference
+		return
 	}
+	image.Path = path
+	image.Resolved = image.Reference
 
-	// Run `terraform output` to get the values of output variables and check they have the expected values.
-	output := terraform.Output(t, terraformOptions, "hello_world")
-	assert.Equal(t, "Hello, World!", output)
+	if rewrite {
+		image.Resolved = config.ResolveRegistryCache(image.Reference, v.registryCaches)
+		image.Cached = image.Resolved != image.Reference
+		if len(v.registryCaches) > 0 && !image.Cached {
+			v.warnf(path, node, "image %q is not served through a registry cache", image.Reference)
+		}
+	}
 
-	return map[string]interface{}{"message": "Template successfully verified", "template_dir": dir}, nil
+	v.images = append(v.images, image)
 }
 
-func ValidateContainerConfig(t *testing.T, dir string) (map[string]interface{}, error) {
+func (v *templateVerifier) verifyServices(root *yaml.Node) {
+	_, compose := yamlMappingEntry(root, "containers")
+	_, services := yamlMappingEntry(compose, "services")
+	if services == nil || services.Kind != yaml.MappingNode {
+		return
+	}
 
-	// open json data returned from webhook
-	jsonData, err := os.Open(dir)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open specified file path: %v", err)
+	// named volumes must be declared at the top level of the compose spec
+	declaredVolumes := make(map[string]bool)
+	_, volumes := yamlMappingEntry(compose, "volumes")
+	if volumes != nil && volumes.Kind == yaml.MappingNode {
+		for i := 0; i < len(volumes.Content); i += 2 {
+			declaredVolumes[volumes.Content[i].Value] = true
+		}
+	}
+
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		name, service := services.Content[i], services.Content[i+1]
+		if service.Kind != yaml.MappingNode {
+			continue
+		}
+		path := "containers.services." + name.Value
+
+		_, image := yamlMappingEntry(service, "image")
+		if !yamlIsNull(image) && image.Kind == yaml.ScalarNode {
+			v.verifyImage(path+".image", image, true)
+		}
+
+		_, privileged := yamlMappingEntry(service, "privileged")
+		var isPrivileged bool
+		if !yamlIsNull(privileged) && privileged.Decode(&isPrivileged) == nil && isPrivileged {
+			v.errorf(path+".privileged", privileged, "privileged containers are not permitted")
+		}
+
+		for _, option := range composeHostOptions {
+			_, value := yamlMappingEntry(service, option)
+			if !yamlIsNull(value) && value.Value == "host" {
+				v.errorf(path+"."+option, value, "%s: host is not permitted", option)
+			}
+		}
+
+		v.collectServicePorts(path, service)
+		v.verifyServiceVolumes(path, service, declaredVolumes)
+	}
+}
+
+func (v *templateVerifier) collectServicePorts(path string, service *yaml.Node) {
+	_, ports := yamlMappingEntry(service, "ports")
+	if ports == nil || ports.Kind != yaml.SequenceNode {
+		return
+	}
+
+	for i, port := range ports.Content {
+		portPath := fmt.Sprintf("%s.ports[%d]", path, i)
+		switch port.Kind {
+		case yaml.ScalarNode:
+			published, protocol, err := parseComposePortBinding(port.Value)
+			if err != nil {
+				// malformed mappings have already been reported by the linter
+				if composePortMappingRegex.MatchString(port.Value) {
+					v.errorf(portPath, port, "%v", err)
+				}
+				continue
+			}
+			for _, p := range published {
+				v.bindings = append(v.bindings, templatePortBinding{port: p, protocol: protocol, path: portPath, node: port})
+			}
+		case yaml.MappingNode:
+			_, publishedNode := yamlMappingEntry(port, "published")
+			var published int
+			if yamlIsNull(publishedNode) || publishedNode.Decode(&published) != nil {
+				continue
+			}
+			protocol := "tcp"
+			if _, protocolNode := yamlMappingEntry(port, "protocol"); !yamlIsNull(protocolNode) {
+				protocol = protocolNode.Value
+			}
+			v.bindings = append(v.bindings, templatePortBinding{port: published, protocol: protocol, path: portPath, node: publishedNode})
+		}
+	}
+}
+
+func (v *templateVerifier) verifyServiceVolumes(servicePath string, service *yaml.Node, declaredVolumes map[string]bool) {
+	_, volumes := yamlMappingEntry(service, "volumes")
+	if yamlIsNull(volumes) {
+		return
+	}
+	if volumes.Kind != yaml.SequenceNode {
+		v.errorf(servicePath+".volumes", volumes, "volumes must be a list")
+		return
 	}
 
-	// read json data
-	byte, err := io.ReadAll(jsonData)
+	for i, volume := range volumes.Content {
+		volumePath := fmt.Sprintf("%s.volumes[%d]", servicePath, i)
+
+		var source, target string
+		switch volume.Kind {
+		case yaml.ScalarNode:
+			parts := strings.Split(volume.Value, ":")
+			if len(parts) == 1 {
+				// anonymous volumes only specify a container path
+				target = parts[0]
+			} else {
+				source, target = parts[0], parts[1]
+			}
+		case yaml.MappingNode:
+			_, sourceNode := yamlMappingEntry(volume, "source")
+			_, targetNode := yamlMappingEntry(volume, "target")
+			if !yamlIsNull(sourceNode) {
+				source = sourceNode.Value
+			}
+			if !yamlIsNull(targetNode) {
+				target = targetNode.Value
+			}
+		default:
+			v.errorf(volumePath, volume, "volume must be a string or mapping")
+			continue
+		}
+
+		if !strings.HasPrefix(target, "/") {
+			v.errorf(volumePath, volume, "volume target %q must be an absolute path", target)
+		}
+
+		if source == "" {
+			continue
+		}
 
-	// create empty interface to hold json data
-	res := make(map[string]json.RawMessage)
+		// sources that are not paths refer to named volumes
+		if !strings.HasPrefix(source, "/") && !strings.HasPrefix(source, ".") && !strings.HasPrefix(source, "~") {
+			if !declaredVolumes[source] {
+				v.errorf(volumePath, volume, "named volume %q is not declared in containers.volumes", source)
+			}
+			continue
+		}
 
-	// unmarshal
-	err = json.Unmarshal(byte, &res)
+		// bind mounts are restricted to the workspace so that containers cannot
+		// reach into the host
+		resolved := source
+		if strings.HasPrefix(source, "~") {
+			resolved = workspaceHomeDirectory + strings.TrimPrefix(source, "~")
+		} else if !strings.HasPrefix(source, "/") {
+			resolved = path.Join(v.workingDirectory, source)
+		}
+		resolved = path.Clean(resolved)
 
-	// Construct the terraform options with default retryable errors to handle the most common
-	// retryable errors in terraform testing.
-	terraformOptions := terraform.WithDefaultRetryableErrors(t, &terraform.Options{
-		// Set the path to the Terraform code that will be tested.
-		TerraformDir: dir,
-	})
+		if !isWithinDirectory(resolved, workspaceHomeDirectory) && (v.workingDirectory == "" || !isWithinDirectory(resolved, v.workingDirectory)) {
+			v.errorf(volumePath, volume, "bind mount %q must be inside of the workspace", source)
+		}
+	}
+}
+
+// isWithinDirectory reports whether the cleaned path is the directory or one
+// of its descendants
+func isWithinDirectory(p string, dir string) bool {
+	return p == dir || strings.HasPrefix(p, dir+"/")
+}
+
+func (v *templateVerifier) verifyPortConflicts(root *yaml.Node) {
+	seen := make(map[string]templatePortBinding)
+	for _, binding := range v.bindings {
+		if binding.port == workspaceEditorPort {
+			v.errorf(binding.path, binding.node, "port %d is reserved for the editor", workspaceEditorPort)
+			continue
+		}
 
-	// Clean up resources with "terraform destroy" at the end of the test.
-	defer terraform.Destroy(t, terraformOptions)
+		key := fmt.Sprintf("%d/%s", binding.port, binding.protocol)
+		if existing, ok := seen[key]; ok {
+			v.errorf(binding.path, binding.node, "host port %s is already published by %s", key, existing.path)
+			continue
+		}
+		seen[key] = binding
+	}
+
+	// forwarded ports are served from the workspace so they cannot use the editor port
+	_, ports := yamlMappingEntry(root, "port_forward")
+	if ports == nil || ports.Kind != yaml.SequenceNode {
+		return
+	}
+	for i, entry := range ports.Content {
+		_, portNode := yamlMappingEntry(entry, "port")
+		var port int
+		if yamlIsNull(portNode) || portNode.Decode(&port) != nil {
+			continue
+		}
+		if port == workspaceEditorPort {
+			v.errorf(fmt.Sprintf("port_forward[%d].port", i), portNode, "port %d is reserved for the editor", workspaceEditorPort)
+		}
+	}
+}
 
-	// Run "terraform init" and "terraform apply". Fail the test if there are any errors.
-	terraform.InitAndApply(t, terraformOptions)
+// VerifyWorkspaceTemplate verifies a workspace template for the API and
+// returns the verification report
+func VerifyWorkspaceTemplate(ctx context.Context, registryCaches []config.RegistryCacheConfig, content string) (map[string]interface{}, error) {
+	_, span := otel.Tracer("gigo-core").Start(ctx, "verify-workspace-template-core")
+	defer span.End()
 
-	// Run `terraform output` to get the values of output variables and check they have the expected values.
-	output := terraform.Output(t, terraformOptions, "hello_world")
-	assert.Equal(t, "Hello, World!", output)
+	report := VerifyTemplate(content, registryCaches)
 
-	return map[string]interface{}{"message": "Template successfully verified"}, nil
+	return map[string]interface{}{
+		"valid":    report.Valid,
+		"errors":   report.Errors,
+		"warnings": report.Warnings,
+		"images":   report.Images,
+	}, nil
 }
@@ -1,12 +1,137 @@
 package core
 
 import (
+	"reflect"
 	"testing"
+
+	"gigo-core/gigo/config"
 )
 
-func TestTestTemplate(t *testing.T) {
+func TestParseImageReference(t *testing.T) {
+	tests := []struct {
+		reference string
+		want      TemplateImage
+	}{
+		{"redis", TemplateImage{Registry: "docker.io", Repository: "library/redis", Tag: "latest"}},
+		{"mongo:6", TemplateImage{Registry: "docker.io", Repository: "library/mongo", Tag: "6"}},
+		{"gigodev/gimg:base-ubuntu", TemplateImage{Registry: "docker.io", Repository: "gigodev/gimg", Tag: "base-ubuntu"}},
+		{"ghcr.io/org/app:1.2", TemplateImage{Registry: "ghcr.io", Repository: "org/app", Tag: "1.2"}},
+		{"localhost:5000/app", TemplateImage{Registry: "localhost:5000", Repository: "app", Tag: "latest"}},
+	}
+
+	for _, tt := range tests {
+		got, err := parseImageReference(tt.reference)
+		if err != nil {
+			t.Errorf("\nTestParseImageReference failed\n    Case: %s\n    Error: %v", tt.reference, err)
+			continue
+		}
+		tt.want.Reference = tt.reference
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("\nTestParseImageReference failed\n    Case: %s\n    Error: got %+v, want %+v", tt.reference, got, tt.want)
+		}
+	}
+
+	if _, err := parseImageReference("Not An Image"); err == nil {
+		t.Error("\nTestParseImageReference failed\n    Error: invalid reference was accepted")
+	}
+}
+
+func TestParseComposePortBinding(t *testing.T) {
+	tests := []struct {
+		mapping  string
+		ports    []int
+		protocol string
+	}{
+		{"80", nil, "tcp"},
+		{"8080:80", []int{8080}, "tcp"},
+		{"127.0.0.1:8080:80/udp", []int{8080}, "udp"},
+		{"9000-9002:9000-9002", []int{9000, 9001, 9002}, "tcp"},
+	}
+
+	for _, tt := range tests {
+		ports, protocol, err := parseComposePortBinding(tt.mapping)
+		if err != nil || !reflect.DeepEqual(ports, tt.ports) || protocol != tt.protocol {
+			t.Errorf("\nTestParseComposePortBinding failed\n    Case: %s\n    Error: got %v %s %v", tt.mapping, ports, protocol, err)
+		}
+	}
+}
+
+func TestVerifyTemplate(t *testing.T) {
+	template := `version: 0.1
+resources:
+  cpu: 2
+  mem: 2
+  disk: 10
+base_container: gigodev/gimg:base-ubuntu
+working_directory: /home/gigo/codebase
+containers:
+  version: '3.7'
+  volumes:
+    data: {}
+  services:
+    db:
+      image: mongo:6
+      ports:
+        - '8080:27017'
+      volumes:
+        - data:/data/db
+        - ./seed:/seed
+        - /home/gigo/.gigo/containers/data/db:/backup
+    cache:
+      image: ghcr.io/org/cache:1
+      privileged: true
+      network_mode: host
+      ports:
+        - '8080:6379'
+        - '13337:80'
+      volumes:
+        - /var/run/docker.sock:/var/run/docker.sock
+        - ../../../etc:/host-etc
+        - logs:/logs
+`
+
+	caches := []config.RegistryCacheConfig{{Source: "docker.io", Cache: "registry-cache.gigo.dev"}}
+	report := VerifyTemplate(template, caches)
+	if report.Valid {
+		t.Fatal("\nTestVerifyTemplate failed\n    Error: invalid template was accepted")
+	}
+
+	wantErrors := map[string]int{
+		"containers.services.cache.privileged":   23,
+		"containers.services.cache.network_mode": 24,
+		"containers.services.cache.ports[0]":     26,
+		"containers.services.cache.ports[1]":     27,
+		"containers.services.cache.volumes[0]":   29,
+		"containers.services.cache.volumes[1]":   30,
+		"containers.services.cache.volumes[2]":   31,
+	}
+	if len(report.Errors) != len(wantErrors) {
+		t.Errorf("\nTestVerifyTemplate failed\n    Error: unexpected errors %v", report.Errors)
+	}
+	for _, issue := range report.Errors {
+		if line, ok := wantErrors[issue.Path]; !ok || line != issue.Line {
+			t.Errorf("\nTestVerifyTemplate failed\n    Error: unexpected error %+v", issue)
+		}
+	}
 
-	// dir := "/home/user/Development/Terraform/GIGOTerraGo-main/main.tf"
+	// the docker hub image is routed through the cache while the ghcr image is not
+	if len(report.Images) != 3 {
+		t.Fatalf("\nTestVerifyTemplate failed\n    Error: unexpected images %+v", report.Images)
+	}
+	if !report.Images[1].Cached || report.Images[1].Resolved != "registry-cache.gigo.dev/mongo:6" {
+		t.Errorf("\nTestVerifyTemplate failed\n    Error: mongo was not resolved through the cache %+v", report.Images[1])
+	}
+	if report.Images[2].Cached {
+		t.Errorf("\nTestVerifyTemplate failed\n    Error: ghcr image should not be cached %+v", report.Images[2])
+	}
 
-	t.Log("\nnGetActiveProjects succeeded")
+	foundCacheWarning := false
+	for _, issue := range report.Warnings {
+		if issue.Path == "containers.services.cache.image" {
+			foundCacheWarning = true
+		}
+	}
+	if !foundCacheWarning {
+		t.Errorf("\nTestVerifyTemplate failed\n    Error: missing registry cache warning in %v", report.Warnings)
+	}
 }
@@ -49,12 +49,14 @@ func CreateWorkspace(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSC
 
 	// attempt to retrieve any existing workspaces
 	res, err := tidb.QueryContext(ctx, &span, &callerName,
-		"select * from workspaces where repo_id = ? and commit = ? and owner_id = ? and code_source_id = ? and state not in (?, ?, ?) limit 1",
+		"select * from workspaces where repo_id = ? and commit = ? and owner_id = ? and code_source_id = ? and state not in (?, ?, ?) "+
+			"and not exists (select 1 from attempt_grading g where g.workspace_id = workspaces._id) limit 1",
 		repo, commit, callingUser.ID, csId, models.WorkspaceRemoving, models.WorkspaceDeleted, models.WorkspaceFailed,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query for existing workspace: %v\n    query: %s\n    params: %v", err,
-			"select * from workspaces where repo_id = ? and commit = ? and owner_id = ? and code_source_id = ? and state not in (?, ?, ?) limit 1",
+			"select * from workspaces where repo_id = ? and commit = ? and owner_id = ? and code_source_id = ? and state not in (?, ?, ?) "+
+				"and not exists (select 1 from attempt_grading g where g.workspace_id = workspaces._id) limit 1",
 			[]interface{}{repo, commit, callingUser.ID, csId, models.WorkspaceRemoving, models.WorkspaceDeleted, models.WorkspaceFailed})
 	}
 
@@ -135,7 +137,7 @@ func CreateWorkspace(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSC
 
 		// retrieve the gigo workspace config for this repo and commit
 		configBytes, gitRes, err := vcsClient.GiteaClient.GetFile(
-			fmt.Sprintf("%d", callingUser.ID),
+			repository.Owner.UserName,
 			repository.Name,
 			commit,
 			".gigo/workspace.yaml",
@@ -182,9 +184,18 @@ func CreateWorkspace(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSC
 
 	// confirm that passed code source values are valid and that the calling user is the owner
 	if csType == models.CodeSourcePost {
-		// query posts for the passed id and user
-		err := tidb.QueryRowContext(ctx, &span, &callerName,
-			"select _id, workspace_settings from post where _id = ? and author_id = ? limit 1", csId, callingUser.ID,
+		// ensure that the calling user is the author or a collaborator on the post
+		hasPermission, err := HasPostPermission(ctx, tidb, csId, callingUser.ID, CollaboratorEditor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check post permission: %v", err)
+		}
+		if !hasPermission {
+			return map[string]interface{}{"message": "Unable to locate code source."}, fmt.Errorf("code source not found")
+		}
+
+		// query posts for the passed id
+		err = tidb.QueryRowContext(ctx, &span, &callerName,
+			"select _id, workspace_settings from post where _id = ? limit 1", csId,
 		).Scan(&csId, &wsSettingsBytes)
 		if err != nil {
 			if err == sql.ErrNoRows {
@@ -192,9 +203,9 @@ func CreateWorkspace(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSC
 			}
 			return nil, fmt.Errorf(
 				"failed to query for existing post: %v\n    query: %s\n    params: %v",
-				err, "select _id from post where _id = ? and author_id = ? limit 1", []interface{}{csId, callingUser.ID})
+				err, "select _id from post where _id = ? limit 1", []interface{}{csId})
 		}
-		tidb.ExecContext(ctx, &span, &callerName, "update post set updated_at =? where _id =? and author_id = ? limit 1", time.Now(), csId, callingUser.ID)
+		tidb.ExecContext(ctx, &span, &callerName, "update post set updated_at =? where _id =? limit 1", time.Now(), csId)
 
 	} else {
 		// query attempts for the passed id and user
@@ -234,7 +245,7 @@ func CreateWorkspace(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSC
 
 	// retrieve the gigo workspace config from the passed branch
 	configBytes, gitRes, err := vcsClient.GiteaClient.GetFile(
-		fmt.Sprintf("%d", callingUser.ID),
+		repository.Owner.UserName,
 		repository.Name,
 		commit,
 		".gigo/workspace.yaml",
@@ -811,7 +822,7 @@ func StartWorkspace(ctx context.Context, tidb *ti.Database, js *mq.JetstreamClie
 
 	// perform update via the tx
 	res, err := tx.ExecContext(ctx, &callerName,
-		"update workspaces set expiration = ?, state = ?, init_state = -1, last_state_update = ? where _id = ? and owner_id = ?",
+		"update workspaces set expiration = ?, state = ?, init_state = -1, last_state_update = ?, config_update_pending = false where _id = ? and owner_id = ?",
 		time.Now().Add(time.Minute*30), models.WorkspaceStarting, time.Now(), workspaceID, callingUser.ID,
 	)
 	if err != nil {
@@ -1058,7 +1069,7 @@ func GetWorkspaceStatus(ctx context.Context, db *ti.Database, vcsClient *git.VCS
 
 	// retrieve the gigo workspace config for this repo and commit
 	configBytes, gitRes, err := vcsClient.GiteaClient.GetFile(
-		fmt.Sprintf("%d", callingUser.ID),
+		repository.Owner.UserName,
 		repository.Name,
 		workspace.Commit,
 		".gigo/workspace.yaml",
@@ -1091,6 +1102,13 @@ func GetWorkspaceStatus(ctx context.Context, db *ti.Database, vcsClient *git.VCS
 		}
 	}
 
+	// check if a push changed the config since the workspace was started
+	var configUpdatePending bool
+	err = db.QueryRowContext(ctx, &span, &callerName, "select config_update_pending from workspaces where _id = ? limit 1", workspace.ID).Scan(&configUpdatePending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query workspace config update state: %v", err)
+	}
+
 	// increment init state before frontend to represent the step that we are working on
 	// unless we are finished then we return the true code
 	if workspace.InitState != models.WorkspaceInitCompleted {
@@ -1098,8 +1116,9 @@ func GetWorkspaceStatus(ctx context.Context, db *ti.Database, vcsClient *git.VCS
 	}
 
 	return map[string]interface{}{
-		"workspace":     workspace.ToFrontend(hostname, tls),
-		"workspace_url": fmt.Sprintf("/editor/%d/%d-%s?folder=%s", callingUser.ID, workspace.ID, workspace.Commit, url.QueryEscape(gigoConfig.WorkingDirectory)),
+		"workspace":             workspace.ToFrontend(hostname, tls),
+		"config_update_pending": configUpdatePending,
+		"workspace_url":         fmt.Sprintf("/editor/%d/%d-%s?folder=%s", callingUser.ID, workspace.ID, workspace.Commit, url.QueryEscape(gigoConfig.WorkingDirectory)),
 		"code_source": map[string]interface{}{
 			"_id":         fmt.Sprintf("%d", workspace.CodeSourceID),
 			"type":        workspace.CodeSourceType,
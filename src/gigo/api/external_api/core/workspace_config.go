@@ -4,25 +4,35 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
+
 	"github.com/bwmarrin/snowflake"
 	ti "github.com/gage-technologies/gigo-lib/db"
 	"github.com/gage-technologies/gigo-lib/db/models"
-	"github.com/gage-technologies/gigo-lib/search"
+	"github.com/gage-technologies/gigo-lib/git"
+	"github.com/gage-technologies/gigo-lib/mq"
+	"github.com/go-redis/redis/v8"
 	"go.opentelemetry.io/otel"
 )
 
-func CreateWorkspaceConfig(ctx context.Context, db *ti.Database, meili *search.MeiliSearchEngine, sf *snowflake.Node,
+func CreateWorkspaceConfig(ctx context.Context, db *ti.Database, rdb redis.UniversalClient, sf *snowflake.Node,
 	callingUser *models.User, title string, description string, content string, tags []*models.Tag,
 	languages []models.ProgrammingLanguage) (map[string]interface{}, error) {
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "create-workspace-config")
 	callerName := "CreateWorkspaceConfig"
 
+	// validate that the config is in the right format
+	res, err := validateWorkspaceConfig(ctx, rdb, callingUser, content)
+	if res != nil || err != nil {
+		return res, err
+	}
+
 	// create id for new workspace config
 	id := sf.Generate().Int64()
 
 	// create slice to hold tag ids
 	tagIds := make([]int64, len(tags))
-	newTags := make([]interface{}, 0)
+	newTags := make([]int64, 0)
 
 	// open tx to perform insertion
 	tx, err := db.BeginTx(ctx, &span, &callerName, nil)
@@ -41,10 +51,6 @@ func CreateWorkspaceConfig(ctx context.Context, db *ti.Database, meili *search.M
 		}
 
 		_ = tx.Rollback()
-		_ = meili.DeleteDocuments("workspace_configs", id)
-		for _, tag := range newTags {
-			_ = meili.DeleteDocuments("tags", tag.(*models.TagSearch).ID)
-		}
 	}()
 
 	// iterate over the tags creating new tag structs for tags that do not already exist and adding ids to the slice created above
@@ -63,7 +69,7 @@ func CreateWorkspaceConfig(ctx context.Context, db *ti.Database, meili *search.M
 			}
 
 			// add tag to new tags for search engine insertion
-			newTags = append(newTags, tag.ToSearch())
+			newTags = append(newTags, tag.ID)
 		} else {
 			// increment tag column usage_count in database
 			_, err = tx.ExecContext(ctx, &callerName, "update tag set usage_count = usage_count + 1 where id =?", tag.ID)
@@ -102,18 +108,14 @@ func CreateWorkspaceConfig(ctx context.Context, db *ti.Database, meili *search.M
 		}
 	}
 
-	// perform insertion into search engine
-	err = meili.AddDocuments("workspace_configs", wc)
+	// queue the workspace config and the new tags to be made discoverable once committed
+	err = queueSearchSync(ctx, tx, callerName, "workspace_configs", id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to insert workspace config: %v", err)
+		return nil, err
 	}
-
-	// conditionally attempt to insert the tags into the search engine to make it discoverable
-	if len(newTags) > 0 {
-		err = meili.AddDocuments("tags", newTags...)
-		if err != nil {
-			return nil, fmt.Errorf("failed to add new tags to search engine: %v", err)
-		}
+	err = queueSearchSync(ctx, tx, callerName, "tags", newTags...)
+	if err != nil {
+		return nil, err
 	}
 
 	// commit insertion tx
@@ -131,15 +133,33 @@ func CreateWorkspaceConfig(ctx context.Context, db *ti.Database, meili *search.M
 	}, nil
 }
 
-func UpdateWorkspaceConfig(ctx context.Context, db *ti.Database, meili *search.MeiliSearchEngine, sf *snowflake.Node,
-	callingUser *models.User, id int64, description *string, content *string, tags []*models.Tag,
-	languages []models.ProgrammingLanguage) (map[string]interface{}, error) {
+// UpdateWorkspaceConfig creates a new revision of a workspace config. The new
+// content is validated like a new config and the revision is queued so that
+// posts following the latest revision are moved to it and the authors of every
+// post using the config are notified.
+func UpdateWorkspaceConfig(ctx context.Context, db *ti.Database, rdb redis.UniversalClient, js *mq.JetstreamClient,
+	vcsClient *git.VCSClient, sf *snowflake.Node, callingUser *models.User, id int64, description *string, content *string,
+	tags []*models.Tag, languages []models.ProgrammingLanguage, changeNote string) (map[string]interface{}, error) {
+	return updateWorkspaceConfig(ctx, db, rdb, js, vcsClient, sf, callingUser, id, description, content, tags, languages, changeNote, nil)
+}
+
+func updateWorkspaceConfig(ctx context.Context, db *ti.Database, rdb redis.UniversalClient, js *mq.JetstreamClient,
+	vcsClient *git.VCSClient, sf *snowflake.Node, callingUser *models.User, id int64, description *string, content *string,
+	tags []*models.Tag, languages []models.ProgrammingLanguage, changeNote string, rolledBackFrom *int) (map[string]interface{}, error) {
 	ctx, span := otel.Tracer("gigo-core").Start(ctx, "update-workspace-config")
 	callerName := "UpdateWorkspaceConfig"
 
+	// validate that the new config is in the right format
+	if content != nil {
+		res, err := validateWorkspaceConfig(ctx, rdb, callingUser, *content)
+		if res != nil || err != nil {
+			return res, err
+		}
+	}
+
 	// create slice to hold tag ids
 	tagIds := make([]int64, len(tags))
-	newTags := make([]interface{}, 0)
+	newTags := make([]int64, 0)
 
 	// open tx to perform insertion
 	tx, err := db.BeginTx(ctx, &span, &callerName, nil)
@@ -158,10 +178,6 @@ func UpdateWorkspaceConfig(ctx context.Context, db *ti.Database, meili *search.M
 		}
 
 		_ = tx.Rollback()
-		_ = meili.DeleteDocuments("workspace_configs", id)
-		for _, tag := range newTags {
-			_ = meili.DeleteDocuments("tags", tag.(*models.TagSearch).ID)
-		}
 	}()
 
 	// conditionally iterate over the tags creating new tag structs for tags that do not already exist and adding ids to the slice created above
@@ -181,7 +197,7 @@ func UpdateWorkspaceConfig(ctx context.Context, db *ti.Database, meili *search.M
 				}
 
 				// add tag to new tags for search engine insertion
-				newTags = append(newTags, tag.ToSearch())
+				newTags = append(newTags, tag.ID)
 			} else {
 				// increment tag column usage_count in database
 				_, err = tx.ExecContext(ctx, &callerName, "update tag set usage_count = usage_count + 1 where id =?", tag.ID)
@@ -249,25 +265,43 @@ func UpdateWorkspaceConfig(ctx context.Context, db *ti.Database, meili *search.M
 	}
 
 	// iterate over insertion statements
-	for _, statement := range statements {
-		_, err = tx.ExecContext(ctx, &callerName, statement.Statement, statement.Values...)
+	for i, statement := range statements {
+		insertRes, err := tx.ExecContext(ctx, &callerName, statement.Statement, statement.Values...)
 		if err != nil {
 			return nil, fmt.Errorf("failed to insert statement: %v", err)
 		}
+
+		// revisions are immutable so an existing revision means that another
+		// update was performed concurrently and this one must not be dropped silently
+		if i == 0 {
+			inserted, err := insertRes.RowsAffected()
+			if err != nil {
+				return nil, fmt.Errorf("failed to check inserted revision: %v", err)
+			}
+			if inserted == 0 {
+				return map[string]interface{}{"message": "The workspace config was updated by someone else. Please reload and try again."},
+					fmt.Errorf("revision %d of workspace config %d already exists", newWorkspaceConfig.Revision, id)
+			}
+		}
 	}
 
-	// replace document in search engine
-	err = meili.AddDocuments("workspace_configs", newWorkspaceConfig)
+	// record who created the revision and why
+	_, err = tx.ExecContext(ctx, &callerName,
+		"update workspace_config set created_at = ?, created_by = ?, change_note = ?, rolled_back_from = ? where _id = ? and revision = ?",
+		time.Now(), callingUser.ID, truncateString(changeNote, 280), rolledBackFrom, id, newWorkspaceConfig.Revision,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to insert workspace config to search engine: %v", err)
+		return nil, fmt.Errorf("failed to record workspace config revision details: %v", err)
 	}
 
-	// conditionally attempt to insert the tags into the search engine to make it discoverable
-	if len(newTags) > 0 {
-		err = meili.AddDocuments("tags", newTags...)
-		if err != nil {
-			return nil, fmt.Errorf("failed to add new tags to search engine: %v", err)
-		}
+	// queue the new revision and the new tags to be synced to the search engine once committed
+	err = queueSearchSync(ctx, tx, callerName, "workspace_configs", id)
+	if err != nil {
+		return nil, err
+	}
+	err = queueSearchSync(ctx, tx, callerName, "tags", newTags...)
+	if err != nil {
+		return nil, err
 	}
 
 	// commit insertion tx
@@ -279,6 +313,18 @@ func UpdateWorkspaceConfig(ctx context.Context, db *ti.Database, meili *search.M
 	// mark failed as false
 	failed = false
 
+	// queue the revision to be applied to the posts that use the config
+	err = publishWorkspaceConfigRevision(js, WorkspaceConfigRevisionMsg{
+		ConfigID: id,
+		Revision: newWorkspaceConfig.Revision,
+		EditorID: callingUser.ID,
+	})
+	if err != nil {
+		return map[string]interface{}{
+			"message": "Workspace Config Updated but the projects using it could not be updated.",
+		}, err
+	}
+
 	return map[string]interface{}{
 		"message":          "Workspace Config Updated.",
 		"workspace_config": newWorkspaceConfig.ToFrontend(),
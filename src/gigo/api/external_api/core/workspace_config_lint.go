@@ -0,0 +1,687 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/openvsx"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// hard resource limits for any workspace
+	workspaceMaxCPU  = 6
+	workspaceMaxMem  = 8
+	workspaceMaxDisk = 100
+
+	// resource limits for users without premium; larger values are clamped
+	// when the workspace is started
+	workspaceFreeCPU  = 2
+	workspaceFreeMem  = 3
+	workspaceFreeDisk = 15
+)
+
+var (
+	workspaceConfigTopLevelKeys = map[string]bool{
+		"version":           true,
+		"resources":         true,
+		"base_container":    true,
+		"working_directory": true,
+		"environment":       true,
+		"containers":        true,
+		"vscode":            true,
+		"port_forward":      true,
+		"exec":              true,
+	}
+
+	composeTopLevelKeys = map[string]bool{
+		"version":  true,
+		"name":     true,
+		"services": true,
+		"volumes":  true,
+		"networks": true,
+		"configs":  true,
+		"secrets":  true,
+	}
+
+	yamlErrorLineRegex      = regexp.MustCompile(`^yaml: line (\d+): `)
+	envVarNameRegex         = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	extensionIdRegex        = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*\.[A-Za-z0-9][A-Za-z0-9-]*$`)
+	containerImageRegex     = regexp.MustCompile(`^(?:[A-Za-z0-9.-]+(?::[0-9]+)?/)?[a-z0-9]+(?:[._-]+[a-z0-9]+)*(?:/[a-z0-9]+(?:[._-]+[a-z0-9]+)*)*(?::[A-Za-z0-9_][A-Za-z0-9_.-]{0,127})?(?:@sha256:[a-f0-9]{64})?$`)
+	composePortMappingRegex = regexp.MustCompile(`^(?:(?:[0-9.]+|\[[0-9a-fA-F:]+\]):)?(?:[0-9]+(?:-[0-9]+)?:)?[0-9]+(?:-[0-9]+)?(?:/(?:tcp|udp|sctp))?$`)
+)
+
+type LintWorkspaceConfigRequest struct {
+	Content string `json:"content" validate:"required"`
+	Test    bool   `json:"test"`
+}
+
+// WorkspaceConfigIssue is a single problem found in a workspace config. Line
+// and Column are 1-based positions in the yaml source.
+type WorkspaceConfigIssue struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+}
+
+// WorkspaceConfigLintResult holds the outcome of linting a workspace config.
+// Errors block the config from being saved while warnings are informational.
+type WorkspaceConfigLintResult struct {
+	Valid    bool                   `json:"valid"`
+	Errors   []WorkspaceConfigIssue `json:"errors"`
+	Warnings []WorkspaceConfigIssue `json:"warnings"`
+}
+
+// workspaceConfigExtensionRef is a vscode extension referenced by a config
+// that still needs to be checked against Open VSX
+type workspaceConfigExtensionRef struct {
+	id   string
+	path string
+	node *yaml.Node
+}
+
+type workspaceConfigLinter struct {
+	premium    bool
+	result     *WorkspaceConfigLintResult
+	extensions []workspaceConfigExtensionRef
+}
+
+func (l *workspaceConfigLinter) errorf(path string, node *yaml.Node, format string, args ...interface{}) {
+	l.result.Errors = append(l.result.Errors, newWorkspaceConfigIssue(path, node, fmt.Sprintf(format, args...)))
+}
+
+func (l *workspaceConfigLinter) warnf(path string, node *yaml.Node, format string, args ...interface{}) {
+	l.result.Warnings = append(l.result.Warnings, newWorkspaceConfigIssue(path, node, fmt.Sprintf(format, args...)))
+}
+
+func newWorkspaceConfigIssue(path string, node *yaml.Node, message string) WorkspaceConfigIssue {
+	issue := WorkspaceConfigIssue{Path: path, Message: message, Line: 1, Column: 1}
+	if node != nil && node.Line > 0 {
+		issue.Line = node.Line
+		issue.Column = node.Column
+	}
+	return issue
+}
+
+// yamlMappingEntry returns the key and value nodes for the key in a mapping
+// node or nil if the key is not present
+func yamlMappingEntry(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// yamlIsNull reports whether the node is missing or an explicit null
+func yamlIsNull(node *yaml.Node) bool {
+	return node == nil || (node.Kind == yaml.ScalarNode && node.Tag == "!!null")
+}
+
+// lintWorkspaceConfigContent performs every check on a workspace config that
+// does not require an external service. Extensions that are well formed are
+// returned so that the caller can verify that they exist.
+func lintWorkspaceConfigContent(content string, premium bool) (*WorkspaceConfigLintResult, []workspaceConfigExtensionRef) {
+	l := &workspaceConfigLinter{
+		premium: premium,
+		result: &WorkspaceConfigLintResult{
+			Errors:   make([]WorkspaceConfigIssue, 0),
+			Warnings: make([]WorkspaceConfigIssue, 0),
+		},
+		extensions: make([]workspaceConfigExtensionRef, 0),
+	}
+
+	var doc yaml.Node
+	err := yaml.Unmarshal([]byte(content), &doc)
+	if err != nil {
+		// yaml syntax errors only carry the line that they occurred on
+		issue := WorkspaceConfigIssue{Message: strings.TrimPrefix(err.Error(), "yaml: "), Line: 1, Column: 1}
+		if m := yamlErrorLineRegex.FindStringSubmatch(err.Error()); m != nil {
+			issue.Line, _ = strconv.Atoi(m[1])
+			issue.Message = strings.TrimPrefix(err.Error(), m[0])
+		}
+		l.result.Errors = append(l.result.Errors, issue)
+		return l.result, nil
+	}
+
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		l.errorf("", nil, "config is empty")
+		return l.result, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		l.errorf("", root, "config must be a mapping of keys to values")
+		return l.result, nil
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if !workspaceConfigTopLevelKeys[root.Content[i].Value] {
+			l.warnf(root.Content[i].Value, root.Content[i], "unknown key %q will be ignored", root.Content[i].Value)
+		}
+	}
+
+	l.lintVersion(root)
+	l.lintBaseContainer(root)
+	l.lintWorkingDirectory(root)
+	l.lintResources(root)
+	l.lintEnvironment(root)
+	l.lintContainers(root)
+	l.lintVSCode(root)
+	l.lintPortForward(root)
+	l.lintExec(root)
+
+	l.result.Valid = len(l.result.Errors) == 0
+	return l.result, l.extensions
+}
+
+func (l *workspaceConfigLinter) lintVersion(root *yaml.Node) {
+	_, node := yamlMappingEntry(root, "version")
+	if yamlIsNull(node) {
+		l.errorf("version", root, "version is required")
+		return
+	}
+
+	var version float64
+	if node.Kind != yaml.ScalarNode || node.Decode(&version) != nil || version != 0.1 {
+		l.errorf("version", node, "version must be 0.1")
+	}
+}
+
+func (l *workspaceConfigLinter) lintBaseContainer(root *yaml.Node) {
+	_, node := yamlMappingEntry(root, "base_container")
+	if yamlIsNull(node) || node.Kind != yaml.ScalarNode || node.Value == "" {
+		l.errorf("base_container", nodeOr(node, root), "must have a base container")
+		return
+	}
+
+	l.lintImageReference("base_container", node)
+}
+
+// lintImageReference validates the format of a container image reference and
+// warns when the image is not pinned to a tag or digest
+func (l *workspaceConfigLinter) lintImageReference(path string, node *yaml.Node) {
+	if !containerImageRegex.MatchString(node.Value) {
+		l.errorf(path, node, "%q is not a valid container image reference", node.Value)
+		return
+	}
+
+	// strip the registry host so that a registry port is not mistaken for a tag
+	name := node.Value
+	if idx := strings.LastIndex(name, "/"); idx >= 0 {
+		name = name[idx+1:]
+	}
+	if !strings.Contains(name, ":") && !strings.Contains(name, "@") {
+		l.warnf(path, node, "image %q does not specify a tag; latest will be used", node.Value)
+	}
+}
+
+func (l *workspaceConfigLinter) lintWorkingDirectory(root *yaml.Node) {
+	_, node := yamlMappingEntry(root, "working_directory")
+	if yamlIsNull(node) || node.Kind != yaml.ScalarNode || node.Value == "" {
+		l.errorf("working_directory", nodeOr(node, root), "must have a working directory")
+		return
+	}
+
+	if !strings.HasPrefix(node.Value, "/") {
+		l.errorf("working_directory", node, "working directory must be an absolute path")
+	}
+}
+
+func (l *workspaceConfigLinter) lintResources(root *yaml.Node) {
+	_, resources := yamlMappingEntry(root, "resources")
+	if yamlIsNull(resources) {
+		l.errorf("resources", root, "resources are required")
+		return
+	}
+	if resources.Kind != yaml.MappingNode {
+		l.errorf("resources", resources, "resources must be a mapping")
+		return
+	}
+
+	limits := []struct {
+		key   string
+		label string
+		unit  string
+		max   int
+		free  int
+	}{
+		{"cpu", "cpu cores", " CPU cores", workspaceMaxCPU, workspaceFreeCPU},
+		{"mem", "memory", " GB of RAM", workspaceMaxMem, workspaceFreeMem},
+		{"disk", "disk", " GB of disk space", workspaceMaxDisk, workspaceFreeDisk},
+	}
+
+	for _, limit := range limits {
+		path := "resources." + limit.key
+		_, node := yamlMappingEntry(resources, limit.key)
+		if yamlIsNull(node) {
+			l.errorf(path, resources, "must provide %s", limit.label)
+			continue
+		}
+
+		var value int
+		if node.Kind != yaml.ScalarNode || node.Decode(&value) != nil {
+			l.errorf(path, node, "%s must be a whole number", limit.label)
+			continue
+		}
+
+		if value <= 0 {
+			l.errorf(path, node, "must provide %s", limit.label)
+			continue
+		}
+
+		if value > limit.max {
+			l.errorf(path, node, "cannot use more than %d%s", limit.max, limit.unit)
+			continue
+		}
+
+		if value > limit.free {
+			if l.premium {
+				l.warnf(path, node, "users without premium will be limited to %d%s", limit.free, limit.unit)
+			} else {
+				l.warnf(path, node, "your workspaces will be limited to %d%s without premium", limit.free, limit.unit)
+			}
+		}
+	}
+
+	_, gpu := yamlMappingEntry(resources, "gpu")
+	if yamlIsNull(gpu) {
+		return
+	}
+	if gpu.Kind != yaml.MappingNode {
+		l.errorf("resources.gpu", gpu, "gpu must be a mapping")
+		return
+	}
+	_, count := yamlMappingEntry(gpu, "count")
+	var gpuCount int
+	if !yamlIsNull(count) && (count.Decode(&gpuCount) != nil || gpuCount < 0) {
+		l.errorf("resources.gpu.count", count, "gpu count must be a positive whole number")
+	}
+}
+
+func (l *workspaceConfigLinter) lintEnvironment(root *yaml.Node) {
+	_, env := yamlMappingEntry(root, "environment")
+	if yamlIsNull(env) {
+		return
+	}
+	if env.Kind != yaml.MappingNode {
+		l.errorf("environment", env, "environment must be a mapping of variable names to values")
+		return
+	}
+
+	for i := 0; i+1 < len(env.Content); i += 2 {
+		key, value := env.Content[i], env.Content[i+1]
+		path := "environment." + key.Value
+		if !envVarNameRegex.MatchString(key.Value) {
+			l.errorf(path, key, "%q is not a valid environment variable name", key.Value)
+		}
+		if value.Kind != yaml.ScalarNode {
+			l.errorf(path, value, "environment variable values must be strings")
+		}
+	}
+}
+
+// lintContainers validates the docker compose spec that is used to launch
+// the auxiliary containers of a workspace
+func (l *workspaceConfigLinter) lintContainers(root *yaml.Node) {
+	_, compose := yamlMappingEntry(root, "containers")
+	if yamlIsNull(compose) {
+		return
+	}
+	if compose.Kind != yaml.MappingNode {
+		l.errorf("containers", compose, "containers must be a docker compose mapping")
+		return
+	}
+
+	// an empty mapping disables the auxiliary containers
+	if len(compose.Content) == 0 {
+		return
+	}
+
+	for i := 0; i+1 < len(compose.Content); i += 2 {
+		if !composeTopLevelKeys[compose.Content[i].Value] {
+			l.warnf("containers."+compose.Content[i].Value, compose.Content[i], "unknown docker compose key %q", compose.Content[i].Value)
+		}
+	}
+
+	_, services := yamlMappingEntry(compose, "services")
+	if yamlIsNull(services) {
+		l.errorf("containers.services", compose, "docker compose spec must define services")
+		return
+	}
+	if services.Kind != yaml.MappingNode {
+		l.errorf("containers.services", services, "services must be a mapping of service names to definitions")
+		return
+	}
+
+	serviceNames := make(map[string]bool)
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		serviceNames[services.Content[i].Value] = true
+	}
+
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		name, service := services.Content[i], services.Content[i+1]
+		path := "containers.services." + name.Value
+		if service.Kind != yaml.MappingNode {
+			l.errorf(path, service, "service %q must be a mapping", name.Value)
+			continue
+		}
+
+		_, image := yamlMappingEntry(service, "image")
+		_, build := yamlMappingEntry(service, "build")
+		if yamlIsNull(image) && yamlIsNull(build) {
+			l.errorf(path, name, "service %q must specify an image or build", name.Value)
+		}
+		if !yamlIsNull(image) {
+			if image.Kind != yaml.ScalarNode || image.Value == "" {
+				l.errorf(path+".image", image, "image must be a string")
+			} else {
+				l.lintImageReference(path+".image", image)
+			}
+		}
+
+		l.lintComposePorts(path, service)
+		l.lintComposeEnvironment(path, service)
+		l.lintComposeDependsOn(path, service, serviceNames)
+	}
+}
+
+func (l *workspaceConfigLinter) lintComposePorts(path string, service *yaml.Node) {
+	_, ports := yamlMappingEntry(service, "ports")
+	if yamlIsNull(ports) {
+		return
+	}
+	if ports.Kind != yaml.SequenceNode {
+		l.errorf(path+".ports", ports, "ports must be a list")
+		return
+	}
+
+	for i, port := range ports.Content {
+		portPath := fmt.Sprintf("%s.ports[%d]", path, i)
+		switch port.Kind {
+		case yaml.ScalarNode:
+			if !composePortMappingRegex.MatchString(port.Value) {
+				l.errorf(portPath, port, "%q is not a valid port mapping", port.Value)
+			}
+		case yaml.MappingNode:
+			_, target := yamlMappingEntry(port, "target")
+			var targetPort int
+			if yamlIsNull(target) || target.Decode(&targetPort) != nil || targetPort < 1 || targetPort > 65535 {
+				l.errorf(portPath, port, "port mapping must specify a target between 1 and 65535")
+			}
+		default:
+			l.errorf(portPath, port, "port mapping must be a string or mapping")
+		}
+	}
+}
+
+func (l *workspaceConfigLinter) lintComposeEnvironment(path string, service *yaml.Node) {
+	_, env := yamlMappingEntry(service, "environment")
+	if yamlIsNull(env) {
+		return
+	}
+
+	switch env.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(env.Content); i += 2 {
+			if env.Content[i+1].Kind != yaml.ScalarNode {
+				l.errorf(path+".environment."+env.Content[i].Value, env.Content[i+1], "environment variable values must be strings")
+			}
+		}
+	case yaml.SequenceNode:
+		for i, entry := range env.Content {
+			name := strings.SplitN(entry.Value, "=", 2)[0]
+			if entry.Kind != yaml.ScalarNode || !envVarNameRegex.MatchString(name) {
+				l.errorf(fmt.Sprintf("%s.environment[%d]", path, i), entry, "environment entries must be in the form NAME=value")
+			}
+		}
+	default:
+		l.errorf(path+".environment", env, "environment must be a mapping or a list")
+	}
+}
+
+func (l *workspaceConfigLinter) lintComposeDependsOn(path string, service *yaml.Node, serviceNames map[string]bool) {
+	_, dependsOn := yamlMappingEntry(service, "depends_on")
+	if yamlIsNull(dependsOn) {
+		return
+	}
+
+	dependencies := make([]*yaml.Node, 0)
+	switch dependsOn.Kind {
+	case yaml.SequenceNode:
+		dependencies = dependsOn.Content
+	case yaml.MappingNode:
+		for i := 0; i < len(dependsOn.Content); i += 2 {
+			dependencies = append(dependencies, dependsOn.Content[i])
+		}
+	default:
+		l.errorf(path+".depends_on", dependsOn, "depends_on must be a list or mapping")
+		return
+	}
+
+	for _, dep := range dependencies {
+		if !serviceNames[dep.Value] {
+			l.errorf(path+".depends_on", dep, "service depends on unknown service %q", dep.Value)
+		}
+	}
+}
+
+func (l *workspaceConfigLinter) lintVSCode(root *yaml.Node) {
+	_, vscode := yamlMappingEntry(root, "vscode")
+	if yamlIsNull(vscode) {
+		return
+	}
+	if vscode.Kind != yaml.MappingNode {
+		l.errorf("vscode", vscode, "vscode must be a mapping")
+		return
+	}
+
+	enabled := false
+	_, enabledNode := yamlMappingEntry(vscode, "enabled")
+	if !yamlIsNull(enabledNode) && enabledNode.Decode(&enabled) != nil {
+		l.errorf("vscode.enabled", enabledNode, "enabled must be true or false")
+	}
+
+	_, extensions := yamlMappingEntry(vscode, "extensions")
+	if yamlIsNull(extensions) {
+		return
+	}
+	if extensions.Kind != yaml.SequenceNode {
+		l.errorf("vscode.extensions", extensions, "extensions must be a list")
+		return
+	}
+
+	if !enabled && len(extensions.Content) > 0 {
+		l.warnf("vscode.extensions", extensions, "extensions will not be installed because vscode is not enabled")
+	}
+
+	seen := make(map[string]bool)
+	for i, ext := range extensions.Content {
+		path := fmt.Sprintf("vscode.extensions[%d]", i)
+		if ext.Kind != yaml.ScalarNode || !extensionIdRegex.MatchString(ext.Value) {
+			l.errorf(path, ext, "%q is not a valid extension id; expected publisher.name", ext.Value)
+			continue
+		}
+
+		id := strings.ToLower(ext.Value)
+		if seen[id] {
+			l.warnf(path, ext, "extension %q is listed more than once", ext.Value)
+			continue
+		}
+		seen[id] = true
+
+		l.extensions = append(l.extensions, workspaceConfigExtensionRef{id: ext.Value, path: path, node: ext})
+	}
+}
+
+func (l *workspaceConfigLinter) lintPortForward(root *yaml.Node) {
+	_, ports := yamlMappingEntry(root, "port_forward")
+	if yamlIsNull(ports) {
+		return
+	}
+	if ports.Kind != yaml.SequenceNode {
+		l.errorf("port_forward", ports, "port_forward must be a list")
+		return
+	}
+
+	seen := make(map[int]bool)
+	for i, entry := range ports.Content {
+		path := fmt.Sprintf("port_forward[%d]", i)
+		if entry.Kind != yaml.MappingNode {
+			l.errorf(path, entry, "port forward must be a mapping with a name and port")
+			continue
+		}
+
+		_, portNode := yamlMappingEntry(entry, "port")
+		var port int
+		if yamlIsNull(portNode) || portNode.Decode(&port) != nil || port < 1 || port > 65535 {
+			l.errorf(path+".port", nodeOr(portNode, entry), "port must be between 1 and 65535")
+			continue
+		}
+
+		if seen[port] {
+			l.errorf(path+".port", portNode, "port %d is forwarded more than once", port)
+		}
+		seen[port] = true
+	}
+}
+
+func (l *workspaceConfigLinter) lintExec(root *yaml.Node) {
+	_, execs := yamlMappingEntry(root, "exec")
+	if yamlIsNull(execs) {
+		return
+	}
+	if execs.Kind != yaml.SequenceNode {
+		l.errorf("exec", execs, "exec must be a list")
+		return
+	}
+
+	for i, entry := range execs.Content {
+		path := fmt.Sprintf("exec[%d]", i)
+		if entry.Kind != yaml.MappingNode {
+			l.errorf(path, entry, "exec must be a mapping with a name and command")
+			continue
+		}
+
+		_, name := yamlMappingEntry(entry, "name")
+		if yamlIsNull(name) || name.Value == "" {
+			l.errorf(path+".name", entry, "exec must have a name")
+		}
+
+		_, command := yamlMappingEntry(entry, "command")
+		if yamlIsNull(command) || command.Kind != yaml.ScalarNode || strings.TrimSpace(command.Value) == "" {
+			l.errorf(path+".command", nodeOr(command, entry), "exec must have a command")
+		}
+
+		_, init := yamlMappingEntry(entry, "init")
+		var initValue bool
+		if !yamlIsNull(init) && init.Decode(&initValue) != nil {
+			l.errorf(path+".init", init, "init must be true or false")
+		}
+	}
+}
+
+func nodeOr(node *yaml.Node, fallback *yaml.Node) *yaml.Node {
+	if node != nil {
+		return node
+	}
+	return fallback
+}
+
+// openVsxExtensionExists checks whether an extension is published on Open VSX.
+// Results are cached in redis so that repeated lints do not hit the registry.
+func openVsxExtensionExists(ctx context.Context, rdb redis.UniversalClient, extensionId string) (bool, error) {
+	key := fmt.Sprintf("vsc:ext:exists:%s", strings.ToLower(extensionId))
+
+	cached, err := rdb.Get(ctx, key).Result()
+	if err != nil && err != redis.Nil {
+		return false, fmt.Errorf("failed to get extension existence from redis: %v", err)
+	}
+	if err == nil {
+		return cached == "1", nil
+	}
+
+	client := openvsx.NewClient("", nil)
+	_, err = client.GetMetadata(extensionId, "")
+	if err != nil {
+		if errors.Is(err, openvsx.ErrExtensionNotFound) {
+			// cache misses for a shorter period so newly published extensions are picked up
+			_ = rdb.Set(ctx, key, "0", time.Hour).Err()
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get extension metadata: %v", err)
+	}
+
+	_ = rdb.Set(ctx, key, "1", time.Hour*24).Err()
+	return true, nil
+}
+
+// lintWorkspaceConfig runs the full set of checks on a workspace config
+// including the verification of vscode extensions against Open VSX
+func lintWorkspaceConfig(ctx context.Context, rdb redis.UniversalClient, callingUser *models.User, content string) *WorkspaceConfigLintResult {
+	premium := callingUser != nil && callingUser.UserStatus == models.UserStatusPremium
+	result, extensions := lintWorkspaceConfigContent(content, premium)
+
+	for _, ext := range extensions {
+		exists, err := openVsxExtensionExists(ctx, rdb, ext.id)
+		if err != nil {
+			// an unreachable registry should not block saving the config
+			result.Warnings = append(result.Warnings, newWorkspaceConfigIssue(ext.path, ext.node,
+				fmt.Sprintf("could not verify extension %q on Open VSX", ext.id)))
+			continue
+		}
+		if !exists {
+			result.Errors = append(result.Errors, newWorkspaceConfigIssue(ext.path, ext.node,
+				fmt.Sprintf("extension %q was not found on Open VSX", ext.id)))
+		}
+	}
+
+	result.Valid = len(result.Errors) == 0
+	return result
+}
+
+// LintWorkspaceConfig validates a workspace config and returns every error and
+// warning anchored to its position in the yaml source
+func LintWorkspaceConfig(ctx context.Context, rdb redis.UniversalClient, callingUser *models.User, content string) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "lint-workspace-config-core")
+	defer span.End()
+
+	result := lintWorkspaceConfig(ctx, rdb, callingUser, content)
+
+	return map[string]interface{}{
+		"valid":    result.Valid,
+		"errors":   result.Errors,
+		"warnings": result.Warnings,
+	}, nil
+}
+
+// validateWorkspaceConfig lints a workspace config before it is saved. A
+// non-nil response describes the first error along with the full lint result.
+func validateWorkspaceConfig(ctx context.Context, rdb redis.UniversalClient, callingUser *models.User, content string) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "validate-workspace-config-core")
+	defer span.End()
+
+	result := lintWorkspaceConfig(ctx, rdb, callingUser, content)
+	if result.Valid {
+		return nil, nil
+	}
+
+	first := result.Errors[0]
+	return map[string]interface{}{
+		"message": fmt.Sprintf("line %d: %s", first.Line, first.Message),
+		"lint":    result,
+	}, nil
+}
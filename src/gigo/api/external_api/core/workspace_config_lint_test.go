@@ -0,0 +1,95 @@
+package core
+
+import (
+	"testing"
+)
+
+const lintTestConfig = `version: 0.1
+resources:
+  cpu: 4
+  mem: 3
+  disk: 15
+base_container: gigodev/gimg:base-ubuntu
+working_directory: /home/gigo/codebase
+environment:
+  APP_ENV: dev
+containers:
+  version: '3.7'
+  services:
+    db:
+      image: 'mongo:6'
+      ports:
+        - '27017:27017'
+vscode:
+  enabled: true
+  extensions:
+    - golang.Go
+port_forward:
+  - name: app
+    port: 8080
+exec:
+  - name: install
+    init: true
+    command: go mod download
+`
+
+func TestLintWorkspaceConfigContent(t *testing.T) {
+	// cpu exceeds the free tier so only a warning is expected
+	res, extensions := lintWorkspaceConfigContent(lintTestConfig, false)
+	if !res.Valid || len(res.Errors) != 0 {
+		t.Fatalf("\nTestLintWorkspaceConfigContent failed\n    Error: unexpected errors %v", res.Errors)
+	}
+	if len(res.Warnings) != 1 || res.Warnings[0].Path != "resources.cpu" || res.Warnings[0].Line != 3 || res.Warnings[0].Column != 8 {
+		t.Errorf("\nTestLintWorkspaceConfigContent failed\n    Error: unexpected warnings %v", res.Warnings)
+	}
+	if len(extensions) != 1 || extensions[0].id != "golang.Go" {
+		t.Errorf("\nTestLintWorkspaceConfigContent failed\n    Error: unexpected extensions %v", extensions)
+	}
+
+	// premium users are not clamped but are still told about the free tier
+	res, _ = lintWorkspaceConfigContent(lintTestConfig, true)
+	if !res.Valid || len(res.Warnings) != 1 {
+		t.Errorf("\nTestLintWorkspaceConfigContent failed\n    Error: unexpected premium result %v", res)
+	}
+}
+
+func TestLintWorkspaceConfigContentErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		path   string
+		line   int
+		column int
+	}{
+		{"syntax", "version: 0.1\nresources: [\n", "", 2, 1},
+		{"version", "version: 2\n", "version", 1, 10},
+		{"cpu limit", "resources:\n  cpu: 12\n", "resources.cpu", 2, 8},
+		{"relative working directory", "working_directory: codebase\n", "working_directory", 1, 20},
+		{"env name", "environment:\n  1BAD: x\n", "environment.1BAD", 2, 3},
+		{"compose image", "containers:\n  services:\n    db:\n      ports: ['80']\n", "containers.services.db", 3, 5},
+		{"compose port", "containers:\n  services:\n    db:\n      image: mongo:6\n      ports: ['abc']\n", "containers.services.db.ports[0]", 5, 15},
+		{"compose depends", "containers:\n  services:\n    db:\n      image: mongo:6\n      depends_on: [cache]\n", "containers.services.db.depends_on", 5, 20},
+		{"extension id", "vscode:\n  enabled: true\n  extensions:\n    - not-an-extension\n", "vscode.extensions[0]", 4, 7},
+		{"port range", "port_forward:\n  - name: app\n    port: 70000\n", "port_forward[0].port", 3, 11},
+		{"exec command", "exec:\n  - name: build\n    command: ''\n", "exec[0].command", 3, 14},
+	}
+
+	for _, tt := range tests {
+		res, _ := lintWorkspaceConfigContent(tt.config, false)
+		if res.Valid {
+			t.Errorf("\nTestLintWorkspaceConfigContentErrors failed\n    Case: %s\n    Error: config was valid", tt.name)
+			continue
+		}
+
+		found := false
+		for _, issue := range res.Errors {
+			if issue.Path == tt.path && issue.Line == tt.line && issue.Column == tt.column {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("\nTestLintWorkspaceConfigContentErrors failed\n    Case: %s\n    Error: missing error at %s %d:%d in %v", tt.name, tt.path, tt.line, tt.column, res.Errors)
+		}
+	}
+}
@@ -0,0 +1,469 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/snowflake"
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/git"
+	"github.com/gage-technologies/gigo-lib/logging"
+	"github.com/gage-technologies/gigo-lib/mq"
+	"github.com/gage-technologies/gitea-go/gitea"
+	"github.com/go-redis/redis/v8"
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel"
+)
+
+// Jetstream configuration for the workspace config revision work queue. The
+// stream is owned and initialized by gigo-core like the discussion stream.
+const (
+	StreamWorkspaceConfig = "WorkspaceConfig"
+
+	SubjectWorkspaceConfigRevision = "WORKSPACE_CONFIG.Revision"
+
+	RetentionPolicyWorkspaceConfig = nats.WorkQueuePolicy
+)
+
+type WorkspaceConfigRevisionsRequest struct {
+	ConfigID string `json:"config_id" validate:"required,number"`
+	Test     bool   `json:"test"`
+}
+
+type DiffWorkspaceConfigRevisionsRequest struct {
+	ConfigID     string `json:"config_id" validate:"required,number"`
+	FromRevision int    `json:"from_revision" validate:"gte=0"`
+	ToRevision   int    `json:"to_revision" validate:"gte=0"`
+	Test         bool   `json:"test"`
+}
+
+type RollbackWorkspaceConfigRequest struct {
+	ConfigID string `json:"config_id" validate:"required,number"`
+	Revision int    `json:"revision" validate:"gte=0"`
+	Test     bool   `json:"test"`
+}
+
+type SetPostWorkspaceConfigRevisionRequest struct {
+	ProjectID string `json:"project_id" validate:"required,number"`
+	// Revision pins the project to a revision of its workspace config. A nil
+	// revision makes the project follow the latest revision.
+	Revision *int `json:"revision" validate:"omitempty,gte=0"`
+	Test     bool `json:"test"`
+}
+
+type WorkspaceConfigRevisionFrontend struct {
+	Revision       int        `json:"revision"`
+	CreatedAt      *time.Time `json:"created_at"`
+	CreatedBy      *string    `json:"created_by"`
+	CreatedByName  *string    `json:"created_by_name"`
+	ChangeNote     string     `json:"change_note"`
+	RolledBackFrom *int       `json:"rolled_back_from"`
+}
+
+// ListWorkspaceConfigRevisions lists the revisions of a workspace config with
+// the newest revision first
+func ListWorkspaceConfigRevisions(ctx context.Context, tidb *ti.Database, configId int64) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "list-workspace-config-revisions-core")
+	defer span.End()
+	callerName := "ListWorkspaceConfigRevisions"
+
+	rows, err := tidb.QueryContext(ctx, &span, &callerName,
+		"select w.revision, w.created_at, w.created_by, u.user_name, w.change_note, w.rolled_back_from "+
+			"from workspace_config w left join users u on u._id = w.created_by where w._id = ? order by w.revision desc",
+		configId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query workspace config revisions: %v", err)
+	}
+	defer rows.Close()
+
+	revisions := make([]*WorkspaceConfigRevisionFrontend, 0)
+	for rows.Next() {
+		var revision WorkspaceConfigRevisionFrontend
+		var createdAt sql.NullTime
+		var createdBy, rolledBackFrom sql.NullInt64
+		var createdByName, changeNote sql.NullString
+		err = rows.Scan(&revision.Revision, &createdAt, &createdBy, &createdByName, &changeNote, &rolledBackFrom)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan workspace config revision: %v", err)
+		}
+
+		// revisions created before revision details were recorded have no author or time
+		if createdAt.Valid {
+			revision.CreatedAt = &createdAt.Time
+		}
+		if createdBy.Valid {
+			id := fmt.Sprintf("%d", createdBy.Int64)
+			revision.CreatedBy = &id
+		}
+		if createdByName.Valid {
+			revision.CreatedByName = &createdByName.String
+		}
+		if rolledBackFrom.Valid {
+			r := int(rolledBackFrom.Int64)
+			revision.RolledBackFrom = &r
+		}
+		revision.ChangeNote = changeNote.String
+		revisions = append(revisions, &revision)
+	}
+
+	if len(revisions) == 0 {
+		return map[string]interface{}{"message": "workspace config not found"}, ErrNotFound
+	}
+
+	return map[string]interface{}{"revisions": revisions}, nil
+}
+
+// loadWorkspaceConfigRevision retrieves a single revision of a workspace config
+func loadWorkspaceConfigRevision(ctx context.Context, tidb *ti.Database, configId int64, revision int) (*models.WorkspaceConfig, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "load-workspace-config-revision-core")
+	defer span.End()
+	callerName := "loadWorkspaceConfigRevision"
+
+	rows, err := tidb.QueryContext(ctx, &span, &callerName,
+		"select * from workspace_config where _id = ? and revision = ? limit 1", configId, revision,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query workspace config revision: %v", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, ErrNotFound
+	}
+
+	cfg, err := models.WorkspaceConfigFromSQLNative(tidb, rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode workspace config revision: %v", err)
+	}
+
+	return cfg, nil
+}
+
+// DiffWorkspaceConfigRevisions returns the line diff between the yaml of two
+// revisions of a workspace config
+func DiffWorkspaceConfigRevisions(ctx context.Context, tidb *ti.Database, configId int64, fromRevision int, toRevision int) (map[string]interface{}, error) {
+	from, err := loadWorkspaceConfigRevision(ctx, tidb, configId, fromRevision)
+	if err != nil {
+		if err == ErrNotFound {
+			return map[string]interface{}{"message": fmt.Sprintf("revision %d not found", fromRevision)}, err
+		}
+		return nil, err
+	}
+
+	to, err := loadWorkspaceConfigRevision(ctx, tidb, configId, toRevision)
+	if err != nil {
+		if err == ErrNotFound {
+			return map[string]interface{}{"message": fmt.Sprintf("revision %d not found", toRevision)}, err
+		}
+		return nil, err
+	}
+
+	hunks, additions, deletions := computeFileDiff(from.Content, to.Content)
+
+	return map[string]interface{}{
+		"from_revision": fromRevision,
+		"to_revision":   toRevision,
+		"hunks":         hunks,
+		"additions":     additions,
+		"deletions":     deletions,
+	}, nil
+}
+
+// RollbackWorkspaceConfig restores a previous revision of a workspace config.
+// The rollback creates a new revision with the content of the old revision so
+// that the history is never rewritten.
+func RollbackWorkspaceConfig(ctx context.Context, tidb *ti.Database, rdb redis.UniversalClient, js *mq.JetstreamClient,
+	vcsClient *git.VCSClient, sf *snowflake.Node, callingUser *models.User, configId int64, revision int) (map[string]interface{}, error) {
+	target, err := loadWorkspaceConfigRevision(ctx, tidb, configId, revision)
+	if err != nil {
+		if err == ErrNotFound {
+			return map[string]interface{}{"message": fmt.Sprintf("revision %d not found", revision)}, err
+		}
+		return nil, err
+	}
+
+	if target.AuthorID != callingUser.ID {
+		return map[string]interface{}{"message": "you do not have permission to edit this workspace config"},
+			fmt.Errorf("user %d cannot roll back workspace config %d: %w", callingUser.ID, configId, ErrForbidden)
+	}
+
+	tags := make([]*models.Tag, 0, len(target.Tags))
+	for _, tag := range target.Tags {
+		tags = append(tags, &models.Tag{ID: tag})
+	}
+
+	return updateWorkspaceConfig(ctx, tidb, rdb, js, vcsClient, sf, callingUser, configId, &target.Description,
+		&target.Content, tags, target.Languages, fmt.Sprintf("Rolled back to revision %d", revision), &revision)
+}
+
+// writeRepoWorkspaceConfig commits the workspace config content to the main
+// branch of a post repository
+func writeRepoWorkspaceConfig(vcsClient *git.VCSClient, repoId int64, content string, message string) error {
+	repo, _, err := vcsClient.GiteaClient.GetRepoByID(repoId)
+	if err != nil {
+		return fmt.Errorf("failed to locate repo %d: %v", repoId, err)
+	}
+
+	fileMeta, _, err := vcsClient.GiteaClient.GetContents(repo.Owner.UserName, repo.Name, "main", ".gigo/workspace.yaml")
+	if err != nil {
+		return fmt.Errorf("failed to retrieve workspace config from repo %d: %v", repoId, err)
+	}
+
+	// skip the commit if the repo already has the content
+	if fileMeta.Content != nil {
+		existing, err := base64.StdEncoding.DecodeString(*fileMeta.Content)
+		if err == nil && string(existing) == content {
+			return nil
+		}
+	}
+
+	_, gitRes, err := vcsClient.GiteaClient.UpdateFile(
+		repo.Owner.UserName,
+		repo.Name,
+		".gigo/workspace.yaml",
+		gitea.UpdateFileOptions{
+			SHA:     fileMeta.SHA,
+			Content: base64.StdEncoding.EncodeToString([]byte(content)),
+			FileOptions: gitea.FileOptions{
+				Message:    message,
+				BranchName: "main",
+				Author: gitea.Identity{
+					Name:  "Gigo",
+					Email: "gigo@gigo.dev",
+				},
+				Committer: gitea.Identity{
+					Name:  "Gigo",
+					Email: "gigo@gigo.dev",
+				},
+			},
+		},
+	)
+	if err != nil {
+		buf := []byte{}
+		if gitRes != nil {
+			buf, _ = io.ReadAll(gitRes.Body)
+		}
+		return fmt.Errorf("failed to update the workspace config in repo %d: %v\n    res: %v", repoId, err, string(buf))
+	}
+
+	return nil
+}
+
+// WorkspaceConfigRevisionMsg is published when a workspace config gets a new
+// revision so that a follower can apply it to the posts using the config
+// outside of the request
+type WorkspaceConfigRevisionMsg struct {
+	ConfigID int64
+	Revision int
+	EditorID int64
+}
+
+// InitWorkspaceConfigStream creates the workspace config stream if it does not exist
+func InitWorkspaceConfigStream(js *mq.JetstreamClient) error {
+	_, err := js.StreamInfo(StreamWorkspaceConfig)
+	if err == nil {
+		return nil
+	}
+	if err != nats.ErrStreamNotFound {
+		return fmt.Errorf("failed to retrieve workspace config stream: %v", err)
+	}
+
+	_, err = js.AddStream(&nats.StreamConfig{
+		Name:      StreamWorkspaceConfig,
+		Subjects:  []string{SubjectWorkspaceConfigRevision},
+		Retention: RetentionPolicyWorkspaceConfig,
+	})
+	if err != nil && !strings.Contains(err.Error(), "stream name already in use") {
+		return fmt.Errorf("failed to create workspace config stream: %v", err)
+	}
+
+	return nil
+}
+
+// publishWorkspaceConfigRevision queues the propagation of a new revision to
+// the posts using the config
+func publishWorkspaceConfigRevision(js *mq.JetstreamClient, msg WorkspaceConfigRevisionMsg) error {
+	buf := bytes.NewBuffer(nil)
+	encoder := gob.NewEncoder(buf)
+	err := encoder.Encode(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode workspace config revision %d of %d: %v", msg.Revision, msg.ConfigID, err)
+	}
+
+	_, err = js.PublishAsync(SubjectWorkspaceConfigRevision, buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to publish workspace config revision %d of %d: %v", msg.Revision, msg.ConfigID, err)
+	}
+
+	return nil
+}
+
+// PropagateWorkspaceConfigRevision moves the posts that follow the latest
+// revision of the config onto the new revision and notifies the authors of
+// every post using the config. Revisions that have been superseded by the time
+// they are processed are skipped since the newer revision is propagated by its
+// own message. Failures of individual posts are logged so that one broken repo
+// does not block the others; an error is only returned when no post has been
+// processed yet and the message can safely be retried.
+func PropagateWorkspaceConfigRevision(ctx context.Context, tidb *ti.Database, js *mq.JetstreamClient, vcsClient *git.VCSClient,
+	sf *snowflake.Node, logger logging.Logger, msg WorkspaceConfigRevisionMsg) error {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "propagate-workspace-config-revision-core")
+	defer span.End()
+	callerName := "PropagateWorkspaceConfigRevision"
+
+	var latest int
+	err := tidb.QueryRowContext(ctx, &span, &callerName,
+		"select max(revision) from workspace_config where _id = ?", msg.ConfigID,
+	).Scan(&latest)
+	if err != nil {
+		return fmt.Errorf("failed to query latest workspace config revision: %v", err)
+	}
+	if latest != msg.Revision {
+		return nil
+	}
+
+	cfg, err := loadWorkspaceConfigRevision(ctx, tidb, msg.ConfigID, msg.Revision)
+	if err != nil {
+		return err
+	}
+
+	rows, err := tidb.QueryContext(ctx, &span, &callerName,
+		"select _id, author_id, repo_id, title, workspace_config_follow_latest from post where workspace_config = ? and deleted = false",
+		cfg.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to query posts using workspace config: %v", err)
+	}
+
+	type dependentPost struct {
+		id           int64
+		authorId     int64
+		repoId       int64
+		title        string
+		followLatest bool
+	}
+
+	posts := make([]dependentPost, 0)
+	for rows.Next() {
+		var p dependentPost
+		err = rows.Scan(&p.id, &p.authorId, &p.repoId, &p.title, &p.followLatest)
+		if err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("failed to scan post using workspace config: %v", err)
+		}
+		posts = append(posts, p)
+	}
+	_ = rows.Close()
+
+	for _, p := range posts {
+		message := fmt.Sprintf("The workspace config %q used by %q has a new revision %d.", cfg.Title, p.title, cfg.Revision)
+
+		if p.followLatest {
+			err = writeRepoWorkspaceConfig(vcsClient, p.repoId, cfg.Content,
+				fmt.Sprintf("Update workspace config to revision %d", cfg.Revision))
+			if err == nil {
+				// posts are only ever moved forward in case an older revision is processed late
+				_, err = tidb.ExecContext(ctx, &span, &callerName,
+					"update post set workspace_config_revision = ? where _id = ? and workspace_config_revision < ?",
+					cfg.Revision, p.id, cfg.Revision,
+				)
+			}
+			if err != nil {
+				logger.Errorf("failed to move post %d to workspace config revision %d: %v", p.id, cfg.Revision, err)
+				continue
+			}
+			message = fmt.Sprintf("%q was updated to revision %d of the workspace config %q.", p.title, cfg.Revision, cfg.Title)
+		}
+
+		// authors are never notified about their own changes
+		if p.authorId == msg.EditorID {
+			continue
+		}
+
+		_, err = CreateNotification(ctx, tidb, js, sf, p.authorId, message, WorkspaceConfigUpdateNotification, &msg.EditorID)
+		if err != nil {
+			logger.Errorf("failed to notify author of post %d of workspace config revision %d: %v", p.id, cfg.Revision, err)
+		}
+	}
+
+	return nil
+}
+
+// SetPostWorkspaceConfigRevision pins a post to a revision of its workspace
+// config or makes it follow the latest revision. The selected revision is
+// committed to the post repository.
+func SetPostWorkspaceConfigRevision(ctx context.Context, tidb *ti.Database, vcsClient *git.VCSClient, callingUser *models.User,
+	postId int64, revision *int) (map[string]interface{}, error) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "set-post-workspace-config-revision-core")
+	defer span.End()
+	callerName := "SetPostWorkspaceConfigRevision"
+
+	ok, err := HasPostPermission(ctx, tidb, postId, callingUser.ID, CollaboratorMaintainer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check post permission: %v", err)
+	}
+	if !ok {
+		return map[string]interface{}{"message": "you do not have permission to edit this project"},
+			fmt.Errorf("user %d cannot edit post %d: %w", callingUser.ID, postId, ErrForbidden)
+	}
+
+	var configId, repoId int64
+	err = tidb.QueryRowContext(ctx, &span, &callerName,
+		"select workspace_config, repo_id from post where _id = ? and deleted = false limit 1", postId,
+	).Scan(&configId, &repoId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return map[string]interface{}{"message": "project not found"}, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to query post: %v", err)
+	}
+
+	followLatest := revision == nil
+	selected := 0
+	if followLatest {
+		err = tidb.QueryRowContext(ctx, &span, &callerName,
+			"select max(revision) from workspace_config where _id = ?", configId,
+		).Scan(&selected)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query latest workspace config revision: %v", err)
+		}
+	} else {
+		selected = *revision
+	}
+
+	cfg, err := loadWorkspaceConfigRevision(ctx, tidb, configId, selected)
+	if err != nil {
+		if err == ErrNotFound {
+			return map[string]interface{}{"message": fmt.Sprintf("revision %d not found", selected)}, err
+		}
+		return nil, err
+	}
+
+	err = writeRepoWorkspaceConfig(vcsClient, repoId, cfg.Content, fmt.Sprintf("Use workspace config revision %d", selected))
+	if err != nil {
+		return map[string]interface{}{"message": "failed to update the workspace config in repo"}, err
+	}
+
+	_, err = tidb.ExecContext(ctx, &span, &callerName,
+		"update post set workspace_config_revision = ?, workspace_config_follow_latest = ? where _id = ?",
+		selected, followLatest, postId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update post workspace config revision: %v", err)
+	}
+
+	return map[string]interface{}{
+		"message":       "Workspace config revision updated.",
+		"revision":      selected,
+		"follow_latest": followLatest,
+	}, nil
+}
@@ -0,0 +1,187 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bwmarrin/snowflake"
+	config2 "github.com/gage-technologies/gigo-lib/config"
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/logging"
+	"github.com/gage-technologies/gigo-lib/mq"
+)
+
+// testWorkspaceConfigContent formats a valid workspace config for the passed base container
+func testWorkspaceConfigContent(baseContainer string) string {
+	return "version: 0.1\nresources:\n  cpu: 2\n  mem: 2\n  disk: 10\nbase_container: " + baseContainer +
+		"\nworking_directory: /home/gigo/codebase\n"
+}
+
+// testWorkspaceConfigRevisions inserts two revisions of a workspace config
+// authored by the passed user
+func testWorkspaceConfigRevisions(t *testing.T, testTiDB *ti.Database, configId int64, authorId int64) {
+	contents := []string{
+		testWorkspaceConfigContent("golang:1.20"),
+		testWorkspaceConfigContent("golang:1.21"),
+	}
+
+	for revision, content := range contents {
+		cfg := models.CreateWorkspaceConfig(configId, "Test Config", "Test Description", content, authorId, revision,
+			nil, []models.ProgrammingLanguage{models.Go})
+
+		statements, err := cfg.ToSQLNative()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, statement := range statements {
+			_, err = testTiDB.DB.Exec(statement.Statement, statement.Values...)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestWorkspaceConfigRevisions(t *testing.T) {
+	testTiDB, err := ti.CreateDatabase("gigo-dev-tidb", "4000", "mysql", "gigo-dev",
+		"gigo-dev",
+		"gigo_test_db")
+	if err != nil {
+		t.Fatal("Initialize test database failed:", err)
+	}
+
+	var configId int64 = 42069
+	testWorkspaceConfigRevisions(t, testTiDB, configId, 69)
+
+	defer func() {
+		_, _ = testTiDB.DB.Exec("delete from workspace_config where _id = ?", configId)
+		_, _ = testTiDB.DB.Exec("delete from workspace_config_langs where cfg_id = ?", configId)
+	}()
+
+	res, err := ListWorkspaceConfigRevisions(context.Background(), testTiDB, configId)
+	if err != nil {
+		t.Errorf("\nTestWorkspaceConfigRevisions failed\n    Error: %v\n", err)
+		return
+	}
+
+	revisions := res["revisions"].([]*WorkspaceConfigRevisionFrontend)
+	if len(revisions) != 2 || revisions[0].Revision != 1 || revisions[1].Revision != 0 {
+		t.Errorf("\nTestWorkspaceConfigRevisions failed\n    Error: unexpected revisions %+v\n", revisions)
+		return
+	}
+
+	res, err = DiffWorkspaceConfigRevisions(context.Background(), testTiDB, configId, 0, 1)
+	if err != nil {
+		t.Errorf("\nTestWorkspaceConfigRevisions failed\n    Error: %v\n", err)
+		return
+	}
+
+	if res["additions"] != 1 || res["deletions"] != 1 {
+		t.Errorf("\nTestWorkspaceConfigRevisions failed\n    Error: unexpected diff %v\n", res)
+	}
+
+	_, err = DiffWorkspaceConfigRevisions(context.Background(), testTiDB, configId, 0, 2)
+	if err != ErrNotFound {
+		t.Errorf("\nTestWorkspaceConfigRevisions failed\n    Error: expected missing revision, got %v\n", err)
+	}
+}
+
+func TestRollbackWorkspaceConfig(t *testing.T) {
+	testTiDB, err := ti.CreateDatabase("gigo-dev-tidb", "4000", "mysql", "gigo-dev",
+		"gigo-dev",
+		"gigo_test_db")
+	if err != nil {
+		t.Fatal("Initialize test database failed:", err)
+	}
+
+	logger, err := logging.CreateBasicLogger(logging.NewDefaultBasicLoggerOptions("/tmp/gigo-core-test.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	js, err := mq.NewJetstreamClient(config2.JetstreamConfig{
+		Host:        "mq://gigo-dev-nats:4222",
+		Username:    "gigo-dev",
+		Password:    "gigo-dev",
+		MaxPubQueue: 256,
+	}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = InitWorkspaceConfigStream(js)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testSnowflake, err := snowflake.NewNode(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ava models.AvatarSettings
+
+	author, err := models.CreateUser(69, "test", "", "", "", models.UserStatusBasic, "", nil, nil, "", "", 0, "None", models.UserStart{}, "America/Chicago", ava, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := models.CreateUser(70, "other", "", "", "", models.UserStatusBasic, "", nil, nil, "", "", 0, "None", models.UserStart{}, "America/Chicago", ava, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var configId int64 = 42070
+	testWorkspaceConfigRevisions(t, testTiDB, configId, author.ID)
+
+	defer func() {
+		_, _ = testTiDB.DB.Exec("delete from workspace_config where _id = ?", configId)
+		_, _ = testTiDB.DB.Exec("delete from workspace_config_langs where cfg_id = ?", configId)
+		_, _ = testTiDB.DB.Exec("delete from search_outbox where search_index = 'workspace_configs' and document_id = ?", configId)
+	}()
+
+	// only the author of the config can roll it back
+	_, err = RollbackWorkspaceConfig(context.Background(), testTiDB, nil, js, nil, testSnowflake, other, configId, 0)
+	if !errors.Is(err, ErrForbidden) {
+		t.Errorf("\nTestRollbackWorkspaceConfig failed\n    Error: expected forbidden, got %v\n", err)
+		return
+	}
+
+	_, err = RollbackWorkspaceConfig(context.Background(), testTiDB, nil, js, nil, testSnowflake, author, configId, 5)
+	if err != ErrNotFound {
+		t.Errorf("\nTestRollbackWorkspaceConfig failed\n    Error: expected missing revision, got %v\n", err)
+		return
+	}
+
+	// updates are linted like new configs before a revision is created
+	invalid := "version: 0.1\nbase_container: golang:1.20\n"
+	res, err := UpdateWorkspaceConfig(context.Background(), testTiDB, nil, js, nil, testSnowflake, author, configId, nil, &invalid, nil, nil, "")
+	if err != nil || res == nil || res["lint"] == nil {
+		t.Errorf("\nTestRollbackWorkspaceConfig failed\n    Error: expected lint failure, got %v %v\n", res, err)
+		return
+	}
+
+	_, err = RollbackWorkspaceConfig(context.Background(), testTiDB, nil, js, nil, testSnowflake, author, configId, 0)
+	if err != nil {
+		t.Errorf("\nTestRollbackWorkspaceConfig failed\n    Error: %v\n", err)
+		return
+	}
+
+	// the rollback creates a new revision with the content of the old one
+	var content string
+	var createdBy, rolledBackFrom int64
+	err = testTiDB.DB.QueryRow(
+		"select content, created_by, rolled_back_from from workspace_config where _id = ? and revision = 2", configId,
+	).Scan(&content, &createdBy, &rolledBackFrom)
+	if err != nil {
+		t.Errorf("\nTestRollbackWorkspaceConfig failed\n    Error: %v\n", err)
+		return
+	}
+
+	if content != testWorkspaceConfigContent("golang:1.20") || createdBy != author.ID || rolledBackFrom != 0 {
+		t.Errorf("\nTestRollbackWorkspaceConfig failed\n    Error: unexpected rollback revision %q %d %d\n", content, createdBy, rolledBackFrom)
+	}
+}
@@ -224,6 +224,14 @@ func AddXP(ctx context.Context, tidb *ti.Database, js *mq.JetstreamClient, rdb r
 	case "create_tutorial":
 		expGain = 250
 		break
+	// xp granted for completing every step of a learning path
+	case "learning_path":
+		expGain = 500
+		break
+	// xp granted when your comment is accepted as the answer to a discussion
+	case "accepted_answer":
+		expGain = 100
+		break
 	// xp granted when another user attempts your challenge
 	case "challenge_is_attempted":
 		expGain = 25
@@ -338,7 +346,14 @@ func AddXP(ctx context.Context, tidb *ti.Database, js *mq.JetstreamClient, rdb r
 		return nil, fmt.Errorf("failed to update xp_reasons for user: %v in AddXP Core: %v", userID, err)
 	}
 
-	return map[string]interface{}{"xp_update": update, "level_up_reward": levelUpReward}, nil
+	// evaluate the achievements triggered by this xp source now that the
+	// reason has been recorded - failures are logged so the xp grant is kept
+	achievements, err := EvaluateAchievements(ctx, tidb, js, sf, userID, source, logger)
+	if err != nil {
+		logger.Errorf("failed to evaluate achievements for user: %v in AddXP Core: %v", userID, err)
+	}
+
+	return map[string]interface{}{"xp_update": update, "level_up_reward": levelUpReward, "achievements": achievements}, nil
 }
 
 func GetXP(ctx context.Context, tidb *ti.Database, userID int64) (map[string]interface{}, error) {
@@ -0,0 +1,197 @@
+package external_api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"gigo-core/gigo/api/external_api/core"
+
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/network"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func (s *HTTPServer) AcceptDiscussionAnswer(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "accept-discussion-answer-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	// return if calling user was not retrieved in authentication
+	if callingUserI == nil {
+		s.handleError(w, "calling user missing from context", r.URL.Path, "AcceptDiscussionAnswer", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), network.GetRequestIP(r), network.GetRequestIP(r), http.StatusInternalServerError, "internal server error occurred", nil)
+		return
+	}
+
+	callingUser := callingUserI.(*models.User)
+	callingUsername := callingUser.UserName
+	callingId := fmt.Sprintf("%d", callingUser.ID)
+
+	// parse and validate request body
+	var req core.AcceptDiscussionAnswerRequest
+	if !s.validateRequest(w, r, callingUser, r.Body, &req) {
+		return
+	}
+
+	// check if this is a test
+	if req.Test {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "AcceptDiscussionAnswer", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+		return
+	}
+
+	// validation guarantees that the id is numeric
+	commentId, _ := strconv.ParseInt(req.CommentID, 10, 64)
+
+	// execute core function logic
+	res, err := core.AcceptDiscussionAnswer(ctx, s.tiDB, s.jetstreamClient, s.rdb, s.sf, callingUser, s.logger, commentId)
+	if err != nil {
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "AcceptDiscussionAnswer core failed", r.URL.Path, "AcceptDiscussionAnswer", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, coreErrorStatus(err), responseMessage, err)
+		// exit
+		return
+	}
+
+	// drop the cached trees that show the accepted answer
+	s.invalidateDiscussionTree(ctx, "comment", commentId)
+
+	parentSpan.AddEvent(
+		"accept-discussion-answer",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUsername),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "AcceptDiscussionAnswer", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+}
+
+func (s *HTTPServer) UnacceptDiscussionAnswer(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "unaccept-discussion-answer-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	// return if calling user was not retrieved in authentication
+	if callingUserI == nil {
+		s.handleError(w, "calling user missing from context", r.URL.Path, "UnacceptDiscussionAnswer", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), network.GetRequestIP(r), network.GetRequestIP(r), http.StatusInternalServerError, "internal server error occurred", nil)
+		return
+	}
+
+	callingUser := callingUserI.(*models.User)
+	callingUsername := callingUser.UserName
+	callingId := fmt.Sprintf("%d", callingUser.ID)
+
+	// parse and validate request body
+	var req core.UnacceptDiscussionAnswerRequest
+	if !s.validateRequest(w, r, callingUser, r.Body, &req) {
+		return
+	}
+
+	// check if this is a test
+	if req.Test {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "UnacceptDiscussionAnswer", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+		return
+	}
+
+	// validation guarantees that the id is numeric
+	discussionId, _ := strconv.ParseInt(req.DiscussionID, 10, 64)
+
+	// execute core function logic
+	res, err := core.UnacceptDiscussionAnswer(ctx, s.tiDB, callingUser, discussionId)
+	if err != nil {
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "UnacceptDiscussionAnswer core failed", r.URL.Path, "UnacceptDiscussionAnswer", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, coreErrorStatus(err), responseMessage, err)
+		// exit
+		return
+	}
+
+	// drop the cached trees that show the accepted answer
+	s.invalidateDiscussionTree(ctx, "discussion", discussionId)
+
+	parentSpan.AddEvent(
+		"unaccept-discussion-answer",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUsername),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "UnacceptDiscussionAnswer", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+}
+
+func (s *HTTPServer) GetUnansweredDiscussions(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "get-unanswered-discussions-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	// unanswered questions can be browsed without logging in
+	var callingUser *models.User
+	callingUsername := network.GetRequestIP(r)
+	callingId := network.GetRequestIP(r)
+	if callingUserI != nil {
+		callingUser = callingUserI.(*models.User)
+		callingUsername = callingUser.UserName
+		callingId = fmt.Sprintf("%d", callingUser.ID)
+	}
+
+	// parse and validate request body
+	var req core.UnansweredDiscussionsRequest
+	if !s.validateRequest(w, r, callingUser, r.Body, &req) {
+		return
+	}
+
+	// check if this is a test
+	if req.Test {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "GetUnansweredDiscussions", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+		return
+	}
+
+	// validation guarantees that the id is numeric
+	postId, _ := strconv.ParseInt(req.PostID, 10, 64)
+
+	// execute core function logic
+	res, err := core.GetUnansweredDiscussions(ctx, s.tiDB, postId, req.Skip, req.Limit)
+	if err != nil {
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "GetUnansweredDiscussions core failed", r.URL.Path, "GetUnansweredDiscussions", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, coreErrorStatus(err), responseMessage, err)
+		// exit
+		return
+	}
+
+	parentSpan.AddEvent(
+		"get-unanswered-discussions",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUsername),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "GetUnansweredDiscussions", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+}
@@ -0,0 +1,158 @@
+package external_api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestHTTPServer_AcceptDiscussionAnswer(t *testing.T) {
+	body := bytes.NewReader([]byte(`{"comment_id":"1688617436791701504","test":true}`))
+	req, err := http.NewRequest("POST", "http://localhost:1818/api/discussion/acceptAnswer", body)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_AcceptDiscussionAnswer failed\n    Error: %v", err)
+		return
+	}
+
+	req.AddCookie(&http.Cookie{
+		Name:  "gigoAuthToken",
+		Value: testUserAuth,
+	})
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_AcceptDiscussionAnswer failed\n    Error: %v", err)
+		return
+	}
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		fmt.Println(string(body))
+		fmt.Println(res.StatusCode)
+		t.Error("\nTestHTTPServer_AcceptDiscussionAnswer failed\n    Error: incorrect response code")
+		return
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Error("\nTestHTTPServer_AcceptDiscussionAnswer failed\n    Error: ", err)
+		return
+	}
+
+	var resJson map[string]interface{}
+	err = json.Unmarshal(resBody, &resJson)
+	if err != nil {
+		t.Error("\nTestHTTPServer_AcceptDiscussionAnswer failed\n    Error: ", err)
+		return
+	}
+
+	expectedJson := map[string]interface{}{}
+	if !reflect.DeepEqual(resJson, expectedJson) {
+		t.Error("\nTestHTTPServer_AcceptDiscussionAnswer failed\n    Error: response JSON does not match expected JSON")
+		return
+	}
+
+	t.Log("\nTestHTTPServer_AcceptDiscussionAnswer succeeded")
+}
+
+func TestHTTPServer_UnacceptDiscussionAnswer(t *testing.T) {
+	body := bytes.NewReader([]byte(`{"discussion_id":"1688617436791701504","test":true}`))
+	req, err := http.NewRequest("POST", "http://localhost:1818/api/discussion/unacceptAnswer", body)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_UnacceptDiscussionAnswer failed\n    Error: %v", err)
+		return
+	}
+
+	req.AddCookie(&http.Cookie{
+		Name:  "gigoAuthToken",
+		Value: testUserAuth,
+	})
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_UnacceptDiscussionAnswer failed\n    Error: %v", err)
+		return
+	}
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		fmt.Println(string(body))
+		fmt.Println(res.StatusCode)
+		t.Error("\nTestHTTPServer_UnacceptDiscussionAnswer failed\n    Error: incorrect response code")
+		return
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Error("\nTestHTTPServer_UnacceptDiscussionAnswer failed\n    Error: ", err)
+		return
+	}
+
+	var resJson map[string]interface{}
+	err = json.Unmarshal(resBody, &resJson)
+	if err != nil {
+		t.Error("\nTestHTTPServer_UnacceptDiscussionAnswer failed\n    Error: ", err)
+		return
+	}
+
+	expectedJson := map[string]interface{}{}
+	if !reflect.DeepEqual(resJson, expectedJson) {
+		t.Error("\nTestHTTPServer_UnacceptDiscussionAnswer failed\n    Error: response JSON does not match expected JSON")
+		return
+	}
+
+	t.Log("\nTestHTTPServer_UnacceptDiscussionAnswer succeeded")
+}
+
+func TestHTTPServer_GetUnansweredDiscussions(t *testing.T) {
+	body := bytes.NewReader([]byte(`{"post_id":"1688617436791701504","skip":0,"limit":10,"test":true}`))
+	req, err := http.NewRequest("POST", "http://localhost:1818/api/discussion/unanswered", body)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_GetUnansweredDiscussions failed\n    Error: %v", err)
+		return
+	}
+
+	req.AddCookie(&http.Cookie{
+		Name:  "gigoAuthToken",
+		Value: testUserAuth,
+	})
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_GetUnansweredDiscussions failed\n    Error: %v", err)
+		return
+	}
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		fmt.Println(string(body))
+		fmt.Println(res.StatusCode)
+		t.Error("\nTestHTTPServer_GetUnansweredDiscussions failed\n    Error: incorrect response code")
+		return
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Error("\nTestHTTPServer_GetUnansweredDiscussions failed\n    Error: ", err)
+		return
+	}
+
+	var resJson map[string]interface{}
+	err = json.Unmarshal(resBody, &resJson)
+	if err != nil {
+		t.Error("\nTestHTTPServer_GetUnansweredDiscussions failed\n    Error: ", err)
+		return
+	}
+
+	expectedJson := map[string]interface{}{}
+	if !reflect.DeepEqual(resJson, expectedJson) {
+		t.Error("\nTestHTTPServer_GetUnansweredDiscussions failed\n    Error: response JSON does not match expected JSON")
+		return
+	}
+
+	t.Log("\nTestHTTPServer_GetUnansweredDiscussions succeeded")
+}
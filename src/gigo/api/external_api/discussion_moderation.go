@@ -0,0 +1,325 @@
+package external_api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"gigo-core/gigo/api/external_api/core"
+
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/network"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func (s *HTTPServer) DeleteDiscussionContent(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "delete-discussion-content-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	// return if calling user was not retrieved in authentication
+	if callingUserI == nil {
+		s.handleError(w, "calling user missing from context", r.URL.Path, "DeleteDiscussionContent", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), network.GetRequestIP(r), network.GetRequestIP(r), http.StatusInternalServerError, "internal server error occurred", nil)
+		return
+	}
+
+	callingUser := callingUserI.(*models.User)
+	callingUsername := callingUser.UserName
+	callingId := fmt.Sprintf("%d", callingUser.ID)
+
+	// parse and validate request body
+	var req core.DeleteDiscussionContentRequest
+	if !s.validateRequest(w, r, callingUser, r.Body, &req) {
+		return
+	}
+
+	// check if this is a test
+	if req.Test {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "DeleteDiscussionContent", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+		return
+	}
+
+	// validation guarantees that the id is numeric
+	id, _ := strconv.ParseInt(req.ID, 10, 64)
+
+	// execute core function logic
+	res, err := core.DeleteDiscussionContent(ctx, s.tiDB, callingUser, req.DiscussionType, id)
+	if err != nil {
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "DeleteDiscussionContent core failed", r.URL.Path, "DeleteDiscussionContent", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, coreErrorStatus(err), responseMessage, err)
+		// exit
+		return
+	}
+
+	// drop the cached trees that show the deleted content
+	s.invalidateDiscussionTree(ctx, req.DiscussionType, id)
+
+	parentSpan.AddEvent(
+		"delete-discussion-content",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUsername),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "DeleteDiscussionContent", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+}
+
+func (s *HTTPServer) HideDiscussionContent(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "hide-discussion-content-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	// return if calling user was not retrieved in authentication
+	if callingUserI == nil {
+		s.handleError(w, "calling user missing from context", r.URL.Path, "HideDiscussionContent", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), network.GetRequestIP(r), network.GetRequestIP(r), http.StatusInternalServerError, "internal server error occurred", nil)
+		return
+	}
+
+	callingUser := callingUserI.(*models.User)
+	callingUsername := callingUser.UserName
+	callingId := fmt.Sprintf("%d", callingUser.ID)
+
+	// parse and validate request body
+	var req core.ModerateDiscussionContentRequest
+	if !s.validateRequest(w, r, callingUser, r.Body, &req) {
+		return
+	}
+
+	// check if this is a test
+	if req.Test {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "HideDiscussionContent", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+		return
+	}
+
+	// validation guarantees that the id is numeric
+	id, _ := strconv.ParseInt(req.ID, 10, 64)
+
+	// execute core function logic
+	res, err := core.HideDiscussionContent(ctx, s.tiDB, callingUser, req.DiscussionType, id)
+	if err != nil {
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "HideDiscussionContent core failed", r.URL.Path, "HideDiscussionContent", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, coreErrorStatus(err), responseMessage, err)
+		// exit
+		return
+	}
+
+	// drop the cached trees that show the hidden content
+	s.invalidateDiscussionTree(ctx, req.DiscussionType, id)
+
+	parentSpan.AddEvent(
+		"hide-discussion-content",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUsername),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "HideDiscussionContent", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+}
+
+func (s *HTTPServer) RestoreDiscussionContent(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "restore-discussion-content-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	// return if calling user was not retrieved in authentication
+	if callingUserI == nil {
+		s.handleError(w, "calling user missing from context", r.URL.Path, "RestoreDiscussionContent", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), network.GetRequestIP(r), network.GetRequestIP(r), http.StatusInternalServerError, "internal server error occurred", nil)
+		return
+	}
+
+	callingUser := callingUserI.(*models.User)
+	callingUsername := callingUser.UserName
+	callingId := fmt.Sprintf("%d", callingUser.ID)
+
+	// parse and validate request body
+	var req core.ModerateDiscussionContentRequest
+	if !s.validateRequest(w, r, callingUser, r.Body, &req) {
+		return
+	}
+
+	// check if this is a test
+	if req.Test {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "RestoreDiscussionContent", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+		return
+	}
+
+	// validation guarantees that the id is numeric
+	id, _ := strconv.ParseInt(req.ID, 10, 64)
+
+	// execute core function logic
+	res, err := core.RestoreDiscussionContent(ctx, s.tiDB, callingUser, req.DiscussionType, id)
+	if err != nil {
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "RestoreDiscussionContent core failed", r.URL.Path, "RestoreDiscussionContent", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, coreErrorStatus(err), responseMessage, err)
+		// exit
+		return
+	}
+
+	// drop the cached trees that show the restored content
+	s.invalidateDiscussionTree(ctx, req.DiscussionType, id)
+
+	parentSpan.AddEvent(
+		"restore-discussion-content",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUsername),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "RestoreDiscussionContent", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+}
+
+func (s *HTTPServer) LockDiscussion(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "lock-discussion-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	// return if calling user was not retrieved in authentication
+	if callingUserI == nil {
+		s.handleError(w, "calling user missing from context", r.URL.Path, "LockDiscussion", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), network.GetRequestIP(r), network.GetRequestIP(r), http.StatusInternalServerError, "internal server error occurred", nil)
+		return
+	}
+
+	callingUser := callingUserI.(*models.User)
+	callingUsername := callingUser.UserName
+	callingId := fmt.Sprintf("%d", callingUser.ID)
+
+	// parse and validate request body
+	var req core.LockDiscussionRequest
+	if !s.validateRequest(w, r, callingUser, r.Body, &req) {
+		return
+	}
+
+	// check if this is a test
+	if req.Test {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "LockDiscussion", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+		return
+	}
+
+	// validation guarantees that the id is numeric
+	discussionId, _ := strconv.ParseInt(req.DiscussionID, 10, 64)
+
+	// execute core function logic
+	res, err := core.LockDiscussion(ctx, s.tiDB, callingUser, discussionId)
+	if err != nil {
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "LockDiscussion core failed", r.URL.Path, "LockDiscussion", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, coreErrorStatus(err), responseMessage, err)
+		// exit
+		return
+	}
+
+	// drop the cached trees that show the lock state
+	s.invalidateDiscussionTree(ctx, "discussion", discussionId)
+
+	parentSpan.AddEvent(
+		"lock-discussion",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUsername),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "LockDiscussion", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+}
+
+func (s *HTTPServer) UnlockDiscussion(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "unlock-discussion-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	// return if calling user was not retrieved in authentication
+	if callingUserI == nil {
+		s.handleError(w, "calling user missing from context", r.URL.Path, "UnlockDiscussion", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), network.GetRequestIP(r), network.GetRequestIP(r), http.StatusInternalServerError, "internal server error occurred", nil)
+		return
+	}
+
+	callingUser := callingUserI.(*models.User)
+	callingUsername := callingUser.UserName
+	callingId := fmt.Sprintf("%d", callingUser.ID)
+
+	// parse and validate request body
+	var req core.LockDiscussionRequest
+	if !s.validateRequest(w, r, callingUser, r.Body, &req) {
+		return
+	}
+
+	// check if this is a test
+	if req.Test {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "UnlockDiscussion", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+		return
+	}
+
+	// validation guarantees that the id is numeric
+	discussionId, _ := strconv.ParseInt(req.DiscussionID, 10, 64)
+
+	// execute core function logic
+	res, err := core.UnlockDiscussion(ctx, s.tiDB, callingUser, discussionId)
+	if err != nil {
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "UnlockDiscussion core failed", r.URL.Path, "UnlockDiscussion", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, coreErrorStatus(err), responseMessage, err)
+		// exit
+		return
+	}
+
+	// drop the cached trees that show the lock state
+	s.invalidateDiscussionTree(ctx, "discussion", discussionId)
+
+	parentSpan.AddEvent(
+		"unlock-discussion",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUsername),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "UnlockDiscussion", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+}
@@ -0,0 +1,256 @@
+package external_api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestHTTPServer_DeleteDiscussionContent(t *testing.T) {
+	body := bytes.NewReader([]byte(`{"discussion_type":"comment","id":"1688617436791701504","test":true}`))
+	req, err := http.NewRequest("POST", "http://localhost:1818/api/discussion/delete", body)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_DeleteDiscussionContent failed\n    Error: %v", err)
+		return
+	}
+
+	req.AddCookie(&http.Cookie{
+		Name:  "gigoAuthToken",
+		Value: testUserAuth,
+	})
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_DeleteDiscussionContent failed\n    Error: %v", err)
+		return
+	}
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		fmt.Println(string(body))
+		fmt.Println(res.StatusCode)
+		t.Error("\nTestHTTPServer_DeleteDiscussionContent failed\n    Error: incorrect response code")
+		return
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Error("\nTestHTTPServer_DeleteDiscussionContent failed\n    Error: ", err)
+		return
+	}
+
+	var resJson map[string]interface{}
+	err = json.Unmarshal(resBody, &resJson)
+	if err != nil {
+		t.Error("\nTestHTTPServer_DeleteDiscussionContent failed\n    Error: ", err)
+		return
+	}
+
+	expectedJson := map[string]interface{}{}
+	if !reflect.DeepEqual(resJson, expectedJson) {
+		t.Error("\nTestHTTPServer_DeleteDiscussionContent failed\n    Error: response JSON does not match expected JSON")
+		return
+	}
+
+	t.Log("\nTestHTTPServer_DeleteDiscussionContent succeeded")
+}
+
+func TestHTTPServer_HideDiscussionContent(t *testing.T) {
+	body := bytes.NewReader([]byte(`{"discussion_type":"thread_reply","id":"1688617436791701504","test":true}`))
+	req, err := http.NewRequest("POST", "http://localhost:1818/api/discussion/hide", body)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_HideDiscussionContent failed\n    Error: %v", err)
+		return
+	}
+
+	req.AddCookie(&http.Cookie{
+		Name:  "gigoAuthToken",
+		Value: testUserAuth,
+	})
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_HideDiscussionContent failed\n    Error: %v", err)
+		return
+	}
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		fmt.Println(string(body))
+		fmt.Println(res.StatusCode)
+		t.Error("\nTestHTTPServer_HideDiscussionContent failed\n    Error: incorrect response code")
+		return
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Error("\nTestHTTPServer_HideDiscussionContent failed\n    Error: ", err)
+		return
+	}
+
+	var resJson map[string]interface{}
+	err = json.Unmarshal(resBody, &resJson)
+	if err != nil {
+		t.Error("\nTestHTTPServer_HideDiscussionContent failed\n    Error: ", err)
+		return
+	}
+
+	expectedJson := map[string]interface{}{}
+	if !reflect.DeepEqual(resJson, expectedJson) {
+		t.Error("\nTestHTTPServer_HideDiscussionContent failed\n    Error: response JSON does not match expected JSON")
+		return
+	}
+
+	t.Log("\nTestHTTPServer_HideDiscussionContent succeeded")
+}
+
+func TestHTTPServer_RestoreDiscussionContent(t *testing.T) {
+	body := bytes.NewReader([]byte(`{"discussion_type":"thread_reply","id":"1688617436791701504","test":true}`))
+	req, err := http.NewRequest("POST", "http://localhost:1818/api/discussion/restore", body)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_RestoreDiscussionContent failed\n    Error: %v", err)
+		return
+	}
+
+	req.AddCookie(&http.Cookie{
+		Name:  "gigoAuthToken",
+		Value: testUserAuth,
+	})
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_RestoreDiscussionContent failed\n    Error: %v", err)
+		return
+	}
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		fmt.Println(string(body))
+		fmt.Println(res.StatusCode)
+		t.Error("\nTestHTTPServer_RestoreDiscussionContent failed\n    Error: incorrect response code")
+		return
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Error("\nTestHTTPServer_RestoreDiscussionContent failed\n    Error: ", err)
+		return
+	}
+
+	var resJson map[string]interface{}
+	err = json.Unmarshal(resBody, &resJson)
+	if err != nil {
+		t.Error("\nTestHTTPServer_RestoreDiscussionContent failed\n    Error: ", err)
+		return
+	}
+
+	expectedJson := map[string]interface{}{}
+	if !reflect.DeepEqual(resJson, expectedJson) {
+		t.Error("\nTestHTTPServer_RestoreDiscussionContent failed\n    Error: response JSON does not match expected JSON")
+		return
+	}
+
+	t.Log("\nTestHTTPServer_RestoreDiscussionContent succeeded")
+}
+
+func TestHTTPServer_LockDiscussion(t *testing.T) {
+	body := bytes.NewReader([]byte(`{"discussion_id":"1688617436791701504","test":true}`))
+	req, err := http.NewRequest("POST", "http://localhost:1818/api/discussion/lock", body)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_LockDiscussion failed\n    Error: %v", err)
+		return
+	}
+
+	req.AddCookie(&http.Cookie{
+		Name:  "gigoAuthToken",
+		Value: testUserAuth,
+	})
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_LockDiscussion failed\n    Error: %v", err)
+		return
+	}
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		fmt.Println(string(body))
+		fmt.Println(res.StatusCode)
+		t.Error("\nTestHTTPServer_LockDiscussion failed\n    Error: incorrect response code")
+		return
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Error("\nTestHTTPServer_LockDiscussion failed\n    Error: ", err)
+		return
+	}
+
+	var resJson map[string]interface{}
+	err = json.Unmarshal(resBody, &resJson)
+	if err != nil {
+		t.Error("\nTestHTTPServer_LockDiscussion failed\n    Error: ", err)
+		return
+	}
+
+	expectedJson := map[string]interface{}{}
+	if !reflect.DeepEqual(resJson, expectedJson) {
+		t.Error("\nTestHTTPServer_LockDiscussion failed\n    Error: response JSON does not match expected JSON")
+		return
+	}
+
+	t.Log("\nTestHTTPServer_LockDiscussion succeeded")
+}
+
+func TestHTTPServer_UnlockDiscussion(t *testing.T) {
+	body := bytes.NewReader([]byte(`{"discussion_id":"1688617436791701504","test":true}`))
+	req, err := http.NewRequest("POST", "http://localhost:1818/api/discussion/unlock", body)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_UnlockDiscussion failed\n    Error: %v", err)
+		return
+	}
+
+	req.AddCookie(&http.Cookie{
+		Name:  "gigoAuthToken",
+		Value: testUserAuth,
+	})
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_UnlockDiscussion failed\n    Error: %v", err)
+		return
+	}
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		fmt.Println(string(body))
+		fmt.Println(res.StatusCode)
+		t.Error("\nTestHTTPServer_UnlockDiscussion failed\n    Error: incorrect response code")
+		return
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Error("\nTestHTTPServer_UnlockDiscussion failed\n    Error: ", err)
+		return
+	}
+
+	var resJson map[string]interface{}
+	err = json.Unmarshal(resBody, &resJson)
+	if err != nil {
+		t.Error("\nTestHTTPServer_UnlockDiscussion failed\n    Error: ", err)
+		return
+	}
+
+	expectedJson := map[string]interface{}{}
+	if !reflect.DeepEqual(resJson, expectedJson) {
+		t.Error("\nTestHTTPServer_UnlockDiscussion failed\n    Error: response JSON does not match expected JSON")
+		return
+	}
+
+	t.Log("\nTestHTTPServer_UnlockDiscussion succeeded")
+}
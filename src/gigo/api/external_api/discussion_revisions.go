@@ -0,0 +1,190 @@
+package external_api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"gigo-core/gigo/api/external_api/core"
+
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/network"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func (s *HTTPServer) GetDiscussionRevisions(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "get-discussion-revisions-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	// edit history can be browsed without logging in
+	var callingUser *models.User
+	callingUsername := network.GetRequestIP(r)
+	callingId := network.GetRequestIP(r)
+	if callingUserI != nil {
+		callingUser = callingUserI.(*models.User)
+		callingUsername = callingUser.UserName
+		callingId = fmt.Sprintf("%d", callingUser.ID)
+	}
+
+	// parse and validate request body
+	var req core.DiscussionRevisionsRequest
+	if !s.validateRequest(w, r, callingUser, r.Body, &req) {
+		return
+	}
+
+	// check if this is a test
+	if req.Test {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "GetDiscussionRevisions", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+		return
+	}
+
+	// validation guarantees that the id is numeric
+	id, _ := strconv.ParseInt(req.ID, 10, 64)
+
+	// execute core function logic
+	res, err := core.GetDiscussionRevisions(ctx, s.tiDB, callingUser, req.DiscussionType, id)
+	if err != nil {
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "GetDiscussionRevisions core failed", r.URL.Path, "GetDiscussionRevisions", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, coreErrorStatus(err), responseMessage, err)
+		// exit
+		return
+	}
+
+	parentSpan.AddEvent(
+		"get-discussion-revisions",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUsername),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "GetDiscussionRevisions", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+}
+
+func (s *HTTPServer) DiffDiscussionRevisions(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "diff-discussion-revisions-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	// edit history can be browsed without logging in
+	var callingUser *models.User
+	callingUsername := network.GetRequestIP(r)
+	callingId := network.GetRequestIP(r)
+	if callingUserI != nil {
+		callingUser = callingUserI.(*models.User)
+		callingUsername = callingUser.UserName
+		callingId = fmt.Sprintf("%d", callingUser.ID)
+	}
+
+	// parse and validate request body
+	var req core.DiffDiscussionRevisionsRequest
+	if !s.validateRequest(w, r, callingUser, r.Body, &req) {
+		return
+	}
+
+	// check if this is a test
+	if req.Test {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "DiffDiscussionRevisions", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+		return
+	}
+
+	// validation guarantees that the id is numeric
+	id, _ := strconv.ParseInt(req.ID, 10, 64)
+
+	// execute core function logic
+	res, err := core.DiffDiscussionRevisions(ctx, s.tiDB, callingUser, req.DiscussionType, id, req.FromRevision, req.ToRevision)
+	if err != nil {
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "DiffDiscussionRevisions core failed", r.URL.Path, "DiffDiscussionRevisions", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, coreErrorStatus(err), responseMessage, err)
+		// exit
+		return
+	}
+
+	parentSpan.AddEvent(
+		"diff-discussion-revisions",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUsername),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "DiffDiscussionRevisions", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+}
+
+func (s *HTTPServer) DeleteDiscussionRevision(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "delete-discussion-revision-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	// return if calling user was not retrieved in authentication
+	if callingUserI == nil {
+		s.handleError(w, "calling user missing from context", r.URL.Path, "DeleteDiscussionRevision", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), network.GetRequestIP(r), network.GetRequestIP(r), http.StatusInternalServerError, "internal server error occurred", nil)
+		return
+	}
+
+	callingUser := callingUserI.(*models.User)
+	callingUsername := callingUser.UserName
+	callingId := fmt.Sprintf("%d", callingUser.ID)
+
+	// parse and validate request body
+	var req core.DeleteDiscussionRevisionRequest
+	if !s.validateRequest(w, r, callingUser, r.Body, &req) {
+		return
+	}
+
+	// check if this is a test
+	if req.Test {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "DeleteDiscussionRevision", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+		return
+	}
+
+	// validation guarantees that the id is numeric
+	id, _ := strconv.ParseInt(req.ID, 10, 64)
+
+	// execute core function logic
+	res, err := core.DeleteDiscussionRevision(ctx, s.tiDB, callingUser, req.DiscussionType, id, req.Revision)
+	if err != nil {
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "DeleteDiscussionRevision core failed", r.URL.Path, "DeleteDiscussionRevision", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, coreErrorStatus(err), responseMessage, err)
+		// exit
+		return
+	}
+
+	parentSpan.AddEvent(
+		"delete-discussion-revision",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUsername),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "DeleteDiscussionRevision", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+}
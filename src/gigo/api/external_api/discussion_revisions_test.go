@@ -0,0 +1,158 @@
+package external_api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestHTTPServer_GetDiscussionRevisions(t *testing.T) {
+	body := bytes.NewReader([]byte(`{"discussion_type":"comment","id":"1688617436791701504","test":true}`))
+	req, err := http.NewRequest("POST", "http://localhost:1818/api/discussion/revisions", body)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_GetDiscussionRevisions failed\n    Error: %v", err)
+		return
+	}
+
+	req.AddCookie(&http.Cookie{
+		Name:  "gigoAuthToken",
+		Value: testUserAuth,
+	})
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_GetDiscussionRevisions failed\n    Error: %v", err)
+		return
+	}
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		fmt.Println(string(body))
+		fmt.Println(res.StatusCode)
+		t.Error("\nTestHTTPServer_GetDiscussionRevisions failed\n    Error: incorrect response code")
+		return
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Error("\nTestHTTPServer_GetDiscussionRevisions failed\n    Error: ", err)
+		return
+	}
+
+	var resJson map[string]interface{}
+	err = json.Unmarshal(resBody, &resJson)
+	if err != nil {
+		t.Error("\nTestHTTPServer_GetDiscussionRevisions failed\n    Error: ", err)
+		return
+	}
+
+	expectedJson := map[string]interface{}{}
+	if !reflect.DeepEqual(resJson, expectedJson) {
+		t.Error("\nTestHTTPServer_GetDiscussionRevisions failed\n    Error: response JSON does not match expected JSON")
+		return
+	}
+
+	t.Log("\nTestHTTPServer_GetDiscussionRevisions succeeded")
+}
+
+func TestHTTPServer_DiffDiscussionRevisions(t *testing.T) {
+	body := bytes.NewReader([]byte(`{"discussion_type":"comment","id":"1688617436791701504","from_revision":0,"to_revision":1,"test":true}`))
+	req, err := http.NewRequest("POST", "http://localhost:1818/api/discussion/revisionDiff", body)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_DiffDiscussionRevisions failed\n    Error: %v", err)
+		return
+	}
+
+	req.AddCookie(&http.Cookie{
+		Name:  "gigoAuthToken",
+		Value: testUserAuth,
+	})
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_DiffDiscussionRevisions failed\n    Error: %v", err)
+		return
+	}
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		fmt.Println(string(body))
+		fmt.Println(res.StatusCode)
+		t.Error("\nTestHTTPServer_DiffDiscussionRevisions failed\n    Error: incorrect response code")
+		return
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Error("\nTestHTTPServer_DiffDiscussionRevisions failed\n    Error: ", err)
+		return
+	}
+
+	var resJson map[string]interface{}
+	err = json.Unmarshal(resBody, &resJson)
+	if err != nil {
+		t.Error("\nTestHTTPServer_DiffDiscussionRevisions failed\n    Error: ", err)
+		return
+	}
+
+	expectedJson := map[string]interface{}{}
+	if !reflect.DeepEqual(resJson, expectedJson) {
+		t.Error("\nTestHTTPServer_DiffDiscussionRevisions failed\n    Error: response JSON does not match expected JSON")
+		return
+	}
+
+	t.Log("\nTestHTTPServer_DiffDiscussionRevisions succeeded")
+}
+
+func TestHTTPServer_DeleteDiscussionRevision(t *testing.T) {
+	body := bytes.NewReader([]byte(`{"discussion_type":"comment","id":"1688617436791701504","revision":0,"test":true}`))
+	req, err := http.NewRequest("POST", "http://localhost:1818/api/discussion/deleteRevision", body)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_DeleteDiscussionRevision failed\n    Error: %v", err)
+		return
+	}
+
+	req.AddCookie(&http.Cookie{
+		Name:  "gigoAuthToken",
+		Value: testUserAuth,
+	})
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_DeleteDiscussionRevision failed\n    Error: %v", err)
+		return
+	}
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		fmt.Println(string(body))
+		fmt.Println(res.StatusCode)
+		t.Error("\nTestHTTPServer_DeleteDiscussionRevision failed\n    Error: incorrect response code")
+		return
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Error("\nTestHTTPServer_DeleteDiscussionRevision failed\n    Error: ", err)
+		return
+	}
+
+	var resJson map[string]interface{}
+	err = json.Unmarshal(resBody, &resJson)
+	if err != nil {
+		t.Error("\nTestHTTPServer_DeleteDiscussionRevision failed\n    Error: ", err)
+		return
+	}
+
+	expectedJson := map[string]interface{}{}
+	if !reflect.DeepEqual(resJson, expectedJson) {
+		t.Error("\nTestHTTPServer_DeleteDiscussionRevision failed\n    Error: response JSON does not match expected JSON")
+		return
+	}
+
+	t.Log("\nTestHTTPServer_DeleteDiscussionRevision succeeded")
+}
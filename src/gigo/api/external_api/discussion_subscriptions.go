@@ -0,0 +1,192 @@
+package external_api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"gigo-core/gigo/api/external_api/core"
+
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/network"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func (s *HTTPServer) WatchDiscussion(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "watch-discussion-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	// return if calling user was not retrieved in authentication
+	if callingUserI == nil {
+		s.handleError(w, "calling user missing from context", r.URL.Path, "WatchDiscussion", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), network.GetRequestIP(r), network.GetRequestIP(r), http.StatusInternalServerError, "internal server error occurred", nil)
+		return
+	}
+
+	callingUser := callingUserI.(*models.User)
+	callingUsername := callingUser.UserName
+	callingId := fmt.Sprintf("%d", callingUser.ID)
+
+	// parse and validate request body
+	var req core.WatchDiscussionRequest
+	if !s.validateRequest(w, r, callingUser, r.Body, &req) {
+		return
+	}
+
+	// check if this is a test
+	if req.Test {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "WatchDiscussion", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+		return
+	}
+
+	// validation guarantees that the id is numeric
+	discussionId, _ := strconv.ParseInt(req.DiscussionID, 10, 64)
+
+	// execute core function logic
+	res, err := core.WatchDiscussion(ctx, s.tiDB, callingUser, discussionId)
+	if err != nil {
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "WatchDiscussion core failed", r.URL.Path, "WatchDiscussion", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, coreErrorStatus(err), responseMessage, err)
+		// exit
+		return
+	}
+
+	parentSpan.AddEvent(
+		"watch-discussion",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUsername),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "WatchDiscussion", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+}
+
+func (s *HTTPServer) UnwatchDiscussion(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "unwatch-discussion-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	// return if calling user was not retrieved in authentication
+	if callingUserI == nil {
+		s.handleError(w, "calling user missing from context", r.URL.Path, "UnwatchDiscussion", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), network.GetRequestIP(r), network.GetRequestIP(r), http.StatusInternalServerError, "internal server error occurred", nil)
+		return
+	}
+
+	callingUser := callingUserI.(*models.User)
+	callingUsername := callingUser.UserName
+	callingId := fmt.Sprintf("%d", callingUser.ID)
+
+	// parse and validate request body
+	var req core.UnwatchDiscussionRequest
+	if !s.validateRequest(w, r, callingUser, r.Body, &req) {
+		return
+	}
+
+	// check if this is a test
+	if req.Test {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "UnwatchDiscussion", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+		return
+	}
+
+	// validation guarantees that the id is numeric
+	discussionId, _ := strconv.ParseInt(req.DiscussionID, 10, 64)
+
+	// execute core function logic
+	res, err := core.UnwatchDiscussion(ctx, s.tiDB, callingUser, discussionId)
+	if err != nil {
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "UnwatchDiscussion core failed", r.URL.Path, "UnwatchDiscussion", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, coreErrorStatus(err), responseMessage, err)
+		// exit
+		return
+	}
+
+	parentSpan.AddEvent(
+		"unwatch-discussion",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUsername),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "UnwatchDiscussion", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+}
+
+func (s *HTTPServer) GetDiscussionWatchStatus(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "get-discussion-watch-status-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	// return if calling user was not retrieved in authentication
+	if callingUserI == nil {
+		s.handleError(w, "calling user missing from context", r.URL.Path, "GetDiscussionWatchStatus", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), network.GetRequestIP(r), network.GetRequestIP(r), http.StatusInternalServerError, "internal server error occurred", nil)
+		return
+	}
+
+	callingUser := callingUserI.(*models.User)
+	callingUsername := callingUser.UserName
+	callingId := fmt.Sprintf("%d", callingUser.ID)
+
+	// parse and validate request body
+	var req core.DiscussionWatchStatusRequest
+	if !s.validateRequest(w, r, callingUser, r.Body, &req) {
+		return
+	}
+
+	// check if this is a test
+	if req.Test {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "GetDiscussionWatchStatus", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+		return
+	}
+
+	// validation guarantees that the id is numeric
+	discussionId, _ := strconv.ParseInt(req.DiscussionID, 10, 64)
+
+	// execute core function logic
+	res, err := core.GetDiscussionWatchStatus(ctx, s.tiDB, callingUser, discussionId)
+	if err != nil {
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "GetDiscussionWatchStatus core failed", r.URL.Path, "GetDiscussionWatchStatus", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, coreErrorStatus(err), responseMessage, err)
+		// exit
+		return
+	}
+
+	parentSpan.AddEvent(
+		"get-discussion-watch-status",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUsername),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "GetDiscussionWatchStatus", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+}
@@ -0,0 +1,90 @@
+package external_api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"gigo-core/gigo/api/external_api/core"
+
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/network"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func (s *HTTPServer) GetDiscussionTree(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "get-discussion-tree-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	// the discussion tree is public so the calling user is optional
+	var callingUser *models.User
+	callingUsername := network.GetRequestIP(r)
+	callingId := network.GetRequestIP(r)
+	if callingUserI != nil {
+		callingUser = callingUserI.(*models.User)
+		callingUsername = callingUser.UserName
+		callingId = fmt.Sprintf("%d", callingUser.ID)
+	}
+
+	// parse and validate request body
+	var req core.DiscussionTreeRequest
+	if !s.validateRequest(w, r, callingUser, r.Body, &req) {
+		return
+	}
+
+	// check if this is a test
+	if req.Test {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "GetDiscussionTree", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+		return
+	}
+
+	// validation guarantees that the id is numeric
+	discussionId, _ := strconv.ParseInt(req.DiscussionID, 10, 64)
+
+	// execute core function logic
+	res, err := core.GetDiscussionTree(ctx, s.tiDB, callingUser, discussionId, req.Cursor, req.Sort, req.CommentLimit, req.ThreadLimit, req.ReplyLimit)
+	if err != nil {
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "GetDiscussionTree core failed", r.URL.Path, "GetDiscussionTree", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, coreErrorStatus(err), responseMessage, err)
+		// exit
+		return
+	}
+
+	parentSpan.AddEvent(
+		"get-discussion-tree",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUsername),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "GetDiscussionTree", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+}
+
+// invalidateDiscussionTree drops the cached trees of the discussion that a
+// discussion, comment, thread comment or thread reply belongs to. Failures
+// are logged since the write has already succeeded and the cache expires.
+func (s *HTTPServer) invalidateDiscussionTree(ctx context.Context, discussionType string, id int64) {
+	discussionId, err := core.DiscussionRootID(ctx, s.tiDB, discussionType, id)
+	if err != nil {
+		s.logger.Errorf("failed to resolve discussion of %s %d for cache invalidation: %v", discussionType, id, err)
+		return
+	}
+
+	err = s.invalidateCacheGroup(ctx, "/api/discussion/tree", strconv.FormatInt(discussionId, 10))
+	if err != nil {
+		s.logger.Errorf("failed to invalidate discussion tree %d: %v", discussionId, err)
+	}
+}
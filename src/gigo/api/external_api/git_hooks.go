@@ -6,7 +6,6 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
-	"fmt"
 	"gigo-core/gigo/api/external_api/core"
 	"io"
 	"net/http"
@@ -18,8 +17,6 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
-// TODO: needs testing
-
 func (s *HTTPServer) GiteaWebhookPush(w http.ResponseWriter, r *http.Request) {
 	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "gitea-webhook-push-http")
 	defer parentSpan.End()
@@ -36,6 +33,24 @@ func (s *HTTPServer) GiteaWebhookPush(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// calculate signature
+	sig256 := hmac.New(sha256.New, []byte(s.gitWebhookSecret))
+	_, err = sig256.Write(sigRequestBody)
+	if err != nil {
+		s.handleError(w, "failed to write payload to signature hashed", r.URL.Path,
+			"GiteaWebhookPush", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), "git", deliveryId, http.StatusInternalServerError, "internal server error occurred", nil)
+		return
+	}
+
+	// validate git webhook signature with local signature before the payload is trusted
+	signature, err := hex.DecodeString(r.Header.Get("X-Gitea-Signature"))
+	if err != nil || !hmac.Equal(signature, sig256.Sum(nil)) {
+		s.handleError(w, "signature check failed", r.URL.Path, "GiteaWebhookPush", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), "git", deliveryId, http.StatusUnauthorized, "signature check failed", nil)
+		return
+	}
+
 	// create a new read closed and assign the body to it to preserve the default logic of the jsonRequest function
 	r.Body = io.NopCloser(bytes.NewBuffer(sigRequestBody))
 
@@ -60,25 +75,6 @@ func (s *HTTPServer) GiteaWebhookPush(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// calculate signature
-	sig256 := hmac.New(sha256.New, []byte(s.gitWebhookSecret))
-	_, err = sig256.Write(sigRequestBody)
-	if err != nil {
-		s.handleError(w, "failed to write payload to signature hashed", r.URL.Path,
-			"GiteaWebhookPush", r.Method, r.Context().Value(CtxKeyRequestID),
-			network.GetRequestIP(r), "git", deliveryId, http.StatusInternalServerError, "internal server error occurred", nil)
-		return
-	}
-	sig := hex.EncodeToString(sig256.Sum(nil))
-
-	// validate git webhook signature with local signature
-	secret := r.Header.Get("X-Gitea-Signature")
-	if secret != sig {
-		s.handleError(w, fmt.Sprintf("signature check failed %s != %s", secret, sig), r.URL.Path, "GiteaWebhookPush", r.Method, r.Context().Value(CtxKeyRequestID),
-			network.GetRequestIP(r), "git", "", http.StatusUnauthorized, "signature check failed", nil)
-		return
-	}
-
 	// check if this is a test
 	if val, ok := reqJson["test"]; ok && (val == true || val == "true") {
 		// return success for test
@@ -87,12 +83,12 @@ func (s *HTTPServer) GiteaWebhookPush(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// execute core function logic
-	err = core.GiteaWebhookPush(ctx, s.tiDB, s.vscClient, s.sf, &push)
+	res, err := core.GiteaWebhookPush(ctx, s.tiDB, s.vscClient, s.giteaConfig, s.rdb, &push)
 	if err != nil {
 		// select error message dependent on if there was one returned from the function
-		responseMessage := selectErrorResponse("internal server error occurred", nil)
+		responseMessage := selectErrorResponse("internal server error occurred", res)
 		// handle error internally
-		s.handleError(w, "GiteaWebhookPush core failed", r.URL.Path, "WorkspaceAFK", r.Method, r.Context().Value(CtxKeyRequestID),
+		s.handleError(w, "GiteaWebhookPush core failed", r.URL.Path, "GiteaWebhookPush", r.Method, r.Context().Value(CtxKeyRequestID),
 			network.GetRequestIP(r), "git", deliveryId, http.StatusInternalServerError, responseMessage, err)
 		// exit
 		return
@@ -106,6 +102,5 @@ func (s *HTTPServer) GiteaWebhookPush(w http.ResponseWriter, r *http.Request) {
 		),
 	)
 
-	s.jsonResponse(r, w, map[string]interface{}{"message": "success"}, r.URL.Path, "GiteaWebhookPush", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), "git", deliveryId, http.StatusOK)
-
+	s.jsonResponse(r, w, res, r.URL.Path, "GiteaWebhookPush", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), "git", deliveryId, http.StatusOK)
 }
@@ -0,0 +1,62 @@
+package external_api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestHTTPServer_GiteaWebhookPush(t *testing.T) {
+	payload := []byte(`{"ref":"refs/heads/main","test":true}`)
+
+	// an unsigned push must be rejected
+	req, err := http.NewRequest("POST", "http://localhost:1818/internal/git/push-hook", bytes.NewReader(payload))
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_GiteaWebhookPush failed\n    Error: %v", err)
+		return
+	}
+	req.Header.Set("X-Gitea-Signature", "00")
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_GiteaWebhookPush failed\n    Error: %v", err)
+		return
+	}
+
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("\nTestHTTPServer_GiteaWebhookPush failed\n    Error: expected unauthorized for bad signature, got %d", res.StatusCode)
+		return
+	}
+
+	// sign the payload with the webhook secret of the test server
+	sig := hmac.New(sha256.New, []byte(""))
+	sig.Write(payload)
+
+	req, err = http.NewRequest("POST", "http://localhost:1818/internal/git/push-hook", bytes.NewReader(payload))
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_GiteaWebhookPush failed\n    Error: %v", err)
+		return
+	}
+	req.Header.Set("X-Gitea-Signature", hex.EncodeToString(sig.Sum(nil)))
+
+	res, err = client.Do(req)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_GiteaWebhookPush failed\n    Error: %v", err)
+		return
+	}
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		fmt.Println(string(body))
+		fmt.Println(res.StatusCode)
+		t.Error("\nTestHTTPServer_GiteaWebhookPush failed\n    Error: incorrect response code")
+		return
+	}
+
+	t.Log("\nTestHTTPServer_GiteaWebhookPush succeeded")
+}
@@ -0,0 +1,373 @@
+package external_api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"gigo-core/gigo/api/external_api/core"
+
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/network"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func (s *HTTPServer) CreateContentReport(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "create-content-report-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	// return if calling user was not retrieved in authentication
+	if callingUserI == nil {
+		s.handleError(w, "calling user missing from context", r.URL.Path, "CreateContentReport", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), network.GetRequestIP(r), network.GetRequestIP(r), http.StatusInternalServerError, "internal server error occurred", nil)
+		return
+	}
+
+	callingUser := callingUserI.(*models.User)
+	callingId := fmt.Sprintf("%d", callingUser.ID)
+
+	// parse and validate request body
+	var req core.CreateContentReportRequest
+	if !s.validateRequest(w, r, callingUser, r.Body, &req) {
+		return
+	}
+
+	// check if this is a test
+	if req.Test {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "CreateContentReport", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusOK)
+		return
+	}
+
+	// validation guarantees that the id is numeric
+	contentId, _ := strconv.ParseInt(req.ContentID, 10, 64)
+
+	// execute core function logic
+	res, err := core.CreateContentReport(ctx, s.tiDB, s.sf, callingUser, req.ContentType, contentId, req.Reason, req.Details)
+	if err != nil {
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "CreateContentReport core failed", r.URL.Path, "CreateContentReport", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUser.UserName, callingId, coreErrorStatus(err), responseMessage, err)
+		// exit
+		return
+	}
+
+	parentSpan.AddEvent(
+		"create-content-report",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUser.UserName),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "CreateContentReport", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusOK)
+}
+
+func (s *HTTPServer) GetModerationQueue(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "get-moderation-queue-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	// return if calling user was not retrieved in authentication
+	if callingUserI == nil {
+		s.handleError(w, "calling user missing from context", r.URL.Path, "GetModerationQueue", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), network.GetRequestIP(r), network.GetRequestIP(r), http.StatusInternalServerError, "internal server error occurred", nil)
+		return
+	}
+
+	callingUser := callingUserI.(*models.User)
+	callingId := fmt.Sprintf("%d", callingUser.ID)
+
+	// parse and validate request body
+	var req core.GetModerationQueueRequest
+	if !s.validateRequest(w, r, callingUser, r.Body, &req) {
+		return
+	}
+
+	// check if this is a test
+	if req.Test {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "GetModerationQueue", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusOK)
+		return
+	}
+
+	// execute core function logic
+	res, err := core.GetModerationQueue(ctx, s.tiDB, callingUser, req.Status, req.AssignedToMe, req.Skip, req.Limit)
+	if err != nil {
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "GetModerationQueue core failed", r.URL.Path, "GetModerationQueue", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUser.UserName, callingId, coreErrorStatus(err), responseMessage, err)
+		// exit
+		return
+	}
+
+	parentSpan.AddEvent(
+		"get-moderation-queue",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUser.UserName),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "GetModerationQueue", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusOK)
+}
+
+func (s *HTTPServer) GetContentReport(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "get-content-report-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	// return if calling user was not retrieved in authentication
+	if callingUserI == nil {
+		s.handleError(w, "calling user missing from context", r.URL.Path, "GetContentReport", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), network.GetRequestIP(r), network.GetRequestIP(r), http.StatusInternalServerError, "internal server error occurred", nil)
+		return
+	}
+
+	callingUser := callingUserI.(*models.User)
+	callingId := fmt.Sprintf("%d", callingUser.ID)
+
+	// parse and validate request body
+	var req core.ContentReportRequest
+	if !s.validateRequest(w, r, callingUser, r.Body, &req) {
+		return
+	}
+
+	// check if this is a test
+	if req.Test {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "GetContentReport", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusOK)
+		return
+	}
+
+	// validation guarantees that the id is numeric
+	reportId, _ := strconv.ParseInt(req.ReportID, 10, 64)
+
+	// execute core function logic
+	res, err := core.GetContentReport(ctx, s.tiDB, callingUser, reportId)
+	if err != nil {
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "GetContentReport core failed", r.URL.Path, "GetContentReport", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUser.UserName, callingId, coreErrorStatus(err), responseMessage, err)
+		// exit
+		return
+	}
+
+	parentSpan.AddEvent(
+		"get-content-report",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUser.UserName),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "GetContentReport", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusOK)
+}
+
+func (s *HTTPServer) AssignContentReport(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "assign-content-report-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	// return if calling user was not retrieved in authentication
+	if callingUserI == nil {
+		s.handleError(w, "calling user missing from context", r.URL.Path, "AssignContentReport", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), network.GetRequestIP(r), network.GetRequestIP(r), http.StatusInternalServerError, "internal server error occurred", nil)
+		return
+	}
+
+	callingUser := callingUserI.(*models.User)
+	callingId := fmt.Sprintf("%d", callingUser.ID)
+
+	// parse and validate request body
+	var req core.AssignContentReportRequest
+	if !s.validateRequest(w, r, callingUser, r.Body, &req) {
+		return
+	}
+
+	// check if this is a test
+	if req.Test {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "AssignContentReport", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusOK)
+		return
+	}
+
+	// validation guarantees that the id is numeric
+	reportId, _ := strconv.ParseInt(req.ReportID, 10, 64)
+
+	// an empty assignee returns the report to the open queue
+	var assigneeId *int64
+	if req.AssigneeID != "" {
+		id, _ := strconv.ParseInt(req.AssigneeID, 10, 64)
+		assigneeId = &id
+	}
+
+	// execute core function logic
+	res, err := core.AssignContentReport(ctx, s.tiDB, callingUser, reportId, assigneeId)
+	if err != nil {
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "AssignContentReport core failed", r.URL.Path, "AssignContentReport", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUser.UserName, callingId, coreErrorStatus(err), responseMessage, err)
+		// exit
+		return
+	}
+
+	parentSpan.AddEvent(
+		"assign-content-report",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUser.UserName),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "AssignContentReport", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusOK)
+}
+
+func (s *HTTPServer) AddContentReportNote(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "add-content-report-note-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	// return if calling user was not retrieved in authentication
+	if callingUserI == nil {
+		s.handleError(w, "calling user missing from context", r.URL.Path, "AddContentReportNote", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), network.GetRequestIP(r), network.GetRequestIP(r), http.StatusInternalServerError, "internal server error occurred", nil)
+		return
+	}
+
+	callingUser := callingUserI.(*models.User)
+	callingId := fmt.Sprintf("%d", callingUser.ID)
+
+	// parse and validate request body
+	var req core.AddContentReportNoteRequest
+	if !s.validateRequest(w, r, callingUser, r.Body, &req) {
+		return
+	}
+
+	// check if this is a test
+	if req.Test {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "AddContentReportNote", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusOK)
+		return
+	}
+
+	// validation guarantees that the id is numeric
+	reportId, _ := strconv.ParseInt(req.ReportID, 10, 64)
+
+	// execute core function logic
+	res, err := core.AddContentReportNote(ctx, s.tiDB, s.sf, callingUser, reportId, req.Note)
+	if err != nil {
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "AddContentReportNote core failed", r.URL.Path, "AddContentReportNote", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUser.UserName, callingId, coreErrorStatus(err), responseMessage, err)
+		// exit
+		return
+	}
+
+	parentSpan.AddEvent(
+		"add-content-report-note",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUser.UserName),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "AddContentReportNote", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusOK)
+}
+
+func (s *HTTPServer) ResolveContentReport(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "resolve-content-report-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	// return if calling user was not retrieved in authentication
+	if callingUserI == nil {
+		s.handleError(w, "calling user missing from context", r.URL.Path, "ResolveContentReport", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), network.GetRequestIP(r), network.GetRequestIP(r), http.StatusInternalServerError, "internal server error occurred", nil)
+		return
+	}
+
+	callingUser := callingUserI.(*models.User)
+	callingId := fmt.Sprintf("%d", callingUser.ID)
+
+	// parse and validate request body
+	var req core.ResolveContentReportRequest
+	if !s.validateRequest(w, r, callingUser, r.Body, &req) {
+		return
+	}
+
+	// check if this is a test
+	if req.Test {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "ResolveContentReport", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusOK)
+		return
+	}
+
+	// validation guarantees that the id is numeric
+	reportId, _ := strconv.ParseInt(req.ReportID, 10, 64)
+
+	// execute core function logic
+	res, err := core.ResolveContentReport(ctx, s.tiDB, s.jetstreamClient, s.rdb, s.sf, callingUser, reportId, req.Action, req.Note, req.SuspendDays)
+	if err != nil {
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "ResolveContentReport core failed", r.URL.Path, "ResolveContentReport", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUser.UserName, callingId, coreErrorStatus(err), responseMessage, err)
+		// exit
+		return
+	}
+
+	// hiding discussion content changes the cached discussion trees
+	if discussionType, ok := res["discussion_type"].(string); ok {
+		contentId, _ := strconv.ParseInt(res["content_id"].(string), 10, 64)
+		s.invalidateDiscussionTree(ctx, discussionType, contentId)
+	}
+
+	parentSpan.AddEvent(
+		"resolve-content-report",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUser.UserName),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "ResolveContentReport", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusOK)
+}
@@ -485,36 +485,71 @@ func (s *HTTPServer) WarHistory(w http.ResponseWriter, r *http.Request) {
 	// retrieve calling user from context
 	callingUser := r.Context().Value(CtxKeyUser)
 
-	callingId := strconv.FormatInt(callingUser.(*models.User).ID, 10)
-
-	// return if calling user was not retrieved in authentication
-	if callingUser == nil {
-		s.handleError(w, "calling user missing from context", r.URL.Path, "WarHistory", r.Method, r.Context().Value(CtxKeyRequestID),
-			network.GetRequestIP(r), callingUser.(*models.User).UserName, callingId, http.StatusInternalServerError, "internal server error occurred", nil)
-		return
+	callingUsername := network.GetRequestIP(r)
+	callingId := network.GetRequestIP(r)
+	var callingIdInt int64
+	var callingUserModel *models.User
+	if callingUser != nil {
+		callingUserModel = callingUser.(*models.User)
+		callingUsername = callingUserModel.UserName
+		callingId = strconv.FormatInt(callingUserModel.ID, 10)
+		callingIdInt = callingUserModel.ID
 	}
 
 	// attempt to load JSON from request body
-	reqJson := s.jsonRequest(w, r, "WarHistory", false, "", -1)
+	reqJson := s.jsonRequest(w, r, "WarHistory", false, callingUsername, callingIdInt)
 	if reqJson == nil {
 		return
 	}
 
+	// attempt to load the id of the user whose history is being viewed
+	rawUserId, ok := s.loadValue(w, r, reqJson, "WarHistory", "user_id", reflect.String, nil, true, callingUsername, callingId)
+	if !ok {
+		return
+	}
+
+	// default to the calling user
+	userId := callingIdInt
+	if rawUserId != nil {
+		var err error
+		userId, err = strconv.ParseInt(rawUserId.(string), 10, 64)
+		if err != nil {
+			s.handleError(w, fmt.Sprintf("failed to parse user id string to integer: %s", rawUserId.(string)), r.URL.Path, "WarHistory", r.Method, r.Context().Value(CtxKeyRequestID),
+				network.GetRequestIP(r), callingUsername, callingId, http.StatusUnprocessableEntity, "invalid user id", err)
+			return
+		}
+	} else if callingUserModel == nil {
+		s.handleError(w, "calling user missing from context", r.URL.Path, "WarHistory", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, http.StatusUnprocessableEntity, "user_id is required when logged out", nil)
+		return
+	}
+
 	// check if this is a test
 	if val, ok := reqJson["test"]; ok && (val == true || val == "true") {
 		// return success for test
-		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "WarHistory", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), "", "", http.StatusOK)
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "WarHistory", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+		return
+	}
+
+	// ensure the caller is permitted to view the nemesis history
+	access, ok := s.resolvePrivacyAccess(ctx, w, r, "WarHistory", callingUserModel, userId, callingUsername, callingId)
+	if !ok {
+		return
+	}
+	if !access.Nemesis {
+		s.jsonResponse(r, w, map[string]interface{}{"history": []interface{}{}, "hidden": true}, r.URL.Path, "WarHistory", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
 		return
 	}
 
 	// execute core function logic
-	res, err := core.WarHistory(ctx, s.tiDB, callingUser.(*models.User).ID)
+	res, err := core.WarHistory(ctx, s.tiDB, userId)
 	if err != nil {
 		// select error message dependent on if there was one returned from the function
 		responseMessage := selectErrorResponse("internal server error occurred", res)
 		// handle error internally
 		s.handleError(w, "WarHistory core failed", r.URL.Path, "WarHistory", r.Method, r.Context().Value(CtxKeyRequestID),
-			network.GetRequestIP(r), "", "", http.StatusInternalServerError, responseMessage, err)
+			network.GetRequestIP(r), callingUsername, callingId, http.StatusInternalServerError, responseMessage, err)
 		// exit
 		return
 	}
@@ -524,12 +559,12 @@ func (s *HTTPServer) WarHistory(w http.ResponseWriter, r *http.Request) {
 		trace.WithAttributes(
 			attribute.Bool("success", true),
 			attribute.String("ip", network.GetRequestIP(r)),
-			attribute.String("username", callingUser.(*models.User).UserName),
+			attribute.String("username", callingUsername),
 		),
 	)
 
 	// return response
-	s.jsonResponse(r, w, res, r.URL.Path, "WarHistory", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), "", "", http.StatusOK)
+	s.jsonResponse(r, w, res, r.URL.Path, "WarHistory", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
 }
 
 func (s *HTTPServer) PendingNemesis(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,78 @@
+package external_api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"gigo-core/gigo/api/external_api/core"
+
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/network"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func (s *HTTPServer) GetPostAnalytics(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "get-post-analytics-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	// return if calling user was not retrieved in authentication
+	if callingUserI == nil {
+		s.handleError(w, "calling user missing from context", r.URL.Path, "GetPostAnalytics", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), network.GetRequestIP(r), network.GetRequestIP(r), http.StatusInternalServerError, "internal server error occurred", nil)
+		return
+	}
+
+	callingUser := callingUserI.(*models.User)
+	callingId := fmt.Sprintf("%d", callingUser.ID)
+
+	// parse and validate request body
+	var req core.PostAnalyticsRequest
+	if !s.validateRequest(w, r, callingUser, r.Body, &req) {
+		return
+	}
+
+	// check if this is a test
+	if req.Test {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "GetPostAnalytics", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusOK)
+		return
+	}
+
+	// validation guarantees that the id is numeric
+	projectId, _ := strconv.ParseInt(req.ProjectID, 10, 64)
+
+	// execute core function logic
+	res, err := core.GetPostAnalytics(ctx, s.tiDB, callingUser, projectId, req.Days)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, core.ErrForbidden) {
+			status = http.StatusForbidden
+		}
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "GetPostAnalytics core failed", r.URL.Path, "GetPostAnalytics", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUser.UserName, callingId, status, responseMessage, err)
+		// exit
+		return
+	}
+
+	parentSpan.AddEvent(
+		"get-post-analytics",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUser.UserName),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "GetPostAnalytics", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusOK)
+}
@@ -0,0 +1,147 @@
+package external_api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"gigo-core/gigo/api/external_api/core"
+
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/network"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// resolvePrivacyAccess resolves which sections of the profile owned by ownerId
+// are visible to the calling user. All hybrid routes that expose profile data
+// for a user other than the caller must pass through this function so that the
+// privacy settings are enforced in a single place. If false is returned the
+// response has already been written and the handler should exit.
+func (s *HTTPServer) resolvePrivacyAccess(ctx context.Context, w http.ResponseWriter, r *http.Request, method string,
+	callingUser *models.User, ownerId int64, username string, callingId string) (*core.PrivacyAccess, bool) {
+	access, err := core.ResolvePrivacyAccess(ctx, s.tiDB, callingUser, ownerId)
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			s.handleError(w, fmt.Sprintf("user not found: %d", ownerId), r.URL.Path, method, r.Method, r.Context().Value(CtxKeyRequestID),
+				network.GetRequestIP(r), username, callingId, http.StatusNotFound, "user not found", err)
+			return nil, false
+		}
+		s.handleError(w, "failed to resolve privacy access", r.URL.Path, method, r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), username, callingId, http.StatusInternalServerError, "internal server error occurred", err)
+		return nil, false
+	}
+	return access, true
+}
+
+func (s *HTTPServer) SetUserPrivacySettings(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "set-user-privacy-settings-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	// return if calling user was not retrieved in authentication
+	if callingUserI == nil {
+		s.handleError(w, "calling user missing from context", r.URL.Path, "SetUserPrivacySettings", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), network.GetRequestIP(r), network.GetRequestIP(r), http.StatusInternalServerError, "internal server error occurred", nil)
+		return
+	}
+
+	callingUser := callingUserI.(*models.User)
+	callingId := fmt.Sprintf("%d", callingUser.ID)
+
+	// parse and validate request body
+	var req core.SetUserPrivacySettingsRequest
+	if !s.validateRequest(w, r, callingUser, r.Body, &req) {
+		return
+	}
+
+	// check if this is a test
+	if req.Test {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "SetUserPrivacySettings", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusOK)
+		return
+	}
+
+	// execute core function logic
+	res, err := core.SetUserPrivacySettings(ctx, callingUser, s.tiDB, &req.PrivacySettings)
+	if err != nil {
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "SetUserPrivacySettings core failed", r.URL.Path, "SetUserPrivacySettings", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusInternalServerError, responseMessage, err)
+		// exit
+		return
+	}
+
+	parentSpan.AddEvent(
+		"set-user-privacy-settings",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUser.UserName),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "SetUserPrivacySettings", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusOK)
+}
+
+func (s *HTTPServer) GetUserPrivacySettings(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "get-user-privacy-settings-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	// return if calling user was not retrieved in authentication
+	if callingUserI == nil {
+		s.handleError(w, "calling user missing from context", r.URL.Path, "GetUserPrivacySettings", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), network.GetRequestIP(r), network.GetRequestIP(r), http.StatusInternalServerError, "internal server error occurred", nil)
+		return
+	}
+
+	callingUser := callingUserI.(*models.User)
+	callingId := fmt.Sprintf("%d", callingUser.ID)
+
+	// attempt to load JSON from request body
+	reqJson := s.jsonRequest(w, r, "GetUserPrivacySettings", false, callingUser.UserName, callingUser.ID)
+	if reqJson == nil {
+		return
+	}
+
+	// check if this is a test
+	if val, ok := reqJson["test"]; ok && (val == true || val == "true") {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "GetUserPrivacySettings", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusOK)
+		return
+	}
+
+	// execute core function logic
+	res, err := core.GetUserPrivacySettings(ctx, callingUser, s.tiDB)
+	if err != nil {
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "GetUserPrivacySettings core failed", r.URL.Path, "GetUserPrivacySettings", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusInternalServerError, responseMessage, err)
+		// exit
+		return
+	}
+
+	parentSpan.AddEvent(
+		"get-user-privacy-settings",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUser.UserName),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "GetUserPrivacySettings", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusOK)
+}
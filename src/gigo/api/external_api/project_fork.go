@@ -0,0 +1,173 @@
+package external_api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"gigo-core/gigo/api/external_api/core"
+
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/network"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func (s *HTTPServer) ForkProject(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "fork-project-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUserI := r.Context().Value(CtxKeyUser)
+
+	// return if calling user was not retrieved in authentication
+	if callingUserI == nil {
+		s.handleError(w, "calling user missing from context", r.URL.Path, "ForkProject", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), network.GetRequestIP(r), network.GetRequestIP(r), http.StatusInternalServerError, "internal server error occurred", nil)
+		return
+	}
+
+	callingUser := callingUserI.(*models.User)
+	callingId := fmt.Sprintf("%d", callingUser.ID)
+
+	// the session is needed to push the snapshot into the new repository as the caller
+	userSession := r.Context().Value("userSession")
+	if userSession == nil {
+		s.handleError(w, "user session missing from context", r.URL.Path, "ForkProject", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusInternalServerError, "internal server error occurred", nil)
+		return
+	}
+
+	// parse and validate request body
+	var req core.ForkProjectRequest
+	if !s.validateRequest(w, r, callingUser, r.Body, &req) {
+		return
+	}
+
+	// check if this is a test
+	if req.Test {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "ForkProject", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusOK)
+		return
+	}
+
+	// validation guarantees that the ids are numeric
+	projectId, _ := strconv.ParseInt(req.ProjectID, 10, 64)
+	var attemptId *int64
+	if req.AttemptID != nil {
+		id, _ := strconv.ParseInt(*req.AttemptID, 10, 64)
+		attemptId = &id
+	}
+
+	// execute core function logic
+	res, err := core.ForkProject(ctx, s.tiDB, s.vscClient, s.storageEngine, s.sf, callingUser, userSession.(*models.UserSession), projectId, attemptId,
+		req.Commit, req.Title, req.Description)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, core.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "ForkProject core failed", r.URL.Path, "ForkProject", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUser.UserName, callingId, status, responseMessage, err)
+		// exit
+		return
+	}
+
+	parentSpan.AddEvent(
+		"fork-project",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUser.UserName),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "ForkProject", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUser.UserName, callingId, http.StatusOK)
+}
+
+func (s *HTTPServer) GetProjectForks(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "get-project-forks-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUser := r.Context().Value(CtxKeyUser)
+
+	callingUsername := network.GetRequestIP(r)
+	callingId := network.GetRequestIP(r)
+	var callingIdInt int64
+	if callingUser != nil {
+		callingUsername = callingUser.(*models.User).UserName
+		callingId = strconv.FormatInt(callingUser.(*models.User).ID, 10)
+		callingIdInt = callingUser.(*models.User).ID
+	}
+
+	// attempt to load JSON from request body
+	reqJson := s.jsonRequest(w, r, "GetProjectForks", false, callingUsername, callingIdInt)
+	if reqJson == nil {
+		return
+	}
+
+	// attempt to load project id from body
+	projectIdI, ok := s.loadValue(w, r, reqJson, "GetProjectForks", "project_id", reflect.String, nil, false, callingUsername, callingId)
+	if projectIdI == nil || !ok {
+		return
+	}
+
+	// parse project id to integer
+	projectId, err := strconv.ParseInt(projectIdI.(string), 10, 64)
+	if err != nil {
+		s.handleError(w, fmt.Sprintf("failed to parse project id string to integer: %s", projectIdI.(string)), r.URL.Path, "GetProjectForks", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, http.StatusUnprocessableEntity, "invalid project id", err)
+		return
+	}
+
+	// attempt to load skip from request
+	skip, ok := s.loadValue(w, r, reqJson, "GetProjectForks", "skip", reflect.Float64, nil, false, callingUsername, callingId)
+	if skip == nil || !ok {
+		return
+	}
+
+	// attempt to load limit from request
+	limit, ok := s.loadValue(w, r, reqJson, "GetProjectForks", "limit", reflect.Float64, nil, false, callingUsername, callingId)
+	if limit == nil || !ok {
+		return
+	}
+
+	// check if this is a test
+	if val, ok := reqJson["test"]; ok && (val == true || val == "true") {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "GetProjectForks", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+		return
+	}
+
+	// execute core function logic
+	res, err := core.GetProjectForks(ctx, s.tiDB, projectId, int(skip.(float64)), int(limit.(float64)))
+	if err != nil {
+		// select error message dependent on if there was one returned from the function
+		responseMessage := selectErrorResponse("internal server error occurred", res)
+		// handle error internally
+		s.handleError(w, "GetProjectForks core failed", r.URL.Path, "GetProjectForks", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, http.StatusInternalServerError, responseMessage, err)
+		// exit
+		return
+	}
+
+	parentSpan.AddEvent(
+		"get-project-forks",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUsername),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, res, r.URL.Path, "GetProjectForks", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+}
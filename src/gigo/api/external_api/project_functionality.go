@@ -3,6 +3,7 @@ package external_api
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"gigo-core/gigo/api/external_api/core"
 	"io"
@@ -430,6 +431,61 @@ func (s *HTTPServer) CreateProject(w http.ResponseWriter, r *http.Request) {
 		exclusiveDescription = &tempWorkspace
 	}
 
+	// attempt to load parameter from body
+	importGitUrlI, ok := s.loadValue(w, r, reqJson, "CreateProject", "import_git_url", reflect.String, nil, true, callingUser.(*models.User).UserName, callingId)
+	if !ok {
+		return
+	}
+
+	// attempt to load parameter from body
+	importGitRefI, ok := s.loadValue(w, r, reqJson, "CreateProject", "import_git_ref", reflect.String, nil, true, callingUser.(*models.User).UserName, callingId)
+	if !ok {
+		return
+	}
+
+	// attempt to load parameter from body
+	importUploadIdI, ok := s.loadValue(w, r, reqJson, "CreateProject", "import_upload_id", reflect.String, nil, true, callingUser.(*models.User).UserName, callingId)
+	if !ok {
+		return
+	}
+
+	// create import source if the project is being imported from a git repository or archive
+	var importSource *core.ProjectImportSource
+	if importGitUrlI != nil || importUploadIdI != nil {
+		importSource = &core.ProjectImportSource{}
+		if importGitUrlI != nil {
+			importSource.GitURL = importGitUrlI.(string)
+		}
+		if importGitRefI != nil {
+			importSource.GitRef = importGitRefI.(string)
+		}
+		if importUploadIdI != nil {
+			// ensure the upload id cannot be used to traverse the storage
+			if filepath.Base(importUploadIdI.(string)) != importUploadIdI.(string) {
+				s.handleError(w, "invalid import upload id", r.URL.Path, "CreateProject", r.Method, r.Context().Value(CtxKeyRequestID),
+					network.GetRequestIP(r), callingUser.(*models.User).UserName, callingId, http.StatusUnprocessableEntity, "invalid import upload id", nil)
+				return
+			}
+
+			importSource.ArchivePath = projectImportArchivePath(callingUser.(*models.User).ID, importUploadIdI.(string))
+
+			// defer removal of the import archive
+			defer s.storageEngine.DeleteFile(importSource.ArchivePath)
+		}
+	}
+
+	// retrieve the user session which is required to push imported content
+	var userSession *models.UserSession
+	if importSource != nil {
+		userSessionI := r.Context().Value("userSession")
+		if userSessionI == nil {
+			s.handleError(w, "user session missing from context", r.URL.Path, "CreateProject", r.Method, r.Context().Value(CtxKeyRequestID),
+				network.GetRequestIP(r), callingUser.(*models.User).UserName, callingId, http.StatusInternalServerError, "internal server error occurred", nil)
+			return
+		}
+		userSession = userSessionI.(*models.UserSession)
+	}
+
 	// check if this is a test
 	if val, ok := reqJson["test"]; ok && (val == true || val == "true") {
 		// return success for test
@@ -441,7 +497,6 @@ func (s *HTTPServer) CreateProject(w http.ResponseWriter, r *http.Request) {
 	res, err := core.CreateProject(
 		ctx,
 		s.tiDB,
-		s.meili,
 		s.vscClient,
 		s.storageEngine,
 		s.rdb,
@@ -469,6 +524,8 @@ func (s *HTTPServer) CreateProject(w http.ResponseWriter, r *http.Request) {
 		projectCost,
 		s.logger,
 		exclusiveDescription,
+		importSource,
+		userSession,
 	)
 	if err != nil {
 		// select error message dependent on if there was one returned from the function
@@ -543,7 +600,6 @@ func (s *HTTPServer) DeleteProject(w http.ResponseWriter, r *http.Request) {
 		ctx,
 		s.tiDB,
 		callingUser.(*models.User),
-		s.meili,
 		projectId,
 		s.logger,
 	)
@@ -638,6 +694,17 @@ func (s *HTTPServer) StartAttempt(w http.ResponseWriter, r *http.Request) {
 		parentAttempt = &pa
 	}
 
+	// attempt to load the share link the attempt is started through
+	shareLinkI, ok := s.loadValue(w, r, reqJson, "StartAttempt", "share_link", reflect.String, nil, true, callingUser.(*models.User).UserName, callingId)
+	if !ok {
+		return
+	}
+
+	shareLink := ""
+	if shareLinkI != nil {
+		shareLink = shareLinkI.(string)
+	}
+
 	// check if this is a test
 	if val, ok := reqJson["test"]; ok && (val == true || val == "true") {
 		// return success for test
@@ -646,7 +713,7 @@ func (s *HTTPServer) StartAttempt(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// execute core function logic
-	res, err := core.StartAttempt(ctx, s.tiDB, s.vscClient, s.jetstreamClient, s.rdb, callingUser.(*models.User), userSession.(*models.UserSession), s.sf, postId, parentAttempt, s.logger)
+	res, err := core.StartAttempt(ctx, s.tiDB, s.vscClient, s.jetstreamClient, s.rdb, callingUser.(*models.User), userSession.(*models.UserSession), s.sf, postId, parentAttempt, shareLink, s.logger)
 	if err != nil {
 		// select error message dependent on if there was one returned from the function
 		responseMessage := selectErrorResponse("internal server error occurred", map[string]interface{}{"message": err})
@@ -716,7 +783,7 @@ func (s *HTTPServer) PublishProject(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// execute core function logic
-	res, err := core.PublishProject(ctx, s.tiDB, s.meili, postId)
+	res, err := core.PublishProject(ctx, s.tiDB, postId)
 	if err != nil {
 		// select error message dependent on if there was one returned from the function
 		responseMessage := selectErrorResponse("internal server error occurred", map[string]interface{}{"message": err})
@@ -790,7 +857,7 @@ func (s *HTTPServer) EditConfig(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// execute core function logic
-	res, err := core.EditConfig(ctx, s.vscClient, callingUser.(*models.User), repoId, content.(string), commit.(string))
+	res, err := core.EditConfig(ctx, s.tiDB, s.vscClient, s.rdb, callingUser.(*models.User), repoId, content.(string), commit.(string))
 	if err != nil {
 		// select error message dependent on if there was one returned from the function
 		responseMessage := selectErrorResponse("internal server error occurred", res)
@@ -921,7 +988,7 @@ func (s *HTTPServer) GetConfig(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// execute core function logic
-	res, err := core.GetConfig(ctx, s.vscClient, callingUser.(*models.User), repoId, commit.(string))
+	res, err := core.GetConfig(ctx, s.tiDB, s.vscClient, callingUser.(*models.User), repoId, commit.(string))
 	if err != nil {
 		// select error message dependent on if there was one returned from the function
 		responseMessage := selectErrorResponse("internal server error occurred", map[string]interface{}{"message": err})
@@ -1046,13 +1113,17 @@ func (s *HTTPServer) MarkSuccess(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	// execute core function logic
-	res, err := core.MarkSuccess(ctx, s.tiDB, s.jetstreamClient, s.rdb, s.sf, attemptId, s.logger, callingUser.(*models.User))
+	res, err := core.MarkSuccess(ctx, s.tiDB, s.vscClient, s.jetstreamClient, s.rdb, s.sf, attemptId, s.logger, callingUser.(*models.User))
 	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, core.ErrNotFound) {
+			status = http.StatusNotFound
+		}
 		// select error message dependent on if there was one returned from the function
-		responseMessage := selectErrorResponse("internal server error occurred", map[string]interface{}{"message": err})
+		responseMessage := selectErrorResponse("internal server error occurred", res)
 		// handle error internally
 		s.handleError(w, "MarkSuccess core failed", r.URL.Path, "MarkSuccess", r.Method, r.Context().Value(CtxKeyRequestID),
-			network.GetRequestIP(r), callingUser.(*models.User).UserName, callingId, http.StatusInternalServerError, responseMessage, err)
+			network.GetRequestIP(r), callingUser.(*models.User).UserName, callingId, status, responseMessage, err)
 		// exit
 		return
 	}
@@ -1110,13 +1181,17 @@ func (s *HTTPServer) ShareLink(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// execute core function logic
-	res, err := core.ShareLink(ctx, s.tiDB, postId, callingUser.(*models.User))
+	res, err := core.ShareLink(ctx, s.tiDB, s.sf, postId, callingUser.(*models.User))
 	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, core.ErrForbidden) {
+			status = http.StatusForbidden
+		}
 		// select error message dependent on if there was one returned from the function
-		responseMessage := selectErrorResponse("internal server error occurred", map[string]interface{}{"message": err})
+		responseMessage := selectErrorResponse("internal server error occurred", res)
 		// handle error internally
 		s.handleError(w, "ShareLink core failed", r.URL.Path, "ShareLink", r.Method, r.Context().Value(CtxKeyRequestID),
-			network.GetRequestIP(r), callingUser.(*models.User).UserName, callingId, http.StatusInternalServerError, responseMessage, err)
+			network.GetRequestIP(r), callingUser.(*models.User).UserName, callingId, status, responseMessage, err)
 		// exit
 		return
 	}
@@ -0,0 +1,79 @@
+package external_api
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strconv"
+
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/network"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// projectImportArchivePath returns the storage path of an uploaded project import archive
+func projectImportArchivePath(userId int64, uploadId string) string {
+	return fmt.Sprintf("temp_proj_imports/%d/%s", userId, uploadId)
+}
+
+// UploadProjectImport receives a zip or tar archive in parts that can later be
+// passed to CreateProject via import_upload_id
+func (s *HTTPServer) UploadProjectImport(w http.ResponseWriter, r *http.Request) {
+	_, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "upload-project-import-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUser := r.Context().Value(CtxKeyUser)
+
+	// return if calling user was not retrieved in authentication
+	if callingUser == nil {
+		s.handleError(w, "calling user missing from context", r.URL.Path, "UploadProjectImport", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), "", "-1", http.StatusInternalServerError, "internal server error occurred", nil)
+		return
+	}
+
+	callingId := strconv.FormatInt(callingUser.(*models.User).ID, 10)
+
+	// receive upload part and handle file assemble
+	reqJson := s.receiveUpload(w, r, "UploadProjectImport", "File Part Uploaded.", callingUser.(*models.User).UserName, callingUser.(*models.User).ID)
+	if reqJson == nil {
+		return
+	}
+
+	// attempt to load parameter from body
+	uploadId, ok := s.loadValue(w, r, reqJson, "UploadProjectImport", "upload_id", reflect.String, nil, false, callingUser.(*models.User).UserName, callingId)
+	if uploadId == nil || !ok {
+		return
+	}
+
+	// ensure the upload id cannot be used to traverse the storage
+	if filepath.Base(uploadId.(string)) != uploadId.(string) {
+		s.handleError(w, "invalid upload id", r.URL.Path, "UploadProjectImport", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUser.(*models.User).UserName, callingId, http.StatusUnprocessableEntity, "invalid upload id", nil)
+		return
+	}
+
+	// move the assembled archive into the calling user's import directory so
+	// that it can only be consumed by the user that uploaded it
+	err := s.storageEngine.MoveFile(filepath.Join("temp", uploadId.(string)), projectImportArchivePath(callingUser.(*models.User).ID, uploadId.(string)))
+	if err != nil {
+		s.handleError(w, "failed to move import archive", r.URL.Path, "UploadProjectImport", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUser.(*models.User).UserName, callingId, http.StatusInternalServerError, "internal server error occurred", err)
+		return
+	}
+
+	parentSpan.AddEvent(
+		"upload-project-import",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", callingUser.(*models.User).UserName),
+		),
+	)
+
+	// return response
+	s.jsonResponse(r, w, map[string]interface{}{"message": "Archive Uploaded.", "upload_id": uploadId.(string)}, r.URL.Path, "UploadProjectImport", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUser.(*models.User).UserName, callingId, http.StatusOK)
+}
@@ -0,0 +1,60 @@
+package external_api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestHTTPServer_UploadProjectImport(t *testing.T) {
+	body := bytes.NewReader([]byte(`{"upload_id":"test-import-upload","part":1,"total_parts":2,"chunk":"UEsDBA=="}`))
+	req, err := http.NewRequest("POST", "http://localhost:1818/api/project/import/upload", body)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_UploadProjectImport failed\n    Error: %v", err)
+		return
+	}
+
+	req.AddCookie(&http.Cookie{
+		Name:  "gigoAuthToken",
+		Value: testUserAuth,
+	})
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_UploadProjectImport failed\n    Error: %v", err)
+		return
+	}
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		fmt.Println(string(body))
+		fmt.Println(res.StatusCode)
+		t.Error("\nTestHTTPServer_UploadProjectImport failed\n    Error: incorrect response code")
+		return
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Error("\nTestHTTPServer_UploadProjectImport failed\n    Error: ", err)
+		return
+	}
+
+	var resJson map[string]interface{}
+	err = json.Unmarshal(resBody, &resJson)
+	if err != nil {
+		t.Error("\nTestHTTPServer_UploadProjectImport failed\n    Error: ", err)
+		return
+	}
+
+	expectedJson := map[string]interface{}{"message": "File Part Uploaded."}
+	if !reflect.DeepEqual(resJson, expectedJson) {
+		t.Error("\nTestHTTPServer_UploadProjectImport failed\n    Error: response JSON does not match expected JSON")
+		return
+	}
+
+	t.Log("\nTestHTTPServer_UploadProjectImport succeeded")
+}
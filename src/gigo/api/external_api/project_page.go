@@ -146,7 +146,11 @@ func (s *HTTPServer) ProjectAttempts(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// execute core function logic
-	res, err := core.ProjectAttempts(ctx, s.tiDB, postId, int(skip.(float64)), int(limit.(float64)))
+	var caller *models.User
+	if callingUser != nil {
+		caller = callingUser.(*models.User)
+	}
+	res, err := core.ProjectAttempts(ctx, s.tiDB, caller, postId, int(skip.(float64)), int(limit.(float64)))
 	if err != nil {
 		// select error message dependent on if there was one returned from the function
 		responseMessage := selectErrorResponse("internal server error occurred", map[string]interface{}{"message": err})
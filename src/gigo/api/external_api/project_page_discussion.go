@@ -14,6 +14,32 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
+// loadDiscussionSort loads the optional sort mode of a discussion listing from
+// the request body defaulting to the newest content. False is returned when
+// the sort mode is invalid and the error has already been written.
+func (s *HTTPServer) loadDiscussionSort(w http.ResponseWriter, r *http.Request, reqJson map[string]interface{}, method string,
+	callingUsername string, callingId string) (string, bool) {
+	sortMode, ok := s.loadValue(w, r, reqJson, method, "sort", reflect.String, nil, true, callingUsername, callingId)
+	if !ok {
+		return "", false
+	}
+
+	// default to the newest content
+	sort := string(core.DiscussionSortNew)
+	if sortMode != nil {
+		sort = sortMode.(string)
+	}
+
+	// reject unknown sort modes
+	if !core.ValidDiscussionSort(sort) {
+		s.handleError(w, fmt.Sprintf("invalid sort mode: %s", sort), r.URL.Path, method, r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, http.StatusUnprocessableEntity, "invalid sort mode", nil)
+		return "", false
+	}
+
+	return sort, true
+}
+
 func (s *HTTPServer) GetDiscussions(w http.ResponseWriter, r *http.Request) {
 	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "get-discussions-http")
 	defer parentSpan.End()
@@ -70,6 +96,12 @@ func (s *HTTPServer) GetDiscussions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// attempt to load the optional sort mode from body
+	sort, ok := s.loadDiscussionSort(w, r, reqJson, "GetDiscussions", callingUsername, callingId)
+	if !ok {
+		return
+	}
+
 	// check if this is a test
 	if val, ok := reqJson["test"]; ok && (val == true || val == "true") {
 		// return success for test
@@ -78,7 +110,7 @@ func (s *HTTPServer) GetDiscussions(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// execute core function logic
-	res, err := core.GetDiscussions(ctx, s.tiDB, callingUserModel, postId, int(skip.(float64)), int(limit.(float64)))
+	res, err := core.GetDiscussions(ctx, s.tiDB, callingUserModel, postId, int(skip.(float64)), int(limit.(float64)), sort)
 	if err != nil {
 		// select error message dependent on if there was one returned from the function
 		responseMessage := selectErrorResponse("internal server error occurred", res)
@@ -175,6 +207,12 @@ func (s *HTTPServer) GetDiscussionComments(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// attempt to load the optional sort mode from body
+	sort, ok := s.loadDiscussionSort(w, r, reqJson, "GetDiscussionComments", callingUsername, callingId)
+	if !ok {
+		return
+	}
+
 	// check if this is a test
 	if val, ok := reqJson["test"]; ok && (val == true || val == "true") {
 		// return success for test
@@ -183,7 +221,7 @@ func (s *HTTPServer) GetDiscussionComments(w http.ResponseWriter, r *http.Reques
 	}
 
 	// execute core function logic
-	res, err := core.GetDiscussionComments(ctx, s.tiDB, callingUserModel, discussionIds, int(skip.(float64)), int(limit.(float64)))
+	res, err := core.GetDiscussionComments(ctx, s.tiDB, callingUserModel, discussionIds, int(skip.(float64)), int(limit.(float64)), sort)
 	if err != nil {
 		// select error message dependent on if there was one returned from the function
 		responseMessage := selectErrorResponse("internal server error occurred", res)
@@ -274,6 +312,12 @@ func (s *HTTPServer) GetCommentThreads(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// attempt to load the optional sort mode from body
+	sort, ok := s.loadDiscussionSort(w, r, reqJson, "GetCommentThreads", callingUsername, callingId)
+	if !ok {
+		return
+	}
+
 	// check if this is a test
 	if val, ok := reqJson["test"]; ok && (val == true || val == "true") {
 		// return success for test
@@ -282,7 +326,7 @@ func (s *HTTPServer) GetCommentThreads(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// execute core function logic
-	res, err := core.GetCommentThreads(ctx, s.tiDB, callingUserModel, commentIds, int(skip.(float64)), int(limit.(float64)))
+	res, err := core.GetCommentThreads(ctx, s.tiDB, callingUserModel, commentIds, int(skip.(float64)), int(limit.(float64)), sort)
 	if err != nil {
 		// select error message dependent on if there was one returned from the function
 		responseMessage := selectErrorResponse("internal server error occurred", res)
@@ -373,6 +417,12 @@ func (s *HTTPServer) GetThreadReply(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// attempt to load the optional sort mode from body
+	sort, ok := s.loadDiscussionSort(w, r, reqJson, "GetThreadReply", callingUsername, callingId)
+	if !ok {
+		return
+	}
+
 	// check if this is a test
 	if val, ok := reqJson["test"]; ok && (val == true || val == "true") {
 		// return success for test
@@ -381,7 +431,7 @@ func (s *HTTPServer) GetThreadReply(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// execute core function logic
-	res, err := core.GetThreadReply(ctx, s.tiDB, callingUserModel, threadIds, int(skip.(float64)), int(limit.(float64)))
+	res, err := core.GetThreadReply(ctx, s.tiDB, callingUserModel, threadIds, int(skip.(float64)), int(limit.(float64)), sort)
 	if err != nil {
 		// select error message dependent on if there was one returned from the function
 		responseMessage := selectErrorResponse("internal server error occurred", res)
@@ -530,7 +580,7 @@ func (s *HTTPServer) CreateDiscussion(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// execute core function logic
-	res, err := core.CreateDiscussion(ctx, s.tiDB, s.meili, callingUser.(*models.User), s.sf, postId, title.(string), body.(string), tags)
+	res, err := core.CreateDiscussion(ctx, s.tiDB, s.jetstreamClient, callingUser.(*models.User), s.sf, s.logger, postId, title.(string), body.(string), tags)
 	if err != nil {
 		// select error message dependent on if there was one returned from the function
 		responseMessage := selectErrorResponse("internal server error occurred", res)
@@ -606,7 +656,7 @@ func (s *HTTPServer) CreateComment(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// execute core function logic
-	res, err := core.CreateComment(ctx, s.tiDB, s.meili, callingUser.(*models.User), s.sf, discussionId, body.(string))
+	res, err := core.CreateComment(ctx, s.tiDB, s.jetstreamClient, callingUser.(*models.User), s.sf, s.logger, discussionId, body.(string))
 	if err != nil {
 		// select error message dependent on if there was one returned from the function
 		responseMessage := selectErrorResponse("internal server error occurred", res)
@@ -617,6 +667,9 @@ func (s *HTTPServer) CreateComment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// drop the cached trees that include the changed content
+	s.invalidateDiscussionTree(ctx, "discussion", discussionId)
+
 	parentSpan.AddEvent(
 		"create-comment",
 		trace.WithAttributes(
@@ -682,7 +735,7 @@ func (s *HTTPServer) CreateThreadComment(w http.ResponseWriter, r *http.Request)
 	}
 
 	// execute core function logic
-	res, err := core.CreateThreadComment(ctx, s.tiDB, s.meili, callingUser.(*models.User), s.sf, commentId, body.(string))
+	res, err := core.CreateThreadComment(ctx, s.tiDB, s.jetstreamClient, callingUser.(*models.User), s.sf, s.logger, commentId, body.(string))
 	if err != nil {
 		// select error message dependent on if there was one returned from the function
 		responseMessage := selectErrorResponse("internal server error occurred", res)
@@ -693,6 +746,9 @@ func (s *HTTPServer) CreateThreadComment(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// drop the cached trees that include the changed content
+	s.invalidateDiscussionTree(ctx, "comment", commentId)
+
 	parentSpan.AddEvent(
 		"create-thread-comment",
 		trace.WithAttributes(
@@ -758,7 +814,7 @@ func (s *HTTPServer) CreateThreadReply(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// execute core function logic
-	res, err := core.CreateThreadReply(ctx, s.tiDB, callingUser.(*models.User), s.sf, threadId, body.(string))
+	res, err := core.CreateThreadReply(ctx, s.tiDB, s.jetstreamClient, callingUser.(*models.User), s.sf, s.logger, threadId, body.(string))
 	if err != nil {
 		// select error message dependent on if there was one returned from the function
 		responseMessage := selectErrorResponse("internal server error occurred", res)
@@ -769,6 +825,9 @@ func (s *HTTPServer) CreateThreadReply(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// drop the cached trees that include the changed content
+	s.invalidateDiscussionTree(ctx, "thread_comment", threadId)
+
 	parentSpan.AddEvent(
 		"create-thread-reply",
 		trace.WithAttributes(
@@ -920,7 +979,7 @@ func (s *HTTPServer) EditDiscussions(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// execute core function logic
-	res, err := core.EditDiscussions(ctx, s.tiDB, callingUser.(*models.User), s.meili, s.sf, discussionType.(string), mainId, title, body.(string), tags)
+	res, err := core.EditDiscussions(ctx, s.tiDB, callingUser.(*models.User), s.sf, discussionType.(string), mainId, title, body.(string), tags)
 	if err != nil {
 		// select error message dependent on if there was one returned from the function
 		responseMessage := selectErrorResponse("internal server error occurred", res)
@@ -931,6 +990,9 @@ func (s *HTTPServer) EditDiscussions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// drop the cached trees that include the changed content
+	s.invalidateDiscussionTree(ctx, discussionType.(string), mainId)
+
 	parentSpan.AddEvent(
 		"edit-discussions",
 		trace.WithAttributes(
@@ -997,6 +1059,11 @@ func (s *HTTPServer) AddDiscussionCoffee(w http.ResponseWriter, r *http.Request)
 
 	// execute core function logic
 	res, err := core.AddDiscussionCoffee(ctx, s.tiDB, callingUser.(*models.User), s.sf, id, discussionType.(string))
+
+	// drop the cached trees that include the changed content. this runs on
+	// failures too since the coffee may have changed before the error
+	s.invalidateDiscussionTree(ctx, discussionType.(string), id)
+
 	if err != nil {
 		// select error message dependent on if there was one returned from the function
 		responseMessage := selectErrorResponse("internal server error occurred", res)
@@ -1073,6 +1140,11 @@ func (s *HTTPServer) RemoveDiscussionCoffee(w http.ResponseWriter, r *http.Reque
 
 	// execute core function logic
 	res, err := core.RemoveDiscussionCoffee(ctx, s.tiDB, callingUser.(*models.User), id, discussionType.(string))
+
+	// drop the cached trees that include the changed content. this runs on
+	// failures too since the coffee may have changed before the error
+	s.invalidateDiscussionTree(ctx, discussionType.(string), id)
+
 	if err != nil {
 		// select error message dependent on if there was one returned from the function
 		responseMessage := selectErrorResponse("internal server error occurred", res)
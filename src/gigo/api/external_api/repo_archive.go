@@ -0,0 +1,120 @@
+package external_api
+
+import (
+	"errors"
+	"fmt"
+	"gigo-core/gigo/api/external_api/core"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gage-technologies/gigo-lib/db/models"
+	"github.com/gage-technologies/gigo-lib/network"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DownloadRepoArchive streams a zip or tar.gz archive of a project or attempt
+// repository at the ref passed in the query string
+func (s *HTTPServer) DownloadRepoArchive(w http.ResponseWriter, r *http.Request) {
+	ctx, parentSpan := otel.Tracer("gigo-core").Start(r.Context(), "download-repo-archive-http")
+	defer parentSpan.End()
+
+	// retrieve calling user from context
+	callingUser := r.Context().Value(CtxKeyUser)
+
+	var finalCallingUser *models.User
+
+	// create variables to hold user data defaulting to anonymous user
+	userName := "anon"
+	userId := ""
+	if callingUser != nil {
+		finalCallingUser = callingUser.(*models.User)
+		userName = callingUser.(*models.User).UserName
+		userId = fmt.Sprintf("%d", callingUser.(*models.User).ID)
+	}
+
+	// attempt to retrieve target id from url
+	vars := mux.Vars(r)
+	idString, ok := vars["id"]
+	if !ok {
+		// handle error internally
+		s.handleError(w, "no id found in path", r.URL.Path, "DownloadRepoArchive", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), userName, userId, http.StatusMethodNotAllowed, "invalid path", nil)
+		return
+	}
+
+	// parse id to integer
+	id, err := strconv.ParseInt(idString, 10, 64)
+	if err != nil {
+		// handle error internally
+		s.handleError(w, "failed to parse id to int", r.URL.Path, "DownloadRepoArchive", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), userName, userId, http.StatusUnprocessableEntity, "invalid id", err)
+		return
+	}
+
+	// parse the archive format
+	format, ok := core.ParseArchiveFormat(r.URL.Query().Get("format"))
+	if !ok {
+		s.handleError(w, "invalid archive format", r.URL.Path, "DownloadRepoArchive", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), userName, userId, http.StatusUnprocessableEntity, "format must be zip or tar.gz", nil)
+		return
+	}
+
+	ref := r.URL.Query().Get("ref")
+
+	// resolve the archive for the project or attempt depending on the called path
+	var archive *core.RepoArchive
+	if strings.HasPrefix(r.URL.Path, "/api/attempt") {
+		archive, err = core.ResolveAttemptArchive(ctx, s.tiDB, s.vscClient, finalCallingUser, id, ref, format)
+	} else {
+		archive, err = core.ResolveProjectArchive(ctx, s.tiDB, s.vscClient, finalCallingUser, id, ref, format)
+	}
+	if err != nil {
+		if errors.Is(err, core.ErrNotFound) {
+			s.handleError(w, "DownloadRepoArchive not found", r.URL.Path, "DownloadRepoArchive", r.Method, r.Context().Value(CtxKeyRequestID),
+				network.GetRequestIP(r), userName, userId, http.StatusNotFound, "not found", err)
+			return
+		}
+		if errors.Is(err, core.ErrForbidden) {
+			s.handleError(w, "DownloadRepoArchive forbidden", r.URL.Path, "DownloadRepoArchive", r.Method, r.Context().Value(CtxKeyRequestID),
+				network.GetRequestIP(r), userName, userId, http.StatusForbidden, "this project must be purchased before it can be downloaded", err)
+			return
+		}
+		// handle error internally
+		s.handleError(w, "DownloadRepoArchive core failed", r.URL.Path, "DownloadRepoArchive", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), userName, userId, http.StatusInternalServerError, "internal server error occurred", err)
+		return
+	}
+
+	// add headers
+	w.Header().Set("Content-Type", archive.Format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", archive.FileName))
+
+	// set status code
+	w.WriteHeader(200)
+
+	// stream the archive to the response
+	err = core.WriteRepoArchive(ctx, s.vscClient, s.storageEngine, archive, w)
+	if err != nil {
+		// the response has already started so we can only log the failure
+		s.logger.LogErrorExternalAPI("failed to stream repo archive", r.URL.Path, "DownloadRepoArchive", r.Method,
+			r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), userName, userId, http.StatusInternalServerError, err)
+		return
+	}
+
+	parentSpan.AddEvent(
+		"download-repo-archive",
+		trace.WithAttributes(
+			attribute.Bool("success", true),
+			attribute.String("ip", network.GetRequestIP(r)),
+			attribute.String("username", userName),
+		),
+	)
+
+	// log successful function execution
+	s.logger.LogDebugExternalAPI("function execution successful", r.URL.Path, "DownloadRepoArchive", r.Method,
+		r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), userName, userId, http.StatusOK, nil)
+}
@@ -0,0 +1,37 @@
+package external_api
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestHTTPServer_DownloadRepoArchive(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://localhost:1818/api/project/download/1?format=rar", nil)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_DownloadRepoArchive failed\n    Error: %v", err)
+		return
+	}
+
+	req.AddCookie(&http.Cookie{
+		Name:  "gigoAuthToken",
+		Value: testUserAuth,
+	})
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_DownloadRepoArchive failed\n    Error: %v", err)
+		return
+	}
+
+	if res.StatusCode != http.StatusUnprocessableEntity {
+		body, _ := ioutil.ReadAll(res.Body)
+		fmt.Println(string(body))
+		fmt.Println(res.StatusCode)
+		t.Error("\nTestHTTPServer_DownloadRepoArchive failed\n    Error: incorrect response code")
+		return
+	}
+
+	t.Log("\nTestHTTPServer_DownloadRepoArchive succeeded")
+}
@@ -659,7 +659,7 @@ func (s *HTTPServer) SearchDiscussions(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// execute core function logic
-	res, err := core.SearchDiscussions(ctx, s.meili, query.(string), int(skip.(float64)), int(limit.(float64)), postId)
+	res, err := core.SearchDiscussions(ctx, s.tiDB, s.meili, query.(string), int(skip.(float64)), int(limit.(float64)), postId)
 	if err != nil {
 		// select error message dependent on if there was one returned from the function
 		responseMessage := selectErrorResponse("internal server error occurred", res)
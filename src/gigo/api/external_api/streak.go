@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"reflect"
 	"strconv"
 	"time"
 
@@ -227,6 +228,12 @@ func (s *HTTPServer) StreakHandlerExt(w http.ResponseWriter, r *http.Request) {
 					return
 				}
 
+				// evaluate the streak achievements now that the streak has been extended
+				_, err = core.EvaluateAchievements(ctx, s.tiDB, s.jetstreamClient, s.sf, ownerID, core.AchievementEventStreak, s.logger)
+				if err != nil {
+					s.logger.Errorf("StreakHandler: failed to evaluate streak achievements: %v", err)
+				}
+
 				// retrieve updated map for user after streak update
 				resMap, err = core.CheckElapsedStreakTime(ctx, s.tiDB, ownerID, timezone, s.logger)
 				if err != nil {
@@ -466,6 +473,12 @@ func (s *HTTPServer) StreakHandlerFrontend(w http.ResponseWriter, r *http.Reques
 					return
 				}
 
+				// evaluate the streak achievements now that the streak has been extended
+				_, err = core.EvaluateAchievements(ctx, s.tiDB, s.jetstreamClient, s.sf, callingUser.(*models.User).ID, core.AchievementEventStreak, s.logger)
+				if err != nil {
+					s.logger.Errorf("StreakHandler: failed to evaluate streak achievements: %v", err)
+				}
+
 				// retrieve updated map for user after streak update
 				resMap, err = core.CheckElapsedStreakTime(ctx, s.tiDB, callingUser.(*models.User).ID, callingUser.(*models.User).Timezone, s.logger)
 				if err != nil {
@@ -559,29 +572,73 @@ func (s *HTTPServer) GetUserStreaks(w http.ResponseWriter, r *http.Request) {
 	s.logger.Debugf("GetUserStreaks: start of call")
 	// retrieve calling user from context
 	callingUser := r.Context().Value(CtxKeyUser)
-	callingId := strconv.FormatInt(callingUser.(*models.User).ID, 10)
 
-	// return if calling user was not retrieved in authentication
-	if callingUser == nil {
-		s.handleError(w, "calling user missing from context", r.URL.Path, "GetUserStreaks", r.Method, r.Context().Value(CtxKeyRequestID),
-			network.GetRequestIP(r), callingUser.(*models.User).UserName, "-1", http.StatusInternalServerError, "internal server error occurred", nil)
-		return
+	callingUsername := network.GetRequestIP(r)
+	callingId := network.GetRequestIP(r)
+	var callingIdInt int64
+	var callingUserModel *models.User
+	if callingUser != nil {
+		callingUserModel = callingUser.(*models.User)
+		callingUsername = callingUserModel.UserName
+		callingId = strconv.FormatInt(callingUserModel.ID, 10)
+		callingIdInt = callingUserModel.ID
 	}
+
 	// attempt to load JSON from request body
-	reqJson := s.jsonRequest(w, r, "GetUserStreaks", false, "", -1)
+	reqJson := s.jsonRequest(w, r, "GetUserStreaks", false, callingUsername, callingIdInt)
 	if reqJson == nil {
 		return
 	}
 
+	// attempt to load the id of the user whose streaks are being viewed
+	rawUserId, ok := s.loadValue(w, r, reqJson, "GetUserStreaks", "user_id", reflect.String, nil, true, callingUsername, callingId)
+	if !ok {
+		return
+	}
+
+	// default to the calling user
+	userId := callingIdInt
+	if rawUserId != nil {
+		var err error
+		userId, err = strconv.ParseInt(rawUserId.(string), 10, 64)
+		if err != nil {
+			s.handleError(w, fmt.Sprintf("failed to parse user id string to integer: %s", rawUserId.(string)), r.URL.Path, "GetUserStreaks", r.Method, r.Context().Value(CtxKeyRequestID),
+				network.GetRequestIP(r), callingUsername, callingId, http.StatusUnprocessableEntity, "invalid user id", err)
+			return
+		}
+	} else if callingUserModel == nil {
+		s.handleError(w, "calling user missing from context", r.URL.Path, "GetUserStreaks", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, http.StatusUnprocessableEntity, "user_id is required when logged out", nil)
+		return
+	}
+
+	// check if this is a test
+	if val, ok := reqJson["test"]; ok && (val == true || val == "true") {
+		// return success for test
+		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "GetUserStreaks", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+		return
+	}
+
+	// ensure the caller is permitted to view the streaks
+	access, ok := s.resolvePrivacyAccess(ctx, w, r, "GetUserStreaks", callingUserModel, userId, callingUsername, callingId)
+	if !ok {
+		return
+	}
+	if !access.Streaks {
+		s.jsonResponse(r, w, map[string]interface{}{"hidden": true}, r.URL.Path, "GetUserStreaks", r.Method, r.Context().Value(CtxKeyRequestID),
+			network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
+		return
+	}
+
 	// execute core function logic
-	res, err := core.GetUserStreaks(ctx, s.tiDB, callingUser.(*models.User), s.logger)
+	res, err := core.GetUserStreaks(ctx, s.tiDB, callingUserModel, userId, s.logger)
 	s.logger.Debugf("GetUserStreaks: calling core function now")
 	if err != nil {
 		// select error message dependent on if there was one returned from the function
 		responseMessage := selectErrorResponse("internal server error occurred", res)
 		// handle error internally
 		s.handleError(w, "GetUserStreaks core failed", r.URL.Path, "GetUserStreaks", r.Method, r.Context().Value(CtxKeyRequestID),
-			network.GetRequestIP(r), callingUser.(*models.User).UserName, callingId, http.StatusInternalServerError, responseMessage, err)
+			network.GetRequestIP(r), callingUsername, callingId, http.StatusInternalServerError, responseMessage, err)
 		// exit
 		return
 	}
@@ -591,7 +648,7 @@ func (s *HTTPServer) GetUserStreaks(w http.ResponseWriter, r *http.Request) {
 		trace.WithAttributes(
 			attribute.Bool("success", true),
 			attribute.String("ip", network.GetRequestIP(r)),
-			attribute.String("username", callingUser.(*models.User).UserName),
+			attribute.String("username", callingUsername),
 		),
 	)
 
@@ -599,7 +656,7 @@ func (s *HTTPServer) GetUserStreaks(w http.ResponseWriter, r *http.Request) {
 
 	// return JSON response
 	s.jsonResponse(r, w, res, r.URL.Path, "GetUserStreaks", r.Method, r.Context().Value(CtxKeyRequestID),
-		network.GetRequestIP(r), callingUser.(*models.User).UserName, callingId, http.StatusOK)
+		network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
 
 }
 
@@ -173,7 +173,7 @@ func (s *HTTPServer) CreateNewUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// execute core function logic
-	res, err := core.CreateNewUser(ctx, s.tiDB, s.meili, s.streakEngine, s.sf, s.domain, userName.(string), password.(string),
+	res, err := core.CreateNewUser(ctx, s.tiDB, s.streakEngine, s.sf, s.domain, userName.(string), password.(string),
 		email.(string), phone.(string), bio.(string), firstName.(string), lastName.(string),
 		s.vscClient, userInitForm, timeZoneI.(string), thumbnailTempPath, s.storageEngine, avatarSetting,
 		s.passwordFilter, forcePass.(bool), s.initialRecUrl, s.logger, s.mailGunKey, s.mailGunDomain, referral)
@@ -537,8 +537,23 @@ func (s *HTTPServer) UserProfilePage(w http.ResponseWriter, r *http.Request) {
 		s.jsonResponse(r, w, map[string]interface{}{}, r.URL.Path, "GenerateUserOtpUri", r.Method, r.Context().Value(CtxKeyRequestID), network.GetRequestIP(r), callingUsername, callingId, http.StatusOK)
 		return
 	}
+	// resolve the privacy access for the profile being viewed
+	var access *core.PrivacyAccess
+	if client != nil || callingUserModel != nil {
+		ownerId := callingIdInt
+		if client != nil {
+			ownerId = *client
+		}
+
+		var ok bool
+		access, ok = s.resolvePrivacyAccess(ctx, w, r, "UserProfilePage", callingUserModel, ownerId, callingUsername, callingId)
+		if !ok {
+			return
+		}
+	}
+
 	// execute core function logic
-	res, err := core.UserProfilePage(ctx, callingUserModel, s.tiDB, client)
+	res, err := core.UserProfilePage(ctx, callingUserModel, s.tiDB, client, access)
 	if err != nil {
 		// select error message dependent on if there was one returned from the function
 		responseMessage := selectErrorResponse("internal server error occurred", res)
@@ -953,7 +968,7 @@ func (s *HTTPServer) DeleteUserAccount(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// execute core function logic
-	res, err := core.DeleteUserAccount(ctx, s.tiDB, s.meili, s.vscClient, callingUser.(*models.User))
+	res, err := core.DeleteUserAccount(ctx, s.tiDB, s.vscClient, callingUser.(*models.User))
 	if err != nil {
 
 		// select error message dependent on if there was one returned from the function
@@ -1101,7 +1116,7 @@ func (s *HTTPServer) CreateNewGoogleUser(w http.ResponseWriter, r *http.Request)
 	}
 
 	// execute core function logic
-	res, err := core.CreateNewGoogleUser(ctx, s.tiDB, s.meili, s.sf, s.streakEngine, s.domain, externalAuth.(string),
+	res, err := core.CreateNewGoogleUser(ctx, s.tiDB, s.sf, s.streakEngine, s.domain, externalAuth.(string),
 		password.(string), s.vscClient, *workspaceSettings, timeZoneI.(string), avatarSetting, thumbnailTempPath,
 		s.storageEngine, s.mailGunKey, s.mailGunDomain, s.initialRecUrl, referral, s.logger)
 	if err != nil {
@@ -1249,7 +1264,7 @@ func (s *HTTPServer) CreateNewGithubUser(w http.ResponseWriter, r *http.Request)
 	}
 
 	// execute core function logic
-	res, err := core.CreateNewGithubUser(ctx, s.tiDB, s.meili, s.sf, s.streakEngine, s.domain, externalAuth.(string),
+	res, err := core.CreateNewGithubUser(ctx, s.tiDB, s.sf, s.streakEngine, s.domain, externalAuth.(string),
 		password.(string), s.vscClient, *workspaceSettings, timeZoneI.(string), avatarSetting, s.githubSecret,
 		thumbnailTempPath, s.storageEngine, s.mailGunKey, s.mailGunDomain, s.initialRecUrl, referral, s.logger)
 	if err != nil {
@@ -145,7 +145,7 @@ func (s *HTTPServer) CreateWorkspaceConfig(w http.ResponseWriter, r *http.Reques
 
 	// execute core function logic
 	res, err := core.CreateWorkspaceConfig(ctx,
-		s.tiDB, s.meili, s.sf, callingUser.(*models.User), title.(string), description.(string), content.(string),
+		s.tiDB, s.rdb, s.sf, callingUser.(*models.User), title.(string), description.(string), content.(string),
 		tags, languages,
 	)
 	if err != nil {
@@ -323,6 +323,16 @@ func (s *HTTPServer) UpdateWorkspaceConfig(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
+	// attempt to load the optional note describing the change from body
+	changeNoteI, ok := s.loadValue(w, r, reqJson, "UpdateWorkspaceConfig", "change_note", reflect.String, nil, true, callingUser.(*models.User).UserName, callingId)
+	if !ok {
+		return
+	}
+	changeNote := ""
+	if changeNoteI != nil {
+		changeNote = changeNoteI.(string)
+	}
+
 	// check if this is a test
 	if val, ok := reqJson["test"]; ok && (val == true || val == "true") {
 		// return success for test
@@ -332,7 +342,7 @@ func (s *HTTPServer) UpdateWorkspaceConfig(w http.ResponseWriter, r *http.Reques
 
 	// execute core function logic
 	res, err := core.UpdateWorkspaceConfig(ctx,
-		s.tiDB, s.meili, s.sf, callingUser.(*models.User), workspaceConfigId, description, content, tags, languages,
+		s.tiDB, s.rdb, s.jetstreamClient, s.vscClient, s.sf, callingUser.(*models.User), workspaceConfigId, description, content, tags, languages, changeNote,
 	)
 	if err != nil {
 		// select error message dependent on if there was one returned from the function
@@ -0,0 +1,207 @@
+package external_api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestHTTPServer_ListWorkspaceConfigRevisions(t *testing.T) {
+	body := bytes.NewReader([]byte(`{"config_id":"1","test":true}`))
+	req, err := http.NewRequest("POST", "http://localhost:1818/api/workspace/config/revisions", body)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_ListWorkspaceConfigRevisions failed\n    Error: %v", err)
+		return
+	}
+
+	req.AddCookie(&http.Cookie{
+		Name:  "gigoAuthToken",
+		Value: testUserAuth,
+	})
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_ListWorkspaceConfigRevisions failed\n    Error: %v", err)
+		return
+	}
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		fmt.Println(string(body))
+		fmt.Println(res.StatusCode)
+		t.Error("\nTestHTTPServer_ListWorkspaceConfigRevisions failed\n    Error: incorrect response code")
+		return
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Error("\nTestHTTPServer_ListWorkspaceConfigRevisions failed\n    Error: ", err)
+		return
+	}
+
+	var resJson map[string]interface{}
+	err = json.Unmarshal(resBody, &resJson)
+	if err != nil {
+		t.Error("\nTestHTTPServer_ListWorkspaceConfigRevisions failed\n    Error: ", err)
+		return
+	}
+
+	expectedJson := map[string]interface{}{}
+	if !reflect.DeepEqual(resJson, expectedJson) {
+		t.Error("\nTestHTTPServer_ListWorkspaceConfigRevisions failed\n    Error: response JSON does not match expected JSON")
+		return
+	}
+
+	t.Log("\nTestHTTPServer_ListWorkspaceConfigRevisions succeeded")
+}
+
+func TestHTTPServer_DiffWorkspaceConfigRevisions(t *testing.T) {
+	body := bytes.NewReader([]byte(`{"config_id":"1","from_revision":0,"to_revision":1,"test":true}`))
+	req, err := http.NewRequest("POST", "http://localhost:1818/api/workspace/config/diff", body)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_DiffWorkspaceConfigRevisions failed\n    Error: %v", err)
+		return
+	}
+
+	req.AddCookie(&http.Cookie{
+		Name:  "gigoAuthToken",
+		Value: testUserAuth,
+	})
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_DiffWorkspaceConfigRevisions failed\n    Error: %v", err)
+		return
+	}
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		fmt.Println(string(body))
+		fmt.Println(res.StatusCode)
+		t.Error("\nTestHTTPServer_DiffWorkspaceConfigRevisions failed\n    Error: incorrect response code")
+		return
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Error("\nTestHTTPServer_DiffWorkspaceConfigRevisions failed\n    Error: ", err)
+		return
+	}
+
+	var resJson map[string]interface{}
+	err = json.Unmarshal(resBody, &resJson)
+	if err != nil {
+		t.Error("\nTestHTTPServer_DiffWorkspaceConfigRevisions failed\n    Error: ", err)
+		return
+	}
+
+	expectedJson := map[string]interface{}{}
+	if !reflect.DeepEqual(resJson, expectedJson) {
+		t.Error("\nTestHTTPServer_DiffWorkspaceConfigRevisions failed\n    Error: response JSON does not match expected JSON")
+		return
+	}
+
+	t.Log("\nTestHTTPServer_DiffWorkspaceConfigRevisions succeeded")
+}
+
+func TestHTTPServer_RollbackWorkspaceConfig(t *testing.T) {
+	body := bytes.NewReader([]byte(`{"config_id":"1","revision":0,"test":true}`))
+	req, err := http.NewRequest("POST", "http://localhost:1818/api/workspace/config/rollback", body)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_RollbackWorkspaceConfig failed\n    Error: %v", err)
+		return
+	}
+
+	req.AddCookie(&http.Cookie{
+		Name:  "gigoAuthToken",
+		Value: testUserAuth,
+	})
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_RollbackWorkspaceConfig failed\n    Error: %v", err)
+		return
+	}
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		fmt.Println(string(body))
+		fmt.Println(res.StatusCode)
+		t.Error("\nTestHTTPServer_RollbackWorkspaceConfig failed\n    Error: incorrect response code")
+		return
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Error("\nTestHTTPServer_RollbackWorkspaceConfig failed\n    Error: ", err)
+		return
+	}
+
+	var resJson map[string]interface{}
+	err = json.Unmarshal(resBody, &resJson)
+	if err != nil {
+		t.Error("\nTestHTTPServer_RollbackWorkspaceConfig failed\n    Error: ", err)
+		return
+	}
+
+	expectedJson := map[string]interface{}{}
+	if !reflect.DeepEqual(resJson, expectedJson) {
+		t.Error("\nTestHTTPServer_RollbackWorkspaceConfig failed\n    Error: response JSON does not match expected JSON")
+		return
+	}
+
+	t.Log("\nTestHTTPServer_RollbackWorkspaceConfig succeeded")
+}
+
+func TestHTTPServer_SetPostWorkspaceConfigRevision(t *testing.T) {
+	body := bytes.NewReader([]byte(`{"project_id":"1","revision":null,"test":true}`))
+	req, err := http.NewRequest("POST", "http://localhost:1818/api/project/workspaceConfigRevision", body)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_SetPostWorkspaceConfigRevision failed\n    Error: %v", err)
+		return
+	}
+
+	req.AddCookie(&http.Cookie{
+		Name:  "gigoAuthToken",
+		Value: testUserAuth,
+	})
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Errorf("\nTestHTTPServer_SetPostWorkspaceConfigRevision failed\n    Error: %v", err)
+		return
+	}
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		fmt.Println(string(body))
+		fmt.Println(res.StatusCode)
+		t.Error("\nTestHTTPServer_SetPostWorkspaceConfigRevision failed\n    Error: incorrect response code")
+		return
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Error("\nTestHTTPServer_SetPostWorkspaceConfigRevision failed\n    Error: ", err)
+		return
+	}
+
+	var resJson map[string]interface{}
+	err = json.Unmarshal(resBody, &resJson)
+	if err != nil {
+		t.Error("\nTestHTTPServer_SetPostWorkspaceConfigRevision failed\n    Error: ", err)
+		return
+	}
+
+	expectedJson := map[string]interface{}{}
+	if !reflect.DeepEqual(resJson, expectedJson) {
+		t.Error("\nTestHTTPServer_SetPostWorkspaceConfigRevision failed\n    Error: response JSON does not match expected JSON")
+		return
+	}
+
+	t.Log("\nTestHTTPServer_SetPostWorkspaceConfigRevision succeeded")
+}
@@ -0,0 +1,61 @@
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+
+	ti "github.com/gage-technologies/gigo-lib/db"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/mysql"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// MigrationsTable is the table used to track the schema version of the
+// gigo-core owned migrations. It is kept separate from the table used by
+// gigo-lib so that both sets of migrations can be versioned independently.
+const MigrationsTable = "gigo_core_schema_migrations"
+
+//go:embed sql/*.sql
+var migrations embed.FS
+
+// Run applies all pending gigo-core migrations to the database. The base
+// schema is owned by gigo-lib and is created by ti.CreateDatabase so Run
+// must be called after the database has been created.
+func Run(db *ti.Database) error {
+	// open a dedicated connection with multi statement support so that
+	// migrations can contain more than one statement
+	dataSourceName := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true&multiStatements=true&tidb_skip_isolation_level_check=1",
+		db.User, db.Pass, db.Host, db.Port, db.DBName)
+	conn, err := sql.Open("mysql", dataSourceName)
+	if err != nil {
+		return fmt.Errorf("failed to open migration connection: %v", err)
+	}
+	defer conn.Close()
+
+	source, err := iofs.New(migrations, "sql")
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %v", err)
+	}
+
+	driver, err := mysql.WithInstance(conn, &mysql.Config{
+		MigrationsTable: MigrationsTable,
+		DatabaseName:    db.DBName,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create migration driver: %v", err)
+	}
+
+	migrator, err := migrate.NewWithInstance("iofs", source, "mysql", driver)
+	if err != nil {
+		return fmt.Errorf("failed to create migrator: %v", err)
+	}
+
+	err = migrator.Up()
+	if err != nil && err != migrate.ErrNoChange {
+		return fmt.Errorf("failed to run migrations: %v", err)
+	}
+
+	return nil
+}
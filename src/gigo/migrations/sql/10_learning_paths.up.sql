@@ -0,0 +1,50 @@
+-- Learning paths are ordered collections of posts created by curators or
+-- authors that guide a learner through a sequence of challenges
+CREATE TABLE IF NOT EXISTS learning_path (
+    _id bigint primary key not null,
+    title varchar(120) not null,
+    description text,
+    author_id bigint not null,
+    curated boolean not null default false,
+    published boolean not null default false,
+    created_at datetime not null,
+    updated_at datetime not null,
+    index learning_path_author_idx (author_id),
+    index learning_path_published_idx (published, curated, updated_at)
+);
+
+-- Posts of a learning path in the order that they should be completed
+CREATE TABLE IF NOT EXISTS learning_path_step (
+    path_id bigint not null,
+    post_id bigint not null,
+    position int not null,
+    primary key (path_id, post_id),
+    index learning_path_step_post_idx (post_id)
+);
+
+-- Steps that must be completed before a step of the path is unlocked
+CREATE TABLE IF NOT EXISTS learning_path_prerequisite (
+    path_id bigint not null,
+    post_id bigint not null,
+    prerequisite_post_id bigint not null,
+    primary key (path_id, post_id, prerequisite_post_id)
+);
+
+-- Users that completed every step of a learning path
+CREATE TABLE IF NOT EXISTS learning_path_completion (
+    path_id bigint not null,
+    user_id bigint not null,
+    completed_at datetime not null,
+    primary key (path_id, user_id),
+    index learning_path_completion_user_idx (user_id)
+);
+
+-- Learning path xp that has been granted to a user. The award is kept when
+-- the path is deleted so completing a recreated path cannot grant xp again.
+CREATE TABLE IF NOT EXISTS learning_path_xp (
+    path_id bigint not null,
+    user_id bigint not null,
+    awarded_at datetime not null,
+    primary key (path_id, user_id),
+    index learning_path_xp_user_idx (user_id)
+);
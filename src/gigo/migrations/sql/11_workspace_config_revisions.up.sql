@@ -0,0 +1,11 @@
+-- Every update of a workspace config inserts a new immutable revision. These
+-- columns record who created each revision, when and why.
+ALTER TABLE workspace_config ADD COLUMN IF NOT EXISTS created_at datetime;
+ALTER TABLE workspace_config ADD COLUMN IF NOT EXISTS created_by bigint;
+ALTER TABLE workspace_config ADD COLUMN IF NOT EXISTS change_note varchar(280);
+ALTER TABLE workspace_config ADD COLUMN IF NOT EXISTS rolled_back_from int;
+
+-- Posts either pin the revision stored in workspace_config_revision or follow
+-- the latest revision of their workspace config. Existing posts stay pinned.
+ALTER TABLE post ADD COLUMN IF NOT EXISTS workspace_config_follow_latest boolean not null default false;
+CREATE INDEX IF NOT EXISTS post_workspace_config_idx ON post (workspace_config);
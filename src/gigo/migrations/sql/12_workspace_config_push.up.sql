@@ -0,0 +1,4 @@
+-- Running workspaces are flagged when a push changes the workspace config of
+-- their repo so that the owner can restart them with the new config. The flag
+-- is cleared when the workspace is started again.
+ALTER TABLE workspaces ADD COLUMN IF NOT EXISTS config_update_pending boolean not null default false;
@@ -0,0 +1,7 @@
+-- Discussion bodies are rendered from markdown to sanitized html when they are
+-- written so that readers never render user supplied markup. The html is kept
+-- per revision next to the markdown source that it was rendered from.
+ALTER TABLE discussion ADD COLUMN IF NOT EXISTS body_html mediumtext;
+ALTER TABLE comment ADD COLUMN IF NOT EXISTS body_html mediumtext;
+ALTER TABLE thread_comment ADD COLUMN IF NOT EXISTS body_html mediumtext;
+ALTER TABLE thread_reply ADD COLUMN IF NOT EXISTS body_html mediumtext;
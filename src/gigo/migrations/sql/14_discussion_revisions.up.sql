@@ -0,0 +1,15 @@
+-- Every edit of a discussion, comment, thread comment or thread reply inserts
+-- a new revision. These columns record who made each edit and when. Deleted
+-- revisions are kept for the moderators but hidden from everyone else.
+ALTER TABLE discussion ADD COLUMN IF NOT EXISTS edited_at datetime;
+ALTER TABLE discussion ADD COLUMN IF NOT EXISTS edited_by bigint;
+ALTER TABLE discussion ADD COLUMN IF NOT EXISTS revision_deleted boolean not null default false;
+ALTER TABLE comment ADD COLUMN IF NOT EXISTS edited_at datetime;
+ALTER TABLE comment ADD COLUMN IF NOT EXISTS edited_by bigint;
+ALTER TABLE comment ADD COLUMN IF NOT EXISTS revision_deleted boolean not null default false;
+ALTER TABLE thread_comment ADD COLUMN IF NOT EXISTS edited_at datetime;
+ALTER TABLE thread_comment ADD COLUMN IF NOT EXISTS edited_by bigint;
+ALTER TABLE thread_comment ADD COLUMN IF NOT EXISTS revision_deleted boolean not null default false;
+ALTER TABLE thread_reply ADD COLUMN IF NOT EXISTS edited_at datetime;
+ALTER TABLE thread_reply ADD COLUMN IF NOT EXISTS edited_by bigint;
+ALTER TABLE thread_reply ADD COLUMN IF NOT EXISTS revision_deleted boolean not null default false;
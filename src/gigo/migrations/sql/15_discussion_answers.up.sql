@@ -0,0 +1,22 @@
+-- The comment that solved the question asked in a discussion. Discussions are
+-- revisioned so the answer is kept outside of the discussion rows to survive
+-- edits of the discussion.
+CREATE TABLE IF NOT EXISTS discussion_answer (
+    discussion_id bigint primary key not null,
+    post_id bigint not null,
+    comment_id bigint not null,
+    accepted_by bigint not null,
+    accepted_at datetime not null,
+    index discussion_answer_post_idx (post_id),
+    unique index discussion_answer_comment_idx (comment_id)
+);
+
+-- Discussions whose accepted answer earned xp. The award is keyed on the
+-- discussion so that moving the accepted answer between comments cannot grant
+-- xp more than once per question.
+CREATE TABLE IF NOT EXISTS discussion_answer_award (
+    discussion_id bigint primary key not null,
+    comment_id bigint not null,
+    user_id bigint not null,
+    awarded_at datetime not null
+);
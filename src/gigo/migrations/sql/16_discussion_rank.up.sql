@@ -0,0 +1,45 @@
+-- Ranking data for discussions, comments, thread comments and thread replies.
+-- The content tables hold one row per revision so the ranking is kept in its
+-- own table with one row per item. The scores are updated when coffee is
+-- added or removed and when replies are created so that every sort mode is
+-- served by an index on the parent of the items.
+CREATE TABLE IF NOT EXISTS discussion_rank (
+    _id bigint primary key not null,
+    content_type int not null,
+    parent_id bigint not null,
+    coffee bigint not null default 0,
+    hot_score double not null default 0,
+    created_at datetime not null,
+    last_activity_at datetime not null,
+    index discussion_rank_top_idx (parent_id, coffee),
+    index discussion_rank_hot_idx (parent_id, hot_score),
+    index discussion_rank_active_idx (parent_id, last_activity_at),
+    index discussion_rank_new_idx (parent_id, created_at)
+);
+
+-- Rank the existing content. The hot score uses the same formula as
+-- discussionHotScore in gigo core.
+INSERT IGNORE INTO discussion_rank (_id, content_type, parent_id, coffee, hot_score, created_at, last_activity_at)
+SELECT d._id, 0, d.post_id, d.coffee, log10(greatest(d.coffee, 1)) + (unix_timestamp(d.created_at) - 1672531200) / 45000, d.created_at, d.created_at
+FROM discussion d INNER JOIN (SELECT _id, max(revision) AS revision FROM discussion GROUP BY _id) t ON d._id = t._id AND d.revision = t.revision;
+
+INSERT IGNORE INTO discussion_rank (_id, content_type, parent_id, coffee, hot_score, created_at, last_activity_at)
+SELECT c._id, 1, c.discussion_id, c.coffee, log10(greatest(c.coffee, 1)) + (unix_timestamp(c.created_at) - 1672531200) / 45000, c.created_at, c.created_at
+FROM comment c INNER JOIN (SELECT _id, max(revision) AS revision FROM comment GROUP BY _id) t ON c._id = t._id AND c.revision = t.revision;
+
+INSERT IGNORE INTO discussion_rank (_id, content_type, parent_id, coffee, hot_score, created_at, last_activity_at)
+SELECT c._id, 2, c.comment_id, c.coffee, log10(greatest(c.coffee, 1)) + (unix_timestamp(c.created_at) - 1672531200) / 45000, c.created_at, c.created_at
+FROM thread_comment c INNER JOIN (SELECT _id, max(revision) AS revision FROM thread_comment GROUP BY _id) t ON c._id = t._id AND c.revision = t.revision;
+
+INSERT IGNORE INTO discussion_rank (_id, content_type, parent_id, coffee, hot_score, created_at, last_activity_at)
+SELECT c._id, 3, c.thread_comment_id, c.coffee, log10(greatest(c.coffee, 1)) + (unix_timestamp(c.created_at) - 1672531200) / 45000, c.created_at, c.created_at
+FROM thread_reply c INNER JOIN (SELECT _id, max(revision) AS revision FROM thread_reply GROUP BY _id) t ON c._id = t._id AND c.revision = t.revision;
+
+-- The last activity of an item is the newest reply anywhere below it. Thread
+-- comments are updated first so that their activity carries up the tree.
+UPDATE discussion_rank r INNER JOIN (SELECT parent_id, max(last_activity_at) AS last_activity_at FROM discussion_rank WHERE content_type = 3 GROUP BY parent_id) c ON r._id = c.parent_id
+SET r.last_activity_at = greatest(r.last_activity_at, c.last_activity_at);
+UPDATE discussion_rank r INNER JOIN (SELECT parent_id, max(last_activity_at) AS last_activity_at FROM discussion_rank WHERE content_type = 2 GROUP BY parent_id) c ON r._id = c.parent_id
+SET r.last_activity_at = greatest(r.last_activity_at, c.last_activity_at);
+UPDATE discussion_rank r INNER JOIN (SELECT parent_id, max(last_activity_at) AS last_activity_at FROM discussion_rank WHERE content_type = 1 GROUP BY parent_id) c ON r._id = c.parent_id
+SET r.last_activity_at = greatest(r.last_activity_at, c.last_activity_at);
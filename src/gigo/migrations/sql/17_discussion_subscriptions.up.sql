@@ -0,0 +1,29 @@
+-- Users that are notified when new comments and replies are posted in a
+-- discussion. Authors are subscribed automatically when they create or reply
+-- to a discussion. Unwatching keeps the row with watching set to false so
+-- that replying again does not subscribe the user a second time.
+CREATE TABLE IF NOT EXISTS discussion_subscription (
+    discussion_id bigint not null,
+    user_id bigint not null,
+    watching boolean not null default true,
+    created_at datetime not null,
+    primary key (discussion_id, user_id),
+    index discussion_subscription_user_idx (user_id)
+);
+
+-- Subscribe the authors of existing discussions, comments, thread comments
+-- and thread replies to the discussions they took part in.
+INSERT IGNORE INTO discussion_subscription (discussion_id, user_id, watching, created_at)
+SELECT _id, author_id, true, min(created_at) FROM discussion GROUP BY _id, author_id;
+
+INSERT IGNORE INTO discussion_subscription (discussion_id, user_id, watching, created_at)
+SELECT discussion_id, author_id, true, min(created_at) FROM comment GROUP BY discussion_id, author_id;
+
+INSERT IGNORE INTO discussion_subscription (discussion_id, user_id, watching, created_at)
+SELECT c.discussion_id, t.author_id, true, min(t.created_at) FROM thread_comment t
+JOIN comment c ON c._id = t.comment_id GROUP BY c.discussion_id, t.author_id;
+
+INSERT IGNORE INTO discussion_subscription (discussion_id, user_id, watching, created_at)
+SELECT c.discussion_id, r.author_id, true, min(r.created_at) FROM thread_reply r
+JOIN thread_comment t ON t._id = r.thread_comment_id
+JOIN comment c ON c._id = t.comment_id GROUP BY c.discussion_id, r.author_id;
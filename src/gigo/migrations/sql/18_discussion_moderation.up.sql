@@ -0,0 +1,23 @@
+-- Discussions, comments, thread comments and thread replies deleted by their
+-- author are kept so that the replies below them stay in place. The flag is
+-- set on every revision of the content and readers are shown a placeholder.
+ALTER TABLE discussion ADD COLUMN IF NOT EXISTS deleted boolean not null default false;
+ALTER TABLE discussion ADD COLUMN IF NOT EXISTS deleted_at datetime;
+ALTER TABLE discussion ADD COLUMN IF NOT EXISTS deleted_by bigint;
+ALTER TABLE comment ADD COLUMN IF NOT EXISTS deleted boolean not null default false;
+ALTER TABLE comment ADD COLUMN IF NOT EXISTS deleted_at datetime;
+ALTER TABLE comment ADD COLUMN IF NOT EXISTS deleted_by bigint;
+ALTER TABLE thread_comment ADD COLUMN IF NOT EXISTS deleted boolean not null default false;
+ALTER TABLE thread_comment ADD COLUMN IF NOT EXISTS deleted_at datetime;
+ALTER TABLE thread_comment ADD COLUMN IF NOT EXISTS deleted_by bigint;
+ALTER TABLE thread_reply ADD COLUMN IF NOT EXISTS deleted boolean not null default false;
+ALTER TABLE thread_reply ADD COLUMN IF NOT EXISTS deleted_at datetime;
+ALTER TABLE thread_reply ADD COLUMN IF NOT EXISTS deleted_by bigint;
+
+-- Locked discussions do not accept new comments, thread comments or thread
+-- replies. Discussions are locked and unlocked by the moderators.
+CREATE TABLE IF NOT EXISTS discussion_lock (
+    discussion_id bigint primary key not null,
+    locked_by bigint not null,
+    locked_at datetime not null
+);
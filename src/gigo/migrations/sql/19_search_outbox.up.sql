@@ -0,0 +1,29 @@
+-- Searchable documents that changed and need to be synced to meilisearch. Rows
+-- are written in the same transaction as the change so that the search
+-- engine cannot miss a committed change. The follower drains the table by
+-- loading the current state of each document, so repeated changes to the same
+-- document collapse into a single row and bump its version.
+CREATE TABLE IF NOT EXISTS search_outbox (
+    search_index varchar(64) not null,
+    document_id bigint not null,
+    version bigint not null default 1,
+    attempts int not null default 0,
+    last_error text,
+    claim_id bigint,
+    available_at datetime not null,
+    created_at datetime not null,
+    primary key (search_index, document_id),
+    index search_outbox_available_idx (available_at),
+    index search_outbox_claim_idx (claim_id)
+);
+
+-- Progress of the reindex of each search index. An interrupted reindex
+-- resumes after the last document id that was indexed.
+CREATE TABLE IF NOT EXISTS search_reindex_checkpoint (
+    search_index varchar(64) primary key not null,
+    last_id bigint not null,
+    indexed bigint not null default 0,
+    started_at datetime not null,
+    updated_at datetime not null,
+    completed_at datetime
+);
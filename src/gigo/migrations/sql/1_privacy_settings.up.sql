@@ -0,0 +1,2 @@
+-- Add privacy settings column to users table
+ALTER TABLE users ADD COLUMN IF NOT EXISTS privacy_settings json;
@@ -0,0 +1,41 @@
+-- Achievement rules are declarative so that new badges can be added by
+-- inserting a row instead of changing code. Each rule is evaluated when the
+-- named event occurs and is awarded once the metric reaches the threshold.
+CREATE TABLE IF NOT EXISTS achievement (
+    _id bigint primary key not null,
+    name varchar(64) not null,
+    title varchar(255) not null,
+    description varchar(1024) not null,
+    rarity int not null,
+    event varchar(64) not null,
+    metric varchar(64) not null,
+    threshold bigint not null,
+    enabled boolean not null default true,
+    created_at datetime not null,
+    unique key achievement_name_uq (name),
+    index achievement_event_idx (event, enabled)
+);
+
+CREATE TABLE IF NOT EXISTS user_achievement (
+    user_id bigint not null,
+    achievement_id bigint not null,
+    awarded_at datetime not null,
+    primary key (user_id, achievement_id),
+    index user_achievement_awarded_idx (user_id, awarded_at)
+);
+
+INSERT IGNORE INTO achievement(_id, name, title, description, rarity, event, metric, threshold, enabled, created_at) VALUES
+    (1, 'first_attempt', 'First Steps', 'Start your first attempt on a challenge', 0, 'attempt', 'count', 1, true, now()),
+    (2, 'first_success', 'First Blood', 'Complete your first attempt successfully', 0, 'successful', 'count', 1, true, now()),
+    (3, 'ten_successes', 'Problem Solver', 'Complete 10 attempts successfully', 1, 'successful', 'count', 10, true, now()),
+    (4, 'fifty_successes', 'Seasoned Hacker', 'Complete 50 attempts successfully', 2, 'successful', 'count', 50, true, now()),
+    (5, 'first_challenge', 'Architect', 'Publish your first challenge', 0, 'create', 'count', 1, true, now()),
+    (6, 'first_tutorial', 'Teacher', 'Publish your first interactive tutorial', 1, 'create_tutorial', 'count', 1, true, now()),
+    (7, 'tutorial_complete', 'Student', 'Complete a tutorial', 0, 'tutorial', 'count', 1, true, now()),
+    (8, 'streak_7', 'On Fire', 'Reach a 7 day streak', 1, 'streak', 'longest_streak', 7, true, now()),
+    (9, 'streak_30', 'Unstoppable', 'Reach a 30 day streak', 2, 'streak', 'longest_streak', 30, true, now()),
+    (10, 'streak_100', 'Centurion', 'Reach a 100 day streak', 3, 'streak', 'longest_streak', 100, true, now()),
+    (11, 'first_nemesis_victory', 'Rival Slayer', 'Win your first nemesis war', 1, 'nemesis_victory', 'count', 1, true, now()),
+    (12, 'ten_nemesis_victories', 'Warlord', 'Win 10 nemesis wars', 2, 'nemesis_victory', 'count', 10, true, now()),
+    (13, 'five_referrals', 'Recruiter', 'Refer 5 friends to GIGO', 2, 'refer', 'count', 5, true, now()),
+    (14, 'hundred_attempted', 'Crowd Favorite', 'Have your challenges attempted 100 times', 3, 'challenge_is_attempted', 'count', 100, true, now());
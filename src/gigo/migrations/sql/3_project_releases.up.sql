@@ -0,0 +1,23 @@
+-- Releases pin a project to an explicit commit so that authors can ship fixes
+-- without silently changing what existing attempts were built against. Each
+-- release is also tagged as v<version> in the project repository.
+CREATE TABLE IF NOT EXISTS post_release (
+    _id bigint primary key not null,
+    post_id bigint not null,
+    version int not null,
+    commit varchar(64) not null,
+    title varchar(255) not null,
+    notes text not null,
+    author_id bigint not null,
+    created_at datetime not null,
+    unique key post_release_version_uq (post_id, version)
+);
+
+-- Attempts record the release they were started from or last upgraded to
+ALTER TABLE attempt ADD COLUMN IF NOT EXISTS release_id bigint;
+ALTER TABLE attempt ADD COLUMN IF NOT EXISTS release_version int;
+ALTER TABLE attempt ADD COLUMN IF NOT EXISTS release_commit varchar(64);
+
+-- Attempts record the project commit they were forked from so that they can
+-- be compared to the project without searching their history
+ALTER TABLE attempt ADD COLUMN IF NOT EXISTS fork_commit varchar(64);
@@ -0,0 +1,6 @@
+-- Forked projects keep a link to the project (and optionally the attempt)
+-- and commit that they were copied from so that attribution is preserved
+ALTER TABLE post ADD COLUMN IF NOT EXISTS forked_from_post bigint;
+ALTER TABLE post ADD COLUMN IF NOT EXISTS forked_from_attempt bigint;
+ALTER TABLE post ADD COLUMN IF NOT EXISTS forked_from_commit varchar(64);
+CREATE INDEX IF NOT EXISTS post_forked_from_idx ON post (forked_from_post);
@@ -0,0 +1,12 @@
+-- Collaborators are invited to a post with a role and are granted push access
+-- to the post repository once they accept the invite
+CREATE TABLE IF NOT EXISTS post_collaborator (
+    post_id bigint not null,
+    user_id bigint not null,
+    role int not null,
+    accepted boolean not null default false,
+    invited_by bigint not null,
+    created_at datetime not null,
+    primary key (post_id, user_id),
+    index post_collaborator_user_idx (user_id, accepted)
+);
@@ -0,0 +1,31 @@
+-- Gradings record each run of a challenge's declared tests against an attempt.
+-- The tests run in a dedicated workspace that is destroyed once the run ends.
+CREATE TABLE IF NOT EXISTS attempt_grading (
+    _id bigint primary key not null,
+    attempt_id bigint not null,
+    post_id bigint not null,
+    author_id bigint not null,
+    workspace_id bigint not null,
+    commit varchar(64) not null,
+    command text not null,
+    status int not null,
+    passed int not null default 0,
+    failed int not null default 0,
+    exit_code int,
+    error text,
+    created_at datetime not null,
+    finished_at datetime,
+    index attempt_grading_attempt_idx (attempt_id, created_at),
+    index attempt_grading_workspace_idx (workspace_id)
+);
+
+-- Individual test results parsed from the output of a grading run
+CREATE TABLE IF NOT EXISTS attempt_grading_result (
+    grading_id bigint not null,
+    idx int not null,
+    name varchar(512) not null,
+    passed boolean not null,
+    duration_ms bigint,
+    output text,
+    primary key (grading_id, idx)
+);
@@ -0,0 +1,28 @@
+-- Share links let authors hand out several named links to a post. Each link
+-- can expire, be limited to a number of started attempts and be revoked, and
+-- tracks the views and attempts that came through it.
+CREATE TABLE IF NOT EXISTS post_share_link (
+    _id bigint primary key not null,
+    post_id bigint not null,
+    author_id bigint not null,
+    name varchar(255) not null,
+    token binary(16) not null,
+    expires_at datetime,
+    max_uses int,
+    views bigint not null default 0,
+    attempts bigint not null default 0,
+    revoked boolean not null default false,
+    created_at datetime not null,
+    revoked_at datetime,
+    last_used_at datetime,
+    unique key post_share_link_token_uq (token),
+    index post_share_link_post_idx (post_id, created_at)
+);
+
+-- Carry the single share hash that posts used to have over as a default link
+-- so that links that were already handed out keep working
+INSERT IGNORE INTO post_share_link (_id, post_id, author_id, name, token, created_at)
+SELECT _id, _id, author_id, 'Default', share_hash, created_at FROM post WHERE share_hash IS NOT NULL;
+
+-- Attempts record the share link they were started through
+ALTER TABLE attempt ADD COLUMN IF NOT EXISTS share_link_id bigint;
@@ -0,0 +1,31 @@
+-- Daily rollups of the activity on each post that back the author analytics.
+-- The rollup job recomputes the rows of the current and previous day while
+-- share link views are counted as they happen.
+CREATE TABLE IF NOT EXISTS post_analytics_daily (
+    post_id bigint not null,
+    day date not null,
+    views bigint not null default 0,
+    search_views bigint not null default 0,
+    share_link_views bigint not null default 0,
+    attempts bigint not null default 0,
+    search_attempts bigint not null default 0,
+    recommendation_attempts bigint not null default 0,
+    share_link_attempts bigint not null default 0,
+    workspaces bigint not null default 0,
+    workspaces_ready bigint not null default 0,
+    successes bigint not null default 0,
+    primary key (post_id, day)
+);
+
+-- Workspaces launched on attempts of a post that never finished initializing
+-- grouped by the init step they stopped at
+CREATE TABLE IF NOT EXISTS post_analytics_init_daily (
+    post_id bigint not null,
+    day date not null,
+    init_state int not null,
+    workspaces bigint not null default 0,
+    primary key (post_id, day, init_state)
+);
+
+-- Attempts record when they were first marked successful
+ALTER TABLE attempt ADD COLUMN IF NOT EXISTS success_at datetime;
@@ -0,0 +1,50 @@
+-- Moderators work the content report queue. Moderators are granted by
+-- inserting the user into this table.
+CREATE TABLE IF NOT EXISTS moderator (
+    user_id bigint primary key not null,
+    granted_by bigint,
+    created_at datetime not null
+);
+
+-- Reports made by users against a specific piece of content or a user
+CREATE TABLE IF NOT EXISTS content_report (
+    _id bigint primary key not null,
+    reporter_id bigint not null,
+    content_type int not null,
+    content_id bigint not null,
+    content_owner_id bigint not null,
+    reason int not null,
+    details text,
+    status int not null,
+    assignee_id bigint,
+    resolution int,
+    created_at datetime not null,
+    updated_at datetime not null,
+    resolved_at datetime,
+    unique key content_report_reporter_uq (reporter_id, content_type, content_id),
+    index content_report_queue_idx (status, created_at),
+    index content_report_content_idx (content_type, content_id),
+    index content_report_assignee_idx (assignee_id, status)
+);
+
+-- Notes left by moderators while working a report
+CREATE TABLE IF NOT EXISTS content_report_note (
+    _id bigint primary key not null,
+    report_id bigint not null,
+    author_id bigint not null,
+    note text not null,
+    created_at datetime not null,
+    index content_report_note_report_idx (report_id, created_at)
+);
+
+-- Content hidden by a moderator is excluded from feeds, search and the project page
+ALTER TABLE post ADD COLUMN IF NOT EXISTS hidden boolean not null default false;
+ALTER TABLE attempt ADD COLUMN IF NOT EXISTS hidden boolean not null default false;
+ALTER TABLE discussion ADD COLUMN IF NOT EXISTS hidden boolean not null default false;
+ALTER TABLE comment ADD COLUMN IF NOT EXISTS hidden boolean not null default false;
+ALTER TABLE thread_comment ADD COLUMN IF NOT EXISTS hidden boolean not null default false;
+ALTER TABLE thread_reply ADD COLUMN IF NOT EXISTS hidden boolean not null default false;
+ALTER TABLE chat_messages ADD COLUMN IF NOT EXISTS hidden boolean not null default false;
+
+-- Suspended users cannot log in until the suspension ends
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_until datetime;
@@ -0,0 +1,72 @@
+package follower
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"time"
+
+	"gigo-core/gigo/api/external_api/core"
+
+	"github.com/bwmarrin/snowflake"
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/logging"
+	"github.com/gage-technologies/gigo-lib/mq"
+	"github.com/nats-io/nats.go"
+	"github.com/sourcegraph/conc/pool"
+	"go.opentelemetry.io/otel"
+)
+
+// asyncNotifyDiscussionSubscribers
+//
+//	Fans out the notifications for new discussion content to the
+//	users mentioned in it and the users watching the discussion
+func asyncNotifyDiscussionSubscribers(nodeId int64, tidb *ti.Database, js *mq.JetstreamClient, sf *snowflake.Node,
+	msg *nats.Msg, logger logging.Logger) {
+	ctx, span := otel.Tracer("gigo-core").Start(context.TODO(), "async-notify-discussion-subscribers-routine")
+	defer span.End()
+
+	// unmarshall discussion reply message
+	var replyMsg core.DiscussionReplyMsg
+	decoder := gob.NewDecoder(bytes.NewBuffer(msg.Data))
+	err := decoder.Decode(&replyMsg)
+	if err != nil {
+		logger.Errorf("(discussion: %d) failed to decode discussion reply message: %v", nodeId, err)
+		// ack the message since it can never be processed
+		_ = msg.Ack()
+		return
+	}
+
+	err = core.NotifyDiscussionSubscribers(ctx, tidb, js, sf, logger, replyMsg)
+	if err != nil {
+		logger.Errorf("(discussion: %d) failed to notify subscribers of discussion %d: %v", nodeId, replyMsg.DiscussionID, err)
+		// no notifications have been sent so the message can be retried
+		_ = msg.Nak()
+		return
+	}
+
+	// ack the message so it isn't repeated
+	err = msg.Ack()
+	if err != nil {
+		logger.Errorf("(discussion: %d) failed to ack discussion reply message: %v", nodeId, err)
+	}
+}
+
+func DiscussionNotificationOperations(nodeId int64, tidb *ti.Database, js *mq.JetstreamClient, sf *snowflake.Node,
+	workerPool *pool.Pool, logger logging.Logger) {
+	// process discussion reply stream
+	processStream(
+		nodeId,
+		js,
+		workerPool,
+		core.StreamDiscussion,
+		core.SubjectDiscussionReply,
+		"gigo-core-follower-discussion-reply",
+		time.Minute,
+		"discussion",
+		logger,
+		func(msg *nats.Msg) {
+			asyncNotifyDiscussionSubscribers(nodeId, tidb, js, sf, msg, logger)
+		},
+	)
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/go-redis/redis/v8"
 
+	"gigo-core/gigo/api/external_api/core"
 	"gigo-core/gigo/api/ws"
 	"gigo-core/gigo/config"
 	"gigo-core/gigo/streak"
@@ -30,6 +31,9 @@ func Routine(nodeId int64, cfg *config.Config, tiDB *ti.Database, wsClient *ws.W
 	// create integer to track execution count
 	execCount := 0
 
+	// create the client that syncs the search outbox to the search engine
+	meili := core.NewSearchSyncClient(cfg.MeiliConfig)
+
 	// this function will be executed approximately once every second.
 	// when defining routine logic that will execute on interval
 	// use the execCount variable to offset the execution from the
@@ -59,6 +63,15 @@ func Routine(nodeId int64, cfg *config.Config, tiDB *ti.Database, wsClient *ws.W
 		// XpManagementOperations(ctx, nodeId, tiDB, sf, js, rdb, workerPool, logger)
 		// RemoveExpiredStreakIds(ctx, nodeId, tiDB, js, logger)
 
+		// notify the subscribers of discussions with new replies every second
+		DiscussionNotificationOperations(nodeId, tiDB, js, sf, workerPool, logger)
+
+		// apply new workspace config revisions to the posts using them every second
+		WorkspaceConfigOperations(nodeId, tiDB, js, vcsClient, sf, workerPool, logger)
+
+		// sync the queued search documents every second
+		SearchOutboxOperations(ctx, nodeId, tiDB, meili, sf, logger)
+
 		LaunchUserStatsManagementRoutine(ctx, tiDB, streakEngine, sf, workerPool, js, nodeId, logger)
 		LaunchPremiumWeeklyFreeze(ctx, tiDB, workerPool, js, nodeId, rdb, logger)
 
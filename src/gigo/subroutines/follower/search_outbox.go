@@ -0,0 +1,44 @@
+package follower
+
+import (
+	"context"
+
+	"gigo-core/gigo/api/external_api/core"
+
+	"github.com/bwmarrin/snowflake"
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/logging"
+	"github.com/meilisearch/meilisearch-go"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	// number of search documents synced per batch
+	searchOutboxBatchSize = 100
+	// maximum number of batches drained on each refresh so that a large
+	// backlog does not stall the other follower operations
+	searchOutboxMaxBatches = 10
+)
+
+// SearchOutboxOperations
+//
+//	Drains the search outbox into the search engine so that the changes
+//	committed to the database become searchable
+func SearchOutboxOperations(ctx context.Context, nodeId int64, tidb *ti.Database, meili *meilisearch.Client,
+	sf *snowflake.Node, logger logging.Logger) {
+	ctx, span := otel.Tracer("gigo-core").Start(ctx, "search-outbox-operations-routine")
+	defer span.End()
+
+	for i := 0; i < searchOutboxMaxBatches; i++ {
+		claimed, err := core.DrainSearchOutbox(ctx, tidb, meili, sf, logger, searchOutboxBatchSize)
+		if err != nil {
+			logger.Errorf("(search_outbox: %d) failed to drain search outbox: %v", nodeId, err)
+			return
+		}
+
+		// a partial batch means that the outbox has been drained
+		if claimed < searchOutboxBatchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package follower
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"time"
+
+	"gigo-core/gigo/api/external_api/core"
+
+	"github.com/bwmarrin/snowflake"
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/git"
+	"github.com/gage-technologies/gigo-lib/logging"
+	"github.com/gage-technologies/gigo-lib/mq"
+	"github.com/nats-io/nats.go"
+	"github.com/sourcegraph/conc/pool"
+	"go.opentelemetry.io/otel"
+)
+
+// asyncPropagateWorkspaceConfigRevision
+//
+//	Applies a new workspace config revision to the repos of the
+//	posts following the config and notifies their authors
+func asyncPropagateWorkspaceConfigRevision(nodeId int64, tidb *ti.Database, js *mq.JetstreamClient, vcsClient *git.VCSClient,
+	sf *snowflake.Node, msg *nats.Msg, logger logging.Logger) {
+	ctx, span := otel.Tracer("gigo-core").Start(context.TODO(), "async-propagate-workspace-config-revision-routine")
+	defer span.End()
+
+	// unmarshall workspace config revision message
+	var revisionMsg core.WorkspaceConfigRevisionMsg
+	decoder := gob.NewDecoder(bytes.NewBuffer(msg.Data))
+	err := decoder.Decode(&revisionMsg)
+	if err != nil {
+		logger.Errorf("(workspace-config: %d) failed to decode workspace config revision message: %v", nodeId, err)
+		// ack the message since it can never be processed
+		_ = msg.Ack()
+		return
+	}
+
+	err = core.PropagateWorkspaceConfigRevision(ctx, tidb, js, vcsClient, sf, logger, revisionMsg)
+	if err != nil {
+		logger.Errorf("(workspace-config: %d) failed to propagate revision %d of workspace config %d: %v",
+			nodeId, revisionMsg.Revision, revisionMsg.ConfigID, err)
+		// no posts have been updated so the message can be retried
+		_ = msg.Nak()
+		return
+	}
+
+	// ack the message so it isn't repeated
+	err = msg.Ack()
+	if err != nil {
+		logger.Errorf("(workspace-config: %d) failed to ack workspace config revision message: %v", nodeId, err)
+	}
+}
+
+func WorkspaceConfigOperations(nodeId int64, tidb *ti.Database, js *mq.JetstreamClient, vcsClient *git.VCSClient,
+	sf *snowflake.Node, workerPool *pool.Pool, logger logging.Logger) {
+	// process workspace config revision stream
+	processStream(
+		nodeId,
+		js,
+		workerPool,
+		core.StreamWorkspaceConfig,
+		core.SubjectWorkspaceConfigRevision,
+		"gigo-core-follower-workspace-config-revision",
+		time.Minute,
+		"workspace-config",
+		logger,
+		func(msg *nats.Msg) {
+			asyncPropagateWorkspaceConfigRevision(nodeId, tidb, js, vcsClient, sf, msg, logger)
+		},
+	)
+}
@@ -0,0 +1,23 @@
+package leader
+
+import (
+	"context"
+	"time"
+
+	"gigo-core/gigo/api/external_api/core"
+
+	ti "github.com/gage-technologies/gigo-lib/db"
+	"github.com/gage-technologies/gigo-lib/logging"
+)
+
+// PostAnalyticsRollupOperations rolls up the post analytics of the current day
+// and the previous day so that activity recorded around midnight is not lost
+func PostAnalyticsRollupOperations(ctx context.Context, nodeId int64, tidb *ti.Database, logger logging.Logger) {
+	now := time.Now()
+	for _, day := range []time.Time{now.AddDate(0, 0, -1), now} {
+		err := core.RollupPostAnalytics(ctx, tidb, day)
+		if err != nil {
+			logger.Errorf("(leader: %d) failed to roll up post analytics: %v", nodeId, err)
+		}
+	}
+}
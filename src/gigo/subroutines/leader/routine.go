@@ -90,6 +90,8 @@ func Routine(nodeId int64, cfg *config.Config, tiDB *ti.Database, js *mq.Jetstre
 
 	// create time variable to track the last execution of the user stats routine
 	lastUserStatsExec := time.Unix(0, 0)
+	// create time variable to track the last execution of the analytics rollup
+	lastAnalyticsRollupExec := time.Unix(0, 0)
 	// lastNemesisExec := time.Unix(0, 0)
 
 	// this function will be executed approximately once every second.
@@ -146,6 +148,16 @@ func Routine(nodeId int64, cfg *config.Config, tiDB *ti.Database, js *mq.Jetstre
 			publishUserFreePremium(nodeId, js, logger)
 		}
 
+		// roll up post analytics on every 10m interval
+		if timeNow.Minute()%10 == 0 && timeNow.Second() < 10 &&
+			timeNowTrimmedSec.Unix() != lastAnalyticsRollupExec.Unix() {
+			// update last execution time
+			lastAnalyticsRollupExec = timeNowTrimmedSec
+			logger.Infof("(leader: %d) executing post analytics rollup", nodeId)
+
+			PostAnalyticsRollupOperations(ctx, nodeId, tiDB, logger)
+		}
+
 		// timeNow = time.Now()
 		// timeNowTrimmedSec = time.Date(timeNow.Year(), timeNow.Month(), timeNow.Day(), timeNow.Hour(), timeNow.Minute(), 0, 0, timeNow.Location())
 		// if timeNow.Minute()%10 == 0 && timeNow.Second() < 10 &&
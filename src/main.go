@@ -20,8 +20,10 @@ import (
 
 	"gigo-core/coder/api"
 	"gigo-core/gigo/api/external_api"
+	"gigo-core/gigo/api/external_api/core"
 	"gigo-core/gigo/api/ws"
 	"gigo-core/gigo/config"
+	"gigo-core/gigo/migrations"
 	"gigo-core/gigo/subroutines/follower"
 	"gigo-core/gigo/subroutines/leader"
 	"gigo-core/gigo/utils"
@@ -232,6 +234,14 @@ func main() {
 		log.Fatal("failed to create titanium database: ", err)
 	}
 
+	// apply the gigo-core migrations on top of the base schema
+	err = migrations.Run(tiDB)
+	if err != nil {
+		rootLogger.Errorf("failed to run gigo-core migrations: %v", err)
+		rootLogger.Flush()
+		log.Fatal("failed to run gigo-core migrations: ", err)
+	}
+
 	fmt.Println("Creating meili client")
 	meili, err := search.CreateMeiliSearchEngine(cfg.MeiliConfig)
 	if err != nil {
@@ -320,6 +330,17 @@ func main() {
 		log.Fatal(fmt.Sprintf("failed to create jetstream client, %v", err))
 	}
 
+	// initialize the streams owned by gigo-core
+	err = core.InitDiscussionStream(js)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("failed to initialize discussion stream, %v", err))
+	}
+
+	err = core.InitWorkspaceConfigStream(js)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("failed to initialize workspace config stream, %v", err))
+	}
+
 	fmt.Println("Creating gitea client")
 	vcsClient, err := git.CreateVCSClient(cfg.GiteaConfig.HostUrl, cfg.GiteaConfig.Username, cfg.GiteaConfig.Password, false)
 	if err != nil {
@@ -452,8 +473,8 @@ func main() {
 	fmt.Printf("Creating HTTP server @ %s:%s\n", cfg.HTTPServerConfig.Address, cfg.HTTPServerConfig.Port)
 	// create HTTP server
 	externalServer, err := external_api.CreateHTTPServer(cfg.HTTPServerConfig, cfg.OTELConfig.ServiceName, tiDB, meili, rdb, snowflakeNode,
-		vcsClient, storageEngine, wsClient, js, wsStatusUpdater, parsedAccessUrl, passwordFilter, cfg.GithubSecret,
-		cfg.HTTPServerConfig.ForceCdnAccess, cfg.HTTPServerConfig.CdnAccessKey, cfg.MasterKey, cfg.CaptchaSecret, whitelistedIpRanges, httpLogger)
+		vcsClient, cfg.GiteaConfig, storageEngine, wsClient, js, wsStatusUpdater, parsedAccessUrl, passwordFilter, cfg.GithubSecret,
+		cfg.HTTPServerConfig.ForceCdnAccess, cfg.HTTPServerConfig.CdnAccessKey, cfg.MasterKey, cfg.CaptchaSecret, cfg.RegistryCaches, whitelistedIpRanges, httpLogger)
 	if err != nil {
 		log.Fatal(fmt.Sprintf("failed to create http server, %v", err))
 	}
@@ -1,15 +1,20 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	"gigo-core/gigo/api/external_api/core"
+
 	"github.com/gage-technologies/gigo-lib/config"
 	ti "github.com/gage-technologies/gigo-lib/db"
-	"github.com/gage-technologies/gigo-lib/db/models"
 	"github.com/gage-technologies/gigo-lib/search"
 	"gopkg.in/yaml.v3"
-	"log"
-	"os"
 )
 
 type Config struct {
@@ -17,239 +22,14 @@ type Config struct {
 	MeiliConfig    config.MeiliConfig    `yaml:"meili"`
 }
 
-func indexPosts(db *ti.Database, meili *search.MeiliSearchEngine) {
-	res, err := db.DB.Query("select * from post")
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	buf := make([]interface{}, 0)
-	for res.Next() {
-		post, err := models.PostFromSQLNative(db, res)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		buf = append(buf, post)
-
-		if len(buf) >= 1000 {
-			err = meili.AddDocuments("posts", buf...)
-			if err != nil {
-				log.Fatal(err)
-			}
-
-			buf = make([]interface{}, 0)
-		}
-	}
-
-	if len(buf) > 0 {
-		err = meili.AddDocuments("posts", buf...)
-		if err != nil {
-			log.Fatal(err)
-		}
-	}
-}
-
-func indexComments(db *ti.Database, meili *search.MeiliSearchEngine) {
-	res, err := db.DB.Query("select * from comment")
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	buf := make([]interface{}, 0)
-	for res.Next() {
-		comment, err := models.CommentFromSQLNative(db, res)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		buf = append(buf, comment)
-
-		if len(buf) >= 1000 {
-			err = meili.AddDocuments("comment", buf...)
-			if err != nil {
-				log.Fatal(err)
-			}
-
-			buf = make([]interface{}, 0)
-		}
-	}
-
-	if len(buf) > 0 {
-		err = meili.AddDocuments("comment", buf...)
-		if err != nil {
-			log.Fatal(err)
-		}
-	}
-}
-
-func indexUsers(db *ti.Database, meili *search.MeiliSearchEngine) {
-	res, err := db.DB.Query("select * from users")
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	buf := make([]interface{}, 0)
-	for res.Next() {
-		user, err := models.UserFromSQLNative(db, res)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		buf = append(buf, user.ToSearch())
-
-		if len(buf) >= 1000 {
-			err = meili.AddDocuments("users", buf...)
-			if err != nil {
-				log.Fatal(err)
-			}
-
-			buf = make([]interface{}, 0)
-		}
-	}
-
-	if len(buf) > 0 {
-		err = meili.AddDocuments("users", buf...)
-		if err != nil {
-			log.Fatal(err)
-		}
-	}
-}
-
-func indexTags(db *ti.Database, meili *search.MeiliSearchEngine) {
-	res, err := db.DB.Query("select * from tag")
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	buf := make([]interface{}, 0)
-	for res.Next() {
-		tag, err := models.TagFromSQLNative(res)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		buf = append(buf, tag.ToSearch())
-
-		if len(buf) >= 1000 {
-			err = meili.AddDocuments("tags", buf...)
-			if err != nil {
-				log.Fatal(err)
-			}
-
-			buf = make([]interface{}, 0)
-		}
-	}
-
-	if len(buf) > 0 {
-		err = meili.AddDocuments("tags", buf...)
-		if err != nil {
-			log.Fatal(err)
-		}
-	}
-}
-
-func indexThreadComments(db *ti.Database, meili *search.MeiliSearchEngine) {
-	res, err := db.DB.Query("select * from thread_comment")
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	buf := make([]interface{}, 0)
-	for res.Next() {
-		threadComment, err := models.ThreadCommentFromSQLNative(res)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		buf = append(buf, threadComment)
-
-		if len(buf) >= 1000 {
-			err = meili.AddDocuments("thread_comment", buf...)
-			if err != nil {
-				log.Fatal(err)
-			}
-
-			buf = make([]interface{}, 0)
-		}
-	}
-
-	if len(buf) > 0 {
-		err = meili.AddDocuments("thread_comment", buf...)
-		if err != nil {
-			log.Fatal(err)
-		}
-	}
-}
-
-func indexDiscussions(db *ti.Database, meili *search.MeiliSearchEngine) {
-	res, err := db.DB.Query("select * from discussion")
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	buf := make([]interface{}, 0)
-	for res.Next() {
-		discussion, err := models.DiscussionFromSQLNative(db, res)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		buf = append(buf, discussion)
-
-		if len(buf) >= 1000 {
-			err = meili.AddDocuments("discussion", buf...)
-			if err != nil {
-				log.Fatal(err)
-			}
-
-			buf = make([]interface{}, 0)
-		}
-	}
-
-	if len(buf) > 0 {
-		err = meili.AddDocuments("discussion", buf...)
-		if err != nil {
-			log.Fatal(err)
-		}
-	}
-}
-
-func indexWorkspaceConfigs(db *ti.Database, meili *search.MeiliSearchEngine) {
-	res, err := db.DB.Query("select * from workspace_config")
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	buf := make([]interface{}, 0)
-	for res.Next() {
-		workspaceConfig, err := models.WorkspaceConfigFromSQLNative(db, res)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		buf = append(buf, workspaceConfig)
-
-		if len(buf) >= 1000 {
-			err = meili.AddDocuments("workspace_configs", buf...)
-			if err != nil {
-				log.Fatal(err)
-			}
-
-			buf = make([]interface{}, 0)
-		}
-	}
-
-	if len(buf) > 0 {
-		err = meili.AddDocuments("workspace_configs", buf...)
-		if err != nil {
-			log.Fatal(err)
-		}
-	}
-}
-
+// update_meili reindexes the search engine from the database. Every index is
+// checkpointed after each batch so an interrupted run resumes where it
+// stopped when it is started again.
 func main() {
 	configPath := flag.String("c", "config.yml", "Path to the configuration file")
+	indexes := flag.String("index", strings.Join(core.SearchIndexes, ","), "Comma separated list of the indexes to reindex")
+	batchSize := flag.Int("batch", 1000, "Number of documents indexed per batch")
+	restart := flag.Bool("restart", false, "Ignore the checkpoints and reindex from the start")
 	flag.Parse()
 
 	cfgBuf, err := os.ReadFile(*configPath)
@@ -269,31 +49,32 @@ func main() {
 		log.Fatal("failed to create titanium database: ", err)
 	}
 
-	meili, err := search.CreateMeiliSearchEngine(cfg.MeiliConfig)
+	// creating the search engine initializes the configured indexes
+	_, err = search.CreateMeiliSearchEngine(cfg.MeiliConfig)
 	if err != nil {
 		log.Fatal(fmt.Sprintf("failed to create meili search engine: %v", err))
 	}
+	meili := core.NewSearchSyncClient(cfg.MeiliConfig)
 
-	fmt.Println("Indexing users...")
-	indexUsers(db, meili)
-
-	fmt.Println("Indexing tags...")
-	indexTags(db, meili)
+	// stop at the end of the current batch on interrupt so the checkpoint is kept
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
 
-	fmt.Println("Indexing thread comments...")
-	indexThreadComments(db, meili)
-
-	fmt.Println("Indexing discussions...")
-	indexDiscussions(db, meili)
-
-	fmt.Println("Indexing workspace configs...")
-	indexWorkspaceConfigs(db, meili)
-
-	fmt.Println("Indexing posts...")
-	indexPosts(db, meili)
+	for _, index := range strings.Split(*indexes, ",") {
+		index = strings.TrimSpace(index)
+		if index == "" {
+			continue
+		}
 
-	fmt.Println("Indexing comments...")
-	indexComments(db, meili)
+		fmt.Printf("Indexing %s...\n", index)
+		indexed, err := core.ReindexSearchIndex(ctx, db, meili, index, *batchSize, *restart, func(lastId int64, indexed int64) {
+			fmt.Printf("    %s: %d documents indexed through id %d\n", index, indexed, lastId)
+		})
+		if err != nil {
+			log.Fatalf("failed to reindex %s after %d documents: %v", index, indexed, err)
+		}
+		fmt.Printf("Indexed %d %s documents\n", indexed, index)
+	}
 
 	fmt.Println("Done!")
 }
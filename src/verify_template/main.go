@@ -0,0 +1,70 @@
+// verify_template checks a workspace template and its container compose
+// definition offline and prints the verification report. The command exits
+// with a non-zero status when the template has errors so that it can be used
+// in CI for template repositories.
+//
+//	go run ./verify_template -configPath config.yml .gigo/workspace.yaml
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"gigo-core/gigo/api/external_api/core"
+	"gigo-core/gigo/config"
+)
+
+func main() {
+	configPath := flag.String("configPath", "", "Path to the gigo configuration file used to load the registry caches")
+	jsonOutput := flag.Bool("json", false, "Print the report as json")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: verify_template [-configPath config.yml] [-json] <workspace.yaml>")
+		os.Exit(2)
+	}
+
+	// registry caches are optional since they only affect the image report
+	var registryCaches []config.RegistryCacheConfig
+	if *configPath != "" {
+		cfg, err := config.LoadConfig(*configPath)
+		if err != nil {
+			log.Fatal("failed to load config ", err)
+		}
+		registryCaches = cfg.RegistryCaches
+	}
+
+	templatePath := flag.Arg(0)
+	content, err := os.ReadFile(templatePath)
+	if err != nil {
+		log.Fatal("failed to read template ", err)
+	}
+
+	report := core.VerifyTemplate(string(content), registryCaches)
+
+	if *jsonOutput {
+		buf, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Fatal("failed to marshal report ", err)
+		}
+		fmt.Println(string(buf))
+	} else {
+		for _, issue := range report.Errors {
+			fmt.Printf("%s:%d:%d: error: %s (%s)\n", templatePath, issue.Line, issue.Column, issue.Message, issue.Path)
+		}
+		for _, issue := range report.Warnings {
+			fmt.Printf("%s:%d:%d: warning: %s (%s)\n", templatePath, issue.Line, issue.Column, issue.Message, issue.Path)
+		}
+		for _, image := range report.Images {
+			fmt.Printf("image %s -> %s\n", image.Reference, image.Resolved)
+		}
+		fmt.Printf("%d error(s), %d warning(s)\n", len(report.Errors), len(report.Warnings))
+	}
+
+	if !report.Valid {
+		os.Exit(1)
+	}
+}